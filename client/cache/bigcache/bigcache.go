@@ -0,0 +1,270 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bigcache for cache provider
+//
+// depend on github.com/allegro/bigcache/v3
+//
+// BigCache keeps the entries in a few big byte slices, so a cache of many GB doesn't add
+// pointers for the GC to scan. The values are gob encoded by the adapter together with
+// their expiration time, because BigCache only evicts the entries older than lifeWindow.
+//
+// Usage:
+// import(
+//
+//	_ "github.com/beego/beego/v2/client/cache/bigcache"
+//	"github.com/beego/beego/v2/client/cache"
+//
+// )
+//
+//	bm, err := cache.NewCache("bigcache", `{"lifeWindow":"1h","hardMaxCacheSize":1024}`)
+package bigcache
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/core/berror"
+)
+
+const (
+	// DefaultLifeWindow is the max lifetime of the entries if lifeWindow isn't set
+	DefaultLifeWindow = time.Hour
+	// DefaultCleanWindow is the interval of removing the entries older than lifeWindow
+	DefaultCleanWindow = time.Minute
+	defaultShards      = 1024
+	// defaultMaxEntriesInWindow and defaultMaxEntrySize are only used to preallocate the shards
+	defaultMaxEntriesInWindow = 1000 * 10 * 60
+	defaultMaxEntrySize       = 500
+)
+
+type bigCacheConfig struct {
+	// Shards is the number of shards, it must be a power of two, 1024 by default
+	Shards int `json:"shards"`
+	// LifeWindow is the max lifetime of any entry, including the ones put with timeout 0 or a longer timeout
+	LifeWindow string `json:"lifeWindow"`
+	// CleanWindow is the interval of removing the entries older than LifeWindow, "0" disables it
+	CleanWindow string `json:"cleanWindow"`
+	// MaxEntriesInWindow and MaxEntrySize are used to preallocate the shards
+	MaxEntriesInWindow int `json:"maxEntriesInWindow"`
+	MaxEntrySize       int `json:"maxEntrySize"`
+	// HardMaxCacheSize is the memory limit in MB, the oldest entries are overridden when it's reached.
+	// 0 means unlimited
+	HardMaxCacheSize int `json:"hardMaxCacheSize"`
+	// Stats enables the hits and misses returned by Cache.Stats
+	Stats bool `json:"stats"`
+}
+
+// Cache is the bigcache adapter
+type Cache struct {
+	cache *bigcache.BigCache
+	// lock makes Incr and Decr atomic
+	lock sync.Mutex
+}
+
+// NewBigCache creates the bigcache adapter
+func NewBigCache() cache.Cache {
+	return &Cache{}
+}
+
+// Get returns the value of key.
+// The error is cache.ErrKeyNotExist if it doesn't exist and cache.ErrKeyExpired if it's expired.
+func (bc *Cache) Get(ctx context.Context, key string) (interface{}, error) {
+	item, err := bc.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return item.Data, nil
+}
+
+func (bc *Cache) get(key string) (*cache.FileCacheItem, error) {
+	data, err := bc.cache.Get(key)
+	if errors.Is(err, bigcache.ErrEntryNotFound) {
+		return nil, cache.ErrKeyNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	item := &cache.FileCacheItem{}
+	if err = cache.GobDecode(data, item); err != nil {
+		return nil, err
+	}
+	if !item.Expired.IsZero() && item.Expired.Before(time.Now()) {
+		_ = bc.cache.Delete(key)
+		return nil, cache.ErrKeyExpired
+	}
+	return item, nil
+}
+
+// GetMulti gets the values of keys, the value of the key which doesn't exist is nil.
+func (bc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	keysErr := make([]string, 0)
+	for i, key := range keys {
+		v, err := bc.Get(ctx, key)
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", key, err.Error()))
+			continue
+		}
+		values[i] = v
+	}
+	if len(keysErr) == 0 {
+		return values, nil
+	}
+	return values, berror.Error(cache.MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// Put puts the value, timeout 0 means it never expires.
+// Note that BigCache still evicts the entry once it's older than lifeWindow.
+func (bc *Cache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	gob.Register(val)
+	item := &cache.FileCacheItem{Data: val, Lastaccess: time.Now()}
+	if timeout > 0 {
+		item.Expired = item.Lastaccess.Add(timeout)
+	}
+	return bc.set(key, item)
+}
+
+func (bc *Cache) set(key string, item *cache.FileCacheItem) error {
+	data, err := cache.GobEncode(item)
+	if err != nil {
+		return err
+	}
+	return bc.cache.Set(key, data)
+}
+
+// Delete deletes the value of key.
+func (bc *Cache) Delete(ctx context.Context, key string) error {
+	err := bc.cache.Delete(key)
+	if err != nil && !errors.Is(err, bigcache.ErrEntryNotFound) {
+		return berror.Wrapf(err, cache.DeleteFailed, "could not delete key: %s", key)
+	}
+	return nil
+}
+
+// Incr increases the counter, supports int,int32,int64,uint,uint32,uint64.
+func (bc *Cache) Incr(ctx context.Context, key string) error {
+	return bc.update(key, cache.IncrValue)
+}
+
+// Decr decreases the counter.
+func (bc *Cache) Decr(ctx context.Context, key string) error {
+	return bc.update(key, cache.DecrValue)
+}
+
+// update replaces the value of key by fn and keeps the expiration time
+func (bc *Cache) update(key string, fn func(interface{}) (interface{}, error)) error {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+	item, err := bc.get(key)
+	if err != nil {
+		return err
+	}
+	if item.Data, err = fn(item.Data); err != nil {
+		return err
+	}
+	item.Lastaccess = time.Now()
+	return bc.set(key, item)
+}
+
+// IsExist checks whether key exists and isn't expired.
+func (bc *Cache) IsExist(ctx context.Context, key string) (bool, error) {
+	_, err := bc.get(key)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, cache.ErrKeyNotExist) || errors.Is(err, cache.ErrKeyExpired) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ClearAll deletes all the entries.
+func (bc *Cache) ClearAll(context.Context) error {
+	return bc.cache.Reset()
+}
+
+// Stats returns the hits, misses and collisions, the per-key hits are counted only if "stats" is true in the config
+func (bc *Cache) Stats() bigcache.Stats {
+	return bc.cache.Stats()
+}
+
+// StartAndGC creates the bigcache, the entries older than lifeWindow are removed every cleanWindow.
+// config is like {"shards":1024,"lifeWindow":"1h","cleanWindow":"1m","hardMaxCacheSize":1024}
+func (bc *Cache) StartAndGC(config string) error {
+	cf := bigCacheConfig{}
+	if strings.TrimSpace(config) != "" {
+		if err := json.Unmarshal([]byte(config), &cf); err != nil {
+			return berror.Wrapf(err, cache.InvalidBigCacheCfg, "invalid config, please check your input: %s", config)
+		}
+	}
+	if cf.Shards < 0 || cf.MaxEntriesInWindow < 0 || cf.MaxEntrySize < 0 || cf.HardMaxCacheSize < 0 {
+		return berror.Errorf(cache.InvalidBigCacheCfg, "invalid config, the sizes must be positive: %s", config)
+	}
+	lifeWindow, err := parseDuration(cf.LifeWindow, DefaultLifeWindow)
+	if err != nil || lifeWindow <= 0 {
+		return berror.Errorf(cache.InvalidBigCacheCfg, "invalid lifeWindow: %s", cf.LifeWindow)
+	}
+	cleanWindow, err := parseDuration(cf.CleanWindow, DefaultCleanWindow)
+	if err != nil {
+		return berror.Wrapf(err, cache.InvalidBigCacheCfg, "invalid cleanWindow: %s", cf.CleanWindow)
+	}
+	if cf.Shards == 0 {
+		cf.Shards = defaultShards
+	}
+	if cf.MaxEntriesInWindow == 0 {
+		cf.MaxEntriesInWindow = defaultMaxEntriesInWindow
+	}
+	if cf.MaxEntrySize == 0 {
+		cf.MaxEntrySize = defaultMaxEntrySize
+	}
+
+	c, err := bigcache.New(context.Background(), bigcache.Config{
+		Shards:             cf.Shards,
+		LifeWindow:         lifeWindow,
+		CleanWindow:        cleanWindow,
+		MaxEntriesInWindow: cf.MaxEntriesInWindow,
+		MaxEntrySize:       cf.MaxEntrySize,
+		HardMaxCacheSize:   cf.HardMaxCacheSize,
+		StatsEnabled:       cf.Stats,
+	})
+	if err != nil {
+		return berror.Wrapf(err, cache.InvalidBigCacheCfg, "could not create bigcache: %s", config)
+	}
+	if bc.cache != nil {
+		_ = bc.cache.Close()
+	}
+	bc.cache = c
+	return nil
+}
+
+func parseDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func init() {
+	cache.Register("bigcache", NewBigCache)
+}
@@ -16,6 +16,8 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math"
 	"os"
 	"strings"
@@ -24,6 +26,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
 )
 
 func TestCacheIncr(t *testing.T) {
@@ -111,6 +115,224 @@ func TestCache(t *testing.T) {
 	assert.True(t, strings.Contains(err.Error(), "key isn't exist"))
 }
 
+func TestMemoryCacheIncrBy(t *testing.T) {
+	bm, err := NewCache("memory", `{"interval":1}`)
+	assert.Nil(t, err)
+	ctx := context.Background()
+	cc := bm.(CounterCache)
+
+	n, err := cc.IncrBy(ctx, "rate", 1, WithInitial(10), WithCounterTTL(time.Second), WithCeiling(12))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), n)
+	n, err = cc.IncrBy(ctx, "rate", 1, WithCeiling(12))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), n)
+	n, err = cc.IncrBy(ctx, "rate", 1, WithCeiling(12))
+	assert.True(t, errors.Is(err, ErrCounterOutOfBounds))
+	assert.Equal(t, int64(12), n)
+	// the TTL is set by the first IncrBy
+	time.Sleep(1500 * time.Millisecond)
+	res, _ := cc.IsExist(ctx, "rate")
+	assert.False(t, res)
+
+	n, err = cc.IncrBy(ctx, "stock", -1, WithInitial(1), WithFloor(0))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+	_, err = cc.IncrBy(ctx, "stock", -1, WithFloor(0))
+	assert.True(t, errors.Is(err, ErrCounterOutOfBounds))
+	_, err = cc.IncrBy(ctx, "missing", -1, WithFloor(0))
+	assert.True(t, errors.Is(err, ErrCounterOutOfBounds))
+	res, _ = cc.IsExist(ctx, "missing")
+	assert.False(t, res)
+
+	// the type of the existing counter is kept
+	assert.Nil(t, cc.Put(ctx, "count", 1, time.Minute))
+	n, err = cc.IncrBy(ctx, "count", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), n)
+	v, _ := cc.Get(ctx, "count")
+	assert.Equal(t, 11, v)
+	assert.Nil(t, cc.Put(ctx, "name", "astaxie", time.Minute))
+	_, err = cc.IncrBy(ctx, "name", 1)
+	assert.Equal(t, ErrNotIntegerType, err)
+}
+
+func TestMemoryCacheDeleteByPrefix(t *testing.T) {
+	bm, err := NewCache("memory", `{"interval":1,"prefixIndex":true}`)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	for _, key := range []string{"user:4", "user:42", "user:42:profile", "user:42:orders", "user:43:orders"} {
+		assert.Nil(t, bm.Put(ctx, key, key, time.Minute))
+	}
+	assert.Nil(t, DeleteByPrefix(ctx, bm, "user:42"))
+	for key, exist := range map[string]bool{
+		"user:4": true, "user:42": false, "user:42:profile": false, "user:42:orders": false, "user:43:orders": true,
+	} {
+		res, _ := bm.IsExist(ctx, key)
+		assert.Equal(t, exist, res, key)
+	}
+	assert.Nil(t, DeleteByPrefix(ctx, bm, "not_exist"))
+
+	// the expired keys are removed from the index
+	assert.Nil(t, bm.Put(ctx, "short", "short", time.Second))
+	time.Sleep(2500 * time.Millisecond)
+	mc := bm.(*MemoryCache)
+	mc.RLock()
+	assert.Equal(t, []string(nil), mc.keys.withPrefix("short"))
+	mc.RUnlock()
+
+	assert.Nil(t, DeleteByPrefix(ctx, bm, ""))
+	res, _ := bm.IsExist(ctx, "user:4")
+	assert.False(t, res)
+	// the empty nodes of the index are pruned
+	mc.RLock()
+	assert.Equal(t, 0, len(mc.keys.root.children))
+	mc.RUnlock()
+
+	err = DeleteByPrefix(ctx, &FileCache{}, "user")
+	code, _ := berror.FromError(err)
+	assert.Equal(t, DeleteByPrefixNotSupported, code)
+
+	// the keys are scanned without the index
+	bm = NewMemoryCache()
+	for _, key := range []string{"user:4", "user:42", "user:42:profile"} {
+		assert.Nil(t, bm.Put(ctx, key, key, time.Minute))
+	}
+	assert.Nil(t, DeleteByPrefix(ctx, bm, "user:42"))
+	assert.Nil(t, bm.(*MemoryCache).keys)
+	res, _ = bm.IsExist(ctx, "user:4")
+	assert.True(t, res)
+	res, _ = bm.IsExist(ctx, "user:42:profile")
+	assert.False(t, res)
+}
+
+func TestMemoryCacheKeyIndexBytes(t *testing.T) {
+	bm, err := NewCache("memory", fmt.Sprintf(`{"interval":0,"maxBytes":%d,"prefixIndex":true}`, 8*keyNodeSize))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	mc := bm.(*MemoryCache)
+
+	// "ab" costs 3 bytes and 2 nodes
+	assert.Nil(t, bm.Put(ctx, "ab", "a", time.Minute))
+	assert.Equal(t, int64(2*keyNodeSize), mc.keys.bytes())
+	// "ac" shares the node of "a"
+	assert.Nil(t, bm.Put(ctx, "ac", "a", time.Minute))
+	assert.Equal(t, int64(3*keyNodeSize), mc.keys.bytes())
+	// the nodes of "xyzwv" don't fit, so the old keys are evicted
+	assert.Nil(t, bm.Put(ctx, "xyzwv", "a", time.Minute))
+	res, _ := bm.IsExist(ctx, "ab")
+	assert.False(t, res)
+	assert.True(t, mc.usedBytes+mc.keys.bytes() <= 8*keyNodeSize)
+	assert.Nil(t, bm.Delete(ctx, "xyzwv"))
+	assert.Nil(t, bm.Delete(ctx, "ac"))
+	assert.Equal(t, int64(0), mc.keys.bytes())
+}
+
+func TestMemoryCacheTags(t *testing.T) {
+	bm, err := NewCache("memory", `{"interval":1}`)
+	assert.Nil(t, err)
+	ctx := context.Background()
+	tc := bm.(TaggedCache)
+
+	assert.Nil(t, tc.PutWithTags(ctx, "user:42:profile", "profile", time.Minute, "user:42"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:42:orders", "orders", time.Minute, "user:42", "orders"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:43:orders", "orders", time.Minute, "user:43", "orders"))
+	assert.Nil(t, tc.Put(ctx, "astaxie", "author", time.Minute))
+
+	assert.Nil(t, tc.InvalidateTag(ctx, "user:42"))
+	for key, exist := range map[string]bool{
+		"user:42:profile": false, "user:42:orders": false, "user:43:orders": true, "astaxie": true,
+	} {
+		res, _ := tc.IsExist(ctx, key)
+		assert.Equal(t, exist, res, key)
+	}
+
+	// the tags are replaced by Put, and the deleted keys are removed from the index
+	assert.Nil(t, tc.Put(ctx, "user:43:orders", "orders", time.Minute))
+	assert.Nil(t, tc.PutWithTags(ctx, "short", "short", time.Second, "orders"))
+	time.Sleep(2500 * time.Millisecond)
+	mc := bm.(*MemoryCache)
+	mc.RLock()
+	assert.Equal(t, 0, len(mc.tags))
+	mc.RUnlock()
+	assert.Nil(t, tc.InvalidateTag(ctx, "orders"))
+	res, _ := tc.IsExist(ctx, "user:43:orders")
+	assert.True(t, res)
+}
+
+func TestMemoryCacheBounded(t *testing.T) {
+	ctx := context.Background()
+	exists := func(c Cache, keys ...string) []bool {
+		res := make([]bool, 0, len(keys))
+		for _, key := range keys {
+			ok, _ := c.IsExist(ctx, key)
+			res = append(res, ok)
+		}
+		return res
+	}
+
+	lru, err := NewCache("memory", `{"interval":0,"maxEntries":2}`)
+	assert.Nil(t, err)
+	assert.Nil(t, lru.Put(ctx, "a", 1, 0))
+	assert.Nil(t, lru.Put(ctx, "b", 2, 0))
+	_, _ = lru.Get(ctx, "a")
+	assert.Nil(t, lru.Put(ctx, "c", 3, 0))
+	assert.Equal(t, []bool{true, false, true}, exists(lru, "a", "b", "c"))
+
+	lfu, err := NewCache("memory", `{"interval":0,"maxEntries":2,"eviction":"lfu"}`)
+	assert.Nil(t, err)
+	assert.Nil(t, lfu.Put(ctx, "a", 1, 0))
+	assert.Nil(t, lfu.Put(ctx, "b", 2, 0))
+	_, _ = lfu.Get(ctx, "a")
+	_, _ = lfu.Get(ctx, "a")
+	_, _ = lfu.Get(ctx, "b")
+	assert.Nil(t, lfu.Put(ctx, "c", 3, 0))
+	assert.Equal(t, []bool{true, false, true}, exists(lfu, "a", "b", "c"))
+	// c is used less than a
+	assert.Nil(t, lfu.Put(ctx, "d", 4, 0))
+	assert.Equal(t, []bool{true, false, true}, exists(lfu, "a", "c", "d"))
+
+	bytes, err := NewCache("memory", `{"interval":0,"maxBytes":10}`)
+	assert.Nil(t, err)
+	mc := bytes.(*MemoryCache)
+	assert.Nil(t, bytes.Put(ctx, "a", "1234", 0))
+	assert.Nil(t, bytes.Put(ctx, "b", "1234", 0))
+	assert.Equal(t, int64(10), mc.usedBytes)
+	assert.Nil(t, bytes.Put(ctx, "c", "1", 0))
+	assert.Equal(t, []bool{false, true, true}, exists(bytes, "a", "b", "c"))
+	assert.Nil(t, mc.PutWithCost(ctx, "d", struct{}{}, 0, 8))
+	// the cost is the size of the key and the value by default
+	assert.Equal(t, []bool{false, true, true}, exists(bytes, "b", "c", "d"))
+	// the item larger than maxBytes is rejected
+	assert.True(t, errors.Is(mc.PutWithCost(ctx, "e", struct{}{}, 0, 11), ErrItemTooLarge))
+	assert.Equal(t, []bool{false, true}, exists(bytes, "e", "d"))
+	code, _ := berror.FromError(mc.PutMulti(ctx, []string{"e", "c"}, []any{"12345678901", "2"}, 0))
+	assert.Equal(t, ItemTooLarge, code)
+	assert.Equal(t, []bool{false, true}, exists(bytes, "e", "c"))
+	assert.Nil(t, bytes.Delete(ctx, "d"))
+	assert.Equal(t, int64(2), mc.usedBytes)
+	// the old value is deleted
+	assert.True(t, errors.Is(bytes.Put(ctx, "c", "12345678901", 0), ErrItemTooLarge))
+	assert.Equal(t, []bool{false}, exists(bytes, "c"))
+	assert.Equal(t, int64(0), mc.usedBytes)
+
+	assert.Nil(t, lru.ClearAll(ctx))
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Nil(t, lru.Put(ctx, key, key, 0))
+	}
+	assert.Equal(t, []bool{false, true, true}, exists(lru, "a", "b", "c"))
+
+	for _, config := range []string{
+		`{"maxEntries":-1}`,
+		`{"maxBytes":-1}`,
+		`{"eviction":"fifo"}`,
+	} {
+		_, err = NewCache("memory", config)
+		assert.NotNil(t, err, config)
+	}
+}
+
 func TestFileCache(t *testing.T) {
 	bm, err := NewCache("file", `{"CachePath":"cache","FileSuffix":".bin","DirectoryLevel":"2","EmbedExpiry":"0"}`)
 	assert.Nil(t, err)
@@ -93,3 +93,84 @@ func decr(originVal interface{}) (interface{}, error) {
 		return nil, ErrNotIntegerType
 	}
 }
+
+// IncrValue returns originVal increased by 1, it's used by the adapters which store the values as they are.
+// Supports int,int32,int64,uint,uint32,uint64.
+func IncrValue(originVal interface{}) (interface{}, error) {
+	return incr(originVal)
+}
+
+// DecrValue returns originVal decreased by 1, see IncrValue
+func DecrValue(originVal interface{}) (interface{}, error) {
+	return decr(originVal)
+}
+
+// addInt adds delta to originVal and keeps the type of originVal, n is the new value as int64.
+// Supports int,int32,int64,uint,uint32,uint64.
+func addInt(originVal interface{}, delta int64) (val interface{}, n int64, err error) {
+	var cur int64
+	switch v := originVal.(type) {
+	case int:
+		cur = int64(v)
+	case int32:
+		cur = int64(v)
+	case int64:
+		cur = v
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return nil, 0, ErrIncrementOverflow
+		}
+		cur = int64(v)
+	case uint32:
+		cur = int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, 0, ErrIncrementOverflow
+		}
+		cur = int64(v)
+	default:
+		return nil, 0, ErrNotIntegerType
+	}
+	if delta > 0 && cur > math.MaxInt64-delta {
+		return nil, 0, ErrIncrementOverflow
+	}
+	if delta < 0 && cur < math.MinInt64-delta {
+		return nil, 0, ErrDecrementOverflow
+	}
+	n = cur + delta
+	switch originVal.(type) {
+	case int:
+		if n > math.MaxInt || n < math.MinInt {
+			return nil, 0, overflowErr(delta)
+		}
+		return int(n), n, nil
+	case int32:
+		if n > math.MaxInt32 || n < math.MinInt32 {
+			return nil, 0, overflowErr(delta)
+		}
+		return int32(n), n, nil
+	case int64:
+		return n, n, nil
+	}
+	// the unsigned ones
+	if n < 0 {
+		return nil, 0, ErrDecrementOverflow
+	}
+	switch originVal.(type) {
+	case uint:
+		return uint(n), n, nil
+	case uint32:
+		if n > math.MaxUint32 {
+			return nil, 0, ErrIncrementOverflow
+		}
+		return uint32(n), n, nil
+	}
+	return uint64(n), n, nil
+}
+
+func overflowErr(delta int64) error {
+	if delta < 0 {
+		return ErrDecrementOverflow
+	}
+	return ErrIncrementOverflow
+}
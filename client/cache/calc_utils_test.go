@@ -138,3 +138,29 @@ func TestDecr(t *testing.T) {
 	_, err = decr("string")
 	assert.Equal(t, ErrNotIntegerType, err)
 }
+
+func TestAddInt(t *testing.T) {
+	for _, tc := range []struct {
+		origin interface{}
+		delta  int64
+		want   interface{}
+		err    error
+	}{
+		{origin: int(1), delta: 5, want: int(6)},
+		{origin: int32(1), delta: -5, want: int32(-4)},
+		{origin: int64(1), delta: 5, want: int64(6)},
+		{origin: uint(1), delta: 5, want: uint(6)},
+		{origin: uint32(5), delta: -5, want: uint32(0)},
+		{origin: uint64(1), delta: 5, want: uint64(6)},
+		{origin: int32(math.MaxInt32), delta: 1, err: ErrIncrementOverflow},
+		{origin: int64(math.MinInt64), delta: -1, err: ErrDecrementOverflow},
+		{origin: uint32(0), delta: -1, err: ErrDecrementOverflow},
+		{origin: uint32(math.MaxUint32), delta: 1, err: ErrIncrementOverflow},
+		{origin: uint64(math.MaxUint64), delta: 1, err: ErrIncrementOverflow},
+		{origin: "1", delta: 1, err: ErrNotIntegerType},
+	} {
+		val, _, err := addInt(tc.origin, tc.delta)
+		assert.Equal(t, tc.err, err, tc.origin)
+		assert.Equal(t, tc.want, val, tc.origin)
+	}
+}
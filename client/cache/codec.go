@@ -0,0 +1,190 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// the names of the builtin codecs
+const (
+	CodecGob      = "gob"
+	CodecJSON     = "json"
+	CodecMsgPack  = "msgpack"
+	CodecProtobuf = "protobuf"
+)
+
+// Codec encodes the values before the remote adapters, e.g. redis, memcache and ssdb, store them
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes data into v, which must be a pointer.
+	// Get of the adapters decodes the values into *any.
+	Unmarshal(data []byte, v any) error
+}
+
+// CodecCache is implemented by the adapters which support the pluggable codecs.
+// Without the codec, the adapters keep storing the strings and []byte as they are.
+type CodecCache interface {
+	Cache
+	SetCodec(c Codec)
+	// Codec returns the codec set by SetCodec or the config, it's nil if the codec isn't set
+	Codec() Codec
+	// GetInto decodes the value of key into v by the codec, it returns ErrKeyNotExist if the key doesn't exist
+	GetInto(ctx context.Context, key string, v any) error
+}
+
+var (
+	codecLock sync.RWMutex
+	codecs    = map[string]Codec{
+		CodecGob:      gobCodec{},
+		CodecJSON:     jsonCodec{},
+		CodecMsgPack:  msgpackCodec{},
+		CodecProtobuf: protobufCodec{},
+	}
+)
+
+// RegisterCodec registers the codec by name, so it can be used by the "codec" field of the adapter config.
+// The registered one is replaced
+func RegisterCodec(name string, c Codec) {
+	codecLock.Lock()
+	defer codecLock.Unlock()
+	codecs[name] = c
+}
+
+// GetCodec returns the codec registered by name
+func GetCodec(name string) (Codec, error) {
+	codecLock.RLock()
+	defer codecLock.RUnlock()
+	c, ok := codecs[name]
+	if !ok {
+		return nil, berror.Errorf(InvalidCodec, "unknown codec %q", name)
+	}
+	return c, nil
+}
+
+// EncodeValue encodes val by c, the errors are wrapped by CodecFailed
+func EncodeValue(c Codec, val any) ([]byte, error) {
+	data, err := c.Marshal(val)
+	if err != nil {
+		return nil, berror.Wrapf(err, CodecFailed, "could not encode the value: %v", val)
+	}
+	return data, nil
+}
+
+// DecodeValue decodes data into v by c, the errors are wrapped by CodecFailed
+func DecodeValue(c Codec, data []byte, v any) error {
+	if err := c.Unmarshal(data, v); err != nil {
+		return berror.Wrap(err, CodecFailed, "could not decode the value")
+	}
+	return nil
+}
+
+// gobCodec keeps the concrete types of the values, the custom types must be registered by gob.Register
+type gobCodec struct{}
+
+// gobValue wraps the value, so it can be decoded into *any
+type gobValue struct {
+	Value any
+}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(gobValue{Value: v}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	var gv gobValue
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&gv); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("gob codec: non-nil pointer is required, but got %T", v)
+	}
+	if gv.Value == nil {
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+		return nil
+	}
+	val := reflect.ValueOf(gv.Value)
+	if !val.Type().AssignableTo(rv.Elem().Type()) {
+		return fmt.Errorf("gob codec: %T cannot be decoded into %T", gv.Value, v)
+	}
+	rv.Elem().Set(val)
+	return nil
+}
+
+// jsonCodec can be read by the other languages, the numbers are decoded into *any as float64
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// msgpackCodec is more compact than JSON, the integers are decoded into *any as int64 or uint64
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.UseLooseInterfaceDecoding(true)
+	return dec.Decode(v)
+}
+
+// protobufCodec only accepts proto.Message. The type of message is unknown when it's decoded into *any,
+// so the raw bytes are returned, and GetInto should be used instead.
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec: the value must be proto.Message, but got %T", v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	switch p := v.(type) {
+	case proto.Message:
+		return proto.Unmarshal(data, p)
+	case *any:
+		*p = append([]byte(nil), data...)
+		return nil
+	case *[]byte:
+		*p = append([]byte(nil), data...)
+		return nil
+	}
+	return fmt.Errorf("protobuf codec: the value must be proto.Message, but got %T", v)
+}
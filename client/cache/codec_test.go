@@ -0,0 +1,101 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+type codecUser struct {
+	Name string
+	Age  int
+}
+
+func init() {
+	gob.Register(codecUser{})
+}
+
+func TestCodec(t *testing.T) {
+	u := codecUser{Name: "astaxie", Age: 18}
+	testCases := []struct {
+		name string
+		// anyValue is the value decoded into *any
+		anyValue any
+	}{
+		{name: CodecGob, anyValue: u},
+		{name: CodecJSON, anyValue: map[string]any{"Name": "astaxie", "Age": float64(18)}},
+		{name: CodecMsgPack, anyValue: map[string]any{"Name": "astaxie", "Age": int64(18)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := GetCodec(tc.name)
+			assert.Nil(t, err)
+			data, err := EncodeValue(c, u)
+			assert.Nil(t, err)
+
+			var typed codecUser
+			assert.Nil(t, DecodeValue(c, data, &typed))
+			assert.Equal(t, u, typed)
+			var v any
+			assert.Nil(t, DecodeValue(c, data, &v))
+			assert.Equal(t, tc.anyValue, v)
+		})
+	}
+
+	_, err := GetCodec("unknown")
+	code, _ := berror.FromError(err)
+	assert.Equal(t, InvalidCodec, code)
+
+	// the gob codec keeps the concrete type
+	c, _ := GetCodec(CodecGob)
+	data, _ := EncodeValue(c, "astaxie")
+	var typed codecUser
+	code, _ = berror.FromError(DecodeValue(c, data, &typed))
+	assert.Equal(t, CodecFailed, code)
+}
+
+func TestProtobufCodec(t *testing.T) {
+	c, err := GetCodec(CodecProtobuf)
+	assert.Nil(t, err)
+	data, err := EncodeValue(c, wrapperspb.String("astaxie"))
+	assert.Nil(t, err)
+
+	msg := &wrapperspb.StringValue{}
+	assert.Nil(t, DecodeValue(c, data, msg))
+	assert.Equal(t, "astaxie", msg.GetValue())
+	var v any
+	assert.Nil(t, DecodeValue(c, data, &v))
+	raw, _ := proto.Marshal(wrapperspb.String("astaxie"))
+	assert.Equal(t, raw, v)
+
+	_, err = EncodeValue(c, "astaxie")
+	code, _ := berror.FromError(err)
+	assert.Equal(t, CodecFailed, code)
+}
+
+func TestRegisterCodec(t *testing.T) {
+	c, _ := GetCodec(CodecJSON)
+	RegisterCodec("custom", c)
+	got, err := GetCodec("custom")
+	assert.Nil(t, err)
+	assert.Equal(t, c, got)
+}
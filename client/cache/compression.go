@@ -0,0 +1,168 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// the names of the compression algorithms
+const (
+	CompressionGzip   = "gzip"
+	CompressionSnappy = "snappy"
+	CompressionZstd   = "zstd"
+)
+
+// DefaultCompressThreshold is the min size of the compressed values if the threshold isn't set
+const DefaultCompressThreshold = 1024
+
+// compressionMagic starts the header of the values written by Compressor,
+// it's followed by one byte of the algorithm
+const compressionMagic = "\x00bc"
+
+// the algorithm byte of the header
+const (
+	// compressionNone marks the small value which looks like the header, so it isn't misread
+	compressionNone byte = iota
+	compressionGzip
+	compressionSnappy
+	compressionZstd
+)
+
+var compressionIDs = map[string]byte{
+	CompressionGzip:   compressionGzip,
+	CompressionSnappy: compressionSnappy,
+	CompressionZstd:   compressionZstd,
+}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+// initZstd creates the shared encoder and decoder, EncodeAll and DecodeAll of them are goroutine safe
+func initZstd() {
+	zstdOnce.Do(func() {
+		zstdEncoder, _ = zstd.NewWriter(nil)
+		zstdDecoder, _ = zstd.NewReader(nil)
+	})
+}
+
+// Compressor compresses the values which are not smaller than the threshold before the remote adapters store them.
+// The compressed values start with a small header, so Decompress detects whether a value is compressed and how.
+type Compressor struct {
+	id        byte
+	threshold int
+}
+
+// NewCompressor creates Compressor of the algorithm,
+// DefaultCompressThreshold is used if threshold is 0, and all the values are compressed if it's negative
+func NewCompressor(algorithm string, threshold int) (*Compressor, error) {
+	id, ok := compressionIDs[algorithm]
+	if !ok {
+		return nil, berror.Errorf(InvalidCompression, "unknown compression algorithm %q", algorithm)
+	}
+	if threshold == 0 {
+		threshold = DefaultCompressThreshold
+	}
+	if id == compressionZstd {
+		initZstd()
+	}
+	return &Compressor{id: id, threshold: threshold}, nil
+}
+
+// Compress compresses data if it's large enough, otherwise data is returned as it is
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	if len(data) < c.threshold {
+		if bytes.HasPrefix(data, []byte(compressionMagic)) {
+			return append(header(compressionNone), data...), nil
+		}
+		return data, nil
+	}
+	dst := header(c.id)
+	switch c.id {
+	case compressionGzip:
+		buf := bytes.NewBuffer(dst)
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, berror.Wrap(err, CompressionFailed, "could not compress the value by gzip")
+		}
+		if err := w.Close(); err != nil {
+			return nil, berror.Wrap(err, CompressionFailed, "could not compress the value by gzip")
+		}
+		return buf.Bytes(), nil
+	case compressionSnappy:
+		return append(dst, snappy.Encode(nil, data)...), nil
+	default:
+		return zstdEncoder.EncodeAll(data, dst), nil
+	}
+}
+
+// CompressValue compresses the string and []byte, the values of the other types, e.g. the integers, are returned as they are
+func (c *Compressor) CompressValue(val interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case []byte:
+		return c.Compress(v)
+	case string:
+		return c.Compress([]byte(v))
+	}
+	return val, nil
+}
+
+func header(id byte) []byte {
+	return append([]byte(compressionMagic), id)
+}
+
+// Decompress decompresses data written by Compressor with any algorithm,
+// data without the header is returned as it is
+func Decompress(data []byte) ([]byte, error) {
+	if len(data) <= len(compressionMagic) || !bytes.HasPrefix(data, []byte(compressionMagic)) {
+		return data, nil
+	}
+	id, body := data[len(compressionMagic)], data[len(compressionMagic)+1:]
+	var (
+		res []byte
+		err error
+	)
+	switch id {
+	case compressionNone:
+		return body, nil
+	case compressionGzip:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(body)); err == nil {
+			res, err = io.ReadAll(r)
+		}
+	case compressionSnappy:
+		res, err = snappy.Decode(nil, body)
+	case compressionZstd:
+		initZstd()
+		res, err = zstdDecoder.DecodeAll(body, nil)
+	default:
+		return nil, berror.Errorf(CompressionFailed, "unknown compression algorithm %d in the header", id)
+	}
+	if err != nil {
+		return nil, berror.Wrap(err, CompressionFailed, "could not decompress the value")
+	}
+	return res, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+func TestCompressor(t *testing.T) {
+	large := []byte(strings.Repeat("<div>hello, beego</div>", 100))
+	for _, algorithm := range []string{CompressionGzip, CompressionSnappy, CompressionZstd} {
+		t.Run(algorithm, func(t *testing.T) {
+			c, err := NewCompressor(algorithm, 0)
+			assert.Nil(t, err)
+
+			data, err := c.Compress(large)
+			assert.Nil(t, err)
+			assert.True(t, len(data) < len(large))
+			assert.True(t, bytes.HasPrefix(data, []byte(compressionMagic)))
+			res, err := Decompress(data)
+			assert.Nil(t, err)
+			assert.Equal(t, large, res)
+
+			// the small values are kept as they are
+			data, err = c.Compress([]byte("small"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("small"), data)
+			res, err = Decompress(data)
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("small"), res)
+		})
+	}
+
+	c, err := NewCompressor(CompressionSnappy, 0)
+	assert.Nil(t, err)
+	// the small value which looks like the header
+	tricky := []byte(compressionMagic + "\x01tricky")
+	data, err := c.Compress(tricky)
+	assert.Nil(t, err)
+	res, err := Decompress(data)
+	assert.Nil(t, err)
+	assert.Equal(t, tricky, res)
+
+	val, err := c.CompressValue(string(large))
+	assert.Nil(t, err)
+	res, err = Decompress(val.([]byte))
+	assert.Nil(t, err)
+	assert.Equal(t, large, res)
+	val, err = c.CompressValue(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, val)
+
+	_, err = Decompress([]byte(compressionMagic + "\x01broken"))
+	code, _ := berror.FromError(err)
+	assert.Equal(t, CompressionFailed, code)
+	_, err = NewCompressor("lz4", 0)
+	code, _ = berror.FromError(err)
+	assert.Equal(t, InvalidCompression, code)
+}
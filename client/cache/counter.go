@@ -0,0 +1,100 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// ErrCounterOutOfBounds is returned by IncrBy if the counter would pass the floor or the ceiling
+var ErrCounterOutOfBounds = berror.Error(CounterOutOfBounds, "the counter would be out of bounds")
+
+// CounterCache is a Cache whose counters are changed atomically with the initial value, TTL and bounds,
+// so the counters and the rate limiters can be built on the cache directly.
+// usage:
+//
+//	// allow 100 requests per minute
+//	n, err := c.(cache.CounterCache).IncrBy(ctx, "rate:"+ip, 1,
+//		cache.WithCounterTTL(time.Minute), cache.WithCeiling(100))
+//	if errors.Is(err, cache.ErrCounterOutOfBounds) {
+//		// too many requests
+//	}
+type CounterCache interface {
+	Cache
+	// IncrBy adds delta, which may be negative, to the counter and returns the new value.
+	// The missing counter starts from the initial value and expires after the TTL, the TTL isn't changed later.
+	// If the new value would be out of bounds, the counter is kept,
+	// and IncrBy returns the current value and ErrCounterOutOfBounds.
+	IncrBy(ctx context.Context, key string, delta int64, opts ...CounterOption) (int64, error)
+}
+
+// CounterOptions are the options of IncrBy
+type CounterOptions struct {
+	// Initial is the value of the missing counter before delta is added
+	Initial int64
+	// TTL is the lifetime of the counter created by IncrBy, it never expires if TTL is 0
+	TTL time.Duration
+	// Floor and Ceiling are the bounds of the counter, nil means no bound
+	Floor   *int64
+	Ceiling *int64
+}
+
+// CounterOption configures IncrBy
+type CounterOption func(o *CounterOptions)
+
+// NewCounterOptions applies opts, it's used by the adapters
+func NewCounterOptions(opts ...CounterOption) CounterOptions {
+	var o CounterOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// InBounds checks whether v is between Floor and Ceiling
+func (o CounterOptions) InBounds(v int64) bool {
+	return (o.Floor == nil || v >= *o.Floor) && (o.Ceiling == nil || v <= *o.Ceiling)
+}
+
+// WithInitial sets the value of the missing counter before delta is added, it's 0 by default
+func WithInitial(v int64) CounterOption {
+	return func(o *CounterOptions) {
+		o.Initial = v
+	}
+}
+
+// WithCounterTTL sets the lifetime of the counter created by IncrBy
+func WithCounterTTL(ttl time.Duration) CounterOption {
+	return func(o *CounterOptions) {
+		o.TTL = ttl
+	}
+}
+
+// WithFloor rejects the changes making the counter smaller than v
+func WithFloor(v int64) CounterOption {
+	return func(o *CounterOptions) {
+		o.Floor = &v
+	}
+}
+
+// WithCeiling rejects the changes making the counter larger than v
+func WithCeiling(v int64) CounterOption {
+	return func(o *CounterOptions) {
+		o.Ceiling = &v
+	}
+}
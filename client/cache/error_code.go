@@ -100,6 +100,7 @@ Get multiple keys failed. Please check the detail msg to find out the root cause
 
 var InvalidMemoryCacheCfg = berror.DefineCode(4002017, moduleName, "InvalidMemoryCacheCfg", `
 The config is invalid. Please check your input. It must be a json string.
+"maxEntries" and "maxBytes" must be positive if they're set, and "eviction" must be "lru" or "lfu".
 `)
 
 var InvalidMemCacheCfg = berror.DefineCode(4002018, moduleName, "InvalidMemCacheCfg", `
@@ -142,6 +143,83 @@ Failed to execute the StoreFunc.
 Please check the log to make sure the StoreFunc works for the specific key and value.
 `)
 
+var InvalidRistrettoCacheCfg = berror.DefineCode(4002027, moduleName, "InvalidRistrettoCacheCfg", `
+The config of ristretto cache must be json string, "numCounters", "maxCost" and "bufferItems" must be positive if they're set.
+`)
+
+var InvalidBigCacheCfg = berror.DefineCode(4002028, moduleName, "InvalidBigCacheCfg", `
+The config of bigcache must be json string, "lifeWindow" and "cleanWindow" must be valid durations like "10m",
+"shards" must be a power of two and the sizes must be positive if they're set.
+`)
+
+var InvalidFreeCacheCfg = berror.DefineCode(4002029, moduleName, "InvalidFreeCacheCfg", `
+The config of freecache must be json string, "size" is the memory budget in bytes and must be positive if it's set.
+`)
+
+var InvalidGroupCacheCfg = berror.DefineCode(4002030, moduleName, "InvalidGroupCacheCfg", `
+The config of groupcache must be json string, "self" must be set if "peers" is set, and all the groupcache adapters
+in the process must use the same "self" and "basePath" because they share one peer pool.
+The "name" is the group name, it must be unique in the process.
+`)
+
+var InvalidCodec = berror.DefineCode(4002031, moduleName, "InvalidCodec", `
+The codec is unknown, or it isn't set. The builtin codecs are "gob", "json", "msgpack" and "protobuf",
+and the custom ones must be registered by cache.RegisterCodec before the adapters are started.
+`)
+
+var InvalidCompression = berror.DefineCode(4002032, moduleName, "InvalidCompression", `
+The compression algorithm must be "gzip", "snappy" or "zstd", and the threshold must be an integer.
+`)
+
+var DeleteByPrefixNotSupported = berror.DefineCode(4002033, moduleName, "DeleteByPrefixNotSupported", `
+The cache doesn't implement cache.PrefixCache. The memory, redis, redis_cluster, redis_sentinel and ssdb adapters support it,
+memcache doesn't because it can't list the keys.
+`)
+
+var CounterOutOfBounds = berror.DefineCode(4002034, moduleName, "CounterOutOfBounds", `
+The counter would be smaller than the floor or larger than the ceiling passed to IncrBy, so it isn't changed.
+It's expected when the counter is used as a rate limiter.
+`)
+
+var LockNotSupported = berror.DefineCode(4002035, moduleName, "LockNotSupported", `
+The cache doesn't implement cache.Locker, or the ttl of the lock isn't positive.
+The memory, redis, redis_cluster, redis_sentinel and memcache adapters support the distributed lock.
+`)
+
+var LockNotObtained = berror.DefineCode(4002036, moduleName, "LockNotObtained", `
+The lock is held by the others. Use cache.Lock instead of cache.TryLock to wait for it.
+`)
+
+var LockNotHeld = berror.DefineCode(4002037, moduleName, "LockNotHeld", `
+The lock is expired, so it may be held by the others now. Use a longer ttl or keep the heartbeat enabled.
+`)
+
+var InvalidMultiValues = berror.DefineCode(4002038, moduleName, "InvalidMultiValues", `
+The numbers of the keys and the values passed to PutMulti are different.
+`)
+
+var MultiPutFailed = berror.DefineCode(4002039, moduleName, "MultiPutFailed", `
+Put multiple keys failed. Please check the detail msg to find out the root cause.
+`)
+
+var MultiDeleteFailed = berror.DefineCode(4002040, moduleName, "MultiDeleteFailed", `
+Delete multiple keys failed. Please check the detail msg to find out the root cause.
+`)
+
+var ThroughCacheClosed = berror.DefineCode(4002041, moduleName, "ThroughCacheClosed", `
+The write-behind ThroughCache is closed, so the writes can't be queued any more.
+`)
+
+var InvalidValueType = berror.DefineCode(4002042, moduleName, "InvalidValueType", `
+The cached value isn't the type of TypedCache. Make sure the key is only written by the TypedCache of the same type,
+and the values put by the remote adapters are encoded by a codec if they aren't strings.
+`)
+
+var ItemTooLarge = berror.DefineCode(4002043, moduleName, "ItemTooLarge", `
+The cost of the item exceeds maxBytes of the memory cache, so it can't be cached even if all the other items are evicted.
+Increase maxBytes, or don't cache the item.
+`)
+
 var DeleteFileCacheItemFailed = berror.DefineCode(5002001, moduleName, "DeleteFileCacheItemFailed", `
 Beego try to delete file cache item failed. 
 Please check whether Beego generated file correctly. 
@@ -193,7 +271,26 @@ var DeleteFailed = berror.DefineCode(5002008, moduleName, "DeleteFailed", `
 Beego attempt to delete cache item failed. Please check if the target key is correct.
 `)
 
+var FreeCacheCurdFailed = berror.DefineCode(5002009, moduleName, "FreeCacheCurdFailed", `
+Beego could not put the item into freecache. The encoded item must be smaller than 1/1024 of the cache size.
+`)
+
+var GroupCacheCurdFailed = berror.DefineCode(5002010, moduleName, "GroupCacheCurdFailed", `
+Beego could not access groupcache. Please check whether the peers are reachable and serve the groupcache handler.
+`)
+
+var CodecFailed = berror.DefineCode(5002011, moduleName, "CodecFailed", `
+The value could not be encoded or decoded by the codec. The custom types must be registered by gob.Register for the gob codec,
+the protobuf codec only accepts proto.Message, and the value must be decoded by the codec which encoded it.
+`)
+
+var CompressionFailed = berror.DefineCode(5002012, moduleName, "CompressionFailed", `
+The value could not be compressed or decompressed. The compressed value may be corrupted or written by the other tools.
+`)
+
 var (
 	ErrKeyExpired  = berror.Error(KeyExpired, "the key is expired")
 	ErrKeyNotExist = berror.Error(KeyNotExist, "the key isn't exist")
+	// ErrItemTooLarge is returned by MemoryCache if the cost of the item exceeds maxBytes
+	ErrItemTooLarge = berror.Error(ItemTooLarge, "the cost of the item exceeds maxBytes")
 )
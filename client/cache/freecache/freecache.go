@@ -0,0 +1,233 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package freecache for cache provider
+//
+// depend on github.com/coocood/freecache
+//
+// FreeCache preallocates a fixed memory budget and keeps the entries in it without pointers,
+// so the cache never grows beyond "size" and adds no GC overhead. The oldest entries are
+// evicted when it's full. The values are gob encoded by the adapter.
+//
+// Usage:
+// import(
+//
+//	_ "github.com/beego/beego/v2/client/cache/freecache"
+//	"github.com/beego/beego/v2/client/cache"
+//
+// )
+//
+//	bm, err := cache.NewCache("freecache", `{"size":104857600}`)
+package freecache
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coocood/freecache"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// DefaultSize is the memory budget in bytes if size isn't set
+const DefaultSize = 64 * 1024 * 1024
+
+type freeCacheConfig struct {
+	// Size is the memory budget in bytes, it's allocated at once. The min size is 512KB
+	Size int `json:"size"`
+}
+
+// Cache is the freecache adapter
+type Cache struct {
+	cache *freecache.Cache
+	// lock makes Incr and Decr atomic
+	lock sync.Mutex
+}
+
+// NewFreeCache creates the freecache adapter
+func NewFreeCache() cache.Cache {
+	return &Cache{}
+}
+
+// Get returns the value of key.
+// The error is cache.ErrKeyNotExist if it doesn't exist and cache.ErrKeyExpired if it's expired.
+func (fc *Cache) Get(ctx context.Context, key string) (interface{}, error) {
+	item, err := fc.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return item.Data, nil
+}
+
+func (fc *Cache) get(key string) (*cache.FileCacheItem, error) {
+	data, err := fc.cache.Get([]byte(key))
+	if errors.Is(err, freecache.ErrNotFound) {
+		return nil, cache.ErrKeyNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	item := &cache.FileCacheItem{}
+	if err = cache.GobDecode(data, item); err != nil {
+		return nil, err
+	}
+	// freecache expires the entries in seconds, the exact expiration time is kept in the item
+	if !item.Expired.IsZero() && item.Expired.Before(time.Now()) {
+		fc.cache.Del([]byte(key))
+		return nil, cache.ErrKeyExpired
+	}
+	return item, nil
+}
+
+// GetMulti gets the values of keys, the value of the key which doesn't exist is nil.
+func (fc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	keysErr := make([]string, 0)
+	for i, key := range keys {
+		v, err := fc.Get(ctx, key)
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", key, err.Error()))
+			continue
+		}
+		values[i] = v
+	}
+	if len(keysErr) == 0 {
+		return values, nil
+	}
+	return values, berror.Error(cache.MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// Put puts the value, timeout 0 means it never expires.
+// The item may still be evicted when the cache is full.
+func (fc *Cache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	gob.Register(val)
+	item := &cache.FileCacheItem{Data: val, Lastaccess: time.Now()}
+	if timeout > 0 {
+		item.Expired = item.Lastaccess.Add(timeout)
+	}
+	return fc.set(key, item)
+}
+
+func (fc *Cache) set(key string, item *cache.FileCacheItem) error {
+	data, err := cache.GobEncode(item)
+	if err != nil {
+		return err
+	}
+	expireSeconds := 0
+	if !item.Expired.IsZero() {
+		// round up, so freecache never drops the item before it expires
+		expireSeconds = int((time.Until(item.Expired) + time.Second - 1) / time.Second)
+		if expireSeconds <= 0 {
+			return cache.ErrKeyExpired
+		}
+	}
+	if err = fc.cache.Set([]byte(key), data, expireSeconds); err != nil {
+		return berror.Wrapf(err, cache.FreeCacheCurdFailed, "could not put key: %s", key)
+	}
+	return nil
+}
+
+// Delete deletes the value of key.
+func (fc *Cache) Delete(ctx context.Context, key string) error {
+	fc.cache.Del([]byte(key))
+	return nil
+}
+
+// Incr increases the counter, supports int,int32,int64,uint,uint32,uint64.
+func (fc *Cache) Incr(ctx context.Context, key string) error {
+	return fc.update(key, cache.IncrValue)
+}
+
+// Decr decreases the counter.
+func (fc *Cache) Decr(ctx context.Context, key string) error {
+	return fc.update(key, cache.DecrValue)
+}
+
+// update replaces the value of key by fn and keeps the expiration time
+func (fc *Cache) update(key string, fn func(interface{}) (interface{}, error)) error {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	item, err := fc.get(key)
+	if err != nil {
+		return err
+	}
+	if item.Data, err = fn(item.Data); err != nil {
+		return err
+	}
+	item.Lastaccess = time.Now()
+	return fc.set(key, item)
+}
+
+// IsExist checks whether key exists and isn't expired.
+func (fc *Cache) IsExist(ctx context.Context, key string) (bool, error) {
+	_, err := fc.get(key)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, cache.ErrKeyNotExist) || errors.Is(err, cache.ErrKeyExpired) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ClearAll deletes all the entries.
+func (fc *Cache) ClearAll(context.Context) error {
+	fc.cache.Clear()
+	return nil
+}
+
+// HitRate returns the ratio of hits to lookups
+func (fc *Cache) HitRate() float64 {
+	return fc.cache.HitRate()
+}
+
+// EntryCount returns the number of entries, including the expired ones which aren't evicted yet
+func (fc *Cache) EntryCount() int64 {
+	return fc.cache.EntryCount()
+}
+
+// Evictions returns how many entries are evacuated because the memory budget is full
+func (fc *Cache) Evictions() uint64 {
+	return uint64(fc.cache.EvacuateCount())
+}
+
+// StartAndGC allocates the memory budget, the expired entries are evicted by freecache lazily.
+// config is like {"size":104857600}
+func (fc *Cache) StartAndGC(config string) error {
+	cf := freeCacheConfig{}
+	if strings.TrimSpace(config) != "" {
+		if err := json.Unmarshal([]byte(config), &cf); err != nil {
+			return berror.Wrapf(err, cache.InvalidFreeCacheCfg, "invalid config, please check your input: %s", config)
+		}
+	}
+	if cf.Size < 0 {
+		return berror.Errorf(cache.InvalidFreeCacheCfg, "invalid config, the size must be positive: %s", config)
+	}
+	if cf.Size == 0 {
+		cf.Size = DefaultSize
+	}
+	fc.cache = freecache.NewCache(cf.Size)
+	return nil
+}
+
+func init() {
+	cache.Register("freecache", NewFreeCache)
+}
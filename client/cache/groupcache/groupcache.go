@@ -0,0 +1,349 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package groupcache for cache provider
+//
+// depend on github.com/mailgun/groupcache/v2
+//
+// The keys are sharded across the peers by consistent hashing, every key is owned and loaded by one peer.
+// The concurrent loads of a key are deduplicated, and the hot keys fetched from the other peers are
+// replicated to the local hot cache. So the beego instances share one read-through cache without Redis.
+//
+// Every instance must serve the peer requests by Handler, for example:
+//
+//	c := groupcache.NewReadThroughCache(time.Minute, func(ctx context.Context, key string) (any, error) {
+//		return loadFromDB(ctx, key)
+//	})
+//	err := c.StartAndGC(`{"name":"users","self":"http://10.0.0.1:8080",
+//		"peers":["http://10.0.0.1:8080","http://10.0.0.2:8080"]}`)
+//	web.Handler(groupcache.DefaultBasePath+"*", c.(*groupcache.Cache).Handler())
+//
+// Or use it without a loader, the values are put into the peer which owns the key:
+//
+//	bm, err := cache.NewCache("groupcache", `{"self":"http://10.0.0.1:8080","peers":[...]}`)
+package groupcache
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mailgun/groupcache/v2"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/core/berror"
+)
+
+const (
+	// DefaultName is the group name if name isn't set
+	DefaultName = "beego"
+	// DefaultBasePath is the path of the peer requests if basePath isn't set
+	DefaultBasePath = "/_groupcache/"
+	// DefaultCacheBytes is the memory limit of the local caches if cacheBytes isn't set
+	DefaultCacheBytes = 64 * 1024 * 1024
+)
+
+// groupcache allows only one peer pool in the process, so it's shared by all the groups
+var (
+	poolLock sync.Mutex
+	pool     *groupcache.HTTPPool
+	poolSelf string
+	poolPath string
+)
+
+type groupCacheConfig struct {
+	// Name is the group name, the groups with the same name on the peers share the keys
+	Name string `json:"name"`
+	// Self is the base URL of this peer, like http://10.0.0.1:8080
+	Self string `json:"self"`
+	// Peers are the base URLs of all the peers including self
+	Peers []string `json:"peers"`
+	// CacheBytes limits the memory of the keys owned by this peer and the hot keys replicated from the others
+	CacheBytes int64 `json:"cacheBytes"`
+	// BasePath is the path of the peer requests, "/_groupcache/" by default
+	BasePath string `json:"basePath"`
+	// Replicas is the number of the virtual nodes of every peer in the consistent hash, 50 by default
+	Replicas int `json:"replicas"`
+}
+
+// Cache is the groupcache adapter
+type Cache struct {
+	// group is replaced by ClearAll
+	group      atomic.Pointer[groupcache.Group]
+	name       string
+	cacheBytes int64
+	expiration time.Duration
+	loadFunc   func(ctx context.Context, key string) (any, error)
+	// lock makes Incr and Decr atomic in this peer
+	lock sync.Mutex
+}
+
+// NewGroupCache creates the groupcache adapter without a loader,
+// Get returns cache.ErrKeyNotExist unless the key is put by any peer.
+func NewGroupCache() cache.Cache {
+	return &Cache{}
+}
+
+// NewReadThroughCache creates the groupcache adapter which loads the missing key by loadFunc
+// on the peer owning the key, the loaded value expires after expiration, 0 means it never expires.
+func NewReadThroughCache(expiration time.Duration,
+	loadFunc func(ctx context.Context, key string) (any, error),
+) cache.Cache {
+	return &Cache{expiration: expiration, loadFunc: loadFunc}
+}
+
+// Get returns the value of key, it's loaded by the peer owning the key if it's missing.
+func (gc *Cache) Get(ctx context.Context, key string) (interface{}, error) {
+	item, _, err := gc.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return item.Data, nil
+}
+
+func (gc *Cache) get(ctx context.Context, key string) (*cache.FileCacheItem, time.Time, error) {
+	var view groupcache.ByteView
+	err := gc.group.Load().Get(ctx, key, groupcache.ByteViewSink(&view))
+	if errors.Is(err, &groupcache.ErrNotFound{}) {
+		return nil, time.Time{}, cache.ErrKeyNotExist
+	}
+	if err != nil {
+		code := cache.GroupCacheCurdFailed
+		if gc.loadFunc != nil {
+			code = cache.LoadFuncFailed
+		}
+		return nil, time.Time{}, berror.Wrapf(err, code, "could not get key: %s", key)
+	}
+	item := &cache.FileCacheItem{}
+	if err = cache.GobDecode(view.ByteSlice(), item); err != nil {
+		return nil, time.Time{}, err
+	}
+	return item, view.Expire(), nil
+}
+
+// load is the groupcache getter, it runs on the peer owning the key
+func (gc *Cache) load(ctx context.Context, key string, dest groupcache.Sink) error {
+	if gc.loadFunc == nil {
+		return &groupcache.ErrNotFound{Msg: "the key isn't exist"}
+	}
+	val, err := gc.loadFunc(ctx, key)
+	if errors.Is(err, cache.ErrKeyNotExist) {
+		return &groupcache.ErrNotFound{Msg: err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	var expire time.Time
+	if gc.expiration > 0 {
+		expire = time.Now().Add(gc.expiration)
+	}
+	data, err := encode(val)
+	if err != nil {
+		return err
+	}
+	return dest.SetBytes(data, expire)
+}
+
+func encode(val interface{}) ([]byte, error) {
+	gob.Register(val)
+	return cache.GobEncode(&cache.FileCacheItem{Data: val, Lastaccess: time.Now()})
+}
+
+// GetMulti gets the values of keys, the value of the key which doesn't exist is nil.
+func (gc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	keysErr := make([]string, 0)
+	for i, key := range keys {
+		v, err := gc.Get(ctx, key)
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", key, err.Error()))
+			continue
+		}
+		values[i] = v
+	}
+	if len(keysErr) == 0 {
+		return values, nil
+	}
+	return values, berror.Error(cache.MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// Put puts the value into the peer owning the key, timeout 0 means it never expires.
+// The copies in the hot caches of the other peers aren't updated, use Delete to purge them.
+func (gc *Cache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	var expire time.Time
+	if timeout > 0 {
+		expire = time.Now().Add(timeout)
+	}
+	data, err := encode(val)
+	if err != nil {
+		return err
+	}
+	return gc.set(ctx, key, data, expire)
+}
+
+func (gc *Cache) set(ctx context.Context, key string, data []byte, expire time.Time) error {
+	if err := gc.group.Load().Set(ctx, key, data, expire, false); err != nil {
+		return berror.Wrapf(err, cache.GroupCacheCurdFailed, "could not put key: %s", key)
+	}
+	return nil
+}
+
+// Delete deletes the key from the owner and the hot caches of all the peers.
+func (gc *Cache) Delete(ctx context.Context, key string) error {
+	if err := gc.group.Load().Remove(ctx, key); err != nil {
+		return berror.Wrapf(err, cache.DeleteFailed, "could not delete key: %s", key)
+	}
+	return nil
+}
+
+// Incr increases the counter, supports int,int32,int64,uint,uint32,uint64.
+// It's atomic only if the counter is updated by one peer.
+func (gc *Cache) Incr(ctx context.Context, key string) error {
+	return gc.update(ctx, key, cache.IncrValue)
+}
+
+// Decr decreases the counter, see Incr.
+func (gc *Cache) Decr(ctx context.Context, key string) error {
+	return gc.update(ctx, key, cache.DecrValue)
+}
+
+// update replaces the value of key by fn and keeps the expiration time
+func (gc *Cache) update(ctx context.Context, key string, fn func(interface{}) (interface{}, error)) error {
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+	item, expire, err := gc.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	val, err := fn(item.Data)
+	if err != nil {
+		return err
+	}
+	data, err := encode(val)
+	if err != nil {
+		return err
+	}
+	return gc.set(ctx, key, data, expire)
+}
+
+// IsExist checks whether Get returns the value of key, so the key is loaded if it's missing.
+func (gc *Cache) IsExist(ctx context.Context, key string) (bool, error) {
+	_, _, err := gc.get(ctx, key)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, cache.ErrKeyNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ClearAll drops the caches of this peer, the keys owned or replicated by the other peers are kept.
+func (gc *Cache) ClearAll(context.Context) error {
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+	groupcache.DeregisterGroup(gc.name)
+	gc.group.Store(groupcache.NewGroup(gc.name, gc.cacheBytes, groupcache.GetterFunc(gc.load)))
+	return nil
+}
+
+// Stats returns the statistics of the group, such as the loads and the peer requests
+func (gc *Cache) Stats() *groupcache.Stats {
+	return &gc.group.Load().Stats
+}
+
+// Handler serves the requests from the other peers, it must be mounted on the basePath
+func (gc *Cache) Handler() http.Handler {
+	poolLock.Lock()
+	defer poolLock.Unlock()
+	return pool
+}
+
+// SetPeers updates the base URLs of all the peers including self, the keys are resharded
+func (gc *Cache) SetPeers(peers ...string) {
+	poolLock.Lock()
+	defer poolLock.Unlock()
+	pool.Set(peers...)
+}
+
+// StartAndGC joins the group, the expired keys are evicted by groupcache.
+// config is like {"name":"beego","self":"http://10.0.0.1:8080","peers":["http://10.0.0.1:8080"],"cacheBytes":67108864}
+func (gc *Cache) StartAndGC(config string) error {
+	cf := groupCacheConfig{}
+	if strings.TrimSpace(config) != "" {
+		if err := json.Unmarshal([]byte(config), &cf); err != nil {
+			return berror.Wrapf(err, cache.InvalidGroupCacheCfg, "invalid config, please check your input: %s", config)
+		}
+	}
+	if cf.CacheBytes < 0 || cf.Replicas < 0 {
+		return berror.Errorf(cache.InvalidGroupCacheCfg, "invalid config, the sizes must be positive: %s", config)
+	}
+	if cf.Self == "" && len(cf.Peers) > 0 {
+		return berror.Errorf(cache.InvalidGroupCacheCfg, "invalid config, self must be set with peers: %s", config)
+	}
+	if cf.Name == "" {
+		cf.Name = DefaultName
+	}
+	if cf.BasePath == "" {
+		cf.BasePath = DefaultBasePath
+	}
+	if cf.CacheBytes == 0 {
+		cf.CacheBytes = DefaultCacheBytes
+	}
+	if err := initPool(cf); err != nil {
+		return err
+	}
+
+	// the groups are registered globally by name
+	if gc.group.Load() != nil {
+		groupcache.DeregisterGroup(gc.name)
+	}
+	if groupcache.GetGroup(cf.Name) != nil {
+		return berror.Errorf(cache.InvalidGroupCacheCfg, "the group %s is in use", cf.Name)
+	}
+	gc.name = cf.Name
+	gc.cacheBytes = cf.CacheBytes
+	gc.group.Store(groupcache.NewGroup(cf.Name, cf.CacheBytes, groupcache.GetterFunc(gc.load)))
+	return nil
+}
+
+func initPool(cf groupCacheConfig) error {
+	poolLock.Lock()
+	defer poolLock.Unlock()
+	if pool == nil {
+		pool = groupcache.NewHTTPPoolOpts(cf.Self, &groupcache.HTTPPoolOptions{
+			BasePath: cf.BasePath,
+			Replicas: cf.Replicas,
+		})
+		poolSelf, poolPath = cf.Self, cf.BasePath
+	} else if cf.Self != "" && (cf.Self != poolSelf || cf.BasePath != poolPath) {
+		return berror.Errorf(cache.InvalidGroupCacheCfg,
+			"the peer pool is started as %s%s, it can't be changed to %s%s", poolSelf, poolPath, cf.Self, cf.BasePath)
+	}
+	if len(cf.Peers) > 0 {
+		pool.Set(cf.Peers...)
+	}
+	return nil
+}
+
+func init() {
+	cache.Register("groupcache", NewGroupCache)
+}
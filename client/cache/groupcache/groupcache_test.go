@@ -0,0 +1,150 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groupcache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/core/berror"
+)
+
+func TestGroupCache(t *testing.T) {
+	ctx := context.Background()
+	bm, err := cache.NewCache("groupcache", `{"name":"basic","cacheBytes":1048576}`)
+	assert.Nil(t, err)
+
+	assert.Nil(t, bm.Put(ctx, "astaxie", 1, time.Second))
+	res, _ := bm.IsExist(ctx, "astaxie")
+	assert.True(t, res)
+	v, err := bm.Get(ctx, "astaxie")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+
+	assert.Nil(t, bm.Incr(ctx, "astaxie"))
+	v, _ = bm.Get(ctx, "astaxie")
+	assert.Equal(t, 2, v)
+	assert.Nil(t, bm.Decr(ctx, "astaxie"))
+	v, _ = bm.Get(ctx, "astaxie")
+	assert.Equal(t, 1, v)
+
+	time.Sleep(2 * time.Second)
+	res, _ = bm.IsExist(ctx, "astaxie")
+	assert.False(t, res)
+	_, err = bm.Get(ctx, "astaxie")
+	assert.True(t, errors.Is(err, cache.ErrKeyNotExist))
+
+	assert.Nil(t, bm.Put(ctx, "astaxie", "author", 0))
+	assert.Nil(t, bm.Put(ctx, "astaxie1", "author1", 0))
+	vv, err := bm.GetMulti(ctx, []string{"astaxie", "astaxie1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"author", "author1"}, vv)
+	vv, err = bm.GetMulti(ctx, []string{"astaxie0", "astaxie1"})
+	assert.Equal(t, []interface{}{nil, "author1"}, vv)
+	assert.True(t, strings.Contains(err.Error(), "key isn't exist"))
+	assert.NotNil(t, bm.Incr(ctx, "astaxie"))
+
+	assert.Nil(t, bm.Delete(ctx, "astaxie"))
+	res, _ = bm.IsExist(ctx, "astaxie")
+	assert.False(t, res)
+
+	assert.Nil(t, bm.ClearAll(ctx))
+	res, _ = bm.IsExist(ctx, "astaxie1")
+	assert.False(t, res)
+}
+
+func TestGroupCacheReadThrough(t *testing.T) {
+	ctx := context.Background()
+	var loads int32
+	bm := NewReadThroughCache(time.Minute, func(ctx context.Context, key string) (any, error) {
+		atomic.AddInt32(&loads, 1)
+		switch key {
+		case "missing":
+			return nil, cache.ErrKeyNotExist
+		case "broken":
+			return nil, errors.New("db is down")
+		}
+		time.Sleep(100 * time.Millisecond)
+		return "value of " + key, nil
+	})
+	assert.Nil(t, bm.StartAndGC(`{"name":"read_through"}`))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := bm.Get(ctx, "user:42")
+			assert.Nil(t, err)
+			assert.Equal(t, "value of user:42", v)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	assert.Equal(t, int64(1), bm.(*Cache).Stats().LocalLoads.Get())
+
+	_, err := bm.Get(ctx, "missing")
+	assert.True(t, errors.Is(err, cache.ErrKeyNotExist))
+	_, err = bm.Get(ctx, "broken")
+	code, ok := berror.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, cache.LoadFuncFailed, code)
+}
+
+func TestGroupCacheHandler(t *testing.T) {
+	ctx := context.Background()
+	bm, err := cache.NewCache("groupcache", `{"name":"handler"}`)
+	assert.Nil(t, err)
+	assert.Nil(t, bm.Put(ctx, "astaxie", "author", 0))
+
+	srv := httptest.NewServer(bm.(*Cache).Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + DefaultBasePath + "handler/astaxie")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + DefaultBasePath + "handler/astaxie0")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGroupCacheConfig(t *testing.T) {
+	_, err := cache.NewCache("groupcache", `{"name":"config"}`)
+	assert.Nil(t, err)
+	for _, config := range []string{
+		`{"name":`,
+		`{"name":"config"}`,
+		`{"name":"negative","cacheBytes":-1}`,
+		`{"name":"no_self","peers":["http://127.0.0.1:8080"]}`,
+		`{"name":"other_self","self":"http://127.0.0.1:8080"}`,
+	} {
+		_, err = cache.NewCache("groupcache", config)
+		code, ok := berror.FromError(err)
+		assert.True(t, ok, config)
+		assert.Equal(t, cache.InvalidGroupCacheCfg, code, config)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// LoadingCache is a Cache which loads the missing keys on demand, it's implemented by SingleflightCache
+type LoadingCache interface {
+	Cache
+	// GetOrLoad returns the cached value of key, or loads it by loader and puts it with ttl.
+	// The concurrent loads of the same key are deduplicated, so only one loader is called.
+	GetOrLoad(ctx context.Context, key string, ttl time.Duration,
+		loader func(ctx context.Context) (any, error)) (any, error)
+}
+
+// NotFoundValue is the type of NotFound
+type NotFoundValue string
+
+// NotFound is cached as the value of the missing keys, see WithNegativeTTL.
+// The loading caches return ErrKeyNotExist instead of it.
+const NotFound NotFoundValue = "beego: cache: not found"
+
+// IsNotFound checks whether val is NotFound,
+// the string and []byte are accepted because the remote adapters may not keep the type.
+func IsNotFound(val any) bool {
+	switch v := val.(type) {
+	case NotFoundValue:
+		return v == NotFound
+	case string:
+		return v == string(NotFound)
+	case []byte:
+		return string(v) == string(NotFound)
+	}
+	return false
+}
+
+// LoadOption configures the loading of SingleflightCache and the read-through cache
+type LoadOption func(o *loadOptions)
+
+type loadOptions struct {
+	negativeTTL time.Duration
+}
+
+// WithNegativeTTL caches NotFound for ttl when the loader returns ErrKeyNotExist,
+// so the missing key returns ErrKeyNotExist without calling the loader until ttl passes.
+func WithNegativeTTL(ttl time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.negativeTTL = ttl
+	}
+}
+
+func newLoadOptions(opts []LoadOption) loadOptions {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// load calls loader and puts the value of key with ttl.
+// If the loader returns ErrKeyNotExist, NotFound is put with the negative TTL if it's set.
+func (o loadOptions) load(ctx context.Context, c Cache, key string, ttl time.Duration,
+	loader func(ctx context.Context) (any, error),
+) (any, error) {
+	v, err := loader(ctx)
+	if o.negativeTTL > 0 && errors.Is(err, ErrKeyNotExist) {
+		if err = c.Put(ctx, key, NotFound, o.negativeTTL); err != nil {
+			return nil, err
+		}
+		return nil, ErrKeyNotExist
+	}
+	if err != nil {
+		return nil, berror.Wrap(err, LoadFuncFailed, "cache unable to load data")
+	}
+	return v, c.Put(ctx, key, v, ttl)
+}
+
+// cached returns the cached value of key, or ErrKeyNotExist if it's cached as NotFound.
+// Both are nil if the key should be loaded.
+func cached(ctx context.Context, c Cache, key string) (any, error) {
+	val, err := c.Get(ctx, key)
+	if val == nil || err != nil {
+		return nil, nil
+	}
+	if IsNotFound(val) {
+		return nil, ErrKeyNotExist
+	}
+	return val, nil
+}
+
+// getMulti returns nil and the error of ErrKeyNotExist for the keys cached as NotFound.
+func getMulti(ctx context.Context, c Cache, keys []string) ([]any, error) {
+	vals, err := c.GetMulti(ctx, keys)
+	keysErr := make([]string, 0)
+	for i, val := range vals {
+		if IsNotFound(val) {
+			vals[i] = nil
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", keys[i], ErrKeyNotExist.Error()))
+		}
+	}
+	if len(keysErr) == 0 {
+		return vals, err
+	}
+	if err != nil {
+		keysErr = append([]string{err.Error()}, keysErr...)
+	}
+	return vals, berror.Error(MultiGetFailed, strings.Join(keysErr, "; "))
+}
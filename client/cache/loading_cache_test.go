@@ -0,0 +1,172 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// newLoadingCache returns the SingleflightCache whose loadFunc isn't used
+func newLoadingCache(t *testing.T, bm Cache, opts ...LoadOption) LoadingCache {
+	c, err := NewSingleflightCache(bm, time.Minute, func(ctx context.Context, key string) (any, error) {
+		return nil, ErrKeyNotExist
+	}, opts...)
+	assert.Nil(t, err)
+	return c.(LoadingCache)
+}
+
+func TestSingleflightCache_GetOrLoad(t *testing.T) {
+	bm, err := NewCache("memory", `{"interval":20}`)
+	assert.Nil(t, err)
+	c := newLoadingCache(t, bm)
+	ctx := context.Background()
+
+	var loads int32
+	loader := func(ctx context.Context) (any, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(50 * time.Millisecond)
+		return "value", nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := c.GetOrLoad(ctx, "key", time.Minute, loader)
+			assert.Nil(t, err)
+			assert.Equal(t, "value", val)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	val, err := c.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", val)
+	val, err = c.GetOrLoad(ctx, "key", time.Minute, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, "value", val)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	_, err = c.GetOrLoad(ctx, "broken", time.Minute, func(ctx context.Context) (any, error) {
+		return nil, errors.New("db is down")
+	})
+	code, ok := berror.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, LoadFuncFailed, code)
+	exist, _ := c.IsExist(ctx, "broken")
+	assert.False(t, exist)
+
+	_, err = c.GetOrLoad(ctx, "key", time.Minute, nil)
+	code, _ = berror.FromError(err)
+	assert.Equal(t, InvalidLoadFunc, code)
+}
+
+func TestSingleflightCache_NegativeTTL(t *testing.T) {
+	bm, err := NewCache("memory", `{"interval":20}`)
+	assert.Nil(t, err)
+	c := newLoadingCache(t, bm, WithNegativeTTL(time.Second))
+	ctx := context.Background()
+
+	var loads int32
+	loader := func(ctx context.Context) (any, error) {
+		atomic.AddInt32(&loads, 1)
+		return nil, ErrKeyNotExist
+	}
+	for i := 0; i < 3; i++ {
+		_, err = c.GetOrLoad(ctx, "missing", time.Minute, loader)
+		assert.True(t, errors.Is(err, ErrKeyNotExist))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	val, err := bm.Get(ctx, "missing")
+	assert.Nil(t, err)
+	assert.True(t, IsNotFound(val))
+	_, err = c.Get(ctx, "missing")
+	assert.True(t, errors.Is(err, ErrKeyNotExist))
+	assert.Nil(t, c.Put(ctx, "exist", "value", time.Minute))
+	vals, err := c.GetMulti(ctx, []string{"missing", "exist"})
+	assert.Equal(t, []any{nil, "value"}, vals)
+	assert.True(t, strings.Contains(err.Error(), "key [missing] error"))
+
+	time.Sleep(1500 * time.Millisecond)
+	_, err = c.GetOrLoad(ctx, "missing", time.Minute, loader)
+	assert.True(t, errors.Is(err, ErrKeyNotExist))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loads))
+
+	// without negative caching the missing key is loaded every time
+	c = newLoadingCache(t, bm)
+	_, err = c.GetOrLoad(ctx, "missing2", time.Minute, loader)
+	assert.True(t, errors.Is(err, ErrKeyNotExist))
+	_, err = c.GetOrLoad(ctx, "missing2", time.Minute, loader)
+	assert.True(t, errors.Is(err, ErrKeyNotExist))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&loads))
+}
+
+func TestReadThroughCache_NegativeTTL(t *testing.T) {
+	bm := NewMemoryCache()
+	var loads int32
+	c, err := NewReadThroughCache(bm, time.Minute, func(ctx context.Context, key string) (any, error) {
+		atomic.AddInt32(&loads, 1)
+		return nil, ErrKeyNotExist
+	}, WithNegativeTTL(time.Minute))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err = c.Get(ctx, "missing")
+		assert.True(t, errors.Is(err, ErrKeyNotExist))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	vals, err := c.GetMulti(ctx, []string{"missing"})
+	assert.Equal(t, []any{nil}, vals)
+	assert.NotNil(t, err)
+}
+
+func TestIsNotFound(t *testing.T) {
+	assert.True(t, IsNotFound(NotFound))
+	assert.True(t, IsNotFound(string(NotFound)))
+	assert.True(t, IsNotFound([]byte(NotFound)))
+	assert.False(t, IsNotFound("value"))
+	assert.False(t, IsNotFound(nil))
+}
+
+func ExampleSingleflightCache_GetOrLoad() {
+	c, err := NewSingleflightCache(NewMemoryCache(), time.Minute, func(ctx context.Context, key string) (any, error) {
+		return nil, ErrKeyNotExist
+	})
+	if err != nil {
+		panic(err)
+	}
+	val, err := c.(LoadingCache).GetOrLoad(context.Background(), "Beego", time.Minute, func(ctx context.Context) (any, error) {
+		return "hello, Beego", nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(val)
+	// Output:
+	// hello, Beego
+}
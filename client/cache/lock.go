@@ -0,0 +1,235 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// DefaultLockRetryInterval is the interval between the attempts of Lock if WithLockRetryInterval isn't used
+const DefaultLockRetryInterval = 100 * time.Millisecond
+
+var (
+	// ErrLockNotObtained is returned by TryLock if the key is locked by the others
+	ErrLockNotObtained = berror.Error(LockNotObtained, "the lock is held by the others")
+	// ErrLockNotHeld is returned by Unlock if the lock is expired or released already
+	ErrLockNotHeld = berror.Error(LockNotHeld, "the lock isn't held any more")
+)
+
+// Locker is implemented by the adapters which support the distributed lock.
+// The lock is the key whose value is the random token of the owner, so only the owner releases or extends it.
+type Locker interface {
+	// AcquireLock sets key to token with ttl if key doesn't exist, it returns false if key exists
+	AcquireLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+	// ExtendLock resets the ttl of key if its value is token, it returns false if it isn't
+	ExtendLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+	// ReleaseLock deletes key if its value is token, it returns false if it isn't
+	ReleaseLock(ctx context.Context, key string, token string) (bool, error)
+}
+
+// LockOption configures Lock and TryLock
+type LockOption func(l *DistributedLock)
+
+// WithLockRetryInterval sets the interval between the attempts of Lock
+func WithLockRetryInterval(interval time.Duration) LockOption {
+	return func(l *DistributedLock) {
+		l.retryInterval = interval
+	}
+}
+
+// WithHeartbeat extends the lock every interval until it's released, the heartbeat is disabled if interval is 0.
+// The interval is a third of ttl by default.
+func WithHeartbeat(interval time.Duration) LockOption {
+	return func(l *DistributedLock) {
+		l.heartbeat = interval
+	}
+}
+
+// DistributedLock is the lock held by Lock or TryLock, it must be released by Unlock.
+// usage:
+//
+//	l, err := cache.Lock(ctx, bm, "lock:report", 10*time.Second)
+//	if err != nil {
+//		return err
+//	}
+//	defer l.Unlock(context.Background())
+//	select {
+//	case <-l.Lost():
+//		// the lock is taken by the others because the heartbeat failed
+//	default:
+//	}
+type DistributedLock struct {
+	locker        Locker
+	key           string
+	token         string
+	ttl           time.Duration
+	retryInterval time.Duration
+	heartbeat     time.Duration
+
+	stop     chan struct{}
+	stopped  chan struct{}
+	lost     chan struct{}
+	lostOnce sync.Once
+	stopOnce sync.Once
+}
+
+// Lock blocks until key is locked with ttl or ctx is done.
+// c must be a Locker, e.g. the memory, redis and memcache adapters.
+func Lock(ctx context.Context, c Cache, key string, ttl time.Duration, opts ...LockOption) (*DistributedLock, error) {
+	l, err := newDistributedLock(c, key, ttl, opts)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		ok, err := l.locker.AcquireLock(ctx, key, l.token, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			l.start()
+			return l, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.retryInterval):
+		}
+	}
+}
+
+// TryLock locks key with ttl, it returns ErrLockNotObtained immediately if key is locked by the others.
+func TryLock(ctx context.Context, c Cache, key string, ttl time.Duration, opts ...LockOption) (*DistributedLock, error) {
+	l, err := newDistributedLock(c, key, ttl, opts)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := l.locker.AcquireLock(ctx, key, l.token, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockNotObtained
+	}
+	l.start()
+	return l, nil
+}
+
+func newDistributedLock(c Cache, key string, ttl time.Duration, opts []LockOption) (*DistributedLock, error) {
+	locker, ok := c.(Locker)
+	if !ok {
+		return nil, berror.Errorf(LockNotSupported, "%T doesn't support the distributed lock", c)
+	}
+	if ttl <= 0 {
+		return nil, berror.Errorf(LockNotSupported, "the ttl of the lock must be positive: %s", ttl)
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	l := &DistributedLock{
+		locker:        locker,
+		key:           key,
+		token:         hex.EncodeToString(token),
+		ttl:           ttl,
+		retryInterval: DefaultLockRetryInterval,
+		heartbeat:     ttl / 3,
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		lost:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l, nil
+}
+
+// start runs the heartbeat
+func (l *DistributedLock) start() {
+	if l.heartbeat <= 0 {
+		close(l.stopped)
+		return
+	}
+	go func() {
+		defer close(l.stopped)
+		ticker := time.NewTicker(l.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), l.heartbeat)
+			ok, err := l.locker.ExtendLock(ctx, l.key, l.token, l.ttl)
+			cancel()
+			if err == nil && !ok {
+				l.markLost()
+				return
+			}
+			// the error may be temporary, so it's retried until the lock expires
+		}
+	}()
+}
+
+func (l *DistributedLock) markLost() {
+	l.lostOnce.Do(func() {
+		close(l.lost)
+	})
+}
+
+// Key returns the locked key
+func (l *DistributedLock) Key() string {
+	return l.key
+}
+
+// Lost is closed when the heartbeat finds that the lock is expired and may be held by the others
+func (l *DistributedLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Extend resets the ttl of the lock, it returns ErrLockNotHeld if the lock is expired.
+func (l *DistributedLock) Extend(ctx context.Context) error {
+	ok, err := l.locker.ExtendLock(ctx, l.key, l.token, l.ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		l.markLost()
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Unlock stops the heartbeat and releases the lock, it returns ErrLockNotHeld if the lock is expired.
+func (l *DistributedLock) Unlock(ctx context.Context) error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	<-l.stopped
+	ok, err := l.locker.ReleaseLock(ctx, l.key, l.token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
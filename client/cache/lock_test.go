@@ -0,0 +1,85 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+func TestLock(t *testing.T) {
+	bm := NewMemoryCache()
+	ctx := context.Background()
+
+	l, err := TryLock(ctx, bm, "lock", time.Second, WithHeartbeat(0))
+	assert.Nil(t, err)
+	assert.Equal(t, "lock", l.Key())
+	_, err = TryLock(ctx, bm, "lock", time.Second)
+	assert.True(t, errors.Is(err, ErrLockNotObtained))
+
+	// Lock waits until the lock is released
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		assert.Nil(t, l.Unlock(ctx))
+		close(released)
+	}()
+	l2, err := Lock(ctx, bm, "lock", time.Second, WithLockRetryInterval(10*time.Millisecond))
+	assert.Nil(t, err)
+	<-released
+	assert.True(t, errors.Is(l.Unlock(ctx), ErrLockNotHeld))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = Lock(timeoutCtx, bm, "lock", time.Second, WithLockRetryInterval(10*time.Millisecond))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Nil(t, l2.Unlock(ctx))
+
+	_, err = Lock(ctx, struct{ Cache }{bm}, "lock", time.Second)
+	code, _ := berror.FromError(err)
+	assert.Equal(t, LockNotSupported, code)
+}
+
+func TestLockHeartbeat(t *testing.T) {
+	bm := NewMemoryCache()
+	ctx := context.Background()
+
+	l, err := TryLock(ctx, bm, "lock", 60*time.Millisecond, WithHeartbeat(20*time.Millisecond))
+	assert.Nil(t, err)
+	time.Sleep(150 * time.Millisecond)
+	// the heartbeat keeps the lock
+	_, err = TryLock(ctx, bm, "lock", time.Second)
+	assert.True(t, errors.Is(err, ErrLockNotObtained))
+	assert.Nil(t, l.Extend(ctx))
+
+	// the heartbeat finds that the lock is taken by the others
+	assert.Nil(t, bm.Delete(ctx, "lock"))
+	other, err := TryLock(ctx, bm, "lock", time.Second, WithHeartbeat(0))
+	assert.Nil(t, err)
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("the lost lock isn't detected")
+	}
+	assert.True(t, errors.Is(l.Unlock(ctx), ErrLockNotHeld))
+	assert.True(t, errors.Is(l.Extend(ctx), ErrLockNotHeld))
+	assert.Nil(t, other.Unlock(ctx))
+}
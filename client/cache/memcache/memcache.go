@@ -27,13 +27,18 @@
 // )
 //
 //	bm, err := cache.NewCache("memcache", `{"conn":"127.0.0.1:11211"}`)
+//	bm, err := cache.NewCache("memcache", `{"conn":"127.0.0.1:11211","codec":"json"}`)
+//	bm, err := cache.NewCache("memcache", `{"conn":"127.0.0.1:11211","compression":"gzip","compressThreshold":"4096"}`)
 package memcache
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -46,6 +51,10 @@ import (
 type Cache struct {
 	conn     *memcache.Client
 	conninfo []string
+	// codec encodes the values, the values must be string or []byte if it's nil
+	codec cache.Codec
+	// compressor compresses the large values, they aren't compressed if it's nil
+	compressor *cache.Compressor
 }
 
 // NewMemCache creates a new memcache adapter.
@@ -56,7 +65,7 @@ func NewMemCache() cache.Cache {
 // Get get value from memcache.
 func (rc *Cache) Get(ctx context.Context, key string) (interface{}, error) {
 	if item, err := rc.conn.Get(key); err == nil {
-		return item.Value, nil
+		return rc.decode(item.Value)
 	} else {
 		return nil, berror.Wrapf(err, cache.MemCacheCurdFailed,
 			"could not read data from memcache, please check your key, network and connection. Root cause: %s",
@@ -82,7 +91,12 @@ func (rc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, er
 			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", ki, "key not exist"))
 			continue
 		}
-		rv[i] = mv[ki].Value
+		v, err := rc.decode(mv[ki].Value)
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", ki, err.Error()))
+			continue
+		}
+		rv[i] = v
 	}
 
 	if len(keysErr) == 0 {
@@ -93,17 +107,161 @@ func (rc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, er
 
 // Put puts a value into memcache.
 func (rc *Cache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
-	item := memcache.Item{Key: key, Expiration: int32(timeout / time.Second)}
-	if v, ok := val.([]byte); ok {
+	item, err := rc.newItem(key, val, timeout)
+	if err != nil {
+		return err
+	}
+	return berror.Wrapf(rc.conn.Set(item), cache.MemCacheCurdFailed,
+		"could not put key-value to memcache, key: %s", key)
+}
+
+// newItem encodes val by the codec, then compresses it
+func (rc *Cache) newItem(key string, val interface{}, timeout time.Duration) (*memcache.Item, error) {
+	item := &memcache.Item{Key: key, Expiration: int32(timeout / time.Second)}
+	if rc.codec != nil {
+		v, err := cache.EncodeValue(rc.codec, val)
+		if err != nil {
+			return nil, err
+		}
+		item.Value = v
+	} else if v, ok := val.([]byte); ok {
 		item.Value = v
 	} else if str, ok := val.(string); ok {
 		item.Value = []byte(str)
 	} else {
-		return berror.Errorf(cache.InvalidMemCacheValue,
+		return nil, berror.Errorf(cache.InvalidMemCacheValue,
 			"the value must be string or byte[]. key: %s, value:%v", key, val)
 	}
-	return berror.Wrapf(rc.conn.Set(&item), cache.MemCacheCurdFailed,
-		"could not put key-value to memcache, key: %s", key)
+	if rc.compressor != nil {
+		v, err := rc.compressor.Compress(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		item.Value = v
+	}
+	return item, nil
+}
+
+// PutMulti puts vals[i] by keys[i], see cache.MultiCache.
+// The memcache client doesn't pipeline the writes, so they're sent concurrently by multiConcurrency connections at most.
+func (rc *Cache) PutMulti(ctx context.Context, keys []string, vals []interface{}, timeout time.Duration) error {
+	if len(keys) != len(vals) {
+		return berror.Errorf(cache.InvalidMultiValues, "%d keys but %d values", len(keys), len(vals))
+	}
+	items := make([]*memcache.Item, len(keys))
+	for i, key := range keys {
+		item, err := rc.newItem(key, vals[i], timeout)
+		if err != nil {
+			return err
+		}
+		items[i] = item
+	}
+	return forEachKey(ctx, keys, cache.MultiPutFailed, func(i int) error {
+		return rc.conn.Set(items[i])
+	})
+}
+
+// DeleteMulti deletes keys concurrently by multiConcurrency connections at most, see cache.MultiCache.
+func (rc *Cache) DeleteMulti(ctx context.Context, keys []string) error {
+	return forEachKey(ctx, keys, cache.MultiDeleteFailed, func(i int) error {
+		if err := rc.conn.Delete(keys[i]); err != memcache.ErrCacheMiss {
+			return err
+		}
+		return nil
+	})
+}
+
+// multiConcurrency is the max number of the concurrent requests of PutMulti and DeleteMulti
+const multiConcurrency = 16
+
+// forEachKey calls fn for the indexes of keys concurrently, the failed keys are joined into the error of code
+func forEachKey(ctx context.Context, keys []string, code berror.Code, fn func(i int) error) error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		keysErr = make([]string, 0)
+		sem     = make(chan struct{}, multiConcurrency)
+	)
+	for i := range keys {
+		if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return err
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", keys[i], err.Error()))
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(keysErr) == 0 {
+		return nil
+	}
+	return berror.Error(code, strings.Join(keysErr, "; "))
+}
+
+// GetInto decodes the value of key into v by the codec.
+func (rc *Cache) GetInto(ctx context.Context, key string, v interface{}) error {
+	if rc.codec == nil {
+		return berror.Error(cache.InvalidCodec, "the codec of memcache isn't set")
+	}
+	item, err := rc.conn.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return cache.ErrKeyNotExist
+	}
+	if err != nil {
+		return berror.Wrapf(err, cache.MemCacheCurdFailed,
+			"could not read data from memcache, please check your key, network and connection. Root cause: %s",
+			err.Error())
+	}
+	data := item.Value
+	if rc.compressor != nil {
+		if data, err = cache.Decompress(data); err != nil {
+			return err
+		}
+	}
+	return cache.DecodeValue(rc.codec, data, v)
+}
+
+// SetCodec sets the codec of the values, it should be called before the cache is used.
+func (rc *Cache) SetCodec(c cache.Codec) {
+	rc.codec = c
+}
+
+// Codec returns the codec of the values, it's nil if the codec isn't set
+func (rc *Cache) Codec() cache.Codec {
+	return rc.codec
+}
+
+// SetCompressor compresses the large values by c, it should be called before the cache is used.
+func (rc *Cache) SetCompressor(c *cache.Compressor) {
+	rc.compressor = c
+}
+
+// decode returns data itself if neither the codec nor the compressor is set
+func (rc *Cache) decode(data []byte) (interface{}, error) {
+	if rc.compressor != nil {
+		var err error
+		if data, err = cache.Decompress(data); err != nil {
+			return nil, err
+		}
+	}
+	if rc.codec == nil {
+		return data, nil
+	}
+	var v interface{}
+	if err := cache.DecodeValue(rc.codec, data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
 // Delete deletes a value in memcache.
@@ -126,6 +284,103 @@ func (rc *Cache) Decr(ctx context.Context, key string) error {
 		"could not decrease value for key: %s", key)
 }
 
+// IncrBy adds delta to the counter in memcache atomically by CAS, see cache.CounterCache.
+// It retries until no other client changes the counter between the read and the write, or ctx is done.
+// The counter is stored as the decimal string, and its deadline is kept in the flags,
+// so the TTL isn't reset by the writes. The counter put by Put never expires after IncrBy.
+func (rc *Cache) IncrBy(ctx context.Context, key string, delta int64, opts ...cache.CounterOption) (int64, error) {
+	o := cache.NewCounterOptions(opts...)
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		item, err := rc.conn.Get(key)
+		if err == memcache.ErrCacheMiss {
+			n := o.Initial + delta
+			if !o.InBounds(n) {
+				return o.Initial, cache.ErrCounterOutOfBounds
+			}
+			item = &memcache.Item{Key: key, Value: []byte(strconv.FormatInt(n, 10))}
+			if o.TTL > 0 {
+				// the expiration larger than 30 days is the unix time, so the deadline is kept by CAS
+				deadline := time.Now().Add(o.TTL + time.Second - 1).Unix()
+				item.Flags, item.Expiration = uint32(deadline), int32(deadline)
+			}
+			if err = rc.conn.Add(item); err == memcache.ErrNotStored {
+				// created by the other client
+				continue
+			}
+			return n, berror.Wrapf(err, cache.MemCacheCurdFailed, "could not increase value for key: %s", key)
+		}
+		if err != nil {
+			return 0, berror.Wrapf(err, cache.MemCacheCurdFailed, "could not increase value for key: %s", key)
+		}
+		cur, err := strconv.ParseInt(strings.TrimSpace(string(item.Value)), 10, 64)
+		if err != nil {
+			return 0, cache.ErrNotIntegerType
+		}
+		if (delta > 0 && cur > math.MaxInt64-delta) || (delta < 0 && cur < math.MinInt64-delta) {
+			return 0, cache.ErrIncrementOverflow
+		}
+		n := cur + delta
+		if !o.InBounds(n) {
+			return cur, cache.ErrCounterOutOfBounds
+		}
+		item.Value = []byte(strconv.FormatInt(n, 10))
+		item.Expiration = int32(item.Flags)
+		err = rc.conn.CompareAndSwap(item)
+		if err == memcache.ErrCASConflict || err == memcache.ErrNotStored {
+			continue
+		}
+		return n, berror.Wrapf(err, cache.MemCacheCurdFailed, "could not increase value for key: %s", key)
+	}
+}
+
+// AcquireLock adds key with token, it's used by cache.Lock.
+// The ttl is rounded up to seconds because it's the precision of memcache.
+func (rc *Cache) AcquireLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	err := rc.conn.Add(&memcache.Item{Key: key, Value: []byte(token), Expiration: lockExpiration(ttl)})
+	if err == memcache.ErrNotStored {
+		return false, nil
+	}
+	return err == nil, berror.Wrapf(err, cache.MemCacheCurdFailed, "could not lock key: %s", key)
+}
+
+// ExtendLock resets the ttl of key by CAS if it's held by token.
+func (rc *Cache) ExtendLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return rc.casLock(key, token, lockExpiration(ttl))
+}
+
+// ReleaseLock expires key by CAS if it's held by token, so the lock taken by the others after the check isn't deleted.
+func (rc *Cache) ReleaseLock(ctx context.Context, key string, token string) (bool, error) {
+	// the negative expiration expires the item immediately
+	return rc.casLock(key, token, -1)
+}
+
+func (rc *Cache) casLock(key string, token string, expiration int32) (bool, error) {
+	item, err := rc.conn.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return false, nil
+	}
+	if err != nil {
+		return false, berror.Wrapf(err, cache.MemCacheCurdFailed, "could not read lock key: %s", key)
+	}
+	if string(item.Value) != token {
+		return false, nil
+	}
+	item.Expiration = expiration
+	err = rc.conn.CompareAndSwap(item)
+	if err == memcache.ErrCASConflict || err == memcache.ErrNotStored || err == memcache.ErrCacheMiss {
+		// the lock is expired and taken by the others
+		return false, nil
+	}
+	return err == nil, berror.Wrapf(err, cache.MemCacheCurdFailed, "could not update lock key: %s", key)
+}
+
+func lockExpiration(ttl time.Duration) int32 {
+	return int32((ttl + time.Second - 1) / time.Second)
+}
+
 // IsExist checks if a value exists in memcache.
 func (rc *Cache) IsExist(ctx context.Context, key string) (bool, error) {
 	_, err := rc.Get(ctx, key)
@@ -139,7 +394,8 @@ func (rc *Cache) ClearAll(context.Context) error {
 }
 
 // StartAndGC starts the memcache adapter.
-// config: must be in the format {"conn":"connection info"}.
+// config: must be in the format {"conn":"connection info","codec":"codec name","compression":"gzip","compressThreshold":"1024"},
+// the codec and the compression are optional.
 // If an error occurs during connecting, an error is returned
 func (rc *Cache) StartAndGC(config string) error {
 	var cf map[string]string
@@ -151,6 +407,27 @@ func (rc *Cache) StartAndGC(config string) error {
 	if _, ok := cf["conn"]; !ok {
 		return berror.Errorf(cache.InvalidMemCacheCfg, `config must contains "conn" field: %s`, config)
 	}
+	if name, ok := cf["codec"]; ok {
+		c, err := cache.GetCodec(name)
+		if err != nil {
+			return err
+		}
+		rc.codec = c
+	}
+	if name, ok := cf["compression"]; ok {
+		threshold := 0
+		if str, ok := cf["compressThreshold"]; ok {
+			var err error
+			if threshold, err = strconv.Atoi(str); err != nil {
+				return berror.Wrapf(err, cache.InvalidCompression, "invalid compressThreshold: %s", str)
+			}
+		}
+		c, err := cache.NewCompressor(name, threshold)
+		if err != nil {
+			return err
+		}
+		rc.compressor = c
+	}
 	rc.conninfo = strings.Split(cf["conn"], ";")
 	rc.conn = memcache.New(rc.conninfo...)
 	return nil
@@ -114,6 +114,89 @@ func TestMemcacheCache(t *testing.T) {
 	// test clear all
 }
 
+func TestMemcacheIncrBy(t *testing.T) {
+	addr := os.Getenv("MEMCACHE_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:11211"
+	}
+	bm, err := cache.NewCache("memcache", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	cc := bm.(cache.CounterCache)
+	_ = cc.Delete(ctx, "rate")
+
+	n, err := cc.IncrBy(ctx, "rate", 1, cache.WithInitial(10), cache.WithCounterTTL(2*time.Second), cache.WithCeiling(12))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), n)
+	n, err = cc.IncrBy(ctx, "rate", 1, cache.WithCeiling(12))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), n)
+	n, err = cc.IncrBy(ctx, "rate", -20, cache.WithFloor(0))
+	assert.True(t, errors.Is(err, cache.ErrCounterOutOfBounds))
+	assert.Equal(t, int64(12), n)
+
+	// the TTL is kept by CAS
+	time.Sleep(3 * time.Second)
+	res, _ := cc.IsExist(ctx, "rate")
+	assert.False(t, res)
+}
+
+func TestMemcacheLock(t *testing.T) {
+	addr := os.Getenv("MEMCACHE_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:11211"
+	}
+	bm, err := cache.NewCache("memcache", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	_ = bm.Delete(ctx, "lock")
+
+	l, err := cache.TryLock(ctx, bm, "lock", 2*time.Second, cache.WithHeartbeat(500*time.Millisecond))
+	assert.Nil(t, err)
+	_, err = cache.TryLock(ctx, bm, "lock", time.Second)
+	assert.True(t, errors.Is(err, cache.ErrLockNotObtained))
+	// the heartbeat keeps the lock
+	time.Sleep(3 * time.Second)
+	res, _ := bm.IsExist(ctx, "lock")
+	assert.True(t, res)
+	assert.Nil(t, l.Unlock(ctx))
+	res, _ = bm.IsExist(ctx, "lock")
+	assert.False(t, res)
+
+	l, err = cache.Lock(ctx, bm, "lock", time.Second)
+	assert.Nil(t, err)
+	// the lock held by the others isn't released
+	assert.Nil(t, bm.Put(ctx, "lock", "other", time.Minute))
+	assert.True(t, errors.Is(l.Unlock(ctx), cache.ErrLockNotHeld))
+	assert.Nil(t, bm.Delete(ctx, "lock"))
+}
+
+func TestMemcacheMulti(t *testing.T) {
+	addr := os.Getenv("MEMCACHE_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:11211"
+	}
+	bm, err := cache.NewCache("memcache", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	keys := []string{"k1", "k2", "k3"}
+	assert.Nil(t, cache.PutMulti(ctx, bm, keys, []interface{}{"v1", "v2", "v3"}, time.Minute))
+	res, err := bm.GetMulti(ctx, keys)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{[]byte("v1"), []byte("v2"), []byte("v3")}, res)
+
+	err = cache.PutMulti(ctx, bm, keys, []interface{}{"v1", 2, "v3"}, time.Minute)
+	code, _ := berror.FromError(err)
+	assert.Equal(t, cache.InvalidMemCacheValue, code)
+
+	// the missing keys are ignored
+	assert.Nil(t, cache.DeleteMulti(ctx, bm, []string{"k1", "k2", "missing"}))
+	res, _ = bm.GetMulti(ctx, keys)
+	assert.Equal(t, []interface{}{nil, nil, []byte("v3")}, res)
+	assert.Nil(t, bm.Delete(ctx, "k3"))
+}
+
 func TestReadThroughCache_Memcache_Get(t *testing.T) {
 	bm, err := cache.NewCache("memcache", fmt.Sprintf(`{"conn": "%s"}`, "127.0.0.1:11211"))
 	assert.Nil(t, err)
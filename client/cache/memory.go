@@ -33,6 +33,8 @@ type MemoryItem struct {
 	val         interface{}
 	createdTime time.Time
 	lifespan    time.Duration
+	tags        []string
+	cost        int64
 }
 
 func (mi *MemoryItem) isExpire() bool {
@@ -45,11 +47,42 @@ func (mi *MemoryItem) isExpire() bool {
 
 // MemoryCache is a memory cache adapter.
 // Contains a RW locker for safe map storage.
+// It's unbounded unless maxEntries or maxBytes is set, then the items are evicted by LRU or LFU.
 type MemoryCache struct {
 	sync.RWMutex
 	dur   time.Duration
 	items map[string]*MemoryItem
+	// tags indexes the keys by tag
+	tags map[string]map[string]struct{}
+	// keys indexes the keys by prefix, it's nil unless prefixIndex is configured
+	keys  *keyIndex
 	Every int // run an expiration check Every clock time
+
+	// Cost returns the cost of the item counted against maxBytes, the size of string and []byte is used by default.
+	// It must be set before StartAndGC
+	Cost func(key string, val interface{}) int64
+
+	// policy is nil if the cache is unbounded
+	policy     evictionPolicy
+	eviction   string
+	maxEntries int
+	maxBytes   int64
+	usedBytes  int64
+	evictions  uint64
+}
+
+type memoryConfig struct {
+	// Interval is the seconds between the expiration checks, DefaultEvery if it's not set and no check if it's 0
+	Interval *int `json:"interval"`
+	// MaxEntries is the max number of items, 0 means unlimited
+	MaxEntries int `json:"maxEntries"`
+	// MaxBytes is the max total cost of items, 0 means unlimited
+	MaxBytes int64 `json:"maxBytes"`
+	// Eviction is "lru" or "lfu", "lru" by default
+	Eviction string `json:"eviction"`
+	// PrefixIndex indexes the keys by a trie for DeleteByPrefix, whose memory is counted against MaxBytes.
+	// DeleteByPrefix scans all the keys without it
+	PrefixIndex bool `json:"prefixIndex"`
 }
 
 // NewMemoryCache returns a new MemoryCache.
@@ -62,11 +95,21 @@ func NewMemoryCache() Cache {
 // If non-existent or expired, return nil.
 func (bc *MemoryCache) Get(ctx context.Context, key string) (interface{}, error) {
 	bc.RLock()
-	defer bc.RUnlock()
+	if bc.policy != nil {
+		// the access is recorded by the eviction policy, so the lock is required
+		bc.RUnlock()
+		bc.Lock()
+		defer bc.Unlock()
+	} else {
+		defer bc.RUnlock()
+	}
 	if itm, ok := bc.items[key]; ok {
 		if itm.isExpire() {
 			return nil, ErrKeyExpired
 		}
+		if bc.policy != nil {
+			bc.policy.access(key)
+		}
 		return itm.val, nil
 	}
 	return nil, ErrKeyNotExist
@@ -96,12 +139,152 @@ func (bc *MemoryCache) GetMulti(ctx context.Context, keys []string) ([]interface
 // Put puts cache into memory.
 // If lifespan is 0, it will never overwrite this value unless restarted
 func (bc *MemoryCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	return bc.PutWithTags(ctx, key, val, timeout)
+}
+
+// PutMulti puts vals[i] by keys[i] into memory under one lock.
+func (bc *MemoryCache) PutMulti(ctx context.Context, keys []string, vals []interface{}, timeout time.Duration) error {
+	if len(keys) != len(vals) {
+		return berror.Errorf(InvalidMultiValues, "%d keys but %d values", len(keys), len(vals))
+	}
 	bc.Lock()
 	defer bc.Unlock()
+	var err error
+	for i, key := range keys {
+		if e := bc.putItem(key, vals[i], timeout, bc.cost(key, vals[i]), nil); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// PutWithTags puts cache into memory and indexes key by tags.
+// The tags of the old value are replaced.
+func (bc *MemoryCache) PutWithTags(ctx context.Context, key string, val interface{},
+	timeout time.Duration, tags ...string,
+) error {
+	return bc.put(key, val, timeout, bc.cost(key, val), tags)
+}
+
+// PutWithCost puts cache into memory with the cost counted against maxBytes instead of the one returned by Cost.
+func (bc *MemoryCache) PutWithCost(ctx context.Context, key string, val interface{},
+	timeout time.Duration, cost int64,
+) error {
+	return bc.put(key, val, timeout, cost, nil)
+}
+
+func (bc *MemoryCache) cost(key string, val interface{}) int64 {
+	if bc.Cost != nil {
+		return bc.Cost(key, val)
+	}
+	switch v := val.(type) {
+	case string:
+		return int64(len(key) + len(v))
+	case []byte:
+		return int64(len(key) + len(v))
+	}
+	return int64(len(key)) + 1
+}
+
+func (bc *MemoryCache) put(key string, val interface{}, timeout time.Duration, cost int64, tags []string) error {
+	bc.Lock()
+	defer bc.Unlock()
+	return bc.putItem(key, val, timeout, cost, tags)
+}
+
+// putItem replaces the item of key, the caller must hold the lock.
+// It returns ErrItemTooLarge if the cost exceeds maxBytes, and the old item is deleted.
+func (bc *MemoryCache) putItem(key string, val interface{}, timeout time.Duration, cost int64, tags []string) error {
+	bc.deleteItem(key)
+	if bc.maxBytes > 0 && cost+bc.keys.missingBytes(key) > bc.maxBytes {
+		// it would evict everything including itself
+		return ErrItemTooLarge
+	}
+	if bc.policy != nil {
+		// make room before adding the item, otherwise LFU always evicts the new one
+		bc.evict(1, cost+bc.keys.missingBytes(key))
+		bc.policy.add(key)
+	}
 	bc.items[key] = &MemoryItem{
 		val:         val,
 		createdTime: time.Now(),
 		lifespan:    timeout,
+		tags:        tags,
+		cost:        cost,
+	}
+	bc.usedBytes += cost
+	bc.keys.add(key)
+	for _, tag := range tags {
+		if bc.tags == nil {
+			bc.tags = make(map[string]map[string]struct{})
+		}
+		keys, ok := bc.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			bc.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	return nil
+}
+
+// evict deletes the items chosen by the eviction policy until the cache has room for
+// the number of entries and the cost, the caller must hold the lock
+func (bc *MemoryCache) evict(entries int, cost int64) {
+	for (bc.maxEntries > 0 && len(bc.items)+entries > bc.maxEntries) ||
+		(bc.maxBytes > 0 && bc.usedBytes+bc.keys.bytes()+cost > bc.maxBytes) {
+		key, ok := bc.policy.victim()
+		if !ok {
+			return
+		}
+		bc.deleteItem(key)
+		bc.evictions++
+	}
+}
+
+// Evictions returns how many items are evicted because the cache is full.
+func (bc *MemoryCache) Evictions() uint64 {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.evictions
+}
+
+// InvalidateTag deletes all the keys of tag in memory.
+func (bc *MemoryCache) InvalidateTag(ctx context.Context, tag string) error {
+	bc.Lock()
+	defer bc.Unlock()
+	for key := range bc.tags[tag] {
+		bc.deleteItem(key)
+	}
+	delete(bc.tags, tag)
+	return nil
+}
+
+// DeleteByPrefix deletes all the keys starting with prefix in memory,
+// the keys are found by the index if prefixIndex is configured, otherwise all the keys are scanned.
+func (bc *MemoryCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	bc.Lock()
+	defer bc.Unlock()
+	if bc.keys != nil {
+		for _, key := range bc.keys.withPrefix(prefix) {
+			bc.deleteItem(key)
+		}
+		return nil
+	}
+	for key := range bc.items {
+		if strings.HasPrefix(key, prefix) {
+			bc.deleteItem(key)
+		}
+	}
+	return nil
+}
+
+// DeleteMulti deletes keys from memory under one lock.
+func (bc *MemoryCache) DeleteMulti(ctx context.Context, keys []string) error {
+	bc.Lock()
+	defer bc.Unlock()
+	for _, key := range keys {
+		bc.deleteItem(key)
 	}
 	return nil
 }
@@ -111,10 +294,32 @@ func (bc *MemoryCache) Put(ctx context.Context, key string, val interface{}, tim
 func (bc *MemoryCache) Delete(ctx context.Context, key string) error {
 	bc.Lock()
 	defer bc.Unlock()
-	delete(bc.items, key)
+	bc.deleteItem(key)
 	return nil
 }
 
+// deleteItem deletes key and removes it from the tag index, the caller must hold the lock
+func (bc *MemoryCache) deleteItem(key string) {
+	itm, ok := bc.items[key]
+	if !ok {
+		return
+	}
+	for _, tag := range itm.tags {
+		if keys, ok := bc.tags[tag]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(bc.tags, tag)
+			}
+		}
+	}
+	delete(bc.items, key)
+	bc.keys.remove(key)
+	bc.usedBytes -= itm.cost
+	if bc.policy != nil {
+		bc.policy.remove(key)
+	}
+}
+
 // Incr increases cache counter in memory.
 // Supports int,int32,int64,uint,uint32,uint64.
 func (bc *MemoryCache) Incr(ctx context.Context, key string) error {
@@ -133,6 +338,37 @@ func (bc *MemoryCache) Incr(ctx context.Context, key string) error {
 	return nil
 }
 
+// IncrBy adds delta to the counter in memory atomically, the new counter is int64.
+// Supports int,int32,int64,uint,uint32,uint64, and the type of the existing counter is kept.
+func (bc *MemoryCache) IncrBy(ctx context.Context, key string, delta int64, opts ...CounterOption) (int64, error) {
+	o := NewCounterOptions(opts...)
+	bc.Lock()
+	defer bc.Unlock()
+	itm, ok := bc.items[key]
+	if !ok || itm.isExpire() {
+		n := o.Initial + delta
+		if !o.InBounds(n) {
+			return o.Initial, ErrCounterOutOfBounds
+		}
+		if err := bc.putItem(key, n, o.TTL, bc.cost(key, n), nil); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	val, n, err := addInt(itm.val, delta)
+	if err != nil {
+		return 0, err
+	}
+	if !o.InBounds(n) {
+		return n - delta, ErrCounterOutOfBounds
+	}
+	itm.val = val
+	if bc.policy != nil {
+		bc.policy.access(key)
+	}
+	return n, nil
+}
+
 // Decr decreases counter in memory.
 func (bc *MemoryCache) Decr(ctx context.Context, key string) error {
 	bc.Lock()
@@ -150,6 +386,45 @@ func (bc *MemoryCache) Decr(ctx context.Context, key string) error {
 	return nil
 }
 
+// AcquireLock puts token if key doesn't exist, it's used by cache.Lock.
+// The lock may be evicted if maxEntries or maxBytes is set.
+func (bc *MemoryCache) AcquireLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	bc.Lock()
+	defer bc.Unlock()
+	if itm, ok := bc.items[key]; ok && !itm.isExpire() {
+		return false, nil
+	}
+	if err := bc.putItem(key, token, ttl, bc.cost(key, token), nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ExtendLock resets the ttl of key if its value is token.
+func (bc *MemoryCache) ExtendLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	bc.Lock()
+	defer bc.Unlock()
+	itm, ok := bc.items[key]
+	if !ok || itm.isExpire() || itm.val != token {
+		return false, nil
+	}
+	itm.createdTime = time.Now()
+	itm.lifespan = ttl
+	return true, nil
+}
+
+// ReleaseLock deletes key if its value is token.
+func (bc *MemoryCache) ReleaseLock(ctx context.Context, key string, token string) (bool, error) {
+	bc.Lock()
+	defer bc.Unlock()
+	itm, ok := bc.items[key]
+	if !ok || itm.isExpire() || itm.val != token {
+		return false, nil
+	}
+	bc.deleteItem(key)
+	return true, nil
+}
+
 // IsExist checks if cache exists in memory.
 func (bc *MemoryCache) IsExist(ctx context.Context, key string) (bool, error) {
 	bc.RLock()
@@ -165,22 +440,55 @@ func (bc *MemoryCache) ClearAll(context.Context) error {
 	bc.Lock()
 	defer bc.Unlock()
 	bc.items = make(map[string]*MemoryItem)
+	bc.tags = nil
+	if bc.keys != nil {
+		bc.keys = &keyIndex{}
+	}
+	bc.usedBytes = 0
+	if bc.policy != nil {
+		bc.policy, _ = newEvictionPolicy(bc.eviction)
+	}
 	return nil
 }
 
 // StartAndGC starts memory cache. Checks expiration in every clock time.
+// config is like {"interval":60,"maxEntries":10000,"maxBytes":104857600,"eviction":"lru","prefixIndex":true}
 func (bc *MemoryCache) StartAndGC(config string) error {
-	var cf map[string]int
+	var cf memoryConfig
 	if err := json.Unmarshal([]byte(config), &cf); err != nil {
 		return berror.Wrapf(err, InvalidMemoryCacheCfg, "invalid config, please check your input: %s", config)
 	}
-	if _, ok := cf["interval"]; !ok {
-		cf = make(map[string]int)
-		cf["interval"] = DefaultEvery
+	if cf.Interval == nil {
+		cf.Interval = &DefaultEvery
+	}
+	if cf.MaxEntries < 0 || cf.MaxBytes < 0 {
+		return berror.Errorf(InvalidMemoryCacheCfg, "invalid config, maxEntries and maxBytes must be positive: %s", config)
+	}
+	policy, ok := newEvictionPolicy(cf.Eviction)
+	if !ok {
+		return berror.Errorf(InvalidMemoryCacheCfg, "invalid config, unknown eviction: %s", cf.Eviction)
+	}
+
+	bc.Lock()
+	bc.Every = *cf.Interval
+	bc.dur = time.Duration(bc.Every) * time.Second
+	bc.maxEntries, bc.maxBytes, bc.eviction = cf.MaxEntries, cf.MaxBytes, cf.Eviction
+	bc.keys = nil
+	if cf.PrefixIndex {
+		bc.keys = &keyIndex{}
+		for key := range bc.items {
+			bc.keys.add(key)
+		}
+	}
+	bc.policy = nil
+	if bc.maxEntries > 0 || bc.maxBytes > 0 {
+		bc.policy = policy
+		for key := range bc.items {
+			policy.add(key)
+		}
+		bc.evict(0, 0)
 	}
-	dur := time.Duration(cf["interval"]) * time.Second
-	bc.Every = cf["interval"]
-	bc.dur = dur
+	bc.Unlock()
 	go bc.vacuum()
 	return nil
 }
@@ -225,7 +533,7 @@ func (bc *MemoryCache) clearItems(keys []string) {
 	bc.Lock()
 	defer bc.Unlock()
 	for _, key := range keys {
-		delete(bc.items, key)
+		bc.deleteItem(key)
 	}
 }
 
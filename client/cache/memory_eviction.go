@@ -0,0 +1,156 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+const (
+	// EvictionLRU evicts the least recently used item first
+	EvictionLRU = "lru"
+	// EvictionLFU evicts the least frequently used item first,
+	// the least recently used one is evicted if their frequencies are equal
+	EvictionLFU = "lfu"
+)
+
+// evictionPolicy tracks the keys of the bounded MemoryCache, it's guarded by the lock of MemoryCache
+type evictionPolicy interface {
+	// add tracks the new key
+	add(key string)
+	// access records that key is read
+	access(key string)
+	remove(key string)
+	// victim returns the key which should be evicted first
+	victim() (string, bool)
+}
+
+func newEvictionPolicy(name string) (evictionPolicy, bool) {
+	switch name {
+	case "", EvictionLRU:
+		return &lruPolicy{elements: make(map[string]*list.Element), keys: list.New()}, true
+	case EvictionLFU:
+		return &lfuPolicy{entries: make(map[string]*lfuEntry)}, true
+	}
+	return nil, false
+}
+
+// lruPolicy keeps the most recently used key at the front
+type lruPolicy struct {
+	elements map[string]*list.Element
+	keys     *list.List
+}
+
+func (p *lruPolicy) add(key string) {
+	p.elements[key] = p.keys.PushFront(key)
+}
+
+func (p *lruPolicy) access(key string) {
+	if e, ok := p.elements[key]; ok {
+		p.keys.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy) remove(key string) {
+	if e, ok := p.elements[key]; ok {
+		p.keys.Remove(e)
+		delete(p.elements, key)
+	}
+}
+
+func (p *lruPolicy) victim() (string, bool) {
+	if e := p.keys.Back(); e != nil {
+		return e.Value.(string), true
+	}
+	return "", false
+}
+
+type lfuEntry struct {
+	key  string
+	freq uint64
+	// tick orders the entries with the same freq by the last access
+	tick  uint64
+	index int
+}
+
+// lfuPolicy is a min-heap of the entries ordered by freq and tick
+type lfuPolicy struct {
+	heap    lfuHeap
+	entries map[string]*lfuEntry
+	tick    uint64
+}
+
+func (p *lfuPolicy) add(key string) {
+	p.tick++
+	e := &lfuEntry{key: key, freq: 1, tick: p.tick}
+	p.entries[key] = e
+	heap.Push(&p.heap, e)
+}
+
+func (p *lfuPolicy) access(key string) {
+	if e, ok := p.entries[key]; ok {
+		p.tick++
+		e.freq++
+		e.tick = p.tick
+		heap.Fix(&p.heap, e.index)
+	}
+}
+
+func (p *lfuPolicy) remove(key string) {
+	if e, ok := p.entries[key]; ok {
+		heap.Remove(&p.heap, e.index)
+		delete(p.entries, key)
+	}
+}
+
+func (p *lfuPolicy) victim() (string, bool) {
+	if len(p.heap) == 0 {
+		return "", false
+	}
+	return p.heap[0].key, true
+}
+
+type lfuHeap []*lfuEntry
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].tick < h[j].tick
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*lfuEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
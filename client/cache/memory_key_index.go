@@ -0,0 +1,119 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+// keyNodeSize is the estimated memory of a node of keyIndex, including its entry in the parent,
+// it's counted against maxBytes of MemoryCache
+const keyNodeSize = 80
+
+// keyIndex is a trie of the keys of MemoryCache, so the keys with a prefix are found
+// without scanning all the keys. It's guarded by the lock of MemoryCache.
+// It's only built if prefixIndex is configured, and the methods of the nil index do nothing.
+type keyIndex struct {
+	root  keyNode
+	nodes int64
+}
+
+type keyNode struct {
+	children map[byte]*keyNode
+	// terminal is true if a key ends at the node
+	terminal bool
+}
+
+// bytes returns the estimated memory of the index
+func (idx *keyIndex) bytes() int64 {
+	if idx == nil {
+		return 0
+	}
+	return idx.nodes * keyNodeSize
+}
+
+// missingBytes returns the estimated memory of the nodes which will be created by adding key
+func (idx *keyIndex) missingBytes(key string) int64 {
+	if idx == nil {
+		return 0
+	}
+	n := &idx.root
+	for i := 0; i < len(key); i++ {
+		child, ok := n.children[key[i]]
+		if !ok {
+			return int64(len(key)-i) * keyNodeSize
+		}
+		n = child
+	}
+	return 0
+}
+
+func (idx *keyIndex) add(key string) {
+	if idx == nil {
+		return
+	}
+	n := &idx.root
+	for i := 0; i < len(key); i++ {
+		if n.children == nil {
+			n.children = make(map[byte]*keyNode)
+		}
+		child, ok := n.children[key[i]]
+		if !ok {
+			child = &keyNode{}
+			n.children[key[i]] = child
+			idx.nodes++
+		}
+		n = child
+	}
+	n.terminal = true
+}
+
+func (idx *keyIndex) remove(key string) {
+	if idx == nil {
+		return
+	}
+	idx.root.remove(key, 0, &idx.nodes)
+}
+
+// remove unmarks key and prunes the empty nodes, it returns true if n is empty after that
+func (n *keyNode) remove(key string, depth int, nodes *int64) bool {
+	if depth == len(key) {
+		n.terminal = false
+	} else if child, ok := n.children[key[depth]]; ok && child.remove(key, depth+1, nodes) {
+		delete(n.children, key[depth])
+		*nodes--
+	}
+	return !n.terminal && len(n.children) == 0
+}
+
+// withPrefix returns all the keys starting with prefix
+func (idx *keyIndex) withPrefix(prefix string) []string {
+	n := &idx.root
+	for i := 0; i < len(prefix); i++ {
+		child, ok := n.children[prefix[i]]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	var keys []string
+	n.collect([]byte(prefix), &keys)
+	return keys
+}
+
+func (n *keyNode) collect(path []byte, keys *[]string) {
+	if n.terminal {
+		*keys = append(*keys, string(path))
+	}
+	for b, child := range n.children {
+		child.collect(append(path, b), keys)
+	}
+}
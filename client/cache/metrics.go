@@ -0,0 +1,256 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// LatencyBuckets are the upper bounds of the latency buckets of MetricsCache in seconds
+var LatencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// EvictionCounter is implemented by the adapters which report how many items are evicted for the capacity,
+// such as the bounded memory cache, freecache and ristretto
+type EvictionCounter interface {
+	Evictions() uint64
+}
+
+// Metrics is the snapshot of the metrics of MetricsCache
+type Metrics struct {
+	Name string `json:"name"`
+	// Hits and Misses count the keys read by Get and GetMulti
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// HitRatio is Hits / (Hits + Misses), it's 0 before any key is read
+	HitRatio float64 `json:"hitRatio"`
+	// Errors counts the failed operations, the missing keys aren't errors
+	Errors uint64 `json:"errors"`
+	// Evictions is reported by the adapter, it's 0 if the adapter isn't an EvictionCounter
+	Evictions uint64 `json:"evictions"`
+	// Operations counts all the operations, LatencySum is their total duration in seconds
+	Operations uint64  `json:"operations"`
+	LatencySum float64 `json:"latencySum"`
+	// LatencyBuckets are the cumulative counts of the operations by the upper bounds in LatencyBuckets
+	LatencyBuckets map[float64]uint64 `json:"-"`
+}
+
+var (
+	metricsLock   sync.RWMutex
+	metricsCaches = make(map[string]*MetricsCache)
+)
+
+// AllMetrics returns the metrics of all the MetricsCaches sorted by name
+func AllMetrics() []Metrics {
+	metricsLock.RLock()
+	res := make([]Metrics, 0, len(metricsCaches))
+	for _, c := range metricsCaches {
+		res = append(res, c.Metrics())
+	}
+	metricsLock.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// MetricsCache is a decorator counting the hits, misses, errors and latency of the operations of the original Cache.
+// The metrics are exposed by AllMetrics, and the admin server exports them to Prometheus and expvar.
+type MetricsCache struct {
+	Cache
+	name string
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errs   atomic.Uint64
+	ops    atomic.Uint64
+	// latencySum is in nanoseconds
+	latencySum atomic.Uint64
+	buckets    []atomic.Uint64
+}
+
+// NewMetricsCache creates MetricsCache and registers it by name, the one registered by the same name is replaced.
+// Call Unregister if the cache isn't used any more.
+func NewMetricsCache(name string, c Cache) *MetricsCache {
+	mc := &MetricsCache{Cache: c, name: name, buckets: make([]atomic.Uint64, len(LatencyBuckets))}
+	metricsLock.Lock()
+	metricsCaches[name] = mc
+	metricsLock.Unlock()
+	return mc
+}
+
+// Unregister removes the metrics of the cache from AllMetrics
+func (c *MetricsCache) Unregister() {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+	if metricsCaches[c.name] == c {
+		delete(metricsCaches, c.name)
+	}
+}
+
+// Metrics returns the snapshot of the metrics
+func (c *MetricsCache) Metrics() Metrics {
+	m := Metrics{
+		Name:           c.name,
+		Hits:           c.hits.Load(),
+		Misses:         c.misses.Load(),
+		Errors:         c.errs.Load(),
+		Operations:     c.ops.Load(),
+		LatencySum:     time.Duration(c.latencySum.Load()).Seconds(),
+		LatencyBuckets: make(map[float64]uint64, len(LatencyBuckets)),
+	}
+	if total := m.Hits + m.Misses; total > 0 {
+		m.HitRatio = float64(m.Hits) / float64(total)
+	}
+	if ec, ok := c.Cache.(EvictionCounter); ok {
+		m.Evictions = ec.Evictions()
+	}
+	var cumulative uint64
+	for i, bound := range LatencyBuckets {
+		cumulative += c.buckets[i].Load()
+		m.LatencyBuckets[bound] = cumulative
+	}
+	return m
+}
+
+// observe records the latency of the operation started at start, and counts err unless it's a miss
+func (c *MetricsCache) observe(start time.Time, err error) {
+	d := time.Since(start)
+	c.ops.Add(1)
+	c.latencySum.Add(uint64(d))
+	if i := sort.SearchFloat64s(LatencyBuckets, d.Seconds()); i < len(LatencyBuckets) {
+		c.buckets[i].Add(1)
+	}
+	if err != nil && !isMiss(err) {
+		c.errs.Add(1)
+	}
+}
+
+func isMiss(err error) bool {
+	if errors.Is(err, ErrKeyNotExist) || errors.Is(err, ErrKeyExpired) {
+		return true
+	}
+	code, ok := berror.FromError(err)
+	return ok && code == MultiGetFailed
+}
+
+// Get counts a hit if the value isn't nil.
+func (c *MetricsCache) Get(ctx context.Context, key string) (interface{}, error) {
+	start := time.Now()
+	val, err := c.Cache.Get(ctx, key)
+	c.observe(start, err)
+	if val != nil && err == nil {
+		c.hits.Add(1)
+	} else if err == nil || isMiss(err) {
+		c.misses.Add(1)
+	}
+	return val, err
+}
+
+// GetMulti counts a hit for every value which isn't nil.
+func (c *MetricsCache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	start := time.Now()
+	vals, err := c.Cache.GetMulti(ctx, keys)
+	c.observe(start, err)
+	if err == nil || isMiss(err) {
+		var hits uint64
+		for _, v := range vals {
+			if v != nil {
+				hits++
+			}
+		}
+		c.hits.Add(hits)
+		c.misses.Add(uint64(len(keys)) - hits)
+	}
+	return vals, err
+}
+
+// Put records the latency and the error of the original Put.
+func (c *MetricsCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	start := time.Now()
+	err := c.Cache.Put(ctx, key, val, timeout)
+	c.observe(start, err)
+	return err
+}
+
+// Delete records the latency and the error of the original Delete.
+func (c *MetricsCache) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.Cache.Delete(ctx, key)
+	c.observe(start, err)
+	return err
+}
+
+// Incr records the latency and the error of the original Incr.
+func (c *MetricsCache) Incr(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.Cache.Incr(ctx, key)
+	c.observe(start, err)
+	return err
+}
+
+// Decr records the latency and the error of the original Decr.
+func (c *MetricsCache) Decr(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.Cache.Decr(ctx, key)
+	c.observe(start, err)
+	return err
+}
+
+// IsExist records the latency and the error of the original IsExist, it isn't counted as a hit or miss.
+func (c *MetricsCache) IsExist(ctx context.Context, key string) (bool, error) {
+	start := time.Now()
+	ok, err := c.Cache.IsExist(ctx, key)
+	c.observe(start, err)
+	return ok, err
+}
+
+// PutMulti records the latency and the error of PutMulti of the original Cache.
+func (c *MetricsCache) PutMulti(ctx context.Context, keys []string, vals []interface{}, timeout time.Duration) error {
+	start := time.Now()
+	err := PutMulti(ctx, c.Cache, keys, vals, timeout)
+	c.observe(start, err)
+	return err
+}
+
+// DeleteMulti records the latency and the error of DeleteMulti of the original Cache.
+func (c *MetricsCache) DeleteMulti(ctx context.Context, keys []string) error {
+	start := time.Now()
+	err := DeleteMulti(ctx, c.Cache, keys)
+	c.observe(start, err)
+	return err
+}
+
+// DeleteByPrefix records the latency and the error of DeleteByPrefix of the original Cache.
+func (c *MetricsCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	start := time.Now()
+	err := DeleteByPrefix(ctx, c.Cache, prefix)
+	c.observe(start, err)
+	return err
+}
+
+// ClearAll records the latency and the error of the original ClearAll.
+func (c *MetricsCache) ClearAll(ctx context.Context) error {
+	start := time.Now()
+	err := c.Cache.ClearAll(ctx)
+	c.observe(start, err)
+	return err
+}
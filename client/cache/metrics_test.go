@@ -0,0 +1,58 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsCache(t *testing.T) {
+	bm, err := NewCache("memory", `{"interval":20,"maxEntries":2}`)
+	assert.Nil(t, err)
+	c := NewMetricsCache("test_metrics", bm)
+	defer c.Unregister()
+	ctx := context.Background()
+
+	assert.Nil(t, c.Put(ctx, "k1", "v1", time.Minute))
+	assert.Nil(t, c.Put(ctx, "k2", "v2", time.Minute))
+	_, err = c.Get(ctx, "k1")
+	assert.Nil(t, err)
+	_, err = c.Get(ctx, "k0")
+	assert.NotNil(t, err)
+	_, _ = c.GetMulti(ctx, []string{"k2", "k1", "k0"})
+	// evicts k2 which is the least recently used one
+	assert.Nil(t, c.Put(ctx, "k3", "v3", time.Minute))
+	assert.NotNil(t, c.Incr(ctx, "k1"))
+
+	m := c.Metrics()
+	assert.Equal(t, "test_metrics", m.Name)
+	assert.Equal(t, uint64(3), m.Hits)
+	assert.Equal(t, uint64(2), m.Misses)
+	assert.Equal(t, 0.6, m.HitRatio)
+	assert.Equal(t, uint64(1), m.Errors)
+	assert.Equal(t, uint64(1), m.Evictions)
+	assert.Equal(t, uint64(7), m.Operations)
+	assert.Equal(t, uint64(7), m.LatencyBuckets[LatencyBuckets[len(LatencyBuckets)-1]])
+
+	all := AllMetrics()
+	assert.Equal(t, 1, len(all))
+	assert.Equal(t, m.Hits, all[0].Hits)
+	c.Unregister()
+	assert.Equal(t, 0, len(AllMetrics()))
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// MultiBatchSize is the max number of keys sent by the adapters in one command or one pipeline,
+// the larger batches are split into chunks, so a huge batch doesn't block the server
+var MultiBatchSize = 512
+
+// MultiCache is implemented by the adapters which put and delete multiple keys in batches,
+// e.g. by pipelining, rather than one round trip per key
+type MultiCache interface {
+	Cache
+	// PutMulti puts vals[i] by keys[i] with the same timeout
+	PutMulti(ctx context.Context, keys []string, vals []interface{}, timeout time.Duration) error
+	// DeleteMulti deletes keys, the missing keys are ignored
+	DeleteMulti(ctx context.Context, keys []string) error
+}
+
+// PutMulti puts vals[i] by keys[i] into c in batches if c is a MultiCache,
+// otherwise they are put one by one and the failed keys are reported by MultiPutFailed.
+func PutMulti(ctx context.Context, c Cache, keys []string, vals []interface{}, timeout time.Duration) error {
+	if len(keys) != len(vals) {
+		return berror.Errorf(InvalidMultiValues, "%d keys but %d values", len(keys), len(vals))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if mc, ok := c.(MultiCache); ok {
+		return mc.PutMulti(ctx, keys, vals, timeout)
+	}
+	keysErr := make([]string, 0)
+	for i, key := range keys {
+		if err := c.Put(ctx, key, vals[i], timeout); err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", key, err.Error()))
+		}
+	}
+	if len(keysErr) == 0 {
+		return nil
+	}
+	return berror.Error(MultiPutFailed, strings.Join(keysErr, "; "))
+}
+
+// DeleteMulti deletes keys from c in batches if c is a MultiCache,
+// otherwise they are deleted one by one and the failed keys are reported by MultiDeleteFailed.
+func DeleteMulti(ctx context.Context, c Cache, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if mc, ok := c.(MultiCache); ok {
+		return mc.DeleteMulti(ctx, keys)
+	}
+	keysErr := make([]string, 0)
+	for _, key := range keys {
+		if err := c.Delete(ctx, key); err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", key, err.Error()))
+		}
+	}
+	if len(keysErr) == 0 {
+		return nil
+	}
+	return berror.Error(MultiDeleteFailed, strings.Join(keysErr, "; "))
+}
+
+// ChunkKeys calls fn with the chunks of keys whose size is at most MultiBatchSize,
+// start is the index of the first key of the chunk. It stops at the first error.
+func ChunkKeys(keys []string, fn func(start int, chunk []string) error) error {
+	size := MultiBatchSize
+	if size <= 0 {
+		size = len(keys)
+	}
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := fn(start, keys[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+func TestPutMultiAndDeleteMulti(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"k1", "k2", "k3"}
+	vals := []interface{}{1, "v2", []byte("v3")}
+	testCases := []struct {
+		name  string
+		cache Cache
+	}{
+		{name: "multi cache", cache: NewMemoryCache()},
+		// the cache which isn't a MultiCache puts and deletes the keys one by one
+		{name: "fallback", cache: struct{ Cache }{NewMemoryCache()}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Nil(t, PutMulti(ctx, tc.cache, keys, vals, time.Minute))
+			res, err := tc.cache.GetMulti(ctx, keys)
+			assert.Nil(t, err)
+			assert.Equal(t, vals, res)
+
+			assert.Nil(t, DeleteMulti(ctx, tc.cache, []string{"k1", "k3", "missing"}))
+			res, _ = tc.cache.GetMulti(ctx, keys)
+			assert.Equal(t, []interface{}{nil, "v2", nil}, res)
+
+			err = PutMulti(ctx, tc.cache, keys, vals[:1], time.Minute)
+			code, _ := berror.FromError(err)
+			assert.Equal(t, InvalidMultiValues, code)
+		})
+	}
+
+	fc := &failingCache{Cache: NewMemoryCache()}
+	err := PutMulti(ctx, fc, keys, vals, time.Minute)
+	code, _ := berror.FromError(err)
+	assert.Equal(t, MultiPutFailed, code)
+	assert.Contains(t, err.Error(), "key [k2] error")
+	err = DeleteMulti(ctx, fc, keys)
+	code, _ = berror.FromError(err)
+	assert.Equal(t, MultiDeleteFailed, code)
+}
+
+// failingCache fails to put and delete k2
+type failingCache struct {
+	Cache
+}
+
+func (c *failingCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	if key == "k2" {
+		return errors.New("put failed")
+	}
+	return nil
+}
+
+func (c *failingCache) Delete(ctx context.Context, key string) error {
+	if key == "k2" {
+		return errors.New("delete failed")
+	}
+	return nil
+}
+
+func TestChunkKeys(t *testing.T) {
+	defer func(size int) {
+		MultiBatchSize = size
+	}(MultiBatchSize)
+	MultiBatchSize = 2
+
+	var chunks [][]string
+	var starts []int
+	err := ChunkKeys([]string{"a", "b", "c", "d", "e"}, func(start int, chunk []string) error {
+		starts = append(starts, start)
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 2, 4}, starts)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, chunks)
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = ChunkKeys([]string{"a", "b", "c"}, func(start int, chunk []string) error {
+		calls++
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 1, calls)
+}
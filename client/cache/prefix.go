@@ -0,0 +1,45 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// PrefixCache is a Cache which deletes a family of keys, e.g. the versioned keys or the keys of one entity, by prefix.
+// It isn't a part of Cache, so the custom adapters keep working.
+// usage:
+//
+//	c.Put(ctx, "user:42:profile", profile, time.Hour)
+//	c.Put(ctx, "user:42:orders", orders, time.Hour)
+//	cache.DeleteByPrefix(ctx, c, "user:42:") // both keys are deleted
+type PrefixCache interface {
+	Cache
+	// DeleteByPrefix deletes all the keys starting with prefix, it deletes all the keys if prefix is empty.
+	// It isn't atomic, the keys put during the deletion may be kept.
+	DeleteByPrefix(ctx context.Context, prefix string) error
+}
+
+// DeleteByPrefix deletes the keys of c starting with prefix,
+// it returns the error of DeleteByPrefixNotSupported if c isn't a PrefixCache.
+func DeleteByPrefix(ctx context.Context, c Cache, prefix string) error {
+	pc, ok := c.(PrefixCache)
+	if !ok {
+		return berror.Errorf(DeleteByPrefixNotSupported, "%T doesn't support DeleteByPrefix", c)
+	}
+	return pc.DeleteByPrefix(ctx, prefix)
+}
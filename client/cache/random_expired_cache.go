@@ -31,19 +31,55 @@ func WithRandomExpireOffsetFunc(fn func() time.Duration) RandomExpireCacheOption
 	}
 }
 
+// WithRandomExpireJitter returns a RandomExpireCacheOption that randomizes the timeout
+// within ±percent of itself instead of adding the offset, percent is in [0, 1]
+// e.g. 0.1 makes the timeout 1m be [54s, 66s]
+func WithRandomExpireJitter(percent float64) RandomExpireCacheOption {
+	return func(cache *RandomExpireCache) {
+		cache.jitter = percent
+	}
+}
+
 // RandomExpireCache prevent cache batch invalidation
 // Cache random time offset expired
 type RandomExpireCache struct {
 	Cache
 	offset func() time.Duration
+	jitter float64
 }
 
 // Put random time offset expired
 func (rec *RandomExpireCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
-	timeout += rec.offset()
+	if rec.jitter > 0 {
+		timeout = JitterTTL(timeout, rec.jitter)
+	} else {
+		timeout += rec.offset()
+	}
 	return rec.Cache.Put(ctx, key, val, timeout)
 }
 
+// JitterTTL returns a random timeout within ±percent of ttl, so the keys put together don't expire together.
+// It can be used to jitter the timeout of a single Put, e.g. c.Put(ctx, key, val, JitterTTL(time.Minute, 0.1)).
+// percent is clamped to [0, 1] and ttl <= 0 is returned as it is because it means never expiring.
+func JitterTTL(ttl time.Duration, percent float64) time.Duration {
+	if ttl <= 0 || percent <= 0 {
+		return ttl
+	}
+	if percent > 1 {
+		percent = 1
+	}
+	band := int64(float64(ttl) * percent)
+	if band <= 0 {
+		return ttl
+	}
+	jittered := ttl + time.Duration(rand.Int63n(2*band+1)-band)
+	if jittered <= 0 {
+		// a timeout 0 never expires, so keep it positive
+		return 1
+	}
+	return jittered
+}
+
 // NewRandomExpireCache return random expire cache struct
 func NewRandomExpireCache(adapter Cache, opts ...RandomExpireCacheOption) Cache {
 	rec := RandomExpireCache{
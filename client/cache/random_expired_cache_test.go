@@ -99,6 +99,41 @@ func TestWithRandomExpireOffsetFunc(t *testing.T) {
 	assert.Equal(t, magic, cache.(*RandomExpireCache).offset())
 }
 
+func TestWithRandomExpireJitter(t *testing.T) {
+	mock := &putRecorder{Cache: NewMemoryCache()}
+	cache := NewRandomExpireCache(mock, WithRandomExpireJitter(0.1))
+	seen := make(map[time.Duration]struct{})
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, cache.Put(context.Background(), "astaxie", "author", time.Minute))
+		assert.True(t, mock.timeout >= 54*time.Second && mock.timeout <= 66*time.Second, mock.timeout)
+		seen[mock.timeout] = struct{}{}
+	}
+	assert.True(t, len(seen) > 1)
+
+	// never expiring keys are kept as they are
+	assert.Nil(t, cache.Put(context.Background(), "astaxie", "author", 0))
+	assert.Equal(t, time.Duration(0), mock.timeout)
+}
+
+func TestJitterTTL(t *testing.T) {
+	assert.Equal(t, time.Minute, JitterTTL(time.Minute, 0))
+	assert.Equal(t, time.Duration(0), JitterTTL(0, 0.5))
+	for i := 0; i < 100; i++ {
+		ttl := JitterTTL(time.Second, 2)
+		assert.True(t, ttl > 0 && ttl <= 2*time.Second, ttl)
+	}
+}
+
+type putRecorder struct {
+	Cache
+	timeout time.Duration
+}
+
+func (p *putRecorder) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	p.timeout = timeout
+	return p.Cache.Put(ctx, key, val, timeout)
+}
+
 func ExampleNewRandomExpireCache() {
 	mc := NewMemoryCache()
 	// use the default strategy which will generate random time offset (range: [3s,8s)) expired
@@ -27,11 +27,12 @@ type readThroughCache struct {
 	Cache
 	expiration time.Duration
 	loadFunc   func(ctx context.Context, key string) (any, error)
+	opts       loadOptions
 }
 
 // NewReadThroughCache create readThroughCache
 func NewReadThroughCache(cache Cache, expiration time.Duration,
-	loadFunc func(ctx context.Context, key string) (any, error),
+	loadFunc func(ctx context.Context, key string) (any, error), opts ...LoadOption,
 ) (Cache, error) {
 	if loadFunc == nil {
 		return nil, berror.Error(InvalidLoadFunc, "loadFunc cannot be nil")
@@ -40,22 +41,23 @@ func NewReadThroughCache(cache Cache, expiration time.Duration,
 		Cache:      cache,
 		expiration: expiration,
 		loadFunc:   loadFunc,
+		opts:       newLoadOptions(opts),
 	}, nil
 }
 
 // Get will try to call the LoadFunc to load data if the Cache returns value nil or non-nil error.
+// It returns ErrKeyNotExist if the key is cached as NotFound.
 func (c *readThroughCache) Get(ctx context.Context, key string) (any, error) {
-	val, err := c.Cache.Get(ctx, key)
-	if val == nil || err != nil {
-		val, err = c.loadFunc(ctx, key)
-		if err != nil {
-			return nil, berror.Wrap(
-				err, LoadFuncFailed, "cache unable to load data")
-		}
-		err = c.Cache.Put(ctx, key, val, c.expiration)
-		if err != nil {
-			return val, err
-		}
+	val, err := cached(ctx, c.Cache, key)
+	if val != nil || err != nil {
+		return val, err
 	}
-	return val, nil
+	return c.opts.load(ctx, c.Cache, key, c.expiration, func(ctx context.Context) (any, error) {
+		return c.loadFunc(ctx, key)
+	})
+}
+
+// GetMulti returns nil and the error of ErrKeyNotExist for the keys cached as NotFound.
+func (c *readThroughCache) GetMulti(ctx context.Context, keys []string) ([]any, error) {
+	return getMulti(ctx, c.Cache, keys)
 }
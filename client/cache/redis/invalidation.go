@@ -0,0 +1,37 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/beego/beego/v2/client/cache"
+	redispubsub "github.com/beego/beego/v2/core/pubsub/redis"
+)
+
+// DefaultInvalidationChannel is the redis channel of the invalidations if the channel isn't specified
+const DefaultInvalidationChannel = "beego:cache:invalidation"
+
+// InvalidationBus broadcasts the invalidations of cache.TieredCache by redis pub/sub,
+// the client can be a redis.Client, redis.ClusterClient or the failover client of sentinel
+type InvalidationBus = redispubsub.Bus[cache.Invalidation]
+
+// NewInvalidationBus returns the InvalidationBus publishing to channel, DefaultInvalidationChannel if it's empty
+func NewInvalidationBus(client goredis.UniversalClient, channel string) *InvalidationBus {
+	if channel == "" {
+		channel = DefaultInvalidationChannel
+	}
+	return redispubsub.NewBus[cache.Invalidation](client, channel)
+}
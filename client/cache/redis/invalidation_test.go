@@ -0,0 +1,54 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/client/cache"
+)
+
+func TestInvalidationBus(t *testing.T) {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "127.0.0.1:6379"
+	}
+	client := goredis.NewClient(&goredis.Options{Addr: redisAddr})
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := NewInvalidationBus(client, "")
+	received := make(chan cache.Invalidation, 1)
+	err := bus.Subscribe(ctx, func(ctx context.Context, inv cache.Invalidation) {
+		received <- inv
+	})
+	assert.Nil(t, err)
+
+	inv := cache.Invalidation{Keys: []string{"astaxie"}, Origin: "instance"}
+	assert.Nil(t, bus.Publish(ctx, inv))
+	select {
+	case got := <-received:
+		assert.Equal(t, inv, got)
+	case <-time.After(time.Second):
+		t.Fatal("the invalidation isn't received")
+	}
+}
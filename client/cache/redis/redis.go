@@ -27,6 +27,8 @@
 // )
 //
 //	bm, err := cache.NewCache("redis", `{"conn":"127.0.0.1:11211"}`)
+//	bm, err := cache.NewCache("redis", `{"conn":"127.0.0.1:11211","codec":"msgpack"}`)
+//	bm, err := cache.NewCache("redis", `{"conn":"127.0.0.1:11211","compression":"zstd","compressThreshold":"4096"}`)
 package redis
 
 import (
@@ -70,6 +72,11 @@ type Cache struct {
 	// Timeout value (less than the redis server's timeout value).
 	// Timeout used for idle connection
 	timeout time.Duration
+
+	// codec encodes the values, the values are passed to redigo as they are if it's nil
+	codec cache.Codec
+	// compressor compresses the large values, they aren't compressed if it's nil
+	compressor *cache.Compressor
 }
 
 // NewRedisCache creates a new redis cache with default collection name.
@@ -105,35 +112,280 @@ func (rc *Cache) associate(originKey interface{}) string {
 // Get cache from redis.
 func (rc *Cache) Get(ctx context.Context, key string) (interface{}, error) {
 	if v, err := rc.do("GET", key); err == nil {
-		return v, nil
+		return rc.decode(v)
 	} else {
 		return nil, err
 	}
 }
 
-// GetMulti gets cache from redis.
+// GetMulti gets the values of keys by MGET, the keys are split into chunks of cache.MultiBatchSize
+// and all the chunks are sent in one pipeline.
 func (rc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	cmds := make([]redisCommand, 0, len(keys)/cache.MultiBatchSize+1)
+	_ = cache.ChunkKeys(keys, func(_ int, chunk []string) error {
+		args := make([]interface{}, len(chunk))
+		for i, key := range chunk {
+			args[i] = rc.associate(key)
+		}
+		cmds = append(cmds, redisCommand{name: "MGET", args: args})
+		return nil
+	})
+	replies, err := rc.pipeline(cmds)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0, len(keys))
+	for _, reply := range replies {
+		vs, err := redis.Values(reply, nil)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, vs...)
+	}
+	if rc.codec == nil && rc.compressor == nil {
+		return values, nil
+	}
+	keysErr := make([]string, 0)
+	for i, v := range values {
+		if values[i], err = rc.decode(v); err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", keys[i], err.Error()))
+		}
+	}
+	if len(keysErr) == 0 {
+		return values, nil
+	}
+	return values, berror.Error(cache.MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// PutMulti puts vals[i] by keys[i] by SETEX, all the commands are sent in one pipeline, see cache.MultiCache.
+func (rc *Cache) PutMulti(ctx context.Context, keys []string, vals []interface{}, timeout time.Duration) error {
+	if len(keys) != len(vals) {
+		return berror.Errorf(cache.InvalidMultiValues, "%d keys but %d values", len(keys), len(vals))
+	}
+	cmds := make([]redisCommand, len(keys))
+	for i, key := range keys {
+		val, err := rc.encode(vals[i])
+		if err != nil {
+			return err
+		}
+		cmds[i] = redisCommand{name: "SETEX", args: []interface{}{rc.associate(key), int64(timeout / time.Second), val}}
+	}
+	_, err := rc.pipeline(cmds)
+	return err
+}
+
+// DeleteMulti deletes keys by DEL, the keys are split into chunks of cache.MultiBatchSize
+// and all the chunks are sent in one pipeline, see cache.MultiCache.
+func (rc *Cache) DeleteMulti(ctx context.Context, keys []string) error {
+	cmds := make([]redisCommand, 0, len(keys)/cache.MultiBatchSize+1)
+	_ = cache.ChunkKeys(keys, func(_ int, chunk []string) error {
+		args := make([]interface{}, len(chunk))
+		for i, key := range chunk {
+			args[i] = rc.associate(key)
+		}
+		cmds = append(cmds, redisCommand{name: "DEL", args: args})
+		return nil
+	})
+	_, err := rc.pipeline(cmds)
+	return err
+}
+
+// redisCommand is the command sent by pipeline
+type redisCommand struct {
+	name string
+	args []interface{}
+}
+
+// pipeline sends cmds in one round trip and returns their replies,
+// all the replies are received even if some commands fail, then the first error is returned
+func (rc *Cache) pipeline(cmds []redisCommand) ([]interface{}, error) {
 	c := rc.p.Get()
 	defer func() {
 		_ = c.Close()
 	}()
-	var args []interface{}
-	for _, key := range keys {
-		args = append(args, rc.associate(key))
+	for _, cmd := range cmds {
+		if err := c.Send(cmd.name, cmd.args...); err != nil {
+			return nil, berror.Wrapf(err, cache.RedisCacheCurdFailed, "could not execute this command: %s", cmd.name)
+		}
 	}
-	return redis.Values(c.Do("MGET", args...))
+	if err := c.Flush(); err != nil {
+		return nil, berror.Wrap(err, cache.RedisCacheCurdFailed, "could not flush the pipeline")
+	}
+	replies := make([]interface{}, len(cmds))
+	var firstErr error
+	for i, cmd := range cmds {
+		reply, err := c.Receive()
+		if err != nil && firstErr == nil {
+			firstErr = berror.Wrapf(err, cache.RedisCacheCurdFailed, "could not execute this command: %s", cmd.name)
+		}
+		replies[i] = reply
+	}
+	return replies, firstErr
 }
 
-// Put puts cache into redis.
+// GetInto decodes the value of key into v by the codec.
+func (rc *Cache) GetInto(ctx context.Context, key string, v interface{}) error {
+	if rc.codec == nil {
+		return berror.Error(cache.InvalidCodec, "the codec of redis isn't set")
+	}
+	data, err := redis.Bytes(rc.do("GET", key))
+	if err == redis.ErrNil {
+		return cache.ErrKeyNotExist
+	}
+	if err != nil {
+		return err
+	}
+	if rc.compressor != nil {
+		if data, err = cache.Decompress(data); err != nil {
+			return err
+		}
+	}
+	return cache.DecodeValue(rc.codec, data, v)
+}
+
+// SetCodec sets the codec of the values, it should be called before the cache is used.
+func (rc *Cache) SetCodec(c cache.Codec) {
+	rc.codec = c
+}
+
+// Codec returns the codec of the values, it's nil if the codec isn't set
+func (rc *Cache) Codec() cache.Codec {
+	return rc.codec
+}
+
+// SetCompressor compresses the large values by c, it should be called before the cache is used.
+func (rc *Cache) SetCompressor(c *cache.Compressor) {
+	rc.compressor = c
+}
+
+// encode returns val itself if neither the codec nor the compressor is set
+func (rc *Cache) encode(val interface{}) (interface{}, error) {
+	var err error
+	if rc.codec != nil {
+		if val, err = cache.EncodeValue(rc.codec, val); err != nil {
+			return nil, err
+		}
+	}
+	if rc.compressor != nil {
+		return rc.compressor.CompressValue(val)
+	}
+	return val, nil
+}
+
+// decode returns reply itself if neither the codec nor the compressor is set
+func (rc *Cache) decode(reply interface{}) (interface{}, error) {
+	data, ok := reply.([]byte)
+	if !ok {
+		return reply, nil
+	}
+	if rc.compressor != nil {
+		var err error
+		if data, err = cache.Decompress(data); err != nil {
+			return nil, err
+		}
+	}
+	if rc.codec == nil {
+		return data, nil
+	}
+	var v interface{}
+	if err := cache.DecodeValue(rc.codec, data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Put puts cache into redis, the key is removed from the tag sets of the old value.
 func (rc *Cache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
-	_, err := rc.do("SETEX", key, int64(timeout/time.Second), val)
-	return err
+	return rc.PutWithTags(ctx, key, val, timeout)
+}
+
+// putWithTagsScript sets KEYS[1], removes it from the old tag sets listed in KEYS[2],
+// and adds it to the tag sets KEYS[3:], which are listed in KEYS[2] then.
+// The tag sets expire no earlier than their members.
+var putWithTagsScript = redis.NewScript(-1, `
+for _, tag in ipairs(redis.call('SMEMBERS', KEYS[2])) do
+	redis.call('SREM', tag, KEYS[1])
+end
+redis.call('DEL', KEYS[2])
+redis.call('SETEX', KEYS[1], ARGV[1], ARGV[2])
+for i = 3, #KEYS do
+	local ttl = redis.call('TTL', KEYS[i])
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if ttl == -2 or (ttl >= 0 and ttl < tonumber(ARGV[1])) then
+		redis.call('EXPIRE', KEYS[i], ARGV[1])
+	end
+	redis.call('SADD', KEYS[2], KEYS[i])
+end
+if #KEYS > 2 then
+	redis.call('EXPIRE', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// invalidateTagScript deletes the members of the tag set KEYS[1], their tag lists and the set itself
+var invalidateTagScript = redis.NewScript(1, `
+local keys = redis.call('SMEMBERS', KEYS[1])
+for i = 1, #keys, 500 do
+	local batch = {unpack(keys, i, math.min(i + 499, #keys))}
+	for j = 1, #batch do
+		batch[#batch + 1] = batch[j] .. '@tags'
+	end
+	redis.call('DEL', unpack(batch))
+end
+redis.call('DEL', KEYS[1])
+return #keys
+`)
+
+// PutWithTags puts cache into redis and adds key to the set of every tag atomically.
+// The tags of the old value are replaced, the tags of key are kept in the set key@tags.
+func (rc *Cache) PutWithTags(ctx context.Context, key string, val interface{},
+	timeout time.Duration, tags ...string,
+) error {
+	val, err := rc.encode(val)
+	if err != nil {
+		return err
+	}
+	args := make([]interface{}, 0, len(tags)+5)
+	args = append(args, len(tags)+2, rc.associate(key), rc.associate(key)+"@tags")
+	for _, tag := range tags {
+		args = append(args, rc.tagKey(tag))
+	}
+	args = append(args, int64(timeout/time.Second), val)
+	return rc.eval(putWithTagsScript, args...)
+}
+
+// InvalidateTag deletes all the keys of tag and the set of tag atomically.
+func (rc *Cache) InvalidateTag(ctx context.Context, tag string) error {
+	return rc.eval(invalidateTagScript, rc.tagKey(tag))
 }
 
-// Delete deletes a key's cache in redis.
+// tagKey is the key of the set of tag
+func (rc *Cache) tagKey(tag string) string {
+	return rc.associate("tag@" + tag)
+}
+
+func (rc *Cache) eval(script *redis.Script, args ...interface{}) error {
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	if _, err := script.Do(c, args...); err != nil {
+		return berror.Wrap(err, cache.RedisCacheCurdFailed, "could not execute the script")
+	}
+	return nil
+}
+
+// deleteScript deletes KEYS[1] and removes it from the tag sets listed in KEYS[2]
+var deleteScript = redis.NewScript(2, `
+for _, tag in ipairs(redis.call('SMEMBERS', KEYS[2])) do
+	redis.call('SREM', tag, KEYS[1])
+end
+return redis.call('DEL', KEYS[1], KEYS[2])
+`)
+
+// Delete deletes a key's cache in redis and removes the key from its tag sets.
 func (rc *Cache) Delete(ctx context.Context, key string) error {
-	_, err := rc.do("DEL", key)
-	return err
+	return rc.eval(deleteScript, rc.associate(key), rc.associate(key)+"@tags")
 }
 
 // IsExist checks cache's existence in redis.
@@ -157,6 +409,116 @@ func (rc *Cache) Decr(ctx context.Context, key string) error {
 	return err
 }
 
+// incrByLua adds ARGV[1] to the counter KEYS[1], the missing counter is set to ARGV[2] with the TTL ARGV[3] in ms first.
+// ARGV[4] and ARGV[5] are the floor and the ceiling, they're empty if there is no bound.
+// It returns {1, new value}, or {0, current value} if the counter would be out of bounds.
+const incrByLua = `
+local cur = redis.call('GET', KEYS[1])
+local created = false
+if cur then
+	cur = tonumber(cur)
+	if cur == nil then
+		return redis.error_reply('ERR value is not an integer or out of range')
+	end
+else
+	cur = tonumber(ARGV[2])
+	created = true
+end
+local n = cur + tonumber(ARGV[1])
+if (ARGV[4] ~= '' and n < tonumber(ARGV[4])) or (ARGV[5] ~= '' and n > tonumber(ARGV[5])) then
+	return {0, cur}
+end
+if created then
+	if tonumber(ARGV[3]) > 0 then
+		redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	else
+		redis.call('SET', KEYS[1], ARGV[2])
+	end
+end
+return {1, redis.call('INCRBY', KEYS[1], ARGV[1])}
+`
+
+var incrByScript = redis.NewScript(1, incrByLua)
+
+// IncrBy adds delta to the counter in redis atomically by the Lua script, see cache.CounterCache.
+// The counter is stored as the integer, so it isn't encoded by the codec.
+func (rc *Cache) IncrBy(ctx context.Context, key string, delta int64, opts ...cache.CounterOption) (int64, error) {
+	o := cache.NewCounterOptions(opts...)
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	res, err := redis.Int64s(incrByScript.Do(c, rc.associate(key), delta, o.Initial,
+		o.TTL.Milliseconds(), counterBound(o.Floor), counterBound(o.Ceiling)))
+	if err != nil {
+		return 0, berror.Wrap(err, cache.RedisCacheCurdFailed, "could not execute the script")
+	}
+	if res[0] == 0 {
+		return res[1], cache.ErrCounterOutOfBounds
+	}
+	return res[1], nil
+}
+
+// counterBound is the argument of incrByLua
+func counterBound(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+// extendLockLua resets the ttl of the lock KEYS[1] to ARGV[2] ms if it's held by the token ARGV[1]
+const extendLockLua = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`
+
+// releaseLockLua deletes the lock KEYS[1] if it's held by the token ARGV[1]
+const releaseLockLua = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+var (
+	extendLockScript  = redis.NewScript(1, extendLockLua)
+	releaseLockScript = redis.NewScript(1, releaseLockLua)
+)
+
+// AcquireLock sets key to token by SET NX PX, it's used by cache.Lock.
+func (rc *Cache) AcquireLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	reply, err := rc.do("SET", key, token, "NX", "PX", ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// ExtendLock resets the ttl of key if it's held by token.
+func (rc *Cache) ExtendLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return rc.runLockScript(extendLockScript, key, token, ttl.Milliseconds())
+}
+
+// ReleaseLock deletes key if it's held by token.
+func (rc *Cache) ReleaseLock(ctx context.Context, key string, token string) (bool, error) {
+	return rc.runLockScript(releaseLockScript, key, token)
+}
+
+func (rc *Cache) runLockScript(script *redis.Script, key string, args ...interface{}) (bool, error) {
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	n, err := redis.Int(script.Do(c, append([]interface{}{rc.associate(key)}, args...)...))
+	if err != nil {
+		return false, berror.Wrap(err, cache.RedisCacheCurdFailed, "could not execute the script")
+	}
+	return n == 1, nil
+}
+
 // ClearAll deletes all cache in the redis collection
 // Be careful about this method, because it scans all keys and the delete them one by one
 func (rc *Cache) ClearAll(context.Context) error {
@@ -176,6 +538,51 @@ func (rc *Cache) ClearAll(context.Context) error {
 	return err
 }
 
+// DeleteByPrefix deletes the keys starting with prefix in the redis collection.
+// The keys are scanned and unlinked in batches, so the server isn't blocked by a huge family of keys.
+func (rc *Cache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	pattern := escapeGlob(rc.associate(prefix)) + "*"
+	var cursor uint64
+	for {
+		result, err := redis.Values(c.Do("SCAN", cursor, "MATCH", pattern, "COUNT", 1024))
+		if err != nil {
+			return berror.Wrap(err, cache.RedisCacheCurdFailed, "could not execute this command: SCAN")
+		}
+		keys, err := redis.Values(result[1], nil)
+		if err != nil {
+			return berror.Wrap(err, cache.RedisCacheCurdFailed, "could not execute this command: SCAN")
+		}
+		if len(keys) > 0 {
+			if _, err = c.Do("UNLINK", keys...); err != nil {
+				return berror.Wrap(err, cache.RedisCacheCurdFailed, "could not execute this command: UNLINK")
+			}
+		}
+		if cursor, err = redis.Uint64(result[0], nil); err != nil || cursor == 0 {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// escapeGlob escapes the special characters of the pattern of SCAN
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
 // Scan scans all keys matching a given pattern.
 func (rc *Cache) Scan(pattern string) (keys []string, err error) {
 	c := rc.p.Get()
@@ -208,7 +615,9 @@ func (rc *Cache) Scan(pattern string) (keys []string, err error) {
 }
 
 // StartAndGC starts the redis cache adapter.
-// config: must be in this format {"key":"collection key","conn":"connection info","dbNum":"0", "skipEmptyPrefix":"true"}
+// config: must be in this format {"key":"collection key","conn":"connection info","dbNum":"0", "skipEmptyPrefix":"true"},
+// the optional "codec" is the name of the codec registered by cache.RegisterCodec,
+// and the optional "compression" is "gzip", "snappy" or "zstd", the values smaller than "compressThreshold" aren't compressed
 // Cached items in redis are stored forever, no garbage collection happens
 func (rc *Cache) StartAndGC(config string) error {
 	err := rc.parseConf(config)
@@ -250,6 +659,8 @@ func (rc *Cache) parseConf(config string) error {
 	rc.maxIdle = cf.maxIdle
 	rc.timeout = cf.timeout
 	rc.skipEmptyPrefix = cf.skipEmptyPrefix
+	rc.codec = cf.codec
+	rc.compressor = cf.compressor
 
 	return nil
 }
@@ -262,6 +673,10 @@ type redisConfig struct {
 	Conn       string `json:"conn"`
 	MaxIdle    string `json:"maxIdle"`
 	TimeoutStr string `json:"timeout"`
+	Codec      string `json:"codec"`
+	// Compression is the algorithm, CompressThreshold is the min size of the compressed values in bytes
+	Compression       string `json:"compression"`
+	CompressThreshold string `json:"compressThreshold"`
 
 	dbNum           int
 	skipEmptyPrefix bool
@@ -269,7 +684,9 @@ type redisConfig struct {
 	// parse from Conn
 	password string
 	// timeout used for idle connection, default is 180 seconds.
-	timeout time.Duration
+	timeout    time.Duration
+	codec      cache.Codec
+	compressor *cache.Compressor
 }
 
 // parse parses the config.
@@ -311,6 +728,29 @@ func (cf *redisConfig) parse() error {
 		cf.timeout = defaultTimeout
 	}
 
+	if cf.Codec != "" {
+		c, err := cache.GetCodec(cf.Codec)
+		if err != nil {
+			return err
+		}
+		cf.codec = c
+	}
+
+	if cf.Compression != "" {
+		threshold := 0
+		if cf.CompressThreshold != "" {
+			var err error
+			if threshold, err = strconv.Atoi(cf.CompressThreshold); err != nil {
+				return berror.Wrapf(err, cache.InvalidCompression, "invalid compressThreshold: %s", cf.CompressThreshold)
+			}
+		}
+		c, err := cache.NewCompressor(cf.Compression, threshold)
+		if err != nil {
+			return err
+		}
+		cf.compressor = c
+	}
+
 	return nil
 }
 
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -136,6 +137,314 @@ func TestCacheScan(t *testing.T) {
 	assert.Equal(t, 0, len(keys))
 }
 
+func TestCacheTags(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	tc := bm.(cache.TaggedCache)
+
+	assert.Nil(t, tc.PutWithTags(ctx, "user:42:profile", "profile", time.Minute, "user:42"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:42:orders", "orders", 10*time.Minute, "user:42", "orders"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:43:orders", "orders", time.Minute, "user:43", "orders"))
+
+	// the tag set lives as long as its longest member
+	ttl, err := redis.Int64(bm.(*Cache).do("TTL", "tag@user:42"))
+	assert.Nil(t, err)
+	assert.True(t, ttl > 9*60, ttl)
+
+	assert.Nil(t, tc.InvalidateTag(ctx, "user:42"))
+	for key, exist := range map[string]bool{
+		"user:42:profile": false, "user:42:orders": false, "user:43:orders": true, "tag@user:42": false,
+	} {
+		res, _ := tc.IsExist(ctx, key)
+		assert.Equal(t, exist, res, key)
+	}
+	assert.Nil(t, tc.InvalidateTag(ctx, "orders"))
+	res, _ := tc.IsExist(ctx, "user:43:orders")
+	assert.False(t, res)
+	assert.Nil(t, tc.InvalidateTag(ctx, "not_exist"))
+
+	// the key is removed from the old tag sets when it's tagged again
+	assert.Nil(t, tc.PutWithTags(ctx, "user:44:orders", "orders", time.Minute, "user:44", "orders"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:44:orders", "orders", time.Minute, "user:45"))
+	assert.Nil(t, tc.InvalidateTag(ctx, "user:44"))
+	res, _ = tc.IsExist(ctx, "user:44:orders")
+	assert.True(t, res)
+	members, err := redis.Strings(bm.(*Cache).do("SMEMBERS", "tag@orders"))
+	assert.Nil(t, err)
+	assert.Empty(t, members)
+	assert.Nil(t, tc.InvalidateTag(ctx, "user:45"))
+	for _, key := range []string{"user:44:orders", "user:44:orders@tags"} {
+		res, _ = tc.IsExist(ctx, key)
+		assert.False(t, res, key)
+	}
+
+	// Put and Delete remove the key from the tag sets of the old value
+	assert.Nil(t, tc.PutWithTags(ctx, "user:46:orders", "orders", time.Minute, "user:46"))
+	assert.Nil(t, tc.Put(ctx, "user:46:orders", "untagged", time.Minute))
+	assert.Nil(t, tc.InvalidateTag(ctx, "user:46"))
+	v, err := tc.Get(ctx, "user:46:orders")
+	assert.Nil(t, err)
+	assert.Equal(t, "untagged", string(v.([]byte)))
+	res, _ = tc.IsExist(ctx, "user:46:orders@tags")
+	assert.False(t, res)
+
+	assert.Nil(t, tc.PutWithTags(ctx, "user:47:orders", "orders", time.Minute, "user:47"))
+	assert.Nil(t, tc.Delete(ctx, "user:47:orders"))
+	members, err = redis.Strings(bm.(*Cache).do("SMEMBERS", "tag@user:47"))
+	assert.Nil(t, err)
+	assert.Empty(t, members)
+	res, _ = tc.IsExist(ctx, "user:47:orders@tags")
+	assert.False(t, res)
+}
+
+func TestCacheDeleteByPrefix(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 2000; i++ {
+		assert.Nil(t, bm.Put(ctx, fmt.Sprintf("user:42:%d", i), i, time.Minute))
+	}
+	assert.Nil(t, bm.Put(ctx, "user:43:orders", "orders", time.Minute))
+	// the special characters of the pattern are escaped
+	assert.Nil(t, bm.Put(ctx, "user:4*", "star", time.Minute))
+	assert.Nil(t, cache.DeleteByPrefix(ctx, bm, "user:4*"))
+	res, _ := bm.IsExist(ctx, "user:42:1")
+	assert.True(t, res)
+
+	assert.Nil(t, cache.DeleteByPrefix(ctx, bm, "user:42:"))
+	keys, err := bm.(*Cache).Scan(DefaultKey + ":user:42:*")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(keys))
+	res, _ = bm.IsExist(ctx, "user:43:orders")
+	assert.True(t, res)
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func TestCacheIncrBy(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	cc := bm.(cache.CounterCache)
+
+	n, err := cc.IncrBy(ctx, "rate", 1, cache.WithInitial(10), cache.WithCounterTTL(time.Minute), cache.WithCeiling(12))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), n)
+	ttl, err := redis.Int64(bm.(*Cache).do("PTTL", "rate"))
+	assert.Nil(t, err)
+	assert.True(t, ttl > 59000, ttl)
+	n, err = cc.IncrBy(ctx, "rate", 1, cache.WithCeiling(12))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), n)
+	n, err = cc.IncrBy(ctx, "rate", 1, cache.WithCeiling(12))
+	assert.True(t, errors.Is(err, cache.ErrCounterOutOfBounds))
+	assert.Equal(t, int64(12), n)
+	// the TTL isn't reset by the later IncrBy
+	ttl, _ = redis.Int64(bm.(*Cache).do("PTTL", "rate"))
+	assert.True(t, ttl > 0 && ttl <= 60000, ttl)
+
+	n, err = cc.IncrBy(ctx, "stock", -2, cache.WithFloor(-2))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-2), n)
+	_, err = cc.IncrBy(ctx, "stock", -1, cache.WithFloor(-2))
+	assert.True(t, errors.Is(err, cache.ErrCounterOutOfBounds))
+
+	assert.Nil(t, bm.Put(ctx, "name", "astaxie", time.Minute))
+	_, err = cc.IncrBy(ctx, "name", 1)
+	assert.NotNil(t, err)
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func TestCacheLock(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	l, err := cache.TryLock(ctx, bm, "lock", time.Second, cache.WithHeartbeat(100*time.Millisecond))
+	assert.Nil(t, err)
+	_, err = cache.TryLock(ctx, bm, "lock", time.Second)
+	assert.True(t, errors.Is(err, cache.ErrLockNotObtained))
+	// the heartbeat keeps the lock
+	time.Sleep(1500 * time.Millisecond)
+	res, _ := bm.IsExist(ctx, "lock")
+	assert.True(t, res)
+	assert.Nil(t, l.Unlock(ctx))
+	assert.True(t, errors.Is(l.Unlock(ctx), cache.ErrLockNotHeld))
+
+	l, err = cache.Lock(ctx, bm, "lock", time.Second)
+	assert.Nil(t, err)
+	// the lock held by the others isn't released
+	assert.Nil(t, bm.Put(ctx, "lock", "other", time.Minute))
+	assert.True(t, errors.Is(l.Unlock(ctx), cache.ErrLockNotHeld))
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func TestCacheMulti(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	defer func(size int) {
+		cache.MultiBatchSize = size
+	}(cache.MultiBatchSize)
+	// the keys are split into chunks
+	cache.MultiBatchSize = 2
+
+	keys := []string{"k1", "k2", "k3", "k4", "k5"}
+	vals := []interface{}{"v1", "v2", "v3", "v4", "v5"}
+	assert.Nil(t, cache.PutMulti(ctx, bm, keys, vals, time.Minute))
+	res, err := bm.GetMulti(ctx, keys)
+	assert.Nil(t, err)
+	for i, v := range res {
+		s, _ := redis.String(v, nil)
+		assert.Equal(t, vals[i], s)
+	}
+
+	assert.Nil(t, cache.DeleteMulti(ctx, bm, keys[:3]))
+	res, err = bm.GetMulti(ctx, keys)
+	assert.Nil(t, err)
+	assert.Nil(t, res[0])
+	assert.Nil(t, res[2])
+	assert.NotNil(t, res[3])
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func TestCacheCodec(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s", "codec": "msgpack"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	cc := bm.(cache.CodecCache)
+
+	type user struct {
+		Name string
+		Age  int
+	}
+	assert.Nil(t, cc.Put(ctx, "user", user{Name: "astaxie", Age: 18}, time.Minute))
+	var u user
+	assert.Nil(t, cc.GetInto(ctx, "user", &u))
+	assert.Equal(t, user{Name: "astaxie", Age: 18}, u)
+	v, err := cc.Get(ctx, "user")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "astaxie", "Age": int64(18)}, v)
+
+	assert.Nil(t, cc.Put(ctx, "count", 1, time.Minute))
+	vv, err := cc.GetMulti(ctx, []string{"count", "count0"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(1), nil}, vv)
+	assert.True(t, errors.Is(cc.GetInto(ctx, "count0", &u), cache.ErrKeyNotExist))
+	assert.Nil(t, cc.ClearAll(ctx))
+
+	_, err = cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s", "codec": "unknown"}`, addr))
+	code, _ := berror.FromError(err)
+	assert.Equal(t, cache.InvalidCodec, code)
+}
+
+func TestCacheTyped(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	type user struct {
+		Name string
+		Age  int
+	}
+	ctx := context.Background()
+	u := user{Name: "astaxie", Age: 18}
+
+	// the values are decoded by GetInto of the adapter
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s", "codec": "msgpack"}`, addr))
+	assert.Nil(t, err)
+	users := cache.Typed[user](bm, nil)
+	assert.Nil(t, users.Put(ctx, "user", u, time.Minute))
+	got, err := users.Get(ctx, "user")
+	assert.Nil(t, err)
+	assert.Equal(t, u, got)
+	_, err = users.Get(ctx, "user0")
+	assert.True(t, errors.Is(err, cache.ErrKeyNotExist))
+
+	// the values are encoded by the codec of TypedCache
+	bm, err = cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	codec, _ := cache.GetCodec(cache.CodecJSON)
+	users = cache.Typed[user](bm, codec)
+	got, err = users.GetOrLoad(ctx, "user1", time.Minute, func(ctx context.Context) (user, error) {
+		return u, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, u, got)
+	got, err = users.Get(ctx, "user1")
+	assert.Nil(t, err)
+	assert.Equal(t, u, got)
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func TestCacheCompression(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s", "compression": "gzip", "compressThreshold": "16"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	html := strings.Repeat("<p>hello, beego</p>", 100)
+	assert.Nil(t, bm.Put(ctx, "html", html, time.Minute))
+	n, err := redis.Int(bm.(*Cache).do("STRLEN", "html"))
+	assert.Nil(t, err)
+	assert.True(t, n < len(html), n)
+	v, err := bm.Get(ctx, "html")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(html), v)
+
+	// the small counters aren't compressed
+	assert.Nil(t, bm.Put(ctx, "count", 1, time.Minute))
+	assert.Nil(t, bm.Incr(ctx, "count"))
+	v, err = bm.Get(ctx, "count")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("2"), v)
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func mustNewCompressor(algorithm string, threshold int) *cache.Compressor {
+	c, err := cache.NewCompressor(algorithm, threshold)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func mustGetCodec(name string) cache.Codec {
+	c, err := cache.GetCodec(name)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
 func TestReadThroughCache_redis_Get(t *testing.T) {
 	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, "127.0.0.1:6379"))
 	assert.Nil(t, err)
@@ -346,6 +655,41 @@ func TestCache_parseConf(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+
+		{
+			name: "codec",
+			configStr: `{
+  "conn": "127.0.0.1:6379",
+  "codec": "json"
+}`,
+
+			wantCache: Cache{
+				conninfo: "127.0.0.1:6379",
+				key:      DefaultKey,
+				maxIdle:  defaultMaxIdle,
+				timeout:  defaultTimeout,
+				codec:    mustGetCodec(cache.CodecJSON),
+			},
+			wantErr: nil,
+		},
+
+		{
+			name: "compression",
+			configStr: `{
+  "conn": "127.0.0.1:6379",
+  "compression": "snappy",
+  "compressThreshold": "4096"
+}`,
+
+			wantCache: Cache{
+				conninfo:   "127.0.0.1:6379",
+				key:        DefaultKey,
+				maxIdle:    defaultMaxIdle,
+				timeout:    defaultTimeout,
+				compressor: mustNewCompressor(cache.CompressionSnappy, 4096),
+			},
+			wantErr: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,588 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/core/berror"
+)
+
+const (
+	modeCluster  = "cluster"
+	modeSentinel = "sentinel"
+)
+
+// UniversalConfig is the config shared by the redis_cluster and redis_sentinel adapters.
+// usage:
+//
+//	bm, err := cache.NewCache("redis_cluster", `{"addrs":["127.0.0.1:7000","127.0.0.1:7001"],"username":"app","password":"secret"}`)
+//	bm, err := cache.NewCache("redis_sentinel", `{"addrs":["127.0.0.1:26379"],"masterName":"mymaster","dbNum":1}`)
+type UniversalConfig struct {
+	// Key is the prefix of the keys, DefaultKey by default
+	Key string `json:"key"`
+	// Addrs is the seed nodes of the cluster or the addresses of the sentinels
+	Addrs []string `json:"addrs"`
+	// MasterName is the name of the master monitored by the sentinels, it's required by redis_sentinel
+	MasterName string `json:"masterName"`
+	// Username authenticates by ACL, the legacy AUTH with only Password is used if it's empty
+	Username         string `json:"username"`
+	Password         string `json:"password"`
+	SentinelUsername string `json:"sentinelUsername"`
+	SentinelPassword string `json:"sentinelPassword"`
+	// DbNum is the database of redis_sentinel, the cluster only has the database 0
+	DbNum    int `json:"dbNum"`
+	PoolSize int `json:"poolSize"`
+	// DialTimeout, ReadTimeout and WriteTimeout are the durations such as "5s", the defaults of go-redis are used if they're empty
+	DialTimeout  string `json:"dialTimeout"`
+	ReadTimeout  string `json:"readTimeout"`
+	WriteTimeout string `json:"writeTimeout"`
+	// ReadOnly routes the reads to the replicas of the cluster
+	ReadOnly bool `json:"readOnly"`
+	// RouteByLatency routes the reads to the node with the lowest latency, it implies ReadOnly
+	RouteByLatency bool `json:"routeByLatency"`
+	// TLS enables TLS if it isn't nil
+	TLS *TLSConfig `json:"tls"`
+	// Codec is the name of the codec registered by cache.RegisterCodec, the values are stored as they are if it's empty
+	Codec string `json:"codec"`
+	// Compression is "gzip", "snappy" or "zstd", the values smaller than CompressThreshold bytes aren't compressed,
+	// and cache.DefaultCompressThreshold is used if CompressThreshold is 0
+	Compression       string `json:"compression"`
+	CompressThreshold int    `json:"compressThreshold"`
+}
+
+// TLSConfig is the TLS config of the connections
+type TLSConfig struct {
+	// CAFile is the CA certificates verifying the server, the system CAs are used if it's empty
+	CAFile string `json:"caFile"`
+	// CertFile and KeyFile are the client certificate for the mutual TLS
+	CertFile           string `json:"certFile"`
+	KeyFile            string `json:"keyFile"`
+	ServerName         string `json:"serverName"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+}
+
+func (t *TLSConfig) build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate is found in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// options converts the config to the options of go-redis
+func (cf *UniversalConfig) options(mode string) (*goredis.UniversalOptions, error) {
+	if len(cf.Addrs) == 0 {
+		return nil, berror.Error(cache.InvalidRedisCacheCfg, "config missing addrs field")
+	}
+	if mode == modeSentinel && cf.MasterName == "" {
+		return nil, berror.Error(cache.InvalidRedisCacheCfg, "config missing masterName field")
+	}
+	if mode == modeCluster && cf.DbNum != 0 {
+		return nil, berror.Error(cache.InvalidRedisCacheCfg, "redis cluster only supports the database 0")
+	}
+	opts := &goredis.UniversalOptions{
+		Addrs:            cf.Addrs,
+		MasterName:       cf.MasterName,
+		Username:         cf.Username,
+		Password:         cf.Password,
+		SentinelUsername: cf.SentinelUsername,
+		SentinelPassword: cf.SentinelPassword,
+		DB:               cf.DbNum,
+		PoolSize:         cf.PoolSize,
+		ReadOnly:         cf.ReadOnly,
+		RouteByLatency:   cf.RouteByLatency,
+	}
+	for _, d := range []struct {
+		value string
+		field *time.Duration
+	}{
+		{cf.DialTimeout, &opts.DialTimeout},
+		{cf.ReadTimeout, &opts.ReadTimeout},
+		{cf.WriteTimeout, &opts.WriteTimeout},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, berror.Wrapf(err, cache.InvalidRedisCacheCfg, "invalid timeout: %s", d.value)
+		}
+		*d.field = v
+	}
+	if cf.TLS != nil {
+		tlsCfg, err := cf.TLS.build()
+		if err != nil {
+			return nil, berror.Wrap(err, cache.InvalidRedisCacheCfg, "invalid tls config")
+		}
+		opts.TLSConfig = tlsCfg
+	}
+	return opts, nil
+}
+
+// UniversalCache is the cache adapter of Redis Cluster and Redis Sentinel, it's based on go-redis
+type UniversalCache struct {
+	client goredis.UniversalClient
+	mode   string
+	// key actually is prefix.
+	key        string
+	codec      cache.Codec
+	compressor *cache.Compressor
+}
+
+// NewRedisClusterCache creates the cache adapter of Redis Cluster
+func NewRedisClusterCache() cache.Cache {
+	return &UniversalCache{key: DefaultKey, mode: modeCluster}
+}
+
+// NewRedisSentinelCache creates the cache adapter of the master monitored by Redis Sentinel
+func NewRedisSentinelCache() cache.Cache {
+	return &UniversalCache{key: DefaultKey, mode: modeSentinel}
+}
+
+// Client returns the go-redis client, e.g. to create the InvalidationBus on the same servers
+func (rc *UniversalCache) Client() goredis.UniversalClient {
+	return rc.client
+}
+
+// SetCodec sets the codec of the values, it should be called before the cache is used.
+func (rc *UniversalCache) SetCodec(c cache.Codec) {
+	rc.codec = c
+}
+
+// Codec returns the codec of the values, it's nil if the codec isn't set
+func (rc *UniversalCache) Codec() cache.Codec {
+	return rc.codec
+}
+
+// SetCompressor compresses the large values by c, it should be called before the cache is used.
+func (rc *UniversalCache) SetCompressor(c *cache.Compressor) {
+	rc.compressor = c
+}
+
+// associate with config key.
+func (rc *UniversalCache) associate(originKey string) string {
+	return fmt.Sprintf("%s:%s", rc.key, originKey)
+}
+
+func (rc *UniversalCache) wrap(err error, commandName string) error {
+	if err == nil {
+		return nil
+	}
+	return berror.Wrapf(err, cache.RedisCacheCurdFailed, "could not execute this command: %s", commandName)
+}
+
+// Get cache from redis, the value is []byte if the codec isn't set, and it's nil if the key doesn't exist.
+func (rc *UniversalCache) Get(ctx context.Context, key string) (interface{}, error) {
+	v, err := rc.client.Get(ctx, rc.associate(key)).Bytes()
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, rc.wrap(err, "GET")
+	}
+	return rc.decode(v)
+}
+
+// GetInto decodes the value of key into v by the codec.
+func (rc *UniversalCache) GetInto(ctx context.Context, key string, v interface{}) error {
+	if rc.codec == nil {
+		return berror.Error(cache.InvalidCodec, "the codec of redis isn't set")
+	}
+	data, err := rc.client.Get(ctx, rc.associate(key)).Bytes()
+	if err == goredis.Nil {
+		return cache.ErrKeyNotExist
+	}
+	if err != nil {
+		return rc.wrap(err, "GET")
+	}
+	if rc.compressor != nil {
+		if data, err = cache.Decompress(data); err != nil {
+			return err
+		}
+	}
+	return cache.DecodeValue(rc.codec, data, v)
+}
+
+// decode returns data itself if neither the codec nor the compressor is set
+func (rc *UniversalCache) decode(data []byte) (interface{}, error) {
+	if rc.compressor != nil {
+		var err error
+		if data, err = cache.Decompress(data); err != nil {
+			return nil, err
+		}
+	}
+	if rc.codec == nil {
+		return data, nil
+	}
+	var v interface{}
+	if err := cache.DecodeValue(rc.codec, data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// decodeValues decodes the values got by GetMulti in place
+func (rc *UniversalCache) decodeValues(keys []string, values []interface{}) ([]interface{}, error) {
+	keysErr := make([]string, 0)
+	for i, v := range values {
+		data, ok := v.([]byte)
+		if !ok {
+			continue
+		}
+		var err error
+		if values[i], err = rc.decode(data); err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", keys[i], err.Error()))
+		}
+	}
+	if len(keysErr) == 0 {
+		return values, nil
+	}
+	return values, berror.Error(cache.MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// GetMulti gets the values of keys in one pipeline, they are nil if the keys don't exist.
+// The keys are read by MGET in chunks of cache.MultiBatchSize, or by GET in the cluster
+// because the keys of one MGET must be in the same slot.
+func (rc *UniversalCache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+	if rc.mode != modeCluster {
+		cmds := make([]*goredis.SliceCmd, 0, len(keys)/cache.MultiBatchSize+1)
+		_, err := rc.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+			return cache.ChunkKeys(keys, func(_ int, chunk []string) error {
+				args := make([]string, len(chunk))
+				for i, key := range chunk {
+					args[i] = rc.associate(key)
+				}
+				cmds = append(cmds, pipe.MGet(ctx, args...))
+				return nil
+			})
+		})
+		if err != nil {
+			return nil, rc.wrap(err, "MGET")
+		}
+		i := 0
+		for _, cmd := range cmds {
+			for _, v := range cmd.Val() {
+				if s, ok := v.(string); ok {
+					values[i] = []byte(s)
+				}
+				i++
+			}
+		}
+		return rc.decodeValues(keys, values)
+	}
+
+	cmds := make([]*goredis.StringCmd, len(keys))
+	_, err := rc.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, rc.associate(key))
+		}
+		return nil
+	})
+	if err != nil && err != goredis.Nil {
+		return nil, rc.wrap(err, "GET")
+	}
+	for i, cmd := range cmds {
+		if v, err := cmd.Bytes(); err == nil {
+			values[i] = v
+		}
+	}
+	return rc.decodeValues(keys, values)
+}
+
+// PutMulti puts vals[i] by keys[i] by SET in one pipeline, see cache.MultiCache.
+// The cluster client sends the commands to the nodes of the keys concurrently.
+func (rc *UniversalCache) PutMulti(ctx context.Context, keys []string, vals []interface{}, timeout time.Duration) error {
+	if len(keys) != len(vals) {
+		return berror.Errorf(cache.InvalidMultiValues, "%d keys but %d values", len(keys), len(vals))
+	}
+	encoded := make([]interface{}, len(vals))
+	for i, val := range vals {
+		var err error
+		if encoded[i], err = rc.encode(val); err != nil {
+			return err
+		}
+	}
+	_, err := rc.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, key := range keys {
+			pipe.Set(ctx, rc.associate(key), encoded[i], timeout)
+		}
+		return nil
+	})
+	return rc.wrap(err, "SET")
+}
+
+// DeleteMulti deletes keys in one pipeline, see cache.MultiCache.
+// The keys are deleted by DEL in chunks of cache.MultiBatchSize, or one by one in the cluster
+// because the keys of one DEL must be in the same slot.
+func (rc *UniversalCache) DeleteMulti(ctx context.Context, keys []string) error {
+	_, err := rc.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		if rc.mode == modeCluster {
+			for _, key := range keys {
+				pipe.Del(ctx, rc.associate(key))
+			}
+			return nil
+		}
+		return cache.ChunkKeys(keys, func(_ int, chunk []string) error {
+			args := make([]string, len(chunk))
+			for i, key := range chunk {
+				args[i] = rc.associate(key)
+			}
+			pipe.Del(ctx, args...)
+			return nil
+		})
+	})
+	return rc.wrap(err, "DEL")
+}
+
+// Put puts cache into redis.
+func (rc *UniversalCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	val, err := rc.encode(val)
+	if err != nil {
+		return err
+	}
+	return rc.wrap(rc.client.Set(ctx, rc.associate(key), val, timeout).Err(), "SET")
+}
+
+// encode encodes val by the codec, then compresses it
+func (rc *UniversalCache) encode(val interface{}) (interface{}, error) {
+	var err error
+	if rc.codec != nil {
+		if val, err = cache.EncodeValue(rc.codec, val); err != nil {
+			return nil, err
+		}
+	}
+	if rc.compressor != nil {
+		return rc.compressor.CompressValue(val)
+	}
+	return val, nil
+}
+
+// Delete deletes a key's cache in redis.
+func (rc *UniversalCache) Delete(ctx context.Context, key string) error {
+	return rc.wrap(rc.client.Del(ctx, rc.associate(key)).Err(), "DEL")
+}
+
+// IsExist checks cache's existence in redis.
+func (rc *UniversalCache) IsExist(ctx context.Context, key string) (bool, error) {
+	n, err := rc.client.Exists(ctx, rc.associate(key)).Result()
+	if err != nil {
+		return false, rc.wrap(err, "EXISTS")
+	}
+	return n > 0, nil
+}
+
+// Incr increases a key's counter in redis.
+func (rc *UniversalCache) Incr(ctx context.Context, key string) error {
+	return rc.wrap(rc.client.IncrBy(ctx, rc.associate(key), 1).Err(), "INCRBY")
+}
+
+var universalIncrByScript = goredis.NewScript(incrByLua)
+
+// IncrBy adds delta to the counter in redis atomically by the Lua script, see cache.CounterCache.
+// The counter is stored as the integer, so it isn't encoded by the codec.
+func (rc *UniversalCache) IncrBy(ctx context.Context, key string, delta int64, opts ...cache.CounterOption) (int64, error) {
+	o := cache.NewCounterOptions(opts...)
+	res, err := universalIncrByScript.Run(ctx, rc.client, []string{rc.associate(key)}, delta, o.Initial,
+		o.TTL.Milliseconds(), counterBound(o.Floor), counterBound(o.Ceiling)).Int64Slice()
+	if err != nil {
+		return 0, rc.wrap(err, "EVALSHA")
+	}
+	if res[0] == 0 {
+		return res[1], cache.ErrCounterOutOfBounds
+	}
+	return res[1], nil
+}
+
+var (
+	universalExtendLockScript  = goredis.NewScript(extendLockLua)
+	universalReleaseLockScript = goredis.NewScript(releaseLockLua)
+)
+
+// AcquireLock sets key to token by SET NX PX, it's used by cache.Lock.
+func (rc *UniversalCache) AcquireLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	ok, err := rc.client.SetNX(ctx, rc.associate(key), token, ttl).Result()
+	return ok, rc.wrap(err, "SET")
+}
+
+// ExtendLock resets the ttl of key if it's held by token.
+func (rc *UniversalCache) ExtendLock(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	n, err := universalExtendLockScript.Run(ctx, rc.client, []string{rc.associate(key)}, token, ttl.Milliseconds()).Int()
+	return n == 1, rc.wrap(err, "EVALSHA")
+}
+
+// ReleaseLock deletes key if it's held by token.
+func (rc *UniversalCache) ReleaseLock(ctx context.Context, key string, token string) (bool, error) {
+	n, err := universalReleaseLockScript.Run(ctx, rc.client, []string{rc.associate(key)}, token).Int()
+	return n == 1, rc.wrap(err, "EVALSHA")
+}
+
+// Decr decreases a key's counter in redis.
+func (rc *UniversalCache) Decr(ctx context.Context, key string) error {
+	return rc.wrap(rc.client.IncrBy(ctx, rc.associate(key), -1).Err(), "INCRBY")
+}
+
+// ClearAll deletes all cache in the redis collection, the keys are scanned on every master of the cluster.
+// Be careful about this method, because it scans all keys and the delete them one by one
+func (rc *UniversalCache) ClearAll(ctx context.Context) error {
+	return rc.forEachMaster(ctx, func(ctx context.Context, client goredis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, rc.key+":*", 1024).Iterator()
+		for iter.Next(ctx) {
+			if err := client.Del(ctx, iter.Val()).Err(); err != nil {
+				return rc.wrap(err, "DEL")
+			}
+		}
+		return rc.wrap(iter.Err(), "SCAN")
+	})
+}
+
+// DeleteByPrefix deletes the keys starting with prefix, the keys are scanned on every master of the cluster.
+// They're unlinked in the pipelined batches, every UNLINK has one key because the keys may be in different slots.
+func (rc *UniversalCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	pattern := escapeGlob(rc.associate(prefix)) + "*"
+	return rc.forEachMaster(ctx, func(ctx context.Context, client goredis.UniversalClient) error {
+		unlink := func(keys []string) error {
+			_, err := client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+				for _, key := range keys {
+					pipe.Unlink(ctx, key)
+				}
+				return nil
+			})
+			return rc.wrap(err, "UNLINK")
+		}
+		batch := make([]string, 0, 512)
+		iter := client.Scan(ctx, 0, pattern, 1024).Iterator()
+		for iter.Next(ctx) {
+			if batch = append(batch, iter.Val()); len(batch) == cap(batch) {
+				if err := unlink(batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return rc.wrap(err, "SCAN")
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		return unlink(batch)
+	})
+}
+
+// Scan scans all keys matching a given pattern, the keys are scanned on every master of the cluster.
+func (rc *UniversalCache) Scan(pattern string) ([]string, error) {
+	var (
+		keys []string
+		lock sync.Mutex
+	)
+	err := rc.forEachMaster(context.Background(), func(ctx context.Context, client goredis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, pattern, 1024).Iterator()
+		for iter.Next(ctx) {
+			// the masters of the cluster are scanned concurrently
+			lock.Lock()
+			keys = append(keys, iter.Val())
+			lock.Unlock()
+		}
+		return rc.wrap(iter.Err(), "SCAN")
+	})
+	return keys, err
+}
+
+func (rc *UniversalCache) forEachMaster(ctx context.Context, fn func(ctx context.Context, client goredis.UniversalClient) error) error {
+	if c, ok := rc.client.(*goredis.ClusterClient); ok {
+		return c.ForEachMaster(ctx, func(ctx context.Context, client *goredis.Client) error {
+			return fn(ctx, client)
+		})
+	}
+	return fn(ctx, rc.client)
+}
+
+// StartAndGC starts the redis cache adapter, config is UniversalConfig in JSON.
+// Cached items in redis are stored forever, no garbage collection happens
+func (rc *UniversalCache) StartAndGC(config string) error {
+	var cf UniversalConfig
+	if err := json.Unmarshal([]byte(config), &cf); err != nil {
+		return berror.Wrapf(err, cache.InvalidRedisCacheCfg, "could not unmarshal the config: %s", config)
+	}
+	opts, err := cf.options(rc.mode)
+	if err != nil {
+		return err
+	}
+	if cf.Key != "" {
+		rc.key = cf.Key
+	}
+	if cf.Codec != "" {
+		if rc.codec, err = cache.GetCodec(cf.Codec); err != nil {
+			return err
+		}
+	}
+	if cf.Compression != "" {
+		if rc.compressor, err = cache.NewCompressor(cf.Compression, cf.CompressThreshold); err != nil {
+			return err
+		}
+	}
+
+	if rc.mode == modeCluster {
+		rc.client = goredis.NewClusterClient(opts.Cluster())
+	} else {
+		rc.client = goredis.NewFailoverClient(opts.Failover())
+	}
+	if err = rc.client.Ping(context.Background()).Err(); err != nil {
+		_ = rc.client.Close()
+		return berror.Wrapf(err, cache.InvalidConnection,
+			"can not connect to remote redis server, please check the connection info and network state: %s", config)
+	}
+	return nil
+}
+
+func init() {
+	cache.Register("redis_cluster", NewRedisClusterCache)
+	cache.Register("redis_sentinel", NewRedisSentinelCache)
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/core/berror"
+)
+
+func TestUniversalConfigOptions(t *testing.T) {
+	cf := UniversalConfig{
+		Addrs:            []string{"127.0.0.1:26379"},
+		MasterName:       "mymaster",
+		Username:         "app",
+		Password:         "secret",
+		SentinelPassword: "sentinel",
+		DbNum:            1,
+		DialTimeout:      "2s",
+		TLS:              &TLSConfig{ServerName: "redis.example.com"},
+	}
+	opts, err := cf.options(modeSentinel)
+	assert.Nil(t, err)
+	failover := opts.Failover()
+	assert.Equal(t, "mymaster", failover.MasterName)
+	assert.Equal(t, "app", failover.Username)
+	assert.Equal(t, "sentinel", failover.SentinelPassword)
+	assert.Equal(t, 1, failover.DB)
+	assert.Equal(t, 2*time.Second, failover.DialTimeout)
+	assert.Equal(t, "redis.example.com", failover.TLSConfig.ServerName)
+
+	_, err = cf.options(modeCluster)
+	assert.NotNil(t, err)
+
+	cf = UniversalConfig{Addrs: []string{"127.0.0.1:7000"}, ReadOnly: true}
+	opts, err = cf.options(modeCluster)
+	assert.Nil(t, err)
+	assert.True(t, opts.Cluster().ReadOnly)
+
+	_, err = cf.options(modeSentinel)
+	assert.NotNil(t, err)
+
+	for _, invalid := range []UniversalConfig{
+		{},
+		{Addrs: []string{"127.0.0.1:7000"}, ReadTimeout: "1"},
+		{Addrs: []string{"127.0.0.1:7000"}, TLS: &TLSConfig{CAFile: "not_exist.pem"}},
+	} {
+		_, err = invalid.options(modeCluster)
+		code, ok := berror.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, cache.InvalidRedisCacheCfg, code)
+	}
+}
+
+func TestUniversalCacheStartAndGC(t *testing.T) {
+	_, err := cache.NewCache("redis_sentinel", `{"addrs":["127.0.0.1:26379"]}`)
+	assert.NotNil(t, err)
+	_, err = cache.NewCache("redis_cluster", `{"addrs":`)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,244 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ristretto for cache provider
+//
+// depend on github.com/dgraph-io/ristretto
+//
+// It's a drop-in replacement of the memory adapter which scales under high concurrency
+// and is bounded by the cost of the items, the items are admitted and evicted by TinyLFU.
+//
+// Usage:
+// import(
+//
+//	_ "github.com/beego/beego/v2/client/cache/ristretto"
+//	"github.com/beego/beego/v2/client/cache"
+//
+// )
+//
+//	bm, err := cache.NewCache("ristretto", `{"numCounters":1000000,"maxCost":100000}`)
+package ristretto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/core/berror"
+)
+
+const (
+	// DefaultMaxCost is the max number of items if maxCost isn't set
+	DefaultMaxCost = 100000
+	// defaultBufferItems is recommended by ristretto
+	defaultBufferItems = 64
+)
+
+type ristrettoConfig struct {
+	// NumCounters is the number of keys whose frequency is tracked, 10 times of the max number of items is recommended.
+	// It's 10 * MaxCost by default
+	NumCounters int64 `json:"numCounters"`
+	// MaxCost is the max total cost of the items, it's the max number of items unless CostBySize is true
+	MaxCost int64 `json:"maxCost"`
+	// BufferItems is the size of the Get buffers, 64 by default
+	BufferItems int64 `json:"bufferItems"`
+	// CostBySize uses the length of string and []byte as the cost so MaxCost is the memory bound in bytes,
+	// the cost of the other values is 1
+	CostBySize bool `json:"costBySize"`
+	// Metrics enables the hit ratio and the other metrics returned by Cache.Metrics
+	Metrics bool `json:"metrics"`
+	// AsyncWrites returns from Put before the item is applied, so Get may miss the item put just now
+	AsyncWrites bool `json:"asyncWrites"`
+}
+
+// Cache is the ristretto cache adapter
+type Cache struct {
+	cache       *ristretto.Cache
+	costBySize  bool
+	asyncWrites bool
+	// lock makes Incr and Decr atomic
+	lock sync.Mutex
+}
+
+// NewRistrettoCache creates the ristretto cache adapter
+func NewRistrettoCache() cache.Cache {
+	return &Cache{}
+}
+
+// Get returns the value of key, the error is cache.ErrKeyNotExist if it doesn't exist or is expired.
+func (rc *Cache) Get(ctx context.Context, key string) (interface{}, error) {
+	if v, ok := rc.cache.Get(key); ok {
+		return v, nil
+	}
+	return nil, cache.ErrKeyNotExist
+}
+
+// GetMulti gets the values of keys, the value of the key which doesn't exist is nil.
+func (rc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	keysErr := make([]string, 0)
+	for i, key := range keys {
+		v, err := rc.Get(ctx, key)
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", key, err.Error()))
+			continue
+		}
+		values[i] = v
+	}
+	if len(keysErr) == 0 {
+		return values, nil
+	}
+	return values, berror.Error(cache.MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// Put puts the value, timeout 0 means it never expires.
+// The new key may be rejected by the admission policy, it's not an error for a cache
+func (rc *Cache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	rc.set(key, val, timeout)
+	return nil
+}
+
+func (rc *Cache) set(key string, val interface{}, ttl time.Duration) {
+	rc.cache.SetWithTTL(key, val, rc.cost(val), ttl)
+	if !rc.asyncWrites {
+		rc.cache.Wait()
+	}
+}
+
+func (rc *Cache) cost(val interface{}) int64 {
+	if rc.costBySize {
+		switch v := val.(type) {
+		case string:
+			return int64(len(v))
+		case []byte:
+			return int64(len(v))
+		}
+	}
+	return 1
+}
+
+// Delete deletes the value of key.
+func (rc *Cache) Delete(ctx context.Context, key string) error {
+	rc.cache.Del(key)
+	if !rc.asyncWrites {
+		rc.cache.Wait()
+	}
+	return nil
+}
+
+// Incr increases the counter, supports int,int32,int64,uint,uint32,uint64.
+func (rc *Cache) Incr(ctx context.Context, key string) error {
+	return rc.update(key, cache.IncrValue)
+}
+
+// Decr decreases the counter.
+func (rc *Cache) Decr(ctx context.Context, key string) error {
+	return rc.update(key, cache.DecrValue)
+}
+
+// update replaces the value of key by fn and keeps the TTL
+func (rc *Cache) update(key string, fn func(interface{}) (interface{}, error)) error {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	v, ok := rc.cache.Get(key)
+	if !ok {
+		return cache.ErrKeyNotExist
+	}
+	v, err := fn(v)
+	if err != nil {
+		return err
+	}
+	ttl, ok := rc.cache.GetTTL(key)
+	if !ok {
+		return cache.ErrKeyNotExist
+	}
+	// the counter is updated synchronously, so the next Incr reads the new value
+	rc.cache.SetWithTTL(key, v, rc.cost(v), ttl)
+	rc.cache.Wait()
+	return nil
+}
+
+// IsExist checks whether key exists and isn't expired.
+func (rc *Cache) IsExist(ctx context.Context, key string) (bool, error) {
+	_, ok := rc.cache.Get(key)
+	return ok, nil
+}
+
+// ClearAll deletes all the items.
+func (rc *Cache) ClearAll(context.Context) error {
+	rc.cache.Clear()
+	return nil
+}
+
+// Metrics returns the metrics such as the hit ratio, it's nil unless "metrics" is true in the config
+func (rc *Cache) Metrics() *ristretto.Metrics {
+	return rc.cache.Metrics
+}
+
+// Evictions returns how many keys are evicted for the cost, it's 0 unless "metrics" is true in the config
+func (rc *Cache) Evictions() uint64 {
+	return rc.cache.Metrics.KeysEvicted()
+}
+
+// StartAndGC creates the ristretto cache, the expired items are removed by ristretto.
+// config is like {"numCounters":1000000,"maxCost":100000,"bufferItems":64,"costBySize":false,"metrics":false}
+func (rc *Cache) StartAndGC(config string) error {
+	cf := ristrettoConfig{}
+	if strings.TrimSpace(config) != "" {
+		if err := json.Unmarshal([]byte(config), &cf); err != nil {
+			return berror.Wrapf(err, cache.InvalidRistrettoCacheCfg, "invalid config, please check your input: %s", config)
+		}
+	}
+	if cf.NumCounters < 0 || cf.MaxCost < 0 || cf.BufferItems < 0 {
+		return berror.Errorf(cache.InvalidRistrettoCacheCfg, "invalid config, the sizes must be positive: %s", config)
+	}
+	if cf.MaxCost == 0 {
+		cf.MaxCost = DefaultMaxCost
+	}
+	if cf.NumCounters == 0 {
+		cf.NumCounters = 10 * cf.MaxCost
+	}
+	if cf.BufferItems == 0 {
+		cf.BufferItems = defaultBufferItems
+	}
+
+	c, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: cf.NumCounters,
+		MaxCost:     cf.MaxCost,
+		BufferItems: cf.BufferItems,
+		Metrics:     cf.Metrics,
+		// the cost is exactly the one passed to SetWithTTL
+		IgnoreInternalCost: true,
+	})
+	if err != nil {
+		return berror.Wrapf(err, cache.InvalidRistrettoCacheCfg, "could not create ristretto cache: %s", config)
+	}
+	if rc.cache != nil {
+		rc.cache.Close()
+	}
+	rc.cache = c
+	rc.costBySize = cf.CostBySize
+	rc.asyncWrites = cf.AsyncWrites
+	return nil
+}
+
+func init() {
+	cache.Register("ristretto", NewRistrettoCache)
+}
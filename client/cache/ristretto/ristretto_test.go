@@ -0,0 +1,102 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ristretto
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/client/cache"
+)
+
+func TestRistrettoCache(t *testing.T) {
+	ctx := context.Background()
+	bm, err := cache.NewCache("ristretto", `{"maxCost":1000,"metrics":true}`)
+	assert.Nil(t, err)
+
+	assert.Nil(t, bm.Put(ctx, "astaxie", 1, time.Second))
+	res, _ := bm.IsExist(ctx, "astaxie")
+	assert.True(t, res)
+	v, err := bm.Get(ctx, "astaxie")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+
+	assert.Nil(t, bm.Incr(ctx, "astaxie"))
+	v, _ = bm.Get(ctx, "astaxie")
+	assert.Equal(t, 2, v)
+	assert.Nil(t, bm.Decr(ctx, "astaxie"))
+	v, _ = bm.Get(ctx, "astaxie")
+	assert.Equal(t, 1, v)
+
+	time.Sleep(2 * time.Second)
+	res, _ = bm.IsExist(ctx, "astaxie")
+	assert.False(t, res)
+	_, err = bm.Get(ctx, "astaxie")
+	assert.True(t, errors.Is(err, cache.ErrKeyNotExist))
+	assert.True(t, errors.Is(bm.Incr(ctx, "astaxie"), cache.ErrKeyNotExist))
+
+	assert.Nil(t, bm.Put(ctx, "astaxie", "author", 0))
+	assert.Nil(t, bm.Put(ctx, "astaxie1", "author1", 0))
+	vv, err := bm.GetMulti(ctx, []string{"astaxie", "astaxie1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"author", "author1"}, vv)
+	vv, err = bm.GetMulti(ctx, []string{"astaxie0", "astaxie1"})
+	assert.Equal(t, []interface{}{nil, "author1"}, vv)
+	assert.True(t, strings.Contains(err.Error(), "key isn't exist"))
+	assert.NotNil(t, bm.Incr(ctx, "astaxie"))
+
+	assert.Nil(t, bm.Delete(ctx, "astaxie"))
+	res, _ = bm.IsExist(ctx, "astaxie")
+	assert.False(t, res)
+
+	assert.Nil(t, bm.ClearAll(ctx))
+	res, _ = bm.IsExist(ctx, "astaxie1")
+	assert.False(t, res)
+	assert.NotNil(t, bm.(*Cache).Metrics())
+}
+
+func TestRistrettoCacheConcurrentIncr(t *testing.T) {
+	ctx := context.Background()
+	bm, err := cache.NewCache("ristretto", "")
+	assert.Nil(t, err)
+	assert.Nil(t, bm.Put(ctx, "counter", int64(0), 0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Nil(t, bm.Incr(ctx, "counter"))
+			}
+		}()
+	}
+	wg.Wait()
+	v, _ := bm.Get(ctx, "counter")
+	assert.Equal(t, int64(1000), v)
+}
+
+func TestRistrettoCacheConfig(t *testing.T) {
+	_, err := cache.NewCache("ristretto", `{"maxCost":-1}`)
+	assert.NotNil(t, err)
+	_, err = cache.NewCache("ristretto", `{"maxCost":`)
+	assert.NotNil(t, err)
+}
@@ -30,11 +30,12 @@ type SingleflightCache struct {
 	group      *singleflight.Group
 	expiration time.Duration
 	loadFunc   func(ctx context.Context, key string) (any, error)
+	opts       loadOptions
 }
 
-// NewSingleflightCache create SingleflightCache
+// NewSingleflightCache create SingleflightCache, it's a LoadingCache
 func NewSingleflightCache(c Cache, expiration time.Duration,
-	loadFunc func(ctx context.Context, key string) (any, error),
+	loadFunc func(ctx context.Context, key string) (any, error), opts ...LoadOption,
 ) (Cache, error) {
 	if loadFunc == nil {
 		return nil, berror.Error(InvalidLoadFunc, "loadFunc cannot be nil")
@@ -44,21 +45,42 @@ func NewSingleflightCache(c Cache, expiration time.Duration,
 		group:      &singleflight.Group{},
 		expiration: expiration,
 		loadFunc:   loadFunc,
+		opts:       newLoadOptions(opts),
 	}, nil
 }
 
 // Get In the Get method, single flight is used to load data and write back the cache.
+// It returns ErrKeyNotExist if the key is cached as NotFound.
 func (s *SingleflightCache) Get(ctx context.Context, key string) (any, error) {
-	val, err := s.Cache.Get(ctx, key)
-	if val == nil || err != nil {
-		val, err, _ = s.group.Do(key, func() (interface{}, error) {
-			v, er := s.loadFunc(ctx, key)
-			if er != nil {
-				return nil, berror.Wrap(er, LoadFuncFailed, "cache unable to load data")
-			}
-			er = s.Cache.Put(ctx, key, v, s.expiration)
-			return v, er
-		})
+	return s.GetOrLoad(ctx, key, s.expiration, func(ctx context.Context) (any, error) {
+		return s.loadFunc(ctx, key)
+	})
+}
+
+// GetMulti returns nil and the error of ErrKeyNotExist for the keys cached as NotFound.
+func (s *SingleflightCache) GetMulti(ctx context.Context, keys []string) ([]any, error) {
+	return getMulti(ctx, s.Cache, keys)
+}
+
+// GetOrLoad calls loader instead of loadFunc if the Cache returns value nil or non-nil error.
+// The loader of the first caller is shared by the concurrent callers, so is its ctx.
+// The loader returns ErrKeyNotExist if the key doesn't exist, which is cached if WithNegativeTTL is used.
+func (s *SingleflightCache) GetOrLoad(ctx context.Context, key string, ttl time.Duration,
+	loader func(ctx context.Context) (any, error),
+) (any, error) {
+	if loader == nil {
+		return nil, berror.Error(InvalidLoadFunc, "loader cannot be nil")
+	}
+	val, err := cached(ctx, s.Cache, key)
+	if val != nil || err != nil {
+		return val, err
 	}
+	val, err, _ = s.group.Do(key, func() (interface{}, error) {
+		// the key may be loaded by the flight which finished just now
+		if v, er := cached(ctx, s.Cache, key); v != nil || er != nil {
+			return v, er
+		}
+		return s.opts.load(ctx, s.Cache, key, ttl, loader)
+	})
 	return val, err
 }
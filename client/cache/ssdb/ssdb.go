@@ -18,6 +18,8 @@ import (
 type Cache struct {
 	conn     *ssdb.Client
 	conninfo []string
+	// codec encodes the values, the values must be string if it's nil
+	codec cache.Codec
 }
 
 // NewSsdbCache creates new ssdb adapter.
@@ -29,34 +31,44 @@ func NewSsdbCache() cache.Cache {
 func (rc *Cache) Get(ctx context.Context, key string) (interface{}, error) {
 	value, err := rc.conn.Get(key)
 	if err == nil {
-		return value, nil
+		return rc.decode(value)
 	}
 	return nil, berror.Wrapf(err, cache.SsdbCacheCurdFailed, "could not get value, key: %s", key)
 }
 
 // GetMulti gets one or keys values from ssdb.
+// The keys are read by multi_get in chunks of cache.MultiBatchSize, and all the chunks are sent in one pipeline.
 func (rc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
 	size := len(keys)
 	values := make([]interface{}, size)
 
-	res, err := rc.conn.Do("multi_get", keys)
+	resps, err := rc.pipeline(keys, func(chunk []string) []interface{} {
+		return []interface{}{"multi_get", chunk}
+	})
 	if err != nil {
 		return values, berror.Wrapf(err, cache.SsdbCacheCurdFailed, "multi_get failed, key: %v", keys)
 	}
 
-	resSize := len(res)
-	keyIdx := make(map[string]int)
-	for i := 1; i < resSize; i += 2 {
-		keyIdx[res[i]] = i
+	kvs := make(map[string]string, size)
+	for _, res := range resps {
+		for i := 1; i+1 < len(res); i += 2 {
+			kvs[res[i]] = res[i+1]
+		}
 	}
 
 	keysErr := make([]string, 0)
 	for i, ki := range keys {
-		if _, ok := keyIdx[ki]; !ok {
+		data, ok := kvs[ki]
+		if !ok {
 			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", ki, "key not exist"))
 			continue
 		}
-		values[i] = res[keyIdx[ki]+1]
+		v, err := rc.decode(data)
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", ki, err.Error()))
+			continue
+		}
+		values[i] = v
 	}
 
 	if len(keysErr) != 0 {
@@ -66,6 +78,95 @@ func (rc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, er
 	return values, nil
 }
 
+// PutMulti puts vals[i] by keys[i], see cache.MultiCache.
+// The values are put by multi_set in chunks of cache.MultiBatchSize if timeout is negative,
+// otherwise by setx one by one because multi_set doesn't set the ttl. All the commands are sent in one pipeline.
+func (rc *Cache) PutMulti(ctx context.Context, keys []string, vals []interface{}, timeout time.Duration) error {
+	if len(keys) != len(vals) {
+		return berror.Errorf(cache.InvalidMultiValues, "%d keys but %d values", len(keys), len(vals))
+	}
+	kvs := make(map[string]string, len(keys))
+	for i, key := range keys {
+		v, err := rc.encode(vals[i])
+		if err != nil {
+			return err
+		}
+		kvs[key] = v
+	}
+	var (
+		resps [][]string
+		err   error
+	)
+	if ttl := int(timeout / time.Second); ttl < 0 {
+		resps, err = rc.pipeline(keys, func(chunk []string) []interface{} {
+			args := make([]string, 0, 2*len(chunk))
+			for _, key := range chunk {
+				args = append(args, key, kvs[key])
+			}
+			return []interface{}{"multi_set", args}
+		})
+	} else {
+		cmds := make([][]interface{}, len(keys))
+		for i, key := range keys {
+			cmds[i] = []interface{}{"setx", key, kvs[key], ttl}
+		}
+		resps, err = rc.send(cmds)
+	}
+	if err != nil {
+		return berror.Wrapf(err, cache.SsdbCacheCurdFailed, "multi_set or setx failed, key: %v", keys)
+	}
+	for _, resp := range resps {
+		if len(resp) != 2 || resp[0] != "ok" {
+			return berror.Errorf(cache.SsdbBadResponse, "the response from SSDB server is invalid: %v", resp)
+		}
+	}
+	return nil
+}
+
+// DeleteMulti deletes keys by multi_del in chunks of cache.MultiBatchSize,
+// and all the chunks are sent in one pipeline, see cache.MultiCache.
+func (rc *Cache) DeleteMulti(ctx context.Context, keys []string) error {
+	_, err := rc.pipeline(keys, func(chunk []string) []interface{} {
+		return []interface{}{"multi_del", chunk}
+	})
+	return berror.Wrapf(err, cache.SsdbCacheCurdFailed, "multi_del failed: %v", keys)
+}
+
+// pipeline sends the commands built by cmd for the chunks of keys in one pipeline
+func (rc *Cache) pipeline(keys []string, cmd func(chunk []string) []interface{}) ([][]string, error) {
+	cmds := make([][]interface{}, 0, len(keys)/cache.MultiBatchSize+1)
+	_ = cache.ChunkKeys(keys, func(_ int, chunk []string) error {
+		cmds = append(cmds, cmd(chunk))
+		return nil
+	})
+	return rc.send(cmds)
+}
+
+// send writes all the commands before reading their responses, so they take one round trip.
+// All the responses are read even if some commands fail, otherwise they would be read by the next command.
+func (rc *Cache) send(cmds [][]interface{}) ([][]string, error) {
+	for _, cmd := range cmds {
+		if err := rc.conn.Send(cmd...); err != nil {
+			return nil, err
+		}
+	}
+	resps := make([][]string, len(cmds))
+	var firstErr error
+	for i := range cmds {
+		resp, err := rc.conn.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) == 0 || (resp[0] != "ok" && resp[0] != "not_found") {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("bad response: %v", resp)
+			}
+		}
+		resps[i] = resp
+	}
+	return resps, firstErr
+}
+
 // DelMulti deletes one or more keys from memcache
 func (rc *Cache) DelMulti(keys []string) error {
 	_, err := rc.conn.Do("multi_del", keys)
@@ -73,14 +174,13 @@ func (rc *Cache) DelMulti(keys []string) error {
 }
 
 // Put puts value into memcache.
-// value:  must be of type string
+// value:  must be of type string if the codec isn't set
 func (rc *Cache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
-	v, ok := val.(string)
-	if !ok {
-		return berror.Errorf(cache.InvalidSsdbCacheValue, "value must be string: %v", val)
+	v, err := rc.encode(val)
+	if err != nil {
+		return err
 	}
 	var resp []string
-	var err error
 	ttl := int(timeout / time.Second)
 	if ttl < 0 {
 		resp, err = rc.conn.Do("set", key, v)
@@ -96,6 +196,60 @@ func (rc *Cache) Put(ctx context.Context, key string, val interface{}, timeout t
 	return berror.Errorf(cache.SsdbBadResponse, "the response from SSDB server is invalid: %v", resp)
 }
 
+// encode encodes val by the codec, val must be string if the codec isn't set
+func (rc *Cache) encode(val interface{}) (string, error) {
+	if rc.codec != nil {
+		data, err := cache.EncodeValue(rc.codec, val)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+	return "", berror.Errorf(cache.InvalidSsdbCacheValue, "value must be string: %v", val)
+}
+
+// GetInto decodes the value of key into v by the codec.
+func (rc *Cache) GetInto(ctx context.Context, key string, v interface{}) error {
+	if rc.codec == nil {
+		return berror.Error(cache.InvalidCodec, "the codec of ssdb isn't set")
+	}
+	value, err := rc.conn.Get(key)
+	if err != nil {
+		return berror.Wrapf(err, cache.SsdbCacheCurdFailed, "could not get value, key: %s", key)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return cache.ErrKeyNotExist
+	}
+	return cache.DecodeValue(rc.codec, []byte(str), v)
+}
+
+// SetCodec sets the codec of the values, it should be called before the cache is used.
+func (rc *Cache) SetCodec(c cache.Codec) {
+	rc.codec = c
+}
+
+// Codec returns the codec of the values, it's nil if the codec isn't set
+func (rc *Cache) Codec() cache.Codec {
+	return rc.codec
+}
+
+// decode returns value itself if the codec isn't set
+func (rc *Cache) decode(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if rc.codec == nil || !ok {
+		return value, nil
+	}
+	var v interface{}
+	if err := cache.DecodeValue(rc.codec, []byte(str), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 // Delete deletes a value in memcache.
 func (rc *Cache) Delete(ctx context.Context, key string) error {
 	_, err := rc.conn.Del(key)
@@ -150,6 +304,41 @@ func (rc *Cache) ClearAll(context.Context) error {
 	return berror.Wrap(err, cache.SsdbCacheCurdFailed, "scan failed")
 }
 
+// DeleteByPrefix deletes the keys starting with prefix in ssdb.
+// The keys are sorted in ssdb, so only the keys with prefix are scanned.
+func (rc *Cache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	if prefix != "" {
+		// the start of scan is exclusive
+		if _, err := rc.conn.Do("del", prefix); err != nil {
+			return berror.Wrapf(err, cache.SsdbCacheCurdFailed, "del failed: %s", prefix)
+		}
+	}
+	keyStart, limit := prefix, 50
+	for {
+		resp, err := rc.Scan(keyStart, "", limit)
+		if err != nil {
+			return berror.Wrap(err, cache.SsdbCacheCurdFailed, "scan failed")
+		}
+		keys := []string{}
+		for i := 1; i < len(resp); i += 2 {
+			if !strings.HasPrefix(resp[i], prefix) {
+				break
+			}
+			keys = append(keys, resp[i])
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		if _, err = rc.conn.Do("multi_del", keys); err != nil {
+			return berror.Wrapf(err, cache.SsdbCacheCurdFailed, "multi_del failed: %v", keys)
+		}
+		if len(keys) < limit {
+			return nil
+		}
+		keyStart = keys[len(keys)-1]
+	}
+}
+
 // Scan key all cached in ssdb.
 func (rc *Cache) Scan(keyStart string, keyEnd string, limit int) ([]string, error) {
 	resp, err := rc.conn.Do("scan", keyStart, keyEnd, limit)
@@ -160,7 +349,7 @@ func (rc *Cache) Scan(keyStart string, keyEnd string, limit int) ([]string, erro
 }
 
 // StartAndGC starts the memcache adapter.
-// config: must be in the format {"conn":"connection info"}.
+// config: must be in the format {"conn":"connection info","codec":"codec name"}, the codec is optional.
 // If an error occurs during connection, an error is returned
 func (rc *Cache) StartAndGC(config string) error {
 	var cf map[string]string
@@ -173,6 +362,13 @@ func (rc *Cache) StartAndGC(config string) error {
 		return berror.Wrapf(err, cache.InvalidSsdbCacheCfg,
 			"Missing conn field: %s", config)
 	}
+	if name, ok := cf["codec"]; ok {
+		c, err := cache.GetCodec(name)
+		if err != nil {
+			return err
+		}
+		rc.codec = c
+	}
 	rc.conninfo = strings.Split(cf["conn"], ";")
 	return rc.connectInit()
 }
@@ -101,6 +101,38 @@ func TestSsdbcacheCache(t *testing.T) {
 	assert.False(t, e2)
 }
 
+func TestSsdbcacheMulti(t *testing.T) {
+	ssdbAddr := os.Getenv("SSDB_ADDR")
+	if ssdbAddr == "" {
+		ssdbAddr = "127.0.0.1:8888"
+	}
+	bm, err := cache.NewCache("ssdb", fmt.Sprintf(`{"conn": "%s"}`, ssdbAddr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	defer func(size int) {
+		cache.MultiBatchSize = size
+	}(cache.MultiBatchSize)
+	// the keys are split into chunks
+	cache.MultiBatchSize = 2
+
+	keys := []string{"k1", "k2", "k3", "k4", "k5"}
+	vals := []interface{}{"v1", "v2", "v3", "v4", "v5"}
+	// by multi_set
+	assert.Nil(t, cache.PutMulti(ctx, bm, keys, vals, -1))
+	res, err := bm.GetMulti(ctx, keys)
+	assert.Nil(t, err)
+	assert.Equal(t, vals, res)
+
+	// by setx
+	assert.Nil(t, cache.PutMulti(ctx, bm, keys[:2], vals[:2], time.Minute))
+	assert.Nil(t, cache.DeleteMulti(ctx, bm, keys[:3]))
+	res, err = bm.GetMulti(ctx, keys)
+	code, _ := berror.FromError(err)
+	assert.Equal(t, cache.MultiGetFailed, code)
+	assert.Equal(t, []interface{}{nil, nil, nil, "v4", "v5"}, res)
+	assert.Nil(t, cache.DeleteMulti(ctx, bm, keys))
+}
+
 func TestReadThroughCache_ssdb_Get(t *testing.T) {
 	bm, err := cache.NewCache("ssdb", fmt.Sprintf(`{"conn": "%s"}`, "127.0.0.1:8888"))
 	assert.Nil(t, err)
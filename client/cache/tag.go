@@ -0,0 +1,34 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// TaggedCache is a Cache whose items can be invalidated by tag.
+// usage:
+//
+//	c.(cache.TaggedCache).PutWithTags(ctx, "user:42:profile", profile, time.Hour, "user:42")
+//	c.(cache.TaggedCache).PutWithTags(ctx, "user:42:orders", orders, time.Hour, "user:42", "orders")
+//	c.(cache.TaggedCache).InvalidateTag(ctx, "user:42") // both keys are deleted
+type TaggedCache interface {
+	Cache
+	// PutWithTags puts the value like Put and adds key to the index of every tag.
+	PutWithTags(ctx context.Context, key string, val interface{}, timeout time.Duration, tags ...string) error
+	// InvalidateTag deletes all the keys of tag and the index of tag atomically.
+	InvalidateTag(ctx context.Context, tag string) error
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// ThroughCacheOption configures ThroughCache
+type ThroughCacheOption func(c *ThroughCache)
+
+// WithLoader loads the missing keys from the source of truth, e.g. the database, see NewSingleflightCache.
+// loader returns ErrKeyNotExist if the key doesn't exist in the source either, which is cached by WithNegativeTTL.
+func WithLoader(loader func(ctx context.Context, key string) (any, error), opts ...LoadOption) ThroughCacheOption {
+	return func(c *ThroughCache) {
+		c.loader = loader
+		c.loadOpts = opts
+	}
+}
+
+// WithWriter writes the values put into the cache to the source of truth, see NewWriteThroughCache
+func WithWriter(writer func(ctx context.Context, key string, val any) error) ThroughCacheOption {
+	return func(c *ThroughCache) {
+		c.writer = writer
+	}
+}
+
+// WithDeleter deletes the keys deleted from the cache from the source of truth
+func WithDeleter(deleter func(ctx context.Context, key string) error) ThroughCacheOption {
+	return func(c *ThroughCache) {
+		c.deleter = deleter
+	}
+}
+
+// WithWriteBehind makes the writer and the deleter asynchronous, the writes are queued and run by the workers.
+// The writes of the same key are run by the same worker in order. Put and Delete block if the queue is full.
+func WithWriteBehind(workers, queueSize int) ThroughCacheOption {
+	return func(c *ThroughCache) {
+		c.workers = workers
+		c.queueSize = queueSize
+	}
+}
+
+// WithWriteBehindErrorHandler handles the errors of the asynchronous writes, they're dropped by default.
+// val is nil if the deleter fails.
+func WithWriteBehindErrorHandler(fn func(key string, val any, err error)) ThroughCacheOption {
+	return func(c *ThroughCache) {
+		c.onWriteError = fn
+	}
+}
+
+// writeTask is the write queued by the write-behind workers, it's deleted if del is true
+type writeTask struct {
+	key string
+	val any
+	del bool
+}
+
+// ThroughCache is a decorator loading the missing keys by the loader (read-through) with SingleflightCache,
+// and writing the values to the source of truth by the writer before they're cached (write-through) with WriteThroughCache,
+// or after by the background workers if WithWriteBehind is used (write-behind).
+// The write-behind ThroughCache must be closed by Close, so the queued writes are flushed.
+// usage:
+//
+//	bm, err := cache.NewThroughCache(cache.NewMemoryCache(), time.Minute,
+//		cache.WithLoader(func(ctx context.Context, key string) (any, error) {
+//			return db.Find(ctx, key)
+//		}),
+//		cache.WithWriter(func(ctx context.Context, key string, val any) error {
+//			return db.Save(ctx, key, val)
+//		}))
+type ThroughCache struct {
+	Cache
+	loader   func(ctx context.Context, key string) (any, error)
+	loadOpts []LoadOption
+	writer   func(ctx context.Context, key string, val any) error
+	deleter  func(ctx context.Context, key string) error
+	// readThrough and writeThrough are created by the loader and the writer
+	readThrough  Cache
+	writeThrough *WriteThroughCache
+
+	workers      int
+	queueSize    int
+	queues       []chan writeTask
+	onWriteError func(key string, val any, err error)
+	// mu guards closed, so no task is queued after the queues are closed
+	mu      sync.RWMutex
+	closed  bool
+	flushed sync.WaitGroup
+}
+
+// NewThroughCache creates ThroughCache, the loaded values are cached with expiration.
+func NewThroughCache(c Cache, expiration time.Duration, opts ...ThroughCacheOption) (*ThroughCache, error) {
+	if c == nil {
+		return nil, berror.Error(InvalidInitParameters, "cache can not be nil")
+	}
+	tc := &ThroughCache{Cache: c}
+	for _, opt := range opts {
+		opt(tc)
+	}
+	var err error
+	if tc.loader != nil {
+		if tc.readThrough, err = NewSingleflightCache(c, expiration, tc.loader, tc.loadOpts...); err != nil {
+			return nil, err
+		}
+	}
+	if tc.writer != nil && tc.workers <= 0 {
+		if tc.writeThrough, err = NewWriteThroughCache(c, tc.writer); err != nil {
+			return nil, err
+		}
+	}
+	if tc.workers > 0 {
+		if tc.writer == nil && tc.deleter == nil {
+			return nil, berror.Error(InvalidInitParameters, "the write-behind cache needs the writer or the deleter")
+		}
+		tc.queues = make([]chan writeTask, tc.workers)
+		for i := range tc.queues {
+			tc.queues[i] = make(chan writeTask, tc.queueSize)
+			tc.flushed.Add(1)
+			go tc.work(tc.queues[i])
+		}
+	}
+	return tc, nil
+}
+
+// Get loads the missing key by the loader and caches it, the concurrent loads of the same key are deduplicated.
+// It returns ErrKeyNotExist if the key is cached as NotFound.
+func (c *ThroughCache) Get(ctx context.Context, key string) (any, error) {
+	if c.readThrough == nil {
+		return c.Cache.Get(ctx, key)
+	}
+	return c.readThrough.Get(ctx, key)
+}
+
+// GetMulti loads the missing keys one by one by the loader.
+func (c *ThroughCache) GetMulti(ctx context.Context, keys []string) ([]any, error) {
+	vals, err := c.Cache.GetMulti(ctx, keys)
+	if c.readThrough == nil {
+		return vals, err
+	}
+	if len(vals) != len(keys) {
+		vals = make([]any, len(keys))
+	}
+	keysErr := make([]string, 0)
+	for i, val := range vals {
+		if val != nil && !IsNotFound(val) {
+			continue
+		}
+		if vals[i], err = c.readThrough.Get(ctx, keys[i]); err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", keys[i], err.Error()))
+		}
+	}
+	if len(keysErr) == 0 {
+		return vals, nil
+	}
+	return vals, berror.Error(MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// Put writes val by the writer before caching it, the value isn't cached if the writer fails.
+// The write is queued after val is cached if WithWriteBehind is used.
+func (c *ThroughCache) Put(ctx context.Context, key string, val any, timeout time.Duration) error {
+	if c.writeThrough != nil {
+		return c.writeThrough.Set(ctx, key, val, timeout)
+	}
+	if err := c.Cache.Put(ctx, key, val, timeout); err != nil {
+		return err
+	}
+	if c.writer != nil && c.queues != nil {
+		return c.enqueue(ctx, writeTask{key: key, val: val})
+	}
+	return nil
+}
+
+// Delete deletes key by the deleter before deleting it from the cache,
+// or queues the deletion after deleting it if WithWriteBehind is used.
+func (c *ThroughCache) Delete(ctx context.Context, key string) error {
+	if c.deleter == nil {
+		return c.Cache.Delete(ctx, key)
+	}
+	if c.queues != nil {
+		if err := c.Cache.Delete(ctx, key); err != nil {
+			return err
+		}
+		return c.enqueue(ctx, writeTask{key: key, del: true})
+	}
+	if err := c.deleter(ctx, key); err != nil {
+		return berror.Wrap(err, PersistCacheFailed, fmt.Sprintf("could not delete key: %s", key))
+	}
+	return c.Cache.Delete(ctx, key)
+}
+
+// enqueue sends t to the worker of its key
+func (c *ThroughCache) enqueue(ctx context.Context, t writeTask) error {
+	if (t.del && c.deleter == nil) || (!t.del && c.writer == nil) {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return berror.Errorf(ThroughCacheClosed, "could not queue the write of key: %s", t.key)
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(t.key))
+	select {
+	case c.queues[h.Sum32()%uint32(len(c.queues))] <- t:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *ThroughCache) work(queue chan writeTask) {
+	defer c.flushed.Done()
+	for t := range queue {
+		var err error
+		if t.del {
+			err = c.deleter(context.Background(), t.key)
+		} else {
+			err = c.writer(context.Background(), t.key, t.val)
+		}
+		if err != nil && c.onWriteError != nil {
+			c.onWriteError(t.key, t.val, err)
+		}
+	}
+}
+
+// Close stops accepting the writes and waits until the queued writes are done or ctx is done.
+// It does nothing if WithWriteBehind isn't used.
+func (c *ThroughCache) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.closed && c.queues != nil {
+		for _, q := range c.queues {
+			close(q)
+		}
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.flushed.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// throughDB is the source of truth of ThroughCache
+type throughDB struct {
+	mu    sync.Mutex
+	kvs   map[string]any
+	loads atomic.Int32
+	// writes records the writes in order, the deletions are recorded as "-key"
+	writes []string
+	err    error
+}
+
+func newThroughDB() *throughDB {
+	return &throughDB{kvs: map[string]any{"k1": "v1", "k2": "v2"}}
+}
+
+func (db *throughDB) load(ctx context.Context, key string) (any, error) {
+	db.loads.Add(1)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if v, ok := db.kvs[key]; ok {
+		return v, nil
+	}
+	return nil, ErrKeyNotExist
+}
+
+func (db *throughDB) write(ctx context.Context, key string, val any) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.err != nil {
+		return db.err
+	}
+	db.kvs[key] = val
+	db.writes = append(db.writes, fmt.Sprintf("%s=%v", key, val))
+	return nil
+}
+
+func (db *throughDB) delete(ctx context.Context, key string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.kvs, key)
+	db.writes = append(db.writes, "-"+key)
+	return nil
+}
+
+func TestThroughCacheReadThrough(t *testing.T) {
+	db := newThroughDB()
+	bm, err := NewThroughCache(NewMemoryCache(), time.Minute, WithLoader(db.load))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := bm.Get(ctx, "k1")
+			assert.Nil(t, err)
+			assert.Equal(t, "v1", val)
+		}()
+	}
+	wg.Wait()
+	val, err := bm.Get(ctx, "k1")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", val)
+	assert.True(t, db.loads.Load() < 10)
+
+	_, err = bm.Get(ctx, "missing")
+	assert.True(t, errors.Is(err, ErrKeyNotExist))
+
+	vals, err := bm.GetMulti(ctx, []string{"k1", "k2", "missing"})
+	code, _ := berror.FromError(err)
+	assert.Equal(t, MultiGetFailed, code)
+	assert.Equal(t, []any{"v1", "v2", nil}, vals)
+
+	_, err = NewThroughCache(nil, time.Minute)
+	code, _ = berror.FromError(err)
+	assert.Equal(t, InvalidInitParameters, code)
+}
+
+func TestThroughCacheNegativeTTL(t *testing.T) {
+	db := newThroughDB()
+	mem := NewMemoryCache()
+	bm, err := NewThroughCache(mem, time.Minute, WithLoader(db.load, WithNegativeTTL(time.Minute)))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err = bm.Get(ctx, "missing")
+		assert.True(t, errors.Is(err, ErrKeyNotExist))
+	}
+	assert.Equal(t, int32(1), db.loads.Load())
+	val, _ := mem.Get(ctx, "missing")
+	assert.True(t, IsNotFound(val))
+
+	vals, err := bm.GetMulti(ctx, []string{"k1", "missing"})
+	assert.Equal(t, []any{"v1", nil}, vals)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(2), db.loads.Load())
+}
+
+func TestThroughCacheWriteThrough(t *testing.T) {
+	db := newThroughDB()
+	mem := NewMemoryCache()
+	bm, err := NewThroughCache(mem, time.Minute, WithWriter(db.write), WithDeleter(db.delete))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	assert.Nil(t, bm.Put(ctx, "k3", "v3", time.Minute))
+	assert.Equal(t, "v3", db.kvs["k3"])
+	val, _ := mem.Get(ctx, "k3")
+	assert.Equal(t, "v3", val)
+
+	assert.Nil(t, bm.Delete(ctx, "k3"))
+	_, ok := db.kvs["k3"]
+	assert.False(t, ok)
+
+	// the value isn't cached if the writer fails
+	db.err = errors.New("db is down")
+	err = bm.Put(ctx, "k4", "v4", time.Minute)
+	code, _ := berror.FromError(err)
+	assert.Equal(t, PersistCacheFailed, code)
+	res, _ := mem.IsExist(ctx, "k4")
+	assert.False(t, res)
+	// Close does nothing without WithWriteBehind
+	assert.Nil(t, bm.Close(ctx))
+}
+
+func TestThroughCacheWriteBehind(t *testing.T) {
+	db := newThroughDB()
+	var failed atomic.Int32
+	bm, err := NewThroughCache(NewMemoryCache(), time.Minute,
+		WithWriter(db.write), WithDeleter(db.delete), WithWriteBehind(4, 16),
+		WithWriteBehindErrorHandler(func(key string, val any, err error) {
+			failed.Add(1)
+		}))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, bm.Put(ctx, "counter", i, time.Minute))
+	}
+	assert.Nil(t, bm.Delete(ctx, "k1"))
+	assert.Nil(t, bm.Close(ctx))
+
+	// the writes of the same key are in order
+	var counterWrites []string
+	for _, w := range db.writes {
+		if w != "-k1" {
+			counterWrites = append(counterWrites, w)
+		}
+	}
+	assert.Equal(t, 100, len(counterWrites))
+	assert.Equal(t, "counter=99", counterWrites[99])
+	assert.Equal(t, 99, db.kvs["counter"])
+	_, ok := db.kvs["k1"]
+	assert.False(t, ok)
+	assert.Equal(t, int32(0), failed.Load())
+
+	err = bm.Put(ctx, "k5", "v5", time.Minute)
+	code, _ := berror.FromError(err)
+	assert.Equal(t, ThroughCacheClosed, code)
+
+	_, err = NewThroughCache(NewMemoryCache(), time.Minute, WithWriteBehind(1, 1))
+	code, _ = berror.FromError(err)
+	assert.Equal(t, InvalidInitParameters, code)
+}
+
+func TestThroughCacheWriteBehindError(t *testing.T) {
+	db := newThroughDB()
+	db.err = errors.New("db is down")
+	var failedKey atomic.Value
+	bm, err := NewThroughCache(NewMemoryCache(), time.Minute, WithWriter(db.write), WithWriteBehind(1, 1),
+		WithWriteBehindErrorHandler(func(key string, val any, err error) {
+			failedKey.Store(key)
+		}))
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	// the value is cached even if the asynchronous write fails
+	assert.Nil(t, bm.Put(ctx, "k3", "v3", time.Minute))
+	assert.Nil(t, bm.Close(ctx))
+	assert.Equal(t, "k3", failedKey.Load())
+	val, _ := bm.Get(ctx, "k3")
+	assert.Equal(t, "v3", val)
+}
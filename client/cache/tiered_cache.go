@@ -0,0 +1,239 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/berror"
+	"github.com/beego/beego/v2/core/pubsub"
+)
+
+// DefaultLocalTTL is the max lifetime of the local copies if WithLocalTTL isn't used
+const DefaultLocalTTL = time.Minute
+
+// Invalidation is broadcast when the keys of TieredCache are changed
+type Invalidation struct {
+	Keys []string `json:"keys"`
+	// Prefixes are deleted by DeleteByPrefix
+	Prefixes []string `json:"prefixes,omitempty"`
+	// All is true if the remote cache is cleared
+	All bool `json:"all"`
+	// Origin is the id of the TieredCache which publishes the invalidation, it ignores its own invalidations
+	Origin string `json:"origin"`
+}
+
+// InvalidationBus broadcasts the invalidations between the instances,
+// so the other instances drop the stale local copies
+type InvalidationBus = pubsub.Bus[Invalidation]
+
+// TieredCacheOption configures TieredCache
+type TieredCacheOption func(c *TieredCache)
+
+// WithInvalidationBus publishes the changed keys to bus and drops the local copies of the keys changed by the other instances
+func WithInvalidationBus(bus InvalidationBus) TieredCacheOption {
+	return func(c *TieredCache) {
+		c.bus = bus
+	}
+}
+
+// WithLocalTTL limits the lifetime of the local copies, it bounds the staleness if an invalidation is lost
+func WithLocalTTL(ttl time.Duration) TieredCacheOption {
+	return func(c *TieredCache) {
+		c.localTTL = ttl
+	}
+}
+
+// TieredCache checks the local cache, e.g. memory, before the remote one, e.g. redis.
+// The writes go to the remote cache first, and the other instances are told to drop their local copies by the bus.
+type TieredCache struct {
+	local    Cache
+	remote   Cache
+	bus      InvalidationBus
+	localTTL time.Duration
+	origin   string
+}
+
+// NewTieredCache creates TieredCache, the subscription of the invalidations stops when ctx is done
+func NewTieredCache(ctx context.Context, local, remote Cache, opts ...TieredCacheOption) (*TieredCache, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	c := &TieredCache{local: local, remote: remote, localTTL: DefaultLocalTTL, origin: hex.EncodeToString(id)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.bus != nil {
+		if err := c.bus.Subscribe(ctx, c.invalidate); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *TieredCache) invalidate(ctx context.Context, inv Invalidation) {
+	if inv.Origin == c.origin {
+		return
+	}
+	if inv.All {
+		_ = c.local.ClearAll(ctx)
+		return
+	}
+	for _, key := range inv.Keys {
+		_ = c.local.Delete(ctx, key)
+	}
+	for _, prefix := range inv.Prefixes {
+		if err := DeleteByPrefix(ctx, c.local, prefix); err != nil {
+			// the stale local copies must be dropped anyway
+			_ = c.local.ClearAll(ctx)
+			return
+		}
+	}
+}
+
+func (c *TieredCache) publish(ctx context.Context, inv Invalidation) error {
+	if c.bus == nil {
+		return nil
+	}
+	inv.Origin = c.origin
+	return c.bus.Publish(ctx, inv)
+}
+
+// Get returns the local copy of key, or gets it from the remote cache and keeps a local copy.
+func (c *TieredCache) Get(ctx context.Context, key string) (interface{}, error) {
+	if val, err := c.local.Get(ctx, key); val != nil && err == nil {
+		return val, nil
+	}
+	val, err := c.remote.Get(ctx, key)
+	if val == nil || err != nil {
+		return val, err
+	}
+	_ = c.local.Put(ctx, key, val, c.localTTL)
+	return val, nil
+}
+
+// GetMulti gets the local copies of keys, the missing ones are got from the remote cache.
+func (c *TieredCache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	keysErr := make([]string, 0)
+	for i, key := range keys {
+		v, err := c.Get(ctx, key)
+		if err == nil && v == nil {
+			err = ErrKeyNotExist
+		}
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", key, err.Error()))
+			continue
+		}
+		values[i] = v
+	}
+	if len(keysErr) == 0 {
+		return values, nil
+	}
+	return values, berror.Error(MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// Put puts the value into the remote cache and the local one, the local copies of the other instances are dropped.
+func (c *TieredCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	if err := c.remote.Put(ctx, key, val, timeout); err != nil {
+		return err
+	}
+	ttl := c.localTTL
+	if timeout > 0 && timeout < ttl {
+		ttl = timeout
+	}
+	_ = c.local.Put(ctx, key, val, ttl)
+	return c.publish(ctx, Invalidation{Keys: []string{key}})
+}
+
+// Delete deletes key from the remote cache and the local copies of all the instances.
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+	return c.dropLocal(ctx, key)
+}
+
+func (c *TieredCache) dropLocal(ctx context.Context, key string) error {
+	if err := c.local.Delete(ctx, key); err != nil {
+		return err
+	}
+	return c.publish(ctx, Invalidation{Keys: []string{key}})
+}
+
+// DeleteByPrefix deletes the keys starting with prefix from the remote cache and the local copies of all the instances.
+// The remote cache must be a PrefixCache, and the local cache is cleared if it isn't.
+func (c *TieredCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	if err := DeleteByPrefix(ctx, c.remote, prefix); err != nil {
+		return err
+	}
+	if err := DeleteByPrefix(ctx, c.local, prefix); err != nil {
+		if err = c.local.ClearAll(ctx); err != nil {
+			return err
+		}
+	}
+	return c.publish(ctx, Invalidation{Prefixes: []string{prefix}})
+}
+
+// Incr increases the counter in the remote cache.
+func (c *TieredCache) Incr(ctx context.Context, key string) error {
+	if err := c.remote.Incr(ctx, key); err != nil {
+		return err
+	}
+	return c.dropLocal(ctx, key)
+}
+
+// Decr decreases the counter in the remote cache.
+func (c *TieredCache) Decr(ctx context.Context, key string) error {
+	if err := c.remote.Decr(ctx, key); err != nil {
+		return err
+	}
+	return c.dropLocal(ctx, key)
+}
+
+// IsExist checks the local copy and then the remote cache.
+func (c *TieredCache) IsExist(ctx context.Context, key string) (bool, error) {
+	if ok, err := c.local.IsExist(ctx, key); ok && err == nil {
+		return true, nil
+	}
+	return c.remote.IsExist(ctx, key)
+}
+
+// ClearAll clears the remote cache and the local caches of all the instances.
+func (c *TieredCache) ClearAll(ctx context.Context) error {
+	if err := c.remote.ClearAll(ctx); err != nil {
+		return err
+	}
+	if err := c.local.ClearAll(ctx); err != nil {
+		return err
+	}
+	return c.publish(ctx, Invalidation{All: true})
+}
+
+// StartAndGC does nothing, the local and remote caches should be started already.
+func (c *TieredCache) StartAndGC(config string) error {
+	return nil
+}
+
+// NewMemoryInvalidationBus returns the InvalidationBus for the TieredCaches in one process, it's useful for tests
+func NewMemoryInvalidationBus() InvalidationBus {
+	return pubsub.NewMemoryBus[Invalidation]()
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieredCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	remote := NewMemoryCache()
+	bus := NewMemoryInvalidationBus()
+	local1, local2 := NewMemoryCache(), NewMemoryCache()
+	c1, err := NewTieredCache(ctx, local1, remote, WithInvalidationBus(bus), WithLocalTTL(time.Minute))
+	assert.Nil(t, err)
+	c2, err := NewTieredCache(ctx, local2, remote, WithInvalidationBus(bus))
+	assert.Nil(t, err)
+
+	assert.Nil(t, c1.Put(ctx, "astaxie", "author", time.Hour))
+	v, err := c2.Get(ctx, "astaxie")
+	assert.Nil(t, err)
+	assert.Equal(t, "author", v)
+	// c2 keeps a local copy
+	v, _ = local2.Get(ctx, "astaxie")
+	assert.Equal(t, "author", v)
+
+	// the write of c1 drops the stale copy of c2
+	assert.Nil(t, c1.Put(ctx, "astaxie", "author1", time.Hour))
+	res, _ := local2.IsExist(ctx, "astaxie")
+	assert.False(t, res)
+	v, _ = c2.Get(ctx, "astaxie")
+	assert.Equal(t, "author1", v)
+
+	assert.Nil(t, c2.Delete(ctx, "astaxie"))
+	for _, c := range []Cache{local1, local2, remote, c1} {
+		res, _ = c.IsExist(ctx, "astaxie")
+		assert.False(t, res)
+	}
+
+	assert.Nil(t, c1.Put(ctx, "counter", 1, time.Hour))
+	_, _ = c2.Get(ctx, "counter")
+	assert.Nil(t, c1.Incr(ctx, "counter"))
+	v, _ = c2.Get(ctx, "counter")
+	assert.Equal(t, 2, v)
+
+	assert.Nil(t, c1.Put(ctx, "astaxie1", "author1", time.Hour))
+	vv, err := c2.GetMulti(ctx, []string{"astaxie0", "astaxie1"})
+	assert.Equal(t, []interface{}{nil, "author1"}, vv)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, c1.Put(ctx, "user:42:profile", "profile", time.Hour))
+	_, _ = c2.Get(ctx, "user:42:profile")
+	assert.Nil(t, c1.DeleteByPrefix(ctx, "user:42:"))
+	for _, c := range []Cache{local1, local2, remote} {
+		res, _ = c.IsExist(ctx, "user:42:profile")
+		assert.False(t, res)
+	}
+
+	assert.Nil(t, c1.ClearAll(ctx))
+	res, _ = local2.IsExist(ctx, "astaxie1")
+	assert.False(t, res)
+
+	// the subscription stops when ctx is done
+	assert.Nil(t, c1.Put(ctx, "astaxie", "author", time.Hour))
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, c2.Put(ctx, "astaxie", "author1", time.Hour))
+	v, _ = local1.Get(ctx, "astaxie")
+	assert.Equal(t, "author", v)
+}
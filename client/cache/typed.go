@@ -0,0 +1,133 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// TypedCache is the view of Cache whose values are T, it's created by Typed
+type TypedCache[T any] struct {
+	c     Cache
+	codec Codec
+	group singleflight.Group
+}
+
+// Typed returns the view of c whose values are T, so the callers don't assert the types.
+// The values are encoded by codec if it isn't nil. Otherwise they're stored as they are,
+// and read by GetInto if c is a CodecCache whose codec is set, so pass nil if the codec of the adapter is set already.
+// usage:
+//
+//	users := cache.Typed[User](bm, codec)
+//	err := users.Put(ctx, "astaxie", User{Name: "astaxie"}, time.Minute)
+//	u, err := users.Get(ctx, "astaxie")
+func Typed[T any](c Cache, codec Codec) *TypedCache[T] {
+	return &TypedCache[T]{c: c, codec: codec}
+}
+
+// Cache returns the original Cache
+func (tc *TypedCache[T]) Cache() Cache {
+	return tc.c
+}
+
+// Get returns the value of key, it returns ErrKeyNotExist if the key doesn't exist,
+// and the error of InvalidValueType if the value isn't T.
+func (tc *TypedCache[T]) Get(ctx context.Context, key string) (T, error) {
+	var val T
+	if cc, ok := tc.c.(CodecCache); ok && tc.codec == nil && cc.Codec() != nil {
+		err := cc.GetInto(ctx, key, &val)
+		return val, err
+	}
+	raw, err := tc.c.Get(ctx, key)
+	if err != nil {
+		return val, err
+	}
+	if raw == nil {
+		return val, ErrKeyNotExist
+	}
+	if tc.codec != nil {
+		var data []byte
+		switch v := raw.(type) {
+		case []byte:
+			data = v
+		case string:
+			data = []byte(v)
+		default:
+			return val, berror.Errorf(InvalidValueType, "the value of key %s isn't encoded by the codec: %T", key, raw)
+		}
+		err = DecodeValue(tc.codec, data, &val)
+		return val, err
+	}
+	if v, ok := raw.(T); ok {
+		return v, nil
+	}
+	// the remote adapters return []byte for the strings
+	if data, ok := raw.([]byte); ok {
+		if v, ok := any(string(data)).(T); ok {
+			return v, nil
+		}
+	}
+	return val, berror.Errorf(InvalidValueType, "the value of key %s is %T, not %T", key, raw, val)
+}
+
+// Put puts val by key, it's encoded by the codec if the codec isn't nil.
+func (tc *TypedCache[T]) Put(ctx context.Context, key string, val T, timeout time.Duration) error {
+	if tc.codec == nil {
+		return tc.c.Put(ctx, key, val, timeout)
+	}
+	data, err := EncodeValue(tc.codec, val)
+	if err != nil {
+		return err
+	}
+	return tc.c.Put(ctx, key, data, timeout)
+}
+
+// Delete deletes key
+func (tc *TypedCache[T]) Delete(ctx context.Context, key string) error {
+	return tc.c.Delete(ctx, key)
+}
+
+// GetOrLoad returns the value of key, or loads it by loader and puts it with ttl if Get fails.
+// The concurrent loads of the same key are deduplicated, so only one loader is called.
+func (tc *TypedCache[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration,
+	loader func(ctx context.Context) (T, error),
+) (T, error) {
+	if loader == nil {
+		var zero T
+		return zero, berror.Error(InvalidLoadFunc, "loader cannot be nil")
+	}
+	if val, err := tc.Get(ctx, key); err == nil {
+		return val, nil
+	}
+	val, err, _ := tc.group.Do(key, func() (interface{}, error) {
+		// the key may be loaded by the flight which finished just now
+		if v, er := tc.Get(ctx, key); er == nil {
+			return v, nil
+		}
+		v, er := loader(ctx)
+		if er != nil {
+			return v, berror.Wrap(er, LoadFuncFailed, "cache unable to load data")
+		}
+		return v, tc.Put(ctx, key, v, ttl)
+	})
+	// val is nil if T is an interface and the loader returns nil
+	res, _ := val.(T)
+	return res, err
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+func TestTyped(t *testing.T) {
+	ctx := context.Background()
+	jsonCodec, _ := GetCodec(CodecJSON)
+	u := codecUser{Name: "astaxie", Age: 18}
+	testCases := []struct {
+		name  string
+		codec Codec
+	}{
+		{name: "without codec"},
+		{name: "json", codec: jsonCodec},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bm := NewMemoryCache()
+			users := Typed[codecUser](bm, tc.codec)
+			assert.Equal(t, bm, users.Cache())
+			assert.Nil(t, users.Put(ctx, "astaxie", u, time.Minute))
+			got, err := users.Get(ctx, "astaxie")
+			assert.Nil(t, err)
+			assert.Equal(t, u, got)
+
+			assert.Nil(t, users.Delete(ctx, "astaxie"))
+			_, err = users.Get(ctx, "astaxie")
+			assert.True(t, errors.Is(err, ErrKeyNotExist))
+
+			assert.Nil(t, bm.Put(ctx, "number", 1, time.Minute))
+			_, err = users.Get(ctx, "number")
+			code, _ := berror.FromError(err)
+			assert.Equal(t, InvalidValueType, code)
+		})
+	}
+
+	// the strings are returned as []byte by the remote adapters
+	bm := NewMemoryCache()
+	assert.Nil(t, bm.Put(ctx, "name", []byte("astaxie"), time.Minute))
+	name, err := Typed[string](bm, nil).Get(ctx, "name")
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie", name)
+}
+
+// codecMemoryCache is the CodecCache like the remote adapters, the values are stored as they are without the codec
+type codecMemoryCache struct {
+	Cache
+	codec Codec
+}
+
+func (c *codecMemoryCache) SetCodec(codec Codec) {
+	c.codec = codec
+}
+
+func (c *codecMemoryCache) Codec() Codec {
+	return c.codec
+}
+
+func (c *codecMemoryCache) Put(ctx context.Context, key string, val any, timeout time.Duration) error {
+	if c.codec == nil {
+		return c.Cache.Put(ctx, key, val, timeout)
+	}
+	data, err := EncodeValue(c.codec, val)
+	if err != nil {
+		return err
+	}
+	return c.Cache.Put(ctx, key, data, timeout)
+}
+
+func (c *codecMemoryCache) GetInto(ctx context.Context, key string, v any) error {
+	if c.codec == nil {
+		return berror.Error(InvalidCodec, "the codec isn't set")
+	}
+	val, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return DecodeValue(c.codec, val.([]byte), v)
+}
+
+func TestTypedCodecCache(t *testing.T) {
+	ctx := context.Background()
+	jsonCodec, _ := GetCodec(CodecJSON)
+	u := codecUser{Name: "astaxie", Age: 18}
+	testCases := []struct {
+		name  string
+		codec Codec
+	}{
+		{name: "without codec"},
+		{name: "json", codec: jsonCodec},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bm := &codecMemoryCache{Cache: NewMemoryCache(), codec: tc.codec}
+			users := Typed[codecUser](bm, nil)
+			assert.Nil(t, users.Put(ctx, "astaxie", u, time.Minute))
+			got, err := users.Get(ctx, "astaxie")
+			assert.Nil(t, err)
+			assert.Equal(t, u, got)
+		})
+	}
+}
+
+func TestTypedGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	counts := Typed[int](NewMemoryCache(), nil)
+	var loads atomic.Int32
+	loader := func(ctx context.Context) (int, error) {
+		loads.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := counts.GetOrLoad(ctx, "answer", time.Minute, loader)
+			assert.Nil(t, err)
+			assert.Equal(t, 42, n)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), loads.Load())
+	n, err := counts.Get(ctx, "answer")
+	assert.Nil(t, err)
+	assert.Equal(t, 42, n)
+
+	_, err = counts.GetOrLoad(ctx, "failed", time.Minute, func(ctx context.Context) (int, error) {
+		return 0, errors.New("db is down")
+	})
+	code, _ := berror.FromError(err)
+	assert.Equal(t, LoadFuncFailed, code)
+
+	_, err = counts.GetOrLoad(ctx, "answer", time.Minute, nil)
+	code, _ = berror.FromError(err)
+	assert.Equal(t, InvalidLoadFunc, code)
+
+	// the loader of the interface type may return nil
+	v, err := Typed[any](NewMemoryCache(), nil).GetOrLoad(ctx, "nil", time.Minute,
+		func(ctx context.Context) (any, error) {
+			return nil, nil
+		})
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+}
@@ -15,6 +15,7 @@
 package httplib
 
 import (
+	"context"
 	"crypto/tls"
 	"net/http"
 	"net/url"
@@ -54,6 +55,14 @@ func WithTransport(transport http.RoundTripper) ClientOption {
 	}
 }
 
+// WithTransportConfig tunes the transport shared by all subsequent request,
+// it's ignored if the transport is set by WithTransport
+func WithTransportConfig(cfg TransportConfig) ClientOption {
+	return func(client *Client) {
+		client.transportConfig = cfg
+	}
+}
+
 // WithProxy will set http proxy field in all subsequent request
 func WithProxy(proxy func(*http.Request) (*url.URL, error)) ClientOption {
 	return func(client *Client) {
@@ -82,6 +91,13 @@ func WithEnableGzip(enable bool) ClientOption {
 	}
 }
 
+// WithInterceptors appends the interceptors of the client, see Client.Use
+func WithInterceptors(fcs ...FilterChain) ClientOption {
+	return func(client *Client) {
+		client.Use(fcs...)
+	}
+}
+
 // BeegoHttpRequestOption
 
 // WithTimeout sets connect time out and read-write time out for BeegoRequest.
@@ -91,6 +107,13 @@ func WithTimeout(connectTimeout, readWriteTimeout time.Duration) BeegoHTTPReques
 	}
 }
 
+// WithContext sets the context of the request, see BeegoHTTPRequest.SetContext
+func WithContext(ctx context.Context) BeegoHTTPRequestOption {
+	return func(request *BeegoHTTPRequest) {
+		request.SetContext(ctx)
+	}
+}
+
 // WithHeader adds header item string in request.
 func WithHeader(key, value string) BeegoHTTPRequestOption {
 	return func(request *BeegoHTTPRequest) {
@@ -147,9 +170,17 @@ func WithParam(key, value string) BeegoHTTPRequestOption {
 // default is 0 (never retry)
 // -1 retry indefinitely (forever)
 // Other numbers specify the exact retry amount
+// WithRetryPolicy supports the exponential backoff and the retries on the status codes.
 func WithRetry(times int, delay time.Duration) BeegoHTTPRequestOption {
 	return func(request *BeegoHTTPRequest) {
 		request.Retries(times)
 		request.RetryDelay(delay)
 	}
 }
+
+// WithRetryPolicy sets the retry policy for the request, which replaces WithRetry
+func WithRetryPolicy(policy *RetryPolicy) BeegoHTTPRequestOption {
+	return func(request *BeegoHTTPRequest) {
+		request.SetRetryPolicy(policy)
+	}
+}
@@ -0,0 +1,419 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// DownloadProgress is passed to the progress callback of the download
+type DownloadProgress struct {
+	// Written is the number of the bytes written, including the bytes of the resumed file
+	Written int64
+	// Total is the size of the body, it's -1 if it's unknown
+	Total int64
+	// Resumes is the number of the resumes after the failures
+	Resumes int
+}
+
+// DownloadOption configures ToWriter and DownloadFile
+type DownloadOption func(o *downloadOptions)
+
+type downloadOptions struct {
+	progress    func(p DownloadProgress)
+	rateLimit   int64
+	maxResumes  int
+	resumeDelay time.Duration
+}
+
+// WithProgress sets the callback which is called after every chunk is written
+func WithProgress(fn func(p DownloadProgress)) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+	}
+}
+
+// WithRateLimit limits the bandwidth of the download to bytesPerSecond, 0 means no limit
+func WithRateLimit(bytesPerSecond int64) DownloadOption {
+	return func(o *downloadOptions) {
+		o.rateLimit = bytesPerSecond
+	}
+}
+
+// WithResume sets the max number of the resumes after the transient failures and the delay before every resume,
+// 3 resumes after 500ms by default, 0 disables the resume
+func WithResume(maxResumes int, delay time.Duration) DownloadOption {
+	return func(o *downloadOptions) {
+		o.maxResumes = maxResumes
+		o.resumeDelay = delay
+	}
+}
+
+func newDownloadOptions(opts []DownloadOption) *downloadOptions {
+	o := &downloadOptions{
+		maxResumes:  3,
+		resumeDelay: 500 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ToWriter streams the body of the response to w, and returns the number of the bytes written.
+// If reading the body fails, the download is resumed by the Range request from where it stops,
+// it requires the method is GET and the server supports the ranges, see WithResume.
+// The download stops when the context of the request is done.
+func (b *BeegoHTTPRequest) ToWriter(w io.Writer, opts ...DownloadOption) (int64, error) {
+	resp, err := b.getResponse()
+	if err != nil {
+		return 0, err
+	}
+	if err = checkDownloadStatus(resp); err != nil {
+		return 0, err
+	}
+	return b.download(w, resp, 0, newDownloadOptions(opts))
+}
+
+// DownloadFile downloads the body of the response to filename, the parent directories are created if necessary.
+// The body is written to filename.part first, which is renamed to filename after the download is done.
+// The ETag or Last-Modified of the response is kept in filename.part.validator.
+// If filename.part exists, which is left by the failed download, it's resumed from its size with If-Range,
+// so it's downloaded again if the entity changes, or if the part can't be validated.
+func (b *BeegoHTTPRequest) DownloadFile(filename string, opts ...DownloadOption) error {
+	if err := pathExistAndMkdir(filename); err != nil {
+		return err
+	}
+	part := filename + ".part"
+	validatorFile := part + ".validator"
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return berror.Wrapf(err, CreateFileIfNotExistFailed, "could not open the file %s", part)
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return berror.Wrapf(err, DownloadFailed, "could not seek the file %s", part)
+	}
+	if offset > 0 {
+		validator, _ := os.ReadFile(validatorFile)
+		if len(validator) == 0 || b.req.Method != http.MethodGet {
+			// the part can't be validated, it may belong to another version of the entity
+			if offset, err = truncatePart(f); err != nil {
+				return err
+			}
+		} else {
+			b.req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			b.req.Header.Set("If-Range", string(validator))
+		}
+	}
+	resp, err := b.getResponse()
+	if err != nil {
+		return err
+	}
+	if offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		_ = resp.Body.Close()
+		if total, ok := parseUnsatisfiedRange(resp.Header.Get("Content-Range")); ok && total == offset {
+			// the part is complete, the download failed before it was renamed
+			return finishDownload(f, part, filename)
+		}
+		if offset, err = truncatePart(f); err != nil {
+			return err
+		}
+		if resp, err = b.refetch(); err != nil {
+			return err
+		}
+	}
+	if err = checkDownloadStatus(resp); err != nil {
+		return err
+	}
+	if offset > 0 && resp.StatusCode != http.StatusPartialContent {
+		// the server sends the whole body
+		if offset, err = truncatePart(f); err != nil {
+			return err
+		}
+	}
+	if err = saveValidator(validatorFile, resp); err != nil {
+		_ = resp.Body.Close()
+		return err
+	}
+	if _, err = b.download(f, resp, offset, newDownloadOptions(opts)); err != nil {
+		return err
+	}
+	return finishDownload(f, part, filename)
+}
+
+func truncatePart(f *os.File) (int64, error) {
+	if err := f.Truncate(0); err != nil {
+		return 0, berror.Wrapf(err, DownloadFailed, "could not truncate the file %s", f.Name())
+	}
+	offset, err := f.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, berror.Wrapf(err, DownloadFailed, "could not seek the file %s", f.Name())
+	}
+	return offset, nil
+}
+
+// saveValidator keeps the validator of resp, so the part is resumed only if the entity doesn't change
+func saveValidator(validatorFile string, resp *http.Response) error {
+	validator := responseValidator(resp)
+	if validator == "" {
+		if err := os.Remove(validatorFile); err != nil && !os.IsNotExist(err) {
+			return berror.Wrapf(err, DownloadFailed, "could not remove the file %s", validatorFile)
+		}
+		return nil
+	}
+	return berror.Wrapf(os.WriteFile(validatorFile, []byte(validator), 0o644),
+		CreateFileIfNotExistFailed, "could not write the file %s", validatorFile)
+}
+
+func finishDownload(f *os.File, part string, filename string) error {
+	if err := f.Close(); err != nil {
+		return berror.Wrapf(err, CloseFileFailed, "could not close the file %s", part)
+	}
+	if err := os.Remove(part + ".validator"); err != nil && !os.IsNotExist(err) {
+		return berror.Wrapf(err, DownloadFailed, "could not remove the file %s.validator", part)
+	}
+	return berror.Wrapf(os.Rename(part, filename), DownloadFailed, "could not rename %s to %s", part, filename)
+}
+
+func checkDownloadStatus(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		return berror.Errorf(DownloadFailed, "the status of response is %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// download copies the body of resp to w, offset is the number of the bytes written before resp
+func (b *BeegoHTTPRequest) download(w io.Writer, resp *http.Response, offset int64, o *downloadOptions) (int64, error) {
+	ctx := b.req.Context()
+	progress := DownloadProgress{Written: offset, Total: responseTotal(resp, offset)}
+	// the validator makes sure the resumed body belongs to the same entity
+	validator := responseValidator(resp)
+	resumable := b.req.Method == http.MethodGet &&
+		(resp.StatusCode == http.StatusPartialContent || resp.Header.Get("Accept-Ranges") == "bytes")
+	limiter := newRateLimiter(o.rateLimit)
+	bufSize := int64(32 * 1024)
+	if o.rateLimit > 0 && o.rateLimit < bufSize {
+		bufSize = o.rateLimit
+	}
+	buf := make([]byte, bufSize)
+	for {
+		err := copyResponseBody(ctx, w, resp.Body, buf, &progress, limiter, o)
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		if err == nil {
+			return progress.Written - offset, nil
+		}
+		var werr *writeError
+		if errors.As(err, &werr) || ctx.Err() != nil || !resumable || progress.Resumes >= o.maxResumes {
+			return progress.Written - offset, err
+		}
+		for {
+			progress.Resumes++
+			if err = sleepCtx(ctx, o.resumeDelay); err != nil {
+				return progress.Written - offset, berror.Wrap(err, DownloadFailed, "the download is canceled")
+			}
+			resp, err = b.resume(progress.Written, validator)
+			if err == nil || ctx.Err() != nil || progress.Resumes >= o.maxResumes {
+				break
+			}
+		}
+		if err != nil {
+			return progress.Written - offset, err
+		}
+	}
+}
+
+// writeError is the error of the writer, the download isn't resumed
+type writeError struct {
+	err error
+}
+
+func (e *writeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *writeError) Unwrap() error {
+	return e.err
+}
+
+func copyResponseBody(ctx context.Context, w io.Writer, body io.Reader, buf []byte,
+	progress *DownloadProgress, limiter *rateLimiter, o *downloadOptions,
+) error {
+	if body == nil {
+		return nil
+	}
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return &writeError{err: berror.Wrap(werr, DownloadFailed, "writing the body failed")}
+			}
+			progress.Written += int64(n)
+			if o.progress != nil {
+				o.progress(*progress)
+			}
+			if lerr := limiter.wait(ctx, n); lerr != nil {
+				return berror.Wrap(lerr, DownloadFailed, "the download is canceled")
+			}
+		}
+		if err == io.EOF {
+			if progress.Total >= 0 && progress.Written < progress.Total {
+				return berror.Wrap(io.ErrUnexpectedEOF, DownloadFailed, "reading the body failed")
+			}
+			return nil
+		}
+		if err != nil {
+			return berror.Wrap(err, DownloadFailed, "reading the body failed")
+		}
+	}
+}
+
+// resume requests the body from offset
+func (b *BeegoHTTPRequest) resume(offset int64, validator string) (*http.Response, error) {
+	r := NewBeegoRequestWithCtx(b.req.Context(), b.url, b.req.Method)
+	r.setting = b.setting
+	r.req.Header = b.req.Header.Clone()
+	r.req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	if validator != "" {
+		r.req.Header.Set("If-Range", validator)
+	} else {
+		r.req.Header.Del("If-Range")
+	}
+	resp, err := r.DoRequest()
+	if err != nil {
+		return nil, err
+	}
+	start, _, ok := parseContentRange(resp.Header.Get("Content-Range"))
+	if resp.StatusCode != http.StatusPartialContent || !ok || start != offset {
+		_ = resp.Body.Close()
+		return nil, berror.Errorf(DownloadFailed, "the download couldn't be resumed from %d, the status of response is %d",
+			offset, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// refetch requests the whole body again
+func (b *BeegoHTTPRequest) refetch() (*http.Response, error) {
+	b.req.Header.Del("Range")
+	b.req.Header.Del("If-Range")
+	r := NewBeegoRequestWithCtx(b.req.Context(), b.url, b.req.Method)
+	r.setting = b.setting
+	r.req.Header = b.req.Header.Clone()
+	return r.DoRequest()
+}
+
+// responseValidator returns the ETag, or the Last-Modified if there is no ETag
+func responseValidator(resp *http.Response) string {
+	if validator := resp.Header.Get("ETag"); validator != "" {
+		return validator
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// responseTotal returns the size of the whole body, or -1 if it's unknown
+func responseTotal(resp *http.Response, offset int64) int64 {
+	if resp.StatusCode == http.StatusPartialContent {
+		if _, total, ok := parseContentRange(resp.Header.Get("Content-Range")); ok {
+			return total
+		}
+	}
+	if resp.ContentLength >= 0 {
+		return offset + resp.ContentLength
+	}
+	return -1
+}
+
+// parseContentRange parses "bytes start-end/total", total is -1 if it's "*"
+func parseContentRange(v string) (start int64, total int64, ok bool) {
+	v = strings.TrimPrefix(v, "bytes ")
+	rng, size, found := strings.Cut(v, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if size == "*" {
+		return start, -1, true
+	}
+	total, err = strconv.ParseInt(size, 10, 64)
+	return start, total, err == nil
+}
+
+// parseUnsatisfiedRange parses "bytes */total" of the 416 response
+func parseUnsatisfiedRange(v string) (int64, bool) {
+	size, found := strings.CutPrefix(v, "bytes */")
+	if !found {
+		return 0, false
+	}
+	total, err := strconv.ParseInt(size, 10, 64)
+	return total, err == nil
+}
+
+// rateLimiter limits the bytes read per second
+type rateLimiter struct {
+	rate  int64
+	start time.Time
+	n     int64
+}
+
+func newRateLimiter(rate int64) *rateLimiter {
+	return &rateLimiter{rate: rate, start: time.Now()}
+}
+
+// wait waits until n more bytes are allowed
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l.rate <= 0 {
+		return nil
+	}
+	l.n += int64(n)
+	expected := time.Duration(float64(l.n) / float64(l.rate) * float64(time.Second))
+	return sleepCtx(ctx, expected-time.Since(l.start))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// newDownloadServer serves content, the first interrupted requests without Range are aborted in the middle
+func newDownloadServer(content []byte, interrupted int32) (*httptest.Server, *atomic.Int32) {
+	var ranges atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("Range") != "" {
+			ranges.Add(1)
+		} else if interrupted > 0 {
+			interrupted--
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	return ts, &ranges
+}
+
+func TestToWriter(t *testing.T) {
+	content := bytes.Repeat([]byte("beego"), 20000)
+	ts, ranges := newDownloadServer(content, 1)
+	defer ts.Close()
+
+	var last DownloadProgress
+	var buf bytes.Buffer
+	n, err := Get(ts.URL).ToWriter(&buf, WithResume(1, time.Millisecond), WithProgress(func(p DownloadProgress) {
+		last = p
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, content, buf.Bytes())
+	assert.Equal(t, int32(1), ranges.Load())
+	assert.Equal(t, DownloadProgress{Written: int64(len(content)), Total: int64(len(content)), Resumes: 1}, last)
+
+	// not resumed
+	ts, _ = newDownloadServer(content, 1)
+	defer ts.Close()
+	buf.Reset()
+	_, err = Get(ts.URL).ToWriter(&buf, WithResume(0, 0))
+	assert.NotNil(t, err)
+	assert.True(t, buf.Len() < len(content))
+}
+
+func TestDownloadFile(t *testing.T) {
+	content := bytes.Repeat([]byte("beego"), 20000)
+	ts, ranges := newDownloadServer(content, 0)
+	defer ts.Close()
+
+	filename := filepath.Join(t.TempDir(), "files", "beego.txt")
+	assert.Nil(t, os.MkdirAll(filepath.Dir(filename), os.ModePerm))
+	download := func(part []byte, validator string) {
+		ranges.Store(0)
+		// the partial file left by the failed download
+		assert.Nil(t, os.WriteFile(filename+".part", part, 0o644))
+		if validator != "" {
+			assert.Nil(t, os.WriteFile(filename+".part.validator", []byte(validator), 0o644))
+		}
+		assert.Nil(t, Get(ts.URL).DownloadFile(filename))
+		data, err := os.ReadFile(filename)
+		assert.Nil(t, err)
+		assert.Equal(t, content, data)
+		_, err = os.Stat(filename + ".part")
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filename + ".part.validator")
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	// resumed
+	download(content[:1000], `"v1"`)
+	assert.Equal(t, int32(1), ranges.Load())
+	// the part can't be validated, it's downloaded again
+	download([]byte("stale"), "")
+	assert.Equal(t, int32(0), ranges.Load())
+	// the entity changed, the server sends the whole body
+	download([]byte("stale"), `"v0"`)
+	assert.Equal(t, int32(1), ranges.Load())
+	// the part is complete, the server responds 416
+	download(content, `"v1"`)
+	assert.Equal(t, int32(1), ranges.Load())
+	// the part is longer than the entity
+	download(append(content, "stale"...), `"v1"`)
+	assert.Equal(t, int32(1), ranges.Load())
+
+	// the status isn't 2xx
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	err := Get(notFound.URL).DownloadFile(filename)
+	code, _ := berror.FromError(err)
+	assert.Equal(t, DownloadFailed, code)
+}
+
+func TestDownloadFileKeepsValidator(t *testing.T) {
+	content := bytes.Repeat([]byte("beego"), 20000)
+	ts, _ := newDownloadServer(content, 1)
+	defer ts.Close()
+
+	filename := filepath.Join(t.TempDir(), "beego.txt")
+	assert.NotNil(t, Get(ts.URL).DownloadFile(filename, WithResume(0, 0)))
+	validator, err := os.ReadFile(filename + ".part.validator")
+	assert.Nil(t, err)
+	assert.Equal(t, `"v1"`, string(validator))
+}
+
+func TestToWriterRateLimit(t *testing.T) {
+	content := bytes.Repeat([]byte("b"), 10*1024)
+	ts, _ := newDownloadServer(content, 0)
+	defer ts.Close()
+
+	start := time.Now()
+	var buf bytes.Buffer
+	_, err := Get(ts.URL).ToWriter(&buf, WithRateLimit(20*1024))
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 450*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = NewBeegoRequestWithCtx(ctx, ts.URL, http.MethodGet).ToWriter(&buf, WithRateLimit(1024))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, total, ok := parseContentRange("bytes 100-199/1000")
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), start)
+	assert.Equal(t, int64(1000), total)
+
+	_, total, ok = parseContentRange("bytes 100-199/*")
+	assert.True(t, ok)
+	assert.Equal(t, int64(-1), total)
+
+	_, _, ok = parseContentRange("bytes */1000")
+	assert.False(t, ok)
+
+	total, ok = parseUnsatisfiedRange("bytes */1000")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1000), total)
+	_, ok = parseUnsatisfiedRange("bytes 0-1/1000")
+	assert.False(t, ok)
+}
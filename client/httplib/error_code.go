@@ -136,3 +136,9 @@ There are several cases that cause this error:
 1. You pass valid structure pointer to the function;
 2. The body is valid json, Yaml or XML document
 `)
+
+var DownloadFailed = berror.DefineCode(5001012, moduleName, "DownloadFailed", `
+The response of the download isn't 2xx, or the download was interrupted and couldn't be resumed.
+The download is resumed by the Range request only if the method is GET and the server supports the ranges,
+check the Accept-Ranges header of the response and the status of the resumed request, which should be 206.
+`)
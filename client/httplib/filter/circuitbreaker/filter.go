@@ -0,0 +1,199 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package circuitbreaker provides the filter which fails fast the requests to the unhealthy hosts,
+// the circuits are core/circuitbreaker.Breaker, which are shared with the server filter.
+// Usage:
+//
+//	breaker := circuitbreaker.NewFilterChainBuilder(circuitbreaker.WithConsecutiveFailures(5))
+//	client, err := httplib.NewClient("user", "http://user-service", httplib.WithInterceptors(breaker.FilterChain))
+//	err = client.Get(&user, "/users/1")
+//	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+//		// fallback
+//	}
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/beego/beego/v2/client/httplib"
+	cb "github.com/beego/beego/v2/core/circuitbreaker"
+)
+
+// State is the state of the circuit of a host
+type State = cb.State
+
+const (
+	// StateClosed lets all the requests pass
+	StateClosed = cb.StateClosed
+	// StateOpen fails all the requests fast until the cooldown passes
+	StateOpen = cb.StateOpen
+	// StateHalfOpen lets a few probes pass, the circuit is closed if they succeed, otherwise it's opened again
+	StateHalfOpen = cb.StateHalfOpen
+)
+
+// ErrCircuitOpen is matched by errors.Is for the errors returned when the circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned without sending the request if the circuit of the host is open
+type CircuitOpenError struct {
+	Host string
+	// RetryAfter is when the circuit will be half-open
+	RetryAfter time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for host %s until %s", e.Host, e.RetryAfter.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) work
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// FilterChainBuilder builds the circuit breaker filter, the circuits are kept by the builder,
+// so the clients sharing the builder share the circuits of the same hosts.
+type FilterChainBuilder struct {
+	settings  cb.Settings
+	maxHosts  int
+	isFailure func(resp *http.Response, err error) bool
+
+	circuits *cb.Group
+}
+
+// BuilderOption configures FilterChainBuilder
+type BuilderOption func(builder *FilterChainBuilder)
+
+// NewFilterChainBuilder creates the builder, the circuit is opened by 5 consecutive failures by default,
+// and it's half-open after 30 seconds.
+func NewFilterChainBuilder(opts ...BuilderOption) *FilterChainBuilder {
+	builder := &FilterChainBuilder{
+		settings: cb.Settings{
+			ConsecutiveFailures: 5,
+			MinRequests:         20,
+			Window:              10 * time.Second,
+			Cooldown:            30 * time.Second,
+			HalfOpenRequests:    1,
+		},
+		maxHosts:  1024,
+		isFailure: DefaultIsFailure,
+	}
+	for _, opt := range opts {
+		opt(builder)
+	}
+	builder.circuits = cb.NewGroup(&builder.settings, builder.maxHosts)
+	return builder
+}
+
+// WithConsecutiveFailures opens the circuit after n consecutive failures, 0 disables it
+func WithConsecutiveFailures(n uint) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		builder.settings.ConsecutiveFailures = n
+	}
+}
+
+// WithFailureRatio opens the circuit if the ratio of the failures in the window reaches ratio,
+// and there are at least WithMinRequests requests in the window. It's disabled by default.
+func WithFailureRatio(ratio float64) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		builder.settings.FailureRatio = ratio
+	}
+}
+
+// WithMinRequests sets the minimum number of requests in the window before WithFailureRatio opens the circuit
+func WithMinRequests(n uint) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		builder.settings.MinRequests = n
+	}
+}
+
+// WithWindow sets the window in which the requests and failures are counted
+func WithWindow(window time.Duration) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		builder.settings.Window = window
+	}
+}
+
+// WithCooldown sets how long the circuit is open before it's half-open
+func WithCooldown(cooldown time.Duration) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		builder.settings.Cooldown = cooldown
+	}
+}
+
+// WithHalfOpenRequests sets the number of the probes which must succeed to close the half-open circuit
+func WithHalfOpenRequests(n uint) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		if n > 0 {
+			builder.settings.HalfOpenRequests = n
+		}
+	}
+}
+
+// WithMaxHosts sets the maximum number of the circuits kept for the hosts, 1024 by default.
+// The idle closed circuits are evicted first if there are more hosts, 0 means no limit
+func WithMaxHosts(n int) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		builder.maxHosts = n
+	}
+}
+
+// WithIsFailure decides which responses are failures, DefaultIsFailure is used by default
+func WithIsFailure(fn func(resp *http.Response, err error) bool) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		builder.isFailure = fn
+	}
+}
+
+// WithStateChange is called when the state of the circuit of host changes
+func WithStateChange(fn func(host string, from, to State)) BuilderOption {
+	return func(builder *FilterChainBuilder) {
+		builder.settings.OnStateChange = fn
+	}
+}
+
+// DefaultIsFailure treats the errors and the 5xx responses as failures
+func DefaultIsFailure(resp *http.Response, err error) bool {
+	return err != nil || (resp != nil && resp.StatusCode >= http.StatusInternalServerError)
+}
+
+// State returns the state of the circuit of host
+func (builder *FilterChainBuilder) State(host string) State {
+	c, ok := builder.circuits.Lookup(host)
+	if !ok {
+		return StateClosed
+	}
+	return c.State()
+}
+
+// FilterChain fails fast if the circuit of the host of the request is open
+func (builder *FilterChainBuilder) FilterChain(next httplib.Filter) httplib.Filter {
+	return func(ctx context.Context, req *httplib.BeegoHTTPRequest) (*http.Response, error) {
+		var host string
+		if u := req.GetRequest().URL; u != nil {
+			host = u.Host
+		}
+		c := builder.circuits.Get(host)
+		if ok, retryAfter := c.Allow(); !ok {
+			return nil, &CircuitOpenError{Host: host, RetryAfter: time.Now().Add(retryAfter)}
+		}
+		resp, err := next(ctx, req)
+		c.Done(!builder.isFailure(resp, err))
+		return resp, err
+	}
+}
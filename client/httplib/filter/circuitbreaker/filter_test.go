@@ -0,0 +1,162 @@
+// Copyright 2020 beego
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/client/httplib"
+)
+
+// newServer responds the status stored in status
+func newServer() (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	var status, calls atomic.Int32
+	status.Store(http.StatusOK)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	return ts, &status, &calls
+}
+
+type transition struct {
+	from, to State
+}
+
+func TestFilterChain(t *testing.T) {
+	ts, status, calls := newServer()
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	var mu sync.Mutex
+	var transitions []transition
+	builder := NewFilterChainBuilder(
+		WithConsecutiveFailures(3),
+		WithCooldown(50*time.Millisecond),
+		WithStateChange(func(host string, from, to State) {
+			assert.Equal(t, u.Host, host)
+			mu.Lock()
+			transitions = append(transitions, transition{from: from, to: to})
+			mu.Unlock()
+		}))
+	get := func() (*http.Response, error) {
+		return httplib.Get(ts.URL).AddFilters(builder.FilterChain).Response()
+	}
+
+	status.Store(http.StatusInternalServerError)
+	for i := 0; i < 3; i++ {
+		resp, err := get()
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+	assert.Equal(t, StateOpen, builder.State(u.Host))
+
+	// fail fast without sending the request
+	_, err := get()
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	var openErr *CircuitOpenError
+	assert.True(t, errors.As(err, &openErr))
+	assert.Equal(t, u.Host, openErr.Host)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// the failed probe opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, StateHalfOpen, builder.State(u.Host))
+	_, err = get()
+	assert.Nil(t, err)
+	assert.Equal(t, StateOpen, builder.State(u.Host))
+
+	// the succeeded probe closes the circuit
+	time.Sleep(60 * time.Millisecond)
+	status.Store(http.StatusOK)
+	resp, err := get()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, StateClosed, builder.State(u.Host))
+	assert.Equal(t, int32(5), calls.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []transition{
+		{StateClosed, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateOpen},
+		{StateOpen, StateHalfOpen},
+		{StateHalfOpen, StateClosed},
+	}, transitions)
+}
+
+func TestFilterChainErrorRate(t *testing.T) {
+	ts, status, _ := newServer()
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	builder := NewFilterChainBuilder(
+		WithConsecutiveFailures(0),
+		WithFailureRatio(0.5),
+		WithMinRequests(4),
+		WithWindow(time.Minute),
+		WithIsFailure(func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode == http.StatusTooManyRequests
+		}))
+	for _, code := range []int{http.StatusTooManyRequests, http.StatusOK, http.StatusInternalServerError} {
+		status.Store(int32(code))
+		_, err := httplib.Get(ts.URL).AddFilters(builder.FilterChain).Response()
+		assert.Nil(t, err)
+	}
+	// 1 failure of 3 requests
+	assert.Equal(t, StateClosed, builder.State(u.Host))
+
+	status.Store(http.StatusTooManyRequests)
+	_, err := httplib.Get(ts.URL).AddFilters(builder.FilterChain).Response()
+	assert.Nil(t, err)
+	assert.Equal(t, StateOpen, builder.State(u.Host))
+
+	// the circuits of the other hosts are closed
+	assert.Equal(t, StateClosed, builder.State("example.com"))
+}
+
+func TestDefaultIsFailure(t *testing.T) {
+	assert.True(t, DefaultIsFailure(nil, errors.New("connection refused")))
+	assert.True(t, DefaultIsFailure(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.False(t, DefaultIsFailure(&http.Response{StatusCode: http.StatusNotFound}, nil))
+}
+
+func TestFilterChainMaxHosts(t *testing.T) {
+	ts, status, _ := newServer()
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	builder := NewFilterChainBuilder(WithConsecutiveFailures(1), WithMaxHosts(2))
+	status.Store(http.StatusInternalServerError)
+	_, err := httplib.Get(ts.URL).AddFilters(builder.FilterChain).Response()
+	assert.Nil(t, err)
+	assert.Equal(t, StateOpen, builder.State(u.Host))
+
+	// the unreachable hosts don't grow the circuits beyond the limit
+	for _, host := range []string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3"} {
+		_, _ = httplib.Get("http://" + host).AddFilters(builder.FilterChain).Response()
+	}
+	assert.Equal(t, 2, builder.circuits.Len())
+}
@@ -12,6 +12,16 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package opentelemetry provides the filter which creates a client span for each request,
+// and injects the span into the headers of request, such as W3C traceparent and baggage.
+// usage:
+//
+//	client, err := httplib.NewClient("user", "http://user-service",
+//		httplib.WithInterceptors(opentelemetry.NewFilterChainBuilder().FilterChain))
+//	err = client.Get(&user, "/users/1", httplib.WithContext(c.Ctx.Request.Context()))
+//
+// The span is the child of the span in the context of request, so pass the context of the incoming request,
+// which carries the server span created by server/web/filter/opentelemetry.
 package opentelemetry
 
 import (
@@ -20,67 +30,157 @@ import (
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/beego/beego/v2/client/httplib"
 )
 
-type CustomSpanFunc func(span trace.Span, ctx context.Context, req *httplib.BeegoHTTPRequest, resp *http.Response, err error)
+const instrumentationName = "github.com/beego/beego/v2/client/httplib/filter/opentelemetry"
 
-type OtelFilterChainBuilder struct {
-	// TagURL true will tag span with url
+type (
+	CustomSpanFunc    func(span trace.Span, ctx context.Context, req *httplib.BeegoHTTPRequest, resp *http.Response, err error)
+	FilterChainOption func(builder *FilterChainBuilder)
+)
+
+// FilterChainBuilder provides an opentelemetry filter for httplib
+type FilterChainBuilder struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+	// tagURL true will tag span with url
 	tagURL bool
-	// CustomSpanFunc users are able to custom their span
-	customSpanFunc CustomSpanFunc
+	// customSpanFunc users are able to custom their span
+	customSpanFunc  CustomSpanFunc
+	spanNameBuilder func(req *httplib.BeegoHTTPRequest) string
+}
+
+// Deprecated: use FilterChainBuilder
+type OtelFilterChainBuilder = FilterChainBuilder
+
+// NewFilterChainBuilder creates FilterChainBuilder, the global TracerProvider is used by default,
+// and so is the global TextMapPropagator if it's set, otherwise W3C trace context and baggage are injected.
+func NewFilterChainBuilder(opts ...FilterChainOption) *FilterChainBuilder {
+	builder := &FilterChainBuilder{
+		tagURL: true,
+	}
+	for _, opt := range opts {
+		opt(builder)
+	}
+	return builder
 }
 
+// Deprecated: use NewFilterChainBuilder
 func NewOpenTelemetryFilter(tagURL bool, spanFunc CustomSpanFunc) *OtelFilterChainBuilder {
-	return &OtelFilterChainBuilder{
-		tagURL:         tagURL,
-		customSpanFunc: spanFunc,
+	return NewFilterChainBuilder(WithTagURL(tagURL), WithCustomSpanFunc(spanFunc),
+		WithSpanNameBuilder(func(req *httplib.BeegoHTTPRequest) string {
+			return req.GetRequest().Method + "#" + req.GetRequest().URL.Path
+		}))
+}
+
+// WithTracerProvider sets the TracerProvider
+func WithTracerProvider(tp trace.TracerProvider) FilterChainOption {
+	return func(builder *FilterChainBuilder) {
+		builder.tracerProvider = tp
 	}
 }
 
-func (builder *OtelFilterChainBuilder) FilterChain(next httplib.Filter) httplib.Filter {
-	return func(ctx context.Context, req *httplib.BeegoHTTPRequest) (*http.Response, error) {
-		method := req.GetRequest().Method
+// WithPropagator sets the propagator which injects the span into the headers of request
+func WithPropagator(p propagation.TextMapPropagator) FilterChainOption {
+	return func(builder *FilterChainBuilder) {
+		builder.propagator = p
+	}
+}
 
-		operationName := method + "#" + req.GetRequest().URL.Path
-		spanCtx, span := otel.Tracer("beego").Start(ctx, operationName)
-		defer span.End()
+// WithTagURL sets whether the attribute http.url is set, the user info of url is never set
+func WithTagURL(tagURL bool) FilterChainOption {
+	return func(builder *FilterChainBuilder) {
+		builder.tagURL = tagURL
+	}
+}
 
-		otel.GetTextMapPropagator().Inject(spanCtx, propagation.HeaderCarrier(req.GetRequest().Header))
+// WithSpanNameBuilder sets the function which returns the span name, it's "HTTP {method}" by default
+func WithSpanNameBuilder(f func(req *httplib.BeegoHTTPRequest) string) FilterChainOption {
+	return func(builder *FilterChainBuilder) {
+		builder.spanNameBuilder = f
+	}
+}
 
-		resp, err := next(spanCtx, req)
+// WithCustomSpanFunc add function to custom span
+func WithCustomSpanFunc(customSpanFunc CustomSpanFunc) FilterChainOption {
+	return func(builder *FilterChainBuilder) {
+		builder.customSpanFunc = customSpanFunc
+	}
+}
 
-		if resp != nil {
-			span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+// FilterChain traces the request with opentelemetry
+func (builder *FilterChainBuilder) FilterChain(next httplib.Filter) httplib.Filter {
+	return func(ctx context.Context, req *httplib.BeegoHTTPRequest) (*http.Response, error) {
+		tp := builder.tracerProvider
+		if tp == nil {
+			tp = otel.GetTracerProvider()
 		}
-		span.SetAttributes(attribute.String("http.method", method))
-		span.SetAttributes(attribute.String("peer.hostname", req.GetRequest().URL.Host))
+		r := req.GetRequest()
+		attrs := semconv.HTTPClientAttributesFromHTTPRequest(r)
+		if !builder.tagURL {
+			attrs = removeAttribute(attrs, semconv.HTTPURLKey)
+		}
+		// the scheme is decided by the TLS of the connection for the server requests
+		attrs = removeAttribute(attrs, semconv.HTTPSchemeKey)
+		attrs = append(attrs, semconv.HTTPSchemeKey.String(r.URL.Scheme), attribute.String("component", "beego"))
+		spanCtx, span := tp.Tracer(instrumentationName).Start(ctx, builder.spanName(req),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+			trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", r)...))
+		defer span.End()
 
-		span.SetAttributes(attribute.String("http.scheme", req.GetRequest().URL.Scheme))
-		span.SetAttributes(attribute.String("span.kind", "client"))
-		span.SetAttributes(attribute.String("component", "beego"))
+		builder.textMapPropagator().Inject(spanCtx, propagation.HeaderCarrier(r.Header))
 
-		if builder.tagURL {
-			span.SetAttributes(attribute.String("http.url", req.GetRequest().URL.String()))
-		}
+		resp, err := next(spanCtx, req)
 
 		if err != nil {
-			span.SetAttributes(attribute.Bool("error", true))
 			span.RecordError(err)
-		} else if resp != nil && !(resp.StatusCode < 300 && resp.StatusCode >= 200) {
-			span.SetAttributes(attribute.Bool("error", true))
+			span.SetStatus(codes.Error, err.Error())
+		} else if resp != nil {
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(resp.StatusCode)...)
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(resp.StatusCode, trace.SpanKindClient))
 		}
 
-		span.SetAttributes(attribute.String("peer.address", req.GetRequest().RemoteAddr))
-		span.SetAttributes(attribute.String("http.proto", req.GetRequest().Proto))
-
 		if builder.customSpanFunc != nil {
 			builder.customSpanFunc(span, ctx, req, resp, err)
 		}
 		return resp, err
 	}
 }
+
+func (builder *FilterChainBuilder) spanName(req *httplib.BeegoHTTPRequest) string {
+	if builder.spanNameBuilder != nil {
+		return builder.spanNameBuilder(req)
+	}
+	return "HTTP " + req.GetRequest().Method
+}
+
+func (builder *FilterChainBuilder) textMapPropagator() propagation.TextMapPropagator {
+	if builder.propagator != nil {
+		return builder.propagator
+	}
+	p := otel.GetTextMapPropagator()
+	// the global propagator injects nothing until it's set
+	if len(p.Fields()) == 0 {
+		return defaultPropagator
+	}
+	return p
+}
+
+var defaultPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+func removeAttribute(attrs []attribute.KeyValue, key attribute.Key) []attribute.KeyValue {
+	res := attrs[:0]
+	for _, attr := range attrs {
+		if attr.Key != key {
+			res = append(res, attr)
+		}
+	}
+	return res
+}
@@ -18,10 +18,18 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/beego/beego/v2/client/httplib"
 )
@@ -44,3 +52,44 @@ func TestFilterChainBuilderFilterChain(t *testing.T) {
 	assert.NotNil(t, resp)
 	assert.NotNil(t, err)
 }
+
+func TestFilterChainBuilderPropagation(t *testing.T) {
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	// the server span of the incoming request
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "GET /users/:id", trace.WithSpanKind(trace.SpanKindServer))
+	member, _ := baggage.NewMember("tenant", "beego")
+	bag, _ := baggage.New(member)
+	ctx = baggage.ContextWithBaggage(ctx, bag)
+
+	client, err := httplib.NewClient("test", ts.URL,
+		httplib.WithInterceptors(NewFilterChainBuilder(WithTracerProvider(tp)).FilterChain))
+	assert.Nil(t, err)
+	err = client.Get(nil, "/users/1", httplib.WithContext(ctx))
+	assert.Nil(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, 2, len(spans))
+	span := spans[0]
+	assert.Equal(t, "HTTP GET", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	attrs := attribute.NewSet(span.Attributes()...)
+	status, _ := attrs.Value(semconv.HTTPStatusCodeKey)
+	assert.Equal(t, int64(http.StatusNotFound), status.AsInt64())
+	u, _ := attrs.Value(semconv.HTTPURLKey)
+	assert.Equal(t, ts.URL+"/users/1", u.AsString())
+
+	assert.Equal(t, "00-"+span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-01",
+		header.Get("traceparent"))
+	assert.Equal(t, "tenant=beego", header.Get("baggage"))
+}
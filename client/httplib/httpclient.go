@@ -27,6 +27,9 @@ type Client struct {
 	CommonOpts []BeegoHTTPRequestOption
 
 	Setting BeegoHTTPSettings
+
+	interceptors    []FilterChain
+	transportConfig TransportConfig
 }
 
 // HTTPResponseCarrier If value implement HTTPResponseCarrier. http.Response will pass to SetHTTPResponse
@@ -55,7 +58,9 @@ type HTTPHeadersCarrier interface {
 	SetHeader(header map[string][]string)
 }
 
-// NewClient return a new http client
+// NewClient return a new http client.
+// The requests of the client share the transport, so the connections are reused,
+// unless the transport is set by WithTransport or WithHTTPSetting. See WithTransportConfig.
 func NewClient(name string, endpoint string, opts ...ClientOption) (*Client, error) {
 	res := &Client{
 		Name:     name,
@@ -66,11 +71,29 @@ func NewClient(name string, endpoint string, opts ...ClientOption) (*Client, err
 	for _, o := range opts {
 		o(res)
 	}
+	if res.Setting.Transport == nil {
+		res.Setting.Transport = newClientTransport(res.transportConfig, res.Setting)
+	}
 	return res, nil
 }
 
+// CloseIdleConnections closes the idle connections of the transport of the client
+func (c *Client) CloseIdleConnections() {
+	if t, ok := c.Setting.Transport.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
+
+// Use appends the interceptors of the client, they run after the FilterChains of Setting in the order they are added.
+// It should be called before sending the requests.
+func (c *Client) Use(fcs ...FilterChain) *Client {
+	c.interceptors = append(c.interceptors, fcs...)
+	return c
+}
+
 func (c *Client) customReq(req *BeegoHTTPRequest, opts []BeegoHTTPRequestOption) {
 	req.Setting(c.Setting)
+	req.AddFilters(c.interceptors...)
 	opts = append(c.CommonOpts, opts...)
 	for _, o := range opts {
 		o(req)
@@ -128,6 +151,9 @@ func (c *Client) handleCarrier(value interface{}, req *BeegoHTTPRequest) error {
 	if carrier, ok := value.(HTTPHeadersCarrier); ok {
 		carrier.SetHeader(req.resp.Header)
 	}
+	if carrier, ok := value.(HTTPMetricsCarrier); ok {
+		carrier.SetMetrics(req.Metrics())
+	}
 	return nil
 }
 
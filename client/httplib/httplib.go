@@ -37,7 +37,6 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"io"
-	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
@@ -50,6 +49,7 @@ import (
 
 	"github.com/beego/beego/v2/core/berror"
 	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/core/utils"
 )
 
 const contentTypeKey = "Content-Type"
@@ -77,7 +77,6 @@ func NewBeegoRequestWithCtx(ctx context.Context, rawurl, method string) *BeegoHT
 		url:     rawurl,
 		req:     req,
 		params:  map[string][]string{},
-		files:   map[string]string{},
 		setting: defaultSetting,
 		resp:    &http.Response{},
 		copyBody: func() io.ReadCloser {
@@ -116,13 +115,16 @@ type BeegoHTTPRequest struct {
 	url     string
 	req     *http.Request
 	params  map[string][]string
-	files   map[string]string
+	files   []FormFile
 	setting BeegoHTTPSettings
 	resp    *http.Response
 	// body the response body, not the request body
 	body []byte
 	// copyBody support retry strategy to avoid copy request body
 	copyBody func() io.ReadCloser
+	// uploadProgress is called while the multipart body is sent
+	uploadProgress func(p UploadProgress)
+	metrics        *metricsRecorder
 }
 
 // GetRequest returns the request object
@@ -130,6 +132,13 @@ func (b *BeegoHTTPRequest) GetRequest() *http.Request {
 	return b.req
 }
 
+// SetContext sets the context of the request, such as the context of the incoming request,
+// so the request is canceled with it, and the filters get the values of it, the span for example.
+func (b *BeegoHTTPRequest) SetContext(ctx context.Context) *BeegoHTTPRequest {
+	b.req = b.req.WithContext(ctx)
+	return b
+}
+
 // Setting changes request settings
 func (b *BeegoHTTPRequest) Setting(setting BeegoHTTPSettings) *BeegoHTTPRequest {
 	b.setting = setting
@@ -158,6 +167,7 @@ func (b *BeegoHTTPRequest) SetUserAgent(useragent string) *BeegoHTTPRequest {
 // default is 0 (never retry)
 // -1 retry indefinitely (forever)
 // Other numbers specify the exact retry amount
+// It only retries the network errors by the fixed delay, and it's ignored if SetRetryPolicy is used.
 func (b *BeegoHTTPRequest) Retries(times int) *BeegoHTTPRequest {
 	b.setting.Retries = times
 	return b
@@ -169,6 +179,12 @@ func (b *BeegoHTTPRequest) RetryDelay(delay time.Duration) *BeegoHTTPRequest {
 	return b
 }
 
+// SetRetryPolicy sets the retry policy, which replaces Retries and RetryDelay
+func (b *BeegoHTTPRequest) SetRetryPolicy(policy *RetryPolicy) *BeegoHTTPRequest {
+	b.setting.RetryPolicy = policy
+	return b
+}
+
 // SetTimeout sets connect time out and read-write time out for BeegoRequest.
 func (b *BeegoHTTPRequest) SetTimeout(connectTimeout, readWriteTimeout time.Duration) *BeegoHTTPRequest {
 	b.setting.ConnectTimeout = connectTimeout
@@ -253,7 +269,9 @@ func (b *BeegoHTTPRequest) SetFilters(fcs ...FilterChain) *BeegoHTTPRequest {
 
 // AddFilters adds filter
 func (b *BeegoHTTPRequest) AddFilters(fcs ...FilterChain) *BeegoHTTPRequest {
-	b.setting.FilterChains = append(b.setting.FilterChains, fcs...)
+	// the FilterChains may be shared with the default setting or the other requests, so they are copied
+	n := len(b.setting.FilterChains)
+	b.setting.FilterChains = append(b.setting.FilterChains[:n:n], fcs...)
 	return b
 }
 
@@ -274,10 +292,9 @@ func (b *BeegoHTTPRequest) Param(key, value string) *BeegoHTTPRequest {
 	return b
 }
 
-// PostFile adds a post file to the request
+// PostFile adds a post file to the request, the file is read when the request is sent
 func (b *BeegoHTTPRequest) PostFile(formname, filename string) *BeegoHTTPRequest {
-	b.files[formname] = filename
-	return b
+	return b.PostFormFile(FormFile{FieldName: formname, FileName: filename, Path: filename})
 }
 
 // Body adds request raw body.
@@ -385,48 +402,6 @@ func (b *BeegoHTTPRequest) buildURL(paramBody string) {
 	}
 }
 
-func (b *BeegoHTTPRequest) handleFiles() {
-	pr, pw := io.Pipe()
-	bodyWriter := multipart.NewWriter(pw)
-	go func() {
-		for formname, filename := range b.files {
-			b.handleFileToBody(bodyWriter, formname, filename)
-		}
-		for k, v := range b.params {
-			for _, vv := range v {
-				_ = bodyWriter.WriteField(k, vv)
-			}
-		}
-		_ = bodyWriter.Close()
-		_ = pw.Close()
-	}()
-	b.Header(contentTypeKey, bodyWriter.FormDataContentType())
-	b.req.Body = io.NopCloser(pr)
-	b.Header("Transfer-Encoding", "chunked")
-}
-
-func (*BeegoHTTPRequest) handleFileToBody(bodyWriter *multipart.Writer, formname string, filename string) {
-	fileWriter, err := bodyWriter.CreateFormFile(formname, filename)
-	const errFmt = "Httplib: %+v"
-	if err != nil {
-		logs.Error(errFmt, berror.Wrapf(err, CreateFormFileFailed,
-			"could not create form file, formname: %s, filename: %s", formname, filename))
-	}
-	fh, err := os.Open(filename)
-	if err != nil {
-		logs.Error(errFmt, berror.Wrapf(err, ReadFileFailed, "could not open this file %s", filename))
-	}
-	// iocopy
-	_, err = io.Copy(fileWriter, fh)
-	if err != nil {
-		logs.Error(errFmt, berror.Wrapf(err, CopyFileFailed, "could not copy this file %s", filename))
-	}
-	err = fh.Close()
-	if err != nil {
-		logs.Error(errFmt, berror.Wrapf(err, CloseFileFailed, "could not close this file %s", filename))
-	}
-}
-
 func (b *BeegoHTTPRequest) getResponse() (*http.Response, error) {
 	if b.resp.StatusCode != 0 {
 		return b.resp, nil
@@ -461,7 +436,7 @@ func (b *BeegoHTTPRequest) DoRequestWithCtx(ctx context.Context) (resp *http.Res
 	return root(ctx, b)
 }
 
-func (b *BeegoHTTPRequest) doRequest(_ context.Context) (*http.Response, error) {
+func (b *BeegoHTTPRequest) doRequest(ctx context.Context) (*http.Response, error) {
 	paramBody := b.buildParamBody()
 
 	b.buildURL(paramBody)
@@ -485,27 +460,67 @@ func (b *BeegoHTTPRequest) doRequest(_ context.Context) (*http.Response, error)
 		b.req.Header.Set("User-Agent", b.setting.UserAgent)
 	}
 
+	// propagate the request ID of the incoming request
+	if id, ok := utils.RequestIDFromContext(ctx); ok && b.req.Header.Get(utils.RequestIDHeader) == "" {
+		b.req.Header.Set(utils.RequestIDHeader, id)
+	}
+
 	if b.setting.CheckRedirect != nil {
 		client.CheckRedirect = b.setting.CheckRedirect
 	}
 
+	if ct, ok := b.setting.Transport.(*clientTransport); ok {
+		client.Timeout = b.setting.ReadWriteTimeout
+		client.Transport = ct.forRequest(b.setting)
+		if b.setting.Proxy != nil {
+			b.req = b.req.WithContext(context.WithValue(b.req.Context(), proxyKey{}, b.setting.Proxy))
+		}
+	}
+
 	return b.sendRequest(client)
 }
 
 func (b *BeegoHTTPRequest) sendRequest(client *http.Client) (resp *http.Response, err error) {
-	// retries default value is 0, it will run once.
-	// retries equal to -1, it will run forever until success
-	// retries is set, it will retry fixed times.
-	// Sleeps for a 400ms between calls to reduce spam
-	for i := 0; b.setting.Retries == -1 || i <= b.setting.Retries; i++ {
-		resp, err = client.Do(b.req)
-		if err == nil {
-			return
+	policy := b.setting.RetryPolicy
+	if policy == nil {
+		// retries default value is 0, it will run once.
+		// retries equal to -1, it will run forever until success
+		// retries is set, it will retry fixed times.
+		policy = legacyRetryPolicy(b.setting.Retries, b.setting.RetryDelay)
+	}
+	ctx := b.req.Context()
+	start := time.Now()
+	b.metrics = &metricsRecorder{}
+	for attempt := 1; ; attempt++ {
+		resp, err = client.Do(b.req.WithContext(b.metrics.trace(ctx)))
+		if (policy.MaxRetries != -1 && attempt > policy.MaxRetries) || !policy.shouldRetry(b.req, resp, err) {
+			break
+		}
+		delay := policy.delay(attempt, resp)
+		if policy.MaxElapsedTime > 0 && time.Since(start)+delay > policy.MaxElapsedTime {
+			break
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(RetryInfo{Attempt: attempt, Request: b.req, Response: resp, Err: err, Delay: delay})
+		}
+		if resp != nil {
+			// the connection is reused if the body is drained
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, berror.Wrap(ctx.Err(), SendRequestFailed, "sending request fail")
+		case <-timer.C:
 		}
-		time.Sleep(b.setting.RetryDelay)
 		b.req.Body = b.copyBody()
 	}
-	return nil, berror.Wrap(err, SendRequestFailed, "sending request fail")
+	if err != nil {
+		return nil, berror.Wrap(err, SendRequestFailed, "sending request fail")
+	}
+	return resp, nil
 }
 
 func (b *BeegoHTTPRequest) buildCookieJar() http.CookieJar {
@@ -601,7 +616,7 @@ func (b *BeegoHTTPRequest) Bytes() ([]byte, error) {
 }
 
 // ToFile saves the body data in response to one file.
-// Calls Response inner.
+// Calls Response inner. DownloadFile supports the progress callbacks and the resume.
 func (b *BeegoHTTPRequest) ToFile(filename string) error {
 	resp, err := b.getResponse()
 	if err != nil {
@@ -0,0 +1,101 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"context"
+	"net/http"
+)
+
+// The interceptors are the FilterChains added by Client.Use, BeegoHTTPRequest.AddFilters or AddDefaultFilter.
+// They run in the order they are added: the first one sees the request first and the response last.
+// An interceptor mutates the request before calling next, observes or replaces the response after next returns,
+// and short-circuits the request by returning without calling next:
+//
+//	client.Use(func(next httplib.Filter) httplib.Filter {
+//		return func(ctx context.Context, req *httplib.BeegoHTTPRequest) (*http.Response, error) {
+//			if resp, ok := lookup(req.GetRequest().URL.String()); ok {
+//				return resp, nil
+//			}
+//			return next(ctx, req)
+//		}
+//	})
+//
+// The log, prometheus, opentracing, opentelemetry and circuitbreaker packages under httplib/filter
+// provide the interceptors for logging, metrics, tracing and failing fast.
+
+// RequestInterceptor returns the interceptor calling fn before sending the request,
+// fn may mutate req, and the request isn't sent if fn returns an error.
+func RequestInterceptor(fn func(ctx context.Context, req *BeegoHTTPRequest) error) FilterChain {
+	return func(next Filter) Filter {
+		return func(ctx context.Context, req *BeegoHTTPRequest) (*http.Response, error) {
+			if err := fn(ctx, req); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// ResponseInterceptor returns the interceptor calling fn with the result of the request,
+// the returned response and error replace the result.
+func ResponseInterceptor(fn func(ctx context.Context, req *BeegoHTTPRequest,
+	resp *http.Response, err error) (*http.Response, error),
+) FilterChain {
+	return func(next Filter) Filter {
+		return func(ctx context.Context, req *BeegoHTTPRequest) (*http.Response, error) {
+			resp, err := next(ctx, req)
+			return fn(ctx, req, resp, err)
+		}
+	}
+}
+
+// ChainInterceptors composes fcs into one interceptor, fcs[0] is the outermost one
+func ChainInterceptors(fcs ...FilterChain) FilterChain {
+	return func(next Filter) Filter {
+		for i := len(fcs) - 1; i >= 0; i-- {
+			next = fcs[i](next)
+		}
+		return next
+	}
+}
+
+// AuthInterceptor sets the Authorization header by tokenFactory, it's called for every request,
+// so the token can be refreshed.
+func AuthInterceptor(tokenFactory func() string) FilterChain {
+	return RequestInterceptor(func(ctx context.Context, req *BeegoHTTPRequest) error {
+		req.Header("Authorization", tokenFactory())
+		return nil
+	})
+}
+
+// BasicAuthInterceptor sets the basic auth by basicAuth for every request
+func BasicAuthInterceptor(basicAuth func() (string, string)) FilterChain {
+	return RequestInterceptor(func(ctx context.Context, req *BeegoHTTPRequest) error {
+		req.SetBasicAuth(basicAuth())
+		return nil
+	})
+}
+
+// RetryInterceptor sets the retry policy of the requests which don't have one.
+// The retries happen when the request is sent, so the interceptors see the retries as one call.
+func RetryInterceptor(policy *RetryPolicy) FilterChain {
+	return RequestInterceptor(func(ctx context.Context, req *BeegoHTTPRequest) error {
+		if req.setting.RetryPolicy == nil {
+			req.SetRetryPolicy(policy)
+		}
+		return nil
+	})
+}
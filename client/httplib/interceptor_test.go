@@ -0,0 +1,95 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientUse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("auth=" + r.Header.Get("Authorization") + " order=" + r.Header.Get("X-Order")))
+	}))
+	defer ts.Close()
+
+	var order []string
+	trace := func(name string) FilterChain {
+		return func(next Filter) Filter {
+			return func(ctx context.Context, req *BeegoHTTPRequest) (*http.Response, error) {
+				order = append(order, "before "+name)
+				req.Header("X-Order", req.GetRequest().Header.Get("X-Order")+name)
+				resp, err := next(ctx, req)
+				order = append(order, "after "+name)
+				return resp, err
+			}
+		}
+	}
+	client, err := NewClient("test", ts.URL, WithInterceptors(trace("a")))
+	assert.Nil(t, err)
+	client.Use(AuthInterceptor(func() string { return "token" }), ChainInterceptors(trace("b"), trace("c")))
+
+	var s string
+	assert.Nil(t, client.Get(&s, "/"))
+	assert.Equal(t, "auth=token order=abc", s)
+	assert.Equal(t, []string{"before a", "before b", "before c", "after c", "after b", "after a"}, order)
+
+	// the interceptors of the client don't change the default setting
+	other, _ := NewClient("other", ts.URL)
+	assert.Nil(t, other.Get(&s, "/"))
+	assert.Equal(t, "auth= order=", s)
+}
+
+func TestRequestInterceptor(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	// short-circuit
+	errDenied := errors.New("denied")
+	client, _ := NewClient("test", ts.URL)
+	client.Use(RequestInterceptor(func(ctx context.Context, req *BeegoHTTPRequest) error {
+		return errDenied
+	}))
+	assert.True(t, errors.Is(client.Get(nil, "/"), errDenied))
+	assert.Equal(t, int32(0), calls.Load())
+
+	// observe and replace the response
+	var status int
+	client, _ = NewClient("test", ts.URL)
+	client.Use(
+		ResponseInterceptor(func(ctx context.Context, req *BeegoHTTPRequest, resp *http.Response, err error) (*http.Response, error) {
+			status = resp.StatusCode
+			_ = resp.Body.Close()
+			return NewHttpResponseWithJsonBody("fallback"), nil
+		}),
+		RetryInterceptor(&RetryPolicy{MaxRetries: 2, InitialInterval: time.Millisecond}),
+	)
+	var s string
+	assert.Nil(t, client.Get(&s, "/"))
+	assert.Equal(t, "fallback", s)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, int32(3), calls.Load())
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestMetrics is the timing of the last attempt of the request, it's collected by httptrace
+type RequestMetrics struct {
+	// DNSLookup, Connect and TLSHandshake are 0 if the connection is reused
+	DNSLookup    time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	// GetConn is the time to get the connection, including DNSLookup, Connect and TLSHandshake
+	GetConn time.Duration
+	// TTFB is the time from the start of the attempt to the first byte of the response
+	TTFB time.Duration
+	// ConnReused is whether the connection is taken from the pool, ConnIdleTime is how long it was idle
+	ConnReused   bool
+	ConnIdleTime time.Duration
+	RemoteAddr   string
+}
+
+// HTTPMetricsCarrier If value implement HTTPMetricsCarrier. the metrics of the request will pass to SetMetrics
+type HTTPMetricsCarrier interface {
+	SetMetrics(metrics RequestMetrics)
+}
+
+// Metrics returns the metrics of the request after the response is received
+func (b *BeegoHTTPRequest) Metrics() RequestMetrics {
+	if b.metrics == nil {
+		return RequestMetrics{}
+	}
+	b.metrics.mu.Lock()
+	defer b.metrics.mu.Unlock()
+	return b.metrics.RequestMetrics
+}
+
+// metricsRecorder records the metrics of an attempt, the callbacks of httptrace may be called by the dialing goroutines
+type metricsRecorder struct {
+	mu sync.Mutex
+	RequestMetrics
+	start, dnsStart, connectStart, tlsStart time.Time
+}
+
+// trace returns the context recording the metrics of a new attempt
+func (m *metricsRecorder) trace(ctx context.Context) context.Context {
+	m.mu.Lock()
+	m.RequestMetrics = RequestMetrics{}
+	m.start = time.Now()
+	m.mu.Unlock()
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			m.mu.Lock()
+			m.dnsStart = time.Now()
+			m.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			m.mu.Lock()
+			m.DNSLookup = time.Since(m.dnsStart)
+			m.mu.Unlock()
+		},
+		ConnectStart: func(network, addr string) {
+			m.mu.Lock()
+			// the dual-stack dialing starts more than one connection
+			if m.connectStart.Before(m.start) {
+				m.connectStart = time.Now()
+			}
+			m.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			m.mu.Lock()
+			if err == nil {
+				m.Connect = time.Since(m.connectStart)
+			}
+			m.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			m.mu.Lock()
+			m.tlsStart = time.Now()
+			m.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			m.mu.Lock()
+			m.TLSHandshake = time.Since(m.tlsStart)
+			m.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			m.mu.Lock()
+			m.GetConn = time.Since(m.start)
+			m.ConnReused = info.Reused
+			m.ConnIdleTime = info.IdleTime
+			m.RemoteAddr = info.Conn.RemoteAddr().String()
+			m.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			m.mu.Lock()
+			m.TTFB = time.Since(m.start)
+			m.mu.Unlock()
+		},
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryInfo describes the failed attempt passed to RetryPolicy.OnRetry
+type RetryInfo struct {
+	// Attempt starts from 1, it's the attempt which just failed
+	Attempt  int
+	Request  *http.Request
+	Response *http.Response
+	Err      error
+	// Delay is the time to wait before the next attempt
+	Delay time.Duration
+}
+
+// RetryPolicy decides whether and when the failed requests are sent again.
+// The delay before the n-th retry is InitialInterval * Multiplier^(n-1), capped by MaxInterval,
+// and randomized by Jitter. The Retry-After header of the response replaces the delay unless IgnoreRetryAfter is set.
+type RetryPolicy struct {
+	// MaxRetries is the max number of the retries, -1 retries until MaxElapsedTime passes or the context is done
+	MaxRetries      int
+	InitialInterval time.Duration
+	// MaxInterval caps the delay, 0 means no cap
+	MaxInterval time.Duration
+	// Multiplier grows the delay after every retry, the delay is constant if it isn't larger than 1
+	Multiplier float64
+	// Jitter randomizes the delay in [delay*(1-Jitter), delay*(1+Jitter)], it's between 0 and 1
+	Jitter float64
+	// MaxElapsedTime stops retrying if the next attempt would start after it since the first attempt, 0 means no limit
+	MaxElapsedTime time.Duration
+	// RetryOn decides whether the attempt should be retried, DefaultRetryOn is used if it's nil
+	RetryOn func(req *http.Request, resp *http.Response, err error) bool
+	// IgnoreRetryAfter ignores the Retry-After header of the responses
+	IgnoreRetryAfter bool
+	// OnRetry is called before waiting for the next attempt
+	OnRetry func(info RetryInfo)
+}
+
+// NewRetryPolicy returns the policy retrying maxRetries times by exponential backoff with jitter,
+// the delay starts from 100ms and doubles until 10s.
+// Only the idempotent requests are retried, see DefaultRetryOn.
+func NewRetryPolicy(maxRetries int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries:      maxRetries,
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+	}
+}
+
+// legacyRetryPolicy keeps the behavior of BeegoHTTPSettings.Retries and RetryDelay,
+// which retries all the requests on the network errors by the fixed delay
+func legacyRetryPolicy(retries int, delay time.Duration) *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries:       retries,
+		InitialInterval:  delay,
+		RetryOn:          RetryOnNetworkError,
+		IgnoreRetryAfter: true,
+	}
+}
+
+// shouldRetry returns whether the attempt should be retried
+func (p *RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if p.RetryOn == nil {
+		return DefaultRetryOn(req, resp, err)
+	}
+	return p.RetryOn(req, resp, err)
+}
+
+// delay returns the time to wait after the attempt which starts from 1
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if !p.IgnoreRetryAfter && resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+	d := float64(p.InitialInterval)
+	if p.Multiplier > 1 {
+		d *= math.Pow(p.Multiplier, float64(attempt-1))
+	}
+	if p.MaxInterval > 0 && d > float64(p.MaxInterval) {
+		d = float64(p.MaxInterval)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// parseRetryAfter parses the Retry-After header, which is the seconds or the HTTP date
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// DefaultRetryOn retries the idempotent requests on the network errors, 429 and 5xx except 501
+func DefaultRetryOn(req *http.Request, resp *http.Response, err error) bool {
+	return IsIdempotent(req) && (RetryOnNetworkError(req, resp, err) || RetryOnStatus(req, resp, err))
+}
+
+// RetryOnNetworkError retries the requests which get no response
+func RetryOnNetworkError(req *http.Request, resp *http.Response, err error) bool {
+	if err == nil {
+		return false
+	}
+	// the request is canceled by the caller
+	return req.Context().Err() == nil
+}
+
+// RetryOnStatus retries the responses of 429 and 5xx except 501
+func RetryOnStatus(req *http.Request, resp *http.Response, err error) bool {
+	if resp == nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented)
+}
+
+// RetryOnStatusCodes returns the predicate retrying the responses of codes
+func RetryOnStatusCodes(codes ...int) func(req *http.Request, resp *http.Response, err error) bool {
+	return func(req *http.Request, resp *http.Response, err error) bool {
+		if resp == nil {
+			return false
+		}
+		for _, code := range codes {
+			if resp.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IsIdempotent returns whether the method of req is idempotent, or req has the Idempotency-Key header
+func IsIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFlakyServer fails the first failures requests with status
+func newFlakyServer(failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write(append([]byte("ok "), body...))
+	}))
+	return ts, &calls
+}
+
+func TestRetryPolicy(t *testing.T) {
+	ts, calls := newFlakyServer(2, http.StatusServiceUnavailable, nil)
+	defer ts.Close()
+
+	var infos []RetryInfo
+	policy := NewRetryPolicy(3)
+	policy.InitialInterval = time.Millisecond
+	policy.OnRetry = func(info RetryInfo) {
+		infos = append(infos, info)
+	}
+	s, err := Get(ts.URL).SetRetryPolicy(policy).String()
+	assert.Nil(t, err)
+	assert.Equal(t, "ok ", s)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 2, len(infos))
+	assert.Equal(t, 1, infos[0].Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, infos[0].Response.StatusCode)
+	assert.Equal(t, 2, infos[1].Attempt)
+
+	// the last response is returned when the retries are exhausted
+	calls.Store(0)
+	resp, err := Get(ts.URL).SetRetryPolicy(&RetryPolicy{MaxRetries: 1}).Response()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetryPolicyIdempotent(t *testing.T) {
+	ts, calls := newFlakyServer(1, http.StatusBadGateway, nil)
+	defer ts.Close()
+	policy := &RetryPolicy{MaxRetries: 2, InitialInterval: time.Millisecond}
+
+	// POST isn't retried by default
+	resp, err := Post(ts.URL).Body("body").SetRetryPolicy(policy).Response()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// the body is sent again with the Idempotency-Key
+	calls.Store(0)
+	s, err := Post(ts.URL).Body("body").Header("Idempotency-Key", "1").SetRetryPolicy(policy).String()
+	assert.Nil(t, err)
+	assert.Equal(t, "ok body", s)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetryPolicyRetryAfter(t *testing.T) {
+	ts, calls := newFlakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}})
+	defer ts.Close()
+
+	start := time.Now()
+	s, err := Get(ts.URL).SetRetryPolicy(&RetryPolicy{MaxRetries: 1, InitialInterval: time.Millisecond}).String()
+	assert.Nil(t, err)
+	assert.Equal(t, "ok ", s)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.True(t, time.Since(start) >= time.Second)
+
+	// the next attempt would start after MaxElapsedTime
+	calls.Store(0)
+	resp, err := Get(ts.URL).SetRetryPolicy(&RetryPolicy{MaxRetries: 1, MaxElapsedTime: 500 * time.Millisecond}).Response()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryPolicyContext(t *testing.T) {
+	ts, _ := newFlakyServer(100, http.StatusServiceUnavailable, nil)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	policy := &RetryPolicy{MaxRetries: -1, InitialInterval: 20 * time.Millisecond}
+	_, err := NewBeegoRequestWithCtx(ctx, ts.URL, http.MethodGet).SetRetryPolicy(policy).Response()
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := &RetryPolicy{InitialInterval: 100 * time.Millisecond, Multiplier: 2, MaxInterval: 300 * time.Millisecond}
+	assert.Equal(t, 100*time.Millisecond, p.delay(1, nil))
+	assert.Equal(t, 200*time.Millisecond, p.delay(2, nil))
+	assert.Equal(t, 300*time.Millisecond, p.delay(3, nil))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.delay(1, nil)
+		assert.True(t, d >= 50*time.Millisecond && d <= 150*time.Millisecond, d)
+	}
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, p.delay(1, resp) > 59*time.Minute)
+	p.IgnoreRetryAfter = true
+	assert.True(t, p.delay(1, resp) <= 150*time.Millisecond)
+
+	_, ok := parseRetryAfter("soon")
+	assert.False(t, ok)
+}
@@ -38,6 +38,9 @@ type BeegoHTTPSettings struct {
 	RetryDelay       time.Duration
 	FilterChains     []FilterChain
 	EscapeHTML       bool // if set to false means will not escape escape HTML special characters during processing, default true
+
+	// RetryPolicy replaces Retries and RetryDelay if it isn't nil
+	RetryPolicy *RetryPolicy
 }
 
 // createDefaultCookie creates a global cookiejar to store cookies.
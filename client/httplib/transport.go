@@ -0,0 +1,149 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig tunes the transport shared by the requests of a Client, the zero fields use the defaults
+type TransportConfig struct {
+	// MaxIdleConns is 100 by default
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is 100 by default
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections including the ones in use, 0 means no limit
+	MaxConnsPerHost int
+	// IdleConnTimeout is 90s by default
+	IdleConnTimeout time.Duration
+	// DialTimeout is ConnectTimeout of the setting of Client by default
+	DialTimeout time.Duration
+	// KeepAlive is 30s by default
+	KeepAlive time.Duration
+	// TLSHandshakeTimeout is 10s by default
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout is the time to wait for the response headers after the request is written, 0 means no limit
+	ResponseHeaderTimeout time.Duration
+	// TLSClientConfig and Proxy are the ones of the setting of Client by default.
+	// The proxy, the connect timeout and the TLS config set by the request take precedence.
+	TLSClientConfig   *tls.Config
+	Proxy             func(*http.Request) (*url.URL, error)
+	ForceAttemptHTTP2 bool
+}
+
+// NewTransport creates the transport by cfg
+func NewTransport(cfg TransportConfig) *http.Transport {
+	if cfg.MaxIdleConns == 0 {
+		cfg.MaxIdleConns = 100
+	}
+	if cfg.MaxIdleConnsPerHost == 0 {
+		cfg.MaxIdleConnsPerHost = 100
+	}
+	if cfg.IdleConnTimeout == 0 {
+		cfg.IdleConnTimeout = 90 * time.Second
+	}
+	if cfg.KeepAlive == 0 {
+		cfg.KeepAlive = 30 * time.Second
+	}
+	if cfg.TLSHandshakeTimeout == 0 {
+		cfg.TLSHandshakeTimeout = 10 * time.Second
+	}
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	return &http.Transport{
+		Proxy:                 cfg.Proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       cfg.TLSClientConfig,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     cfg.ForceAttemptHTTP2,
+	}
+}
+
+// clientTransport is the transport created by NewClient, which is shared by the requests of the client.
+// The connections are reused, so ReadWriteTimeout is the timeout of the request instead of the deadline of the connection.
+type clientTransport struct {
+	*http.Transport
+	// the setting which the transport is created by, see forRequest
+	connectTimeout time.Duration
+	tlsConfig      *tls.Config
+	keepAlive      time.Duration
+}
+
+// proxyKey is the context key of the proxy of the request
+type proxyKey struct{}
+
+// newClientTransport creates the transport of the client by cfg, the zero fields of cfg are filled by setting.
+// The proxy of the request setting takes precedence over cfg.Proxy, so SetProxy works for the shared transport.
+func newClientTransport(cfg TransportConfig, setting BeegoHTTPSettings) *clientTransport {
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = setting.ConnectTimeout
+	}
+	if cfg.TLSClientConfig == nil {
+		cfg.TLSClientConfig = setting.TLSClientConfig
+	}
+	if cfg.KeepAlive == 0 {
+		cfg.KeepAlive = 30 * time.Second
+	}
+	proxy := cfg.Proxy
+	cfg.Proxy = func(req *http.Request) (*url.URL, error) {
+		if p, ok := req.Context().Value(proxyKey{}).(func(*http.Request) (*url.URL, error)); ok {
+			return p(req)
+		}
+		if proxy != nil {
+			return proxy(req)
+		}
+		return nil, nil
+	}
+	return &clientTransport{
+		Transport:      NewTransport(cfg),
+		connectTimeout: setting.ConnectTimeout,
+		tlsConfig:      setting.TLSClientConfig,
+		keepAlive:      cfg.KeepAlive,
+	}
+}
+
+// forRequest returns the transport for the request whose setting is s.
+// If the connect timeout or the TLS config is overridden by the request, the transport is cloned,
+// and the connections of the clone aren't kept alive.
+func (t *clientTransport) forRequest(s BeegoHTTPSettings) http.RoundTripper {
+	if s.ConnectTimeout == t.connectTimeout && s.TLSClientConfig == t.tlsConfig {
+		return t
+	}
+	trans := t.Transport.Clone()
+	trans.DisableKeepAlives = true
+	if s.ConnectTimeout != t.connectTimeout {
+		dialer := &net.Dialer{
+			Timeout:   s.ConnectTimeout,
+			KeepAlive: t.keepAlive,
+		}
+		trans.DialContext = dialer.DialContext
+	}
+	if s.TLSClientConfig != t.tlsConfig {
+		trans.TLSClientConfig = s.TLSClientConfig
+	}
+	return trans
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metricsResponse struct {
+	metrics RequestMetrics
+	bytes   []byte
+}
+
+func (r *metricsResponse) SetMetrics(metrics RequestMetrics) {
+	r.metrics = metrics
+}
+
+func (r *metricsResponse) SetBytes(bytes []byte) {
+	r.bytes = bytes
+}
+
+func TestClientTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	// the transport of the default setting may be set by the other tests
+	client, err := NewClient("test", ts.URL, WithTransport(nil), WithTransportConfig(TransportConfig{MaxConnsPerHost: 2}))
+	require.NoError(t, err)
+	defer client.CloseIdleConnections()
+	trans, ok := client.Setting.Transport.(*clientTransport)
+	require.True(t, ok)
+	assert.Equal(t, 2, trans.MaxConnsPerHost)
+	assert.Equal(t, 100, trans.MaxIdleConnsPerHost)
+
+	// the connection is reused by the next request
+	first := &metricsResponse{}
+	assert.Nil(t, client.Get(first, "/"))
+	assert.Equal(t, "{}", string(first.bytes))
+	assert.False(t, first.metrics.ConnReused)
+	assert.True(t, first.metrics.Connect > 0)
+	assert.Equal(t, ts.Listener.Addr().String(), first.metrics.RemoteAddr)
+	assert.True(t, first.metrics.TTFB >= first.metrics.GetConn)
+
+	second := &metricsResponse{}
+	assert.Nil(t, client.Get(second, "/"))
+	assert.True(t, second.metrics.ConnReused)
+	assert.Equal(t, time.Duration(0), second.metrics.Connect)
+
+	// ReadWriteTimeout is the timeout of the request
+	err = client.Get(nil, "/slow", WithTimeout(time.Second, 50*time.Millisecond))
+	assert.NotNil(t, err)
+
+	// the transport set by WithTransport is used as it is
+	client, _ = NewClient("test", ts.URL, WithTransport(http.DefaultTransport))
+	assert.Equal(t, http.DefaultTransport, client.Setting.Transport)
+}
+
+func TestClientTransportRequestSetting(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the absolute URL is sent to the proxy
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"url":"` + r.URL.String() + `"}`))
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client, err := NewClient("test", "http://beego.invalid", WithTransport(nil),
+		WithTransportConfig(TransportConfig{MaxConnsPerHost: 2}))
+	require.NoError(t, err)
+	defer client.CloseIdleConnections()
+
+	// the proxy set by the request is used by the shared transport
+	resp := &metricsResponse{}
+	assert.Nil(t, client.Get(resp, "/users", func(req *BeegoHTTPRequest) {
+		req.SetProxy(http.ProxyURL(proxyURL))
+	}))
+	assert.Equal(t, `{"url":"http://beego.invalid/users"}`, string(resp.bytes))
+
+	trans := client.Setting.Transport.(*clientTransport)
+	assert.Equal(t, trans, trans.forRequest(client.Setting))
+
+	// the connect timeout and the TLS config set by the request clone the transport
+	setting := client.Setting
+	setting.ConnectTimeout = time.Millisecond
+	setting.TLSClientConfig = &tls.Config{ServerName: "beego.vip"}
+	cloned, ok := trans.forRequest(setting).(*http.Transport)
+	require.True(t, ok)
+	assert.NotEqual(t, trans.Transport, cloned)
+	assert.True(t, cloned.DisableKeepAlives)
+	assert.Equal(t, setting.TLSClientConfig, cloned.TLSClientConfig)
+	assert.Equal(t, 2, cloned.MaxConnsPerHost)
+
+	// 192.0.2.1 is reserved for documentation, so the dial times out
+	req := Get("http://192.0.2.1/").Setting(client.Setting).SetTimeout(time.Nanosecond, time.Second)
+	start := time.Now()
+	_, err = req.Response()
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRequestMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	req := Get(ts.URL)
+	assert.Equal(t, RequestMetrics{}, req.Metrics())
+	_, err := req.String()
+	assert.Nil(t, err)
+	m := req.Metrics()
+	assert.False(t, m.ConnReused)
+	assert.True(t, m.TTFB > 0)
+	assert.Equal(t, ts.Listener.Addr().String(), m.RemoteAddr)
+}
@@ -0,0 +1,221 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"strings"
+
+	"github.com/beego/beego/v2/core/berror"
+)
+
+// FormFile is a file part of the multipart body, it's streamed when the request is sent
+type FormFile struct {
+	FieldName string
+	FileName  string
+	// ContentType is application/octet-stream if it's empty
+	ContentType string
+	// Path is opened when the request is sent if Reader is nil, so the retries are able to send it again
+	Path string
+	// Reader is read when the request is sent, it isn't closed by httplib
+	Reader io.Reader
+	// Size is the size of Reader if it's positive, it's not required if Reader is *bytes.Reader,
+	// *strings.Reader or *os.File. Content-Length is set if the sizes of all the files are known.
+	Size int64
+}
+
+// UploadProgress is passed to the progress callback of the multipart upload
+type UploadProgress struct {
+	// Written is the number of the bytes of the multipart body written
+	Written int64
+	// Total is the size of the multipart body, it's -1 if it's unknown
+	Total int64
+	// FieldName and FileName are of the file being written, they're empty when the fields are written
+	FieldName string
+	FileName  string
+}
+
+// PostFormFile adds the files to the multipart body, they're sent in the order they're added
+func (b *BeegoHTTPRequest) PostFormFile(files ...FormFile) *BeegoHTTPRequest {
+	b.files = append(b.files, files...)
+	return b
+}
+
+// SetUploadProgress sets the callback which is called while the multipart body is written,
+// it's called in the goroutine writing the body.
+func (b *BeegoHTTPRequest) SetUploadProgress(fn func(p UploadProgress)) *BeegoHTTPRequest {
+	b.uploadProgress = fn
+	return b
+}
+
+// handleFiles streams the multipart body by the pipe, so the files are never loaded into the memory
+func (b *BeegoHTTPRequest) handleFiles() {
+	mw := multipart.NewWriter(io.Discard)
+	boundary := mw.Boundary()
+	total := b.multipartSize(boundary)
+	b.Header(contentTypeKey, mw.FormDataContentType())
+	if total >= 0 {
+		b.req.ContentLength = total
+	}
+	b.req.Body = b.multipartBody(boundary, total)
+	for _, f := range b.files {
+		if f.Reader != nil {
+			// the readers can't be read again
+			return
+		}
+	}
+	b.copyBody = func() io.ReadCloser {
+		return b.multipartBody(boundary, total)
+	}
+}
+
+// multipartBody returns the reader of the body, the writing goroutine exits when the reader is closed,
+// and the errors of writing are returned by the reader.
+func (b *BeegoHTTPRequest) multipartBody(boundary string, total int64) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		w := &progressWriter{w: pw, fn: b.uploadProgress, progress: UploadProgress{Total: total}}
+		_ = pw.CloseWithError(b.writeMultipart(w, boundary))
+	}()
+	return pr
+}
+
+func (b *BeegoHTTPRequest) writeMultipart(w *progressWriter, boundary string) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+	for i := range b.files {
+		f := &b.files[i]
+		w.progress.FieldName, w.progress.FileName = f.FieldName, f.FileName
+		if err := writeFormFile(mw, f); err != nil {
+			return err
+		}
+	}
+	w.progress.FieldName, w.progress.FileName = "", ""
+	for k, v := range b.params {
+		for _, vv := range v {
+			if err := mw.WriteField(k, vv); err != nil {
+				return err
+			}
+		}
+	}
+	return mw.Close()
+}
+
+func writeFormFile(mw *multipart.Writer, f *FormFile) error {
+	part, err := mw.CreatePart(formFileHeader(f))
+	if err != nil {
+		return berror.Wrapf(err, CreateFormFileFailed,
+			"could not create form file, formname: %s, filename: %s", f.FieldName, f.FileName)
+	}
+	r := f.Reader
+	if r == nil {
+		fh, err := os.Open(f.Path)
+		if err != nil {
+			return berror.Wrapf(err, ReadFileFailed, "could not open this file %s", f.Path)
+		}
+		defer fh.Close()
+		r = fh
+	}
+	if _, err = io.Copy(part, r); err != nil {
+		return berror.Wrapf(err, CopyFileFailed, "could not copy this file %s", f.FileName)
+	}
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func formFileHeader(f *FormFile) textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(f.FieldName), quoteEscaper.Replace(f.FileName)))
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+	return h
+}
+
+// multipartSize returns the size of the multipart body, or -1 if the size of any file is unknown
+func (b *BeegoHTTPRequest) multipartSize(boundary string) int64 {
+	cw := &progressWriter{w: io.Discard}
+	mw := multipart.NewWriter(cw)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return -1
+	}
+	for i := range b.files {
+		size := formFileSize(&b.files[i])
+		if size < 0 {
+			return -1
+		}
+		if _, err := mw.CreatePart(formFileHeader(&b.files[i])); err != nil {
+			return -1
+		}
+		cw.progress.Written += size
+	}
+	for k, v := range b.params {
+		for _, vv := range v {
+			_ = mw.WriteField(k, vv)
+		}
+	}
+	_ = mw.Close()
+	return cw.progress.Written
+}
+
+func formFileSize(f *FormFile) int64 {
+	if f.Reader == nil {
+		if fi, err := os.Stat(f.Path); err == nil {
+			return fi.Size()
+		}
+		return -1
+	}
+	if f.Size > 0 {
+		return f.Size
+	}
+	switch r := f.Reader.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case *os.File:
+		if fi, err := r.Stat(); err == nil && fi.Mode().IsRegular() {
+			// the file may be read partially
+			if offset, err := r.Seek(0, io.SeekCurrent); err == nil {
+				return fi.Size() - offset
+			}
+		}
+	}
+	return -1
+}
+
+// progressWriter counts the bytes written, and reports them to fn
+type progressWriter struct {
+	w        io.Writer
+	fn       func(p UploadProgress)
+	progress UploadProgress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.progress.Written += int64(n)
+	if w.fn != nil && n > 0 {
+		w.fn(w.progress)
+	}
+	return n, err
+}
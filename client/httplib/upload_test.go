@@ -0,0 +1,113 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httplib
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newUploadServer responds the parts of the multipart body and Content-Length
+func newUploadServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var parts []string
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(p)
+			parts = append(parts, strings.Join([]string{p.FormName(), p.FileName(),
+				p.Header.Get("Content-Type"), string(data[:minInt(len(data), 5)])}, ","))
+		}
+		_, _ = io.WriteString(w, strings.Join(parts, ";")+";"+r.Header.Get("Content-Length"))
+	}))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func TestPostFormFile(t *testing.T) {
+	ts := newUploadServer()
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "beego.txt")
+	large := bytes.Repeat([]byte("beego"), 1<<18)
+	assert.Nil(t, os.WriteFile(path, large, 0o644))
+
+	var last UploadProgress
+	req := Post(ts.URL).Param("username", "astaxie").
+		PostFile("file1", path).
+		PostFormFile(FormFile{FieldName: "file2", FileName: "a.json", ContentType: "application/json",
+			Reader: strings.NewReader(`{"name":"astaxie"}`)}).
+		SetUploadProgress(func(p UploadProgress) {
+			last = p
+		})
+	s, err := req.String()
+	assert.Nil(t, err)
+	parts := strings.Split(s, ";")
+	assert.Equal(t, []string{
+		"file1,beego.txt,application/octet-stream,beego",
+		`file2,a.json,application/json,{"nam`,
+		"username,,,astax",
+	}, parts[:3])
+	// Content-Length is set since the sizes are known
+	assert.True(t, last.Total > int64(len(large)))
+	assert.Equal(t, last.Total, last.Written)
+	assert.Equal(t, parts[3], strconv.FormatInt(last.Total, 10))
+}
+
+func TestPostFormFileStream(t *testing.T) {
+	ts := newUploadServer()
+	defer ts.Close()
+
+	// the size of the reader is unknown, so the body is chunked
+	r := io.MultiReader(strings.NewReader("hello "), strings.NewReader("beego"))
+	s, err := Post(ts.URL).PostFormFile(FormFile{FieldName: "file", FileName: "hello.txt", Reader: r}).String()
+	assert.Nil(t, err)
+	assert.Equal(t, "file,hello.txt,application/octet-stream,hello;", s)
+
+	// the errors of reading the files fail the request
+	_, err = Post(ts.URL).PostFile("file", filepath.Join(t.TempDir(), "missing.txt")).String()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "could not open this file")
+
+	_, err = Post(ts.URL).PostFormFile(FormFile{FieldName: "file", FileName: "broken.txt",
+		Reader: iotest.ErrReader(io.ErrUnexpectedEOF)}).String()
+	assert.NotNil(t, err)
+}
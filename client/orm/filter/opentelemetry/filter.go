@@ -23,6 +23,7 @@ import (
 	otelTrace "go.opentelemetry.io/otel/trace"
 
 	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/utils"
 )
 
 type (
@@ -76,6 +77,9 @@ func (builder *FilterChainBuilder) buildSpan(ctx context.Context, span otelTrace
 	span.SetAttributes(attribute.String("orm.txName", v))
 	span.SetAttributes(attribute.String("span.kind", "client"))
 	span.SetAttributes(attribute.String("component", "beego"))
+	if id, ok := utils.RequestIDFromContext(ctx); ok {
+		span.SetAttributes(attribute.String("request.id", id))
+	}
 
 	if builder.customSpanFunc != nil {
 		builder.customSpanFunc(ctx, span, inv)
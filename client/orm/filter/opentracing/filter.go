@@ -21,6 +21,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 
 	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/core/utils"
 )
 
 // FilterChainBuilder provides an extension point
@@ -57,6 +58,9 @@ func (builder *FilterChainBuilder) buildSpan(span opentracing.Span, ctx context.
 	span.SetTag("orm.txName", ctx.Value(orm.TxNameKey))
 	span.SetTag("span.kind", "client")
 	span.SetTag("component", "beego")
+	if id, ok := utils.RequestIDFromContext(ctx); ok {
+		span.SetTag("request.id", id)
+	}
 
 	if builder.CustomSpanFunc != nil {
 		builder.CustomSpanFunc(span, ctx, inv)
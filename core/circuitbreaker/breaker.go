@@ -0,0 +1,279 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package circuitbreaker is the state machine of the circuit breaker,
+// it's shared by the server filter in server/web/filter/circuitbreaker
+// and the client filter in client/httplib/filter/circuitbreaker.
+//
+// The breaker is closed at first, and it's open if the failures exceed the thresholds.
+// The requests are rejected when it's open, and it's half-open after the cooldown.
+// If the trial requests in half-open state succeed, it's closed again, otherwise it's open again.
+package circuitbreaker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the state of breaker
+type State int
+
+const (
+	// StateClosed means that the requests are allowed
+	StateClosed State = iota
+	// StateOpen means that the requests are rejected
+	StateOpen
+	// StateHalfOpen means that a few requests are allowed to check whether the callee recovers
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// Settings are the thresholds of the breakers
+type Settings struct {
+	// FailureRatio opens the breaker if the ratio of the failures in Window reaches it,
+	// and there are MinRequests requests at least. 0 disables it
+	FailureRatio float64
+	MinRequests  uint
+	Window       time.Duration
+	// ConsecutiveFailures opens the breaker after the consecutive failures, 0 disables it
+	ConsecutiveFailures uint
+	// Cooldown is how long the breaker keeps open before it's half-open
+	Cooldown time.Duration
+	// HalfOpenRequests is the number of the trial requests in half-open state,
+	// the breaker is closed if all of them succeed
+	HalfOpenRequests uint
+	// OnStateChange is called without holding the lock when the state of the breaker of key changes
+	OnStateChange func(key string, from, to State)
+}
+
+// Breaker is the circuit breaker of a key, e.g. a host
+type Breaker struct {
+	settings *Settings
+	key      string
+
+	mutex       sync.Mutex
+	state       State
+	windowStart time.Time
+	openedAt    time.Time
+	lastUsed    time.Time
+	requests    uint
+	failures    uint
+	consecutive uint
+	// the requests in half-open state
+	trials    uint
+	successes uint
+}
+
+// NewBreaker creates the closed breaker of key
+func NewBreaker(key string, settings *Settings) *Breaker {
+	now := time.Now()
+	return &Breaker{settings: settings, key: key, windowStart: now, lastUsed: now}
+}
+
+// stateChange is passed to OnStateChange after the lock is released,
+// so the callback won't block the other requests or deadlock if it calls the breaker
+type stateChange struct {
+	from, to State
+}
+
+// State returns the state of breaker, the open breaker is half-open after the cooldown
+func (b *Breaker) State() State {
+	b.mutex.Lock()
+	var change *stateChange
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.settings.Cooldown {
+		change = b.setState(StateHalfOpen, time.Now())
+	}
+	state := b.state
+	b.mutex.Unlock()
+	b.notify(change)
+	return state
+}
+
+// Allow checks whether the request is allowed, if not, it returns how long the breaker keeps open.
+// Done must be called after the allowed request finishes
+func (b *Breaker) Allow() (bool, time.Duration) {
+	b.mutex.Lock()
+	now := time.Now()
+	b.lastUsed = now
+	var change *stateChange
+	allowed, retryAfter := true, time.Duration(0)
+	switch b.state {
+	case StateOpen:
+		if elapsed := now.Sub(b.openedAt); elapsed < b.settings.Cooldown {
+			allowed, retryAfter = false, b.settings.Cooldown-elapsed
+			break
+		}
+		change = b.setState(StateHalfOpen, now)
+		fallthrough
+	case StateHalfOpen:
+		if b.trials >= b.settings.HalfOpenRequests {
+			// wait for the trial requests in flight
+			allowed = false
+			break
+		}
+		b.trials++
+	default:
+		if now.Sub(b.windowStart) >= b.settings.Window {
+			b.resetCounts(now)
+		}
+	}
+	b.mutex.Unlock()
+	b.notify(change)
+	return allowed, retryAfter
+}
+
+// Done records the result of request, the requests allowed before the breaker was open are ignored
+func (b *Breaker) Done(success bool) {
+	b.mutex.Lock()
+	now := time.Now()
+	var change *stateChange
+	switch b.state {
+	case StateClosed:
+		b.requests++
+		if success {
+			b.consecutive = 0
+			break
+		}
+		b.failures++
+		b.consecutive++
+		s := b.settings
+		if (s.ConsecutiveFailures > 0 && b.consecutive >= s.ConsecutiveFailures) ||
+			(s.FailureRatio > 0 && b.requests >= s.MinRequests &&
+				float64(b.failures) >= s.FailureRatio*float64(b.requests)) {
+			change = b.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if !success {
+			change = b.setState(StateOpen, now)
+			break
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenRequests {
+			change = b.setState(StateClosed, now)
+		}
+	}
+	b.mutex.Unlock()
+	b.notify(change)
+}
+
+// idle reports whether the breaker is closed and hasn't been used in the window, so it can be evicted
+func (b *Breaker) idle(now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state == StateClosed && now.Sub(b.lastUsed) >= b.settings.Window
+}
+
+func (b *Breaker) setState(state State, now time.Time) *stateChange {
+	from := b.state
+	b.state = state
+	b.trials, b.successes = 0, 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.resetCounts(now)
+	}
+	return &stateChange{from: from, to: state}
+}
+
+func (b *Breaker) resetCounts(now time.Time) {
+	b.windowStart = now
+	b.requests, b.failures, b.consecutive = 0, 0, 0
+}
+
+func (b *Breaker) notify(change *stateChange) {
+	if change != nil && b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(b.key, change.from, change.to)
+	}
+}
+
+// Group keeps a breaker for each key, at most maxSize breakers are kept.
+// The closed breakers which haven't been used in the window are evicted first if there are too many keys,
+// otherwise the least recently used one is evicted.
+type Group struct {
+	settings *Settings
+	maxSize  int
+
+	lock     sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup creates Group, maxSize <= 0 means no limit
+func NewGroup(settings *Settings, maxSize int) *Group {
+	return &Group{settings: settings, maxSize: maxSize, breakers: make(map[string]*Breaker)}
+}
+
+// Get returns the breaker of key, it's created if it doesn't exist
+func (g *Group) Get(key string) *Breaker {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	b, ok := g.breakers[key]
+	if !ok {
+		if g.maxSize > 0 && len(g.breakers) >= g.maxSize {
+			g.evict()
+		}
+		b = NewBreaker(key, g.settings)
+		g.breakers[key] = b
+	}
+	return b
+}
+
+// Lookup returns the breaker of key if it exists
+func (g *Group) Lookup(key string) (*Breaker, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	b, ok := g.breakers[key]
+	return b, ok
+}
+
+// Len returns the number of the breakers
+func (g *Group) Len() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return len(g.breakers)
+}
+
+func (g *Group) evict() {
+	now := time.Now()
+	var lruKey string
+	var lruTime time.Time
+	found := false
+	for key, b := range g.breakers {
+		if b.idle(now) {
+			delete(g.breakers, key)
+			continue
+		}
+		b.mutex.Lock()
+		lastUsed := b.lastUsed
+		b.mutex.Unlock()
+		if !found || lastUsed.Before(lruTime) {
+			lruKey, lruTime, found = key, lastUsed, true
+		}
+	}
+	if found && len(g.breakers) >= g.maxSize {
+		delete(g.breakers, lruKey)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	var states []State
+	b := NewBreaker("a", &Settings{
+		ConsecutiveFailures: 2,
+		Window:              time.Minute,
+		Cooldown:            20 * time.Millisecond,
+		HalfOpenRequests:    1,
+		OnStateChange: func(key string, from, to State) {
+			assert.Equal(t, "a", key)
+			states = append(states, to)
+		},
+	})
+	for i := 0; i < 2; i++ {
+		ok, _ := b.Allow()
+		assert.True(t, ok)
+		b.Done(false)
+	}
+	ok, retryAfter := b.Allow()
+	assert.False(t, ok)
+	assert.True(t, retryAfter > 0)
+
+	time.Sleep(30 * time.Millisecond)
+	ok, _ = b.Allow()
+	assert.True(t, ok)
+	// the second trial waits for the first one
+	ok, _ = b.Allow()
+	assert.False(t, ok)
+	b.Done(true)
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, []State{StateOpen, StateHalfOpen, StateClosed}, states)
+}
+
+func TestBreakerFailureRatio(t *testing.T) {
+	b := NewBreaker("", &Settings{FailureRatio: 0.5, MinRequests: 4, Window: time.Minute, Cooldown: time.Minute})
+	for _, success := range []bool{false, true, true} {
+		b.Allow()
+		b.Done(success)
+	}
+	assert.Equal(t, StateClosed, b.State())
+	b.Allow()
+	b.Done(false)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestGroupEvict(t *testing.T) {
+	g := NewGroup(&Settings{ConsecutiveFailures: 1, Window: time.Minute, Cooldown: time.Minute}, 2)
+	a := g.Get("a")
+	a.Allow()
+	a.Done(false)
+	g.Get("b").Allow()
+	g.Get("c")
+	assert.Equal(t, 2, g.Len())
+	// the least recently used one is evicted
+	_, ok := g.Lookup("a")
+	assert.False(t, ok)
+	_, ok = g.Lookup("c")
+	assert.True(t, ok)
+
+	// the idle closed ones are evicted first
+	g = NewGroup(&Settings{Window: 10 * time.Millisecond}, 2)
+	g.Get("a")
+	g.Get("b")
+	time.Sleep(20 * time.Millisecond)
+	g.Get("c")
+	assert.Equal(t, 1, g.Len())
+}
@@ -42,6 +42,7 @@ type AccessLogRecord struct {
 	HTTPReferrer   string        `json:"http_referrer"`
 	HTTPUserAgent  string        `json:"http_user_agent"`
 	RemoteUser     string        `json:"remote_user"`
+	RequestID      string        `json:"request_id,omitempty"`
 }
 
 func (r *AccessLogRecord) json() ([]byte, error) {
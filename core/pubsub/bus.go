@@ -0,0 +1,73 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pubsub broadcasts the messages between the instances,
+// such as the invalidations of cache.TieredCache and the sessions.
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// Bus broadcasts the messages of type T between the instances
+type Bus[T any] interface {
+	// Publish sends msg to all the subscribers
+	Publish(ctx context.Context, msg T) error
+	// Subscribe calls handler for every message until ctx is done,
+	// it returns after the subscription is established
+	Subscribe(ctx context.Context, handler func(ctx context.Context, msg T)) error
+}
+
+// NewMemoryBus returns the Bus in memory, it only works in the same process
+func NewMemoryBus[T any]() Bus[T] {
+	return &memoryBus[T]{}
+}
+
+type memoryBus[T any] struct {
+	lock     sync.RWMutex
+	next     int
+	handlers map[int]func(ctx context.Context, msg T)
+}
+
+func (m *memoryBus[T]) Publish(ctx context.Context, msg T) error {
+	m.lock.RLock()
+	handlers := make([]func(ctx context.Context, msg T), 0, len(m.handlers))
+	for _, h := range m.handlers {
+		handlers = append(handlers, h)
+	}
+	m.lock.RUnlock()
+	for _, h := range handlers {
+		h(ctx, msg)
+	}
+	return nil
+}
+
+func (m *memoryBus[T]) Subscribe(ctx context.Context, handler func(ctx context.Context, msg T)) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.handlers == nil {
+		m.handlers = make(map[int]func(ctx context.Context, msg T))
+	}
+	id := m.next
+	m.next++
+	m.handlers[id] = handler
+	go func() {
+		<-ctx.Done()
+		m.lock.Lock()
+		delete(m.handlers, id)
+		m.lock.Unlock()
+	}()
+	return nil
+}
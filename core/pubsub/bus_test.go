@@ -0,0 +1,46 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBus(t *testing.T) {
+	bus := NewMemoryBus[string]()
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 2)
+	assert.Nil(t, bus.Subscribe(ctx, func(ctx context.Context, msg string) {
+		received <- msg
+	}))
+	assert.Nil(t, bus.Publish(context.Background(), "hello"))
+	assert.Equal(t, "hello", <-received)
+
+	// the handler is removed when ctx is done
+	cancel()
+	assert.Eventually(t, func() bool {
+		_ = bus.Publish(context.Background(), "bye")
+		select {
+		case <-received:
+			return false
+		default:
+			return true
+		}
+	}, time.Second, 10*time.Millisecond)
+}
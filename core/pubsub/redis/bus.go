@@ -0,0 +1,77 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redis implements pubsub.Bus by redis pub/sub
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// Bus broadcasts the messages encoded by JSON through a redis channel,
+// the client can be a redis.Client, redis.ClusterClient or the failover client of sentinel
+type Bus[T any] struct {
+	client  goredis.UniversalClient
+	channel string
+}
+
+// NewBus returns the Bus publishing to channel
+func NewBus[T any](client goredis.UniversalClient, channel string) *Bus[T] {
+	return &Bus[T]{client: client, channel: channel}
+}
+
+// Publish sends msg to the channel
+func (b *Bus[T]) Publish(ctx context.Context, msg T) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+// Subscribe subscribes the channel and calls handler in a goroutine until ctx is done
+func (b *Bus[T]) Subscribe(ctx context.Context, handler func(ctx context.Context, msg T)) error {
+	sub := b.client.Subscribe(ctx, b.channel)
+	// wait for the confirmation, so the messages published after Subscribe returns aren't missed
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return err
+	}
+	ch := sub.Channel()
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-ch:
+				if !ok {
+					return
+				}
+				var msg T
+				if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+					logs.Warn("invalid message of channel %s %q: %v", b.channel, m.Payload, err)
+					continue
+				}
+				handler(ctx, msg)
+			}
+		}
+	}()
+	return nil
+}
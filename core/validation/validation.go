@@ -171,6 +171,18 @@ func (v *Validation) MaxSize(obj interface{}, max int, key string) *Result {
 	return v.apply(MaxSize{max, key}, obj)
 }
 
+// MaxFileSize Test that the uploaded files are not larger than max bytes,
+// obj can be *multipart.FileHeader or []*multipart.FileHeader
+func (v *Validation) MaxFileSize(obj interface{}, max int, key string) *Result {
+	return v.apply(MaxFileSize{max, key}, obj)
+}
+
+// FileType Test that the media type of uploaded files is one of types separated by |, such as image/*|application/pdf,
+// obj can be *multipart.FileHeader or []*multipart.FileHeader
+func (v *Validation) FileType(obj interface{}, types string, key string) *Result {
+	return v.apply(FileType{types, key}, obj)
+}
+
 // Length Test that the obj is same length to n if type is string or slice
 func (v *Validation) Length(obj interface{}, n int, key string) *Result {
 	return v.apply(Length{n, key}, obj)
@@ -15,6 +15,8 @@
 package validation
 
 import (
+	"mime/multipart"
+	"net/textproto"
 	"regexp"
 	"testing"
 	"time"
@@ -631,3 +633,34 @@ func TestFieldNoEmpty(t *testing.T) {
 		t.Fatal("validation should be passed")
 	}
 }
+
+func TestFileValidators(t *testing.T) {
+	valid := Validation{}
+	small := &multipart.FileHeader{Filename: "a.txt", Size: 10,
+		Header: textproto.MIMEHeader{"Content-Type": {"text/csv"}}}
+	large := &multipart.FileHeader{Filename: "b.txt", Size: 100}
+
+	if !valid.MaxFileSize((*multipart.FileHeader)(nil), 50, "nil").Ok {
+		t.Error("absent file should be true")
+	}
+	if !valid.MaxFileSize(small, 50, "small").Ok {
+		t.Error("small file should be true")
+	}
+	if valid.MaxFileSize(large, 50, "large").Ok {
+		t.Error("large file should be false")
+	}
+	if valid.MaxFileSize([]*multipart.FileHeader{small, large}, 50, "files").Ok {
+		t.Error("files containing large file should be false")
+	}
+	if valid.MaxFileSize("a.txt", 50, "string").Ok {
+		t.Error("string should be false")
+	}
+
+	// the content can't be opened, so the declared type is used
+	if !valid.FileType(small, "text/*", "type").Ok {
+		t.Error("text/csv should match text/*")
+	}
+	if valid.FileType(small, "image/png|image/gif", "type").Ok {
+		t.Error("text/csv should not match image/png|image/gif")
+	}
+}
@@ -16,6 +16,9 @@ package validation
 
 import (
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
 	"reflect"
 	"regexp"
 	"strings"
@@ -58,6 +61,8 @@ var MessageTmpls = map[string]string{
 	"Tel":          "Must be valid telephone number",
 	"Phone":        "Must be valid telephone or mobile phone number",
 	"ZipCode":      "Must be valid zipcode",
+	"MaxFileSize":  "Maximum file size is %d bytes",
+	"FileType":     "File type must be %s",
 }
 
 var once sync.Once
@@ -85,6 +90,8 @@ var once sync.Once
 //	"Tel":          "Must be valid telephone number",
 //	"Phone":        "Must be valid telephone or mobile phone number",
 //	"ZipCode":      "Must be valid zipcode",
+//	"MaxFileSize":  "Maximum file size is %d bytes",
+//	"FileType":     "File type must be %s",
 func SetDefaultMessage(msg map[string]string) {
 	if len(msg) == 0 {
 		return
@@ -738,3 +745,105 @@ func (z ZipCode) GetKey() string {
 func (z ZipCode) GetLimitValue() interface{} {
 	return nil
 }
+
+// MaxFileSize Requires the uploaded files to be at most a given size in bytes,
+// obj can be *multipart.FileHeader or []*multipart.FileHeader
+type MaxFileSize struct {
+	Max int
+	Key string
+}
+
+// IsSatisfied judge whether obj is valid, the absent file is valid
+func (m MaxFileSize) IsSatisfied(obj interface{}) bool {
+	return eachFile(obj, func(fh *multipart.FileHeader) bool {
+		return fh.Size <= int64(m.Max)
+	})
+}
+
+// DefaultMessage return the default MaxFileSize error message
+func (m MaxFileSize) DefaultMessage() string {
+	return fmt.Sprintf(MessageTmpls["MaxFileSize"], m.Max)
+}
+
+// GetKey return the m.Key
+func (m MaxFileSize) GetKey() string {
+	return m.Key
+}
+
+// GetLimitValue return the limit value
+func (m MaxFileSize) GetLimitValue() interface{} {
+	return m.Max
+}
+
+// FileType Requires the media type of uploaded files to be one of the types separated by |,
+// such as image/png|image/*. The type is sniffed from the content,
+// and the Content-Type of the part is used only if the content is unknown or text.
+// obj can be *multipart.FileHeader or []*multipart.FileHeader
+type FileType struct {
+	Types string
+	Key   string
+}
+
+// IsSatisfied judge whether obj is valid, the absent file is valid
+func (f FileType) IsSatisfied(obj interface{}) bool {
+	return eachFile(obj, func(fh *multipart.FileHeader) bool {
+		mediaType := sniffFileType(fh)
+		for _, t := range strings.Split(f.Types, "|") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t == mediaType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1])) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// DefaultMessage return the default FileType error message
+func (f FileType) DefaultMessage() string {
+	return fmt.Sprintf(MessageTmpls["FileType"], f.Types)
+}
+
+// GetKey return the f.Key
+func (f FileType) GetKey() string {
+	return f.Key
+}
+
+// GetLimitValue return the limit value
+func (f FileType) GetLimitValue() interface{} {
+	return f.Types
+}
+
+// eachFile checks the files, the pointer of file has been dereferenced by Validation
+func eachFile(obj interface{}, check func(fh *multipart.FileHeader) bool) bool {
+	switch v := obj.(type) {
+	case nil:
+		return true
+	case multipart.FileHeader:
+		return check(&v)
+	case []*multipart.FileHeader:
+		for _, fh := range v {
+			if fh != nil && !check(fh) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func sniffFileType(fh *multipart.FileHeader) string {
+	declared, _, _ := mime.ParseMediaType(fh.Header.Get("Content-Type"))
+	f, err := fh.Open()
+	if err != nil {
+		return declared
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	// the sniffer can't tell the types of text, such as text/csv
+	if sniffed == "application/octet-stream" || (sniffed == "text/plain" && strings.HasPrefix(declared, "text/")) {
+		return declared
+	}
+	return sniffed
+}
@@ -108,6 +108,7 @@ func registerAdmin() error {
 		beeAdminApp = &adminApp{
 			HttpServer: NewHttpServerWithCfg(&adminCfg),
 		}
+		beeAdminApp.systemdName = SystemdListenerAdmin
 		// keep in mind that all data should be html escaped to avoid XSS attack
 		beeAdminApp.Router("/", c, "get:AdminIndex")
 		beeAdminApp.Router("/qps", c, "get:QpsIndex")
@@ -116,6 +117,8 @@ func registerAdmin() error {
 		beeAdminApp.Router("/task", c, "get:TaskStatus")
 		beeAdminApp.Router("/listconf", c, "get:ListConf")
 		beeAdminApp.Router("/metrics", c, "get:PrometheusMetrics")
+		beeAdminApp.Router("/session", c, "get:SessionStats;post:SessionGC")
+		beeAdminApp.Router("/cache", c, "get:CacheStats")
 
 		go beeAdminApp.Run()
 	}
@@ -22,11 +22,19 @@ import (
 	"strconv"
 	"text/template"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/beego/beego/v2/client/cache"
 	"github.com/beego/beego/v2/core/admin"
+	"github.com/beego/beego/v2/server/web/session"
 )
 
+// MetricsRegistry is the registry of metrics which are exposed on "/metrics" of admin server
+// with the metrics registered to prometheus.DefaultRegisterer.
+// Applications are able to register their custom collectors to it.
+var MetricsRegistry = prometheus.NewRegistry()
+
 type adminController struct {
 	Controller
 	servers []*HttpServer
@@ -73,7 +81,53 @@ func (a *adminController) ProfIndex() {
 }
 
 func (a *adminController) PrometheusMetrics() {
-	promhttp.Handler().ServeHTTP(a.Ctx.ResponseWriter, a.Ctx.Request)
+	promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, MetricsRegistry}, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}).ServeHTTP(a.Ctx.ResponseWriter, a.Ctx.Request)
+}
+
+// SessionStats is the http.Handler writing the statistics of the session GC as JSON.
+// it's in "/session" pattern in admin module.
+func (a *adminController) SessionStats() {
+	if GlobalSessions == nil {
+		http.Error(a.Ctx.ResponseWriter, "session is disabled", http.StatusNotFound)
+		return
+	}
+	writeSessionStats(a.Ctx.ResponseWriter, GlobalSessions.GCStats())
+}
+
+// SessionGC is the http.Handler running the session GC right now, it writes the statistics after the sweep.
+// it's in "/session" pattern with POST method in admin module.
+func (a *adminController) SessionGC() {
+	if GlobalSessions == nil {
+		http.Error(a.Ctx.ResponseWriter, "session is disabled", http.StatusNotFound)
+		return
+	}
+	writeSessionStats(a.Ctx.ResponseWriter, GlobalSessions.RunGC(a.Ctx.Request.Context()))
+}
+
+func writeSessionStats(rw http.ResponseWriter, stats session.GCStats) {
+	dataJSON, err := json.Marshal(stats)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(rw, dataJSON)
+}
+
+// CacheStats is the http.Handler writing the metrics of the caches created by cache.NewMetricsCache as JSON.
+// it's in "/cache" pattern in admin module.
+func (a *adminController) CacheStats() {
+	writeCacheStats(a.Ctx.ResponseWriter, cache.AllMetrics())
+}
+
+func writeCacheStats(rw http.ResponseWriter, metrics []cache.Metrics) {
+	dataJSON, err := json.Marshal(metrics)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(rw, dataJSON)
 }
 
 // TaskStatus is a http.Handler with running task status (task name, status and the last execution).
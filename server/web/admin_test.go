@@ -1,6 +1,7 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,7 +12,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/beego/beego/v2/client/cache"
 	"github.com/beego/beego/v2/core/admin"
+	"github.com/beego/beego/v2/server/web/session"
 )
 
 type SampleDatabaseCheck struct{}
@@ -236,3 +239,52 @@ func TestHealthCheckHandlerReturnsJSON(t *testing.T) {
 	assert.Equal(t, expectedResponseBody[0], database)
 	assert.Equal(t, expectedResponseBody[1], cache)
 }
+
+func TestSessionStats(t *testing.T) {
+	manager, err := session.NewManager("memory", session.NewManagerConfig(session.CfgGcLifeTime(3600)))
+	assert.Nil(t, err)
+	old := GlobalSessions
+	GlobalSessions = manager
+	defer func() { GlobalSessions = old }()
+
+	stats := manager.RunGC(context.Background())
+	w := httptest.NewRecorder()
+	writeSessionStats(w, stats)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var decoded session.GCStats
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &decoded))
+	assert.Equal(t, int64(1), decoded.Runs)
+
+	families, err := MetricsRegistry.Gather()
+	assert.Nil(t, err)
+	names := make(map[string]bool)
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	assert.True(t, names["beego_session_active"])
+	assert.True(t, names["beego_session_gc_runs_total"])
+	assert.True(t, names["beego_session_gc_last_duration_seconds"])
+}
+
+func TestCacheStats(t *testing.T) {
+	c := cache.NewMetricsCache("admin_test", cache.NewMemoryCache())
+	defer c.Unregister()
+	_, _ = c.Get(context.Background(), "missing")
+
+	w := httptest.NewRecorder()
+	writeCacheStats(w, cache.AllMetrics())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var decoded []cache.Metrics
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &decoded))
+	assert.Equal(t, 1, len(decoded))
+	assert.Equal(t, uint64(1), decoded[0].Misses)
+
+	families, err := MetricsRegistry.Gather()
+	assert.Nil(t, err)
+	names := make(map[string]bool)
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	assert.True(t, names["beego_cache_misses_total"])
+	assert.True(t, names["beego_cache_operation_duration_seconds"])
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/beego/beego/v2/client/cache"
+)
+
+// SetAutocertCache sets the cache of certificates when AutoTLS is true,
+// the certificates are stored in TLSCacheDir by default.
+// The shared cache, such as Redis by NewAutocertCache, is necessary if the servers run on multiple hosts
+func (app *HttpServer) SetAutocertCache(c autocert.Cache) *HttpServer {
+	app.autocertCache = c
+	return app
+}
+
+// autocertManager returns the ACME manager which obtains the certificates for Domains,
+// it supports both HTTP-01 and TLS-ALPN-01 challenges
+func (app *HttpServer) autocertManager() *autocert.Manager {
+	app.autocertOnce.Do(func() {
+		c := app.autocertCache
+		if c == nil {
+			c = autocert.DirCache(app.Cfg.Listen.TLSCacheDir)
+		}
+		app.autocert = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(app.Cfg.Listen.Domains...),
+			Cache:      c,
+			Email:      app.Cfg.Listen.AutoTLSEmail,
+		}
+		if app.Cfg.Listen.AutoTLSDirectoryURL != "" {
+			app.autocert.Client = &acme.Client{DirectoryURL: app.Cfg.Listen.AutoTLSDirectoryURL}
+		}
+	})
+	return app.autocert
+}
+
+// NewAutocertCache stores the certificates of AutoTLS in the cache of client/cache
+// usage:
+//
+//	bm, _ := cache.NewCache("redis", `{"conn":"127.0.0.1:6379"}`)
+//	web.BeeApp.SetAutocertCache(web.NewAutocertCache(bm))
+func NewAutocertCache(c cache.Cache) autocert.Cache {
+	return &autocertCache{cache: c}
+}
+
+type autocertCache struct {
+	cache cache.Cache
+}
+
+const (
+	autocertCacheKeyPrefix = "autocert:"
+	// the certificates are renewed by autocert before they expire, and the account key is kept longer
+	autocertCacheTTL = 365 * 24 * time.Hour
+)
+
+func (a *autocertCache) Get(ctx context.Context, name string) ([]byte, error) {
+	v, err := a.cache.Get(ctx, autocertCacheKeyPrefix+name)
+	if errors.Is(err, cache.ErrKeyNotExist) || (err == nil && v == nil) {
+		return nil, autocert.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch data := v.(type) {
+	case []byte:
+		return data, nil
+	case string:
+		return []byte(data), nil
+	default:
+		return nil, fmt.Errorf("the autocert cache %s has unexpected type %T", name, v)
+	}
+}
+
+func (a *autocertCache) Put(ctx context.Context, name string, data []byte) error {
+	return a.cache.Put(ctx, autocertCacheKeyPrefix+name, data, autocertCacheTTL)
+}
+
+func (a *autocertCache) Delete(ctx context.Context, name string) error {
+	err := a.cache.Delete(ctx, autocertCacheKeyPrefix+name)
+	if errors.Is(err, cache.ErrKeyNotExist) {
+		return nil
+	}
+	return err
+}
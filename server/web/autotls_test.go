@@ -0,0 +1,64 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/beego/beego/v2/client/cache"
+)
+
+func TestAutocertCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewAutocertCache(cache.NewMemoryCache())
+
+	_, err := c.Get(ctx, "example.com")
+	assert.Equal(t, autocert.ErrCacheMiss, err)
+
+	assert.Nil(t, c.Put(ctx, "example.com", []byte("cert")))
+	data, err := c.Get(ctx, "example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("cert"), data)
+
+	assert.Nil(t, c.Delete(ctx, "example.com"))
+	assert.Nil(t, c.Delete(ctx, "example.com"))
+	_, err = c.Get(ctx, "example.com")
+	assert.Equal(t, autocert.ErrCacheMiss, err)
+}
+
+func TestAutocertManager(t *testing.T) {
+	cfg := *BConfig
+	cfg.Listen.Domains = []string{"example.com"}
+	cfg.Listen.AutoTLSEmail = "admin@example.com"
+	cfg.Listen.AutoTLSDirectoryURL = "https://acme-staging-v02.api.letsencrypt.org/directory"
+	app := NewHttpServerWithCfg(&cfg)
+	c := NewAutocertCache(cache.NewMemoryCache())
+	app.SetAutocertCache(c)
+
+	m := app.autocertManager()
+	assert.Same(t, m, app.autocertManager())
+	assert.Equal(t, c, m.Cache)
+	assert.Equal(t, "admin@example.com", m.Email)
+	assert.Equal(t, cfg.Listen.AutoTLSDirectoryURL, m.Client.DirectoryURL)
+	assert.Nil(t, m.HostPolicy(context.Background(), "example.com"))
+	assert.NotNil(t, m.HostPolicy(context.Background(), "evil.com"))
+	// TLS-ALPN-01 challenge
+	assert.Contains(t, m.TLSConfig().NextProtos, acme.ALPNProto)
+}
@@ -73,6 +73,10 @@ func initBeforeHTTPRun() {
 			registerTemplate,
 			registerAdmin,
 			registerGzip,
+			registerClientIPResolver,
+			registerOpenAPI,
+			registerSecurityHeaders,
+			registerRoutesDebug,
 			// registerCommentRouter,
 		)
 
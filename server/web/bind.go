@@ -0,0 +1,74 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// Bind binds the path parameters, query, header, form and body of request to a new T
+// and validates it, see context.Context.Bind. T must be a struct.
+// If the request is invalid, the error is HTTPError with 422 status wrapping *context.BindError,
+// which is rendered as problem details with the errors of fields by RenderError.
+// usage:
+//
+//	app.Post("/users", func(ctx *context.Context) {
+//		req, err := web.Bind[CreateUserRequest](ctx)
+//		if err != nil {
+//			web.RenderError(ctx, err)
+//			return
+//		}
+//		...
+//	})
+func Bind[T any](ctx *context.Context) (T, error) {
+	var obj T
+	copyRequestBody(ctx)
+	return obj, unprocessable(ctx.BindAndValidate(&obj))
+}
+
+// BindJSON decodes the JSON body to a new T and validates it, T must be a struct,
+// the error is the same as Bind
+func BindJSON[T any](ctx *context.Context) (T, error) {
+	var obj T
+	copyRequestBody(ctx)
+	if len(ctx.Input.RequestBody) == 0 {
+		return obj, unprocessable(&context.BindError{Message: "the request body is empty"})
+	}
+	if err := json.Unmarshal(ctx.Input.RequestBody, &obj); err != nil {
+		return obj, unprocessable(&context.BindError{Message: err.Error()})
+	}
+	return obj, unprocessable(ctx.Validate(&obj))
+}
+
+// copyRequestBody reads the body if it's not copied by router, see Config.CopyRequestBody
+func copyRequestBody(ctx *context.Context) {
+	if len(ctx.Input.RequestBody) == 0 && ctx.Request.Body != nil && ctx.Request.Body != http.NoBody &&
+		!ctx.Input.IsUpload() {
+		ctx.Input.CopyBody(BConfig.MaxMemory)
+	}
+}
+
+// unprocessable wraps the *context.BindError with 422 HTTPError, the other errors are returned as they are
+func unprocessable(err error) error {
+	var bindErr *context.BindError
+	if errors.As(err, &bindErr) {
+		return NewHTTPError(http.StatusUnprocessableEntity, nil, "").Wrap(bindErr)
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+type createUserRequest struct {
+	ID   int    `json:"-" path:"id"`
+	Name string `json:"name" valid:"Required;MaxSize(8)"`
+	Age  int    `json:"age" valid:"Range(1, 150)"`
+}
+
+func TestBindHelpers(t *testing.T) {
+	cfg := *BConfig
+	app := NewHttpServerWithCfg(&cfg)
+	app.Post("/json/:id", func(ctx *context.Context) {
+		req, err := BindJSON[createUserRequest](ctx)
+		if err != nil {
+			RenderError(ctx, err)
+			return
+		}
+		_ = ctx.Output.JSON(req, false, false)
+	})
+	app.Post("/bind/:id", func(ctx *context.Context) {
+		req, err := Bind[createUserRequest](ctx)
+		if err != nil {
+			RenderError(ctx, err)
+			return
+		}
+		_ = ctx.Output.Body([]byte(strings.Repeat("x", req.ID) + req.Name))
+	})
+
+	testCases := []struct {
+		name   string
+		url    string
+		body   string
+		status int
+		errors int
+	}{
+		{name: "json ok", url: "/json/1", body: `{"name":"beego","age":10}`, status: http.StatusOK},
+		{name: "json invalid", url: "/json/1", body: `{"name":"beego framework","age":0}`,
+			status: http.StatusUnprocessableEntity, errors: 2},
+		{name: "json malformed", url: "/json/1", body: `{"name":`, status: http.StatusUnprocessableEntity},
+		{name: "json empty", url: "/json/1", status: http.StatusUnprocessableEntity},
+		{name: "bind ok", url: "/bind/2", body: `{"name":"beego","age":10}`, status: http.StatusOK},
+		{name: "bind invalid", url: "/bind/2", body: `{"age":10}`, status: http.StatusUnprocessableEntity, errors: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, tc.url, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", context.ApplicationJSON)
+			app.Handlers.ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusOK {
+				return
+			}
+			assert.Equal(t, ProblemJSON, w.Header().Get("Content-Type"))
+			pd := &ProblemDetails{}
+			require.Nil(t, json.Unmarshal(w.Body.Bytes(), pd))
+			assert.Equal(t, http.StatusUnprocessableEntity, pd.Status)
+			assert.NotEmpty(t, pd.Detail)
+			assert.Len(t, pd.Errors, tc.errors)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/bind/2", strings.NewReader(`{"name":"beego","age":10}`))
+	r.Header.Set("Content-Type", context.ApplicationJSON)
+	app.Handlers.ServeHTTP(w, r)
+	assert.Equal(t, "xxbeego", w.Body.String())
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"expvar"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/beego/beego/v2/client/cache"
+)
+
+// cacheCollector exports the metrics of the caches created by cache.NewMetricsCache, labeled by the cache name
+type cacheCollector struct {
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	hitRatio  *prometheus.Desc
+	errors    *prometheus.Desc
+	evictions *prometheus.Desc
+	latency   *prometheus.Desc
+}
+
+func newCacheCollector() *cacheCollector {
+	labels := []string{"cache"}
+	return &cacheCollector{
+		hits: prometheus.NewDesc("beego_cache_hits_total",
+			"The number of keys found by Get and GetMulti", labels, nil),
+		misses: prometheus.NewDesc("beego_cache_misses_total",
+			"The number of keys not found by Get and GetMulti", labels, nil),
+		hitRatio: prometheus.NewDesc("beego_cache_hit_ratio",
+			"The ratio of hits to the keys read", labels, nil),
+		errors: prometheus.NewDesc("beego_cache_errors_total",
+			"The number of failed cache operations", labels, nil),
+		evictions: prometheus.NewDesc("beego_cache_evictions_total",
+			"The number of items evicted because the cache is full, 0 if the adapter doesn't report it", labels, nil),
+		latency: prometheus.NewDesc("beego_cache_operation_duration_seconds",
+			"The latency of the cache operations", labels, nil),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.hitRatio
+	ch <- c.errors
+	ch <- c.evictions
+	ch <- c.latency
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range cache.AllMetrics() {
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(m.Hits), m.Name)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(m.Misses), m.Name)
+		ch <- prometheus.MustNewConstMetric(c.hitRatio, prometheus.GaugeValue, m.HitRatio, m.Name)
+		ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(m.Errors), m.Name)
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(m.Evictions), m.Name)
+		ch <- prometheus.MustNewConstHistogram(c.latency, m.Operations, m.LatencySum, m.LatencyBuckets, m.Name)
+	}
+}
+
+func init() {
+	MetricsRegistry.MustRegister(newCacheCollector())
+	expvar.Publish("beego_cache", expvar.Func(func() interface{} {
+		return cache.AllMetrics()
+	}))
+}
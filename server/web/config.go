@@ -58,6 +58,15 @@ type Config struct {
 	// 2. If this is false and the request URL is "/Hello", it will match this pattern
 	// @Default true
 	RouterCaseSensitive bool
+	// TrailingSlash
+	// @Description the policy of the request URL which ends with "/", such as "/hello/",
+	// when a router is registered with pattern "/hello"
+	// 1. rewrite: it matches this pattern
+	// 2. redirect: it's redirected to "/hello" with 301, and "redirect308" uses 308 to keep the method and body
+	// 3. strict: it doesn't match this pattern, and 404 is returned.
+	// The pattern ending with "/", such as the "/" router of namespace, only matches the URL ending with "/"
+	// @Default rewrite
+	TrailingSlash string
 	// RecoverPanic
 	// @Description if it was true, Beego will try to recover from panic when it serves your http request
 	// So you should notice that it doesn't mean that Beego will recover all panic cases.
@@ -72,10 +81,12 @@ type Config struct {
 	// @Default false
 	CopyRequestBody bool
 	// EnableGzip
-	// @Description If it was true, Beego will try to compress data by using zip algorithm.
+	// @Description If it was true, Beego will try to compress data by using br, zstd, gzip or deflate algorithm,
+	// which is negotiated from Accept-Encoding header.
 	// But there are two points:
-	// 1. Only static resources will be compressed
-	// 2. Only those static resource which has the extension specified by StaticExtensionsToGzip will be compressed
+	// 1. Only those static resource which has the extension specified by StaticExtensionsToGzip will be compressed
+	// 2. Only the responses with content types specified by compressContentTypes in app.conf will be compressed,
+	// if compressContentTypes is empty, all the responses will be compressed.
 	// @Default false
 	EnableGzip bool
 	// EnableErrorsShow
@@ -99,6 +110,18 @@ type Config struct {
 	// @Default
 	ServerName string
 
+	// TrustedProxies
+	// @Description the CIDRs or IPs of the trusted proxies, the client IP is resolved from ClientIPHeader
+	// only if the request comes from them, and the hops added by the trusted proxies are skipped.
+	// If it's empty, the leftmost X-Forwarded-For is used as the client IP, which can be forged by the client
+	// @Default []
+	TrustedProxies []string
+	// ClientIPHeader
+	// @Description the header carrying client IP, works with TrustedProxies.
+	// It can be Forwarded, X-Forwarded-For, X-Real-IP or CF-Connecting-IP
+	// @Default X-Forwarded-For
+	ClientIPHeader string
+
 	// RecoverFunc
 	// @Description when Beego want to recover from panic, it will use this func as callback
 	// see RecoverPanic
@@ -136,6 +159,19 @@ type Listen struct {
 	// @Description means use graceful module to start the server
 	// @Default false
 	Graceful bool
+	// EnableSystemdSocket
+	// @Description If it's true, Beego will serve on the listeners passed by systemd socket activation (LISTEN_FDS),
+	// the listeners are matched by FileDescriptorName of socket unit: http, https and admin,
+	// and the first unnamed listener is used as http listener.
+	// Beego listens on the addresses by itself if no listener is passed. It doesn't work in Graceful mode
+	// @Default false
+	EnableSystemdSocket bool
+	// GracefulRestartMode
+	// @Description the way that the new process takes over the listeners when restarting in Graceful mode,
+	// fork, reuseport or spawn. It's fork by default, and spawn on Windows
+	// see grace.RestartMode
+	// @Default ""
+	GracefulRestartMode string
 	// ListenTCP4
 	// @Description if it's true, means that Beego only work for TCP4
 	// please check net.Listen function
@@ -151,9 +187,20 @@ type Listen struct {
 	// AutoTLS
 	// @Description If it's true, Beego will use default value to initialize the TLS configure
 	// But those values could be override if you have custom value.
-	// see Domains, TLSCacheDir
+	// The certificates of Domains are obtained from Let's Encrypt by ACME, and they are renewed automatically.
+	// Both HTTP-01 challenge on HTTP port and TLS-ALPN-01 challenge on HTTPS port are supported
+	// see Domains, TLSCacheDir, AutoTLSEmail, AutoTLSDirectoryURL, HttpServer.SetAutocertCache
 	// @Default false
 	AutoTLS bool
+	// AutoTLSEmail
+	// @Description the contact email of ACME account, the CA notifies the problems of certificates by it
+	// @Default ""
+	AutoTLSEmail string
+	// AutoTLSDirectoryURL
+	// @Description the directory URL of ACME CA, Let's Encrypt production is used by default.
+	// Use https://acme-staging-v02.api.letsencrypt.org/directory when testing
+	// @Default ""
+	AutoTLSDirectoryURL string
 	// EnableHTTPS
 	// @Description If it's true, Beego will accept HTTPS request.
 	// Now, you'd better use HTTPS protocol on prod environment to get better security
@@ -166,6 +213,14 @@ type Listen struct {
 	// see Server.ListenAndServeMutualTLS
 	// @Default false
 	EnableMutualHTTPS bool
+	// EnableHTTP3
+	// @Description If it's true, Beego will also serve HTTP/3 on the UDP port of HTTPS.
+	// The certificates are shared with HTTPS listener,
+	// and the HTTPS responses will advertise HTTP/3 by Alt-Svc header.
+	// It only works when EnableHTTPS or EnableMutualHTTPS is true
+	// see EnableHTTPS, HTTPSPort
+	// @Default false
+	EnableHTTP3 bool
 	// EnableAdmin
 	// @Description if it's true, Beego will provide admin service.
 	// You can visit the admin service via browser.
@@ -187,6 +242,41 @@ type Listen struct {
 	// see http.Server.ReadTimeout, WriteTimeout
 	// @Default 0
 	ServerTimeOut int64
+	// EnableGracefulShutdown
+	// @Description If it's true, Beego will shut down the server gracefully when receiving SIGINT or SIGTERM,
+	// it flips the readiness, waits ShutdownDelay, stops accepting and waits for the in-flight requests
+	// up to ShutdownTimeout, and then runs the OnShutdown hooks.
+	// It doesn't work in Graceful mode which handles the signals by itself
+	// see ShutdownTimeout, ShutdownDelay, ReadinessPath
+	// @Default false
+	EnableGracefulShutdown bool
+	// ShutdownTimeout
+	// @Description the max duration of waiting for the in-flight requests when shutting down,
+	// the connections are closed after the deadline. The unit is second.
+	// @Default 60
+	ShutdownTimeout int64
+	// ShutdownDelay
+	// @Description the duration between flipping the readiness and stopping accepting,
+	// so that the load balancer, Kubernetes for example, removes the server before draining.
+	// The unit is second.
+	// @Default 0
+	ShutdownDelay int64
+	// ReadinessPath
+	// @Description If it's not empty, Beego serves the readiness probe at this path,
+	// it returns 200 when the server is ready and 503 when it's shutting down
+	// @Default ""
+	ReadinessPath string
+	// UnixSocket
+	// @Description If it's not empty, Beego listens on this unix socket path instead of HTTPAddr and HTTPPort,
+	// it's useful when Beego is behind nginx or envoy on the same host.
+	// The stale socket file is removed when starting, and the socket file is removed when the server is closed
+	// see UnixSocketPerm
+	// @Default ""
+	UnixSocket string
+	// UnixSocketPerm
+	// @Description the octal permission of unix socket file
+	// @Default 0660
+	UnixSocketPerm string
 	// HTTPAddr
 	// @Description Beego listen to this address when the application start up.
 	// @Default ""
@@ -239,7 +329,9 @@ type Listen struct {
 	// @Default 8088
 	AdminPort int
 	// @Description Beego use this tls.ClientAuthType to initialize TLS connection
-	// The default value is tls.RequireAndVerifyClientCert
+	// The default value is tls.RequireAndVerifyClientCert.
+	// Set it to tls.VerifyClientCertIfGiven(3) and use WithRouterClientCert
+	// if only some routers require the client certificate
 	// @Default 4
 	ClientAuth int
 }
@@ -261,6 +353,7 @@ type WebConfig struct {
 	// because it's not safe if using HTTP protocol
 	// And, the cookie storing XSRF token has two more flags HttpOnly and Secure
 	// It means that you must use HTTPS protocol and you can not read the token from JS script
+	// unless XSRFDoubleSubmit is true or XSRFHeader is set
 	// This is completed different from Beego 1.x because we got many security reports
 	// And if you are in dev environment, you could set it to false
 	// @Default false
@@ -338,10 +431,139 @@ type WebConfig struct {
 	// second
 	// @Default 0
 	XSRFExpire int
+	// XSRFSameSite
+	// @Description the SameSite attribute of XSRF token cookie
+	// see EnableXSRF
+	// @Default 2 (http.SameSiteLaxMode)
+	XSRFSameSite http.SameSite
+	// XSRFDoubleSubmit
+	// @Description If it's true, the XSRF token is stored in a cookie without HttpOnly flag,
+	// so the JavaScript can read it and submit it in the X-Xsrftoken header.
+	// The token is checked by comparing the header and the cookie, it doesn't need the server-side state.
+	// see EnableXSRF
+	// @Default false
+	XSRFDoubleSubmit bool
+	// XSRFHeader
+	// @Description If it's not empty, the XSRF token is sent to the client in this response header,
+	// it's useful for SPA clients. X-Xsrftoken for example
+	// see EnableXSRF
+	// @Default ""
+	XSRFHeader string
+	// EnableOpenAPI
+	// @Description If it's true, Beego will generate OpenAPI document from the routers,
+	// and serve it and Swagger UI at OpenAPIPath
+	// see OpenAPIPath
+	// @Default false
+	EnableOpenAPI bool
+	// OpenAPIPath
+	// @Description Beego serves Swagger UI at this path, and the document at OpenAPIPath/openapi.json
+	// see EnableOpenAPI
+	// @Default /swagger
+	OpenAPIPath string
+	// EnableRoutesDebug
+	// @Description If it's true and the run mode is dev, Beego prints the route table when starting,
+	// and serves the routers as JSON at RoutesDebugPath, it helps to find the shadowed or conflicting routers
+	// see RoutesDebugPath
+	// @Default false
+	EnableRoutesDebug bool
+	// RoutesDebugPath
+	// @Description Beego serves the routers as JSON at this path
+	// see EnableRoutesDebug
+	// @Default /debug/routes
+	RoutesDebugPath string
+	// I18nQueryName
+	// @Description the query parameter which selects the locale of request, it's saved to the cookie I18nCookieName
+	// see I18nCookieName, Controller.Lang
+	// @Default lang
+	I18nQueryName string
+	// I18nCookieName
+	// @Description the cookie which stores the locale of client,
+	// the Accept-Language header is used if neither the query parameter nor the cookie is set
+	// @Default lang
+	I18nCookieName string
+	// EnableStreamRender
+	// @Description If it's true, Render executes the templates against the response writer
+	// instead of buffering the whole page, the output is flushed every StreamRenderFlushSize bytes
+	// or StreamRenderFlushInterval milliseconds. The errors occurring after the response started
+	// are reported by the X-Template-Error trailer.
+	// The streamed pages bypass Output.Body, so EnableGzip and EnableETag don't apply to them
+	// see StreamRenderFlushSize, StreamRenderFlushInterval
+	// @Default false
+	EnableStreamRender bool
+	// StreamRenderFlushSize
+	// @Description the buffered bytes which trigger the flush in stream rendering
+	// @Default 4096
+	StreamRenderFlushSize int
+	// StreamRenderFlushInterval
+	// @Description the milliseconds after which the buffered output is flushed in stream rendering,
+	// 0 means flushing by size only
+	// @Default 100
+	StreamRenderFlushInterval int
+	// EnableMethodOverride
+	// @Description If it's true, the method of POST request is replaced by
+	// the X-HTTP-Method-Override header or the _method field of the urlencoded form before routing,
+	// so the HTML forms and the legacy proxies can send PUT, PATCH and DELETE requests
+	// @Default false
+	EnableMethodOverride bool
+	// EnableETag
+	// @Description If it's true, Beego will generate ETag for the responses of GET and HEAD requests,
+	// for example, the rendered templates and ServeJSON/ServeXML bodies.
+	// And 304 Not Modified will be returned if the request's If-None-Match matches it
+	// see WeakETag
+	// @Default false
+	EnableETag bool
+	// WeakETag
+	// @Description If it's true, the generated ETag will be weak, like W/"xxx"
+	// see EnableETag
+	// @Default false
+	WeakETag bool
+	// EnableSecurityHeaders
+	// @Description If it's true, Beego will add the security headers to the responses,
+	// such as HSTS, X-Content-Type-Options, X-Frame-Options and Content-Security-Policy
+	// see SecurityHeaders
+	// @Default false
+	EnableSecurityHeaders bool
+	// @Description security headers related config
+	SecurityHeaders SecurityHeadersConfig
 	// @Description session related config
 	Session SessionConfig
 }
 
+// SecurityHeadersConfig holds security headers related config
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge
+	// @Description the max-age of Strict-Transport-Security header in seconds,
+	// the header is only sent over HTTPS and 0 disables it
+	// @Default 31536000
+	HSTSMaxAge int
+	// HSTSIncludeSubdomains
+	// @Description add includeSubDomains to Strict-Transport-Security header
+	// @Default false
+	HSTSIncludeSubdomains bool
+	// HSTSPreload
+	// @Description add preload to Strict-Transport-Security header
+	// @Default false
+	HSTSPreload bool
+	// ContentTypeNosniff
+	// @Description send X-Content-Type-Options: nosniff
+	// @Default true
+	ContentTypeNosniff bool
+	// FrameOptions
+	// @Description the value of X-Frame-Options header, DENY or SAMEORIGIN, empty disables it
+	// @Default DENY
+	FrameOptions string
+	// ReferrerPolicy
+	// @Description the value of Referrer-Policy header, empty disables it
+	// @Default strict-origin-when-cross-origin
+	ReferrerPolicy string
+	// ContentSecurityPolicy
+	// @Description the value of Content-Security-Policy header, empty disables it.
+	// It can be built by NewCSP, and 'nonce' in it is replaced by a random nonce for each request,
+	// the templates can use the nonce by {{.CSPNonce}}
+	// @Default ""
+	ContentSecurityPolicy string
+}
+
 // SessionConfig holds session related config
 type SessionConfig struct {
 	// SessionOn
@@ -410,6 +632,42 @@ type SessionConfig struct {
 	// @Description session id's prefix
 	// @Default ""
 	SessionIDPrefix string
+
+	// SessionIdleTimeout
+	// @Description the session expires if it isn't accessed in this value seconds, 0 means no idle timeout
+	// @Default 0
+	SessionIdleTimeout int64
+	// SessionAbsoluteTimeout
+	// @Description the session expires after this value seconds since it's created, 0 means no absolute timeout
+	// @Default 0
+	SessionAbsoluteTimeout int64
+	// SessionRefreshMode
+	// @Description how often the session is refreshed when it's only read, always, interval or never.
+	// The session providers which don't support it refresh the session in every request
+	// @Default always
+	SessionRefreshMode string
+	// SessionRefreshInterval
+	// @Description the min interval between two refreshes if SessionRefreshMode is interval, unit: second
+	// @Default 0
+	SessionRefreshInterval int64
+	// SessionEncryptionKeys
+	// @Description the keys which encrypt the sessions stored by file and redis providers,
+	// the first key encrypts and all the keys decrypt. The sessions aren't encrypted if it's empty
+	// @Default []
+	SessionEncryptionKeys []string
+	// SessionSerializer
+	// @Description the serializer of the sessions stored by file and redis providers, gob, json or msgpack
+	// @Default gob
+	SessionSerializer string
+	// SessionGCBatchSize
+	// @Description the max number of sessions removed by one batch of the GC, 0 means no limit.
+	// It's supported by memory and file providers
+	// @Default 0
+	SessionGCBatchSize int
+	// SessionGCMaxDuration
+	// @Description the GC stops the sweep after this value milliseconds, 0 means no limit
+	// @Default 0
+	SessionGCMaxDuration int64
 }
 
 // LogConfig holds Log related config
@@ -487,6 +745,12 @@ func defaultRecoverPanic(ctx *context.Context, cfg *Config) {
 		if err == ErrAbort {
 			return
 		}
+		if e, ok := err.(error); ok {
+			if _, ok = toHTTPError(e); ok {
+				RenderError(ctx, e)
+				return
+			}
+		}
 		if !cfg.RecoverPanic {
 			panic(err)
 		}
@@ -525,8 +789,10 @@ func newBConfig() *Config {
 		AppName:             "beego",
 		RunMode:             PROD,
 		RouterCaseSensitive: true,
+		TrailingSlash:       TrailingSlashRewrite,
 		ServerName:          "beegoServer:" + beego.VERSION,
 		RecoverPanic:        true,
+		ClientIPHeader:      context.HeaderXForwardedFor,
 
 		CopyRequestBody:    false,
 		EnableGzip:         false,
@@ -535,44 +801,65 @@ func newBConfig() *Config {
 		EnableErrorsShow:   true,
 		EnableErrorsRender: true,
 		Listen: Listen{
-			Graceful:      false,
-			ServerTimeOut: 0,
-			ListenTCP4:    false,
-			EnableHTTP:    true,
-			AutoTLS:       false,
-			Domains:       []string{},
-			TLSCacheDir:   ".",
-			HTTPAddr:      "",
-			HTTPPort:      8080,
-			EnableHTTPS:   false,
-			HTTPSAddr:     "",
-			HTTPSPort:     10443,
-			HTTPSCertFile: "",
-			HTTPSKeyFile:  "",
-			EnableAdmin:   false,
-			AdminAddr:     "",
-			AdminPort:     8088,
-			EnableFcgi:    false,
-			EnableStdIo:   false,
-			ClientAuth:    int(tls.RequireAndVerifyClientCert),
+			Graceful:        false,
+			ServerTimeOut:   0,
+			UnixSocketPerm:  "0660",
+			ShutdownTimeout: 60,
+			ListenTCP4:      false,
+			EnableHTTP:      true,
+			AutoTLS:         false,
+			Domains:         []string{},
+			TLSCacheDir:     ".",
+			HTTPAddr:        "",
+			HTTPPort:        8080,
+			EnableHTTPS:     false,
+			EnableHTTP3:     false,
+			HTTPSAddr:       "",
+			HTTPSPort:       10443,
+			HTTPSCertFile:   "",
+			HTTPSKeyFile:    "",
+			EnableAdmin:     false,
+			AdminAddr:       "",
+			AdminPort:       8088,
+			EnableFcgi:      false,
+			EnableStdIo:     false,
+			ClientAuth:      int(tls.RequireAndVerifyClientCert),
 		},
 		WebConfig: WebConfig{
-			AutoRender:             true,
-			EnableDocs:             false,
-			FlashName:              "BEEGO_FLASH",
-			FlashSeparator:         "BEEGOFLASH",
-			DirectoryIndex:         false,
-			StaticDir:              map[string]string{"/static": "static"},
-			StaticExtensionsToGzip: []string{".css", ".js"},
-			StaticCacheFileSize:    1024 * 100,
-			StaticCacheFileNum:     1000,
-			TemplateLeft:           "{{",
-			TemplateRight:          "}}",
-			ViewsPath:              "views",
-			CommentRouterPath:      "controllers",
-			EnableXSRF:             false,
-			XSRFKey:                "beegoxsrf",
-			XSRFExpire:             0,
+			AutoRender:                true,
+			EnableDocs:                false,
+			FlashName:                 "BEEGO_FLASH",
+			FlashSeparator:            "BEEGOFLASH",
+			DirectoryIndex:            false,
+			StaticDir:                 map[string]string{"/static": "static"},
+			StaticExtensionsToGzip:    []string{".css", ".js"},
+			StaticCacheFileSize:       1024 * 100,
+			StaticCacheFileNum:        1000,
+			TemplateLeft:              "{{",
+			TemplateRight:             "}}",
+			ViewsPath:                 "views",
+			CommentRouterPath:         "controllers",
+			EnableXSRF:                false,
+			XSRFKey:                   "beegoxsrf",
+			XSRFExpire:                0,
+			XSRFSameSite:              http.SameSiteLaxMode,
+			EnableOpenAPI:             false,
+			OpenAPIPath:               "/swagger",
+			EnableRoutesDebug:         false,
+			RoutesDebugPath:           "/debug/routes",
+			I18nQueryName:             "lang",
+			I18nCookieName:            "lang",
+			EnableStreamRender:        false,
+			StreamRenderFlushSize:     4096,
+			StreamRenderFlushInterval: 100,
+			EnableETag:                false,
+			WeakETag:                  false,
+			SecurityHeaders: SecurityHeadersConfig{
+				HSTSMaxAge:         31536000,
+				ContentTypeNosniff: true,
+				FrameOptions:       "DENY",
+				ReferrerPolicy:     "strict-origin-when-cross-origin",
+			},
 			Session: SessionConfig{
 				SessionOn:                    false,
 				SessionProvider:              "memory",
@@ -587,6 +874,8 @@ func newBConfig() *Config {
 				SessionNameInHTTPHeader:      "Beegosessionid",
 				SessionEnableSidInURLQuery:   false, // enable get the sessionId from Url Query params
 				SessionCookieSameSite:        http.SameSiteDefaultMode,
+				SessionRefreshMode:           "always",
+				SessionSerializer:            "gob",
 			},
 		},
 		Log: LogConfig{
@@ -638,7 +927,7 @@ func assignConfig(ac config.Configer) error {
 }
 
 func parseConfigForV1(ac config.Configer) {
-	for _, i := range []interface{}{BConfig, &BConfig.Listen, &BConfig.WebConfig, &BConfig.Log, &BConfig.WebConfig.Session} {
+	for _, i := range []interface{}{BConfig, &BConfig.Listen, &BConfig.WebConfig, &BConfig.Log, &BConfig.WebConfig.Session, &BConfig.WebConfig.SecurityHeaders} {
 		assignSingleConfig(i, ac)
 	}
 
@@ -0,0 +1,127 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AcceptItem is an item of the Accept, Accept-Language, Accept-Encoding or Accept-Charset header
+type AcceptItem struct {
+	// Value is the media type, language, encoding or charset.
+	// The language keeps its case, and the others are lower case
+	Value string
+	// Q is the quality, 0 means the value is not acceptable
+	Q float64
+	// Params is the parameters except q, such as version=2 in application/json;version=2.
+	// It's nil if there is no parameter
+	Params map[string]string
+}
+
+// the indexes of acceptCache
+const (
+	acceptMediaType = iota
+	acceptLanguage
+	acceptEncoding
+	acceptCharset
+	acceptHeaderCount
+)
+
+var acceptHeaders = [acceptHeaderCount]string{"Accept", "Accept-Language", "Accept-Encoding", "Accept-Charset"}
+
+// ParseAcceptHeader parses the value of Accept-* header, the items are sorted by Q in descending order.
+// The items with the same Q keep their order, except that the more specific media types come first,
+// for example, text/html comes before text/* and */*
+func ParseAcceptHeader(header string, lowerCase bool) []AcceptItem {
+	if header == "" {
+		return nil
+	}
+	items := make([]AcceptItem, 0, strings.Count(header, ",")+1)
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		item := AcceptItem{Value: strings.TrimSpace(value), Q: 1}
+		if item.Value == "" {
+			continue
+		}
+		if lowerCase {
+			item.Value = strings.ToLower(item.Value)
+		}
+		for params != "" {
+			var p string
+			p, params, _ = strings.Cut(params, ";")
+			k, v, _ := strings.Cut(p, "=")
+			k, v = strings.ToLower(strings.TrimSpace(k)), strings.Trim(strings.TrimSpace(v), `"`)
+			if k == "" {
+				continue
+			}
+			if k == "q" {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q >= 0 && q <= 1 {
+					item.Q = q
+				}
+				continue
+			}
+			if item.Params == nil {
+				item.Params = make(map[string]string, 1)
+			}
+			item.Params[k] = v
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Q != items[j].Q {
+			return items[i].Q > items[j].Q
+		}
+		return wildcards(items[i].Value) < wildcards(items[j].Value)
+	})
+	return items
+}
+
+// wildcards returns the number of * in the media type, */* has 2 and text/* has 1
+func wildcards(value string) int {
+	return strings.Count(value, "*")
+}
+
+// Accepts returns the parsed Accept header, see ParseAcceptHeader.
+// The result is cached in the request and shouldn't be modified
+func (input *BeegoInput) Accepts() []AcceptItem {
+	return input.acceptItems(acceptMediaType)
+}
+
+// AcceptLanguages returns the parsed Accept-Language header, see Accepts
+func (input *BeegoInput) AcceptLanguages() []AcceptItem {
+	return input.acceptItems(acceptLanguage)
+}
+
+// AcceptEncodings returns the parsed Accept-Encoding header, see Accepts
+func (input *BeegoInput) AcceptEncodings() []AcceptItem {
+	return input.acceptItems(acceptEncoding)
+}
+
+// AcceptCharsets returns the parsed Accept-Charset header, see Accepts
+func (input *BeegoInput) AcceptCharsets() []AcceptItem {
+	return input.acceptItems(acceptCharset)
+}
+
+func (input *BeegoInput) acceptItems(i int) []AcceptItem {
+	input.acceptLock.Lock()
+	defer input.acceptLock.Unlock()
+	if input.acceptParsed&(1<<i) == 0 {
+		input.acceptCache[i] = ParseAcceptHeader(input.Header(acceptHeaders[i]), i != acceptLanguage)
+		input.acceptParsed |= 1 << i
+	}
+	return input.acceptCache[i]
+}
@@ -25,6 +25,9 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 )
 
 var (
@@ -37,6 +40,8 @@ var (
 	// List of HTTP methods to compress. If not set, only GET requests are compressed.
 	includedMethods map[string]bool
 	getMethodOnly   bool
+	// The content types to compress and their min length, nil means all the content types will be compressed.
+	compressContentTypes map[string]int
 )
 
 // InitGzip initializes the gzipcompress
@@ -55,6 +60,98 @@ func InitGzip(minLength, compressLevel int, methods []string) {
 	}
 }
 
+// InitCompressContentTypes sets the content types which should be compressed.
+// The item could be a type like "application/json", or a wildcard like "text/*",
+// and the min length for this type could be specified after a colon, like "text/html:1024".
+// If types is empty, all the content types will be compressed.
+func InitCompressContentTypes(types []string) {
+	if len(types) == 0 {
+		compressContentTypes = nil
+		return
+	}
+	compressContentTypes = make(map[string]int, len(types))
+	for _, v := range types {
+		minLength := -1
+		if i := strings.LastIndex(v, ":"); i > 0 {
+			if l, err := strconv.Atoi(strings.TrimSpace(v[i+1:])); err == nil {
+				minLength = l
+			}
+			v = v[:i]
+		}
+		compressContentTypes[strings.ToLower(strings.TrimSpace(v))] = minLength
+	}
+}
+
+// incompressibleContentTypes are compressed already, compressing them again wastes CPU for little gain.
+// The item could be a type or a wildcard like "video/*"
+var incompressibleContentTypes = map[string]bool{
+	"image/*":                      true,
+	"video/*":                      true,
+	"audio/*":                      true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+	"application/zip":              true,
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/x-bzip2":          true,
+	"application/x-xz":             true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/vnd.rar":          true,
+	"application/zstd":             true,
+	"application/x-brotli":         true,
+	"application/pdf":              true,
+}
+
+// compressibleImages are the images in text format
+var compressibleImages = map[string]bool{
+	"image/svg+xml": true,
+	"image/bmp":     true,
+	"image/x-icon":  true,
+}
+
+// AddIncompressibleContentTypes adds the content types which are never compressed
+// unless they are specified exactly by compressContentTypes, such as "application/x-tar" or "model/*"
+func AddIncompressibleContentTypes(types ...string) {
+	for _, t := range types {
+		incompressibleContentTypes[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+}
+
+func isIncompressible(mediaType string) bool {
+	if incompressibleContentTypes[mediaType] {
+		return true
+	}
+	if i := strings.Index(mediaType, "/"); i > 0 && incompressibleContentTypes[mediaType[:i]+"/*"] {
+		return !compressibleImages[mediaType]
+	}
+	return false
+}
+
+// IsCompressible checks whether the content with the content type and length should be compressed.
+// The compressed types, such as images, videos and archives, are not compressed
+// unless they are specified exactly by compressContentTypes
+func IsCompressible(contentType string, length int) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if compressContentTypes == nil {
+		return length >= gzipMinLength && !isIncompressible(mediaType)
+	}
+	minLength, ok := compressContentTypes[mediaType]
+	if !ok {
+		if i := strings.Index(mediaType, "/"); i > 0 {
+			minLength, ok = compressContentTypes[mediaType[:i]+"/*"]
+		}
+		ok = ok && !isIncompressible(mediaType)
+	}
+	if !ok {
+		return false
+	}
+	if minLength < 0 {
+		minLength = gzipMinLength
+	}
+	return length >= minLength
+}
+
 type resetWriter interface {
 	io.Writer
 	Reset(w io.Writer)
@@ -73,6 +170,8 @@ type acceptEncoder struct {
 	levelEncode             func(int) resetWriter
 	customCompressLevelPool *sync.Pool
 	bestCompressionPool     *sync.Pool
+	// the encoder with higher preference is chosen if the client accepts them equally
+	preference int
 }
 
 func (ac acceptEncoder) encode(wr io.Writer, level int) resetWriter {
@@ -111,7 +210,7 @@ func (ac acceptEncoder) put(wr resetWriter, level int) {
 }
 
 var (
-	noneCompressEncoder = acceptEncoder{"", nil, nil, nil}
+	noneCompressEncoder = acceptEncoder{}
 	gzipCompressEncoder = acceptEncoder{
 		name:                    "gzip",
 		levelEncode:             func(level int) resetWriter { wr, _ := gzip.NewWriterLevel(nil, level); return wr },
@@ -129,21 +228,71 @@ var (
 		customCompressLevelPool: &sync.Pool{New: func() interface{} { wr, _ := zlib.NewWriterLevel(nil, gzipCompressLevel); return wr }},
 		bestCompressionPool:     &sync.Pool{New: func() interface{} { wr, _ := zlib.NewWriterLevel(nil, flate.BestCompression); return wr }},
 	}
+
+	brotliCompressEncoder = acceptEncoder{
+		name:                    "br",
+		levelEncode:             func(level int) resetWriter { return brotli.NewWriterLevel(nil, brotliLevel(level)) },
+		customCompressLevelPool: &sync.Pool{New: func() interface{} { return brotli.NewWriterLevel(nil, brotliLevel(gzipCompressLevel)) }},
+		bestCompressionPool:     &sync.Pool{New: func() interface{} { return brotli.NewWriterLevel(nil, brotli.BestCompression) }},
+		preference:              2,
+	}
+
+	zstdCompressEncoder = acceptEncoder{
+		name:                    "zstd",
+		levelEncode:             func(level int) resetWriter { return newZstdWriter(level) },
+		customCompressLevelPool: &sync.Pool{New: func() interface{} { return newZstdWriter(gzipCompressLevel) }},
+		bestCompressionPool:     &sync.Pool{New: func() interface{} { return newZstdWriter(flate.BestCompression) }},
+		preference:              1,
+	}
 )
 
+// brotliLevel converts the compress level defined by deflate package to brotli level
+func brotliLevel(level int) int {
+	if level == flate.BestCompression {
+		return brotli.BestCompression
+	}
+	if level < brotli.BestSpeed {
+		return brotli.DefaultCompression
+	}
+	return level
+}
+
+// newZstdWriter creates zstd encoder by the compress level defined by deflate package
+func newZstdWriter(level int) resetWriter {
+	var l zstd.EncoderLevel
+	switch level {
+	case flate.BestSpeed:
+		l = zstd.SpeedFastest
+	case flate.BestCompression:
+		l = zstd.SpeedBestCompression
+	default:
+		l = zstd.EncoderLevelFromZstd(level)
+	}
+	// the content is compressed in one goroutine
+	wr, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(l), zstd.WithEncoderConcurrency(1))
+	return wr
+}
+
 var encoderMap = map[string]acceptEncoder{ // all the other compress methods will ignore
+	"br":       brotliCompressEncoder,
+	"zstd":     zstdCompressEncoder,
 	"gzip":     gzipCompressEncoder,
 	"deflate":  deflateCompressEncoder,
 	"*":        gzipCompressEncoder, // * means any compress will accept,we prefer gzip
 	"identity": noneCompressEncoder, // identity means none-compress
 }
 
-// WriteFile reads from file and writes to writer by the specific encoding(gzip/deflate)
+// WriteFile reads from file and writes to writer by the specific encoding(br/zstd/gzip/deflate)
 func WriteFile(encoding string, writer io.Writer, file *os.File) (bool, string, error) {
 	return writeLevel(encoding, writer, file, flate.BestCompression)
 }
 
-// WriteBody reads writes content to writer by the specific encoding(gzip/deflate)
+// WriteReader reads from reader and writes to writer by the specific encoding(br/zstd/gzip/deflate)
+func WriteReader(encoding string, writer io.Writer, reader io.Reader) (bool, string, error) {
+	return writeLevel(encoding, writer, reader, flate.BestCompression)
+}
+
+// WriteBody reads writes content to writer by the specific encoding(br/zstd/gzip/deflate)
 func WriteBody(encoding string, writer io.Writer, content []byte) (bool, string, error) {
 	if encoding == "" || len(content) < gzipMinLength {
 		_, err := writer.Write(content)
@@ -192,10 +341,13 @@ func ParseEncoding(r *http.Request) string {
 }
 
 type q struct {
-	name  string
-	value float64
+	name       string
+	value      float64
+	preference int
 }
 
+// parseEncoding chooses the encoding with the highest q value,
+// and if the values are equal, the encoding with higher preference or the first one will be chosen
 func parseEncoding(r *http.Request) string {
 	acceptEncoding := r.Header.Get("Accept-Encoding")
 	if acceptEncoding == "" {
@@ -210,20 +362,18 @@ func parseEncoding(r *http.Request) string {
 		vs := strings.Split(v, ";")
 		var cf acceptEncoder
 		var ok bool
-		if cf, ok = encoderMap[vs[0]]; !ok {
+		if cf, ok = encoderMap[strings.TrimSpace(vs[0])]; !ok {
 			continue
 		}
-		if len(vs) == 1 {
-			return cf.name
-		}
+		f := 1.0
 		if len(vs) == 2 {
-			f, _ := strconv.ParseFloat(strings.Replace(vs[1], "q=", "", -1), 64)
+			f, _ = strconv.ParseFloat(strings.Replace(strings.TrimSpace(vs[1]), "q=", "", -1), 64)
 			if f == 0 {
 				continue
 			}
-			if f > lastQ.value {
-				lastQ = q{cf.name, f}
-			}
+		}
+		if f > lastQ.value || (f == lastQ.value && cf.preference > lastQ.preference) {
+			lastQ = q{cf.name, f, cf.preference}
 		}
 	}
 	return lastQ.name
@@ -15,8 +15,18 @@
 package context
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_ExtractEncoding(t *testing.T) {
@@ -57,3 +67,98 @@ func Test_ExtractEncoding(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_ExtractEncodingPreference(t *testing.T) {
+	assert.Equal(t, "br", parseEncoding(&http.Request{Header: map[string][]string{"Accept-Encoding": {"gzip, deflate, br, zstd"}}}))
+	assert.Equal(t, "zstd", parseEncoding(&http.Request{Header: map[string][]string{"Accept-Encoding": {"gzip, deflate, zstd"}}}))
+	assert.Equal(t, "gzip", parseEncoding(&http.Request{Header: map[string][]string{"Accept-Encoding": {"gzip, br; q=0.8"}}}))
+}
+
+func TestWriteBody(t *testing.T) {
+	level := gzipCompressLevel
+	defer func() { gzipCompressLevel = level }()
+	InitGzip(-1, flate.DefaultCompression, nil)
+	content := []byte(strings.Repeat("beego ", 100))
+	decoders := map[string]func(r io.Reader) (io.Reader, error){
+		"br": func(r io.Reader) (io.Reader, error) {
+			return brotli.NewReader(r), nil
+		},
+		"zstd": func(r io.Reader) (io.Reader, error) {
+			return zstd.NewReader(r)
+		},
+		"gzip": func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+	}
+	for encoding, decoder := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			// write twice to reuse the pooled encoder
+			for i := 0; i < 2; i++ {
+				buf := &bytes.Buffer{}
+				ok, name, err := WriteBody(encoding, buf, content)
+				assert.Nil(t, err)
+				assert.True(t, ok)
+				assert.Equal(t, encoding, name)
+				assert.Less(t, buf.Len(), len(content))
+
+				r, err := decoder(buf)
+				assert.Nil(t, err)
+				data, err := io.ReadAll(r)
+				assert.Nil(t, err)
+				assert.Equal(t, content, data)
+			}
+		})
+	}
+}
+
+func TestIsCompressible(t *testing.T) {
+	defer InitCompressContentTypes(nil)
+	assert.True(t, IsCompressible("text/plain", 1024))
+	assert.False(t, IsCompressible("text/html", 1))
+	// compressed already
+	assert.False(t, IsCompressible("image/png", 1024))
+	assert.False(t, IsCompressible("video/mp4", 1024))
+	assert.False(t, IsCompressible("application/zip", 1024))
+	assert.True(t, IsCompressible("image/svg+xml", 1024))
+
+	AddIncompressibleContentTypes("application/x-tar")
+	defer delete(incompressibleContentTypes, "application/x-tar")
+	assert.False(t, IsCompressible("application/x-tar", 1024))
+
+	InitCompressContentTypes([]string{"application/json", "text/*:100"})
+	assert.True(t, IsCompressible("application/json; charset=utf-8", 1024))
+	assert.False(t, IsCompressible("application/json", 1))
+	assert.True(t, IsCompressible("text/html; charset=utf-8", 100))
+	assert.False(t, IsCompressible("text/css", 99))
+	assert.False(t, IsCompressible("image/png", 1024))
+
+	// the type specified exactly is compressed
+	InitCompressContentTypes([]string{"image/*", "application/pdf"})
+	assert.False(t, IsCompressible("image/png", 1024))
+	assert.True(t, IsCompressible("image/svg+xml", 1024))
+	assert.True(t, IsCompressible("application/pdf", 1024))
+}
+
+func TestBodyRespectsContentEncoding(t *testing.T) {
+	InitGzip(0, 1, nil)
+	defer InitGzip(defaultGzipMinLength, -1, nil)
+	for _, preset := range []string{"", "br"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		ctx := NewContext()
+		ctx.Reset(w, r)
+		ctx.Output.EnableGzip = true
+		if preset != "" {
+			ctx.Output.Header("Content-Encoding", preset)
+		}
+		content := []byte(strings.Repeat("beego", 100))
+		assert.Nil(t, ctx.Output.Body(content))
+		if preset == "" {
+			assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+			continue
+		}
+		assert.Equal(t, preset, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, content, w.Body.Bytes())
+	}
+}
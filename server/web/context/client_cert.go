@@ -0,0 +1,59 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+)
+
+// ClientCert is the verified client certificate of mutual TLS
+type ClientCert struct {
+	CommonName     string
+	DNSNames       []string
+	EmailAddresses []string
+	URIs           []string
+	// Fingerprint is the hex encoded SHA-256 of the certificate
+	Fingerprint string
+	Certificate *x509.Certificate
+	// Chain is the verified chain from the client certificate to the trusted CA
+	Chain []*x509.Certificate
+}
+
+// ClientCert returns the client certificate verified by the trusted CA,
+// it returns nil if the request isn't over TLS or the client doesn't provide the certificate.
+// The certificate which isn't verified, when ClientAuth is RequestClientCert for example, is ignored
+func (input *BeegoInput) ClientCert() *ClientCert {
+	r := input.Context.Request
+	if r == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	chain := r.TLS.VerifiedChains[0]
+	cert := chain[0]
+	sum := sha256.Sum256(cert.Raw)
+	cc := &ClientCert{
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		Fingerprint:    hex.EncodeToString(sum[:]),
+		Certificate:    cert,
+		Chain:          chain,
+	}
+	for _, u := range cert.URIs {
+		cc.URIs = append(cc.URIs, u.String())
+	}
+	return cc
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// the headers carrying the client IP, which are supported by ClientIPResolver
+const (
+	HeaderForwarded      = "Forwarded"
+	HeaderXForwardedFor  = "X-Forwarded-For"
+	HeaderXRealIP        = "X-Real-IP"
+	HeaderCFConnectingIP = "CF-Connecting-IP"
+)
+
+// ClientIPResolver resolves the client IP of the request behind the trusted proxies.
+// The header is only used when the request comes from a trusted proxy.
+// For Forwarded and X-Forwarded-For, the hops are walked from right to left,
+// and the rightmost hop which isn't a trusted proxy is the client,
+// so the hops forged by the client are ignored
+type ClientIPResolver struct {
+	header  string
+	trusted []*net.IPNet
+}
+
+// NewClientIPResolver creates the resolver, the trusted proxies are CIDRs or IPs
+// usage:
+//
+//	r, err := context.NewClientIPResolver(context.HeaderXForwardedFor, "10.0.0.0/8", "192.168.1.1")
+func NewClientIPResolver(header string, trustedProxies ...string) (*ClientIPResolver, error) {
+	header = http.CanonicalHeaderKey(header)
+	switch header {
+	case HeaderForwarded, HeaderXForwardedFor, http.CanonicalHeaderKey(HeaderXRealIP),
+		http.CanonicalHeaderKey(HeaderCFConnectingIP):
+	default:
+		return nil, fmt.Errorf("unsupported client IP header: %s", header)
+	}
+	r := &ClientIPResolver{header: header, trusted: make([]*net.IPNet, 0, len(trustedProxies))}
+	for _, p := range trustedProxies {
+		p = strings.TrimSpace(p)
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			r.trusted = append(r.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %s", p)
+		}
+		r.trusted = append(r.trusted, ipNet)
+	}
+	return r, nil
+}
+
+// IsTrusted reports whether the ip belongs to the trusted proxies
+func (r *ClientIPResolver) IsTrusted(ip net.IP) bool {
+	for _, n := range r.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the client IP of the request
+func (r *ClientIPResolver) Resolve(req *http.Request) string {
+	remote := parseHopIP(req.RemoteAddr)
+	if remote == nil {
+		return req.RemoteAddr
+	}
+	if !r.IsTrusted(remote) {
+		return remote.String()
+	}
+	switch r.header {
+	case HeaderForwarded, HeaderXForwardedFor:
+		hops := r.hops(req.Header)
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := parseHopIP(hops[i])
+			if ip == nil {
+				// the hop is unknown or forged, the last valid one is the best we know
+				break
+			}
+			client = ip
+			if !r.IsTrusted(ip) {
+				break
+			}
+		}
+		return client.String()
+	default:
+		if ip := parseHopIP(req.Header.Get(r.header)); ip != nil {
+			return ip.String()
+		}
+		return remote.String()
+	}
+}
+
+// hops returns the addresses in the header from the client to the last proxy
+func (r *ClientIPResolver) hops(h http.Header) []string {
+	res := make([]string, 0, 4)
+	for _, v := range h.Values(r.header) {
+		for _, elem := range strings.Split(v, ",") {
+			if r.header == HeaderXForwardedFor {
+				res = append(res, elem)
+				continue
+			}
+			// Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43
+			forwardedFor := ""
+			for _, pair := range strings.Split(elem, ";") {
+				k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(k, "for") {
+					forwardedFor = v
+				}
+			}
+			res = append(res, forwardedFor)
+		}
+	}
+	return res
+}
+
+// parseHopIP parses the address like 192.0.2.1, 192.0.2.1:80, "[2001:db8::1]:80" or 2001:db8::1
+func parseHopIP(s string) net.IP {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if ip := net.ParseIP(s); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return net.ParseIP(host)
+	}
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+}
+
+// clientIPResolver is used by BeegoInput.IP, nil means the leftmost X-Forwarded-For is used
+var clientIPResolver *ClientIPResolver
+
+// SetClientIPResolver sets the resolver used by BeegoInput.IP.
+// If it's nil, BeegoInput.IP trusts the leftmost X-Forwarded-For of all requests,
+// which can be forged by the client
+func SetClientIPResolver(r *ClientIPResolver) {
+	clientIPResolver = r
+}
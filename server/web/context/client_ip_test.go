@@ -0,0 +1,86 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+		remote string
+		values []string
+		want   string
+	}{
+		{name: "untrusted remote", header: HeaderXForwardedFor, remote: "203.0.113.9:1234",
+			values: []string{"1.1.1.1"}, want: "203.0.113.9"},
+		{name: "no header", header: HeaderXForwardedFor, remote: "10.0.0.1:1234", want: "10.0.0.1"},
+		{name: "forged hops", header: HeaderXForwardedFor, remote: "10.0.0.1:1234",
+			values: []string{"1.1.1.1, 198.51.100.7, 10.0.0.2"}, want: "198.51.100.7"},
+		{name: "multiple headers", header: HeaderXForwardedFor, remote: "10.0.0.1:1234",
+			values: []string{"1.1.1.1", "198.51.100.7"}, want: "198.51.100.7"},
+		{name: "all trusted", header: HeaderXForwardedFor, remote: "10.0.0.1:1234",
+			values: []string{"10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+		{name: "invalid hop", header: HeaderXForwardedFor, remote: "10.0.0.1:1234",
+			values: []string{"198.51.100.7, unknown, 10.0.0.2"}, want: "10.0.0.2"},
+		{name: "forwarded", header: HeaderForwarded, remote: "10.0.0.1:1234",
+			values: []string{`for=1.1.1.1, for="[2001:db8::1]:4711";proto=https, for=10.0.0.2`}, want: "2001:db8::1"},
+		{name: "real ip", header: HeaderXRealIP, remote: "10.0.0.1:1234",
+			values: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "real ip untrusted", header: HeaderXRealIP, remote: "203.0.113.9:1234",
+			values: []string{"198.51.100.7"}, want: "203.0.113.9"},
+		{name: "cloudflare", header: HeaderCFConnectingIP, remote: "192.168.1.1:1234",
+			values: []string{"198.51.100.7"}, want: "198.51.100.7"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewClientIPResolver(tc.header, "10.0.0.0/8", "192.168.1.1")
+			require.Nil(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remote
+			for _, v := range tc.values {
+				req.Header.Add(tc.header, v)
+			}
+			assert.Equal(t, tc.want, r.Resolve(req))
+		})
+	}
+
+	_, err := NewClientIPResolver("X-Client-IP")
+	assert.NotNil(t, err)
+	_, err = NewClientIPResolver(HeaderXForwardedFor, "10.0.0.0/33")
+	assert.NotNil(t, err)
+}
+
+func TestInputIPWithResolver(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.9:1234"
+	req.Header.Set(HeaderXForwardedFor, "1.1.1.1")
+	ctx := NewContext()
+	ctx.Reset(httptest.NewRecorder(), req)
+	assert.Equal(t, "1.1.1.1", ctx.Input.IP())
+
+	r, err := NewClientIPResolver(HeaderXForwardedFor, "10.0.0.0/8")
+	require.Nil(t, err)
+	SetClientIPResolver(r)
+	defer SetClientIPResolver(nil)
+	assert.Equal(t, "203.0.113.9", ctx.Input.IP())
+}
@@ -24,6 +24,7 @@ import (
 	"bufio"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
@@ -41,6 +42,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/beego/beego/v2/core/utils"
+	"github.com/beego/beego/v2/core/validation"
 	"github.com/beego/beego/v2/server/web/session"
 )
 
@@ -49,6 +51,7 @@ const (
 	ApplicationJSON  = "application/json"
 	ApplicationXML   = "application/xml"
 	ApplicationForm  = "application/x-www-form-urlencoded"
+	MultipartForm    = "multipart/form-data"
 	ApplicationProto = "application/x-protobuf"
 	ApplicationYAML  = "application/x-yaml"
 	TextXML          = "text/xml"
@@ -57,6 +60,9 @@ const (
 	formatDate      = "2006-01-02"
 	formatDateTime  = "2006-01-02 15:04:05"
 	formatDateTimeT = "2006-01-02T15:04:05"
+
+	// the max memory of multipart form parsed by BindForm if it's not parsed by router, same as net/http
+	defaultMultipartMemory = 32 << 20
 )
 
 // NewContext return the Context with Input and Output
@@ -77,7 +83,138 @@ type Context struct {
 	_xsrfToken     string
 }
 
+// BindError is returned by Bind if the request is invalid
+type BindError struct {
+	Message string        `json:"message"`
+	Errors  []*FieldError `json:"errors,omitempty"`
+}
+
+func newBindError(err error) *BindError {
+	if fe, ok := err.(*FieldError); ok {
+		return &BindError{Message: "invalid request", Errors: []*FieldError{fe}}
+	}
+	return &BindError{Message: err.Error()}
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Error())
+	}
+	if len(msgs) == 0 {
+		return e.Message
+	}
+	return e.Message + ": " + strings.Join(msgs, "; ")
+}
+
+// FieldError describes why the field is invalid,
+// Source is where the field is bound from, such as query, header, path, form and valid
+type FieldError struct {
+	Field   string `json:"field"`
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Source + " " + e.Field + ": " + e.Message
+}
+
+// Bind binds the request to obj. If obj is a struct pointer,
+// the fields are bound from path parameters, query, header and form via tags,
+// and the body is decoded by Content-Type, JSON by default. For example:
+//
+//	type UpdateUserRequest struct {
+//		ID    int64  `path:"id"`
+//		Page  int    `query:"page" default:"1"`
+//		Token string `header:"X-Token" valid:"Required"`
+//		Name  string `json:"name" valid:"Required;MaxSize(32)"`
+//	}
+//
+// The default tag is applied only if the field is still zero after the body is decoded.
+// The other pointers, such as *map[string]any and *[]T, are decoded from the body only.
+// It returns *BindError if the request is invalid. obj isn't validated, see BindAndValidate.
 func (ctx *Context) Bind(obj interface{}) error {
+	objT := reflect.TypeOf(obj)
+	if !isStructPtr(objT) {
+		return ctx.decodeBody(obj)
+	}
+	if err := ctx.bindBody(obj); err != nil {
+		return newBindError(err)
+	}
+
+	objV := reflect.ValueOf(obj).Elem()
+	objT = objT.Elem()
+	if ctx.Request.URL != nil {
+		query := ctx.Request.URL.Query()
+		if err := parseValuesToStruct(query, "query", objT, objV); err != nil {
+			return newBindError(err)
+		}
+	}
+	header := url.Values(ctx.Request.Header)
+	if err := parseValuesToStruct(header, "header", objT, objV); err != nil {
+		return newBindError(err)
+	}
+	params := url.Values{}
+	for k, v := range ctx.Input.Params() {
+		params.Set(strings.TrimPrefix(k, ":"), v)
+	}
+	if err := parseValuesToStruct(params, "path", objT, objV); err != nil {
+		return newBindError(err)
+	}
+	return nil
+}
+
+// BindAndValidate binds the request to obj like Bind, and validates obj by core/validation via the valid tag.
+// It returns *BindError if the request is invalid.
+func (ctx *Context) BindAndValidate(obj interface{}) error {
+	if err := ctx.Bind(obj); err != nil {
+		return err
+	}
+	return ctx.Validate(obj)
+}
+
+// Validate validates obj by the valid tags, see core/validation.
+// If obj is invalid, it returns *BindError with the errors of fields
+func (ctx *Context) Validate(obj interface{}) error {
+	valid := validation.Validation{}
+	ok, err := valid.Valid(obj)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		bindErr := &BindError{Message: "validation failed"}
+		for _, e := range valid.Errors {
+			bindErr.Errors = append(bindErr.Errors, &FieldError{Field: e.Field, Source: "valid", Message: e.Message})
+		}
+		return bindErr
+	}
+	return nil
+}
+
+// BindOrBadRequest binds the request to obj and validates it like BindAndValidate,
+// and if it's failed, it responds 400 Bad Request with the errors in JSON and returns false.
+// usage:
+//
+//	var req UpdateUserRequest
+//	if !c.Ctx.BindOrBadRequest(&req) {
+//		return
+//	}
+func (ctx *Context) BindOrBadRequest(obj interface{}) bool {
+	err := ctx.BindAndValidate(obj)
+	if err == nil {
+		return true
+	}
+	bindErr, ok := err.(*BindError)
+	if !ok {
+		bindErr = newBindError(err)
+	}
+	ctx.Output.SetStatus(http.StatusBadRequest)
+	_ = ctx.Output.JSON(bindErr, false, false)
+	return false
+}
+
+// decodeBody decodes the body by Content-Type, JSON by default, it's how Bind works for the non-struct pointers
+func (ctx *Context) decodeBody(obj interface{}) error {
 	ct, exist := ctx.Request.Header["Content-Type"]
 	if !exist || len(ct) == 0 {
 		return ctx.BindJSON(obj)
@@ -102,22 +239,45 @@ func (ctx *Context) Bind(obj interface{}) error {
 	}
 }
 
-// Resp sends response based on the Accept Header
-// By default response will be in JSON
-func (ctx *Context) Resp(data interface{}) error {
-	accept := ctx.Input.Header("Accept")
-	switch accept {
-	case ApplicationYAML:
-		return ctx.YamlResp(data)
+// bindBody decodes the body by Content-Type
+func (ctx *Context) bindBody(obj interface{}) error {
+	ct, exist := ctx.Request.Header["Content-Type"]
+	if !exist || len(ct) == 0 {
+		if len(ctx.Input.RequestBody) == 0 {
+			return nil
+		}
+		return ctx.BindJSON(obj)
+	}
+	i, l := 0, len(ct[0])
+	for i < l && ct[0][i] != ';' {
+		i++
+	}
+	contentType := ct[0][0:i]
+	if contentType != ApplicationForm && contentType != MultipartForm && len(ctx.Input.RequestBody) == 0 {
+		return nil
+	}
+	switch contentType {
+	case ApplicationJSON:
+		return ctx.BindJSON(obj)
 	case ApplicationXML, TextXML:
-		return ctx.XMLResp(data)
+		return ctx.BindXML(obj)
+	case ApplicationForm, MultipartForm:
+		return ctx.BindForm(obj)
 	case ApplicationProto:
-		return ctx.ProtoResp(data.(proto.Message))
+		return ctx.BindProtobuf(obj.(proto.Message))
+	case ApplicationYAML:
+		return ctx.BindYAML(obj)
 	default:
-		return ctx.JSONResp(data)
+		return errors.New("Unsupported Content-Type:" + ct[0])
 	}
 }
 
+// Resp sends response based on the Accept Header
+// By default response will be in JSON, see BeegoOutput.Serve
+func (ctx *Context) Resp(data interface{}) error {
+	return ctx.Output.Serve(data)
+}
+
 func (ctx *Context) JSONResp(data interface{}) error {
 	return ctx.Output.JSON(data, false, false)
 }
@@ -140,12 +300,24 @@ func (ctx *Context) BindYAML(obj interface{}) error {
 }
 
 // BindForm will parse form values to struct via tag.
+// The uploaded files are bound to the *multipart.FileHeader and []*multipart.FileHeader fields with form tag.
 func (ctx *Context) BindForm(obj interface{}) error {
+	if ctx.Input.IsUpload() && ctx.Request.MultipartForm == nil {
+		if err := ctx.Request.ParseMultipartForm(defaultMultipartMemory); err != nil {
+			return err
+		}
+	}
 	err := ctx.Request.ParseForm()
 	if err != nil {
 		return err
 	}
-	return ParseForm(ctx.Request.Form, obj)
+	if err = ParseForm(ctx.Request.Form, obj); err != nil {
+		return err
+	}
+	if ctx.Request.MultipartForm != nil && len(ctx.Request.MultipartForm.File) > 0 {
+		parseFilesToStruct(ctx.Request.MultipartForm.File, reflect.TypeOf(obj).Elem(), reflect.ValueOf(obj).Elem())
+	}
+	return nil
 }
 
 // BindJSON only read data from http request body
@@ -261,20 +433,69 @@ func (ctx *Context) SetSecureCookie(Secret, name, value string, others ...interf
 	ctx.Output.Cookie(name, cookie, others...)
 }
 
+// XSRFOptions is the options of xsrf token
+type XSRFOptions struct {
+	// Key is the secret to sign the cookie
+	Key string
+	// Expire is the max age of cookie in seconds
+	Expire int64
+	// SameSite is the SameSite attribute of cookie
+	SameSite http.SameSite
+	// DoubleSubmit stores the token in a cookie which can be read by JavaScript,
+	// and the client submits it in the header or form, the server checks the two values are the same.
+	// It's stateless, and the token doesn't need to be rendered into the page
+	DoubleSubmit bool
+	// Header is the response header carrying the token, so SPA clients can read it without the cookie.
+	// It's not sent if it's empty
+	Header string
+}
+
 // XSRFToken creates and returns an xsrf token string
 func (ctx *Context) XSRFToken(key string, expire int64) string {
+	return ctx.XSRFTokenWithOptions(&XSRFOptions{Key: key, Expire: expire, SameSite: http.SameSiteLaxMode})
+}
+
+// XSRFTokenWithOptions creates and returns an xsrf token string according to the options
+func (ctx *Context) XSRFTokenWithOptions(opts *XSRFOptions) string {
 	if ctx._xsrfToken == "" {
-		token, ok := ctx.GetSecureCookie(key, "_xsrf")
+		var token string
+		var ok bool
+		if opts.DoubleSubmit {
+			token = ctx.Input.Cookie("_xsrf")
+			ok = token != ""
+		} else {
+			token, ok = ctx.GetSecureCookie(opts.Key, "_xsrf")
+		}
 		if !ok {
 			token = string(utils.RandomCreateBytes(32))
-			// TODO make it configurable
-			ctx.SetSecureCookie(key, "_xsrf", token, expire, "/", "", true, true)
+			sameSite := sameSiteString(opts.SameSite)
+			if opts.DoubleSubmit {
+				ctx.Output.Cookie("_xsrf", token, opts.Expire, "/", "", true, false, sameSite)
+			} else {
+				ctx.SetSecureCookie(opts.Key, "_xsrf", token, opts.Expire, "/", "", true, true, sameSite)
+			}
 		}
 		ctx._xsrfToken = token
+		if opts.Header != "" {
+			ctx.Output.Header(opts.Header, token)
+		}
 	}
 	return ctx._xsrfToken
 }
 
+func sameSiteString(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	default:
+		return ""
+	}
+}
+
 // CheckXSRFCookie checks if the XSRF token in this request is valid or not.
 // The token can be provided in the request header in the form "X-Xsrftoken" or "X-CsrfToken"
 // or in form field value named as "_xsrf".
@@ -290,7 +511,7 @@ func (ctx *Context) CheckXSRFCookie() bool {
 		ctx.Abort(422, "422")
 		return false
 	}
-	if ctx._xsrfToken != token {
+	if subtle.ConstantTimeCompare([]byte(ctx._xsrfToken), []byte(token)) != 1 {
 		ctx.Abort(417, "417")
 		return false
 	}
@@ -329,19 +550,44 @@ func (ctx *Context) Session() (store session.Store, err error) {
 	}
 }
 
-// Response is a wrapper for the http.ResponseWriter
+// Response is a wrapper for the http.ResponseWriter, it records the metadata of response,
+// so the filters after exec can use it to write the access logs or metrics.
 // Started:  if true, response was already written to so the other handler will not be executed
 type Response struct {
 	http.ResponseWriter
 	Started bool
 	Status  int
 	Elapsed time.Duration
+	// Size is the number of bytes written to the body
+	Size int64
+	// StartTime is the time when the request starts to be served
+	StartTime time.Time
+	// HeaderTime is the time when the header is sent, it's zero if the header isn't sent yet
+	HeaderTime time.Time
+	// WrittenHeader is the copy of header when it's sent, the changes after that are not sent to the client
+	WrittenHeader http.Header
+	// Flushes is the number of flushes, it's greater than 0 if the response is streamed
+	Flushes int
+	// Hijacked is true if the connection is hijacked, for example upgraded to websocket.
+	// The data written to the hijacked connection isn't counted in Status and Size
+	Hijacked bool
+	// headerShared is true if WrittenHeader is still the header of ResponseWriter,
+	// it's cloned when Header is called after the header was sent
+	headerShared bool
 }
 
 func (r *Response) reset(rw http.ResponseWriter) {
 	r.ResponseWriter = rw
 	r.Status = 0
 	r.Started = false
+	r.Size = 0
+	r.Elapsed = 0
+	r.StartTime = time.Now()
+	r.HeaderTime = time.Time{}
+	r.WrittenHeader = nil
+	r.headerShared = false
+	r.Flushes = 0
+	r.Hijacked = false
 }
 
 // Write writes the data to the connection as part of a HTTP reply,
@@ -349,7 +595,13 @@ func (r *Response) reset(rw http.ResponseWriter) {
 // Started:  if true, the response was already sent
 func (r *Response) Write(p []byte) (int, error) {
 	r.Started = true
-	return r.ResponseWriter.Write(p)
+	if r.Status == 0 {
+		// the header is sent with 200 implicitly
+		r.recordHeader(http.StatusOK)
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.Size += int64(n)
+	return n, err
 }
 
 // WriteHeader sends a HTTP response header with status code,
@@ -359,25 +611,82 @@ func (r *Response) WriteHeader(code int) {
 		// prevent multiple response.WriteHeader calls
 		return
 	}
-	r.Status = code
+	r.recordHeader(code)
 	r.Started = true
 	r.ResponseWriter.WriteHeader(code)
 }
 
-// Hijack hijacker for http
+func (r *Response) recordHeader(code int) {
+	r.Status = code
+	r.HeaderTime = time.Now()
+	// the header is only cloned if it may be modified after sending, see Header
+	r.WrittenHeader = r.ResponseWriter.Header()
+	r.headerShared = true
+}
+
+// Header returns the header map of ResponseWriter.
+// If the header was sent, WrittenHeader is detached from it first,
+// so the changes after sending aren't recorded in WrittenHeader
+func (r *Response) Header() http.Header {
+	if r.headerShared {
+		r.WrittenHeader = r.WrittenHeader.Clone()
+		r.headerShared = false
+	}
+	return r.ResponseWriter.Header()
+}
+
+// Duration returns the time elapsed since the request starts,
+// it's the same as Elapsed once the request is finished
+func (r *Response) Duration() time.Duration {
+	if r.Elapsed > 0 || r.StartTime.IsZero() {
+		return r.Elapsed
+	}
+	return time.Since(r.StartTime)
+}
+
+// TimeToFirstByte returns the time elapsed from the start of request to sending the header,
+// it returns 0 if the header isn't sent
+func (r *Response) TimeToFirstByte() time.Duration {
+	if r.HeaderTime.IsZero() || r.StartTime.IsZero() {
+		return 0
+	}
+	return r.HeaderTime.Sub(r.StartTime)
+}
+
+// Hijack hijacker for http, the wrapped writers which implement Unwrap are supported
 func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	hj, ok := r.ResponseWriter.(http.Hijacker)
-	if !ok {
-		return nil, nil, errors.New("webserver doesn't support hijacking")
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, fmt.Errorf("webserver doesn't support hijacking: %w", err)
 	}
-	return hj.Hijack()
+	r.Hijacked = true
+	r.Started = true
+	return conn, rw, nil
 }
 
 // Flush http.Flusher
 func (r *Response) Flush() {
-	if f, ok := r.ResponseWriter.(http.Flusher); ok {
-		f.Flush()
+	_ = r.FlushError()
+}
+
+// FlushError flushes the buffered data to the client and returns the error,
+// it's used by http.ResponseController
+func (r *Response) FlushError() error {
+	if err := http.NewResponseController(r.ResponseWriter).Flush(); err != nil {
+		return err
 	}
+	if r.Status == 0 {
+		r.recordHeader(http.StatusOK)
+	}
+	r.Started = true
+	r.Flushes++
+	return nil
+}
+
+// Unwrap returns the original http.ResponseWriter,
+// so http.ResponseController can set the deadlines or enable full duplex
+func (r *Response) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
 }
 
 // CloseNotify http.CloseNotifier
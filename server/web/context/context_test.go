@@ -15,9 +15,19 @@
 package context
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/beego/beego/v2/server/web/session"
 )
@@ -48,6 +58,38 @@ func TestXsrfReset_01(t *testing.T) {
 	}
 }
 
+func TestXSRFTokenWithOptions(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := NewContext()
+	c.Reset(&Response{ResponseWriter: w}, r)
+	token := c.XSRFTokenWithOptions(&XSRFOptions{
+		SameSite:     http.SameSiteStrictMode,
+		DoubleSubmit: true,
+		Header:       "X-Xsrftoken",
+	})
+	assert.Equal(t, token, w.Header().Get("X-Xsrftoken"))
+	cookie := w.Header().Get("Set-Cookie")
+	assert.Contains(t, cookie, "_xsrf="+token)
+	assert.Contains(t, cookie, "SameSite=Strict")
+	assert.NotContains(t, cookie, "HttpOnly")
+
+	// the token is submitted in the header and the cookie
+	r = httptest.NewRequest(http.MethodPost, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "_xsrf", Value: token})
+	r.Header.Set("X-Xsrftoken", token)
+	c.Reset(&Response{ResponseWriter: httptest.NewRecorder()}, r)
+	assert.Equal(t, token, c.XSRFTokenWithOptions(&XSRFOptions{DoubleSubmit: true}))
+	assert.True(t, c.CheckXSRFCookie())
+
+	r.Header.Set("X-Xsrftoken", "wrong")
+	c.Reset(&Response{ResponseWriter: httptest.NewRecorder()}, r)
+	c.XSRFTokenWithOptions(&XSRFOptions{DoubleSubmit: true})
+	assert.Panics(t, func() {
+		c.CheckXSRFCookie()
+	})
+}
+
 func TestContext_Session(t *testing.T) {
 	c := NewContext()
 	if store, err := c.Session(); store != nil || err == nil {
@@ -111,3 +153,196 @@ func TestSetCookie(t *testing.T) {
 		}
 	}
 }
+
+type bindRequest struct {
+	ID    int64  `path:"id"`
+	Page  int    `query:"page" default:"1"`
+	Tags  []int  `query:"tag"`
+	Token string `header:"x-token" valid:"Required"`
+	Name  string `json:"name" valid:"Required;MaxSize(8)"`
+}
+
+func newBindContext(url, token, body string) (*Context, *httptest.ResponseRecorder) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	r.Header.Set("Content-Type", ApplicationJSON)
+	if token != "" {
+		r.Header.Set("X-Token", token)
+	}
+	ctx := NewContext()
+	ctx.Reset(rw, r)
+	ctx.Input.RequestBody = []byte(body)
+	ctx.Input.SetParam(":id", "42")
+	return ctx, rw
+}
+
+func TestContext_Bind(t *testing.T) {
+	ctx, _ := newBindContext("/user/42?tag=1&tag=2", "secret", `{"name":"beego"}`)
+	var req bindRequest
+	assert.Nil(t, ctx.Bind(&req))
+	assert.Equal(t, bindRequest{ID: 42, Page: 1, Tags: []int{1, 2}, Token: "secret", Name: "beego"}, req)
+
+	ctx, _ = newBindContext("/user/42?page=abc", "secret", `{"name":"beego"}`)
+	err := ctx.Bind(&bindRequest{})
+	bindErr, ok := err.(*BindError)
+	assert.True(t, ok)
+	assert.Equal(t, []*FieldError{{Field: "page", Source: "query", Message: `strconv.ParseInt: parsing "abc": invalid syntax`}}, bindErr.Errors)
+
+	ctx, rw := newBindContext("/user/42", "", `{"name":"beego framework"}`)
+	assert.False(t, ctx.BindOrBadRequest(&bindRequest{}))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	bindErr = &BindError{}
+	assert.Nil(t, json.Unmarshal(rw.Body.Bytes(), bindErr))
+	assert.Equal(t, "validation failed", bindErr.Message)
+	assert.Len(t, bindErr.Errors, 2)
+	assert.Equal(t, "Token", bindErr.Errors[0].Field)
+	assert.Equal(t, "Name", bindErr.Errors[1].Field)
+}
+
+func TestContext_BindNonStruct(t *testing.T) {
+	ctx, _ := newBindContext("/", "", `{"name":"beego"}`)
+	m := map[string]interface{}{}
+	assert.Nil(t, ctx.Bind(&m))
+	assert.Equal(t, map[string]interface{}{"name": "beego"}, m)
+
+	ctx, _ = newBindContext("/", "", `[1,2,3]`)
+	var s []int
+	assert.Nil(t, ctx.Bind(&s))
+	assert.Equal(t, []int{1, 2, 3}, s)
+}
+
+func TestContext_BindAndValidate(t *testing.T) {
+	// Bind doesn't validate
+	ctx, _ := newBindContext("/user/42", "", `{"name":"beego framework"}`)
+	var req bindRequest
+	assert.Nil(t, ctx.Bind(&req))
+	assert.Equal(t, "beego framework", req.Name)
+
+	ctx, _ = newBindContext("/user/42", "", `{"name":"beego framework"}`)
+	var bindErr *BindError
+	require.ErrorAs(t, ctx.BindAndValidate(&bindRequest{}), &bindErr)
+	assert.Len(t, bindErr.Errors, 2)
+}
+
+func TestContext_BindDefault(t *testing.T) {
+	type pageRequest struct {
+		Page int `json:"page" query:"page" default:"1"`
+		Size int `json:"size" query:"size" default:"20"`
+	}
+	// the body value isn't overwritten by the default value
+	ctx, _ := newBindContext("/", "", `{"page":3}`)
+	var req pageRequest
+	assert.Nil(t, ctx.Bind(&req))
+	assert.Equal(t, pageRequest{Page: 3, Size: 20}, req)
+
+	// the query value still wins
+	ctx, _ = newBindContext("/?page=5", "", `{"page":3}`)
+	req = pageRequest{}
+	assert.Nil(t, ctx.Bind(&req))
+	assert.Equal(t, pageRequest{Page: 5, Size: 20}, req)
+}
+
+type uploadRequest struct {
+	Name   string                  `form:"name"`
+	Avatar *multipart.FileHeader   `form:"avatar" valid:"Required;MaxFileSize(64);FileType(image/png|image/gif)"`
+	Docs   []*multipart.FileHeader `form:"docs" valid:"MaxSize(2)"`
+}
+
+// png header
+var pngContent = []byte("\x89PNG\r\n\x1a\n0000")
+
+func newUploadContext(t *testing.T, files map[string][][]byte) *Context {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	require.Nil(t, w.WriteField("name", "beego"))
+	for field, contents := range files {
+		for i, content := range contents {
+			fw, err := w.CreateFormFile(field, fmt.Sprintf("%s%d", field, i))
+			require.Nil(t, err)
+			_, err = fw.Write(content)
+			require.Nil(t, err)
+		}
+	}
+	require.Nil(t, w.Close())
+	r := httptest.NewRequest(http.MethodPost, "/upload", body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	ctx := NewContext()
+	ctx.Reset(httptest.NewRecorder(), r)
+	return ctx
+}
+
+func TestContext_BindFiles(t *testing.T) {
+	ctx := newUploadContext(t, map[string][][]byte{"avatar": {pngContent}, "docs": {[]byte("a"), []byte("b")}})
+	var req uploadRequest
+	require.Nil(t, ctx.BindAndValidate(&req))
+	assert.Equal(t, "beego", req.Name)
+	require.NotNil(t, req.Avatar)
+	assert.Equal(t, "avatar0", req.Avatar.Filename)
+	assert.Len(t, req.Docs, 2)
+
+	ctx = newUploadContext(t, map[string][][]byte{"docs": {[]byte("a")}})
+	var bindErr *BindError
+	require.ErrorAs(t, ctx.BindAndValidate(&uploadRequest{}), &bindErr)
+	assert.Equal(t, "Avatar", bindErr.Errors[0].Field)
+
+	ctx = newUploadContext(t, map[string][][]byte{"avatar": {append(pngContent, bytes.Repeat([]byte("0"), 64)...)}})
+	require.ErrorAs(t, ctx.BindAndValidate(&uploadRequest{}), &bindErr)
+	assert.Equal(t, "Avatar Maximum file size is 64 bytes", bindErr.Errors[0].Message)
+
+	// the declared type image/png is ignored
+	ctx = newUploadContext(t, map[string][][]byte{"avatar": {[]byte("%PDF-1.4")}})
+	require.ErrorAs(t, ctx.BindAndValidate(&uploadRequest{}), &bindErr)
+	assert.Equal(t, "Avatar File type must be image/png|image/gif", bindErr.Errors[0].Message)
+
+	ctx = newUploadContext(t, map[string][][]byte{"avatar": {pngContent}, "docs": {{'a'}, {'b'}, {'c'}}})
+	require.ErrorAs(t, ctx.BindAndValidate(&uploadRequest{}), &bindErr)
+	assert.Equal(t, "Docs", bindErr.Errors[0].Field)
+}
+
+func TestResponseMetadata(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := NewContext()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	rw := ctx.ResponseWriter
+	assert.False(t, rw.StartTime.IsZero())
+	assert.Equal(t, time.Duration(0), rw.TimeToFirstByte())
+
+	rw.Header().Set("X-Before", "1")
+	_, _ = rw.Write([]byte("hello"))
+	rw.Header().Set("X-After", "1")
+	rw.Flush()
+	_, _ = rw.Write([]byte(" world"))
+
+	assert.Equal(t, http.StatusOK, rw.Status)
+	assert.Equal(t, int64(11), rw.Size)
+	assert.Equal(t, 1, rw.Flushes)
+	assert.Equal(t, "1", rw.WrittenHeader.Get("X-Before"))
+	assert.Empty(t, rw.WrittenHeader.Get("X-After"))
+	assert.True(t, rw.TimeToFirstByte() >= 0)
+	assert.True(t, rw.Duration() >= rw.TimeToFirstByte())
+
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 0, rw.Status)
+	assert.Nil(t, rw.WrittenHeader)
+	assert.Equal(t, 0, rw.Flushes)
+	rw.WriteHeader(http.StatusCreated)
+	_, _ = rw.Write([]byte("created"))
+	assert.Equal(t, http.StatusCreated, rw.Status)
+	assert.Equal(t, int64(7), rw.Size)
+}
+
+func TestResponseWrittenHeaderLazyClone(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := NewContext()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	rw := ctx.ResponseWriter
+	rw.Header().Set("X-Before", "1")
+	_, _ = rw.Write([]byte("hello"))
+	// the header isn't cloned if it's not touched after sending
+	assert.Equal(t, reflect.ValueOf(w.Header()).Pointer(), reflect.ValueOf(rw.WrittenHeader).Pointer())
+
+	rw.Header().Set("X-After", "1")
+	assert.NotEqual(t, reflect.ValueOf(w.Header()).Pointer(), reflect.ValueOf(rw.WrittenHeader).Pointer())
+	assert.Equal(t, "1", rw.WrittenHeader.Get("X-Before"))
+	assert.Empty(t, rw.WrittenHeader.Get("X-After"))
+}
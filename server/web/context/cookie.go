@@ -0,0 +1,104 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the cookie name prefixes which are checked by browsers
+const (
+	CookiePrefixSecure = "__Secure-"
+	CookiePrefixHost   = "__Host-"
+)
+
+// CookieOptions is the attributes of cookie set by BeegoOutput.SetCookie
+type CookieOptions struct {
+	// Path is "/" if it's empty
+	Path   string
+	Domain string
+	// MaxAge > 0 sets both Max-Age and Expires, MaxAge < 0 deletes the cookie.
+	// If MaxAge is 0, the cookie expires at Expires, or when the browser is closed if Expires is zero
+	MaxAge  int
+	Expires time.Time
+	Secure  bool
+	// HttpOnly prevents the cookie from being read by JavaScript
+	HttpOnly bool
+	// SameSite is not sent if it's 0, SameSite=None requires Secure
+	SameSite http.SameSite
+	// Partitioned stores the cookie per top-level site (CHIPS), it requires Secure
+	Partitioned bool
+}
+
+// SetCookie sets the cookie with the options, and validates the cookie
+// as browsers do, so the cookie won't be rejected silently:
+// the name with __Secure- prefix requires Secure,
+// the name with __Host- prefix requires Secure, Path "/" and no Domain.
+// usage:
+//
+//	err := ctx.Output.SetCookie("__Host-sid", sid, context.CookieOptions{
+//		MaxAge: 3600, Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode,
+//	})
+func (output *BeegoOutput) SetCookie(name, value string, opts CookieOptions) error {
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if err := opts.validate(name); err != nil {
+		return err
+	}
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		MaxAge:   opts.MaxAge,
+		Expires:  opts.Expires,
+		Secure:   opts.Secure,
+		HttpOnly: opts.HttpOnly,
+		SameSite: opts.SameSite,
+	}
+	if opts.MaxAge > 0 {
+		// Expires is for the old browsers which don't support Max-Age
+		c.Expires = time.Now().Add(time.Duration(opts.MaxAge) * time.Second)
+	} else if opts.MaxAge < 0 {
+		c.Expires = time.Unix(0, 0)
+	}
+	v := c.String()
+	if v == "" {
+		return fmt.Errorf("invalid cookie name: %q", name)
+	}
+	if opts.Partitioned {
+		v += "; Partitioned"
+	}
+	output.Context.ResponseWriter.Header().Add("Set-Cookie", v)
+	return nil
+}
+
+func (opts *CookieOptions) validate(name string) error {
+	switch {
+	case strings.HasPrefix(name, CookiePrefixSecure) && !opts.Secure:
+		return fmt.Errorf("cookie %s requires Secure", name)
+	case strings.HasPrefix(name, CookiePrefixHost) && (!opts.Secure || opts.Path != "/" || opts.Domain != ""):
+		return fmt.Errorf("cookie %s requires Secure, Path=/ and no Domain", name)
+	case opts.SameSite == http.SameSiteNoneMode && !opts.Secure:
+		return fmt.Errorf("cookie %s with SameSite=None requires Secure", name)
+	case opts.Partitioned && !opts.Secure:
+		return fmt.Errorf("partitioned cookie %s requires Secure", name)
+	}
+	return nil
+}
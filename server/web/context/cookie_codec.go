@@ -0,0 +1,182 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// the version of encoded cookie format
+const cookieCodecVersion = 1
+
+var (
+	// ErrCookieInvalid means the cookie is malformed, or signed by unknown key, or tampered
+	ErrCookieInvalid = errors.New("cookie is invalid")
+	// ErrCookieExpired means the max age embedded in the cookie is exceeded
+	ErrCookieExpired = errors.New("cookie is expired")
+)
+
+// CookieKey is the keys to sign and encrypt the cookie
+type CookieKey struct {
+	// HashKey signs the cookie by HMAC-SHA256, 32 or 64 bytes are recommended
+	HashKey []byte
+	// BlockKey encrypts the cookie by AES-GCM, it should be 16, 24 or 32 bytes.
+	// The cookie is signed only if it's empty
+	BlockKey []byte
+}
+
+// CookieCodec encodes the values into the signed and optionally encrypted cookies,
+// the expiration time is embedded into the payload, so the cookie can't be used after max age
+// even if the client keeps it.
+// The first key encodes the cookies, and all keys are tried to decode,
+// so a new key can be prepended and the old one removed after the old cookies expire
+type CookieCodec struct {
+	keys  []CookieKey
+	aeads []cipher.AEAD
+}
+
+// NewCookieCodec creates the codec, at least one key is required
+// usage:
+//
+//	codec, err := context.NewCookieCodec(
+//		context.CookieKey{HashKey: newHashKey, BlockKey: newBlockKey},
+//		context.CookieKey{HashKey: oldHashKey, BlockKey: oldBlockKey},
+//	)
+func NewCookieCodec(keys ...CookieKey) (*CookieCodec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("cookie codec requires at least one key")
+	}
+	c := &CookieCodec{keys: keys, aeads: make([]cipher.AEAD, len(keys))}
+	for i, k := range keys {
+		if len(k.HashKey) == 0 {
+			return nil, fmt.Errorf("the hash key of cookie key %d is empty", i)
+		}
+		if len(k.BlockKey) == 0 {
+			continue
+		}
+		block, err := aes.NewCipher(k.BlockKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block key of cookie key %d: %w", i, err)
+		}
+		if c.aeads[i], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Encode serializes the value as JSON, encrypts and signs it by the first key.
+// maxAge <= 0 means the cookie doesn't expire
+func (c *CookieCodec) Encode(name string, value interface{}, maxAge time.Duration) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	var expires int64
+	if maxAge > 0 {
+		expires = time.Now().Add(maxAge).Unix()
+	}
+	payload := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(payload, uint64(expires))
+	payload = append(payload, data...)
+
+	if aead := c.aeads[0]; aead != nil {
+		nonce := make([]byte, aead.NonceSize())
+		if _, err = rand.Read(nonce); err != nil {
+			return "", err
+		}
+		payload = aead.Seal(nonce, nonce, payload, []byte(name))
+	}
+	msg := append([]byte{cookieCodecVersion}, payload...)
+	msg = append(msg, cookieMAC(c.keys[0].HashKey, name, msg)...)
+	return base64.RawURLEncoding.EncodeToString(msg), nil
+}
+
+// Decode verifies and decrypts the cookie by each key, and unmarshals the value into dst
+func (c *CookieCodec) Decode(name, cookie string, dst interface{}) error {
+	msg, err := base64.RawURLEncoding.DecodeString(cookie)
+	if err != nil || len(msg) < 1+sha256.Size || msg[0] != cookieCodecVersion {
+		return ErrCookieInvalid
+	}
+	body, mac := msg[:len(msg)-sha256.Size], msg[len(msg)-sha256.Size:]
+	for i, k := range c.keys {
+		if !hmac.Equal(mac, cookieMAC(k.HashKey, name, body)) {
+			continue
+		}
+		payload := body[1:]
+		if aead := c.aeads[i]; aead != nil {
+			if len(payload) < aead.NonceSize() {
+				return ErrCookieInvalid
+			}
+			nonce := payload[:aead.NonceSize()]
+			if payload, err = aead.Open(nil, nonce, payload[aead.NonceSize():], []byte(name)); err != nil {
+				return ErrCookieInvalid
+			}
+		}
+		if len(payload) < 8 {
+			return ErrCookieInvalid
+		}
+		expires := int64(binary.BigEndian.Uint64(payload))
+		if expires > 0 && time.Now().Unix() > expires {
+			return ErrCookieExpired
+		}
+		return json.Unmarshal(payload[8:], dst)
+	}
+	return ErrCookieInvalid
+}
+
+// cookieMAC signs the cookie with its name, so the cookie can't be used as another one
+func cookieMAC(key []byte, name string, body []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name))
+	h.Write([]byte{'|'})
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// SetEncodedCookie encodes the value by codec and sets the cookie,
+// maxAge is in seconds and embedded into the cookie, the others are the same as BeegoOutput.Cookie
+// usage:
+//
+//	err := ctx.SetEncodedCookie(codec, "remember", RememberMe{UserID: 1}, 30*24*3600, "/", "", true, true)
+func (ctx *Context) SetEncodedCookie(codec *CookieCodec, name string, value interface{}, maxAge int64, others ...interface{}) error {
+	cookie, err := codec.Encode(name, value, time.Duration(maxAge)*time.Second)
+	if err != nil {
+		return err
+	}
+	ctx.Output.Cookie(name, cookie, append([]interface{}{maxAge}, others...)...)
+	return nil
+}
+
+// GetEncodedCookie decodes the cookie by codec into dst,
+// it returns http.ErrNoCookie if the cookie doesn't exist
+func (ctx *Context) GetEncodedCookie(codec *CookieCodec, name string, dst interface{}) error {
+	cookie := ctx.Input.Cookie(name)
+	if cookie == "" {
+		return http.ErrNoCookie
+	}
+	return codec.Decode(name, cookie, dst)
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rememberMe struct {
+	UserID int64  `json:"uid"`
+	Token  string `json:"token"`
+}
+
+func TestCookieCodec(t *testing.T) {
+	oldKey := CookieKey{HashKey: bytes.Repeat([]byte("o"), 32), BlockKey: bytes.Repeat([]byte("k"), 32)}
+	newKey := CookieKey{HashKey: bytes.Repeat([]byte("n"), 32), BlockKey: bytes.Repeat([]byte("b"), 16)}
+	signOnly := CookieKey{HashKey: bytes.Repeat([]byte("s"), 32)}
+
+	oldCodec, err := NewCookieCodec(oldKey)
+	require.Nil(t, err)
+	rotated, err := NewCookieCodec(newKey, oldKey)
+	require.Nil(t, err)
+	signCodec, err := NewCookieCodec(signOnly)
+	require.Nil(t, err)
+
+	value := rememberMe{UserID: 1, Token: "secret-token"}
+	encoded, err := oldCodec.Encode("remember", value, time.Hour)
+	require.Nil(t, err)
+	assert.NotContains(t, encoded, "secret-token")
+
+	// the cookie encoded by old key is accepted after rotation
+	var got rememberMe
+	require.Nil(t, rotated.Decode("remember", encoded, &got))
+	assert.Equal(t, value, got)
+
+	// the new cookie can't be decoded by the codec without new key
+	encoded, err = rotated.Encode("remember", value, 0)
+	require.Nil(t, err)
+	assert.ErrorIs(t, oldCodec.Decode("remember", encoded, &got), ErrCookieInvalid)
+
+	// the cookie can't be used as another one
+	assert.ErrorIs(t, rotated.Decode("flash", encoded, &got), ErrCookieInvalid)
+
+	// tampered
+	tampered := []byte(encoded)
+	tampered[len(tampered)/2] ^= 1
+	assert.ErrorIs(t, rotated.Decode("remember", string(tampered), &got), ErrCookieInvalid)
+	assert.ErrorIs(t, rotated.Decode("remember", "!!!", &got), ErrCookieInvalid)
+
+	// expired
+	encoded, err = rotated.Encode("remember", value, time.Nanosecond)
+	require.Nil(t, err)
+	time.Sleep(1100 * time.Millisecond)
+	assert.ErrorIs(t, rotated.Decode("remember", encoded, &got), ErrCookieExpired)
+
+	// signed only
+	encoded, err = signCodec.Encode("flash", map[string]string{"notice": "saved"}, time.Minute)
+	require.Nil(t, err)
+	flash := map[string]string{}
+	require.Nil(t, signCodec.Decode("flash", encoded, &flash))
+	assert.Equal(t, "saved", flash["notice"])
+
+	_, err = NewCookieCodec()
+	assert.NotNil(t, err)
+	_, err = NewCookieCodec(CookieKey{HashKey: []byte("k"), BlockKey: []byte("short")})
+	assert.NotNil(t, err)
+}
+
+func TestContextEncodedCookie(t *testing.T) {
+	codec, err := NewCookieCodec(CookieKey{HashKey: bytes.Repeat([]byte("h"), 32), BlockKey: bytes.Repeat([]byte("b"), 32)})
+	require.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	ctx := NewContext()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	value := rememberMe{UserID: 7, Token: "t"}
+	require.Nil(t, ctx.SetEncodedCookie(codec, "remember", value, 3600, "/", "", true, true))
+	setCookie := w.Header().Get("Set-Cookie")
+	assert.True(t, strings.HasPrefix(setCookie, "remember="))
+	assert.Contains(t, setCookie, "Max-Age=3600")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Cookie", strings.SplitN(setCookie, ";", 2)[0])
+	ctx.Reset(httptest.NewRecorder(), r)
+	var got rememberMe
+	require.Nil(t, ctx.GetEncodedCookie(codec, "remember", &got))
+	assert.Equal(t, value, got)
+	assert.ErrorIs(t, ctx.GetEncodedCookie(codec, "missing", &got), http.ErrNoCookie)
+}
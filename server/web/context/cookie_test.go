@@ -0,0 +1,74 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputSetCookie(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cookie   string
+		opts     CookieOptions
+		contains []string
+		absent   []string
+		wantErr  bool
+	}{
+		{name: "session cookie", cookie: "a", opts: CookieOptions{HttpOnly: true},
+			contains: []string{"a=v", "Path=/", "HttpOnly"}, absent: []string{"Max-Age", "Expires", "SameSite"}},
+		{name: "max age", cookie: "a", opts: CookieOptions{MaxAge: 60, SameSite: http.SameSiteStrictMode},
+			contains: []string{"Max-Age=60", "Expires=", "SameSite=Strict"}},
+		{name: "expires", cookie: "a", opts: CookieOptions{Expires: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)},
+			contains: []string{"Expires=Wed, 02 Jan 2030 03:04:05 GMT"}, absent: []string{"Max-Age"}},
+		{name: "delete", cookie: "a", opts: CookieOptions{MaxAge: -1}, contains: []string{"Max-Age=0"}},
+		{name: "partitioned", cookie: "__Host-a", opts: CookieOptions{Secure: true, SameSite: http.SameSiteNoneMode,
+			Partitioned: true}, contains: []string{"__Host-a=v", "Secure", "SameSite=None", "Partitioned"}},
+		{name: "secure prefix", cookie: "__Secure-a", opts: CookieOptions{}, wantErr: true},
+		{name: "host prefix with domain", cookie: "__Host-a", opts: CookieOptions{Secure: true, Domain: "beego.wiki"},
+			wantErr: true},
+		{name: "host prefix with path", cookie: "__Host-a", opts: CookieOptions{Secure: true, Path: "/admin"},
+			wantErr: true},
+		{name: "insecure SameSite=None", cookie: "a", opts: CookieOptions{SameSite: http.SameSiteNoneMode}, wantErr: true},
+		{name: "insecure partitioned", cookie: "a", opts: CookieOptions{Partitioned: true}, wantErr: true},
+		{name: "invalid name", cookie: "a;b", opts: CookieOptions{}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx := NewContext()
+			ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			err := ctx.Output.SetCookie(tc.cookie, "v", tc.opts)
+			got := w.Header().Get("Set-Cookie")
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				assert.Empty(t, got)
+				return
+			}
+			assert.Nil(t, err)
+			for _, s := range tc.contains {
+				assert.Contains(t, got, s)
+			}
+			for _, s := range tc.absent {
+				assert.NotContains(t, got, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// PreloadLink returns the value of Link header which preloads the resource,
+// as is the type of resource, such as style, script, font and image
+//
+//	PreloadLink("/static/app.css", "style") // </static/app.css>; rel=preload; as=style
+func PreloadLink(url, as string) string {
+	return fmt.Sprintf("<%s>; rel=preload; as=%s", url, as)
+}
+
+// EarlyHints sends 103 Early Hints with the Link headers before the final response,
+// so the browser can start fetching the assets while the page is rendering.
+// The Link headers are also sent with the final response.
+// It's only sent to HTTP/2 and later clients because some HTTP/1.1 clients can't handle 1xx responses,
+// and it returns false if it isn't sent, for example, the response has started
+// usage:
+//
+//	c.Ctx.Output.EarlyHints(context.PreloadLink("/static/app.css", "style"))
+//	c.TplName = "index.tpl"
+func (output *BeegoOutput) EarlyHints(links ...string) bool {
+	ctx := output.Context
+	if len(links) == 0 || ctx.Request == nil || ctx.ResponseWriter == nil ||
+		ctx.ResponseWriter.Started || !ctx.Request.ProtoAtLeast(2, 0) {
+		return false
+	}
+	h := ctx.ResponseWriter.Header()
+	for _, link := range links {
+		h.Add("Link", link)
+	}
+	// bypass Response.WriteHeader which records the final status
+	ctx.ResponseWriter.ResponseWriter.WriteHeader(http.StatusEarlyHints)
+	return true
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type informationalRecorder struct {
+	*httptest.ResponseRecorder
+	informational []int
+}
+
+func (r *informationalRecorder) WriteHeader(code int) {
+	if code >= 100 && code < 200 {
+		r.informational = append(r.informational, code)
+		return
+	}
+	r.ResponseRecorder.WriteHeader(code)
+}
+
+func TestEarlyHints(t *testing.T) {
+	link := PreloadLink("/static/app.css", "style")
+	assert.Equal(t, "</static/app.css>; rel=preload; as=style", link)
+
+	rw := &informationalRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/2.0", 2, 0
+	ctx := NewContext()
+	ctx.Reset(rw, r)
+
+	assert.True(t, ctx.Output.EarlyHints(link))
+	assert.Equal(t, []int{http.StatusEarlyHints}, rw.informational)
+	assert.False(t, ctx.ResponseWriter.Started)
+
+	_ = ctx.Output.Body([]byte("page"))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, link, rw.Header().Get("Link"))
+	// the response has started
+	assert.False(t, ctx.Output.EarlyHints(link))
+
+	// HTTP/1.1
+	rw = &informationalRecorder{ResponseRecorder: httptest.NewRecorder()}
+	ctx.Reset(rw, httptest.NewRequest("GET", "/", nil))
+	assert.False(t, ctx.Output.EarlyHints(link))
+	assert.Empty(t, rw.informational)
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GenerateETag generates ETag from the content, it's like "xxx" or W/"xxx" if weak is true
+func GenerateETag(content []byte, weak bool) string {
+	sum := sha1.Sum(content)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	if weak {
+		etag = "W/" + etag
+	}
+	return etag
+}
+
+// SetETag sets ETag header, the etag will be quoted if it's not
+func (output *BeegoOutput) SetETag(etag string, weak bool) {
+	if !strings.HasSuffix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	if weak && !strings.HasPrefix(etag, "W/") {
+		etag = "W/" + etag
+	}
+	output.Header("ETag", etag)
+}
+
+// SetLastModified sets Last-Modified header
+func (output *BeegoOutput) SetLastModified(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	output.Header("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// CheckNotModified checks the request's If-None-Match and If-Modified-Since
+// with the ETag and Last-Modified set by SetETag and SetLastModified.
+// If the resource was not modified, it responds 304 Not Modified and returns true.
+// usage:
+//
+//	c.Ctx.Output.SetETag(article.Version, false)
+//	if c.Ctx.Output.CheckNotModified() {
+//		return
+//	}
+func (output *BeegoOutput) CheckNotModified() bool {
+	if !output.isNotModified() {
+		return false
+	}
+	output.writeNotModified()
+	return true
+}
+
+// isNotModified follows the RFC 7232 section 6,
+// If-Modified-Since is ignored if the request has If-None-Match
+func (output *BeegoOutput) isNotModified() bool {
+	r := output.Context.Request
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if output.Status != 0 && output.Status != http.StatusOK {
+		return false
+	}
+	header := output.Context.ResponseWriter.Header()
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := header.Get("ETag")
+		return etag != "" && matchETag(inm, etag)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	lm := header.Get("Last-Modified")
+	if ims == "" || lm == "" {
+		return false
+	}
+	imsTime, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	lmTime, err := http.ParseTime(lm)
+	if err != nil {
+		return false
+	}
+	return !lmTime.After(imsTime)
+}
+
+func (output *BeegoOutput) writeNotModified() {
+	header := output.Context.ResponseWriter.Header()
+	// RFC 7232 section 4.1
+	delete(header, "Content-Type")
+	delete(header, "Content-Length")
+	delete(header, "Content-Encoding")
+	output.Context.ResponseWriter.WriteHeader(http.StatusNotModified)
+	output.Status = 0
+}
+
+// matchETag checks whether etag matches the value of If-None-Match by weak comparison
+func matchETag(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newETagContext(method string, header map[string]string) (*Context, *httptest.ResponseRecorder) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(method, "/", nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	ctx := NewContext()
+	ctx.Reset(rw, r)
+	ctx.Output.EnableETag = true
+	return ctx, rw
+}
+
+func TestBeegoOutput_BodyETag(t *testing.T) {
+	content := []byte(`{"name":"beego"}`)
+	etag := GenerateETag(content, false)
+
+	ctx, rw := newETagContext(http.MethodGet, nil)
+	assert.Nil(t, ctx.Output.Body(content))
+	assert.Equal(t, 200, rw.Code)
+	assert.Equal(t, etag, rw.Header().Get("ETag"))
+	assert.Equal(t, content, rw.Body.Bytes())
+
+	ctx, rw = newETagContext(http.MethodGet, map[string]string{"If-None-Match": `"other", ` + etag})
+	ctx.Output.Header("Content-Type", "application/json")
+	assert.Nil(t, ctx.Output.Body(content))
+	assert.Equal(t, 304, rw.Code)
+	assert.Empty(t, rw.Body.Bytes())
+	assert.Empty(t, rw.Header().Get("Content-Type"))
+
+	// weak comparison
+	ctx, rw = newETagContext(http.MethodGet, map[string]string{"If-None-Match": "W/" + etag})
+	ctx.Output.WeakETag = true
+	assert.Nil(t, ctx.Output.Body(content))
+	assert.Equal(t, 304, rw.Code)
+	assert.Equal(t, "W/"+etag, rw.Header().Get("ETag"))
+
+	ctx, rw = newETagContext(http.MethodPost, map[string]string{"If-None-Match": etag})
+	assert.Nil(t, ctx.Output.Body(content))
+	assert.Equal(t, 200, rw.Code)
+	assert.Empty(t, rw.Header().Get("ETag"))
+
+	// the conditional request isn't handled by Body if EnableETag is false
+	ctx, rw = newETagContext(http.MethodGet, map[string]string{"If-None-Match": etag})
+	ctx.Output.EnableETag = false
+	ctx.Output.Header("ETag", etag)
+	assert.Nil(t, ctx.Output.Body(content))
+	assert.Equal(t, 200, rw.Code)
+	assert.Equal(t, content, rw.Body.Bytes())
+}
+
+func TestBeegoOutput_CheckNotModified(t *testing.T) {
+	lastModified := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ctx, rw := newETagContext(http.MethodGet, map[string]string{
+		"If-Modified-Since": lastModified.Format(http.TimeFormat),
+	})
+	ctx.Output.SetLastModified(lastModified)
+	assert.True(t, ctx.Output.CheckNotModified())
+	assert.Equal(t, 304, rw.Code)
+
+	ctx, _ = newETagContext(http.MethodGet, map[string]string{
+		"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat),
+	})
+	ctx.Output.SetLastModified(lastModified)
+	assert.False(t, ctx.Output.CheckNotModified())
+
+	// If-Modified-Since is ignored if If-None-Match exists
+	ctx, _ = newETagContext(http.MethodGet, map[string]string{
+		"If-None-Match":     `"v2"`,
+		"If-Modified-Since": lastModified.Format(http.TimeFormat),
+	})
+	ctx.Output.SetLastModified(lastModified)
+	ctx.Output.SetETag("v1", false)
+	assert.False(t, ctx.Output.CheckNotModified())
+
+	ctx, rw = newETagContext(http.MethodGet, map[string]string{"If-None-Match": "*"})
+	ctx.Output.SetETag("v1", true)
+	assert.Equal(t, `W/"v1"`, rw.Header().Get("ETag"))
+	assert.True(t, ctx.Output.CheckNotModified())
+}
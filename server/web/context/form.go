@@ -15,6 +15,8 @@
 package context
 
 import (
+	"mime/multipart"
+	"net/textproto"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -23,13 +25,21 @@ import (
 )
 
 var (
-	sliceOfInts    = reflect.TypeOf([]int(nil))
-	sliceOfStrings = reflect.TypeOf([]string(nil))
+	sliceOfInts        = reflect.TypeOf([]int(nil))
+	sliceOfStrings     = reflect.TypeOf([]string(nil))
+	fileHeaderType     = reflect.TypeOf((*multipart.FileHeader)(nil))
+	sliceOfFileHeaders = reflect.TypeOf([]*multipart.FileHeader(nil))
 )
 
 // ParseForm will parse form values to struct via tag.
 // Support for anonymous struct.
 func parseFormToStruct(form url.Values, objT reflect.Type, objV reflect.Value) error {
+	return parseValuesToStruct(form, "form", objT, objV)
+}
+
+// parseValuesToStruct parses values to struct via the tag named tagKey, such as form, query, header and path.
+// Unlike form, the fields without the tag are ignored for other tags.
+func parseValuesToStruct(values url.Values, tagKey string, objT reflect.Type, objV reflect.Value) error {
 	for i := 0; i < objT.NumField(); i++ {
 		fieldV := objV.Field(i)
 		if !fieldV.CanSet() {
@@ -38,77 +48,117 @@ func parseFormToStruct(form url.Values, objT reflect.Type, objV reflect.Value) e
 
 		fieldT := objT.Field(i)
 		if fieldT.Anonymous && fieldT.Type.Kind() == reflect.Struct {
-			err := parseFormToStruct(form, fieldT.Type, fieldV)
+			err := parseValuesToStruct(values, tagKey, fieldT.Type, fieldV)
 			if err != nil {
 				return err
 			}
 			continue
 		}
 
-		tag, ok := formTagName(fieldT)
+		tag, ok := valuesTagName(fieldT, tagKey)
 		if !ok {
 			continue
 		}
 
-		value, ok := formValue(tag, form, fieldT)
+		// the default value doesn't overwrite the value decoded from the body
+		if len(values[tag]) == 0 && !fieldV.IsZero() {
+			continue
+		}
+		value, ok := formValue(tag, values, fieldT)
 		if !ok {
 			continue
 		}
 
-		switch fieldT.Type.Kind() {
-		case reflect.Bool:
-			b, err := parseFormBoolValue(value)
-			if err != nil {
-				return err
-			}
-			fieldV.SetBool(b)
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			x, err := strconv.ParseInt(value, 10, 64)
-			if err != nil {
-				return err
-			}
-			fieldV.SetInt(x)
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			x, err := strconv.ParseUint(value, 10, 64)
-			if err != nil {
-				return err
-			}
-			fieldV.SetUint(x)
-		case reflect.Float32, reflect.Float64:
-			x, err := strconv.ParseFloat(value, 64)
+		if err := setFieldValue(fieldV, fieldT, value, values[tag]); err != nil {
+			return &FieldError{Field: tag, Source: tagKey, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// parseFilesToStruct sets the *multipart.FileHeader and []*multipart.FileHeader fields by the form tag
+func parseFilesToStruct(files map[string][]*multipart.FileHeader, objT reflect.Type, objV reflect.Value) {
+	for i := 0; i < objT.NumField(); i++ {
+		fieldV := objV.Field(i)
+		if !fieldV.CanSet() {
+			continue
+		}
+		fieldT := objT.Field(i)
+		if fieldT.Anonymous && fieldT.Type.Kind() == reflect.Struct {
+			parseFilesToStruct(files, fieldT.Type, fieldV)
+			continue
+		}
+		if fieldT.Type != fileHeaderType && fieldT.Type != sliceOfFileHeaders {
+			continue
+		}
+		tag, ok := valuesTagName(fieldT, "form")
+		if !ok {
+			continue
+		}
+		fhs := files[tag]
+		if len(fhs) == 0 {
+			continue
+		}
+		if fieldT.Type == fileHeaderType {
+			fieldV.Set(reflect.ValueOf(fhs[0]))
+		} else {
+			fieldV.Set(reflect.ValueOf(fhs))
+		}
+	}
+}
+
+func setFieldValue(fieldV reflect.Value, fieldT reflect.StructField, value string, formVals []string) error {
+	switch fieldT.Type.Kind() {
+	case reflect.Bool:
+		b, err := parseFormBoolValue(value)
+		if err != nil {
+			return err
+		}
+		fieldV.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		fieldV.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		fieldV.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		fieldV.SetFloat(x)
+	case reflect.Interface:
+		fieldV.Set(reflect.ValueOf(value))
+	case reflect.String:
+		fieldV.SetString(value)
+	case reflect.Struct:
+		if fieldT.Type.String() == "time.Time" {
+			t, err := parseFormTime(value)
 			if err != nil {
 				return err
 			}
-			fieldV.SetFloat(x)
-		case reflect.Interface:
-			fieldV.Set(reflect.ValueOf(value))
-		case reflect.String:
-			fieldV.SetString(value)
-		case reflect.Struct:
-			if fieldT.Type.String() == "time.Time" {
-				t, err := parseFormTime(value)
+			fieldV.Set(reflect.ValueOf(t))
+		}
+	case reflect.Slice:
+		if fieldT.Type == sliceOfInts {
+			fieldV.Set(reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(int(1))), len(formVals), len(formVals)))
+			for i := 0; i < len(formVals); i++ {
+				val, err := strconv.Atoi(formVals[i])
 				if err != nil {
 					return err
 				}
-				fieldV.Set(reflect.ValueOf(t))
+				fieldV.Index(i).SetInt(int64(val))
 			}
-		case reflect.Slice:
-			if fieldT.Type == sliceOfInts {
-				formVals := form[tag]
-				fieldV.Set(reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(int(1))), len(formVals), len(formVals)))
-				for i := 0; i < len(formVals); i++ {
-					val, err := strconv.Atoi(formVals[i])
-					if err != nil {
-						return err
-					}
-					fieldV.Index(i).SetInt(int64(val))
-				}
-			} else if fieldT.Type == sliceOfStrings {
-				formVals := form[tag]
-				fieldV.Set(reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf("")), len(formVals), len(formVals)))
-				for i := 0; i < len(formVals); i++ {
-					fieldV.Index(i).SetString(formVals[i])
-				}
+		} else if fieldT.Type == sliceOfStrings {
+			fieldV.Set(reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf("")), len(formVals), len(formVals)))
+			for i := 0; i < len(formVals); i++ {
+				fieldV.Index(i).SetString(formVals[i])
 			}
 		}
 	}
@@ -154,6 +204,20 @@ func parseFormBoolValue(value string) (bool, error) {
 	return strconv.ParseBool(value)
 }
 
+func valuesTagName(fieldT reflect.StructField, tagKey string) (string, bool) {
+	if tagKey == "form" {
+		return formTagName(fieldT)
+	}
+	tag := strings.Split(fieldT.Tag.Get(tagKey), ",")[0]
+	if tag == "" || tag == "-" {
+		return "", false
+	}
+	if tagKey == "header" {
+		tag = textproto.CanonicalMIMEHeaderKey(tag)
+	}
+	return tag, true
+}
+
 // nolint
 func formTagName(fieldT reflect.StructField) (string, bool) {
 	tags := strings.Split(fieldT.Tag.Get("form"), ",")
@@ -53,6 +53,10 @@ type BeegoInput struct {
 	RequestBody   []byte
 	RunMethod     string
 	RunController reflect.Type
+	// the parsed Accept-* headers, see Accepts
+	acceptCache  [acceptHeaderCount][]AcceptItem
+	acceptParsed uint8
+	acceptLock   sync.Mutex
 }
 
 // NewInput returns the BeegoInput generated by context.
@@ -74,6 +78,10 @@ func (input *BeegoInput) Reset(ctx *Context) {
 	input.data = nil
 	input.dataLock.Unlock()
 	input.RequestBody = []byte{}
+	input.acceptLock.Lock()
+	input.acceptCache = [acceptHeaderCount][]AcceptItem{}
+	input.acceptParsed = 0
+	input.acceptLock.Unlock()
 }
 
 // Protocol returns the request protocol name, such as HTTP/1.1 .
@@ -213,9 +221,13 @@ func (input *BeegoInput) AcceptsYAML() bool {
 }
 
 // IP returns request client ip.
-// if in proxy, return first proxy id.
+// if the ClientIPResolver is set, it resolves the ip, see SetClientIPResolver.
+// otherwise if in proxy, return first proxy id.
 // if error, return RemoteAddr.
 func (input *BeegoInput) IP() string {
+	if clientIPResolver != nil {
+		return clientIPResolver.Resolve(input.Context.Request)
+	}
 	ips := input.Proxy()
 	if len(ips) > 0 && ips[0] != "" {
 		rip, _, err := net.SplitHostPort(ips[0])
@@ -329,16 +341,7 @@ func (input *BeegoInput) Query(key string) string {
 	if val := input.Param(key); val != "" {
 		return val
 	}
-	if input.Context.Request.Form == nil {
-		input.dataLock.Lock()
-		if input.Context.Request.Form == nil {
-			input.Context.Request.ParseForm()
-		}
-		input.dataLock.Unlock()
-	}
-	input.dataLock.RLock()
-	defer input.dataLock.RUnlock()
-	return input.Context.Request.Form.Get(key)
+	return input.queryValue(key)
 }
 
 // Header returns request header item string by a given string.
@@ -408,6 +411,21 @@ func (input *BeegoInput) GetData(key interface{}) interface{} {
 	return nil
 }
 
+// LookupData returns the stored data and whether it exists in this context.
+func (input *BeegoInput) LookupData(key interface{}) (interface{}, bool) {
+	input.dataLock.RLock()
+	defer input.dataLock.RUnlock()
+	v, ok := input.data[key]
+	return v, ok
+}
+
+// DeleteData removes the stored data with given key in this context.
+func (input *BeegoInput) DeleteData(key interface{}) {
+	input.dataLock.Lock()
+	defer input.dataLock.Unlock()
+	delete(input.data, key)
+}
+
 // SetData stores data with given key in this context.
 // This data is only available in this context.
 func (input *BeegoInput) SetData(key, val interface{}) {
@@ -19,6 +19,8 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestBind(t *testing.T) {
@@ -217,3 +219,43 @@ func BenchmarkQuery(b *testing.B) {
 		}
 	})
 }
+
+func TestParseAcceptHeader(t *testing.T) {
+	items := ParseAcceptHeader(`*/*;q=0.8, text/*, Application/JSON;version="2", text/html, image/webp;q=0`, true)
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, item.Value)
+	}
+	assert.Equal(t, []string{"application/json", "text/html", "text/*", "*/*", "image/webp"}, values)
+	assert.Equal(t, map[string]string{"version": "2"}, items[0].Params)
+	assert.Nil(t, items[1].Params)
+	assert.Equal(t, 0.8, items[3].Q)
+	assert.Equal(t, float64(0), items[4].Q)
+
+	assert.Nil(t, ParseAcceptHeader("", true))
+	items = ParseAcceptHeader("en-US;q=0.5, , zh-CN;q=bad, fr;q=2", false)
+	assert.Equal(t, []AcceptItem{{Value: "zh-CN", Q: 1}, {Value: "fr", Q: 1}, {Value: "en-US", Q: 0.5}}, items)
+}
+
+func TestInputAccepts(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/html, application/json;q=0.9")
+	r.Header.Set("Accept-Language", "zh-CN, en;q=0.8")
+	r.Header.Set("Accept-Encoding", "gzip;q=0.5, BR")
+	r.Header.Set("Accept-Charset", "utf-8")
+	ctx := NewContext()
+	ctx.Reset(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "text/html", ctx.Input.Accepts()[0].Value)
+	assert.Equal(t, "zh-CN", ctx.Input.AcceptLanguages()[0].Value)
+	assert.Equal(t, "br", ctx.Input.AcceptEncodings()[0].Value)
+	assert.Equal(t, "utf-8", ctx.Input.AcceptCharsets()[0].Value)
+
+	// cached until reset
+	r.Header.Set("Accept", "application/xml")
+	r.Header.Del("Accept-Charset")
+	assert.Equal(t, "text/html", ctx.Input.Accepts()[0].Value)
+	ctx.Reset(httptest.NewRecorder(), r)
+	assert.Equal(t, "application/xml", ctx.Input.Accepts()[0].Value)
+	assert.Nil(t, ctx.Input.AcceptCharsets())
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// JSONStream encodes the items received from ch as a JSON array until ch is closed.
+// ch must be a receivable channel, such as <-chan T or chan T.
+// The items are written as soon as they are received, and the buffered data is flushed
+// whenever there is no item ready, so the whole result is never held in memory.
+// It stops and returns the error if the client disconnects or an item can't be encoded,
+// the response is truncated in this case because the status has been sent
+// usage:
+//
+//	rows := make(chan Row)
+//	go query(ctx.Request.Context(), rows) // close rows when done
+//	err := ctx.Output.JSONStream(rows)
+func (output *BeegoOutput) JSONStream(ch interface{}) error {
+	return output.stream(ch, ApplicationJSON, []byte("["), []byte(","), []byte("]"))
+}
+
+// NDJSONStream encodes the items received from ch as newline delimited JSON until ch is closed,
+// see JSONStream
+func (output *BeegoOutput) NDJSONStream(ch interface{}) error {
+	return output.stream(ch, "application/x-ndjson", nil, nil, nil)
+}
+
+func (output *BeegoOutput) stream(ch interface{}, contentType string, open, sep, end []byte) error {
+	chv := reflect.ValueOf(ch)
+	if chv.Kind() != reflect.Chan || chv.Type().ChanDir()&reflect.RecvDir == 0 {
+		return fmt.Errorf("stream requires a receivable channel, but got %T", ch)
+	}
+
+	rw := output.Context.ResponseWriter
+	header := rw.Header()
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Del("Content-Length")
+	status := output.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	rw.WriteHeader(status)
+
+	buf := bufio.NewWriter(rw)
+	enc := json.NewEncoder(buf)
+	flush := func() error {
+		if err := buf.Flush(); err != nil {
+			return err
+		}
+		rw.Flush()
+		return nil
+	}
+
+	done := output.Context.Request.Context().Done()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: chv},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)},
+	}
+	_, _ = buf.Write(open)
+	for first := true; ; first = false {
+		select {
+		case <-done:
+			return output.Context.Request.Context().Err()
+		default:
+		}
+		// the item is invalid only if the channel isn't ready
+		item, ok := chv.TryRecv()
+		if !item.IsValid() {
+			// nothing is ready, send what we have before waiting
+			if err := flush(); err != nil {
+				return err
+			}
+			var chosen int
+			chosen, item, ok = reflect.Select(cases)
+			if chosen == 1 {
+				return output.Context.Request.Context().Err()
+			}
+		}
+		if !ok {
+			break
+		}
+		if !first {
+			_, _ = buf.Write(sep)
+		}
+		if err := enc.Encode(item.Interface()); err != nil {
+			_ = flush()
+			return err
+		}
+	}
+	_, _ = buf.Write(end)
+	return flush()
+}
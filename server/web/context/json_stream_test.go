@@ -0,0 +1,86 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamItem struct {
+	ID int `json:"id"`
+}
+
+func produce(n int) <-chan streamItem {
+	ch := make(chan streamItem)
+	go func() {
+		defer close(ch)
+		for i := 1; i <= n; i++ {
+			ch <- streamItem{ID: i}
+		}
+	}()
+	return ch
+}
+
+func TestJSONStream(t *testing.T) {
+	for _, n := range []int{0, 1, 100} {
+		w := httptest.NewRecorder()
+		ctx := NewContext()
+		ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Nil(t, ctx.Output.JSONStream(produce(n)))
+
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		var items []streamItem
+		require.Nil(t, json.Unmarshal(w.Body.Bytes(), &items))
+		assert.Len(t, items, n)
+		assert.True(t, w.Flushed)
+	}
+}
+
+func TestNDJSONStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := NewContext()
+	ctx.Reset(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	ctx.Output.SetStatus(http.StatusAccepted)
+	require.Nil(t, ctx.Output.NDJSONStream(produce(3)))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n", w.Body.String())
+}
+
+func TestJSONStreamCanceled(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	ch := make(chan streamItem, 1)
+	ch <- streamItem{ID: 1}
+	ctx := NewContext()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx))
+
+	done := make(chan error)
+	go func() {
+		done <- ctx.Output.JSONStream(ch)
+	}()
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	assert.NotNil(t, ctx.Output.JSONStream([]int{1}))
+	assert.NotNil(t, ctx.Output.JSONStream(make(chan<- int)))
+}
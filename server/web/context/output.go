@@ -40,6 +40,10 @@ type BeegoOutput struct {
 	Context    *Context
 	Status     int
 	EnableGzip bool
+	// EnableETag generates ETag for the body of GET and HEAD requests if it's not set
+	EnableETag bool
+	// WeakETag makes the generated ETag weak
+	WeakETag bool
 }
 
 // NewOutput returns new BeegoOutput.
@@ -60,15 +64,32 @@ func (output *BeegoOutput) Header(key, val string) {
 }
 
 // Body sets the response body content.
-// if EnableGzip, content is compressed.
+// if EnableGzip, content is compressed by the encoding negotiated from Accept-Encoding,
+// see InitCompressContentTypes.
+// if EnableETag and the request's If-None-Match or If-Modified-Since matches ETag or Last-Modified, 304 is responded,
+// see SetETag, CheckNotModified checks them explicitly if EnableETag is false
 // Sends out response body directly.
 func (output *BeegoOutput) Body(content []byte) error {
+	if output.EnableETag && output.Context.ResponseWriter.Header().Get("ETag") == "" &&
+		(output.Context.Request.Method == http.MethodGet || output.Context.Request.Method == http.MethodHead) {
+		output.Header("ETag", GenerateETag(content, output.WeakETag))
+	}
+	if output.EnableETag && output.CheckNotModified() {
+		return nil
+	}
 	var encoding string
 	buf := &bytes.Buffer{}
-	if output.EnableGzip {
+	// the content encoded by handler is not compressed again
+	if output.EnableGzip && output.Context.ResponseWriter.Header().Get("Content-Encoding") == "" &&
+		IsCompressible(output.Context.ResponseWriter.Header().Get("Content-Type"), len(content)) {
 		encoding = ParseEncoding(output.Context.Request)
 	}
 	if b, n, _ := WriteBody(encoding, buf, content); b {
+		output.Context.ResponseWriter.Header().Add("Vary", "Accept-Encoding")
+		// the compressed body is different from the origin one
+		if etag := output.Context.ResponseWriter.Header().Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			output.Header("ETag", "W/"+etag)
+		}
 		output.Header("Content-Encoding", n)
 		output.Header("Content-Length", strconv.Itoa(buf.Len()))
 	} else {
@@ -43,6 +43,35 @@ func Make(list ...*MethodParam) []*MethodParam {
 	return nil
 }
 
+// Name returns the name of the param
+func (mp *MethodParam) Name() string {
+	return mp.name
+}
+
+// In returns where the param comes from: "query", "path", "body" or "header"
+func (mp *MethodParam) In() string {
+	switch mp.in {
+	case path:
+		return "path"
+	case body:
+		return "body"
+	case header:
+		return "header"
+	default:
+		return "query"
+	}
+}
+
+// Required returns whether the param is required
+func (mp *MethodParam) Required() bool {
+	return mp.required
+}
+
+// DefaultValue returns the default value of the param
+func (mp *MethodParam) DefaultValue() string {
+	return mp.defaultValue
+}
+
 func (mp *MethodParam) String() string {
 	options := []string{}
 	result := "param.New(\"" + mp.name + "\""
@@ -0,0 +1,183 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// the sources of parameters
+const (
+	ParamSourcePath  = "path"
+	ParamSourceQuery = "query"
+)
+
+// ErrParamMissing means the parameter is absent or empty, it can be checked by errors.Is
+// to use the default value of optional parameter
+var ErrParamMissing = errors.New("is required")
+
+// ParamError is returned by the typed getters, such as ParamInt64 and QueryInt,
+// the web module renders it as 400 Bad Request
+type ParamError struct {
+	// Source is ParamSourcePath or ParamSourceQuery
+	Source string
+	Name   string
+	Value  string
+	Err    error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("invalid %s parameter %s: %v", e.Source, e.Name, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// IntConstraint checks the value of integer parameter
+type IntConstraint func(v int64) error
+
+// Min requires the value >= min
+func Min(min int64) IntConstraint {
+	return func(v int64) error {
+		if v < min {
+			return fmt.Errorf("should be >= %d", min)
+		}
+		return nil
+	}
+}
+
+// Max requires the value <= max
+func Max(max int64) IntConstraint {
+	return func(v int64) error {
+		if v > max {
+			return fmt.Errorf("should be <= %d", max)
+		}
+		return nil
+	}
+}
+
+// Between requires min <= value <= max
+func Between(min, max int64) IntConstraint {
+	return func(v int64) error {
+		if v < min || v > max {
+			return fmt.Errorf("should be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// ParamInt64 returns the router param as int64, the constraints are checked in order
+// usage:
+//
+//	id, err := ctx.Input.ParamInt64(":id", context.Min(1))
+func (input *BeegoInput) ParamInt64(key string, constraints ...IntConstraint) (int64, error) {
+	return parseInt(ParamSourcePath, key, input.Param(key), 64, constraints)
+}
+
+// ParamInt returns the router param as int, the constraints are checked in order
+func (input *BeegoInput) ParamInt(key string, constraints ...IntConstraint) (int, error) {
+	v, err := parseInt(ParamSourcePath, key, input.Param(key), strconv.IntSize, constraints)
+	return int(v), err
+}
+
+// ParamBool returns the router param as bool, see strconv.ParseBool for the accepted values
+func (input *BeegoInput) ParamBool(key string) (bool, error) {
+	return parseBool(ParamSourcePath, key, input.Param(key))
+}
+
+// ParamUUID returns the router param as UUID
+func (input *BeegoInput) ParamUUID(key string) (uuid.UUID, error) {
+	return parseUUID(ParamSourcePath, key, input.Param(key))
+}
+
+// QueryInt returns the query param as int, the constraints are checked in order
+// usage:
+//
+//	size, err := ctx.Input.QueryInt("size", context.Between(1, 100))
+//	if errors.Is(err, context.ErrParamMissing) {
+//		size, err = 20, nil
+//	}
+func (input *BeegoInput) QueryInt(key string, constraints ...IntConstraint) (int, error) {
+	v, err := parseInt(ParamSourceQuery, key, input.queryValue(key), strconv.IntSize, constraints)
+	return int(v), err
+}
+
+// QueryInt64 returns the query param as int64, the constraints are checked in order
+func (input *BeegoInput) QueryInt64(key string, constraints ...IntConstraint) (int64, error) {
+	return parseInt(ParamSourceQuery, key, input.queryValue(key), 64, constraints)
+}
+
+// QueryBool returns the query param as bool, see strconv.ParseBool for the accepted values
+func (input *BeegoInput) QueryBool(key string) (bool, error) {
+	return parseBool(ParamSourceQuery, key, input.queryValue(key))
+}
+
+// QueryUUID returns the query param as UUID
+func (input *BeegoInput) QueryUUID(key string) (uuid.UUID, error) {
+	return parseUUID(ParamSourceQuery, key, input.queryValue(key))
+}
+
+// queryValue returns the value of query string or form, unlike Query it ignores the router params
+func (input *BeegoInput) queryValue(key string) string {
+	input.dataLock.Lock()
+	defer input.dataLock.Unlock()
+	if input.Context.Request.Form == nil {
+		_ = input.Context.Request.ParseForm()
+	}
+	return input.Context.Request.Form.Get(key)
+}
+
+func parseInt(source, key, value string, bitSize int, constraints []IntConstraint) (int64, error) {
+	if value == "" {
+		return 0, &ParamError{Source: source, Name: key, Err: ErrParamMissing}
+	}
+	v, err := strconv.ParseInt(value, 10, bitSize)
+	if err != nil {
+		return 0, &ParamError{Source: source, Name: key, Value: value, Err: errors.New("should be an integer")}
+	}
+	for _, c := range constraints {
+		if err = c(v); err != nil {
+			return 0, &ParamError{Source: source, Name: key, Value: value, Err: err}
+		}
+	}
+	return v, nil
+}
+
+func parseBool(source, key, value string) (bool, error) {
+	if value == "" {
+		return false, &ParamError{Source: source, Name: key, Err: ErrParamMissing}
+	}
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &ParamError{Source: source, Name: key, Value: value, Err: errors.New("should be a boolean")}
+	}
+	return v, nil
+}
+
+func parseUUID(source, key, value string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, &ParamError{Source: source, Name: key, Err: ErrParamMissing}
+	}
+	v, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, &ParamError{Source: source, Name: key, Value: value, Err: errors.New("should be a UUID")}
+	}
+	return v, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newParamContext(target string, params map[string]string) *Context {
+	ctx := NewContext()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	for k, v := range params {
+		ctx.Input.SetParam(k, v)
+	}
+	return ctx
+}
+
+func TestParamGetters(t *testing.T) {
+	id := uuid.New()
+	ctx := newParamContext("/", map[string]string{
+		":id":     "42",
+		":neg":    "-1",
+		":name":   "beego",
+		":active": "true",
+		":uuid":   id.String(),
+	})
+
+	v, err := ctx.Input.ParamInt64(":id", Min(1), Max(100))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), v)
+
+	n, err := ctx.Input.ParamInt(":id")
+	assert.Nil(t, err)
+	assert.Equal(t, 42, n)
+
+	_, err = ctx.Input.ParamInt64(":neg", Min(1))
+	assert.Equal(t, "invalid path parameter :neg: should be >= 1", err.Error())
+	var paramErr *ParamError
+	assert.True(t, errors.As(err, &paramErr))
+	assert.Equal(t, ParamSourcePath, paramErr.Source)
+	assert.Equal(t, "-1", paramErr.Value)
+
+	_, err = ctx.Input.ParamInt64(":name")
+	assert.Equal(t, "invalid path parameter :name: should be an integer", err.Error())
+
+	_, err = ctx.Input.ParamInt64(":missing")
+	assert.True(t, errors.Is(err, ErrParamMissing))
+
+	b, err := ctx.Input.ParamBool(":active")
+	assert.Nil(t, err)
+	assert.True(t, b)
+	_, err = ctx.Input.ParamBool(":name")
+	assert.NotNil(t, err)
+
+	u, err := ctx.Input.ParamUUID(":uuid")
+	assert.Nil(t, err)
+	assert.Equal(t, id, u)
+	_, err = ctx.Input.ParamUUID(":name")
+	assert.Equal(t, "invalid path parameter :name: should be a UUID", err.Error())
+}
+
+func TestQueryGetters(t *testing.T) {
+	ctx := newParamContext("/?size=20&page=0&debug=1&size2=abc", map[string]string{"page": "3"})
+
+	size, err := ctx.Input.QueryInt("size", Between(1, 100))
+	assert.Nil(t, err)
+	assert.Equal(t, 20, size)
+
+	// the router params are ignored
+	_, err = ctx.Input.QueryInt64("page", Min(1))
+	assert.Equal(t, "invalid query parameter page: should be >= 1", err.Error())
+
+	_, err = ctx.Input.QueryInt("size", Between(30, 100))
+	assert.Equal(t, "invalid query parameter size: should be between 30 and 100", err.Error())
+
+	_, err = ctx.Input.QueryInt("size2")
+	assert.NotNil(t, err)
+
+	_, err = ctx.Input.QueryInt("limit")
+	assert.True(t, errors.Is(err, ErrParamMissing))
+
+	debug, err := ctx.Input.QueryBool("debug")
+	assert.Nil(t, err)
+	assert.True(t, debug)
+
+	_, err = ctx.Input.QueryUUID("size")
+	assert.NotNil(t, err)
+}
+
+func TestQueryGettersConcurrent(t *testing.T) {
+	ctx := newParamContext("/?page=2", nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			page, err := ctx.Input.QueryInt("page")
+			assert.Nil(t, err)
+			assert.Equal(t, 2, page)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "2", ctx.Input.Query("page"))
+		}()
+	}
+	wg.Wait()
+}
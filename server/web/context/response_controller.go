@@ -0,0 +1,71 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseController returns the http.ResponseController of the response,
+// the metadata of Response is kept when flushing or hijacking by it
+// usage:
+//
+//	rc := ctx.Output.ResponseController()
+//	_ = rc.SetWriteDeadline(time.Now().Add(time.Minute))
+func (output *BeegoOutput) ResponseController() *http.ResponseController {
+	return http.NewResponseController(output.Context.ResponseWriter)
+}
+
+// Flush sends the buffered data to the client
+func (output *BeegoOutput) Flush() error {
+	return output.Context.ResponseWriter.FlushError()
+}
+
+// Hijack takes over the connection, the status and the body written to the connection
+// are not recorded by Response
+func (output *BeegoOutput) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return output.Context.ResponseWriter.Hijack()
+}
+
+// SetWriteDeadline sets the deadline for writing the response, zero means no deadline.
+// It's useful for the long-lived streaming responses which exceed the WriteTimeout of server
+func (output *BeegoOutput) SetWriteDeadline(deadline time.Time) error {
+	return output.ResponseController().SetWriteDeadline(deadline)
+}
+
+// SetReadDeadline sets the deadline for reading the request body, zero means no deadline
+func (output *BeegoOutput) SetReadDeadline(deadline time.Time) error {
+	return output.ResponseController().SetReadDeadline(deadline)
+}
+
+// EnableFullDuplex allows reading the request body after writing the response for HTTP/1,
+// it requires the server built by Go 1.21 or later
+func (output *BeegoOutput) EnableFullDuplex() error {
+	var rw http.ResponseWriter = output.Context.ResponseWriter
+	for {
+		if fd, ok := rw.(interface{ EnableFullDuplex() error }); ok {
+			return fd.EnableFullDuplex()
+		}
+		u, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return fmt.Errorf("EnableFullDuplex: %w", http.ErrNotSupported)
+		}
+		rw = u.Unwrap()
+	}
+}
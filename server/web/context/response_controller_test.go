@@ -0,0 +1,92 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wrappedWriter hides the interfaces of the original writer except Unwrap
+type wrappedWriter struct {
+	http.ResponseWriter
+}
+
+func (w *wrappedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestResponseController(t *testing.T) {
+	var (
+		ctx          = NewContext()
+		flushes      int
+		deadlineErr  error
+		hijacked     bool
+		fullDuplexOK bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx.Reset(&wrappedWriter{ResponseWriter: w}, r)
+		if r.URL.Path == "/hijack" {
+			conn, _, err := ctx.Output.Hijack()
+			if err == nil {
+				hijacked = ctx.ResponseWriter.Hijacked
+				_, _ = conn.Write([]byte("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n"))
+				_ = conn.Close()
+			}
+			return
+		}
+		deadlineErr = ctx.Output.SetWriteDeadline(time.Now().Add(time.Minute))
+		err := ctx.Output.EnableFullDuplex()
+		fullDuplexOK = err == nil || errors.Is(err, http.ErrNotSupported)
+		_, _ = ctx.ResponseWriter.Write([]byte("a"))
+		_ = http.NewResponseController(ctx.ResponseWriter).Flush()
+		_ = ctx.Output.Flush()
+		flushes = ctx.ResponseWriter.Flushes
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.Nil(t, err)
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Nil(t, deadlineErr)
+	assert.True(t, fullDuplexOK)
+	assert.Equal(t, 2, flushes)
+
+	resp, err = http.Get(srv.URL + "/hijack")
+	require.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.True(t, hijacked)
+}
+
+func TestResponseControllerNotSupported(t *testing.T) {
+	ctx := NewContext()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	_, _, err := ctx.Output.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+	assert.ErrorIs(t, ctx.Output.SetWriteDeadline(time.Now()), http.ErrNotSupported)
+	assert.False(t, ctx.ResponseWriter.Hijacked)
+	// the recorder supports flushing
+	assert.Nil(t, ctx.Output.Flush())
+	assert.Equal(t, 1, ctx.ResponseWriter.Flushes)
+}
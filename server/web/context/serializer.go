@@ -0,0 +1,180 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// ApplicationMsgPack is the media type of MessagePack
+const ApplicationMsgPack = "application/msgpack"
+
+// Serializer encodes the data to response body for one media type
+type Serializer interface {
+	// ContentType returns the value of Content-Type header
+	ContentType() string
+	Marshal(data interface{}) ([]byte, error)
+}
+
+// SerializerFunc is an adapter to use a function as Serializer
+type SerializerFunc struct {
+	Type string
+	Func func(data interface{}) ([]byte, error)
+}
+
+// ContentType returns Type
+func (s SerializerFunc) ContentType() string {
+	return s.Type
+}
+
+// Marshal calls Func
+func (s SerializerFunc) Marshal(data interface{}) ([]byte, error) {
+	return s.Func(data)
+}
+
+var (
+	serializerLock sync.RWMutex
+	// the first one is the default serializer
+	serializerTypes []string
+	serializers     = map[string]Serializer{}
+)
+
+func init() {
+	jsonSerializer := SerializerFunc{Type: "application/json; charset=utf-8", Func: json.Marshal}
+	xmlSerializer := SerializerFunc{Type: "application/xml; charset=utf-8", Func: xml.Marshal}
+	yamlSerializer := SerializerFunc{Type: "application/x-yaml; charset=utf-8", Func: yaml.Marshal}
+	msgpackSerializer := SerializerFunc{Type: ApplicationMsgPack, Func: msgpack.Marshal}
+	RegisterSerializer(ApplicationJSON, jsonSerializer)
+	RegisterSerializer(ApplicationXML, xmlSerializer)
+	RegisterSerializer(TextXML, xmlSerializer)
+	RegisterSerializer(ApplicationYAML, yamlSerializer)
+	RegisterSerializer("application/yaml", yamlSerializer)
+	RegisterSerializer(ApplicationMsgPack, msgpackSerializer)
+	RegisterSerializer("application/x-msgpack", msgpackSerializer)
+	RegisterSerializer(ApplicationProto, SerializerFunc{
+		Type: ApplicationProto,
+		Func: func(data interface{}) ([]byte, error) {
+			msg, ok := data.(proto.Message)
+			if !ok {
+				return nil, fmt.Errorf("%T is not proto.Message", data)
+			}
+			return proto.Marshal(msg)
+		},
+	})
+}
+
+// RegisterSerializer registers the serializer for the media type, such as application/json.
+// The registered one will be replaced if the media type was registered.
+// The first registered serializer, JSON, is used if the request doesn't specify Accept header
+func RegisterSerializer(mediaType string, s Serializer) {
+	mediaType = strings.ToLower(mediaType)
+	serializerLock.Lock()
+	defer serializerLock.Unlock()
+	if _, ok := serializers[mediaType]; !ok {
+		serializerTypes = append(serializerTypes, mediaType)
+	}
+	serializers[mediaType] = s
+}
+
+// NegotiateSerializer returns the serializer which is the most acceptable for the Accept header.
+// The quality of a media type is the one of the most specific range matching it, so q=0 excludes it even if */* exists.
+// The default serializer, JSON, is used if the most preferred ranges don't match any registered media type
+// but JSON is acceptable, for example, the Accept header of browsers which prefer text/html.
+// It returns false if none of the registered media types is acceptable
+func NegotiateSerializer(accept string) (Serializer, bool) {
+	return negotiateSerializer(ParseAcceptHeader(accept, true))
+}
+
+func negotiateSerializer(ranges []AcceptItem) (Serializer, bool) {
+	serializerLock.RLock()
+	defer serializerLock.RUnlock()
+	if len(ranges) == 0 {
+		return serializers[serializerTypes[0]], true
+	}
+	best, bestQ, topMatched := "", 0.0, false
+	for _, mediaType := range serializerTypes {
+		q, ok := acceptQuality(ranges, mediaType)
+		if !ok || q <= 0 {
+			continue
+		}
+		if q == ranges[0].Q {
+			topMatched = true
+		}
+		// the earlier registered one wins if the qualities are the same
+		if q > bestQ {
+			best, bestQ = mediaType, q
+		}
+	}
+	if best == "" {
+		return nil, false
+	}
+	if !topMatched {
+		if q, ok := acceptQuality(ranges, serializerTypes[0]); ok && q > 0 {
+			return serializers[serializerTypes[0]], true
+		}
+	}
+	return serializers[best], true
+}
+
+// acceptQuality returns the quality of the most specific range matching the media type
+func acceptQuality(ranges []AcceptItem, mediaType string) (float64, bool) {
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		if sp := 2 - wildcards(r.Value); sp > specificity && matchMediaRange(r.Value, mediaType) {
+			q, specificity = r.Q, sp
+		}
+	}
+	return q, specificity >= 0
+}
+
+// matchMediaRange checks whether media type is in the range, such as */* or application/*
+func matchMediaRange(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mediaType, mediaRange[:len(mediaRange)-1])
+	}
+	return false
+}
+
+// Serve encodes data by the serializer which is chosen by the Accept header of request.
+// JSON is used if none of the serializers is acceptable.
+// usage:
+//
+//	c.Ctx.Output.Serve(user)
+func (output *BeegoOutput) Serve(data interface{}) error {
+	output.Context.ResponseWriter.Header().Add("Vary", "Accept")
+	s, ok := negotiateSerializer(output.Context.Input.Accepts())
+	if !ok {
+		s, _ = negotiateSerializer(nil)
+	}
+	content, err := s.Marshal(data)
+	if err != nil {
+		http.Error(output.Context.ResponseWriter, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+	output.Header("Content-Type", s.ContentType())
+	return output.Body(content)
+}
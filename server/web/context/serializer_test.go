@@ -0,0 +1,93 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type serializeUser struct {
+	Name string `json:"name" xml:"name" yaml:"name" msgpack:"name"`
+}
+
+func serve(accept string, data interface{}) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	ctx := NewContext()
+	ctx.Reset(rw, r)
+	_ = ctx.Output.Serve(data)
+	return rw
+}
+
+func TestBeegoOutput_Serve(t *testing.T) {
+	user := serializeUser{Name: "beego"}
+
+	rw := serve("", user)
+	assert.Equal(t, "application/json; charset=utf-8", rw.Header().Get("Content-Type"))
+	assert.Equal(t, `{"name":"beego"}`, rw.Body.String())
+	assert.Equal(t, "Accept", rw.Header().Get("Vary"))
+
+	// the browsers prefer text/html, so JSON is kept
+	rw = serve("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", user)
+	assert.Equal(t, "application/json; charset=utf-8", rw.Header().Get("Content-Type"))
+
+	rw = serve("text/html, application/xml;q=0.9", user)
+	assert.Equal(t, "application/xml; charset=utf-8", rw.Header().Get("Content-Type"))
+	assert.Equal(t, `<serializeUser><name>beego</name></serializeUser>`, rw.Body.String())
+
+	// q=0 excludes JSON even if */* exists
+	rw = serve("application/json;q=0, */*", user)
+	assert.Equal(t, "application/xml; charset=utf-8", rw.Header().Get("Content-Type"))
+	_, ok := NegotiateSerializer("application/*;q=0, text/html")
+	assert.False(t, ok)
+
+	rw = serve("application/json;q=0.5, application/x-yaml", user)
+	assert.Equal(t, "name: beego\n", rw.Body.String())
+
+	rw = serve("application/msgpack", user)
+	var got serializeUser
+	assert.Nil(t, msgpack.Unmarshal(rw.Body.Bytes(), &got))
+	assert.Equal(t, user, got)
+
+	// fallback to JSON
+	rw = serve("image/png", user)
+	assert.Equal(t, `{"name":"beego"}`, rw.Body.String())
+
+	rw = serve("application/x-protobuf", user)
+	assert.Equal(t, 500, rw.Code)
+}
+
+func TestRegisterSerializer(t *testing.T) {
+	RegisterSerializer("text/csv", SerializerFunc{
+		Type: "text/csv; charset=utf-8",
+		Func: func(data interface{}) ([]byte, error) {
+			return []byte("name\n" + data.(serializeUser).Name + "\n"), nil
+		},
+	})
+
+	rw := serve("text/csv", serializeUser{Name: "beego"})
+	assert.Equal(t, "text/csv; charset=utf-8", rw.Header().Get("Content-Type"))
+	assert.Equal(t, "name\nbeego\n", rw.Body.String())
+
+	_, ok := NegotiateSerializer("image/*")
+	assert.False(t, ok)
+}
@@ -0,0 +1,217 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSSEHeartbeatInterval is the interval of heartbeat comments sent by SSEWriter,
+// 0 disables the heartbeat
+var DefaultSSEHeartbeatInterval = 15 * time.Second
+
+// ErrSSEClosed is returned when writing to a closed SSEWriter
+var ErrSSEClosed = errors.New("the SSE writer is closed")
+
+// sseLineBreaks removes the line breaks from the single line fields
+var sseLineBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+// SSEWriter writes Server-Sent Events to client, each message is flushed after written.
+// usage:
+//
+//	sse := ctx.Output.SSE()
+//	defer sse.Close()
+//	for {
+//		select {
+//		case <-sse.Done():
+//			return
+//		case msg := <-messages:
+//			_ = sse.ID(msg.ID).Event("message").Data(msg)
+//		}
+//	}
+type SSEWriter struct {
+	// Context is pooled, so keep the request and the writer.
+	// The response is written through Response, so Status, Size and Flushes are recorded
+	req   *http.Request
+	rw    *Response
+	lock  sync.Mutex
+	id    string
+	event string
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// SSE sends the headers of event stream and returns the SSEWriter.
+// The heartbeat comments will be sent every DefaultSSEHeartbeatInterval,
+// so the connection won't be closed by proxies. Close should be called before the handler returns
+func (output *BeegoOutput) SSE() *SSEWriter {
+	header := output.Context.ResponseWriter.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disable the buffering of nginx
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Content-Length")
+	status := output.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	output.Context.ResponseWriter.WriteHeader(status)
+	output.Context.ResponseWriter.Flush()
+
+	w := &SSEWriter{
+		req:  output.Context.Request,
+		rw:   output.Context.ResponseWriter,
+		stop: make(chan struct{}),
+	}
+	if DefaultSSEHeartbeatInterval > 0 {
+		go w.heartbeat(DefaultSSEHeartbeatInterval)
+	}
+	return w
+}
+
+// Done returns a channel which is closed when client disconnected
+func (w *SSEWriter) Done() <-chan struct{} {
+	return w.req.Context().Done()
+}
+
+// ID sets the id of next message, CR and LF are removed so it can't break the message
+func (w *SSEWriter) ID(id string) *SSEWriter {
+	w.lock.Lock()
+	w.id = sseLineBreaks.Replace(id)
+	w.lock.Unlock()
+	return w
+}
+
+// Event sets the event name of next message, CR and LF are removed as ID does
+func (w *SSEWriter) Event(event string) *SSEWriter {
+	w.lock.Lock()
+	w.event = sseLineBreaks.Replace(event)
+	w.lock.Unlock()
+	return w
+}
+
+// Data sends a message with the id and event set before.
+// string and []byte are sent as they are, other types are encoded to JSON
+func (w *SSEWriter) Data(data interface{}) error {
+	var content []byte
+	switch d := data.(type) {
+	case string:
+		content = []byte(d)
+	case []byte:
+		content = d
+	default:
+		var err error
+		if content, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	buf := &bytes.Buffer{}
+	if w.id != "" {
+		writeSSEField(buf, "id", w.id)
+	}
+	if w.event != "" {
+		writeSSEField(buf, "event", w.event)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		writeSSEField(buf, "data", strings.TrimSuffix(line, "\r"))
+	}
+	buf.WriteByte('\n')
+	w.id, w.event = "", ""
+	return w.write(buf.Bytes())
+}
+
+// Retry tells client how long to wait before reconnecting
+func (w *SSEWriter) Retry(d time.Duration) error {
+	buf := &bytes.Buffer{}
+	writeSSEField(buf, "retry", strconv.FormatInt(d.Milliseconds(), 10))
+	buf.WriteByte('\n')
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.write(buf.Bytes())
+}
+
+// Comment sends a comment which is ignored by client
+func (w *SSEWriter) Comment(text string) error {
+	buf := &bytes.Buffer{}
+	for _, line := range strings.Split(text, "\n") {
+		buf.WriteString(": " + line + "\n")
+	}
+	buf.WriteByte('\n')
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.write(buf.Bytes())
+}
+
+// Close stops the heartbeat and the following writes will fail.
+// It doesn't close the connection
+func (w *SSEWriter) Close() {
+	w.once.Do(func() {
+		w.lock.Lock()
+		close(w.stop)
+		w.lock.Unlock()
+	})
+}
+
+// write must be invoked with lock
+func (w *SSEWriter) write(data []byte) error {
+	select {
+	case <-w.stop:
+		return ErrSSEClosed
+	default:
+	}
+	if err := w.req.Context().Err(); err != nil {
+		return err
+	}
+	if _, err := w.rw.Write(data); err != nil {
+		return err
+	}
+	w.rw.Flush()
+	return nil
+}
+
+func (w *SSEWriter) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.Done():
+			return
+		case <-ticker.C:
+			if err := w.Comment("heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeSSEField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
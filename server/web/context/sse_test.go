@@ -0,0 +1,125 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeegoOutput_SSE(t *testing.T) {
+	interval := DefaultSSEHeartbeatInterval
+	DefaultSSEHeartbeatInterval = 10 * time.Millisecond
+	defer func() {
+		DefaultSSEHeartbeatInterval = interval
+	}()
+
+	rw := httptest.NewRecorder()
+	ctx := NewContext()
+	ctx.Reset(rw, httptest.NewRequest("GET", "/events", nil))
+	sse := ctx.Output.SSE()
+	assert.Nil(t, sse.Retry(3*time.Second))
+	assert.Nil(t, sse.ID("1").Event("greeting").Data("hello\nworld"))
+	assert.Nil(t, sse.Data(map[string]int{"count": 1}))
+	time.Sleep(50 * time.Millisecond)
+	sse.Close()
+	assert.Equal(t, ErrSSEClosed, sse.Data("closed"))
+
+	assert.Equal(t, 200, rw.Code)
+	assert.True(t, rw.Flushed)
+	assert.Equal(t, "text/event-stream", rw.Header().Get("Content-Type"))
+	body := rw.Body.String()
+	assert.True(t, strings.HasPrefix(body, "retry: 3000\n\n"+
+		"id: 1\nevent: greeting\ndata: hello\ndata: world\n\n"+
+		"data: {\"count\":1}\n\n"), body)
+	assert.Contains(t, body, ": heartbeat\n\n")
+	// the response is recorded by Response
+	assert.True(t, ctx.ResponseWriter.Started)
+	assert.Equal(t, 200, ctx.ResponseWriter.Status)
+	assert.Equal(t, int64(len(body)), ctx.ResponseWriter.Size)
+	assert.True(t, ctx.ResponseWriter.Flushes > 1)
+}
+
+func TestSSEWriter_LineBreaks(t *testing.T) {
+	rw := httptest.NewRecorder()
+	ctx := NewContext()
+	ctx.Reset(rw, httptest.NewRequest("GET", "/events", nil))
+	sse := ctx.Output.SSE()
+	defer sse.Close()
+	assert.Nil(t, sse.ID("1\ndata: injected").Event("greeting\r\n\nevent: admin").Data("hello"))
+	assert.Equal(t, "id: 1data: injected\nevent: greetingevent: admin\ndata: hello\n\n", rw.Body.String())
+}
+
+func TestSSEWriter_Done(t *testing.T) {
+	c, cancel := context.WithCancel(context.Background())
+	ctx := NewContext()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/events", nil).WithContext(c))
+	sse := ctx.Output.SSE()
+	defer sse.Close()
+	cancel()
+	select {
+	case <-sse.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done is not closed")
+	}
+	assert.Equal(t, context.Canceled, sse.Data("disconnected"))
+}
+
+func TestSSEWriter_Concurrent(t *testing.T) {
+	interval := DefaultSSEHeartbeatInterval
+	DefaultSSEHeartbeatInterval = time.Millisecond
+	defer func() {
+		DefaultSSEHeartbeatInterval = interval
+	}()
+
+	rw := httptest.NewRecorder()
+	ctx := NewContext()
+	ctx.Reset(rw, httptest.NewRequest("GET", "/events", nil))
+	sse := ctx.Output.SSE()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := sse.Event("message").Data("hello"); err != nil {
+					assert.Equal(t, ErrSSEClosed, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(5 * time.Millisecond)
+		sse.Close()
+	}()
+	wg.Wait()
+	sse.Close()
+
+	assert.Equal(t, ErrSSEClosed, sse.Comment("closed"))
+	// every message is written completely
+	for _, msg := range strings.Split(strings.TrimSuffix(rw.Body.String(), "\n\n"), "\n\n") {
+		assert.Contains(t, []string{"event: message\ndata: hello", "data: hello", ": heartbeat"}, msg)
+	}
+}
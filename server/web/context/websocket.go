@@ -0,0 +1,37 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultWebSocketUpgrader is used by UpgradeWebSocket if the upgrader is nil
+var DefaultWebSocketUpgrader = &websocket.Upgrader{}
+
+// UpgradeWebSocket upgrades the HTTP connection to the WebSocket protocol.
+// If upgrader is nil, DefaultWebSocketUpgrader will be used.
+// The response is marked as started, so Beego won't write anything to it after upgrading.
+// If it fails, the upgrader has replied an HTTP error response to client.
+func (ctx *Context) UpgradeWebSocket(upgrader *websocket.Upgrader, responseHeader http.Header) (*websocket.Conn, error) {
+	if upgrader == nil {
+		upgrader = DefaultWebSocketUpgrader
+	}
+	conn, err := upgrader.Upgrade(ctx.ResponseWriter, ctx.Request, responseHeader)
+	ctx.ResponseWriter.Started = true
+	return conn, err
+}
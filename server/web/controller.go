@@ -243,12 +243,21 @@ func (c *Controller) HandlerFunc(fnname string) bool {
 // URLMapping register the internal Controller router.
 func (c *Controller) URLMapping() {}
 
-// Bind if the content type is form, we read data from form
-// otherwise, read data from request body
+// Bind binds path parameters, query, header, form and body to obj via tags, see context.Context.Bind
 func (c *Controller) Bind(obj interface{}) error {
 	return c.Ctx.Bind(obj)
 }
 
+// BindAndValidate binds the request to obj like Bind, and validates it, see context.Context.BindAndValidate
+func (c *Controller) BindAndValidate(obj interface{}) error {
+	return c.Ctx.BindAndValidate(obj)
+}
+
+// BindOrBadRequest binds the request to obj and validates it, and responds 400 Bad Request if it's failed
+func (c *Controller) BindOrBadRequest(obj interface{}) bool {
+	return c.Ctx.BindOrBadRequest(obj)
+}
+
 // BindYAML only read data from http request body
 func (c *Controller) BindYAML(obj interface{}) error {
 	return c.Ctx.BindYAML(obj)
@@ -280,10 +289,14 @@ func (c *Controller) Mapping(method string, fn func()) {
 }
 
 // Render sends the response with rendered template bytes as text/html type.
+// The template is streamed by RenderStream if WebConfig.EnableStreamRender is true
 func (c *Controller) Render() error {
 	if !c.EnableRender {
 		return nil
 	}
+	if BConfig.WebConfig.EnableStreamRender {
+		return c.RenderStream()
+	}
 	rb, err := c.RenderBytes()
 	if err != nil {
 		return err
@@ -296,6 +309,39 @@ func (c *Controller) Render() error {
 	return c.Ctx.Output.Body(rb)
 }
 
+// RenderPartial sends the fragment of page as text/html type without layout,
+// for the requests of htmx or turbo for example.
+// name is the template file, or "file#block" for the block defined by {{define "block"}} in the file,
+// or "#block" for the block in c.TplName.
+// The automatic rendering is disabled after it's called.
+// usage:
+//
+//	c.RenderPartial("user/list.tpl#rows", c.Data)
+func (c *Controller) RenderPartial(name string, data interface{}) error {
+	c.EnableRender = false
+	file, block, hasBlock := strings.Cut(name, "#")
+	if file == "" {
+		file = c.TplName
+	}
+	reloadTemplates(c.viewPath(), file)
+	c.prepareLang()
+
+	var buf bytes.Buffer
+	var err error
+	if hasBlock {
+		err = ExecuteViewPathTemplateBlock(&buf, file, block, c.viewPath(), data)
+	} else {
+		err = ExecuteViewPathTemplate(&buf, file, c.viewPath(), data)
+	}
+	if err != nil {
+		return err
+	}
+	if c.Ctx.ResponseWriter.Header().Get("Content-Type") == "" {
+		c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	}
+	return c.Ctx.Output.Body(buf.Bytes())
+}
+
 // RenderString returns the rendered template string. Do not send out response.
 func (c *Controller) RenderString() (string, error) {
 	b, e := c.RenderBytes()
@@ -310,31 +356,45 @@ func (c *Controller) RenderBytes() ([]byte, error) {
 	buf, err := c.renderTemplate()
 	// if the controller has set layout, then first get the tplName's content set the content to the layout
 	if err == nil && c.Layout != "" {
-		c.Data["LayoutContent"] = template.HTML(buf.String())
-
-		if c.LayoutSections != nil {
-			for sectionName, sectionTpl := range c.LayoutSections {
-				if sectionTpl == "" {
-					c.Data[sectionName] = ""
-					continue
-				}
-				buf.Reset()
-				err = ExecuteViewPathTemplate(&buf, sectionTpl, c.viewPath(), c.Data)
-				if err != nil {
-					return nil, err
-				}
-				c.Data[sectionName] = template.HTML(buf.String())
-			}
+		if err = c.renderLayoutData(&buf); err != nil {
+			return nil, err
 		}
-
 		buf.Reset()
 		err = ExecuteViewPathTemplate(&buf, c.Layout, c.viewPath(), c.Data)
 	}
 	return buf.Bytes(), err
 }
 
+// renderLayoutData sets the rendered content and layout sections to c.Data for the layout
+func (c *Controller) renderLayoutData(buf *bytes.Buffer) error {
+	c.Data["LayoutContent"] = template.HTML(buf.String())
+
+	if c.LayoutSections != nil {
+		for sectionName, sectionTpl := range c.LayoutSections {
+			if sectionTpl == "" {
+				c.Data[sectionName] = ""
+				continue
+			}
+			buf.Reset()
+			err := ExecuteViewPathTemplate(buf, sectionTpl, c.viewPath(), c.Data)
+			if err != nil {
+				return err
+			}
+			c.Data[sectionName] = template.HTML(buf.String())
+		}
+	}
+	return nil
+}
+
 func (c *Controller) renderTemplate() (bytes.Buffer, error) {
 	var buf bytes.Buffer
+	c.prepareTemplate()
+	return buf, ExecuteViewPathTemplate(&buf, c.TplName, c.viewPath(), c.Data)
+}
+
+// prepareTemplate fills the default TplName and the Lang of i18n, and reloads the modified templates in dev mode
+func (c *Controller) prepareTemplate() {
+	c.prepareLang()
 	if c.TplName == "" {
 		c.TplName = strings.ToLower(c.controllerName) + "/" + strings.ToLower(c.actionName) + "." + c.TplExt
 	}
@@ -354,9 +414,8 @@ func (c *Controller) renderTemplate() (bytes.Buffer, error) {
 				}
 			}
 		}
-		BuildTemplate(c.viewPath(), buildFiles...)
+		reloadTemplates(c.viewPath(), buildFiles...)
 	}
-	return buf, ExecuteViewPathTemplate(&buf, c.TplName, c.viewPath(), c.Data)
 }
 
 func (c *Controller) viewPath() string {
@@ -735,16 +794,40 @@ func (c *Controller) DelSession(name interface{}) error {
 	return c.CruSession.Delete(context2.Background(), name)
 }
 
-// SessionRegenerateID regenerates session id for this session.
+// SessionFlash adds value to the flash messages of key in session,
+// the messages are read once by SessionFlashes, even in the API controllers and after the redirects.
+func (c *Controller) SessionFlash(key string, value interface{}) error {
+	return session.Flash(c.Ctx.Request.Context(), c.StartSession(), key, value)
+}
+
+// SessionFlashes returns and removes the flash messages of key in session.
+func (c *Controller) SessionFlashes(key string) []interface{} {
+	return session.GetFlashes(c.Ctx.Request.Context(), c.StartSession(), key)
+}
+
+// BindSession fills the fields of the struct obj points to by the values in session,
+// the key of the field is its `session` tag, see session.BindSession.
+func (c *Controller) BindSession(obj interface{}) error {
+	return session.BindSession(c.Ctx.Request.Context(), c.StartSession(), obj)
+}
+
+// SessionRegenerateID regenerates session id for this session, and the old id is invalidated.
 // the session data have no changes.
+// It should be called after login to prevent session fixation.
 func (c *Controller) SessionRegenerateID() error {
-	if c.CruSession != nil {
-		c.CruSession.SessionRelease(context2.Background(), c.Ctx.ResponseWriter)
+	store, err := GlobalSessions.RegenerateID(c.Ctx.Request.Context(), c.Ctx.ResponseWriter, c.Ctx.Request, c.StartSession())
+	if err != nil {
+		return err
 	}
-	var err error
-	c.CruSession, err = GlobalSessions.SessionRegenerateID(c.Ctx.ResponseWriter, c.Ctx.Request)
-	c.Ctx.Input.CruSession = c.CruSession
-	return err
+	c.CruSession = store
+	c.Ctx.Input.CruSession = store
+	return nil
+}
+
+// SessionLifetime returns the expiration of the current session,
+// it can be used to warn the user before the session expires.
+func (c *Controller) SessionLifetime() session.Lifetime {
+	return GlobalSessions.SessionLifetime(c.Ctx.Request.Context(), c.StartSession())
 }
 
 // DestroySession cleans session data and session cookie.
@@ -763,6 +846,11 @@ func (c *Controller) IsAjax() bool {
 	return c.Ctx.Input.IsAjax()
 }
 
+// SetCookie sets the cookie with the options, such as SameSite and Partitioned, see context.CookieOptions.
+func (c *Controller) SetCookie(name, value string, opts context.CookieOptions) error {
+	return c.Ctx.Output.SetCookie(name, value, opts)
+}
+
 // GetSecureCookie returns decoded cookie value from encoded browser cookie values.
 func (c *Controller) GetSecureCookie(Secret, key string) (string, bool) {
 	return c.Ctx.GetSecureCookie(Secret, key)
@@ -773,6 +861,16 @@ func (c *Controller) SetSecureCookie(Secret, name, value string, others ...inter
 	c.Ctx.SetSecureCookie(Secret, name, value, others...)
 }
 
+// GetEncodedCookie decodes the cookie encoded by codec into dst, see context.CookieCodec.
+func (c *Controller) GetEncodedCookie(codec *context.CookieCodec, name string, dst interface{}) error {
+	return c.Ctx.GetEncodedCookie(codec, name, dst)
+}
+
+// SetEncodedCookie encodes the value by codec and puts it into cookie, maxAge is in seconds.
+func (c *Controller) SetEncodedCookie(codec *context.CookieCodec, name string, value interface{}, maxAge int64, others ...interface{}) error {
+	return c.Ctx.SetEncodedCookie(codec, name, value, maxAge, others...)
+}
+
 // XSRFToken creates a CSRF token string and returns.
 func (c *Controller) XSRFToken() string {
 	if c._xsrfToken == "" {
@@ -780,7 +878,13 @@ func (c *Controller) XSRFToken() string {
 		if c.XSRFExpire > 0 {
 			expire = int64(c.XSRFExpire)
 		}
-		c._xsrfToken = c.Ctx.XSRFToken(BConfig.WebConfig.XSRFKey, expire)
+		c._xsrfToken = c.Ctx.XSRFTokenWithOptions(&context.XSRFOptions{
+			Key:          BConfig.WebConfig.XSRFKey,
+			Expire:       expire,
+			SameSite:     BConfig.WebConfig.XSRFSameSite,
+			DoubleSubmit: BConfig.WebConfig.XSRFDoubleSubmit,
+			Header:       BConfig.WebConfig.XSRFHeader,
+		})
 	}
 	return c._xsrfToken
 }
@@ -0,0 +1,73 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+// CtxKey is the typed key of request-scoped value.
+// Each key created by NewCtxKey is unique, so the values set by different packages never collide
+// even if their names are the same. The values are stored in ctx.Input data,
+// and they are cleaned up when the context is reset for the next request
+// usage:
+//
+//	var userKey = web.NewCtxKey[*User]("user")
+//
+//	// in filter
+//	web.SetCtxValue(ctx, userKey, user)
+//	// in controller
+//	user, ok := web.GetCtxValue(c.Ctx, userKey)
+type CtxKey[T any] struct {
+	name string
+}
+
+// NewCtxKey creates the key, the name is only used for debugging
+func NewCtxKey[T any](name string) *CtxKey[T] {
+	return &CtxKey[T]{name: name}
+}
+
+// String returns the name of key
+func (k *CtxKey[T]) String() string {
+	return k.name
+}
+
+// SetCtxValue stores the value with the key in the context
+func SetCtxValue[T any](ctx *beecontext.Context, key *CtxKey[T], value T) {
+	ctx.Input.SetData(key, value)
+}
+
+// GetCtxValue returns the value of the key, ok is false if the value doesn't exist
+func GetCtxValue[T any](ctx *beecontext.Context, key *CtxKey[T]) (value T, ok bool) {
+	v, ok := ctx.Input.LookupData(key)
+	if !ok {
+		return value, false
+	}
+	value, ok = v.(T)
+	return value, ok
+}
+
+// CtxValueOr returns the value of the key, or def if the value doesn't exist
+func CtxValueOr[T any](ctx *beecontext.Context, key *CtxKey[T], def T) T {
+	if v, ok := GetCtxValue(ctx, key); ok {
+		return v
+	}
+	return def
+}
+
+// DeleteCtxValue removes the value of the key from the context
+func DeleteCtxValue[T any](ctx *beecontext.Context, key *CtxKey[T]) {
+	ctx.Input.DeleteData(key)
+}
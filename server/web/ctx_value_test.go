@@ -0,0 +1,62 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+type ctxUser struct {
+	Name string
+}
+
+func TestCtxValue(t *testing.T) {
+	userKey := NewCtxKey[*ctxUser]("user")
+	otherKey := NewCtxKey[*ctxUser]("user")
+	countKey := NewCtxKey[int]("count")
+	assert.Equal(t, "user", userKey.String())
+
+	ctx := context.NewContext()
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	_, ok := GetCtxValue(ctx, userKey)
+	assert.False(t, ok)
+	assert.Equal(t, 10, CtxValueOr(ctx, countKey, 10))
+
+	SetCtxValue(ctx, userKey, &ctxUser{Name: "beego"})
+	SetCtxValue(ctx, countKey, 0)
+	user, ok := GetCtxValue(ctx, userKey)
+	assert.True(t, ok)
+	assert.Equal(t, "beego", user.Name)
+	// the keys with the same name don't collide
+	_, ok = GetCtxValue(ctx, otherKey)
+	assert.False(t, ok)
+	// zero value is different from absence
+	assert.Equal(t, 0, CtxValueOr(ctx, countKey, 10))
+
+	DeleteCtxValue(ctx, countKey)
+	assert.Equal(t, 10, CtxValueOr(ctx, countKey, 10))
+
+	// cleaned up for the next request
+	ctx.Reset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	_, ok = GetCtxValue(ctx, userKey)
+	assert.False(t, ok)
+}
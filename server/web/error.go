@@ -374,6 +374,20 @@ func payloadTooLarge(rw http.ResponseWriter, r *http.Request) {
 	)
 }
 
+// show 415 Unsupported Media Type
+func unsupportedMediaType(rw http.ResponseWriter, r *http.Request) {
+	responseError(rw, r,
+		415,
+		`<br>The page you have requested is unavailable.
+		 <br>Perhaps you are here because:<br><br>
+		 <ul>
+			<br>The media type of request entity is not supported by server.
+			<br>Please change the request entity and try again.
+		 </ul>
+		`,
+	)
+}
+
 func responseError(rw http.ResponseWriter, r *http.Request, errCode int, errContent string) {
 	t, _ := template.New("beegoerrortemp").Parse(errtpl)
 	data := M{
@@ -385,6 +399,7 @@ func responseError(rw http.ResponseWriter, r *http.Request, errCode int, errCont
 }
 
 // ErrorHandler registers http.HandlerFunc to each http err code string.
+// It's ignored if the ErrorRenderer is set by SetErrorRenderer.
 // usage:
 //
 //	beego.ErrorHandler("404",NotFound)
@@ -439,6 +454,11 @@ func exception(errCode string, ctx *context.Context) {
 		return ctx.Output.Status
 	}
 
+	if errorRenderer != nil {
+		errorRenderer(ctx, NewHTTPError(atoi(errCode), nil, ""))
+		return
+	}
+
 	for _, ec := range []string{errCode, "503", "500"} {
 		if h, ok := ErrorMaps[ec]; ok {
 			executeError(h, ctx, atoi(ec))
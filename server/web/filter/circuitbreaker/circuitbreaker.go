@@ -0,0 +1,211 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package circuitbreaker provides a filter chain which stops calling the handler
+// if it keeps failing, for example, the backend which it proxies to is down.
+// usage:
+//
+//	web.InsertFilterChain("/api/payment/*", circuitbreaker.NewFilterChain(
+//		circuitbreaker.WithFailureRatio(0.5),
+//		circuitbreaker.WithCooldown(30*time.Second)))
+//
+// The breaker is closed at first, and it's open if the failure ratio in the window exceeds the threshold.
+// The requests are rejected with 503 when it's open, and it's half-open after the cooldown.
+// If the trial requests in half-open state succeed, it's closed again, otherwise it's open again.
+// The breakers are core/circuitbreaker.Breaker, which are shared with the httplib filter.
+package circuitbreaker
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	cb "github.com/beego/beego/v2/core/circuitbreaker"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// State is the state of breaker
+type State = cb.State
+
+const (
+	// StateClosed means that the requests are allowed
+	StateClosed = cb.StateClosed
+	// StateOpen means that the requests are rejected
+	StateOpen = cb.StateOpen
+	// StateHalfOpen means that a few requests are allowed to check whether the handler recovers
+	StateHalfOpen = cb.StateHalfOpen
+)
+
+// Option is constructor option
+type Option func(f *filter)
+
+type filter struct {
+	settings      cb.Settings
+	maxKeys       int
+	slowThreshold time.Duration
+	isFailure     func(ctx *context.Context) bool
+	fallback      web.FilterFunc
+	key           func(ctx *context.Context) string
+
+	breakers *cb.Group
+}
+
+// NewFilterChain returns FilterChain which opens the breaker if the failure ratio exceeds 50%
+// in the window of 10 seconds and there are 20 requests at least, and half-opens it after 30 seconds.
+// The response whose status code is 5xx is regarded as failure.
+func NewFilterChain(opts ...Option) web.FilterChain {
+	f := &filter{
+		settings: cb.Settings{
+			FailureRatio:     0.5,
+			MinRequests:      20,
+			Window:           10 * time.Second,
+			Cooldown:         30 * time.Second,
+			HalfOpenRequests: 1,
+		},
+		maxKeys:   1024,
+		isFailure: isServerError,
+		key: func(ctx *context.Context) string {
+			return ""
+		},
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	f.breakers = cb.NewGroup(&f.settings, f.maxKeys)
+	return f.chain
+}
+
+// WithFailureRatio sets the failure ratio which opens the breaker, the range is (0, 1]
+func WithFailureRatio(ratio float64) Option {
+	return func(f *filter) {
+		f.settings.FailureRatio = ratio
+	}
+}
+
+// WithMinRequests sets the minimum number of requests in the window before the breaker can be open
+func WithMinRequests(n uint) Option {
+	return func(f *filter) {
+		f.settings.MinRequests = n
+	}
+}
+
+// WithWindow sets the window in which the requests and failures are counted
+func WithWindow(window time.Duration) Option {
+	return func(f *filter) {
+		f.settings.Window = window
+	}
+}
+
+// WithConsecutiveFailures opens the breaker after n consecutive failures, it's disabled by default
+func WithConsecutiveFailures(n uint) Option {
+	return func(f *filter) {
+		f.settings.ConsecutiveFailures = n
+	}
+}
+
+// WithCooldown sets how long the breaker keeps open before it's half-open
+func WithCooldown(cooldown time.Duration) Option {
+	return func(f *filter) {
+		f.settings.Cooldown = cooldown
+	}
+}
+
+// WithHalfOpenRequests sets the number of trial requests in half-open state,
+// the breaker is closed if all of them succeed
+func WithHalfOpenRequests(n uint) Option {
+	return func(f *filter) {
+		if n > 0 {
+			f.settings.HalfOpenRequests = n
+		}
+	}
+}
+
+// WithSlowThreshold regards the request which takes longer than threshold as failure
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(f *filter) {
+		f.slowThreshold = threshold
+	}
+}
+
+// WithFailureFunc sets the function which checks whether the request failed after the handler returns
+func WithFailureFunc(isFailure func(ctx *context.Context) bool) Option {
+	return func(f *filter) {
+		f.isFailure = isFailure
+	}
+}
+
+// WithFallback sets the handler for the requests rejected by the open breaker,
+// it responds 503 by default
+func WithFallback(fallback web.FilterFunc) Option {
+	return func(f *filter) {
+		f.fallback = fallback
+	}
+}
+
+// WithKeyFunc uses a breaker for each key, for example, the host of backend.
+// All the requests share one breaker by default
+func WithKeyFunc(key func(ctx *context.Context) string) Option {
+	return func(f *filter) {
+		f.key = key
+	}
+}
+
+// WithMaxKeys sets the maximum number of the breakers kept for the keys, 1024 by default.
+// The idle closed breakers are evicted first if there are more keys, 0 means no limit
+func WithMaxKeys(n int) Option {
+	return func(f *filter) {
+		f.maxKeys = n
+	}
+}
+
+// WithStateChange sets the callback which is invoked when the state of breaker changes
+func WithStateChange(onStateChange func(key string, from, to State)) Option {
+	return func(f *filter) {
+		f.settings.OnStateChange = onStateChange
+	}
+}
+
+func isServerError(ctx *context.Context) bool {
+	return ctx.ResponseWriter.Status >= http.StatusInternalServerError
+}
+
+func (f *filter) chain(next web.FilterFunc) web.FilterFunc {
+	return func(ctx *context.Context) {
+		b := f.breakers.Get(f.key(ctx))
+		if ok, retryAfter := b.Allow(); !ok {
+			f.reject(ctx, retryAfter)
+			return
+		}
+
+		start := time.Now()
+		success := false
+		defer func() {
+			b.Done(success)
+		}()
+		next(ctx)
+		success = !f.isFailure(ctx) && (f.slowThreshold <= 0 || time.Since(start) <= f.slowThreshold)
+	}
+}
+
+func (f *filter) reject(ctx *context.Context, retryAfter time.Duration) {
+	if f.fallback != nil {
+		f.fallback(ctx)
+		return
+	}
+	ctx.Output.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+	ctx.Output.SetStatus(http.StatusServiceUnavailable)
+	_ = ctx.Output.Body([]byte(http.StatusText(http.StatusServiceUnavailable)))
+}
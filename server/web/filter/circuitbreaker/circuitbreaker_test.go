@@ -0,0 +1,123 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func TestNewFilterChain(t *testing.T) {
+	var states []State
+	status := http.StatusBadGateway
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", NewFilterChain(WithMinRequests(2), WithCooldown(50*time.Millisecond),
+		WithStateChange(func(key string, from, to State) {
+			states = append(states, to)
+		})))
+	handler.Get("/proxy", func(ctx *context.Context) {
+		ctx.Output.SetStatus(status)
+		_ = ctx.Output.Body([]byte(http.StatusText(status)))
+	})
+	handler.Init()
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/proxy", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadGateway, serve().Code)
+	assert.Equal(t, http.StatusBadGateway, serve().Code)
+	w := serve()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, []State{StateOpen}, states)
+
+	// the trial request fails
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, http.StatusBadGateway, serve().Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve().Code)
+	assert.Equal(t, []State{StateOpen, StateHalfOpen, StateOpen}, states)
+
+	// the trial request succeeds
+	status = http.StatusOK
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, serve().Code)
+	assert.Equal(t, http.StatusOK, serve().Code)
+	assert.Equal(t, []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}, states)
+}
+
+func TestNewFilterChainOptions(t *testing.T) {
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", NewFilterChain(WithMinRequests(1), WithSlowThreshold(10*time.Millisecond),
+		WithKeyFunc(func(ctx *context.Context) string {
+			return ctx.Input.Query("backend")
+		}),
+		WithFallback(func(ctx *context.Context) {
+			_ = ctx.Output.Body([]byte("fallback"))
+		})))
+	handler.Get("/slow", func(ctx *context.Context) {
+		time.Sleep(20 * time.Millisecond)
+		_ = ctx.Output.Body([]byte("slow"))
+	})
+	handler.Init()
+
+	serve := func(url string) string {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "slow", serve("/slow?backend=a"))
+	assert.Equal(t, "fallback", serve("/slow?backend=a"))
+	assert.Equal(t, "slow", serve("/slow?backend=b"))
+}
+
+func TestNewFilterChainStateChangeReentrant(t *testing.T) {
+	handler := web.NewControllerRegister()
+	var probed string
+	handler.InsertFilterChain("/*", NewFilterChain(WithMinRequests(1),
+		WithStateChange(func(key string, from, to State) {
+			// the callback calls the breaker again, it mustn't deadlock
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/fail", nil))
+			probed = w.Body.String()
+		})))
+	handler.Get("/fail", func(ctx *context.Context) {
+		ctx.Output.SetStatus(http.StatusInternalServerError)
+		_ = ctx.Output.Body([]byte("fail"))
+	})
+	handler.Init()
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("onStateChange deadlocked")
+	}
+	assert.Equal(t, http.StatusText(http.StatusServiceUnavailable), probed)
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minRefreshInterval limits the refreshing caused by unknown key ID
+const minRefreshInterval = time.Minute
+
+// JWK is the JSON Web Key defined in RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// symmetric
+	K string `json:"k"`
+}
+
+// Key returns *rsa.PublicKey, *ecdsa.PublicKey or []byte according to the key type
+func (k *JWK) Key() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// KeySet fetches the keys from JWKS endpoint and caches them.
+// The keys are refreshed after the ttl, or when the key ID is unknown because of key rotation.
+// The fetch doesn't hold the lock, and the concurrent refreshes share one fetch.
+type KeySet struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+	group  singleflight.Group
+
+	lock      sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewKeySet creates KeySet which fetches the keys from url
+func NewKeySet(url string, ttl time.Duration) *KeySet {
+	return &KeySet{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Key returns the public key whose key ID is kid
+func (j *KeySet) Key(kid string) (interface{}, error) {
+	j.lock.Lock()
+	keys, fetchedAt := j.keys, j.fetchedAt
+	j.lock.Unlock()
+	elapsed := time.Since(fetchedAt)
+	if keys == nil || elapsed >= j.ttl {
+		fresh, err := j.refresh()
+		if err != nil && keys == nil {
+			return nil, err
+		}
+		if err == nil {
+			keys = fresh
+		}
+		elapsed = 0
+	}
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	// the key may be rotated
+	if elapsed >= minRefreshInterval {
+		fresh, err := j.refresh()
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := fresh[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %q not found in JWKS", kid)
+}
+
+// refresh fetches the keys and swaps them in, the old keys are kept if it fails
+func (j *KeySet) refresh() (map[string]interface{}, error) {
+	keys, err, _ := j.group.Do("", func() (interface{}, error) {
+		j.lock.Lock()
+		j.fetchedAt = time.Now()
+		j.lock.Unlock()
+		keys, err := j.fetch()
+		if err != nil {
+			return nil, err
+		}
+		j.lock.Lock()
+		j.keys = keys
+		j.lock.Unlock()
+		return keys, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys.(map[string]interface{}), nil
+}
+
+func (j *KeySet) fetch() (map[string]interface{}, error) {
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS failed, status: %d", resp.StatusCode)
+	}
+	var set struct {
+		Keys []*JWK `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.Key()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no valid key in JWKS")
+	}
+	return keys, nil
+}
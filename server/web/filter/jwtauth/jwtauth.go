@@ -0,0 +1,255 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwtauth provides a filter chain which authenticates the requests by Bearer JWT.
+// usage:
+//
+//	web.InsertFilterChain("/api/*", jwtauth.NewFilterChain(
+//		jwtauth.WithJWKS("https://example.com/.well-known/jwks.json", time.Hour),
+//		jwtauth.WithIssuer("https://example.com/"),
+//		jwtauth.WithAudience("my-api"),
+//		jwtauth.WithExcludePaths("/api/login", "/api/public/**")))
+//
+// The verified claims are available in the controllers:
+//
+//	claims, ok := jwtauth.GetClaims(c.Ctx)
+package jwtauth
+
+import (
+	context2 "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// claimsKey is the key of claims in the Input data
+const claimsKey = "JWTClaims"
+
+type claimsCtxKey struct{}
+
+// Option is constructor option
+type Option func(f *filter)
+
+type filter struct {
+	secret     []byte
+	publicKeys map[string]interface{}
+	jwks       *KeySet
+	algorithms []string
+	issuer     string
+	audience   string
+	leeway     time.Duration
+	excludes   []string
+	extract    func(ctx *context.Context) string
+	onError    func(ctx *context.Context, err error)
+}
+
+// NewFilterChain returns FilterChain which rejects the requests without valid Bearer JWT.
+// The token must have exp claim, and it's signed by HS256, RS256 or ES256 by default.
+func NewFilterChain(opts ...Option) web.FilterChain {
+	f := &filter{
+		publicKeys: make(map[string]interface{}),
+		algorithms: []string{"HS256", "RS256", "ES256"},
+		extract:    bearerToken,
+		onError:    unauthorized,
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f.chain
+}
+
+// WithSecret sets the secret of HMAC algorithms
+func WithSecret(secret []byte) Option {
+	return func(f *filter) {
+		f.secret = secret
+	}
+}
+
+// WithPublicKey adds the public key of RSA or ECDSA algorithms,
+// kid is the key ID in the header of token, and the key whose kid is empty matches any token
+func WithPublicKey(kid string, key interface{}) Option {
+	return func(f *filter) {
+		f.publicKeys[kid] = key
+	}
+}
+
+// WithJWKS fetches the keys from JWKS endpoint and refreshes them every ttl.
+// The keys are also refreshed if the key ID is unknown, so the rotated keys are fetched in time
+func WithJWKS(url string, ttl time.Duration) Option {
+	return func(f *filter) {
+		f.jwks = NewKeySet(url, ttl)
+	}
+}
+
+// WithAlgorithms sets the allowed algorithms, such as HS256, RS384 and ES512
+func WithAlgorithms(algorithms ...string) Option {
+	return func(f *filter) {
+		f.algorithms = algorithms
+	}
+}
+
+// WithIssuer requires the iss claim to be issuer
+func WithIssuer(issuer string) Option {
+	return func(f *filter) {
+		f.issuer = issuer
+	}
+}
+
+// WithAudience requires the aud claim to contain audience
+func WithAudience(audience string) Option {
+	return func(f *filter) {
+		f.audience = audience
+	}
+}
+
+// WithLeeway sets the leeway of exp, nbf and iat claims to tolerate the clock skew
+func WithLeeway(leeway time.Duration) Option {
+	return func(f *filter) {
+		f.leeway = leeway
+	}
+}
+
+// WithExcludePaths skips the authentication of the paths.
+// The patterns use the syntax of path.Match, and the pattern ending with "/**" matches all the sub paths
+func WithExcludePaths(patterns ...string) Option {
+	return func(f *filter) {
+		f.excludes = append(f.excludes, patterns...)
+	}
+}
+
+// WithTokenExtractor sets the function which gets the token from request,
+// it's from the Authorization header by default
+func WithTokenExtractor(extract func(ctx *context.Context) string) Option {
+	return func(f *filter) {
+		f.extract = extract
+	}
+}
+
+// WithErrorHandler sets the handler of the requests failed to authenticate,
+// it responds 401 by default
+func WithErrorHandler(onError func(ctx *context.Context, err error)) Option {
+	return func(f *filter) {
+		f.onError = onError
+	}
+}
+
+// GetClaims returns the verified claims of the request
+func GetClaims(ctx *context.Context) (jwt.MapClaims, bool) {
+	claims, ok := ctx.Input.GetData(claimsKey).(jwt.MapClaims)
+	return claims, ok
+}
+
+// ClaimsFromContext returns the verified claims stored in the context of request,
+// it's used when only the context.Context is passed to the services
+func ClaimsFromContext(c context2.Context) (jwt.MapClaims, bool) {
+	claims, ok := c.Value(claimsCtxKey{}).(jwt.MapClaims)
+	return claims, ok
+}
+
+func (f *filter) chain(next web.FilterFunc) web.FilterFunc {
+	parser := f.parser()
+	return func(ctx *context.Context) {
+		if f.excluded(ctx.Request.URL.Path) {
+			next(ctx)
+			return
+		}
+		raw := f.extract(ctx)
+		if raw == "" {
+			f.onError(ctx, errors.New("missing token"))
+			return
+		}
+		claims := jwt.MapClaims{}
+		if _, err := parser.ParseWithClaims(raw, claims, f.key); err != nil {
+			f.onError(ctx, err)
+			return
+		}
+		ctx.Input.SetData(claimsKey, claims)
+		ctx.Request = ctx.Request.WithContext(withClaims(ctx.Request.Context(), claims))
+		next(ctx)
+	}
+}
+
+func withClaims(c context2.Context, claims jwt.MapClaims) context2.Context {
+	return context2.WithValue(c, claimsCtxKey{}, claims)
+}
+
+func (f *filter) parser() *jwt.Parser {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(f.algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(f.leeway),
+	}
+	if f.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(f.issuer))
+	}
+	if f.audience != "" {
+		opts = append(opts, jwt.WithAudience(f.audience))
+	}
+	return jwt.NewParser(opts...)
+}
+
+// key returns the key to verify the token according to the algorithm and key ID
+func (f *filter) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && f.secret != nil {
+		return f.secret, nil
+	}
+	if key, ok := f.publicKeys[kid]; ok {
+		return key, nil
+	}
+	if key, ok := f.publicKeys[""]; ok {
+		return key, nil
+	}
+	if f.jwks != nil {
+		return f.jwks.Key(kid)
+	}
+	return nil, fmt.Errorf("no key for algorithm %s", token.Method.Alg())
+}
+
+func (f *filter) excluded(p string) bool {
+	for _, pattern := range f.excludes {
+		if prefix := strings.TrimSuffix(pattern, "/**"); prefix != pattern {
+			if p == prefix || strings.HasPrefix(p, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func bearerToken(ctx *context.Context) string {
+	auth := ctx.Input.Header("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+func unauthorized(ctx *context.Context, err error) {
+	ctx.Output.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	ctx.Output.SetStatus(http.StatusUnauthorized)
+	_ = ctx.Output.Body([]byte(http.StatusText(http.StatusUnauthorized)))
+}
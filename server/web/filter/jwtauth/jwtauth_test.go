@@ -0,0 +1,223 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwtauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func newHandler(opts ...Option) *web.ControllerRegister {
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", NewFilterChain(opts...))
+	handler.Get("/*", func(ctx *context.Context) {
+		sub := ""
+		if claims, ok := GetClaims(ctx); ok {
+			sub, _ = claims["sub"].(string)
+		}
+		_ = ctx.Output.Body([]byte(sub))
+	})
+	handler.Init()
+	return handler
+}
+
+func serve(handler http.Handler, url, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", url, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestFilterChainHMAC(t *testing.T) {
+	secret := []byte("secret")
+	handler := newHandler(WithSecret(secret), WithIssuer("beego"), WithAudience("api"),
+		WithExcludePaths("/public/**"))
+	sign := func(claims jwt.MapClaims) string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		assert.Nil(t, err)
+		return s
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	w := serve(handler, "/user", sign(jwt.MapClaims{"sub": "tom", "iss": "beego", "aud": "api", "exp": exp}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "tom", w.Body.String())
+
+	w = serve(handler, "/user", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+
+	// invalid claims
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, "/user",
+		sign(jwt.MapClaims{"sub": "tom", "iss": "other", "aud": "api", "exp": exp})).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, "/user",
+		sign(jwt.MapClaims{"sub": "tom", "iss": "beego", "aud": "other", "exp": exp})).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, "/user",
+		sign(jwt.MapClaims{"sub": "tom", "iss": "beego", "aud": "api", "exp": time.Now().Add(-time.Hour).Unix()})).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, "/user",
+		sign(jwt.MapClaims{"sub": "tom", "iss": "beego", "aud": "api"})).Code)
+
+	assert.Equal(t, http.StatusOK, serve(handler, "/public/css/app.css", "").Code)
+}
+
+func TestFilterChainJWKS(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	keys := map[string]*rsa.PrivateKey{"old": oldKey}
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		set := map[string][]map[string]string{}
+		for kid, k := range keys {
+			set["keys"] = append(set["keys"], map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	defer server.Close()
+
+	handler := newHandler(WithJWKS(server.URL, time.Hour), WithAlgorithms("RS256"))
+	sign := func(kid string, key *rsa.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"sub": kid,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = kid
+		s, err := token.SignedString(key)
+		assert.Nil(t, err)
+		return s
+	}
+
+	w := serve(handler, "/user", sign("old", oldKey))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "old", w.Body.String())
+	assert.Equal(t, 1, fetches)
+
+	// the algorithm is not allowed
+	hs, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, "/user", hs).Code)
+
+	// the key is rotated, but the last fetch is too recent
+	keys["new"] = newKey
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, "/user", sign("new", newKey)).Code)
+	assert.Equal(t, 1, fetches)
+}
+
+func TestJWKSRotation(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	kid := "old"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]map[string]string{"keys": {{
+			"kty": "RSA",
+			"kid": kid,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	j := NewKeySet(server.URL, time.Hour)
+	_, err = j.Key("old")
+	assert.Nil(t, err)
+
+	kid = "new"
+	j.fetchedAt = time.Now().Add(-minRefreshInterval)
+	k, err := j.Key("new")
+	assert.Nil(t, err)
+	assert.Equal(t, key.N, k.(*rsa.PublicKey).N)
+}
+
+func TestKeySetRefreshWithoutLock(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]map[string]string{"keys": {{
+			"kty": "RSA",
+			"kid": "old",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	j := NewKeySet(server.URL, time.Hour)
+	_, err = j.Key("old")
+	assert.Nil(t, err)
+
+	// the refreshes caused by the unknown key share one fetch
+	j.fetchedAt = time.Now().Add(-minRefreshInterval)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := j.Key("unknown")
+			assert.NotNil(t, err)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// the cached key is returned while the fetch is in flight
+	done := make(chan struct{})
+	go func() {
+		_, err := j.Key("old")
+		assert.Nil(t, err)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		close(release)
+		t.Fatal("Key is blocked by the fetch")
+	}
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), fetches.Load())
+}
@@ -0,0 +1,458 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oidc provides the OpenID Connect login with authorization code flow and PKCE.
+// The session must be enabled, since the login state and the user are stored in the session.
+// usage:
+//
+//	client, err := oidc.NewClient(context.Background(), oidc.Config{
+//		Issuer:       "https://accounts.example.com",
+//		ClientID:     "admin",
+//		ClientSecret: "secret",
+//		RedirectURL:  "https://admin.example.com/oidc/callback",
+//	})
+//	web.Get("/oidc/login", client.Login)
+//	web.Get("/oidc/callback", client.Callback)
+//	web.Get("/oidc/logout", client.Logout)
+//	web.InsertFilter("/admin/*", web.BeforeRouter, client.RequireLogin())
+//
+// The user is available in the controllers:
+//
+//	principal, ok := oidc.GetPrincipal(c.Ctx)
+package oidc
+
+import (
+	context2 "context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+	"github.com/beego/beego/v2/server/web/filter/jwtauth"
+)
+
+const (
+	// principalKey is the session key of the user
+	principalKey = "oidc.principal"
+	// authRequestKey is the session key of the login in progress
+	authRequestKey = "oidc.auth_request"
+)
+
+func init() {
+	// the session providers except memory encode the values by gob
+	gob.Register(&Principal{})
+	gob.Register(&authRequest{})
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// Config is the configuration of OIDC client
+type Config struct {
+	// Issuer is the URL of provider, the endpoints are discovered from {Issuer}/.well-known/openid-configuration
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the URL of Callback handler registered in the provider
+	RedirectURL string
+	// Scopes is "openid profile email" by default
+	Scopes []string
+	// PostLoginURL is the page after login if the user doesn't come from a protected page, it's "/" by default
+	PostLoginURL string
+	// PostLogoutURL is the page after logout, it's "/" by default
+	PostLogoutURL string
+	// LoginURL is the path of Login handler which RequireLogin redirects to, it's "/oidc/login" by default
+	LoginURL string
+	// HTTPClient is used to call the provider, http.DefaultClient is used if it's nil
+	HTTPClient *http.Client
+}
+
+// Principal is the authenticated user.
+// It expires with the access token, and RequireLogin refreshes it by the refresh token if it exists.
+type Principal struct {
+	Subject      string
+	Email        string
+	Name         string
+	Claims       map[string]interface{}
+	IDToken      string
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// authRequest is the state of login in progress
+type authRequest struct {
+	State    string
+	Nonce    string
+	Verifier string
+	ReturnTo string
+}
+
+// Client is the OIDC client, it's safe for concurrent use
+type Client struct {
+	cfg      Config
+	endpoint endpoint
+	keys     *jwtauth.KeySet
+	parser   *jwt.Parser
+}
+
+// endpoint is the provider metadata
+type endpoint struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Error        string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+// NewClient discovers the endpoints of provider and creates the client
+func NewClient(ctx context2.Context, cfg Config) (*Client, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	if cfg.PostLoginURL == "" {
+		cfg.PostLoginURL = "/"
+	}
+	if cfg.PostLogoutURL == "" {
+		cfg.PostLogoutURL = "/"
+	}
+	if cfg.LoginURL == "" {
+		cfg.LoginURL = "/oidc/login"
+	}
+
+	discovery := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: discovery failed, status: %d", resp.StatusCode)
+	}
+	c := &Client{cfg: cfg}
+	if err = json.NewDecoder(resp.Body).Decode(&c.endpoint); err != nil {
+		return nil, err
+	}
+	if c.endpoint.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch, expect %s but got %s", cfg.Issuer, c.endpoint.Issuer)
+	}
+	c.keys = jwtauth.NewKeySet(c.endpoint.JWKSURI, time.Hour)
+	c.parser = jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithAudience(cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute))
+	return c, nil
+}
+
+// GetPrincipal returns the user who has logged in, it returns false if the principal has expired
+func GetPrincipal(ctx *context.Context) (*Principal, bool) {
+	p, ok := sessionPrincipal(ctx)
+	if !ok || p.expired() {
+		return nil, false
+	}
+	return p, true
+}
+
+func sessionPrincipal(ctx *context.Context) (*Principal, bool) {
+	if ctx.Input.CruSession == nil {
+		return nil, false
+	}
+	p, ok := ctx.Input.Session(principalKey).(*Principal)
+	return p, ok
+}
+
+func (p *Principal) expired() bool {
+	return !p.Expiry.IsZero() && time.Now().After(p.Expiry)
+}
+
+// Login redirects the user to the provider,
+// the user is redirected to the return_to parameter after login if it's a local path
+func (c *Client) Login(ctx *context.Context) {
+	if ctx.Input.CruSession == nil {
+		c.fail(ctx, http.StatusInternalServerError, errors.New("oidc: session is not enabled"))
+		return
+	}
+	returnTo := ctx.Input.Query("return_to")
+	if !isLocalURL(returnTo) {
+		returnTo = c.cfg.PostLoginURL
+	}
+	ar := &authRequest{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString(),
+		ReturnTo: returnTo,
+	}
+	ctx.Output.Session(authRequestKey, ar)
+
+	challenge := sha256.Sum256([]byte(ar.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"scope":                 {strings.Join(c.cfg.Scopes, " ")},
+		"state":                 {ar.State},
+		"nonce":                 {ar.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	ctx.Redirect(http.StatusFound, appendQuery(c.endpoint.AuthorizationEndpoint, q))
+}
+
+// Callback exchanges the code for tokens, verifies the ID token and stores the user in the session
+func (c *Client) Callback(ctx *context.Context) {
+	if ctx.Input.CruSession == nil {
+		c.fail(ctx, http.StatusInternalServerError, errors.New("oidc: session is not enabled"))
+		return
+	}
+	ar, ok := ctx.Input.Session(authRequestKey).(*authRequest)
+	if !ok {
+		c.fail(ctx, http.StatusBadRequest, errors.New("oidc: no login in progress"))
+		return
+	}
+	// the state is used once
+	_ = ctx.Input.CruSession.Delete(context2.Background(), authRequestKey)
+	if e := ctx.Input.Query("error"); e != "" {
+		c.fail(ctx, http.StatusUnauthorized, fmt.Errorf("oidc: %s: %s", e, ctx.Input.Query("error_description")))
+		return
+	}
+	if ctx.Input.Query("state") != ar.State {
+		c.fail(ctx, http.StatusBadRequest, errors.New("oidc: state mismatch"))
+		return
+	}
+
+	token, err := c.exchange(ctx.Request.Context(), ctx.Input.Query("code"), ar.Verifier)
+	if err != nil {
+		c.fail(ctx, http.StatusBadGateway, err)
+		return
+	}
+	claims := jwt.MapClaims{}
+	if _, err = c.parser.ParseWithClaims(token.IDToken, claims, c.key); err != nil {
+		c.fail(ctx, http.StatusUnauthorized, fmt.Errorf("oidc: invalid ID token: %w", err))
+		return
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != ar.Nonce {
+		c.fail(ctx, http.StatusUnauthorized, errors.New("oidc: nonce mismatch"))
+		return
+	}
+
+	p := &Principal{
+		Claims:       claims,
+		IDToken:      token.IDToken,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+	}
+	p.Subject, _ = claims["sub"].(string)
+	p.Email, _ = claims["email"].(string)
+	p.Name, _ = claims["name"].(string)
+	p.setExpiry(token)
+
+	// prevent the session fixation
+	if store, err := web.GlobalSessions.SessionRegenerateID(ctx.ResponseWriter, ctx.Request); err == nil {
+		ctx.Input.CruSession = store
+	}
+	ctx.Output.Session(principalKey, p)
+	ctx.Redirect(http.StatusFound, ar.ReturnTo)
+}
+
+// Logout removes the user from the session and redirects to the end session endpoint of provider if it exists
+func (c *Client) Logout(ctx *context.Context) {
+	redirect := c.cfg.PostLogoutURL
+	if p, ok := sessionPrincipal(ctx); ok {
+		_ = ctx.Input.CruSession.Delete(context2.Background(), principalKey)
+		if c.endpoint.EndSessionEndpoint != "" {
+			redirect = appendQuery(c.endpoint.EndSessionEndpoint, url.Values{
+				"id_token_hint":            {p.IDToken},
+				"post_logout_redirect_uri": {absoluteURL(ctx, c.cfg.PostLogoutURL)},
+			})
+		}
+	}
+	ctx.Redirect(http.StatusFound, redirect)
+}
+
+// RequireLogin returns the filter which redirects the user to login if the user hasn't logged in.
+// The expired principal is refreshed by the refresh token, or removed from the session if it can't be refreshed.
+// It should be inserted at web.BeforeRouter or later, since the session is not started before that.
+// The requests which are not GET get 401 instead of redirect
+func (c *Client) RequireLogin() web.FilterFunc {
+	return func(ctx *context.Context) {
+		if p, ok := sessionPrincipal(ctx); ok {
+			if !p.expired() {
+				return
+			}
+			err := c.refresh(ctx.Request.Context(), p)
+			if err == nil {
+				ctx.Output.Session(principalKey, p)
+				return
+			}
+			logs.Warn(err)
+			_ = ctx.Input.CruSession.Delete(context2.Background(), principalKey)
+		}
+		if ctx.Input.Method() != http.MethodGet {
+			ctx.Output.SetStatus(http.StatusUnauthorized)
+			_ = ctx.Output.Body([]byte(http.StatusText(http.StatusUnauthorized)))
+			return
+		}
+		ctx.Redirect(http.StatusFound, appendQuery(c.cfg.LoginURL, url.Values{"return_to": {ctx.Input.URI()}}))
+	}
+}
+
+func (c *Client) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	return c.keys.Key(kid)
+}
+
+func (c *Client) exchange(ctx context2.Context, code, verifier string) (*tokenResponse, error) {
+	token, err := c.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	})
+	if err == nil && token.IDToken == "" {
+		return nil, errors.New("oidc: token exchange failed, no ID token")
+	}
+	return token, err
+}
+
+// refresh gets the new tokens of p by the refresh token, the ID token is verified if it's returned
+func (c *Client) refresh(ctx context2.Context, p *Principal) error {
+	if p.RefreshToken == "" {
+		return errors.New("oidc: the principal has expired")
+	}
+	token, err := c.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {p.RefreshToken},
+	})
+	if err != nil {
+		return err
+	}
+	if token.IDToken != "" {
+		claims := jwt.MapClaims{}
+		if _, err = c.parser.ParseWithClaims(token.IDToken, claims, c.key); err != nil {
+			return fmt.Errorf("oidc: invalid ID token: %w", err)
+		}
+		if sub, _ := claims["sub"].(string); sub != p.Subject {
+			return errors.New("oidc: subject mismatch")
+		}
+		p.IDToken = token.IDToken
+		p.Claims = claims
+	}
+	p.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		p.RefreshToken = token.RefreshToken
+	}
+	p.setExpiry(token)
+	return nil
+}
+
+func (p *Principal) setExpiry(token *tokenResponse) {
+	p.Expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		p.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+}
+
+func (c *Client) token(ctx context2.Context, form url.Values) (*tokenResponse, error) {
+	form.Set("client_id", c.cfg.ClientID)
+	if c.cfg.ClientSecret != "" {
+		form.Set("client_secret", c.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	token := &tokenResponse{}
+	if err = json.Unmarshal(body, token); err != nil {
+		return nil, fmt.Errorf("oidc: invalid token response: %w", err)
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("oidc: %s: %s", token.Error, token.Description)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token exchange failed, status: %d", resp.StatusCode)
+	}
+	return token, nil
+}
+
+func (c *Client) fail(ctx *context.Context, status int, err error) {
+	logs.Warn(err)
+	ctx.Output.SetStatus(status)
+	_ = ctx.Output.Body([]byte(http.StatusText(status)))
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func appendQuery(u string, q url.Values) string {
+	if strings.Contains(u, "?") {
+		return u + "&" + q.Encode()
+	}
+	return u + "?" + q.Encode()
+}
+
+// isLocalURL checks the URL to avoid the open redirect
+func isLocalURL(u string) bool {
+	return strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") && !strings.HasPrefix(u, "/\\")
+}
+
+func absoluteURL(ctx *context.Context, u string) string {
+	if !isLocalURL(u) {
+		return u
+	}
+	return ctx.Input.Scheme() + "://" + ctx.Request.Host + u
+}
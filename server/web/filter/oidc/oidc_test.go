@@ -0,0 +1,228 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	context2 "context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+	"github.com/beego/beego/v2/server/web/session"
+)
+
+// newProvider starts a fake provider which issues the ID token for any code
+func newProvider(t *testing.T) *httptest.Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	var nonce, challenge string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]map[string]string{"keys": {{
+			"kty": "RSA",
+			"kid": "1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		nonce = r.URL.Query().Get("nonce")
+		challenge = r.URL.Query().Get("code_challenge")
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") == "refresh_token" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "refreshed " + r.FormValue("refresh_token"),
+				"expires_in":   3600,
+			})
+			return
+		}
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   server.URL,
+			"aud":   "admin",
+			"sub":   "1",
+			"name":  "tom",
+			"nonce": nonce,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "1"
+		idToken, err := token.SignedString(key)
+		assert.Nil(t, err)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "access",
+			"id_token":      idToken,
+			"refresh_token": "refresh",
+			"expires_in":    3600,
+		})
+	})
+	return server
+}
+
+func TestClient(t *testing.T) {
+	provider := newProvider(t)
+	defer provider.Close()
+
+	var err error
+	web.GlobalSessions, err = session.NewManager("memory", &session.ManagerConfig{
+		CookieName:      "gosessionid",
+		Gclifetime:      3600,
+		EnableSetCookie: true,
+	})
+	assert.Nil(t, err)
+	sessionOn := web.BConfig.WebConfig.Session.SessionOn
+	web.BConfig.WebConfig.Session.SessionOn = true
+	defer func() {
+		web.BConfig.WebConfig.Session.SessionOn = sessionOn
+	}()
+
+	client, err := NewClient(context2.Background(), Config{
+		Issuer:      provider.URL,
+		ClientID:    "admin",
+		RedirectURL: "/oidc/callback",
+	})
+	assert.Nil(t, err)
+
+	handler := web.NewControllerRegister()
+	handler.Get("/oidc/login", client.Login)
+	handler.Get("/oidc/callback", client.Callback)
+	handler.Get("/oidc/logout", client.Logout)
+	handler.InsertFilter("/admin/*", web.BeforeRouter, client.RequireLogin())
+	handler.Get("/admin/index", func(ctx *context.Context) {
+		p, _ := GetPrincipal(ctx)
+		_ = ctx.Output.Body([]byte(p.Name + " " + p.AccessToken))
+	})
+	// expire makes the principal expired, and drops the refresh token if drop is set
+	handler.Get("/expire", func(ctx *context.Context) {
+		p, ok := GetPrincipal(ctx)
+		if !ok {
+			_ = ctx.Output.Body([]byte("none"))
+			return
+		}
+		p.Expiry = time.Now().Add(-time.Second)
+		if ctx.Input.Query("drop") != "" {
+			p.RefreshToken = ""
+		}
+		ctx.Output.Session(principalKey, p)
+		_, ok = GetPrincipal(ctx)
+		_ = ctx.Output.Body([]byte(strconv.FormatBool(ok)))
+	})
+	handler.Init()
+	app := httptest.NewServer(handler)
+	defer app.Close()
+
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar, CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	get := func(u string) *http.Response {
+		resp, err := c.Get(u)
+		assert.Nil(t, err)
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	resp := get(app.URL + "/admin/index")
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	login := resp.Header.Get("Location")
+	assert.Equal(t, "/oidc/login?return_to=%2Fadmin%2Findex", login)
+
+	resp = get(app.URL + login)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	authorize, err := url.Parse(resp.Header.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, "S256", authorize.Query().Get("code_challenge_method"))
+	state := authorize.Query().Get("state")
+	get(authorize.String())
+
+	// the state is wrong
+	resp = get(app.URL + "/oidc/callback?code=abc&state=wrong")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = get(app.URL + "/oidc/callback?code=abc&state=" + state)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = get(app.URL + login)
+	authorize, _ = url.Parse(resp.Header.Get("Location"))
+	get(authorize.String())
+	resp = get(app.URL + "/oidc/callback?code=abc&state=" + authorize.Query().Get("state"))
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/admin/index", resp.Header.Get("Location"))
+
+	read := func(u string) (int, string) {
+		resp, err := c.Get(u)
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return resp.StatusCode, string(body)
+	}
+	status, body := read(app.URL + "/admin/index")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "tom access", body)
+
+	// the expired principal is refreshed by the refresh token
+	_, body = read(app.URL + "/expire")
+	assert.Equal(t, "false", body)
+	status, body = read(app.URL + "/admin/index")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "tom refreshed refresh", body)
+
+	// the expired principal is rejected if it can't be refreshed
+	read(app.URL + "/expire?drop=1")
+	resp = get(app.URL + "/admin/index")
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, login, resp.Header.Get("Location"))
+	// the principal is removed from the session
+	_, body = read(app.URL + "/expire")
+	assert.Equal(t, "none", body)
+
+	resp = get(app.URL + login)
+	authorize, _ = url.Parse(resp.Header.Get("Location"))
+	get(authorize.String())
+	get(app.URL + "/oidc/callback?code=abc&state=" + authorize.Query().Get("state"))
+
+	resp = get(app.URL + "/oidc/logout")
+	assert.Equal(t, "/", resp.Header.Get("Location"))
+	assert.Equal(t, http.StatusFound, get(app.URL+"/admin/index").StatusCode)
+}
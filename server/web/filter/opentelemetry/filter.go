@@ -0,0 +1,181 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package opentelemetry provides a filter chain which creates a server span for each request.
+// usage:
+//
+//	web.InsertFilterChain("/*", opentelemetry.NewFilterChainBuilder().FilterChain)
+//
+// The span is stored in the context of request, so the spans of orm, httplib and cache
+// are children of it if the context of request is passed to them:
+//
+//	o.ReadWithCtx(c.Ctx.Request.Context(), &user)
+//	httplib.Get(url).SetContext(c.Ctx.Request.Context()).Response()
+package opentelemetry
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/beego/beego/v2/core/utils"
+	"github.com/beego/beego/v2/server/web"
+	beegoCtx "github.com/beego/beego/v2/server/web/context"
+)
+
+const instrumentationName = "github.com/beego/beego/v2/server/web/filter/opentelemetry"
+
+type (
+	CustomSpanFunc    func(ctx *beegoCtx.Context, span trace.Span)
+	FilterChainOption func(fcb *FilterChainBuilder)
+)
+
+// FilterChainBuilder provides an opentelemetry filter for web server
+type FilterChainBuilder struct {
+	tracerProvider  trace.TracerProvider
+	propagator      propagation.TextMapPropagator
+	serverName      string
+	injectResponse  bool
+	customSpanFunc  CustomSpanFunc
+	spanNameBuilder func(ctx *beegoCtx.Context) string
+}
+
+// NewFilterChainBuilder creates FilterChainBuilder,
+// the global TracerProvider and TextMapPropagator are used by default
+func NewFilterChainBuilder(options ...FilterChainOption) *FilterChainBuilder {
+	fcb := &FilterChainBuilder{
+		serverName: web.BConfig.ServerName,
+	}
+	for _, o := range options {
+		o(fcb)
+	}
+	return fcb
+}
+
+// WithTracerProvider sets the TracerProvider
+func WithTracerProvider(tp trace.TracerProvider) FilterChainOption {
+	return func(fcb *FilterChainBuilder) {
+		fcb.tracerProvider = tp
+	}
+}
+
+// WithPropagator sets the propagator which extracts the parent span from request, W3C traceparent for example
+func WithPropagator(p propagation.TextMapPropagator) FilterChainOption {
+	return func(fcb *FilterChainBuilder) {
+		fcb.propagator = p
+	}
+}
+
+// WithServerName sets the attribute http.server_name
+func WithServerName(name string) FilterChainOption {
+	return func(fcb *FilterChainBuilder) {
+		fcb.serverName = name
+	}
+}
+
+// WithInjectResponse injects the span into the headers of response, such as traceparent,
+// so the client is able to find the trace of request
+func WithInjectResponse() FilterChainOption {
+	return func(fcb *FilterChainBuilder) {
+		fcb.injectResponse = true
+	}
+}
+
+// WithSpanNameBuilder sets the function which returns the span name after the request is handled.
+// The span name is "{method} {route pattern}" by default
+func WithSpanNameBuilder(f func(ctx *beegoCtx.Context) string) FilterChainOption {
+	return func(fcb *FilterChainBuilder) {
+		fcb.spanNameBuilder = f
+	}
+}
+
+// WithCustomSpanFunc add function to custom span
+func WithCustomSpanFunc(customSpanFunc CustomSpanFunc) FilterChainOption {
+	return func(fcb *FilterChainBuilder) {
+		fcb.customSpanFunc = customSpanFunc
+	}
+}
+
+// FilterChain traces the request with opentelemetry
+func (builder *FilterChainBuilder) FilterChain(next web.FilterFunc) web.FilterFunc {
+	return func(ctx *beegoCtx.Context) {
+		tp := builder.tracerProvider
+		if tp == nil {
+			tp = otel.GetTracerProvider()
+		}
+		propagator := builder.propagator
+		if propagator == nil {
+			propagator = otel.GetTextMapPropagator()
+		}
+
+		r := ctx.Request
+		parent := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		spanCtx, span := tp.Tracer(instrumentationName).Start(parent, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", r)...),
+			trace.WithAttributes(semconv.EndUserAttributesFromHTTPRequest(r)...),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(builder.serverName, "", r)...))
+		defer span.End()
+
+		ctx.Request = r.WithContext(spanCtx)
+		if builder.injectResponse {
+			propagator.Inject(spanCtx, propagation.HeaderCarrier(ctx.ResponseWriter.Header()))
+		}
+
+		defer func() {
+			// the route is found after the request is handled
+			route, _ := ctx.Input.GetData("RouterPattern").(string)
+			if route != "" {
+				span.SetAttributes(semconv.HTTPRouteKey.String(route))
+			}
+			span.SetName(builder.spanName(ctx, route))
+
+			status := ctx.ResponseWriter.Status
+			if p := recover(); p != nil {
+				if p != web.ErrAbort {
+					span.SetStatus(codes.Error, "panic")
+					span.SetAttributes(attribute.String("exception.message", fmt.Sprint(p)))
+				}
+				panic(p)
+			}
+			if status == 0 {
+				status = 200
+			}
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+			if id, ok := utils.RequestIDFromContext(spanCtx); ok {
+				span.SetAttributes(attribute.String("request.id", id))
+			}
+			if builder.customSpanFunc != nil {
+				builder.customSpanFunc(ctx, span)
+			}
+		}()
+		next(ctx)
+	}
+}
+
+func (builder *FilterChainBuilder) spanName(ctx *beegoCtx.Context, route string) string {
+	if builder.spanNameBuilder != nil {
+		return builder.spanNameBuilder(ctx)
+	}
+	if route == "" {
+		return ctx.Request.Method
+	}
+	return ctx.Request.Method + " " + route
+}
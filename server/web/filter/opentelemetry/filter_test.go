@@ -0,0 +1,78 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/beego/beego/v2/server/web"
+	beegoCtx "github.com/beego/beego/v2/server/web/context"
+)
+
+func TestFilterChainBuilderFilterChain(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	builder := NewFilterChainBuilder(WithTracerProvider(tp), WithPropagator(propagation.TraceContext{}),
+		WithInjectResponse(), WithCustomSpanFunc(func(ctx *beegoCtx.Context, span trace.Span) {
+			span.SetAttributes(attribute.String("hello", "work"))
+		}))
+
+	var child trace.SpanContext
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", builder.FilterChain)
+	handler.Get("/user/:id", func(ctx *beegoCtx.Context) {
+		// the span of orm or httplib
+		_, span := tp.Tracer("orm").Start(ctx.Request.Context(), "Read#user")
+		child = span.SpanContext()
+		span.End()
+		ctx.Output.SetStatus(http.StatusInternalServerError)
+	})
+	handler.Init()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/user/1", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(w, r)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	server := spans[1]
+	assert.Equal(t, "GET /user/:id", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, child.TraceID(), server.SpanContext().TraceID())
+	assert.Equal(t, codes.Error, server.Status().Code)
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range server.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "/user/:id", attrs["http.route"].AsString())
+	assert.Equal(t, int64(500), attrs["http.status_code"].AsInt64())
+	assert.Equal(t, "work", attrs["hello"].AsString())
+	assert.Contains(t, w.Header().Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+}
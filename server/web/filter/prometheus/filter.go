@@ -19,11 +19,14 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/beego/beego/v2"
 	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/core/utils"
 	"github.com/beego/beego/v2/server/web"
 	"github.com/beego/beego/v2/server/web/context"
 )
@@ -33,14 +36,32 @@ const unknownRouterPattern = "UnknownRouterPattern"
 // FilterChainBuilder is an extension point,
 // when we want to support some configuration,
 // please use this structure
-type FilterChainBuilder struct{}
+type FilterChainBuilder struct {
+	// Buckets is the buckets of request duration histogram in seconds, prometheus.DefBuckets by default
+	Buckets []float64
+	// Registerer registers the histograms and gauge, web.MetricsRegistry by default.
+	// The legacy summary is always registered to prometheus.DefaultRegisterer
+	Registerer prometheus.Registerer
+}
 
 var (
 	summaryVec     prometheus.ObserverVec
 	initSummaryVec sync.Once
+
+	durationVec *prometheus.HistogramVec
+	sizeVec     *prometheus.HistogramVec
+	inFlight    prometheus.Gauge
 )
 
-// FilterChain returns a FilterFunc. The filter will records some metrics
+// FilterChain returns a FilterFunc. The filter will records some metrics:
+//
+//	beego_http_request: summary of request duration in milliseconds, it's deprecated
+//	beego_http_request_duration_seconds: histogram of request duration, with request_id and trace_id exemplars
+//	beego_http_response_size_bytes: histogram of response size
+//	beego_http_requests_in_flight: the number of requests being served
+//
+// The labels are route pattern, method and status. The metrics are registered only once,
+// so the configuration of the first builder is used.
 func (builder *FilterChainBuilder) FilterChain(next web.FilterFunc) web.FilterFunc {
 	initSummaryVec.Do(func() {
 		summaryVec = builder.buildVec()
@@ -49,13 +70,58 @@ func (builder *FilterChainBuilder) FilterChain(next web.FilterFunc) web.FilterFu
 			logs.Error("web module register prometheus vector failed, %+v", err)
 		}
 		registerBuildInfo()
+		builder.registerMetrics()
 	})
 
 	return func(ctx *context.Context) {
+		inFlight.Inc()
+		defer inFlight.Dec()
 		startTime := time.Now()
 		next(ctx)
-		endTime := time.Now()
-		go report(endTime.Sub(startTime), ctx, summaryVec)
+		dur := time.Since(startTime)
+		// the context will be reused after returning, so it should be reported synchronously
+		report(dur, ctx, summaryVec)
+		reportMetrics(dur, ctx)
+	}
+}
+
+func (builder *FilterChainBuilder) registerMetrics() {
+	constLabels := map[string]string{
+		"server":  web.BConfig.ServerName,
+		"env":     web.BConfig.RunMode,
+		"appname": web.BConfig.AppName,
+	}
+	buckets := builder.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	durationVec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "beego_http_request_duration_seconds",
+		Help:        "The duration of http request",
+		ConstLabels: constLabels,
+		Buckets:     buckets,
+	}, []string{"pattern", "method", "status"})
+	sizeVec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "beego_http_response_size_bytes",
+		Help:        "The size of http response body",
+		ConstLabels: constLabels,
+		Buckets:     prometheus.ExponentialBuckets(100, 10, 7),
+	}, []string{"pattern", "method", "status"})
+	inFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "beego_http_requests_in_flight",
+		Help:        "The number of http requests being served",
+		ConstLabels: constLabels,
+	})
+
+	registerer := builder.Registerer
+	if registerer == nil {
+		registerer = web.MetricsRegistry
+	}
+	for _, c := range []prometheus.Collector{durationVec, sizeVec, inFlight} {
+		err := registerer.Register(c)
+		if _, ok := err.(*prometheus.AlreadyRegisteredError); err != nil && !ok {
+			logs.Error("web module register prometheus collector failed, %+v", err)
+		}
 	}
 }
 
@@ -105,3 +171,43 @@ func report(dur time.Duration, ctx *context.Context, vec prometheus.ObserverVec)
 	ms := dur / time.Millisecond
 	vec.WithLabelValues(ptn, ctx.Input.Method(), strconv.Itoa(status)).Observe(float64(ms))
 }
+
+func reportMetrics(dur time.Duration, ctx *context.Context) {
+	status := ctx.ResponseWriter.Status
+	if status == 0 {
+		status = 200
+	}
+	ptn := unknownRouterPattern
+	if p, ok := ctx.Input.GetData("RouterPattern").(string); ok {
+		ptn = p
+	}
+	labels := []string{ptn, ctx.Input.Method(), strconv.Itoa(status)}
+
+	observer := durationVec.WithLabelValues(labels...)
+	exemplar := prometheus.Labels{}
+	if id, ok := utils.RequestIDFromContext(ctx.Request.Context()); ok {
+		exemplar["request_id"] = id
+	}
+	if sc := trace.SpanContextFromContext(ctx.Request.Context()); sc.HasTraceID() {
+		exemplar["trace_id"] = sc.TraceID().String()
+	}
+	// client_golang panics if the exemplar is longer than ExemplarMaxRunes, the long request ID is dropped
+	if exemplarRunes(exemplar) > prometheus.ExemplarMaxRunes {
+		delete(exemplar, "request_id")
+	}
+	if eo, ok := observer.(prometheus.ExemplarObserver); ok && len(exemplar) > 0 {
+		eo.ObserveWithExemplar(dur.Seconds(), exemplar)
+	} else {
+		observer.Observe(dur.Seconds())
+	}
+	sizeVec.WithLabelValues(labels...).Observe(float64(ctx.ResponseWriter.Size))
+}
+
+// exemplarRunes counts the runes of the names and the values of the exemplar labels
+func exemplarRunes(labels prometheus.Labels) int {
+	var runes int
+	for name, value := range labels {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	return runes
+}
@@ -17,12 +17,17 @@ package prometheus
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/beego/beego/v2/core/utils"
+	"github.com/beego/beego/v2/server/web"
 	"github.com/beego/beego/v2/server/web/context"
+	"github.com/beego/beego/v2/server/web/filter/requestid"
 )
 
 func TestFilterChain(t *testing.T) {
@@ -53,3 +58,68 @@ func TestFilterChainBuilder_report(t *testing.T) {
 	ctx.Input.SetData("RouterPattern", "my-route")
 	report(time.Second, ctx, fb.buildVec())
 }
+
+func TestFilterChainMetrics(t *testing.T) {
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", (&FilterChainBuilder{}).FilterChain)
+	handler.Get("/user/:id", func(ctx *context.Context) {
+		_ = ctx.Output.Body([]byte("hello"))
+	})
+	handler.Init()
+	r := httptest.NewRequest("GET", "/user/1", nil)
+	r = r.WithContext(utils.WithRequestID(r.Context(), "abc"))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	mfs, err := web.MetricsRegistry.Gather()
+	assert.Nil(t, err)
+	metrics := map[string]bool{}
+	found := false
+	for _, mf := range mfs {
+		metrics[mf.GetName()] = true
+		if mf.GetName() != "beego_http_request_duration_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["pattern"] != "/user/:id" {
+				continue
+			}
+			found = true
+			assert.Equal(t, "200", labels["status"])
+			var exemplar bool
+			for _, b := range m.GetHistogram().GetBucket() {
+				if e := b.GetExemplar(); e != nil {
+					exemplar = e.GetLabel()[0].GetValue() == "abc"
+				}
+			}
+			assert.True(t, exemplar)
+		}
+	}
+	assert.True(t, found)
+	assert.True(t, metrics["beego_http_request_duration_seconds"])
+	assert.True(t, metrics["beego_http_response_size_bytes"])
+	assert.True(t, metrics["beego_http_requests_in_flight"])
+}
+
+func TestFilterChainLongRequestID(t *testing.T) {
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", requestid.NewFilterChain())
+	handler.InsertFilterChain("/*", (&FilterChainBuilder{}).FilterChain)
+	handler.Get("/long/:id", func(ctx *context.Context) {
+		_ = ctx.Output.Body([]byte("hello"))
+	})
+	handler.Init()
+	r := httptest.NewRequest("GET", "/long/1", nil)
+	r.Header.Set(utils.RequestIDHeader, strings.Repeat("a", 128))
+	w := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		handler.ServeHTTP(w, r)
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	exemplar := prometheus.Labels{"request_id": strings.Repeat("a", 128), "trace_id": strings.Repeat("0", 32)}
+	assert.True(t, exemplarRunes(exemplar) > prometheus.ExemplarMaxRunes)
+}
@@ -8,7 +8,32 @@ type bucket interface {
 	getCapacity() uint
 	getRemaining() uint
 	getRate() time.Duration
+	// getResetAfter returns the duration after which more requests are allowed
+	getResetAfter() time.Duration
 }
 
 // bucketOption is constructor option
 type bucketOption func(bucket)
+
+func withCapacity(capacity uint) bucketOption {
+	return func(b bucket) {
+		switch bucket := b.(type) {
+		case *tokenBucket:
+			bucket.capacity = capacity
+			bucket.remaining = capacity
+		case *slidingWindow:
+			bucket.capacity = capacity
+		}
+	}
+}
+
+func withRate(rate time.Duration) bucketOption {
+	return func(b bucket) {
+		switch bucket := b.(type) {
+		case *tokenBucket:
+			bucket.rate = rate
+		case *slidingWindow:
+			bucket.window = rate
+		}
+	}
+}
@@ -15,9 +15,13 @@
 package ratelimit
 
 import (
+	"math"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/beego/beego/v2/client/cache"
 	"github.com/beego/beego/v2/server/web"
 	"github.com/beego/beego/v2/server/web/context"
 )
@@ -33,6 +37,17 @@ type limiter struct {
 	bucketFactory func(opts ...bucketOption) bucket
 	sessionKey    func(ctx *context.Context) string
 	resp          RejectionResponse
+	cache         cache.Cache
+	keyPrefix     string
+	headers       bool
+	overrides     []*routeOverride
+}
+
+// routeOverride is the limiter for the requests whose path has the prefix
+type routeOverride struct {
+	prefix  string
+	opts    []limiterOption
+	limiter *limiter
 }
 
 // RejectionResponse stores response information
@@ -59,17 +74,75 @@ func NewLimiter(opts ...limiterOption) web.FilterFunc {
 		capacity:      100,
 		bucketFactory: newTokenBucket,
 		resp:          defaultRejectionResponse,
+		keyPrefix:     "beego:ratelimit:",
+		headers:       true,
 	}
 	for _, o := range opts {
 		o(l)
 	}
+	for _, o := range l.overrides {
+		o.limiter = l.override(o.prefix, o.opts)
+	}
 
 	return func(ctx *context.Context) {
-		if !l.take(perRequestConsumedAmount, ctx) {
-			ctx.ResponseWriter.WriteHeader(l.resp.code)
-			ctx.WriteString(l.resp.body)
+		l.match(ctx.Request.URL.Path).limit(ctx)
+	}
+}
+
+// limit rejects the request if it exceeds the limit,
+// and sets the headers X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset and Retry-After
+func (l *limiter) limit(ctx *context.Context) {
+	b := l.getBucket(ctx)
+	if b == nil {
+		return
+	}
+	ok := b.take(perRequestConsumedAmount)
+	if l.headers {
+		reset := strconv.FormatInt(int64(math.Ceil(b.getResetAfter().Seconds())), 10)
+		header := ctx.ResponseWriter.Header()
+		header.Set("X-RateLimit-Limit", strconv.FormatUint(uint64(b.getCapacity()), 10))
+		header.Set("X-RateLimit-Remaining", strconv.FormatUint(uint64(b.getRemaining()), 10))
+		header.Set("X-RateLimit-Reset", reset)
+		if !ok {
+			header.Set("Retry-After", reset)
+		}
+	}
+	if !ok {
+		ctx.ResponseWriter.WriteHeader(l.resp.code)
+		ctx.WriteString(l.resp.body)
+	}
+}
+
+// match returns the limiter of the route override which has the longest prefix
+func (l *limiter) match(path string) *limiter {
+	res, longest := l, -1
+	for _, o := range l.overrides {
+		if len(o.prefix) > longest && strings.HasPrefix(path, o.prefix) {
+			res, longest = o.limiter, len(o.prefix)
 		}
 	}
+	return res
+}
+
+// override creates a limiter which inherits the options of l
+func (l *limiter) override(prefix string, opts []limiterOption) *limiter {
+	res := &limiter{
+		buckets:       make(map[string]bucket),
+		sessionKey:    l.sessionKey,
+		rate:          l.rate,
+		capacity:      l.capacity,
+		bucketFactory: l.bucketFactory,
+		resp:          l.resp,
+		cache:         l.cache,
+		keyPrefix:     l.keyPrefix + prefix + ":",
+		headers:       l.headers,
+	}
+	for _, o := range opts {
+		o(res)
+	}
+	// nested override is not supported
+	res.overrides = nil
+	return res
 }
 
 // WithSessionKey return limiterOption. WithSessionKey config func
@@ -105,6 +178,52 @@ func WithBucketFactory(f func(opts ...bucketOption) bucket) limiterOption {
 	}
 }
 
+// WithSlidingWindow return limiterOption. WithSlidingWindow allows capacity requests
+// in the window, instead of generating a token every rate.
+func WithSlidingWindow(window time.Duration) limiterOption {
+	return func(l *limiter) {
+		l.rate = window
+		l.bucketFactory = newSlidingWindow
+	}
+}
+
+// WithCache return limiterOption. WithCache stores the counts of sliding window in the cache,
+// so the limit is shared by the instances which use the same cache, such as redis.
+// The rate is used as the window, and the bucket factory is ignored.
+func WithCache(c cache.Cache) limiterOption {
+	return func(l *limiter) {
+		l.cache = c
+	}
+}
+
+// WithCacheKeyPrefix return limiterOption. WithCacheKeyPrefix sets the prefix of the keys in cache,
+// the default value is "beego:ratelimit:"
+func WithCacheKeyPrefix(prefix string) limiterOption {
+	return func(l *limiter) {
+		l.keyPrefix = prefix
+	}
+}
+
+// WithHeaders return limiterOption. WithHeaders enables or disables the headers
+// X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset and Retry-After, it's enabled by default.
+func WithHeaders(enable bool) limiterOption {
+	return func(l *limiter) {
+		l.headers = enable
+	}
+}
+
+// WithRouteOverride return limiterOption. WithRouteOverride applies the options to the requests
+// whose path has the prefix, and the longest prefix wins. The requests are counted separately.
+// usage:
+//
+//	NewLimiter(WithSessionKey(RemoteIPSessionKey), WithCapacity(100),
+//		WithRouteOverride("/api/login", WithSlidingWindow(time.Minute), WithCapacity(5)))
+func WithRouteOverride(prefix string, opts ...limiterOption) limiterOption {
+	return func(l *limiter) {
+		l.overrides = append(l.overrides, &routeOverride{prefix: prefix, opts: opts})
+	}
+}
+
 // WithRejectionResponse return limiterOption. WithRejectionResponse
 // customize the response for the request rejected by the limiter.
 func WithRejectionResponse(resp RejectionResponse) limiterOption {
@@ -113,16 +232,16 @@ func WithRejectionResponse(resp RejectionResponse) limiterOption {
 	}
 }
 
-func (l *limiter) take(amount uint, ctx *context.Context) bool {
-	bucket := l.getBucket(ctx)
-	if bucket == nil {
-		return true
-	}
-	return bucket.take(amount)
-}
-
 func (l *limiter) getBucket(ctx *context.Context) bucket {
 	key := l.sessionKey(ctx)
+	if l.cache != nil {
+		return &cacheWindow{
+			cache:    l.cache,
+			key:      l.keyPrefix + key,
+			capacity: l.capacity,
+			window:   l.rate,
+		}
+	}
 	l.RLock()
 	b, ok := l.buckets[key]
 	l.RUnlock()
@@ -161,3 +280,10 @@ func RemoteIPSessionKey(ctx *context.Context) string {
 	}
 	return IPAddress
 }
+
+// HeaderSessionKey returns the session key function which applies the limit by the header, such as X-API-Key
+func HeaderSessionKey(name string) func(ctx *context.Context) string {
+	return func(ctx *context.Context) string {
+		return ctx.Request.Header.Get(name)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/client/cache"
 	"github.com/beego/beego/v2/server/web"
 	"github.com/beego/beego/v2/server/web/context"
 )
@@ -39,6 +42,42 @@ func TestLimiter(t *testing.T) {
 	testRequest(t, handler, ip, "GET", route, 200)
 }
 
+func TestLimiterHeadersAndOverride(t *testing.T) {
+	handler := web.NewControllerRegister()
+	err := handler.InsertFilter("/*", web.BeforeRouter, NewLimiter(WithSessionKey(HeaderSessionKey("X-API-Key")),
+		WithSlidingWindow(time.Minute), WithCapacity(2),
+		WithRouteOverride("/login", WithCapacity(1), WithCache(cache.NewMemoryCache()))))
+	assert.Nil(t, err)
+	handler.Any("*", func(ctx *context.Context) {
+		ctx.Output.SetStatus(200)
+	})
+
+	serve := func(path, key string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", path, nil)
+		r.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("/foo", "a")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t, 200, serve("/foo", "a").Code)
+	w = serve("/foo", "a")
+	assert.Equal(t, 429, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, 200, serve("/foo", "b").Code)
+
+	// the requests of route override are counted separately
+	w = serve("/login", "a")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, 429, serve("/login", "a").Code)
+}
+
 func BenchmarkWithoutLimiter(b *testing.B) {
 	recorder := httptest.NewRecorder()
 	handler := web.NewControllerRegister()
@@ -0,0 +1,201 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/client/cache"
+)
+
+// slidingWindow allows capacity requests in a window,
+// the count of the window is estimated by the counts of the current and previous fixed window
+type slidingWindow struct {
+	sync.Mutex
+	capacity uint
+	window   time.Duration
+	start    time.Time
+	previous uint
+	current  uint
+}
+
+// newSlidingWindow return an bucket that implements sliding window
+func newSlidingWindow(opts ...bucketOption) bucket {
+	b := &slidingWindow{}
+	for _, o := range opts {
+		o(b)
+	}
+	b.start = time.Now().Truncate(b.window)
+	return b
+}
+
+func (b *slidingWindow) getRemaining() uint {
+	b.Lock()
+	defer b.Unlock()
+	return b.remaining(time.Now())
+}
+
+func (b *slidingWindow) getRate() time.Duration {
+	return b.window
+}
+
+func (b *slidingWindow) getCapacity() uint {
+	return b.capacity
+}
+
+func (b *slidingWindow) getResetAfter() time.Duration {
+	b.Lock()
+	defer b.Unlock()
+	return b.window - time.Since(b.start)%b.window
+}
+
+func (b *slidingWindow) take(amount uint) bool {
+	if b.window <= 0 {
+		return true
+	}
+	b.Lock()
+	defer b.Unlock()
+	now := time.Now()
+	if b.remaining(now) < amount {
+		return false
+	}
+	b.current += amount
+	return true
+}
+
+// remaining moves the window to now, and returns the remaining
+func (b *slidingWindow) remaining(now time.Time) uint {
+	if b.window <= 0 {
+		return b.capacity
+	}
+	if elapsed := now.Sub(b.start); elapsed >= b.window {
+		if elapsed < 2*b.window {
+			b.previous = b.current
+		} else {
+			b.previous = 0
+		}
+		b.current = 0
+		b.start = now.Truncate(b.window)
+	}
+	count := estimateCount(b.previous, b.current, now.Sub(b.start), b.window)
+	if count >= b.capacity {
+		return 0
+	}
+	return b.capacity - count
+}
+
+// estimateCount assumes that the requests of previous window are evenly distributed
+func estimateCount(previous, current uint, elapsed, window time.Duration) uint {
+	weight := float64(window-elapsed) / float64(window)
+	return uint(float64(previous)*weight) + current
+}
+
+// cacheWindowLocks makes the read and the update of the counts of cacheWindow atomic in the process,
+// the key is locked by the lock of its hash
+var cacheWindowLocks [64]sync.Mutex
+
+// cacheWindow is a sliding window which stores the counts in cache,
+// so the limit is shared by all the instances which use the same cache, such as redis.
+// The instances may exceed the capacity slightly since the cache doesn't support the atomic add-or-create.
+// It's created for each request.
+type cacheWindow struct {
+	cache    cache.Cache
+	key      string
+	capacity uint
+	window   time.Duration
+	// the state after take
+	rest       uint
+	resetAfter time.Duration
+}
+
+func (b *cacheWindow) getRemaining() uint {
+	return b.rest
+}
+
+func (b *cacheWindow) getRate() time.Duration {
+	return b.window
+}
+
+func (b *cacheWindow) getCapacity() uint {
+	return b.capacity
+}
+
+func (b *cacheWindow) getResetAfter() time.Duration {
+	return b.resetAfter
+}
+
+func (b *cacheWindow) take(amount uint) bool {
+	if b.window <= 0 {
+		b.rest = b.capacity
+		return true
+	}
+	ctx := context.Background()
+	now := time.Now()
+	start := now.Truncate(b.window)
+	b.resetAfter = b.window - now.Sub(start)
+	currentKey := b.windowKey(start)
+	lock := b.lock()
+	lock.Lock()
+	defer lock.Unlock()
+	previous := b.count(ctx, b.windowKey(start.Add(-b.window)))
+	current := b.count(ctx, currentKey)
+	count := estimateCount(previous, current, now.Sub(start), b.window)
+	if count+amount > b.capacity {
+		b.rest = 0
+		if count < b.capacity {
+			b.rest = b.capacity - count
+		}
+		return false
+	}
+	b.rest = b.capacity - count - amount
+	// the window is required until the end of next window
+	if current == 0 {
+		if err := b.cache.Put(ctx, currentKey, int64(amount), 2*b.window); err == nil {
+			return true
+		}
+	}
+	for i := uint(0); i < amount; i++ {
+		if err := b.cache.Incr(ctx, currentKey); err != nil {
+			// don't reject the request if the cache is not available
+			return true
+		}
+	}
+	return true
+}
+
+func (b *cacheWindow) lock() *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(b.key))
+	return &cacheWindowLocks[h.Sum32()%uint32(len(cacheWindowLocks))]
+}
+
+func (b *cacheWindow) windowKey(start time.Time) string {
+	return fmt.Sprintf("%s:%d", b.key, start.UnixNano()/int64(b.window))
+}
+
+func (b *cacheWindow) count(ctx context.Context, key string) uint {
+	v, err := b.cache.Get(ctx, key)
+	if err != nil || v == nil {
+		return 0
+	}
+	if c := cache.GetInt64(v); c > 0 {
+		return uint(c)
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/client/cache"
+)
+
+func TestSlidingWindowTake(t *testing.T) {
+	b := newSlidingWindow(withCapacity(3), withRate(50*time.Millisecond))
+	assert.Equal(t, uint(3), b.getCapacity())
+	for i := 0; i < 3; i++ {
+		assert.True(t, b.take(1))
+	}
+	assert.False(t, b.take(1))
+	assert.Equal(t, uint(0), b.getRemaining())
+	assert.True(t, b.getResetAfter() <= 50*time.Millisecond)
+
+	// the previous window is expired
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, uint(3), b.getRemaining())
+	assert.True(t, b.take(1))
+}
+
+func TestEstimateCount(t *testing.T) {
+	assert.Equal(t, uint(7), estimateCount(10, 2, 50*time.Millisecond, 100*time.Millisecond))
+	assert.Equal(t, uint(12), estimateCount(10, 2, 0, 100*time.Millisecond))
+}
+
+func TestCacheWindowTake(t *testing.T) {
+	c := cache.NewMemoryCache()
+	newBucket := func() bucket {
+		return &cacheWindow{cache: c, key: "ip", capacity: 3, window: time.Hour}
+	}
+	for i := 0; i < 3; i++ {
+		b := newBucket()
+		assert.True(t, b.take(1))
+		assert.Equal(t, uint(2-i), b.getRemaining())
+	}
+	b := newBucket()
+	assert.False(t, b.take(1))
+	assert.Equal(t, uint(0), b.getRemaining())
+	assert.True(t, b.getResetAfter() <= time.Hour)
+}
+
+// slowCache widens the gap between the read and the update of the counts
+type slowCache struct {
+	cache.Cache
+}
+
+func (c slowCache) Get(ctx context.Context, key string) (any, error) {
+	v, err := c.Cache.Get(ctx, key)
+	time.Sleep(time.Millisecond)
+	return v, err
+}
+
+func TestCacheWindowTakeConcurrently(t *testing.T) {
+	c := slowCache{Cache: cache.NewMemoryCache()}
+	var taken atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b := &cacheWindow{cache: c, key: "ip", capacity: 10, window: time.Hour}
+			if b.take(1) {
+				taken.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(10), taken.Load())
+}
@@ -22,21 +22,6 @@ func newTokenBucket(opts ...bucketOption) bucket {
 	return b
 }
 
-func withCapacity(capacity uint) bucketOption {
-	return func(b bucket) {
-		bucket := b.(*tokenBucket)
-		bucket.capacity = capacity
-		bucket.remaining = capacity
-	}
-}
-
-func withRate(rate time.Duration) bucketOption {
-	return func(b bucket) {
-		bucket := b.(*tokenBucket)
-		bucket.rate = rate
-	}
-}
-
 func (b *tokenBucket) getRemaining() uint {
 	b.RLock()
 	defer b.RUnlock()
@@ -55,6 +40,15 @@ func (b *tokenBucket) getCapacity() uint {
 	return b.capacity
 }
 
+func (b *tokenBucket) getResetAfter() time.Duration {
+	b.RLock()
+	defer b.RUnlock()
+	if b.rate <= 0 {
+		return 0
+	}
+	return b.rate - time.Since(b.lastCheckAt)%b.rate
+}
+
 func (b *tokenBucket) take(amount uint) bool {
 	if b.rate <= 0 {
 		return true
@@ -0,0 +1,114 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package requestid provides a filter chain which assigns an ID to each request.
+// usage:
+//
+//	web.InsertFilterChain("/*", requestid.NewFilterChain())
+//
+// The ID is read from X-Request-ID header of the request, or generated if it's absent or invalid.
+// It's written to the X-Request-ID header of the response and the access log.
+// And it's stored in the context of request, so httplib sends it to the backend
+// and the orm tracing filters add it to the spans if the context of request is passed to them:
+//
+//	httplib.Get(url).DoRequestWithCtx(c.Ctx.Request.Context())
+//	o.ReadWithCtx(c.Ctx.Request.Context(), &user)
+package requestid
+
+import (
+	"github.com/beego/beego/v2/core/utils"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// maxLength is the max length of the request ID from client
+const maxLength = 128
+
+// Option is constructor option
+type Option func(f *filter)
+
+type filter struct {
+	header   string
+	generate func() string
+	trust    bool
+}
+
+// NewFilterChain returns FilterChain which assigns an ID to each request
+func NewFilterChain(opts ...Option) web.FilterChain {
+	f := &filter{
+		header:   utils.RequestIDHeader,
+		generate: utils.NewRequestID,
+		trust:    true,
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f.chain
+}
+
+// WithHeader sets the header name, it's X-Request-ID by default
+func WithHeader(header string) Option {
+	return func(f *filter) {
+		f.header = header
+	}
+}
+
+// WithGenerator sets the function which generates the request ID
+func WithGenerator(generate func() string) Option {
+	return func(f *filter) {
+		f.generate = generate
+	}
+}
+
+// WithTrustIncoming sets whether the request ID from client is used, it's true by default.
+// It should be false if the service is exposed to the Internet directly
+func WithTrustIncoming(trust bool) Option {
+	return func(f *filter) {
+		f.trust = trust
+	}
+}
+
+// Get returns the request ID, it's empty if the filter isn't used
+func Get(ctx *context.Context) string {
+	id, _ := utils.RequestIDFromContext(ctx.Request.Context())
+	return id
+}
+
+func (f *filter) chain(next web.FilterFunc) web.FilterFunc {
+	return func(ctx *context.Context) {
+		id := ""
+		if f.trust {
+			id = ctx.Request.Header.Get(f.header)
+		}
+		if !isValid(id) {
+			id = f.generate()
+		}
+		ctx.Request = ctx.Request.WithContext(utils.WithRequestID(ctx.Request.Context(), id))
+		ctx.Output.Header(f.header, id)
+		next(ctx)
+	}
+}
+
+// isValid checks the request ID from client to avoid injecting into the logs
+func isValid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestid
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/client/httplib"
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func TestNewFilterChain(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Request-ID")))
+	}))
+	defer backend.Close()
+
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", NewFilterChain())
+	handler.Get("/proxy", func(ctx *context.Context) {
+		resp, err := httplib.Get(backend.URL).DoRequestWithCtx(ctx.Request.Context())
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		ctx.Output.Header("X-Handler-ID", Get(ctx))
+		_ = ctx.Output.Body(body)
+	})
+	handler.Init()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/proxy", nil)
+	r.Header.Set("X-Request-ID", "abc-123")
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "abc-123", w.Header().Get("X-Request-ID"))
+	assert.Equal(t, "abc-123", w.Header().Get("X-Handler-ID"))
+	assert.Equal(t, "abc-123", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/proxy", nil)
+	r.Header.Set("X-Request-ID", "invalid id")
+	handler.ServeHTTP(w, r)
+	id := w.Header().Get("X-Request-ID")
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, w.Body.String())
+}
+
+func TestNewFilterChainOptions(t *testing.T) {
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", NewFilterChain(WithHeader("X-Trace-ID"), WithTrustIncoming(false),
+		WithGenerator(func() string {
+			return "generated"
+		})))
+	handler.Get("/", func(ctx *context.Context) {
+		_ = ctx.Output.Body([]byte(Get(ctx)))
+	})
+	handler.Init()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Trace-ID", "abc")
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "generated", w.Header().Get("X-Trace-ID"))
+	assert.Equal(t, "generated", w.Body.String())
+}
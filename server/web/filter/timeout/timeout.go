@@ -0,0 +1,246 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timeout provides a filter chain which enforces a deadline per route.
+// usage:
+//
+//	web.InsertFilterChain("/api/*", timeout.NewFilterChain(3*time.Second))
+//	web.InsertFilterChain("/report/*", timeout.NewFilterChain(time.Minute,
+//		timeout.WithStatus(http.StatusGatewayTimeout)))
+//
+// The request context is canceled when it's timeout, and the filter responds right away without waiting for the handler.
+// The handler runs with its own context and it should return as soon as possible.
+// The response of handler is buffered, and it will be discarded after timeout.
+// So the WebSocket and Server-Sent Events requests are excluded by default.
+package timeout
+
+import (
+	"bytes"
+	gocontext "context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// Option is constructor option
+type Option func(f *filter)
+
+type filter struct {
+	timeout  time.Duration
+	status   int
+	body     string
+	skippers []func(ctx *context.Context) bool
+}
+
+// NewFilterChain returns FilterChain which responds 503 if the handler doesn't finish in timeout
+func NewFilterChain(timeout time.Duration, opts ...Option) web.FilterChain {
+	f := &filter{
+		timeout:  timeout,
+		status:   http.StatusServiceUnavailable,
+		body:     http.StatusText(http.StatusServiceUnavailable),
+		skippers: []func(ctx *context.Context) bool{isStreaming},
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f.chain
+}
+
+// WithStatus sets the status code of the response after timeout, for example, 504
+func WithStatus(code int) Option {
+	return func(f *filter) {
+		f.status = code
+		f.body = http.StatusText(code)
+	}
+}
+
+// WithBody sets the body of the response after timeout
+func WithBody(body string) Option {
+	return func(f *filter) {
+		f.body = body
+	}
+}
+
+// WithSkipper skips the timeout control if skipper returns true
+func WithSkipper(skipper func(ctx *context.Context) bool) Option {
+	return func(f *filter) {
+		f.skippers = append(f.skippers, skipper)
+	}
+}
+
+// WithExcludePaths skips the timeout control for the requests whose path has one of the prefixes
+func WithExcludePaths(prefixes ...string) Option {
+	return WithSkipper(func(ctx *context.Context) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(ctx.Request.URL.Path, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// isStreaming checks whether it's a WebSocket or Server-Sent Events request
+func isStreaming(ctx *context.Context) bool {
+	r := ctx.Request
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+func (f *filter) chain(next web.FilterFunc) web.FilterFunc {
+	return func(ctx *context.Context) {
+		for _, skip := range f.skippers {
+			if skip(ctx) {
+				next(ctx)
+				return
+			}
+		}
+
+		c, cancel := gocontext.WithTimeout(ctx.Request.Context(), f.timeout)
+		defer cancel()
+		rw := ctx.ResponseWriter.ResponseWriter
+		tw := &timeoutWriter{header: rw.Header().Clone()}
+		// the context will be put back into pool after returning,
+		// so the handler runs with its own context and it could outlive the request after timeout
+		hctx := copyContext(ctx, tw, ctx.Request.WithContext(c))
+
+		done := make(chan struct{})
+		var p interface{}
+		go func() {
+			defer close(done)
+			defer func() {
+				p = recover()
+			}()
+			next(hctx)
+		}()
+
+		select {
+		case <-done:
+		case <-c.Done():
+			// don't wait for the handler, its writes are discarded by tw
+			tw.timeout(rw, f.status, f.body)
+			ctx.ResponseWriter.Started = true
+			ctx.ResponseWriter.Status = f.status
+			return
+		}
+
+		copyBack(ctx, hctx)
+		tw.flush(rw)
+		if p != nil {
+			panic(p)
+		}
+	}
+}
+
+// copyContext creates the context for the handler, which shares the state set by the previous filters
+func copyContext(ctx *context.Context, rw http.ResponseWriter, r *http.Request) *context.Context {
+	hctx := context.NewContext()
+	hctx.Reset(rw, r)
+	hctx.ResponseWriter.StartTime = ctx.ResponseWriter.StartTime
+	copyInput(hctx.Input, ctx.Input)
+	hctx.Output.Status = ctx.Output.Status
+	hctx.Output.EnableGzip = ctx.Output.EnableGzip
+	hctx.Output.EnableETag = ctx.Output.EnableETag
+	hctx.Output.WeakETag = ctx.Output.WeakETag
+	return hctx
+}
+
+// copyBack copies the state of the handler context to ctx after the handler returns
+func copyBack(ctx, hctx *context.Context) {
+	ctx.ResponseWriter.Started = hctx.ResponseWriter.Started
+	ctx.ResponseWriter.Status = hctx.ResponseWriter.Status
+	ctx.Input.ResetParams()
+	copyInput(ctx.Input, hctx.Input)
+	ctx.Output.Status = hctx.Output.Status
+}
+
+func copyInput(dst, src *context.BeegoInput) {
+	dst.CruSession = src.CruSession
+	dst.RequestBody = src.RequestBody
+	dst.RunMethod = src.RunMethod
+	dst.RunController = src.RunController
+	for k, v := range src.Params() {
+		dst.SetParam(k, v)
+	}
+	for k, v := range src.Data() {
+		dst.SetData(k, v)
+	}
+}
+
+// timeoutWriter buffers the response until the handler returns
+type timeoutWriter struct {
+	lock     sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+func (tw *timeoutWriter) timeout(rw http.ResponseWriter, code int, body string) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	tw.timedOut = true
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.WriteHeader(code)
+	_, _ = rw.Write([]byte(body))
+	if f, ok := rw.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// flush writes the buffered response, it's invoked after the handler returns
+func (tw *timeoutWriter) flush(rw http.ResponseWriter) {
+	dst := rw.Header()
+	for k := range dst {
+		if _, ok := tw.header[k]; !ok {
+			delete(dst, k)
+		}
+	}
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	if tw.code != 0 {
+		rw.WriteHeader(tw.code)
+	}
+	_, _ = rw.Write(tw.buf.Bytes())
+}
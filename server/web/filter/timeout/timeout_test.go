@@ -0,0 +1,143 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func newHandler(opts ...Option) *web.ControllerRegister {
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", NewFilterChain(50*time.Millisecond, opts...))
+	handler.Get("/fast", func(ctx *context.Context) {
+		ctx.Output.Header("X-Fast", "1")
+		ctx.Output.SetStatus(http.StatusCreated)
+		_ = ctx.Output.Body([]byte("fast"))
+	})
+	handler.Get("/slow", func(ctx *context.Context) {
+		select {
+		case <-ctx.Request.Context().Done():
+		case <-time.After(time.Second):
+		}
+		_ = ctx.Output.Body([]byte("slow"))
+	})
+	handler.Get("/panic", func(ctx *context.Context) {
+		ctx.Abort(http.StatusForbidden, "forbidden")
+	})
+	handler.Init()
+	return handler
+}
+
+func TestNewFilterChain(t *testing.T) {
+	handler := newHandler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Fast"))
+	assert.Equal(t, "fast", w.Body.String())
+
+	w = httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, http.StatusText(http.StatusServiceUnavailable), w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestNewFilterChainOptions(t *testing.T) {
+	handler := newHandler(WithStatus(http.StatusGatewayTimeout), WithBody("timeout"), WithExcludePaths("/slow"))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "slow", w.Body.String())
+
+	handler = newHandler(WithStatus(http.StatusGatewayTimeout), WithBody("timeout"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, "timeout", w.Body.String())
+
+	// streaming requests are excluded
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/slow", nil)
+	r.Header.Set("Accept", "text/event-stream")
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "slow", w.Body.String())
+}
+
+func TestNewFilterChainStuckHandler(t *testing.T) {
+	stuck := make(chan struct{})
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", NewFilterChain(50*time.Millisecond))
+	handler.Get("/stuck", func(ctx *context.Context) {
+		// ignore the canceled context
+		time.Sleep(200 * time.Millisecond)
+		ctx.Output.Header("X-Stuck", "1")
+		_ = ctx.Output.Body([]byte("stuck"))
+		close(stuck)
+	})
+	handler.Init()
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/stuck", nil))
+	// respond right away without waiting for the handler
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	select {
+	case <-stuck:
+	case <-time.After(time.Second):
+		t.Fatal("the handler doesn't return")
+	}
+	assert.Equal(t, "", w.Header().Get("X-Stuck"))
+	assert.Equal(t, http.StatusText(http.StatusServiceUnavailable), w.Body.String())
+}
+
+func TestNewFilterChainContext(t *testing.T) {
+	handler := web.NewControllerRegister()
+	handler.InsertFilterChain("/*", func(next web.FilterFunc) web.FilterFunc {
+		return func(ctx *context.Context) {
+			ctx.Input.SetData("user", "tom")
+			next(ctx)
+			assert.Equal(t, "1", ctx.Input.GetData("id"))
+		}
+	})
+	handler.InsertFilterChain("/*", NewFilterChain(time.Second))
+	handler.Get("/param/:id", func(ctx *context.Context) {
+		ctx.Input.SetData("id", ctx.Input.Param(":id"))
+		_ = ctx.Output.Body([]byte(ctx.Input.GetData("user").(string)))
+	})
+	handler.Init()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/param/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "tom", w.Body.String())
+}
@@ -1,9 +1,12 @@
 package web
 
 import (
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 type FileSystem struct{}
@@ -12,6 +15,50 @@ func (d FileSystem) Open(name string) (http.File, error) {
 	return os.Open(name)
 }
 
+// the files in embed.FS don't have modification time,
+// so we use the start time of process to support Last-Modified header
+var staticFSModTime = time.Now()
+
+// staticFileSystem serves the files in fs.FS with url prefix, see SetStaticFS
+type staticFileSystem struct {
+	prefix string
+	fs     http.FileSystem
+}
+
+func newStaticFileSystem(prefix string, fsys fs.FS) staticFileSystem {
+	return staticFileSystem{prefix: prefix, fs: http.FS(fsys)}
+}
+
+// Open opens the file by the request path, the url prefix will be trimmed
+func (s staticFileSystem) Open(name string) (http.File, error) {
+	name = "/" + strings.TrimPrefix(strings.TrimPrefix(name, s.prefix), "/")
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return staticFile{File: f}, nil
+}
+
+type staticFile struct {
+	http.File
+}
+
+func (f staticFile) Stat() (os.FileInfo, error) {
+	fi, err := f.File.Stat()
+	if err != nil || !fi.ModTime().IsZero() {
+		return fi, err
+	}
+	return staticFileInfo{FileInfo: fi}, nil
+}
+
+type staticFileInfo struct {
+	os.FileInfo
+}
+
+func (fi staticFileInfo) ModTime() time.Time {
+	return staticFSModTime
+}
+
 // Walk walks the file tree rooted at root in filesystem, calling walkFn for each file or
 // directory in the tree, including root. All errors that arise visiting files
 // and directories are filtered by walkFn.
@@ -48,7 +48,6 @@ import (
 	"flag"
 	"net/http"
 	"os"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -94,6 +93,7 @@ var (
 func init() {
 	flag.BoolVar(&isChild, "graceful", false, "listen on open fd (after forking)")
 	flag.StringVar(&socketOrder, "socketorder", "", "previous initialization order - used when more than one listener was started")
+	flag.StringVar(&restartMode, "gracemode", "", "the restart mode of the old process - fork, reuseport or spawn")
 
 	regLock = &sync.Mutex{}
 	runningServers = make(map[string]*Server)
@@ -125,8 +125,8 @@ func NewServer(addr string, handler http.Handler, opts ...ServerOption) (srv *Se
 		flag.Parse()
 	}
 	if len(socketOrder) > 0 {
-		for i, addr := range strings.Split(socketOrder, ",") {
-			socketPtrOffsetMap[addr] = uint(i)
+		for addr, offset := range parseSocketOrder(socketOrder) {
+			socketPtrOffsetMap[addr] = offset
 		}
 	} else {
 		socketPtrOffsetMap[addr] = uint(len(runningServersOrder))
@@ -0,0 +1,155 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grace
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// RestartMode is the way that the new process takes over the listeners
+type RestartMode string
+
+const (
+	// RestartModeFork passes the listening sockets to the new process by inherited file descriptors.
+	// It's the default mode except Windows
+	RestartModeFork RestartMode = "fork"
+	// RestartModeReusePort makes the old and new processes listen on the same address with SO_REUSEPORT,
+	// the new process binds the address by itself, so it works under the supervisors closing the inherited files.
+	// It's supported on Linux, macOS and BSD
+	RestartModeReusePort RestartMode = "reuseport"
+	// RestartModeSpawn starts the new process and then shuts down the old one,
+	// the new process retries binding the address until the old one releases it.
+	// The connections may be refused for a moment, it's the fallback on Windows
+	RestartModeSpawn RestartMode = "spawn"
+)
+
+// DefaultRestartMode is the restart mode of servers
+var DefaultRestartMode = defaultRestartMode()
+
+// ErrNoRunningServer is returned by Restart if no server is running
+var ErrNoRunningServer = errors.New("grace: no running server")
+
+// restartMode is passed to the new process by -gracemode flag
+var restartMode string
+
+func defaultRestartMode() RestartMode {
+	if runtime.GOOS == "windows" {
+		return RestartModeSpawn
+	}
+	return RestartModeFork
+}
+
+func currentRestartMode() RestartMode {
+	if restartMode != "" {
+		return RestartMode(restartMode)
+	}
+	return DefaultRestartMode
+}
+
+// Restart starts the new process and hands over all running servers, like receiving SIGHUP.
+// It's useful on the platforms which can't send SIGHUP, Windows for example
+func Restart() error {
+	regLock.Lock()
+	var srv *Server
+	for _, s := range runningServers {
+		srv = s
+		break
+	}
+	regLock.Unlock()
+	if srv == nil {
+		return ErrNoRunningServer
+	}
+	return srv.Restart()
+}
+
+// Restart starts the new process and hands over all running servers
+func (srv *Server) Restart() error {
+	return srv.fork()
+}
+
+// IsChild reports whether the process is started by restarting
+func IsChild() bool {
+	return isChild
+}
+
+// Restarting reports whether the new process has been started and the servers are handing over
+func Restarting() bool {
+	regLock.Lock()
+	defer regLock.Unlock()
+	return runningServersForked
+}
+
+// State returns the state of server, StateInit, StateRunning, StateShuttingDown or StateTerminate
+func (srv *Server) State() uint8 {
+	return srv.state
+}
+
+// listen opens the listening socket by the restart mode
+func (srv *Server) listen(laddr string) (net.Listener, error) {
+	switch currentRestartMode() {
+	case RestartModeReusePort:
+		return listenReusePort(srv.Network, laddr)
+	case RestartModeSpawn:
+		if !srv.isChild {
+			return net.Listen(srv.Network, laddr)
+		}
+		// waits for the old process releasing the address
+		return listenWithBackoff(srv.Network, laddr, DefaultTimeout)
+	default:
+		return nil, fmt.Errorf("unknown restart mode %q", currentRestartMode())
+	}
+}
+
+// listenWithBackoff retries binding laddr until timeout, the delay doubles from 10ms up to 1s
+func listenWithBackoff(network, laddr string, timeout time.Duration) (net.Listener, error) {
+	const maxDelay = time.Second
+	deadline := time.Now().Add(timeout)
+	delay := 10 * time.Millisecond
+	for {
+		l, err := net.Listen(network, laddr)
+		if err == nil {
+			return l, nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("net.Listen error after retrying for %s: %v", timeout, err)
+		}
+		if delay > remaining {
+			delay = remaining
+		}
+		time.Sleep(delay)
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// parseSocketOrder parses -socketorder, the comma separated addresses of the inherited listeners,
+// and returns the offset of the file descriptor of each address from 3
+func parseSocketOrder(order string) map[string]uint {
+	offsets := make(map[string]uint)
+	if order == "" {
+		return offsets
+	}
+	for i, addr := range strings.Split(order, ",") {
+		offsets[addr] = uint(i)
+	}
+	return offsets
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grace
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSocketOrder(t *testing.T) {
+	assert.Equal(t, map[string]uint{}, parseSocketOrder(""))
+	assert.Equal(t, map[string]uint{":8080": 0, ":8443": 1}, parseSocketOrder(":8080,:8443"))
+}
+
+func TestListenWithBackoff(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	addr := l.Addr().String()
+
+	// the address is in use until the deadline
+	start := time.Now()
+	_, err = listenWithBackoff("tcp", addr, 100*time.Millisecond)
+	assert.NotNil(t, err)
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 100*time.Millisecond && elapsed < time.Second, elapsed)
+
+	// the address is released by the old process
+	time.AfterFunc(50*time.Millisecond, func() {
+		_ = l.Close()
+	})
+	l, err = listenWithBackoff("tcp", addr, time.Second)
+	assert.Nil(t, err)
+	assert.Nil(t, l.Close())
+}
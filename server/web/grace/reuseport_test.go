@@ -0,0 +1,40 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package grace
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenReusePort(t *testing.T) {
+	l1, err := listenReusePort("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l1.Close()
+
+	// the new process listens on the same address while the old one is still listening
+	l2, err := listenReusePort("tcp", l1.Addr().String())
+	assert.Nil(t, err)
+	defer l2.Close()
+	assert.Equal(t, l1.Addr().String(), l2.Addr().String())
+
+	// the plain listener can't share the address
+	_, err = net.Listen("tcp", l1.Addr().String())
+	assert.NotNil(t, err)
+}
@@ -38,10 +38,23 @@ func (srv *Server) Serve() (err error) {
 
 func (srv *Server) ServeWithListener(ln net.Listener) (err error) {
 	srv.ln = ln
+	if err = srv.notifyParent(); err != nil {
+		return err
+	}
 	go srv.handleSignals()
 	return srv.internalServe(ln)
 }
 
+// Listen opens the listening socket on srv.Addr, or takes the socket from the old process when restarted.
+// If srv.Addr is blank, ":http" is used.
+func (srv *Server) Listen() (net.Listener, error) {
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	return srv.getListener(addr)
+}
+
 func (srv *Server) internalServe(ln net.Listener) (err error) {
 	srv.state = StateRunning
 	defer func() { srv.state = StateTerminate }()
@@ -79,16 +92,8 @@ func (srv *Server) ListenAndServe() (err error) {
 		return err
 	}
 
-	if srv.isChild {
-		process, err := os.FindProcess(os.Getppid())
-		if err != nil {
-			log.Println(err)
-			return err
-		}
-		err = process.Signal(syscall.SIGTERM)
-		if err != nil {
-			return err
-		}
+	if err = srv.notifyParent(); err != nil {
+		return err
 	}
 
 	log.Println(os.Getpid(), srv.Addr)
@@ -126,12 +131,15 @@ func (srv *Server) ListenTLS(certFile string, keyFile string) (net.Listener, err
 		srv.TLSConfig.NextProtos = []string{"http/1.1"}
 	}
 
-	srv.TLSConfig.Certificates = make([]tls.Certificate, 1)
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		return nil, err
+	// the certificates are provided by GetCertificate, autocert for example
+	if certFile != "" || keyFile != "" || srv.TLSConfig.GetCertificate == nil {
+		srv.TLSConfig.Certificates = make([]tls.Certificate, 1)
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		srv.TLSConfig.Certificates[0] = cert
 	}
-	srv.TLSConfig.Certificates[0] = cert
 
 	go srv.handleSignals()
 
@@ -156,16 +164,8 @@ func (srv *Server) ListenAndServeMutualTLS(certFile, keyFile, trustFile string)
 }
 
 func (srv *Server) ServeTLS(ln net.Listener) error {
-	if srv.isChild {
-		process, err := os.FindProcess(os.Getppid())
-		if err != nil {
-			log.Println(err)
-			return err
-		}
-		err = process.Signal(syscall.SIGTERM)
-		if err != nil {
-			return err
-		}
+	if err := srv.notifyParent(); err != nil {
+		return err
 	}
 
 	go srv.handleSignals()
@@ -212,9 +212,26 @@ func (srv *Server) ListenMutualTLS(certFile string, keyFile string, trustFile st
 	return tlsListener, nil
 }
 
+// notifyParent asks the old process to shut down when the new process is ready.
+// In RestartModeSpawn the old process has shut down by itself
+func (srv *Server) notifyParent() error {
+	if !srv.isChild || currentRestartMode() == RestartModeSpawn {
+		return nil
+	}
+	process, err := os.FindProcess(os.Getppid())
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return process.Signal(syscall.SIGTERM)
+}
+
 // getListener either opens a new socket to listen on, or takes the acceptor socket
 // it got passed when restarted.
 func (srv *Server) getListener(laddr string) (l net.Listener, err error) {
+	if currentRestartMode() != RestartModeFork {
+		return srv.listen(laddr)
+	}
 	if srv.isChild {
 		var ptrOffset uint
 		if len(socketPtrOffsetMap) > 0 {
@@ -325,15 +342,21 @@ func (srv *Server) fork() (err error) {
 	}
 	runningServersForked = true
 
-	files := make([]*os.File, len(runningServers))
+	mode := currentRestartMode()
+	var files []*os.File
 	orderArgs := make([]string, len(runningServers))
+	if mode == RestartModeFork {
+		files = make([]*os.File, len(runningServers))
+	}
 	for _, srvPtr := range runningServers {
-		f, _ := srvPtr.ln.(*net.TCPListener).File()
-		files[socketPtrOffsetMap[srvPtr.Server.Addr]] = f
+		if files != nil {
+			f, _ := srvPtr.ln.(*net.TCPListener).File()
+			files[socketPtrOffsetMap[srvPtr.Server.Addr]] = f
+		}
 		orderArgs[socketPtrOffsetMap[srvPtr.Server.Addr]] = srvPtr.Server.Addr
 	}
 
-	log.Println(files)
+	log.Println(mode, files)
 	path := os.Args[0]
 	var args []string
 	if len(os.Args) > 1 {
@@ -345,6 +368,9 @@ func (srv *Server) fork() (err error) {
 		}
 	}
 	args = append(args, "-graceful")
+	if mode != RestartModeFork {
+		args = append(args, "-gracemode="+string(mode))
+	}
 	if len(runningServers) > 1 {
 		args = append(args, fmt.Sprintf(`-socketorder=%s`, strings.Join(orderArgs, ",")))
 		log.Println(args)
@@ -357,6 +383,12 @@ func (srv *Server) fork() (err error) {
 	if err != nil {
 		log.Fatalf("Restart: Failed to launch, error: %v", err)
 	}
+	if mode == RestartModeSpawn {
+		// the new process can't signal the old one on Windows, and it waits for the address
+		for _, srvPtr := range runningServers {
+			go srvPtr.shutdown()
+		}
+	}
 
 	return
 }
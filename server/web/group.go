@@ -0,0 +1,310 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+// GroupErrorHandler handles the panic raised by the handlers registered in a Group.
+// err is the value passed to panic
+type GroupErrorHandler func(ctx *beecontext.Context, err interface{})
+
+// Group registers routers with a shared prefix and a shared middleware stack.
+// Unlike Namespace, the routers are registered into the ControllerRegister directly,
+// so you don't need to call AddNamespace.
+// usage:
+//
+//	g := web.NewGroup("/api/v1", authFilter, traceFilter)
+//	g.Get("/users", listUsers).
+//	  Post("/users", createUser)
+//	admin := g.Group("/admin", adminOnly)
+//	admin.Router("/settings", &SettingsController{})
+type Group struct {
+	prefix   string
+	depth    int
+	handlers *ControllerRegister
+	parent   *Group
+	// routes are the routers registered in this group and the nested groups
+	routes *Tree
+}
+
+type groupErrorHandler struct {
+	group   *Group
+	handler GroupErrorHandler
+}
+
+// NewGroup creates a Group registered into BeeApp
+func NewGroup(prefix string, chains ...FilterChain) *Group {
+	return BeeApp.Group(prefix, chains...)
+}
+
+// Group creates a Group registered into this server
+func (app *HttpServer) Group(prefix string, chains ...FilterChain) *Group {
+	return newGroup(app.Handlers, nil, prefix, chains...)
+}
+
+func newGroup(handlers *ControllerRegister, parent *Group, prefix string, chains ...FilterChain) *Group {
+	g := &Group{
+		prefix:   prefix,
+		handlers: handlers,
+		parent:   parent,
+		routes:   NewTree(),
+	}
+	if parent != nil {
+		g.prefix = joinGroupPath(parent.prefix, prefix)
+		g.depth = parent.depth + 1
+	} else {
+		g.prefix = joinGroupPath("", prefix)
+	}
+	return g.Use(chains...)
+}
+
+// Prefix returns the full prefix of this group, including the prefixes of parents
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+// Group creates a nested group. The nested group inherits the prefix and
+// the middleware stack of g, and chains will be executed after g's middleware.
+func (g *Group) Group(prefix string, chains ...FilterChain) *Group {
+	return newGroup(g.handlers, g, prefix, chains...)
+}
+
+// Use appends middleware to this group.
+// It only affects the requests matching the routers registered in this group and the nested groups,
+// so the other groups with the same prefix don't share it.
+func (g *Group) Use(chains ...FilterChain) *Group {
+	for _, chain := range chains {
+		if chain == nil {
+			continue
+		}
+		chain := chain
+		g.handlers.InsertFilterChain("/*", func(next FilterFunc) FilterFunc {
+			grouped := chain(next)
+			return func(ctx *beecontext.Context) {
+				if g.match(ctx) {
+					grouped(ctx)
+				} else {
+					next(ctx)
+				}
+			}
+		})
+	}
+	return g
+}
+
+// OnError sets the error handler of this group.
+// If handlers in this group panic, h will be invoked instead of RecoverFunc.
+// The nested group uses the error handler of the nearest group which has one.
+func (g *Group) OnError(h GroupErrorHandler) *Group {
+	g.handlers.errorHandlers = append(g.handlers.errorHandlers, &groupErrorHandler{
+		group:   g,
+		handler: h,
+	})
+	return g
+}
+
+// Router same as HttpServer.Router
+func (g *Group) Router(rootpath string, c ControllerInterface, mappingMethods ...string) *Group {
+	g.handlers.Add(g.route(rootpath), c, WithRouterMethods(c, mappingMethods...))
+	return g
+}
+
+// RouterWithOpts same as HttpServer.RouterWithOpts
+func (g *Group) RouterWithOpts(rootpath string, c ControllerInterface, opts ...ControllerOption) *Group {
+	g.handlers.Add(g.route(rootpath), c, opts...)
+	return g
+}
+
+// AutoPrefix same as HttpServer.AutoPrefix
+func (g *Group) AutoPrefix(prefix string, c ControllerInterface) *Group {
+	g.addRoute(path.Join(g.path(prefix), "*"))
+	g.handlers.AddAutoPrefix(g.path(prefix), c)
+	return g
+}
+
+// Get same as HttpServer.Get
+func (g *Group) Get(rootpath string, f HandleFunc) *Group {
+	g.handlers.Get(g.route(rootpath), f)
+	return g
+}
+
+// Post same as HttpServer.Post
+func (g *Group) Post(rootpath string, f HandleFunc) *Group {
+	g.handlers.Post(g.route(rootpath), f)
+	return g
+}
+
+// Put same as HttpServer.Put
+func (g *Group) Put(rootpath string, f HandleFunc) *Group {
+	g.handlers.Put(g.route(rootpath), f)
+	return g
+}
+
+// Delete same as HttpServer.Delete
+func (g *Group) Delete(rootpath string, f HandleFunc) *Group {
+	g.handlers.Delete(g.route(rootpath), f)
+	return g
+}
+
+// Patch same as HttpServer.Patch
+func (g *Group) Patch(rootpath string, f HandleFunc) *Group {
+	g.handlers.Patch(g.route(rootpath), f)
+	return g
+}
+
+// Head same as HttpServer.Head
+func (g *Group) Head(rootpath string, f HandleFunc) *Group {
+	g.handlers.Head(g.route(rootpath), f)
+	return g
+}
+
+// Options same as HttpServer.Options
+func (g *Group) Options(rootpath string, f HandleFunc) *Group {
+	g.handlers.Options(g.route(rootpath), f)
+	return g
+}
+
+// Any same as HttpServer.Any
+func (g *Group) Any(rootpath string, f HandleFunc) *Group {
+	g.handlers.Any(g.route(rootpath), f)
+	return g
+}
+
+// Handler same as HttpServer.Handler
+func (g *Group) Handler(rootpath string, h http.Handler, options ...interface{}) *Group {
+	if len(options) > 0 {
+		if _, ok := options[0].(bool); ok {
+			g.addRoute(path.Join(g.path(rootpath), "*"))
+		}
+	}
+	g.handlers.Handler(g.route(rootpath), h, options...)
+	return g
+}
+
+// CtrlGet same as HttpServer.CtrlGet
+func (g *Group) CtrlGet(rootpath string, f interface{}) *Group {
+	g.handlers.CtrlGet(g.route(rootpath), f)
+	return g
+}
+
+// CtrlPost same as HttpServer.CtrlPost
+func (g *Group) CtrlPost(rootpath string, f interface{}) *Group {
+	g.handlers.CtrlPost(g.route(rootpath), f)
+	return g
+}
+
+// CtrlPut same as HttpServer.CtrlPut
+func (g *Group) CtrlPut(rootpath string, f interface{}) *Group {
+	g.handlers.CtrlPut(g.route(rootpath), f)
+	return g
+}
+
+// CtrlDelete same as HttpServer.CtrlDelete
+func (g *Group) CtrlDelete(rootpath string, f interface{}) *Group {
+	g.handlers.CtrlDelete(g.route(rootpath), f)
+	return g
+}
+
+// CtrlPatch same as HttpServer.CtrlPatch
+func (g *Group) CtrlPatch(rootpath string, f interface{}) *Group {
+	g.handlers.CtrlPatch(g.route(rootpath), f)
+	return g
+}
+
+// CtrlHead same as HttpServer.CtrlHead
+func (g *Group) CtrlHead(rootpath string, f interface{}) *Group {
+	g.handlers.CtrlHead(g.route(rootpath), f)
+	return g
+}
+
+// CtrlOptions same as HttpServer.CtrlOptions
+func (g *Group) CtrlOptions(rootpath string, f interface{}) *Group {
+	g.handlers.CtrlOptions(g.route(rootpath), f)
+	return g
+}
+
+// CtrlAny same as HttpServer.CtrlAny
+func (g *Group) CtrlAny(rootpath string, f interface{}) *Group {
+	g.handlers.CtrlAny(g.route(rootpath), f)
+	return g
+}
+
+func (g *Group) path(rootpath string) string {
+	return joinGroupPath(g.prefix, rootpath)
+}
+
+// route returns the full path of rootpath, and adds it to the routes of this group and the parents
+func (g *Group) route(rootpath string) string {
+	res := g.path(rootpath)
+	g.addRoute(res)
+	return res
+}
+
+func (g *Group) addRoute(pattern string) {
+	if !g.handlers.cfg.RouterCaseSensitive {
+		pattern = strings.ToLower(pattern)
+	}
+	for p := g; p != nil; p = p.parent {
+		p.routes.AddRouter(pattern, true)
+	}
+}
+
+// match returns true if the request matches the routers of this group, the params of ctx are kept
+func (g *Group) match(ctx *beecontext.Context) bool {
+	params := ctx.Input.Params()
+	ok, _ := g.routes.Match(g.handlers.getUrlPath(ctx), ctx).(bool)
+	ctx.Input.ResetParams()
+	for k, v := range params {
+		ctx.Input.SetParam(k, v)
+	}
+	return ok
+}
+
+func joinGroupPath(prefix, rootpath string) string {
+	res := path.Join("/", prefix, rootpath)
+	// keep the trailing slash, it's meaningful for some routers
+	if strings.HasSuffix(rootpath, "/") && res != "/" {
+		res += "/"
+	}
+	return res
+}
+
+// recoverGroupError invokes the error handler of the group which the request belongs to.
+// If there is no such group, it panics again so that RecoverFunc is able to handle it.
+func (p *ControllerRegister) recoverGroupError(ctx *beecontext.Context) {
+	err := recover()
+	if err == nil {
+		return
+	}
+	if err == ErrAbort {
+		panic(err)
+	}
+	var target *groupErrorHandler
+	for _, h := range p.errorHandlers {
+		if (target == nil || h.group.depth > target.group.depth) && h.group.match(ctx) {
+			target = h
+		}
+	}
+	if target == nil {
+		panic(err)
+	}
+	target.handler(ctx, err)
+}
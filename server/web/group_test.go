@@ -0,0 +1,144 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func headerChain(key, val string) FilterChain {
+	return func(next FilterFunc) FilterFunc {
+		return func(ctx *context.Context) {
+			ctx.Output.Header(key, ctx.ResponseWriter.Header().Get(key)+val)
+			next(ctx)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	app := NewHttpServerWithCfg(newBConfig())
+	g := app.Group("/api/v1", headerChain("trace", "a"))
+	g.Get("/user/:id", func(ctx *context.Context) {
+		_ = ctx.Output.Body([]byte("user" + ctx.Input.Param(":id")))
+	}).Post("/user", func(ctx *context.Context) {
+		_ = ctx.Output.Body([]byte("create"))
+	})
+	g.Group("/admin", headerChain("trace", "b")).
+		CtrlGet("/ping", ExampleController.Ping)
+	app.Get("/other", func(ctx *context.Context) {
+		_ = ctx.Output.Body([]byte("other"))
+	})
+	app.Handlers.Init()
+
+	testCases := []struct {
+		method string
+		url    string
+		body   string
+		trace  string
+	}{
+		{method: http.MethodGet, url: "/api/v1/user/1", body: "user1", trace: "a"},
+		{method: http.MethodPost, url: "/api/v1/user", body: "create", trace: "a"},
+		{method: http.MethodGet, url: "/api/v1/admin/ping", body: exampleBody, trace: "ab"},
+		{method: http.MethodGet, url: "/other", body: "other", trace: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			r, _ := http.NewRequest(tc.method, tc.url, nil)
+			w := httptest.NewRecorder()
+			app.Handlers.ServeHTTP(w, r)
+			assert.Equal(t, tc.body, w.Body.String())
+			assert.Equal(t, tc.trace, w.Header().Get("trace"))
+		})
+	}
+}
+
+func TestGroupScope(t *testing.T) {
+	app := NewHttpServerWithCfg(newBConfig())
+	body := func(b string) HandleFunc {
+		return func(ctx *context.Context) {
+			_ = ctx.Output.Body([]byte(b))
+		}
+	}
+	// the sibling groups with the same prefix don't share the middleware
+	app.Group("/api", headerChain("trace", "a")).Get("/users", body("users"))
+	app.Group("/api", headerChain("trace", "b")).Get("/orders", body("orders"))
+	// the router of the bare prefix
+	app.Group("/shop", headerChain("trace", "c")).Get("", body("shop"))
+	app.Handlers.Init()
+
+	testCases := []struct {
+		url   string
+		body  string
+		trace string
+	}{
+		{url: "/api/users", body: "users", trace: "a"},
+		{url: "/api/orders", body: "orders", trace: "b"},
+		{url: "/shop", body: "shop", trace: "c"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+			w := httptest.NewRecorder()
+			app.Handlers.ServeHTTP(w, r)
+			assert.Equal(t, tc.body, w.Body.String())
+			assert.Equal(t, tc.trace, w.Header().Get("trace"))
+		})
+	}
+}
+
+func TestGroupOnError(t *testing.T) {
+	app := NewHttpServerWithCfg(newBConfig())
+	g := app.Group("/api").OnError(func(ctx *context.Context, err interface{}) {
+		ctx.ResponseWriter.WriteHeader(http.StatusBadRequest)
+		_, _ = ctx.ResponseWriter.Write([]byte(fmt.Sprintf("api: %v", err)))
+	})
+	g.Get("/panic", func(ctx *context.Context) {
+		panic("oops")
+	})
+	g.Group("/v2").OnError(func(ctx *context.Context, err interface{}) {
+		ctx.ResponseWriter.WriteHeader(http.StatusTeapot)
+		_, _ = ctx.ResponseWriter.Write([]byte(fmt.Sprintf("v2: %v", err)))
+	}).Get("/panic", func(ctx *context.Context) {
+		panic("oops")
+	})
+	app.Get("/panic", func(ctx *context.Context) {
+		panic("oops")
+	})
+	app.Handlers.Init()
+
+	r, _ := http.NewRequest(http.MethodGet, "/api/panic", nil)
+	w := httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "api: oops", w.Body.String())
+
+	r, _ = http.NewRequest(http.MethodGet, "/api/v2/panic", nil)
+	w = httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "v2: oops", w.Body.String())
+
+	r, _ = http.NewRequest(http.MethodGet, "/panic", nil)
+	w = httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
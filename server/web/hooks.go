@@ -35,6 +35,7 @@ func registerDefaultErrorHandler() error {
 		"417": invalidxsrf,
 		"422": missingxsrf,
 		"413": payloadTooLarge,
+		"415": unsupportedMediaType,
 	}
 	for e, h := range m {
 		if _, ok := ErrorMaps[e]; !ok {
@@ -63,6 +64,14 @@ func registerSession() error {
 			conf.EnableSidInURLQuery = BConfig.WebConfig.Session.SessionEnableSidInURLQuery
 			conf.CookieSameSite = BConfig.WebConfig.Session.SessionCookieSameSite
 			conf.SessionIDPrefix = BConfig.WebConfig.Session.SessionIDPrefix
+			conf.IdleTimeout = BConfig.WebConfig.Session.SessionIdleTimeout
+			conf.AbsoluteTimeout = BConfig.WebConfig.Session.SessionAbsoluteTimeout
+			conf.RefreshMode = session.RefreshMode(BConfig.WebConfig.Session.SessionRefreshMode)
+			conf.RefreshInterval = BConfig.WebConfig.Session.SessionRefreshInterval
+			conf.EncryptionKeys = BConfig.WebConfig.Session.SessionEncryptionKeys
+			conf.Serializer = BConfig.WebConfig.Session.SessionSerializer
+			conf.GCBatchSize = BConfig.WebConfig.Session.SessionGCBatchSize
+			conf.GCMaxDuration = BConfig.WebConfig.Session.SessionGCMaxDuration
 		} else {
 			if err = json.Unmarshal([]byte(sessionConfig), conf); err != nil {
 				return err
@@ -87,6 +96,19 @@ func registerTemplate() error {
 	return nil
 }
 
+func registerClientIPResolver() error {
+	proxies := AppConfig.DefaultStrings("trustedProxies", BConfig.TrustedProxies)
+	if len(proxies) == 0 {
+		return nil
+	}
+	r, err := context.NewClientIPResolver(BConfig.ClientIPHeader, proxies...)
+	if err != nil {
+		return err
+	}
+	context.SetClientIPResolver(r)
+	return nil
+}
+
 func registerGzip() error {
 	if BConfig.EnableGzip {
 		context.InitGzip(
@@ -94,6 +116,7 @@ func registerGzip() error {
 			AppConfig.DefaultInt("gzipCompressLevel", -1),
 			AppConfig.DefaultStrings("includedMethods", []string{"GET"}),
 		)
+		context.InitCompressContentTypes(AppConfig.DefaultStrings("compressContentTypes", nil))
 	}
 	return nil
 }
@@ -0,0 +1,121 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"regexp"
+	"strings"
+
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+// hostPattern matches the host of request, the port is ignored.
+// The pattern is like admin.example.com, {tenant}.example.com or *.example.com,
+// {tenant} captures one label of host as the param :tenant, and * matches one label
+type hostPattern struct {
+	pattern string
+	re      *regexp.Regexp
+	names   []string
+	// exact means that the pattern doesn't contain * or {name}
+	exact bool
+}
+
+func newHostPattern(pattern string) *hostPattern {
+	h := &hostPattern{pattern: pattern, exact: true}
+	labels := strings.Split(strings.ToLower(pattern), ".")
+	for i, label := range labels {
+		switch {
+		case label == "*":
+			h.exact = false
+			labels[i] = `[^.]+`
+		case strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}"):
+			h.exact = false
+			h.names = append(h.names, ":"+label[1:len(label)-1])
+			labels[i] = `([^.]+)`
+		default:
+			labels[i] = regexp.QuoteMeta(label)
+		}
+	}
+	h.re = regexp.MustCompile(`^` + strings.Join(labels, `\.`) + `$`)
+	return h
+}
+
+// match checks the host of request and returns the captured params
+func (h *hostPattern) match(ctx *beecontext.Context) (map[string]string, bool) {
+	m := h.re.FindStringSubmatch(strings.ToLower(ctx.Input.Host()))
+	if m == nil {
+		return nil, false
+	}
+	if len(h.names) == 0 {
+		return nil, true
+	}
+	params := make(map[string]string, len(h.names))
+	for i, name := range h.names {
+		params[name] = m[i+1]
+	}
+	return params, true
+}
+
+// setHost sets the host pattern for the routers which don't have their own host patterns
+func setHost(t *Tree, host *hostPattern) {
+	if host == nil {
+		return
+	}
+	for _, v := range t.fixrouters {
+		setHost(v, host)
+	}
+	if t.wildcard != nil {
+		setHost(t.wildcard, host)
+	}
+	for _, l := range t.leaves {
+		if c, ok := l.runObject.(*ControllerInfo); ok && c.host == nil {
+			c.host = host
+		}
+	}
+}
+
+// hostRank is the priority of matching, the routers constrained by exact host are matched first,
+// then the routers constrained by host pattern, and the routers without host are matched at last
+func (t *Tree) hostRank() int {
+	rank := 0
+	for _, v := range t.fixrouters {
+		if r := v.hostRank(); r > rank {
+			rank = r
+		}
+	}
+	if t.wildcard != nil {
+		if r := t.wildcard.hostRank(); r > rank {
+			rank = r
+		}
+	}
+	for _, l := range t.leaves {
+		if r := l.hostRank(); r > rank {
+			rank = r
+		}
+	}
+	return rank
+}
+
+func (leaf *leafInfo) hostRank() int {
+	c, ok := leaf.runObject.(*ControllerInfo)
+	switch {
+	case !ok || c.host == nil:
+		return 0
+	case c.host.exact:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// newHTTP3Server returns nil if HTTP/3 is disabled or HTTPS is disabled
+func (app *HttpServer) newHTTP3Server(handler http.Handler) *http3.Server {
+	if !app.Cfg.Listen.EnableHTTP3 ||
+		!(app.Cfg.Listen.EnableHTTPS || app.Cfg.Listen.EnableMutualHTTPS) {
+		return nil
+	}
+	return &http3.Server{Handler: handler}
+}
+
+// altSvcHandler advertises HTTP/3 to the clients which are using HTTPS
+func altSvcHandler(h3 *http3.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			// it fails before the UDP listener being ready, just ignore it
+			_ = h3.SetQuicHeaders(rw.Header())
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// serveHTTP3 serves HTTP/3 on the UDP address addr.
+// tlsConfig is the configure of TLS listener, and the certificate files are loaded if they are not empty
+func serveHTTP3(h3 *http3.Server, addr string, tlsConfig *tls.Config, certFile, keyFile string) {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if certFile != "" && keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			logs.Critical("Load certificate for HTTP/3: ", err)
+			return
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	// the ALPN is set by http3 package
+	tlsConfig.NextProtos = nil
+	h3.Addr = addr
+	h3.TLSConfig = tlsConfig
+	logs.Info("http3 server Running on https://%s", addr)
+	if err := h3.ListenAndServe(); err != nil &&
+		!errors.Is(err, http.ErrServerClosed) && !errors.Is(err, quic.ErrServerClosed) {
+		logs.Critical("ListenAndServe HTTP/3: ", err)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpServer_newHTTP3Server(t *testing.T) {
+	cfg := newBConfig()
+	app := NewHttpServerWithCfg(cfg)
+	assert.Nil(t, app.newHTTP3Server(app.Handlers))
+
+	cfg.Listen.EnableHTTP3 = true
+	assert.Nil(t, app.newHTTP3Server(app.Handlers))
+
+	cfg.Listen.EnableHTTPS = true
+	h3 := app.newHTTP3Server(app.Handlers)
+	assert.NotNil(t, h3)
+	assert.Equal(t, app.Handlers, h3.Handler)
+}
+
+func TestAltSvcHandler(t *testing.T) {
+	cfg := newBConfig()
+	cfg.Listen.EnableHTTPS = true
+	cfg.Listen.EnableHTTP3 = true
+	app := NewHttpServerWithCfg(cfg)
+	h3 := app.newHTTP3Server(nil)
+	handler := altSvcHandler(h3, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/", nil))
+	assert.Empty(t, w.Header().Get("Alt-Svc"))
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "https://localhost/", nil)
+	r.TLS = &tls.ConnectionState{}
+	handler.ServeHTTP(w, r)
+	// it's empty before the server starts, and it should not block the request
+	assert.Equal(t, http.StatusOK, w.Code)
+}
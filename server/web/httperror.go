@@ -0,0 +1,177 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/beego/beego/v2/core/berror"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// ProblemJSON is the media type of RFC 7807 problem details
+const ProblemJSON = "application/problem+json"
+
+// HTTPError is an error with HTTP status code.
+// If a handler panics with it, or calls RenderError with it,
+// it will be rendered by the ErrorRenderer.
+// usage:
+//
+//	var CodeUserNotFound = berror.DefineCode(4040001, "user", "UserNotFound", "the user doesn't exist")
+//	panic(web.NewHTTPError(http.StatusNotFound, CodeUserNotFound, "user 1 not found"))
+type HTTPError struct {
+	Status int
+	// Code is optional, it's the business error code
+	Code    berror.Code
+	Message string
+	// Err is the cause of this error
+	Err error
+}
+
+// NewHTTPError creates HTTPError, the code could be nil
+func NewHTTPError(status int, code berror.Code, msg string) *HTTPError {
+	return &HTTPError{
+		Status:  status,
+		Code:    code,
+		Message: msg,
+	}
+}
+
+// Wrap sets the cause of error
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	e.Err = err
+	return e
+}
+
+// Error returns the message in the format of berror if the code is not nil,
+// so berror.FromError is able to get the code
+func (e *HTTPError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	if e.Code != nil {
+		msg = berror.Error(e.Code, msg).Error()
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// ProblemDetails is the response body of RFC 7807
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the extension member of business error code
+	Code uint32 `json:"code,omitempty"`
+	// Errors is the extension member of invalid fields, see Bind
+	Errors []*context.FieldError `json:"errors,omitempty"`
+}
+
+// NewProblemDetails converts the err to ProblemDetails,
+// the status is 400 for context.ParamError and 500 if err is not HTTPError.
+// The errors of fields are added if err wraps context.BindError
+func NewProblemDetails(ctx *context.Context, err error) *ProblemDetails {
+	pd := &ProblemDetails{
+		Type:     "about:blank",
+		Status:   http.StatusInternalServerError,
+		Instance: ctx.Request.URL.Path,
+	}
+	if httpErr, ok := toHTTPError(err); ok {
+		pd.Status = httpErr.Status
+		pd.Detail = httpErr.Message
+		if httpErr.Code != nil {
+			pd.Code = httpErr.Code.Code()
+		}
+	}
+	var bindErr *context.BindError
+	if errors.As(err, &bindErr) {
+		if pd.Detail == "" {
+			pd.Detail = bindErr.Message
+		}
+		pd.Errors = bindErr.Errors
+	}
+	pd.Title = http.StatusText(pd.Status)
+	return pd
+}
+
+// toHTTPError returns the HTTPError in the chain of err,
+// the ParamError returned by the typed getters of context is converted to 400 Bad Request
+func toHTTPError(err error) (*HTTPError, bool) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr, true
+	}
+	var paramErr *context.ParamError
+	if errors.As(err, &paramErr) {
+		return NewHTTPError(http.StatusBadRequest, nil, paramErr.Error()), true
+	}
+	return nil, false
+}
+
+// ErrorRenderer renders the error to response
+type ErrorRenderer func(ctx *context.Context, err error)
+
+// errorRenderer is nil unless SetErrorRenderer is invoked
+var errorRenderer ErrorRenderer
+
+// SetErrorRenderer sets the renderer for all the errors, including HTTPError,
+// the errors raised by Abort and Exception and the errors of router, such as 404 and 413.
+// Once it's set, the handlers registered by ErrorHandler and ErrorController are ignored.
+// usage:
+//
+//	web.SetErrorRenderer(web.DefaultErrorRenderer)
+func SetErrorRenderer(r ErrorRenderer) *HttpServer {
+	errorRenderer = r
+	return BeeApp
+}
+
+// RenderError renders the err by the renderer which is set by SetErrorRenderer, DefaultErrorRenderer by default
+func RenderError(ctx *context.Context, err error) {
+	r := errorRenderer
+	if r == nil {
+		r = DefaultErrorRenderer
+	}
+	r(ctx, err)
+}
+
+// DefaultErrorRenderer renders an HTML error page for browsers,
+// and application/problem+json for the others
+func DefaultErrorRenderer(ctx *context.Context, err error) {
+	pd := NewProblemDetails(ctx, err)
+	LogAccess(ctx, nil, pd.Status)
+	if strings.Contains(ctx.Input.Header("Accept"), "text/html") {
+		ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+		ctx.ResponseWriter.WriteHeader(pd.Status)
+		responseError(ctx.ResponseWriter, ctx.Request, pd.Status, template.HTMLEscapeString(pd.Detail))
+		return
+	}
+	content, _ := json.Marshal(pd)
+	ctx.Output.Header("Content-Type", ProblemJSON)
+	ctx.Output.SetStatus(pd.Status)
+	_ = ctx.Output.Body(content)
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/core/berror"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+var codeUserNotFound = berror.DefineCode(4040001, "user", "UserNotFound", "the user doesn't exist")
+
+func TestHTTPError(t *testing.T) {
+	err := NewHTTPError(http.StatusNotFound, codeUserNotFound, "user 1 not found")
+	code, ok := berror.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codeUserNotFound, code)
+
+	cause := errors.New("record not found")
+	err = NewHTTPError(http.StatusNotFound, nil, "").Wrap(cause)
+	assert.Equal(t, "Not Found: record not found", err.Error())
+	assert.True(t, errors.Is(err, cause))
+}
+
+func TestRenderHTTPError(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Get("/user/:id", func(ctx *context.Context) {
+		panic(NewHTTPError(http.StatusNotFound, codeUserNotFound, "user 1 not found"))
+	})
+	handler.Init()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/user/1", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, ProblemJSON, w.Header().Get("Content-Type"))
+	pd := &ProblemDetails{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), pd))
+	assert.Equal(t, &ProblemDetails{
+		Type:     "about:blank",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "user 1 not found",
+		Instance: "/user/1",
+		Code:     4040001,
+	}, pd)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/user/1", nil)
+	r.Header.Set("Accept", "text/html,application/xhtml+xml")
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), "user 1 not found"))
+}
+
+func TestRenderParamError(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Get("/user/:id", func(ctx *context.Context) {
+		if _, err := ctx.Input.ParamInt64(":id", context.Min(1)); err != nil {
+			panic(err)
+		}
+	})
+	handler.Init()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/user/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	pd := &ProblemDetails{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), pd))
+	assert.Equal(t, "invalid path parameter :id: should be an integer", pd.Detail)
+}
+
+func TestSetErrorRenderer(t *testing.T) {
+	SetErrorRenderer(func(ctx *context.Context, err error) {
+		pd := NewProblemDetails(ctx, err)
+		ctx.Output.SetStatus(pd.Status)
+		_ = ctx.Output.Body([]byte("custom " + pd.Title))
+	})
+	defer SetErrorRenderer(nil)
+
+	handler := NewControllerRegister()
+	handler.Init()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "custom Not Found", w.Body.String())
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	beecontext "github.com/beego/beego/v2/server/web/context"
+	"github.com/beego/beego/v2/server/web/i18n"
+)
+
+// I18n is the message bundle used by Controller.Tr and the template functions i18n and i18n_n.
+// usage:
+//
+//	//go:embed locales
+//	var locales embed.FS
+//
+//	web.I18n.LoadFS(locales, "locales")
+//
+// and in the templates:
+//
+//	{{i18n .Lang "hello" .Name}}
+//	{{i18n_n .Lang "apples" .Count}}
+var I18n = i18n.NewBundle("en-US")
+
+// the cookie storing the locale selected by query lasts one year
+const i18nCookieMaxAge = 365 * 24 * 3600
+
+func init() {
+	beegoTplFuncMap["i18n"] = func(lang, key string, args ...interface{}) string {
+		return I18n.Tr(lang, key, args...)
+	}
+	beegoTplFuncMap["i18n_n"] = func(lang, key string, n int, args ...interface{}) string {
+		return I18n.TrN(lang, key, n, args...)
+	}
+}
+
+// NegotiateLang returns the locale of request by the query parameter WebConfig.I18nQueryName,
+// the cookie WebConfig.I18nCookieName and the Accept-Language header in order.
+// The supported locale selected by query is saved to the cookie
+func NegotiateLang(ctx *beecontext.Context) string {
+	cfg := BConfig.WebConfig
+	if cfg.I18nQueryName != "" {
+		if lang := i18n.Match(ctx.Input.Query(cfg.I18nQueryName), I18n.Locales()); lang != "" {
+			if cfg.I18nCookieName != "" {
+				ctx.SetCookie(cfg.I18nCookieName, lang, i18nCookieMaxAge, "/")
+			}
+			return lang
+		}
+	}
+	candidates := make([]string, 0, 4)
+	if cfg.I18nCookieName != "" {
+		if lang := ctx.GetCookie(cfg.I18nCookieName); lang != "" {
+			candidates = append(candidates, lang)
+		}
+	}
+	for _, item := range ctx.Input.AcceptLanguages() {
+		if item.Q > 0 && item.Value != "*" {
+			candidates = append(candidates, item.Value)
+		}
+	}
+	return I18n.Negotiate(candidates...)
+}
+
+// Lang returns the locale of request negotiated by NegotiateLang,
+// it's stored in c.Data["Lang"] for the templates
+func (c *Controller) Lang() string {
+	if lang, ok := c.Data["Lang"].(string); ok && lang != "" {
+		return lang
+	}
+	lang := NegotiateLang(c.Ctx)
+	c.Data["Lang"] = lang
+	return lang
+}
+
+// prepareLang sets c.Data["Lang"] for the templates if there are catalogs
+func (c *Controller) prepareLang() {
+	if c.Ctx != nil && c.Data != nil && len(I18n.Locales()) > 0 {
+		c.Lang()
+	}
+}
+
+// Tr translates the key in the locale of request
+func (c *Controller) Tr(key string, args ...interface{}) string {
+	return I18n.Tr(c.Lang(), key, args...)
+}
+
+// TrN translates the plural message by n in the locale of request
+func (c *Controller) TrN(key string, n int, args ...interface{}) string {
+	return I18n.TrN(c.Lang(), key, n, args...)
+}
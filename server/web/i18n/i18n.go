@@ -0,0 +1,307 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n provides the message catalogs with CLDR plural rules,
+// locale fallback chains and Accept-Language negotiation.
+//
+// The catalog is a JSON file named by the locale, zh-CN.json for example:
+//
+//	{
+//		"hello": "Hello, %s",
+//		"apples": {"one": "%d apple", "other": "%d apples"},
+//		"nav": {"home": "Home"}
+//	}
+//
+// The nested objects are flattened by ".", "nav.home" for example,
+// and the objects whose keys are all plural categories are the plural messages.
+//
+// usage:
+//
+//	//go:embed locales
+//	var locales embed.FS
+//
+//	b := i18n.NewBundle("en-US")
+//	if err := b.LoadFS(locales, "locales"); err != nil {
+//		panic(err)
+//	}
+//	b.Tr("zh-CN", "hello", "beego")
+//	b.TrN("zh-CN", "apples", 3)
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Message is the translation of a key, the simple messages only have the Other form
+type Message map[PluralCategory]string
+
+// Bundle stores the catalogs of locales
+type Bundle struct {
+	defaultLocale string
+	locales       []string
+	catalogs      map[string]map[string]Message
+	fallbacks     map[string][]string
+	mutex         sync.RWMutex
+}
+
+// NewBundle creates the bundle, the messages missing in other locales are looked up in defaultLocale
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		defaultLocale: defaultLocale,
+		catalogs:      make(map[string]map[string]Message),
+		fallbacks:     make(map[string][]string),
+	}
+}
+
+// DefaultLocale returns the default locale
+func (b *Bundle) DefaultLocale() string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.defaultLocale
+}
+
+// SetDefaultLocale sets the default locale
+func (b *Bundle) SetDefaultLocale(locale string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.defaultLocale = locale
+}
+
+// Locales returns the locales in the order they were added
+func (b *Bundle) Locales() []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return append([]string(nil), b.locales...)
+}
+
+// Has reports whether the bundle has the catalog of locale
+func (b *Bundle) Has(locale string) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	_, ok := b.catalogs[canonical(locale)]
+	return ok
+}
+
+// SetFallback sets the locales which are looked up after locale and before its parent locales,
+// for example, SetFallback("pt-BR", "pt-PT")
+func (b *Bundle) SetFallback(locale string, fallbacks ...string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.fallbacks[canonical(locale)] = fallbacks
+}
+
+// AddMessages adds the messages to the catalog of locale, the existing messages are replaced
+func (b *Bundle) AddMessages(locale string, messages map[string]Message) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	key := canonical(locale)
+	catalog, ok := b.catalogs[key]
+	if !ok {
+		catalog = make(map[string]Message, len(messages))
+		b.catalogs[key] = catalog
+		b.locales = append(b.locales, locale)
+	}
+	for k, m := range messages {
+		catalog[k] = m
+	}
+}
+
+// Load adds the messages of JSON catalog to locale
+func (b *Bundle) Load(locale string, data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("i18n: invalid catalog of %s: %w", locale, err)
+	}
+	messages := make(map[string]Message, len(raw))
+	if err := flatten(messages, "", raw); err != nil {
+		return fmt.Errorf("i18n: invalid catalog of %s: %w", locale, err)
+	}
+	b.AddMessages(locale, messages)
+	return nil
+}
+
+// LoadFS loads the *.json catalogs in the dir of fsys, embed.FS for example.
+// The locale is the file name without extension
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		if err = b.Load(strings.TrimSuffix(path.Base(file), ".json"), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flatten(messages map[string]Message, prefix string, raw map[string]interface{}) error {
+	for k, v := range raw {
+		key := prefix + k
+		switch value := v.(type) {
+		case string:
+			messages[key] = Message{Other: value}
+		case map[string]interface{}:
+			if m, ok := pluralMessage(value); ok {
+				messages[key] = m
+				continue
+			}
+			if err := flatten(messages, key+".", value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("the message of %s should be string or object", key)
+		}
+	}
+	return nil
+}
+
+func pluralMessage(raw map[string]interface{}) (Message, bool) {
+	m := make(Message, len(raw))
+	for k, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		switch c := PluralCategory(k); c {
+		case Zero, One, Two, Few, Many, Other:
+			m[c] = s
+		default:
+			return nil, false
+		}
+	}
+	return m, len(m) > 0
+}
+
+// Negotiate returns the first supported locale matching the candidates,
+// the candidates are usually from the query, the cookie and the Accept-Language header.
+// It returns the default locale if none of them is supported
+func (b *Bundle) Negotiate(candidates ...string) string {
+	supported := b.Locales()
+	for _, c := range candidates {
+		if locale := Match(c, supported); locale != "" {
+			return locale
+		}
+	}
+	return b.DefaultLocale()
+}
+
+// FallbackChain returns the locales in which the messages of locale are looked up:
+// the locale itself, its fallbacks, its parent locales and the default locale
+func (b *Bundle) FallbackChain(locale string) []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	chain := make([]string, 0, 4)
+	seen := make(map[string]bool, 4)
+	var add func(locale string)
+	add = func(locale string) {
+		for l := canonical(locale); l != ""; l = parentLocale(l) {
+			if seen[l] {
+				continue
+			}
+			seen[l] = true
+			chain = append(chain, l)
+			for _, f := range b.fallbacks[l] {
+				add(f)
+			}
+		}
+	}
+	add(locale)
+	add(b.defaultLocale)
+	return chain
+}
+
+// message looks up the key along the fallback chain
+func (b *Bundle) message(locale, key string) (Message, string, bool) {
+	chain := b.FallbackChain(locale)
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, l := range chain {
+		if m, ok := b.catalogs[l][key]; ok {
+			return m, l, true
+		}
+	}
+	return nil, "", false
+}
+
+// Tr translates the key in locale and formats it by fmt.Sprintf with args.
+// It returns the key if the message can't be found in the fallback chain
+func (b *Bundle) Tr(locale, key string, args ...interface{}) string {
+	m, _, ok := b.message(locale, key)
+	if !ok {
+		return key
+	}
+	return format(m[Other], args)
+}
+
+// TrN translates the plural message by the plural category of n in the locale where the message is found.
+// The message is formatted with n if args are empty
+func (b *Bundle) TrN(locale, key string, n int, args ...interface{}) string {
+	m, found, ok := b.message(locale, key)
+	if !ok {
+		return key
+	}
+	msg, ok := m[Plural(found, n)]
+	if !ok {
+		msg = m[Other]
+	}
+	if len(args) == 0 {
+		args = []interface{}{n}
+	}
+	return format(msg, args)
+}
+
+func format(msg string, args []interface{}) string {
+	if len(args) == 0 {
+		return msg
+	}
+	// the message may omit the arguments, for example, "one apple" for TrN,
+	// so the extra arguments are dropped instead of being printed as %!(EXTRA ...)
+	if n := countArgs(msg); n >= 0 && n < len(args) {
+		args = args[:n]
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// countArgs returns the number of arguments consumed by the verbs in format,
+// or -1 if the explicit argument indexes like %[1]d are used
+func countArgs(format string) int {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		for i++; i < len(format) && strings.IndexByte("+-# 0123456789.*[", format[i]) >= 0; i++ {
+			switch format[i] {
+			case '[':
+				return -1
+			case '*':
+				n++
+			}
+		}
+		if i < len(format) && format[i] != '%' {
+			n++
+		}
+	}
+	return n
+}
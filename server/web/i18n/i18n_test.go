@@ -0,0 +1,103 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	fsys := fstest.MapFS{
+		"locales/en-US.json": {Data: []byte(`{
+			"hello": "Hello, %s",
+			"bye": "Bye",
+			"apples": {"one": "%d apple", "other": "%d apples"},
+			"nav": {"home": "Home", "other": "Other"}
+		}`)},
+		"locales/ru.json": {Data: []byte(`{
+			"hello": "Привет, %s",
+			"apples": {"one": "%d яблоко", "few": "%d яблока", "many": "%d яблок"}
+		}`)},
+		"locales/pt-PT.json": {Data: []byte(`{"bye": "Adeus"}`)},
+		"locales/README.md":  {Data: []byte(`not a catalog`)},
+	}
+	b := NewBundle("en-US")
+	require.Nil(t, b.LoadFS(fsys, "locales"))
+	return b
+}
+
+func TestBundleLoadFS(t *testing.T) {
+	b := newTestBundle(t)
+	assert.ElementsMatch(t, []string{"en-US", "ru", "pt-PT"}, b.Locales())
+	assert.True(t, b.Has("EN-us"))
+	assert.False(t, b.Has("README"))
+
+	assert.Equal(t, "Home", b.Tr("en-US", "nav.home"))
+	assert.Equal(t, "Other", b.Tr("en-US", "nav.other"))
+
+	err := b.Load("ja", []byte(`{"count": 1}`))
+	assert.NotNil(t, err)
+	assert.NotNil(t, b.Load("ja", []byte(`{`)))
+}
+
+func TestBundleTr(t *testing.T) {
+	b := newTestBundle(t)
+	assert.Equal(t, "Привет, beego", b.Tr("ru-RU", "hello", "beego"))
+	assert.Equal(t, "Bye", b.Tr("ru", "bye"))
+	assert.Equal(t, "missing", b.Tr("ru", "missing"))
+
+	assert.Equal(t, "1 apple", b.TrN("en-US", "apples", 1))
+	assert.Equal(t, "2 apples", b.TrN("en-US", "apples", 2))
+	assert.Equal(t, "21 яблоко", b.TrN("ru", "apples", 21))
+	assert.Equal(t, "3 яблока", b.TrN("ru", "apples", 3))
+	assert.Equal(t, "5 яблок", b.TrN("ru", "apples", 5))
+	// the plural rule of the locale where the message is found is used
+	assert.Equal(t, "1 apple", b.TrN("ja", "apples", 1))
+	assert.Equal(t, "9 apple", b.TrN("en-US", "apples", 1, 9))
+}
+
+func TestBundleTrExtraArgs(t *testing.T) {
+	b := NewBundle("en-US")
+	require.Nil(t, b.Load("en-US", []byte(`{"bye": "Bye", "rate": "100%% of %s", `+
+		`"width": "%*d", "apples": {"one": "an apple", "other": "%d apples"}}`)))
+	assert.Equal(t, "an apple", b.TrN("en-US", "apples", 1))
+	assert.Equal(t, "2 apples", b.TrN("en-US", "apples", 2))
+	assert.Equal(t, "Bye", b.Tr("en-US", "bye", "beego"))
+	assert.Equal(t, "100% of beego", b.Tr("en-US", "rate", "beego", "extra"))
+	assert.Equal(t, "  1", b.Tr("en-US", "width", 3, 1, 2))
+}
+
+func TestBundleFallbackChain(t *testing.T) {
+	b := newTestBundle(t)
+	assert.Equal(t, []string{"zh-hant-tw", "zh-hant", "zh", "en-us", "en"}, b.FallbackChain("zh-Hant-TW"))
+
+	assert.Equal(t, "Bye", b.Tr("pt-BR", "bye"))
+	b.SetFallback("pt-BR", "pt-PT")
+	assert.Equal(t, []string{"pt-br", "pt-pt", "pt", "en-us", "en"}, b.FallbackChain("pt-BR"))
+	assert.Equal(t, "Adeus", b.Tr("pt-BR", "bye"))
+}
+
+func TestBundleNegotiate(t *testing.T) {
+	b := newTestBundle(t)
+	assert.Equal(t, "ru", b.Negotiate("ja", "ru-RU", "en"))
+	assert.Equal(t, "en-US", b.Negotiate("ja"))
+	assert.Equal(t, "en-US", b.Negotiate())
+	b.SetDefaultLocale("ru")
+	assert.Equal(t, "ru", b.Negotiate("ja"))
+}
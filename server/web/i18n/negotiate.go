@@ -0,0 +1,61 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import "strings"
+
+// Match returns the supported locale matching the language tag, or "" if there is no match.
+// The exact match is preferred, otherwise the locales with the same prefix or base language are matched,
+// "zh-Hant-TW" matches "zh-Hant", and "en-GB" matches "en-US" if "en" isn't supported
+func Match(tag string, supported []string) string {
+	tag = canonical(tag)
+	for _, s := range supported {
+		if canonical(s) == tag {
+			return s
+		}
+	}
+	for parent := parentLocale(tag); parent != ""; parent = parentLocale(parent) {
+		for _, s := range supported {
+			if canonical(s) == parent {
+				return s
+			}
+		}
+	}
+	base := baseLanguage(tag)
+	for _, s := range supported {
+		if baseLanguage(s) == base {
+			return s
+		}
+	}
+	return ""
+}
+
+// canonical makes the locale case-insensitive and accepts "_" as the separator
+func canonical(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// parentLocale removes the last subtag, "zh-hant-tw" => "zh-hant"
+func parentLocale(locale string) string {
+	if i := strings.LastIndexByte(locale, '-'); i > 0 {
+		return locale[:i]
+	}
+	return ""
+}
+
+func baseLanguage(locale string) string {
+	base, _, _ := strings.Cut(canonical(locale), "-")
+	return base
+}
@@ -0,0 +1,32 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	supported := []string{"en-US", "zh-Hant", "zh-CN", "pt"}
+	assert.Equal(t, "zh-Hant", Match("zh-hant-tw", supported))
+	assert.Equal(t, "zh-CN", Match("zh_cn", supported))
+	assert.Equal(t, "zh-Hant", Match("zh", supported))
+	assert.Equal(t, "en-US", Match("en-GB", supported))
+	assert.Equal(t, "pt", Match("pt-BR", supported))
+	assert.Equal(t, "", Match("ja", supported))
+	assert.Equal(t, "", Match("", supported))
+}
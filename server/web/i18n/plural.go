@@ -0,0 +1,221 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strings"
+	"sync"
+)
+
+// PluralCategory is the CLDR plural category
+type PluralCategory string
+
+const (
+	Zero  PluralCategory = "zero"
+	One   PluralCategory = "one"
+	Two   PluralCategory = "two"
+	Few   PluralCategory = "few"
+	Many  PluralCategory = "many"
+	Other PluralCategory = "other"
+)
+
+// PluralRule returns the plural category of the integer n
+type PluralRule func(n int) PluralCategory
+
+var (
+	pluralRules     = map[string]PluralRule{}
+	pluralRulesLock sync.RWMutex
+)
+
+// RegisterPluralRule registers the plural rule of languages, it overrides the builtin rules.
+// The languages are the base language subtags, "pt" for example
+func RegisterPluralRule(rule PluralRule, langs ...string) {
+	pluralRulesLock.Lock()
+	defer pluralRulesLock.Unlock()
+	for _, lang := range langs {
+		pluralRules[strings.ToLower(lang)] = rule
+	}
+}
+
+// Plural returns the plural category of n in the locale by the CLDR plural rules of cardinal integers.
+// The languages without registered rule use the English rule
+func Plural(locale string, n int) PluralCategory {
+	pluralRulesLock.RLock()
+	rule, ok := pluralRules[baseLanguage(locale)]
+	pluralRulesLock.RUnlock()
+	if !ok {
+		rule = pluralOneOther
+	}
+	if n < 0 {
+		n = -n
+	}
+	return rule(n)
+}
+
+func init() {
+	RegisterPluralRule(pluralOther, "ja", "zh", "ko", "vi", "th", "id", "ms", "lo", "my", "km")
+	RegisterPluralRule(pluralOneOther, "en", "de", "nl", "sv", "da", "no", "nb", "nn", "fi", "et",
+		"el", "hu", "tr", "bg", "eu", "gl")
+	RegisterPluralRule(pluralRomance(false), "es", "it", "ca")
+	RegisterPluralRule(pluralRomance(true), "fr", "pt")
+	RegisterPluralRule(pluralEastSlavic, "ru", "uk", "be")
+	RegisterPluralRule(pluralPolish, "pl")
+	RegisterPluralRule(pluralCzech, "cs", "sk")
+	RegisterPluralRule(pluralSerbian, "hr", "sr", "bs")
+	RegisterPluralRule(pluralArabic, "ar")
+	RegisterPluralRule(pluralHebrew, "he")
+	RegisterPluralRule(pluralLithuanian, "lt")
+	RegisterPluralRule(pluralLatvian, "lv")
+	RegisterPluralRule(pluralRomanian, "ro")
+	RegisterPluralRule(pluralSlovenian, "sl")
+}
+
+func inRange(n, from, to int) bool {
+	return n >= from && n <= to
+}
+
+func pluralOther(int) PluralCategory {
+	return Other
+}
+
+func pluralOneOther(n int) PluralCategory {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+// pluralRomance uses "many" for the multiples of million, zeroIsOne is true for French and Portuguese
+func pluralRomance(zeroIsOne bool) PluralRule {
+	return func(n int) PluralCategory {
+		switch {
+		case n == 1 || (zeroIsOne && n == 0):
+			return One
+		case n != 0 && n%1000000 == 0:
+			return Many
+		}
+		return Other
+	}
+}
+
+func pluralEastSlavic(n int) PluralCategory {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return One
+	case inRange(n%10, 2, 4) && !inRange(n%100, 12, 14):
+		return Few
+	}
+	return Many
+}
+
+func pluralPolish(n int) PluralCategory {
+	switch {
+	case n == 1:
+		return One
+	case inRange(n%10, 2, 4) && !inRange(n%100, 12, 14):
+		return Few
+	}
+	return Many
+}
+
+func pluralCzech(n int) PluralCategory {
+	switch {
+	case n == 1:
+		return One
+	case inRange(n, 2, 4):
+		return Few
+	}
+	return Other
+}
+
+func pluralSerbian(n int) PluralCategory {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return One
+	case inRange(n%10, 2, 4) && !inRange(n%100, 12, 14):
+		return Few
+	}
+	return Other
+}
+
+func pluralArabic(n int) PluralCategory {
+	switch {
+	case n == 0:
+		return Zero
+	case n == 1:
+		return One
+	case n == 2:
+		return Two
+	case inRange(n%100, 3, 10):
+		return Few
+	case inRange(n%100, 11, 99):
+		return Many
+	}
+	return Other
+}
+
+func pluralHebrew(n int) PluralCategory {
+	switch n {
+	case 1:
+		return One
+	case 2:
+		return Two
+	}
+	return Other
+}
+
+func pluralLithuanian(n int) PluralCategory {
+	switch {
+	case inRange(n%100, 11, 19):
+		return Other
+	case n%10 == 1:
+		return One
+	case n%10 >= 2:
+		return Few
+	}
+	return Other
+}
+
+func pluralLatvian(n int) PluralCategory {
+	switch {
+	case n%10 == 0 || inRange(n%100, 11, 19):
+		return Zero
+	case n%10 == 1:
+		return One
+	}
+	return Other
+}
+
+func pluralRomanian(n int) PluralCategory {
+	switch {
+	case n == 1:
+		return One
+	case n == 0 || inRange(n%100, 2, 19):
+		return Few
+	}
+	return Other
+}
+
+func pluralSlovenian(n int) PluralCategory {
+	switch n % 100 {
+	case 1:
+		return One
+	case 2:
+		return Two
+	case 3, 4:
+		return Few
+	}
+	return Other
+}
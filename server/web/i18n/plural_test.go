@@ -0,0 +1,68 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlural(t *testing.T) {
+	testCases := []struct {
+		locale string
+		n      int
+		want   PluralCategory
+	}{
+		{locale: "en-US", n: 1, want: One},
+		{locale: "en-US", n: 0, want: Other},
+		{locale: "en", n: -1, want: One},
+		{locale: "zh-CN", n: 1, want: Other},
+		{locale: "fr", n: 0, want: One},
+		{locale: "fr", n: 2000000, want: Many},
+		{locale: "es", n: 0, want: Other},
+		{locale: "ru", n: 21, want: One},
+		{locale: "ru", n: 11, want: Many},
+		{locale: "ru", n: 23, want: Few},
+		{locale: "ru", n: 13, want: Many},
+		{locale: "pl", n: 1, want: One},
+		{locale: "pl", n: 21, want: Many},
+		{locale: "pl", n: 22, want: Few},
+		{locale: "cs", n: 3, want: Few},
+		{locale: "cs", n: 5, want: Other},
+		{locale: "ar", n: 0, want: Zero},
+		{locale: "ar", n: 2, want: Two},
+		{locale: "ar", n: 103, want: Few},
+		{locale: "ar", n: 111, want: Many},
+		{locale: "ar", n: 100, want: Other},
+		{locale: "lt", n: 11, want: Other},
+		{locale: "lt", n: 22, want: Few},
+		{locale: "lv", n: 10, want: Zero},
+		{locale: "ro", n: 19, want: Few},
+		{locale: "ro", n: 20, want: Other},
+		{locale: "sl", n: 102, want: Two},
+		{locale: "yy", n: 1, want: One},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, Plural(tc.locale, tc.n), "%s %d", tc.locale, tc.n)
+	}
+}
+
+func TestRegisterPluralRule(t *testing.T) {
+	RegisterPluralRule(func(n int) PluralCategory {
+		return Many
+	}, "xx")
+	assert.Equal(t, Many, Plural("XX-test", 1))
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/beego/beego/v2/server/web/context"
+	"github.com/beego/beego/v2/server/web/i18n"
+)
+
+func useTestI18n(t *testing.T) func() {
+	bundle := I18n
+	I18n = i18n.NewBundle("en-US")
+	require.Nil(t, I18n.Load("en-US", []byte(`{"hello": "Hello, %s", "apples": {"one": "%d apple", "other": "%d apples"}}`)))
+	require.Nil(t, I18n.Load("zh-CN", []byte(`{"hello": "你好，%s", "apples": {"other": "%d个苹果"}}`)))
+	return func() { I18n = bundle }
+}
+
+func newI18nController(r *http.Request) (*Controller, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	ctx := context.NewContext()
+	ctx.Reset(w, r)
+	return &Controller{Ctx: ctx, Data: map[interface{}]interface{}{}}, w
+}
+
+func TestNegotiateLang(t *testing.T) {
+	defer useTestI18n(t)()
+
+	r := httptest.NewRequest("GET", "/?lang=zh", nil)
+	r.Header.Set("Accept-Language", "en")
+	c, w := newI18nController(r)
+	assert.Equal(t, "zh-CN", c.Lang())
+	assert.Equal(t, "zh-CN", c.Data["Lang"])
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "lang=zh-CN")
+
+	r = httptest.NewRequest("GET", "/?lang=fr", nil)
+	r.AddCookie(&http.Cookie{Name: "lang", Value: "zh-CN"})
+	r.Header.Set("Accept-Language", "en")
+	c, w = newI18nController(r)
+	assert.Equal(t, "zh-CN", c.Lang())
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "fr;q=0.9, zh-TW;q=0.8, en;q=0.5")
+	c, _ = newI18nController(r)
+	assert.Equal(t, "zh-CN", c.Lang())
+
+	// q isn't the first parameter, and q=0 isn't acceptable
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "zh-TW;x=1;q=0, *;q=0.5, en;v=2;q=0.8")
+	c, _ = newI18nController(r)
+	assert.Equal(t, "en-US", c.Lang())
+
+	c, _ = newI18nController(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "en-US", c.Lang())
+}
+
+func TestControllerTr(t *testing.T) {
+	defer useTestI18n(t)()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "zh-CN")
+	c, _ := newI18nController(r)
+	assert.Equal(t, "你好，beego", c.Tr("hello", "beego"))
+	assert.Equal(t, "1个苹果", c.TrN("apples", 1))
+
+	c, _ = newI18nController(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "1 apple", c.TrN("apples", 1))
+	assert.Equal(t, "missing", c.Tr("missing"))
+}
+
+func TestI18nTemplateFuncs(t *testing.T) {
+	defer useTestI18n(t)()
+	dir := filepath.Join(t.TempDir(), "TestI18nTemplateFuncs")
+	writeTemplateFile(t, dir, "i18n.tpl", `{{i18n .Lang "hello" .Name}} {{i18n_n .Lang "apples" .Count}}`, time.Now())
+	require.Nil(t, AddViewPath(dir))
+
+	r := httptest.NewRequest("GET", "/?lang=zh-CN", nil)
+	c, _ := newI18nController(r)
+	c.ViewPath = dir
+	c.TplName = "i18n.tpl"
+	c.Data["Name"] = "beego"
+	c.Data["Count"] = 3
+	result, err := c.RenderString()
+	assert.Nil(t, err)
+	assert.Equal(t, "你好，beego 3个苹果", result)
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ClientCertVerifier checks the client certificate after it's verified by the trusted CA,
+// the chain starts with the client certificate and ends with the CA.
+// It's used to check the revocation by CRL or OCSP, and the handshake fails if it returns error
+type ClientCertVerifier func(cert *x509.Certificate, chain []*x509.Certificate) error
+
+// VerifyClientCert adds the verifiers of client certificate, they are executed in order.
+// It only works when EnableMutualHTTPS is true
+// usage:
+//
+//	web.BeeApp.VerifyClientCert(web.CRLVerifier(crl))
+func (app *HttpServer) VerifyClientCert(verifiers ...ClientCertVerifier) *HttpServer {
+	app.clientCertVerifiers = append(app.clientCertVerifiers, verifiers...)
+	return app
+}
+
+// CRLVerifier rejects the client certificates revoked by the CRL.
+// The CRL must be signed by the issuer of client certificate, and it must not expire
+func CRLVerifier(crl *x509.RevocationList) ClientCertVerifier {
+	revoked := make(map[string]struct{}, len(crl.RevokedCertificates))
+	for _, rc := range crl.RevokedCertificates {
+		revoked[rc.SerialNumber.String()] = struct{}{}
+	}
+	return func(cert *x509.Certificate, chain []*x509.Certificate) error {
+		if len(chain) < 2 || !bytes.Equal(cert.RawIssuer, crl.RawIssuer) {
+			return nil
+		}
+		if err := crl.CheckSignatureFrom(chain[1]); err != nil {
+			return fmt.Errorf("invalid CRL: %w", err)
+		}
+		if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+			return errors.New("the CRL is expired")
+		}
+		if _, ok := revoked[cert.SerialNumber.String()]; ok {
+			return fmt.Errorf("the client certificate %s is revoked", cert.SerialNumber)
+		}
+		return nil
+	}
+}
+
+// mutualTLSConfig returns the TLS config verifying client certificates by TrustCaFile
+func (app *HttpServer) mutualTLSConfig() (*tls.Config, error) {
+	data, err := os.ReadFile(app.Cfg.Listen.TrustCaFile)
+	if err != nil {
+		return nil, fmt.Errorf("MutualHTTPS should provide TrustCaFile: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate is found in TrustCaFile %s", app.Cfg.Listen.TrustCaFile)
+	}
+	return &tls.Config{
+		ClientCAs:             pool,
+		ClientAuth:            tls.ClientAuthType(app.Cfg.Listen.ClientAuth),
+		VerifyPeerCertificate: app.verifyPeerCertificate,
+	}, nil
+}
+
+func (app *HttpServer) verifyPeerCertificate(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(app.clientCertVerifiers) == 0 || len(verifiedChains) == 0 {
+		return nil
+	}
+	// the chains share the same leaf, and any chain passing the verifiers is accepted
+	var err error
+	for _, chain := range verifiedChains {
+		if err = app.verifyChain(chain); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (app *HttpServer) verifyChain(chain []*x509.Certificate) error {
+	for _, v := range app.clientCertVerifiers {
+		if err := v(chain[0], chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
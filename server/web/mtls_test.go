@@ -0,0 +1,142 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+type clientCertController struct {
+	Controller
+}
+
+func (c *clientCertController) Get() {
+	cc := c.Ctx.Input.ClientCert()
+	c.Ctx.WriteString(cc.CommonName + ":" + cc.Fingerprint[:8])
+}
+
+func newTestCert(t *testing.T, serial int64, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+	}
+	if parent == nil {
+		tpl.IsCA = true
+		tpl.BasicConstraintsValid = true
+		tpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return cert, key
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca, caKey := newTestCert(t, 1, "ca", nil, nil)
+	alice, aliceKey := newTestCert(t, 2, "alice", ca, caKey)
+	bob, bobKey := newTestCert(t, 3, "bob", ca, caKey)
+	crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(1),
+		ThisUpdate:          time.Now().Add(-time.Hour),
+		NextUpdate:          time.Now().Add(time.Hour),
+		RevokedCertificates: []pkix.RevokedCertificate{{SerialNumber: bob.SerialNumber, RevocationTime: time.Now()}},
+	}, ca, caKey)
+	assert.Nil(t, err)
+	crl, err := x509.ParseRevocationList(crlDER)
+	assert.Nil(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.Nil(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0o600))
+
+	cfg := *BConfig
+	cfg.Listen.TrustCaFile = caFile
+	cfg.Listen.ClientAuth = int(tls.VerifyClientCertIfGiven)
+	app := NewHttpServerWithCfg(&cfg)
+	app.VerifyClientCert(CRLVerifier(crl))
+	app.Handlers.Get("/public", func(ctx *context.Context) {
+		_ = ctx.Output.Body([]byte("public"))
+	})
+	app.Handlers.Add("/secure", &clientCertController{}, WithRouterClientCert())
+
+	tlsConfig, err := app.mutualTLSConfig()
+	assert.Nil(t, err)
+	srv := httptest.NewUnstartedServer(app.Handlers)
+	srv.TLS = tlsConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	get := func(cert *x509.Certificate, key *ecdsa.PrivateKey, path string) (int, string, error) {
+		clientConfig := &tls.Config{RootCAs: pool}
+		if cert != nil {
+			clientConfig.Certificates = []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), nil
+	}
+
+	code, body, err := get(nil, nil, "/public")
+	assert.Nil(t, err)
+	assert.Equal(t, "public", body)
+
+	code, _, err = get(nil, nil, "/secure")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, body, err = get(alice, aliceKey, "/secure")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	sum := sha256.Sum256(alice.Raw)
+	assert.Equal(t, "alice:"+hex.EncodeToString(sum[:4]), body)
+
+	// revoked by CRL
+	_, _, err = get(bob, bobKey, "/secure")
+	assert.NotNil(t, err)
+}
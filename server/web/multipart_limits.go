@@ -0,0 +1,243 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// MultipartLimits restricts the multipart body of the upload router.
+// The body is checked while it's being read, so the violating request is rejected
+// with 413 or 415 as soon as the violating part arrives instead of after reading the whole body
+type MultipartLimits struct {
+	// MaxParts is the max number of parts, including the form values and the files, 0 means no limit
+	MaxParts int
+	// MaxPartHeaderSize is the max bytes of the MIME header of each part, 0 means no limit
+	MaxPartHeaderSize int
+	// MaxFiles is the max number of file parts, 0 means no limit
+	MaxFiles int
+	// AllowedTypes is the allowed Content-Type of the file parts, "image/*" matches all images.
+	// Empty means all types are allowed
+	AllowedTypes []string
+}
+
+// MultipartLimitError is returned by reading the body which violates MultipartLimits,
+// the handlers reading the body by themselves, StreamUpload for example, can get it by errors.As
+type MultipartLimitError struct {
+	// Status is 413 or 415
+	Status int
+	Reason string
+}
+
+func (e *MultipartLimitError) Error() string {
+	return "multipart limit: " + e.Reason
+}
+
+func (l *MultipartLimits) allowType(contentType string) bool {
+	if len(l.AllowedTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range l.AllowedTypes {
+		t = strings.ToLower(t)
+		if t == mediaType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// multipartLimitReader passes the body to a multipart reader running in another goroutine,
+// which checks the parts and stops the body once any limit is violated.
+// Each chunk of body is returned after the checker has checked it, so the violation is found
+// before the handler reads the violating part
+type multipartLimitReader struct {
+	body      io.ReadCloser
+	chunks    chan []byte
+	next      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	limits    MultipartLimits
+	err       *MultipartLimitError
+	mutex     sync.Mutex
+}
+
+// limitMultipartBody wraps the body of r, it returns nil if the request isn't multipart
+func limitMultipartBody(r *http.Request, limits MultipartLimits) *multipartLimitReader {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || params["boundary"] == "" {
+		return nil
+	}
+	lr := &multipartLimitReader{
+		body:   r.Body,
+		chunks: make(chan []byte),
+		next:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		limits: limits,
+	}
+	go lr.check(params["boundary"])
+	r.Body = lr
+	return lr
+}
+
+func (lr *multipartLimitReader) Read(p []byte) (int, error) {
+	if err := lr.Err(); err != nil {
+		return 0, err
+	}
+	n, err := lr.body.Read(p)
+	if n > 0 {
+		lr.feed(p[:n])
+		if limitErr := lr.Err(); limitErr != nil {
+			return 0, limitErr
+		}
+	}
+	if err != nil {
+		lr.closeFeed()
+		<-lr.done
+		if limitErr := lr.Err(); limitErr != nil {
+			return 0, limitErr
+		}
+	}
+	return n, err
+}
+
+// feed hands the chunk to the checker and waits until the checker asks for the next one
+func (lr *multipartLimitReader) feed(chunk []byte) {
+	select {
+	case lr.chunks <- chunk:
+	case <-lr.done:
+		return
+	}
+	select {
+	case <-lr.next:
+	case <-lr.done:
+	}
+}
+
+func (lr *multipartLimitReader) closeFeed() {
+	lr.closeOnce.Do(func() {
+		close(lr.chunks)
+	})
+}
+
+// Close stops the checker and closes the body
+func (lr *multipartLimitReader) Close() error {
+	lr.closeFeed()
+	<-lr.done
+	return lr.body.Close()
+}
+
+// Err returns the violation of limits
+func (lr *multipartLimitReader) Err() *MultipartLimitError {
+	if lr == nil {
+		return nil
+	}
+	lr.mutex.Lock()
+	defer lr.mutex.Unlock()
+	return lr.err
+}
+
+func (lr *multipartLimitReader) check(boundary string) {
+	defer close(lr.done)
+	// the malformed body is left to the parser of handler
+	mr := multipart.NewReader(&multipartFeedReader{lr: lr}, boundary)
+	parts, files := 0, 0
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return
+		}
+		parts++
+		if limitErr := lr.checkPart(part, parts, &files); limitErr != nil {
+			lr.mutex.Lock()
+			lr.err = limitErr
+			lr.mutex.Unlock()
+			return
+		}
+		if _, err = io.Copy(io.Discard, part); err != nil {
+			return
+		}
+	}
+}
+
+// multipartFeedReader reads the chunks fed to the checker
+type multipartFeedReader struct {
+	lr      *multipartLimitReader
+	buf     []byte
+	started bool
+}
+
+func (r *multipartFeedReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.started {
+			// the previous chunk is consumed
+			r.lr.next <- struct{}{}
+		}
+		r.started = true
+		chunk, ok := <-r.lr.chunks
+		if !ok {
+			return 0, io.EOF
+		}
+		r.buf = chunk
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (lr *multipartLimitReader) checkPart(part *multipart.Part, parts int, files *int) *MultipartLimitError {
+	l := &lr.limits
+	if l.MaxParts > 0 && parts > l.MaxParts {
+		return &MultipartLimitError{Status: http.StatusRequestEntityTooLarge,
+			Reason: fmt.Sprintf("more than %d parts", l.MaxParts)}
+	}
+	if l.MaxPartHeaderSize > 0 && headerSize(part.Header) > l.MaxPartHeaderSize {
+		return &MultipartLimitError{Status: http.StatusRequestEntityTooLarge,
+			Reason: fmt.Sprintf("the header of part %d is larger than %d bytes", parts, l.MaxPartHeaderSize)}
+	}
+	if part.FileName() == "" {
+		return nil
+	}
+	*files++
+	if l.MaxFiles > 0 && *files > l.MaxFiles {
+		return &MultipartLimitError{Status: http.StatusRequestEntityTooLarge,
+			Reason: fmt.Sprintf("more than %d files", l.MaxFiles)}
+	}
+	if contentType := part.Header.Get("Content-Type"); !l.allowType(contentType) {
+		return &MultipartLimitError{Status: http.StatusUnsupportedMediaType,
+			Reason: fmt.Sprintf("the type %q of file %s is not allowed", contentType, part.FileName())}
+	}
+	return nil
+}
+
+func headerSize(h textproto.MIMEHeader) int {
+	size := 0
+	for k, vs := range h {
+		for _, v := range vs {
+			size += len(k) + len(v) + 4 // ": " and CRLF
+		}
+	}
+	return size
+}
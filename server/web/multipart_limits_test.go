@@ -0,0 +1,130 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type multipartFile struct {
+	name        string
+	contentType string
+	header      string
+}
+
+func newMultipartRequest(t *testing.T, url string, values int, files ...multipartFile) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for i := 0; i < values; i++ {
+		require.Nil(t, w.WriteField("field", "value"))
+	}
+	for _, f := range files {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", `form-data; name="file"; filename="`+f.name+`"`)
+		h.Set("Content-Type", f.contentType)
+		if f.header != "" {
+			h.Set("X-Extra", f.header)
+		}
+		fw, err := w.CreatePart(h)
+		require.Nil(t, err)
+		_, err = fw.Write(bytes.Repeat([]byte("a"), 1024))
+		require.Nil(t, err)
+	}
+	require.Nil(t, w.Close())
+	r := httptest.NewRequest(http.MethodPost, url, body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+type limitedUploadController struct {
+	Controller
+}
+
+func (c *limitedUploadController) Post() {
+	if c.Ctx.Input.URL() == "/stream" {
+		_, err := StreamUpload(c.Ctx, NewLocalUploadStorage(c.Ctx.Input.Header("X-Dir")), UploadOptions{})
+		var limitErr *MultipartLimitError
+		if errors.As(err, &limitErr) {
+			c.Ctx.Output.SetStatus(limitErr.Status)
+			return
+		}
+		_ = c.Ctx.Output.Body([]byte("ok"))
+		return
+	}
+	_, fh, err := c.GetFile("file")
+	if err != nil {
+		c.Ctx.Output.SetStatus(http.StatusBadRequest)
+		return
+	}
+	_ = c.Ctx.Output.Body([]byte(fh.Filename))
+}
+
+func TestMultipartLimits(t *testing.T) {
+	cfg := *BConfig
+	app := NewHttpServerWithCfg(&cfg)
+	limits := MultipartLimits{
+		MaxParts:          4,
+		MaxPartHeaderSize: 256,
+		MaxFiles:          2,
+		AllowedTypes:      []string{"image/*", "application/pdf"},
+	}
+	app.RouterWithOpts("/upload", &limitedUploadController{}, WithRouterMultipartLimits(limits))
+	app.RouterWithOpts("/stream", &limitedUploadController{},
+		WithRouterMultipartLimits(limits), WithRouterStreamUpload())
+
+	testCases := []struct {
+		name   string
+		values int
+		files  []multipartFile
+		status int
+	}{
+		{name: "ok", values: 1, files: []multipartFile{{name: "a.png", contentType: "image/png"},
+			{name: "b.pdf", contentType: "application/pdf"}}, status: http.StatusOK},
+		{name: "too many parts", values: 4, files: []multipartFile{{name: "a.png", contentType: "image/png"}},
+			status: http.StatusRequestEntityTooLarge},
+		{name: "too many files", files: []multipartFile{{name: "a.png", contentType: "image/png"},
+			{name: "b.png", contentType: "image/png"}, {name: "c.png", contentType: "image/png"}},
+			status: http.StatusRequestEntityTooLarge},
+		{name: "large header", files: []multipartFile{{name: "a.png", contentType: "image/png",
+			header: strings.Repeat("h", 256)}}, status: http.StatusRequestEntityTooLarge},
+		{name: "unsupported type", files: []multipartFile{{name: "a.exe", contentType: "application/octet-stream"}},
+			status: http.StatusUnsupportedMediaType},
+	}
+	for _, path := range []string{"/upload", "/stream"} {
+		for _, tc := range testCases {
+			t.Run(path+" "+tc.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				r := newMultipartRequest(t, path, tc.values, tc.files...)
+				r.Header.Set("X-Dir", t.TempDir())
+				app.Handlers.ServeHTTP(w, r)
+				assert.Equal(t, tc.status, w.Code)
+			})
+		}
+	}
+
+	w := httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, newMultipartRequest(t, "/upload", 0, multipartFile{name: "a.png", contentType: "image/png"}))
+	assert.Equal(t, "a.png", w.Body.String())
+}
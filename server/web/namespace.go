@@ -30,6 +30,11 @@ type LinkNamespace func(*Namespace)
 type Namespace struct {
 	prefix   string
 	handlers *ControllerRegister
+	// the limits of request body, see BodyLimit
+	maxMemory     int64
+	maxUploadSize int64
+	// the host pattern of routers, see Host
+	host *hostPattern
 }
 
 // NewNamespace get new Namespace
@@ -99,6 +104,30 @@ func (n *Namespace) Filter(action string, filter ...FilterFunc) *Namespace {
 	return n
 }
 
+// BodyLimit overrides Config.MaxMemory and Config.MaxUploadSize for the routers in this namespace,
+// 0 means no overriding. The limits set by WithRouterMaxMemory and WithRouterMaxUploadSize take precedence
+// usage:
+//
+//	web.NewNamespace("/upload").BodyLimit(0, 1<<30)
+func (n *Namespace) BodyLimit(maxMemory, maxUploadSize int64) *Namespace {
+	n.maxMemory = maxMemory
+	n.maxUploadSize = maxUploadSize
+	return n
+}
+
+// Host constrains the routers in this namespace by the host of request,
+// the host pattern set by WithRouterHost takes precedence.
+// The pattern is like admin.example.com or {tenant}.example.com,
+// and the captured label can be got by ctx.Input.Param(":tenant").
+// Notice that the filters of namespace are not constrained by the host
+// usage:
+//
+//	web.NewNamespace("/").Host("{tenant}.example.com")
+func (n *Namespace) Host(pattern string) *Namespace {
+	n.host = newHostPattern(pattern)
+	return n
+}
+
 // Router same as beego.Rourer
 // refer: https://godoc.org/github.com/beego/beego/v2#Router
 func (n *Namespace) Router(rootpath string, c ControllerInterface, mappingMethods ...string) *Namespace {
@@ -260,6 +289,8 @@ func (n *Namespace) CtrlAny(rootpath string, f interface{}) *Namespace {
 func (n *Namespace) Namespace(ns ...*Namespace) *Namespace {
 	for _, ni := range ns {
 		for k, v := range ni.handlers.routers {
+			setBodyLimit(v, ni.maxMemory, ni.maxUploadSize)
+			setHost(v, ni.host)
 			if _, ok := n.handlers.routers[k]; ok {
 				addPrefix(v, ni.prefix)
 				n.handlers.routers[k].AddTree(ni.prefix, v)
@@ -289,6 +320,8 @@ func (n *Namespace) Namespace(ns ...*Namespace) *Namespace {
 func AddNamespace(nl ...*Namespace) {
 	for _, n := range nl {
 		for k, v := range n.handlers.routers {
+			setBodyLimit(v, n.maxMemory, n.maxUploadSize)
+			setHost(v, n.host)
 			if _, ok := BeeApp.Handlers.routers[k]; ok {
 				addPrefix(v, n.prefix)
 				BeeApp.Handlers.routers[k].AddTree(n.prefix, v)
@@ -328,6 +361,29 @@ func addPrefix(t *Tree, prefix string) {
 	}
 }
 
+// setBodyLimit sets the limits of request body for the routers which don't have their own limits
+func setBodyLimit(t *Tree, maxMemory, maxUploadSize int64) {
+	if maxMemory <= 0 && maxUploadSize <= 0 {
+		return
+	}
+	for _, v := range t.fixrouters {
+		setBodyLimit(v, maxMemory, maxUploadSize)
+	}
+	if t.wildcard != nil {
+		setBodyLimit(t.wildcard, maxMemory, maxUploadSize)
+	}
+	for _, l := range t.leaves {
+		if c, ok := l.runObject.(*ControllerInfo); ok {
+			if c.maxMemory <= 0 {
+				c.maxMemory = maxMemory
+			}
+			if c.maxUploadSize <= 0 {
+				c.maxUploadSize = maxUploadSize
+			}
+		}
+	}
+}
+
 // NSCond is Namespace Condition
 func NSCond(cond namespaceCond) LinkNamespace {
 	return func(ns *Namespace) {
@@ -349,6 +405,20 @@ func NSAfter(filterList ...FilterFunc) LinkNamespace {
 	}
 }
 
+// NSBodyLimit sets the limits of request body for the routers in Namespace, see Namespace.BodyLimit
+func NSBodyLimit(maxMemory, maxUploadSize int64) LinkNamespace {
+	return func(ns *Namespace) {
+		ns.BodyLimit(maxMemory, maxUploadSize)
+	}
+}
+
+// NSHost constrains the routers in Namespace by the host of request, see Namespace.Host
+func NSHost(pattern string) LinkNamespace {
+	return func(ns *Namespace) {
+		ns.Host(pattern)
+	}
+}
+
 // NSInclude Namespace Include ControllerInterface
 func NSInclude(cList ...ControllerInterface) LinkNamespace {
 	return func(ns *Namespace) {
@@ -19,8 +19,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/beego/beego/v2/server/web/context"
 )
 
@@ -73,6 +76,25 @@ func TestNamespaceGet(t *testing.T) {
 	}
 }
 
+func TestNamespaceRootTrailingSlashStrict(t *testing.T) {
+	policy := BeeApp.Handlers.cfg.TrailingSlash
+	BeeApp.Handlers.cfg.TrailingSlash = TrailingSlashStrict
+	defer func() {
+		BeeApp.Handlers.cfg.TrailingSlash = policy
+	}()
+
+	ns := NewNamespace("/strict")
+	ns.Router("/", &TestController{}, "get:List")
+	AddNamespace(ns)
+
+	w := httptest.NewRecorder()
+	BeeApp.Handlers.ServeHTTP(w, httptest.NewRequest("GET", "/strict/", nil))
+	assert.Equal(t, "i am list", w.Body.String())
+	w = httptest.NewRecorder()
+	BeeApp.Handlers.ServeHTTP(w, httptest.NewRequest("GET", "/strict", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func TestNamespacePost(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/v1/user/123", nil)
 	w := httptest.NewRecorder()
@@ -413,3 +435,58 @@ func TestNamespaceNSCtrlAny(t *testing.T) {
 		}
 	}
 }
+
+func TestNamespaceBodyLimit(t *testing.T) {
+	ns := NewNamespace("/limit", NSBodyLimit(20, 0),
+		NSPost("/user", func(ctx *context.Context) {
+			ctx.Output.Body([]byte("user"))
+		}),
+		NSNamespace("/admin", NSBodyLimit(10, 0),
+			NSPost("/user", func(ctx *context.Context) {
+				ctx.Output.Body([]byte("admin"))
+			}),
+		),
+	)
+	AddNamespace(ns)
+
+	serve := func(url string, size int) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("POST", url, strings.NewReader(strings.Repeat("b", size)))
+		w := httptest.NewRecorder()
+		BeeApp.Handlers.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve("/limit/user", 30).Code)
+	assert.Equal(t, "user", serve("/limit/user", 15).Body.String())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve("/limit/admin/user", 15).Code)
+	assert.Equal(t, "admin", serve("/limit/admin/user", 5).Body.String())
+}
+
+func TestNamespaceHost(t *testing.T) {
+	BeeApp.Handlers.Get("/site/index", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("default"))
+	})
+	AddNamespace(
+		NewNamespace("/site", NSHost("admin.example.com"),
+			NSGet("/index", func(ctx *context.Context) {
+				ctx.Output.Body([]byte("admin"))
+			}),
+		),
+		NewNamespace("/site", NSHost("{tenant}.example.com"),
+			NSGet("/index", func(ctx *context.Context) {
+				ctx.Output.Body([]byte("tenant " + ctx.Input.Param(":tenant")))
+			}),
+		),
+	)
+
+	serve := func(url string) string {
+		r, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		BeeApp.Handlers.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "admin", serve("http://admin.example.com:8080/site/index"))
+	assert.Equal(t, "tenant beego", serve("http://beego.example.com/site/index"))
+	assert.Equal(t, "default", serve("http://example.com/site/index"))
+}
@@ -0,0 +1,255 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/beego/beego/v2/server/web/openapi"
+)
+
+// matches the router params, like :id, :id:int, :id([0-9]+) and ?:id
+var routerParamRegex = regexp.MustCompile(`\??:(\w+)(:int|:string)?(\([^)]*\))?`)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// registerOpenAPI serves the OpenAPI document of BeeApp if EnableOpenAPI is true
+func registerOpenAPI() error {
+	if BConfig.WebConfig.EnableOpenAPI {
+		BeeApp.EnableOpenAPI(BConfig.WebConfig.OpenAPIPath, openapi.Info{
+			Title:   BConfig.AppName,
+			Version: AppConfig.DefaultString("AppVersion", "1.0.0"),
+		})
+	}
+	return nil
+}
+
+// EnableOpenAPI serves the OpenAPI document at prefix/openapi.json and Swagger UI at prefix.
+// The document is generated when it is requested first time,
+// so the routers registered before the server starting will be included.
+// usage:
+//
+//	web.BeeApp.EnableOpenAPI("/swagger", openapi.Info{Title: "pet store", Version: "1.0.0"})
+func (app *HttpServer) EnableOpenAPI(prefix string, info openapi.Info) *HttpServer {
+	specPath := path.Join("/", prefix, "openapi.json")
+	uiPath := path.Join("/", prefix)
+
+	var (
+		once sync.Once
+		data []byte
+		err  error
+	)
+	app.Handlers.Handler(specPath, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			doc := app.OpenAPI(info)
+			delete(doc.Paths, specPath)
+			delete(doc.Paths, uiPath)
+			data, err = json.Marshal(doc)
+		})
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(rw, data)
+	}))
+	app.Handlers.Handler(uiPath, openapi.SwaggerUIHandler(info.Title, specPath))
+	return app
+}
+
+// OpenAPI generates the OpenAPI document from registered routers.
+// The parameters and response types come from:
+//  1. the params in router pattern, for example, /user/:id:int
+//  2. the params of controller methods registered by Include, see ControllerComments.MethodParams
+//  3. the results of controller methods registered by Include
+//
+// The schemas of struct types are generated by openapi.Components.SchemaOf
+func (app *HttpServer) OpenAPI(info openapi.Info) *openapi.Document {
+	doc := &openapi.Document{
+		OpenAPI:    openapi.Version,
+		Info:       info,
+		Paths:      make(map[string]*openapi.PathItem),
+		Components: &openapi.Components{},
+	}
+	for method, t := range app.Handlers.routers {
+		var infos []*ControllerInfo
+		composeControllerInfos(t, &infos)
+		for _, c := range infos {
+			op := c.openAPIOperation(method, doc.Components)
+			if op == nil {
+				continue
+			}
+			p, params := openAPIPath(c.pattern)
+			op.Parameters = mergeOpenAPIParams(params, op.Parameters)
+			item, ok := doc.Paths[p]
+			if !ok {
+				item = &openapi.PathItem{}
+			}
+			if item.SetOperation(method, op) {
+				doc.Paths[p] = item
+			}
+		}
+	}
+	if len(doc.Components.Schemas) == 0 {
+		doc.Components = nil
+	}
+	return doc
+}
+
+// openAPIOperation returns nil if the router doesn't serve this http method
+func (c *ControllerInfo) openAPIOperation(httpMethod string, components *openapi.Components) *openapi.Operation {
+	op := &openapi.Operation{
+		Responses: map[string]*openapi.Response{
+			"200": {Description: http.StatusText(http.StatusOK)},
+		},
+	}
+	switch c.routerType {
+	case routerTypeRESTFul:
+		if _, ok := c.methods[httpMethod]; !ok {
+			return nil
+		}
+		return op
+	case routerTypeHandler:
+		return op
+	}
+
+	methodName, ok := c.methods[httpMethod]
+	if !ok {
+		methodName, ok = c.methods["*"]
+	}
+	ptrType := reflect.PtrTo(c.controllerType)
+	if !ok {
+		// use the default method, like Get, Post...
+		// and ignore it if it is not implemented by the controller
+		methodName = strings.ToUpper(httpMethod[:1]) + strings.ToLower(httpMethod[1:])
+		if !declaresMethod(ptrType, methodName) {
+			return nil
+		}
+	}
+	op.OperationID = c.controllerType.Name() + "." + methodName
+	op.Tags = []string{strings.TrimSuffix(c.controllerType.Name(), "Controller")}
+
+	m, ok := ptrType.MethodByName(methodName)
+	if !ok || c.methodParams == nil {
+		return op
+	}
+	// the first input is receiver
+	for i, mp := range c.methodParams {
+		if i+1 >= m.Type.NumIn() {
+			break
+		}
+		schema := components.SchemaOf(m.Type.In(i + 1))
+		if mp.DefaultValue() != "" {
+			schema.Default = mp.DefaultValue()
+		}
+		if mp.In() == "body" {
+			op.RequestBody = &openapi.RequestBody{
+				Required: mp.Required(),
+				Content: map[string]*openapi.MediaType{
+					"application/json": {Schema: schema},
+				},
+			}
+			continue
+		}
+		op.Parameters = append(op.Parameters, &openapi.Parameter{
+			Name:     mp.Name(),
+			In:       mp.In(),
+			Required: mp.Required() || mp.In() == "path",
+			Schema:   schema,
+		})
+	}
+	for i := 0; i < m.Type.NumOut(); i++ {
+		if out := m.Type.Out(i); out != errorType {
+			op.Responses["200"].Content = map[string]*openapi.MediaType{
+				"application/json": {Schema: components.SchemaOf(out)},
+			}
+			break
+		}
+	}
+	return op
+}
+
+// openAPIPath converts the router pattern to OpenAPI path template,
+// for example, /user/:id([0-9]+) => /user/{id}
+func openAPIPath(pattern string) (string, []*openapi.Parameter) {
+	var params []*openapi.Parameter
+	addParam := func(name string, schema *openapi.Schema) {
+		params = append(params, &openapi.Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+	}
+	p := routerParamRegex.ReplaceAllStringFunc(pattern, func(s string) string {
+		sub := routerParamRegex.FindStringSubmatch(s)
+		schema := &openapi.Schema{Type: "string"}
+		if sub[2] == ":int" || sub[3] == "([0-9]+)" || sub[3] == `(\d+)` {
+			schema = &openapi.Schema{Type: "integer"}
+		}
+		addParam(sub[1], schema)
+		return "{" + sub[1] + "}"
+	})
+	switch {
+	case strings.HasSuffix(p, "/*.*"):
+		addParam("path", &openapi.Schema{Type: "string"})
+		addParam("ext", &openapi.Schema{Type: "string"})
+		p = strings.TrimSuffix(p, "*.*") + "{path}.{ext}"
+	case strings.HasSuffix(p, "/*"):
+		addParam("splat", &openapi.Schema{Type: "string"})
+		p = strings.TrimSuffix(p, "*") + "{splat}"
+	}
+	return p, params
+}
+
+// mergeOpenAPIParams merges the params from router pattern and the params from controller method.
+// The latter has more information, so it wins
+func mergeOpenAPIParams(patternParams, methodParams []*openapi.Parameter) []*openapi.Parameter {
+	res := make([]*openapi.Parameter, 0, len(patternParams)+len(methodParams))
+	for _, pp := range patternParams {
+		found := false
+		for _, mp := range methodParams {
+			if mp.In == pp.In && mp.Name == pp.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, pp)
+		}
+	}
+	return append(res, methodParams...)
+}
+
+// declaresMethod checks whether the method is declared by t itself.
+// The methods promoted from embedded Controller are generated by compiler.
+func declaresMethod(t reflect.Type, name string) bool {
+	m, ok := t.MethodByName(name)
+	if !ok {
+		return false
+	}
+	fn := runtime.FuncForPC(m.Func.Pointer())
+	if fn == nil {
+		return false
+	}
+	file, _ := fn.FileLine(fn.Entry())
+	return file != "<autogenerated>"
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi contains the definitions of OpenAPI 3.1 document
+// and the helpers to build schemas from go types.
+// The document is generated by web.HttpServer.OpenAPI from registered routers,
+// see https://spec.openapis.org/oas/v3.1.0
+package openapi
+
+// Version is the OpenAPI version of the generated document
+const Version = "3.1.0"
+
+// Document is the root object of OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi" yaml:"openapi"`
+	Info       Info                 `json:"info" yaml:"info"`
+	Servers    []Server             `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths" yaml:"paths"`
+	Components *Components          `json:"components,omitempty" yaml:"components,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Info provides metadata about the API
+type Info struct {
+	Title       string   `json:"title" yaml:"title"`
+	Summary     string   `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string   `json:"version" yaml:"version"`
+	Contact     *Contact `json:"contact,omitempty" yaml:"contact,omitempty"`
+	License     *License `json:"license,omitempty" yaml:"license,omitempty"`
+}
+
+// Contact information for the exposed API
+type Contact struct {
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
+	URL   string `json:"url,omitempty" yaml:"url,omitempty"`
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+}
+
+// License information for the exposed API
+type License struct {
+	Name       string `json:"name" yaml:"name"`
+	Identifier string `json:"identifier,omitempty" yaml:"identifier,omitempty"`
+	URL        string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// Server represents a server
+type Server struct {
+	URL         string `json:"url" yaml:"url"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// Tag adds metadata to a single tag that is used by the Operation
+type Tag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PathItem describes the operations available on a single path
+type PathItem struct {
+	Summary     string       `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string       `json:"description,omitempty" yaml:"description,omitempty"`
+	Get         *Operation   `json:"get,omitempty" yaml:"get,omitempty"`
+	Put         *Operation   `json:"put,omitempty" yaml:"put,omitempty"`
+	Post        *Operation   `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete      *Operation   `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Options     *Operation   `json:"options,omitempty" yaml:"options,omitempty"`
+	Head        *Operation   `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch       *Operation   `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Trace       *Operation   `json:"trace,omitempty" yaml:"trace,omitempty"`
+	Parameters  []*Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// SetOperation sets the operation of http method.
+// It returns false if the method is not supported by OpenAPI
+func (p *PathItem) SetOperation(method string, op *Operation) bool {
+	switch method {
+	case "GET":
+		p.Get = op
+	case "PUT":
+		p.Put = op
+	case "POST":
+		p.Post = op
+	case "DELETE":
+		p.Delete = op
+	case "OPTIONS":
+		p.Options = op
+	case "HEAD":
+		p.Head = op
+	case "PATCH":
+		p.Patch = op
+	case "TRACE":
+		p.Trace = op
+	default:
+		return false
+	}
+	return true
+}
+
+// Operation describes a single API operation on a path
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses,omitempty" yaml:"responses,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// Parameter describes a single operation parameter
+type Parameter struct {
+	Name        string  `json:"name" yaml:"name"`
+	In          string  `json:"in" yaml:"in"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// RequestBody describes a single request body
+type RequestBody struct {
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content" yaml:"content"`
+	Required    bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Response describes a single response from an API Operation
+type Response struct {
+	Description string                `json:"description" yaml:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// MediaType provides schema and examples for the media type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// Components holds a set of reusable objects
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
+// Schema is a subset of JSON Schema draft 2020-12 used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty" yaml:"default,omitempty"`
+	Examples             []interface{}      `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
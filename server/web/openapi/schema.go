@@ -0,0 +1,165 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf returns the schema of t.
+// Named struct types are stored in c.Schemas, and a reference schema will be returned.
+// Those struct tags are used:
+//
+//	json: the name of property, "-" means ignoring it
+//	description: the description of property
+//	example: the example of property
+//	enum: the enum values of property, separated by comma
+//	valid: if it contains "Required", the property is required. see core/validation
+func (c *Components) SchemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := schemaName(t)
+		if c.Schemas == nil {
+			c.Schemas = make(map[string]*Schema)
+		}
+		if _, ok := c.Schemas[name]; !ok {
+			// placeholder to stop recursive definition
+			c.Schemas[name] = &Schema{}
+			*c.Schemas[name] = *c.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case t.Kind() == reflect.Struct:
+		return c.structSchema(t)
+	}
+	return c.basicSchema(t)
+}
+
+func (c *Components) basicSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: c.SchemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: c.SchemaOf(t.Elem())}
+	default:
+		// interface, func, chan... we don't know the structure
+		return &Schema{}
+	}
+}
+
+func (c *Components) structSchema(t reflect.Type) *Schema {
+	res := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	c.addFields(res, t)
+	return res
+}
+
+func (c *Components) addFields(res *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// the fields of unexported embedded struct are still promoted, like encoding/json
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		name, inline := fieldName(f)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			c.addFields(res, ft)
+			continue
+		}
+		fs := c.SchemaOf(f.Type)
+		if fs.Ref == "" {
+			fs.Description = f.Tag.Get("description")
+			if example := f.Tag.Get("example"); example != "" {
+				fs.Examples = []interface{}{example}
+			}
+			if enum := f.Tag.Get("enum"); enum != "" {
+				fs.Enum = strings.Split(enum, ",")
+			}
+		}
+		res.Properties[name] = fs
+		if strings.Contains(f.Tag.Get("valid"), "Required") {
+			res.Required = append(res.Required, name)
+		}
+	}
+}
+
+// fieldName returns the json name of field, and whether it's an embedded struct
+func fieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	name := strings.Split(tag, ",")[0]
+	if name != "" {
+		return name, false
+	}
+	ft := f.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	if f.Anonymous && ft.Kind() == reflect.Struct {
+		return "", true
+	}
+	return f.Name, false
+}
+
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if idx := strings.LastIndex(pkg, "/"); idx >= 0 {
+		pkg = pkg[idx+1:]
+	}
+	name := t.Name()
+	if pkg != "" {
+		name = pkg + "." + name
+	}
+	// generic type's name is like Page[main.User], which is not a valid key
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
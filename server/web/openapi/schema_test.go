@@ -0,0 +1,60 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type base struct {
+	ID int64 `json:"id"`
+}
+
+type node struct {
+	base
+	Name     string            `json:"name" valid:"Required" description:"node name" example:"root"`
+	Kind     string            `json:"kind" enum:"file,dir"`
+	Children []*node           `json:"children,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Created  time.Time         `json:"created"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestComponentsSchemaOf(t *testing.T) {
+	c := &Components{}
+	s := c.SchemaOf(reflect.TypeOf(&node{}))
+	assert.Equal(t, "#/components/schemas/openapi.node", s.Ref)
+
+	n := c.Schemas["openapi.node"]
+	assert.Equal(t, "object", n.Type)
+	assert.Equal(t, []string{"name"}, n.Required)
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, n.Properties["id"])
+	assert.Equal(t, "node name", n.Properties["name"].Description)
+	assert.Equal(t, []interface{}{"root"}, n.Properties["name"].Examples)
+	assert.Equal(t, []string{"file", "dir"}, n.Properties["kind"].Enum)
+	assert.Equal(t, "#/components/schemas/openapi.node", n.Properties["children"].Items.Ref)
+	assert.Equal(t, "string", n.Properties["labels"].AdditionalProperties.Type)
+	assert.Equal(t, "date-time", n.Properties["created"].Format)
+	assert.NotContains(t, n.Properties, "Ignored")
+	assert.NotContains(t, n.Properties, "internal")
+
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}}, c.SchemaOf(reflect.TypeOf([]string{})))
+	assert.Equal(t, &Schema{Type: "string", Format: "byte"}, c.SchemaOf(reflect.TypeOf([]byte{})))
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// SwaggerUIDistURL is where the Swagger UI assets are loaded from.
+// Change it if your users can not access unpkg.com
+var SwaggerUIDistURL = "https://unpkg.com/swagger-ui-dist@5"
+
+var swaggerUITpl = template.Must(template.New("swagger-ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="{{.DistURL}}/swagger-ui.css" />
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.DistURL}}/swagger-ui-bundle.js" crossorigin></script>
+<script>
+  window.onload = () => {
+    window.ui = SwaggerUIBundle({
+      url: {{.SpecURL}},
+      dom_id: '#swagger-ui',
+    });
+  };
+</script>
+</body>
+</html>
+`))
+
+// SwaggerUIHandler returns a handler which renders Swagger UI for the document served at specURL
+func SwaggerUIHandler(title, specURL string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = swaggerUITpl.Execute(rw, map[string]string{
+			"Title":   title,
+			"DistURL": SwaggerUIDistURL,
+			"SpecURL": specURL,
+		})
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/context"
+	"github.com/beego/beego/v2/server/web/context/param"
+	"github.com/beego/beego/v2/server/web/openapi"
+)
+
+type openAPIUser struct {
+	Name string `json:"name" valid:"Required" description:"user name"`
+	Age  int    `json:"age,omitempty"`
+}
+
+type openAPIController struct {
+	Controller
+}
+
+func (c *openAPIController) Get() {}
+
+func (c *openAPIController) Find(id int, verbose bool) (*openAPIUser, error) {
+	return nil, nil
+}
+
+func (c *openAPIController) Create(user openAPIUser) error {
+	return nil
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		path    string
+		params  []string
+	}{
+		{pattern: "/user", path: "/user"},
+		{pattern: "/user/:id", path: "/user/{id}", params: []string{"id"}},
+		{pattern: "/user/:id:int/:name([a-z]+)", path: "/user/{id}/{name}", params: []string{"id", "name"}},
+		{pattern: "/static/*", path: "/static/{splat}", params: []string{"splat"}},
+		{pattern: "/file/*.*", path: "/file/{path}.{ext}", params: []string{"path", "ext"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			p, params := openAPIPath(tc.pattern)
+			assert.Equal(t, tc.path, p)
+			names := make([]string, 0, len(params))
+			for _, param := range params {
+				names = append(names, param.Name)
+			}
+			assert.ElementsMatch(t, tc.params, names)
+		})
+	}
+}
+
+func TestHttpServerOpenAPI(t *testing.T) {
+	app := NewHttpServerWithCfg(newBConfig())
+	app.Get("/ping", func(ctx *context.Context) {})
+	app.Router("/default", &openAPIController{})
+	app.Handlers.addWithMethodParams("/user/:id", &openAPIController{},
+		param.Make(param.New("id", param.InPath), param.New("verbose")),
+		WithRouterMethods(&openAPIController{}, "get:Find"))
+	app.Handlers.addWithMethodParams("/user", &openAPIController{},
+		param.Make(param.New("user", param.InBody, param.IsRequired)),
+		WithRouterMethods(&openAPIController{}, "post:Create"))
+	app.EnableOpenAPI("/docs", openapi.Info{Title: "test", Version: "1.0.0"})
+
+	doc := app.OpenAPI(openapi.Info{Title: "test", Version: "1.0.0"})
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	assert.NotNil(t, doc.Paths["/ping"].Get)
+	assert.Nil(t, doc.Paths["/ping"].Post)
+
+	// only Get is implemented by openAPIController
+	assert.NotNil(t, doc.Paths["/default"].Get)
+	assert.Nil(t, doc.Paths["/default"].Post)
+
+	find := doc.Paths["/user/{id}"].Get
+	assert.Equal(t, "openAPIController.Find", find.OperationID)
+	assert.Equal(t, 2, len(find.Parameters))
+	assert.Equal(t, "#/components/schemas/web.openAPIUser",
+		find.Responses["200"].Content["application/json"].Schema.Ref)
+
+	create := doc.Paths["/user"].Post
+	assert.True(t, create.RequestBody.Required)
+	assert.Equal(t, []string{"name"}, doc.Components.Schemas["web.openAPIUser"].Required)
+
+	r, _ := http.NewRequest(http.MethodGet, "/docs/openapi.json", nil)
+	w := httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	res := &openapi.Document{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), res))
+	assert.NotContains(t, res.Paths, "/docs/openapi.json")
+	assert.Contains(t, res.Paths, "/user/{id}")
+
+	r, _ = http.NewRequest(http.MethodGet, "/docs", nil)
+	w = httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), "SwaggerUIBundle")
+}
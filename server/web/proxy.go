@@ -0,0 +1,217 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// ProxyOption is the option of Proxy
+type ProxyOption func(*proxyConfig)
+
+type proxyConfig struct {
+	rewrite               func(path string) string
+	preserveHost          bool
+	setHeaders            http.Header
+	removeHeaders         []string
+	removeResponseHeaders []string
+	retries               int
+	transport             http.RoundTripper
+	errorHandler          func(http.ResponseWriter, *http.Request, error)
+}
+
+// WithProxyStripPrefix removes the prefix from the path before forwarding,
+// for example, /api/users is forwarded to {target}/users if the prefix is /api.
+// The prefix is matched on path segments, so /apiv2/users is forwarded as it is
+func WithProxyStripPrefix(prefix string) ProxyOption {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return WithProxyRewrite(func(p string) string {
+		if p != prefix && !strings.HasPrefix(p, prefix+"/") {
+			return p
+		}
+		p = strings.TrimPrefix(p, prefix)
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		return p
+	})
+}
+
+// WithProxyRewrite sets the function which rewrites the path before forwarding,
+// the rewritten path is joined to the path of target
+func WithProxyRewrite(rewrite func(path string) string) ProxyOption {
+	return func(c *proxyConfig) {
+		c.rewrite = rewrite
+	}
+}
+
+// WithProxyPreserveHost forwards the Host header of the incoming request,
+// the host of target is used by default
+func WithProxyPreserveHost() ProxyOption {
+	return func(c *proxyConfig) {
+		c.preserveHost = true
+	}
+}
+
+// WithProxySetHeader sets the header of the forwarded request
+func WithProxySetHeader(key, value string) ProxyOption {
+	return func(c *proxyConfig) {
+		c.setHeaders.Set(key, value)
+	}
+}
+
+// WithProxyRemoveHeader removes the headers from the forwarded request, Cookie for example
+func WithProxyRemoveHeader(keys ...string) ProxyOption {
+	return func(c *proxyConfig) {
+		c.removeHeaders = append(c.removeHeaders, keys...)
+	}
+}
+
+// WithProxyRemoveResponseHeader removes the headers from the response of target, Server for example
+func WithProxyRemoveResponseHeader(keys ...string) ProxyOption {
+	return func(c *proxyConfig) {
+		c.removeResponseHeaders = append(c.removeResponseHeaders, keys...)
+	}
+}
+
+// WithProxyRetry retries the request if it fails to connect to the target.
+// The request with body is not retried since the body has been consumed
+func WithProxyRetry(retries int) ProxyOption {
+	return func(c *proxyConfig) {
+		c.retries = retries
+	}
+}
+
+// WithProxyTransport sets the transport, http.DefaultTransport is used by default
+func WithProxyTransport(transport http.RoundTripper) ProxyOption {
+	return func(c *proxyConfig) {
+		c.transport = transport
+	}
+}
+
+// WithProxyErrorHandler sets the handler of the error, it responds 502 by default
+func WithProxyErrorHandler(h func(http.ResponseWriter, *http.Request, error)) ProxyOption {
+	return func(c *proxyConfig) {
+		c.errorHandler = h
+	}
+}
+
+// NewProxyHandler creates the handler which forwards the requests to target.
+// X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto are added to the forwarded request,
+// and the websocket is supported
+func NewProxyHandler(target string, opts ...ProxyOption) (http.Handler, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("the target of proxy must be an absolute URL")
+	}
+	c := &proxyConfig{
+		setHeaders: http.Header{},
+		transport:  http.DefaultTransport,
+		errorHandler: func(rw http.ResponseWriter, r *http.Request, err error) {
+			logs.Error("proxy to %s failed: %v", target, err)
+			rw.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+
+	transport := c.transport
+	if c.retries > 0 {
+		transport = &retryTransport{RoundTripper: transport, retries: c.retries}
+	}
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if c.rewrite != nil {
+				pr.Out.URL.Path = c.rewrite(pr.Out.URL.Path)
+				pr.Out.URL.RawPath = ""
+			}
+			pr.SetURL(u)
+			pr.SetXForwarded()
+			if c.preserveHost {
+				pr.Out.Host = pr.In.Host
+			}
+			for k, v := range c.setHeaders {
+				pr.Out.Header[k] = v
+			}
+			for _, k := range c.removeHeaders {
+				pr.Out.Header.Del(k)
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			for _, k := range c.removeResponseHeaders {
+				resp.Header.Del(k)
+			}
+			return nil
+		},
+		Transport:    transport,
+		ErrorHandler: c.errorHandler,
+	}, nil
+}
+
+// Proxy forwards the requests matching the pattern to target, it panics if the target is invalid.
+// The filters and filter chains are executed before forwarding, and the request body is not parsed.
+// usage:
+//
+//	p.Proxy("/api/*", "http://127.0.0.1:8081", web.WithProxyStripPrefix("/api"))
+func (p *ControllerRegister) Proxy(pattern, target string, opts ...ProxyOption) {
+	h, err := NewProxyHandler(target, opts...)
+	if err != nil {
+		panic(err)
+	}
+	route := p.createHandlerRouter(h, pattern)
+	route.rawBody = true
+	for m := range HTTPMETHOD {
+		p.addToRouter(m, pattern, route)
+	}
+}
+
+// retryTransport retries the request if it fails to dial
+type retryTransport struct {
+	http.RoundTripper
+	retries int
+}
+
+func (t *retryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	for i := 0; ; i++ {
+		resp, err := t.RoundTripper.RoundTrip(r)
+		if err == nil || i >= t.retries || !isDialError(err) || r.Context().Err() != nil {
+			return resp, err
+		}
+		if r.Body != nil && r.Body != http.NoBody {
+			if r.GetBody == nil {
+				return resp, err
+			}
+			if r.Body, err = r.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
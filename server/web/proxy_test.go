@@ -0,0 +1,114 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func TestProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Server", "backend")
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Token", r.Header.Get("X-Token"))
+		w.Header().Set("X-Cookie", r.Header.Get("Cookie"))
+		w.Header().Set("X-Forwarded", r.Header.Get("X-Forwarded-Host"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer backend.Close()
+
+	handler := NewControllerRegister()
+	handler.InsertFilterChain("/api/*", func(next FilterFunc) FilterFunc {
+		return func(ctx *context.Context) {
+			ctx.Output.Header("X-Filter", "1")
+			next(ctx)
+		}
+	})
+	handler.Proxy("/api/*", backend.URL+"/v1", WithProxyStripPrefix("/api"),
+		WithProxySetHeader("X-Token", "secret"),
+		WithProxyRemoveHeader("Cookie"),
+		WithProxyRemoveResponseHeader("Server"))
+	handler.Init()
+
+	r := httptest.NewRequest("POST", "http://example.com/api/users", strings.NewReader("name=tom"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Cookie", "session=1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "name=tom", w.Body.String())
+	assert.Equal(t, "/v1/users", w.Header().Get("X-Path"))
+	assert.Equal(t, "secret", w.Header().Get("X-Token"))
+	assert.Equal(t, "", w.Header().Get("X-Cookie"))
+	assert.Equal(t, "example.com", w.Header().Get("X-Forwarded"))
+	assert.Equal(t, "", w.Header().Get("Server"))
+	assert.Equal(t, "1", w.Header().Get("X-Filter"))
+}
+
+func TestProxyStripPrefixSegment(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+	}))
+	defer backend.Close()
+
+	handler := NewControllerRegister()
+	handler.Proxy("/*", backend.URL, WithProxyStripPrefix("/api/"))
+
+	for path, expected := range map[string]string{
+		"/api/users":   "/users",
+		"/api":         "/",
+		"/apiv2/users": "/apiv2/users",
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, expected, w.Header().Get("X-Path"), path)
+	}
+}
+
+type dialFailTransport struct {
+	calls int
+}
+
+func (d *dialFailTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	d.calls++
+	return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+}
+
+func TestProxyRetry(t *testing.T) {
+	transport := &dialFailTransport{}
+	handler := NewControllerRegister()
+	handler.Proxy("/api/*", "http://127.0.0.1:1", WithProxyRetry(2), WithProxyTransport(transport))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, 3, transport.calls)
+
+	_, err := NewProxyHandler("/backend")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// URLOption is the option of ReverseURL
+type URLOption func(*urlOptions)
+
+type urlOptions struct {
+	scheme string
+	host   string
+	query  url.Values
+}
+
+// WithURLScheme sets the scheme of absolute URL, it's http by default
+func WithURLScheme(scheme string) URLOption {
+	return func(o *urlOptions) {
+		o.scheme = scheme
+	}
+}
+
+// WithURLHost builds the absolute URL with the host, such as example.com:8080
+func WithURLHost(host string) URLOption {
+	return func(o *urlOptions) {
+		o.host = host
+	}
+}
+
+// WithURLQuery appends the query parameters to the URL
+func WithURLQuery(query url.Values) URLOption {
+	return func(o *urlOptions) {
+		for k, vs := range query {
+			for _, v := range vs {
+				o.query.Add(k, v)
+			}
+		}
+	}
+}
+
+// ReverseURL see ControllerRegister.ReverseURL
+func ReverseURL(endpoint string, params map[string]interface{}, opts ...URLOption) (string, error) {
+	return BeeApp.Handlers.ReverseURL(endpoint, params, opts...)
+}
+
+// ReverseURL builds the URL of the controller method like URLFor, but it returns the error
+// if the params don't match the router pattern, for example, a param is missing or unknown,
+// or it doesn't match the regexp of pattern such as :id:int.
+// The params whose names start with ":" are the path params and the others are the query params,
+// the values are escaped.
+// usage:
+//
+//	// router /user/:id:int
+//	u, err := web.ReverseURL("UserController.Get", map[string]interface{}{":id": 1, "tab": "posts"},
+//		web.WithURLScheme("https"), web.WithURLHost("example.com"))
+//	// https://example.com/user/1?tab=posts
+func (p *ControllerRegister) ReverseURL(endpoint string, params map[string]interface{}, opts ...URLOption) (string, error) {
+	o := &urlOptions{query: url.Values{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	paths := strings.Split(endpoint, ".")
+	if len(paths) <= 1 {
+		return "", fmt.Errorf("the endpoint %q must like path.controller.method", endpoint)
+	}
+	pathParams := make(map[string]string, len(params))
+	for k, v := range params {
+		s, err := urlParamString(v)
+		if err != nil {
+			return "", fmt.Errorf("the param %s is invalid: %w", k, err)
+		}
+		if strings.HasPrefix(k, ":") {
+			pathParams[k] = url.PathEscape(s)
+		} else {
+			o.query.Add(k, s)
+		}
+	}
+
+	controllerName := strings.Join(paths[:len(paths)-1], "/")
+	methodName := paths[len(paths)-1]
+	for m, t := range p.routers {
+		// getURL deletes the used params from the map
+		remain := make(map[string]string, len(pathParams))
+		for k, v := range pathParams {
+			remain[k] = v
+		}
+		ok, u := p.getURL(t, "/", controllerName, methodName, remain, m)
+		if !ok {
+			continue
+		}
+		if len(remain) > 0 {
+			names := make([]string, 0, len(remain))
+			for k := range remain {
+				names = append(names, k)
+			}
+			return "", fmt.Errorf("the params %s are not in the router pattern of %s", strings.Join(names, ", "), endpoint)
+		}
+		if len(o.query) > 0 {
+			u += "?" + o.query.Encode()
+		}
+		if o.host != "" {
+			scheme := o.scheme
+			if scheme == "" {
+				scheme = "http"
+			}
+			u = scheme + "://" + o.host + u
+		}
+		return u, nil
+	}
+	return "", fmt.Errorf("no router matches %s with the params", endpoint)
+}
+
+// urlParamString converts the scalar value to string
+func urlParamString(v interface{}) (string, error) {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v), nil
+	default:
+		if s, ok := v.(fmt.Stringer); ok {
+			return s.String(), nil
+		}
+		return "", fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// reverseURL is the template function of ReverseURL, the values are key-value pairs
+//
+//	{{reverseurl "UserController.Get" ":id" .User.ID "tab" "posts"}}
+func reverseURL(endpoint string, values ...interface{}) (string, error) {
+	if len(values)%2 != 0 {
+		return "", fmt.Errorf("the params of %s must be key-value pairs", endpoint)
+	}
+	params := make(map[string]interface{}, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		params[fmt.Sprint(values[i])] = values[i+1]
+	}
+	return ReverseURL(endpoint, params)
+}
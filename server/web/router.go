@@ -20,7 +20,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"path"
 	"reflect"
 	"runtime"
@@ -97,6 +99,11 @@ func (l *logFilter) Filter(ctx *beecontext.Context) bool {
 			return true
 		}
 	}
+	for prefix := range staticFS {
+		if strings.HasPrefix(requestPath, prefix) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -127,6 +134,19 @@ type ControllerInfo struct {
 	initialize     func() ControllerInterface
 	methodParams   []*param.MethodParam
 	sessionOn      bool
+	// the limits of request body, 0 means using the global config
+	maxMemory     int64
+	maxUploadSize int64
+	xsrfExempt    bool
+	// rawBody means that the request body is not parsed before calling the handler
+	rawBody bool
+	host    *hostPattern
+	// clientCertRequired means that the request must provide the client certificate verified by mutual TLS
+	clientCertRequired bool
+	// multipartLimits restricts the multipart body, nil means no limits
+	multipartLimits *MultipartLimits
+	// noCompression disables the compression of responses even if EnableGzip is true
+	noCompression bool
 }
 
 type ControllerOption func(*ControllerInfo)
@@ -151,6 +171,87 @@ func WithRouterSessionOn(sessionOn bool) ControllerOption {
 	}
 }
 
+// WithRouterMaxMemory overrides Config.MaxMemory for this router
+func WithRouterMaxMemory(maxMemory int64) ControllerOption {
+	return func(c *ControllerInfo) {
+		c.maxMemory = maxMemory
+	}
+}
+
+// WithRouterMaxUploadSize overrides Config.MaxUploadSize for this router
+func WithRouterMaxUploadSize(maxUploadSize int64) ControllerOption {
+	return func(c *ControllerInfo) {
+		c.maxUploadSize = maxUploadSize
+	}
+}
+
+// WithRouterXSRFExempt skips the XSRF check of the router even if EnableXSRF is true,
+// it's useful for the webhooks and the APIs authenticated by token
+func WithRouterXSRFExempt() ControllerOption {
+	return func(c *ControllerInfo) {
+		c.xsrfExempt = true
+	}
+}
+
+// WithRouterClientCert requires the client certificate verified by mutual TLS, or 403 is returned.
+// It's useful when ClientAuth is VerifyClientCertIfGiven, so only some routers require the certificate,
+// and the certificate can be got by ctx.Input.ClientCert()
+func WithRouterClientCert() ControllerOption {
+	return func(c *ControllerInfo) {
+		c.clientCertRequired = true
+	}
+}
+
+// WithRouterMultipartLimits restricts the number of parts and files, the header size of parts
+// and the types of files of the multipart body, see MultipartLimits
+// usage:
+//
+//	web.RouterWithOpts("/avatar", &AvatarController{}, web.WithRouterMultipartLimits(web.MultipartLimits{
+//		MaxFiles:     1,
+//		AllowedTypes: []string{"image/png", "image/jpeg"},
+//	}))
+func WithRouterMultipartLimits(limits MultipartLimits) ControllerOption {
+	return func(c *ControllerInfo) {
+		c.multipartLimits = &limits
+	}
+}
+
+// WithRouterStreamUpload leaves the multipart body unparsed, so the handler can stream the files
+// to the storage by StreamUpload. The body is still limited by MaxUploadSize
+func WithRouterStreamUpload() ControllerOption {
+	return func(c *ControllerInfo) {
+		c.rawBody = true
+	}
+}
+
+// WithRouterNoCompression disables the compression of the responses of this router even if EnableGzip is true,
+// it's useful for the responses which are compressed already or need to be flushed immediately
+func WithRouterNoCompression() ControllerOption {
+	return func(c *ControllerInfo) {
+		c.noCompression = true
+	}
+}
+
+// WithRouterHost constrains the router by the host of request,
+// the pattern is like admin.example.com or {tenant}.example.com,
+// and the captured label can be got by ctx.Input.Param(":tenant")
+func WithRouterHost(pattern string) ControllerOption {
+	return func(c *ControllerInfo) {
+		c.host = newHostPattern(pattern)
+	}
+}
+
+// bodyLimit returns the limits of request body for this router
+func (c *ControllerInfo) bodyLimit(maxMemory, maxUploadSize int64) (int64, int64) {
+	if c.maxMemory > 0 {
+		maxMemory = c.maxMemory
+	}
+	if c.maxUploadSize > 0 {
+		maxUploadSize = c.maxUploadSize
+	}
+	return maxMemory, maxUploadSize
+}
+
 type filterChainConfig struct {
 	pattern string
 	chain   FilterChain
@@ -172,6 +273,12 @@ type ControllerRegister struct {
 	// keep registered chain and build it when serve http
 	filterChains []filterChainConfig
 
+	// the error handlers registered by Group
+	errorHandlers []*groupErrorHandler
+
+	// the WebSocket connections served by this router
+	webSockets *webSocketRegistry
+
 	cfg *Config
 }
 
@@ -193,6 +300,7 @@ func NewControllerRegisterWithCfg(cfg *Config) *ControllerRegister {
 		},
 		cfg:          cfg,
 		filterChains: make([]filterChainConfig, 0, 4),
+		webSockets:   &webSocketRegistry{},
 	}
 	res.chainRoot = newFilterRouter("/*", res.serveHttp, WithCaseSensitive(false))
 	return res
@@ -1005,7 +1113,10 @@ func (p *ControllerRegister) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 
 func (p *ControllerRegister) serveHttp(ctx *beecontext.Context) {
 	var err error
-	startTime := time.Now()
+	if ctx.ResponseWriter.StartTime.IsZero() {
+		ctx.ResponseWriter.StartTime = time.Now()
+	}
+	startTime := ctx.ResponseWriter.StartTime
 	r := ctx.Request
 	rw := ctx.ResponseWriter.ResponseWriter
 	var (
@@ -1023,8 +1134,13 @@ func (p *ControllerRegister) serveHttp(ctx *beecontext.Context) {
 	if p.cfg.RecoverFunc != nil {
 		defer p.cfg.RecoverFunc(ctx, p.cfg)
 	}
+	if len(p.errorHandlers) > 0 {
+		defer p.recoverGroupError(ctx)
+	}
 
 	ctx.Output.EnableGzip = p.cfg.EnableGzip
+	ctx.Output.EnableETag = p.cfg.WebConfig.EnableETag
+	ctx.Output.WeakETag = p.cfg.WebConfig.WeakETag
 
 	if p.cfg.RunMode == DEV {
 		ctx.Output.Header("Server", p.cfg.ServerName)
@@ -1050,45 +1166,85 @@ func (p *ControllerRegister) serveHttp(ctx *beecontext.Context) {
 		goto Admin
 	}
 
+	// the method is overridden before routing, so the body limits and the session settings of the overridden route are used
+	if p.cfg.WebConfig.EnableMethodOverride {
+		overrideMethod(ctx, p.cfg.MaxMemory)
+	}
+
+	if p.redirectTrailingSlash(ctx) {
+		findRouter = true
+		goto Admin
+	}
+
+	originRouterInfo, originFindRouter = p.FindRouter(ctx)
+	if originFindRouter && originRouterInfo.noCompression {
+		ctx.Output.EnableGzip = false
+	}
+
 	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		body := ctx.Input.Context.Request.Body
 		if body == nil {
 			body = io.NopCloser(bytes.NewReader([]byte{}))
 		}
-
+		maxMemory, maxUploadSize := p.cfg.MaxMemory, p.cfg.MaxUploadSize
+		if originFindRouter {
+			maxMemory, maxUploadSize = originRouterInfo.bodyLimit(maxMemory, maxUploadSize)
+		}
+		limit := maxMemory
 		if ctx.Input.IsUpload() {
+			limit = maxUploadSize
+		}
+		// reject it before reading the body
+		// connection will close if the incoming data are larger (RFC 7231, 6.5.11)
+		if r.ContentLength > limit {
+			logs.Error(errors.New("payload too large"))
+			exception("413", ctx)
+			goto Admin
+		}
+
+		rawBody := originFindRouter && originRouterInfo.rawBody
+		if rawBody {
+			// the body is not parsed, the handler reads it, the proxy for example
 			ctx.Input.Context.Request.Body = http.MaxBytesReader(ctx.Input.Context.ResponseWriter,
 				body,
-				p.cfg.MaxUploadSize)
+				limit)
+		} else if ctx.Input.IsUpload() {
+			ctx.Input.Context.Request.Body = http.MaxBytesReader(ctx.Input.Context.ResponseWriter,
+				body,
+				maxUploadSize)
 		} else if p.cfg.CopyRequestBody {
-			// connection will close if the incoming data are larger (RFC 7231, 6.5.11)
-			if r.ContentLength > p.cfg.MaxMemory {
-				logs.Error(errors.New("payload too large"))
-				exception("413", ctx)
-				goto Admin
-			}
-			ctx.Input.CopyBody(p.cfg.MaxMemory)
+			ctx.Input.CopyBody(maxMemory)
 		} else {
 			ctx.Input.Context.Request.Body = http.MaxBytesReader(ctx.Input.Context.ResponseWriter,
 				body,
-				p.cfg.MaxMemory)
+				maxMemory)
 		}
 
-		err = ctx.Input.ParseFormOrMultiForm(p.cfg.MaxMemory)
-		if err != nil {
-			logs.Error(err)
-			if strings.Contains(err.Error(), `http: request body too large`) {
-				exception("413", ctx)
-			} else {
-				exception("500", ctx)
+		var multipartLimit *multipartLimitReader
+		if originFindRouter && originRouterInfo.multipartLimits != nil && ctx.Input.IsUpload() {
+			if multipartLimit = limitMultipartBody(r, *originRouterInfo.multipartLimits); multipartLimit != nil {
+				defer multipartLimit.Close()
+			}
+		}
+
+		if !rawBody {
+			err = ctx.Input.ParseFormOrMultiForm(maxMemory)
+			if err != nil {
+				logs.Error(err)
+				if limitErr := multipartLimit.Err(); limitErr != nil {
+					exception(strconv.Itoa(limitErr.Status), ctx)
+				} else if strings.Contains(err.Error(), `http: request body too large`) {
+					exception("413", ctx)
+				} else {
+					exception("500", ctx)
+				}
+				goto Admin
 			}
-			goto Admin
 		}
 	}
 
 	// session init
 	currentSessionOn = p.cfg.WebConfig.Session.SessionOn
-	originRouterInfo, originFindRouter = p.FindRouter(ctx)
 	if originFindRouter {
 		currentSessionOn = originRouterInfo.sessionOn
 	}
@@ -1131,6 +1287,10 @@ func (p *ControllerRegister) serveHttp(ctx *beecontext.Context) {
 	if routerInfo != nil {
 		// store router pattern into context
 		ctx.Input.SetData("RouterPattern", routerInfo.pattern)
+		if routerInfo.clientCertRequired && ctx.Input.ClientCert() == nil {
+			exception("403", ctx)
+			goto Admin
+		}
 	}
 
 	// execute middleware filters
@@ -1192,15 +1352,18 @@ func (p *ControllerRegister) serveHttp(ctx *beecontext.Context) {
 
 		// call the controller init function
 		execController.Init(ctx, runRouter.Name(), runMethod, execController)
+		if wt, ok := execController.(webSocketTracker); ok {
+			wt.setWebSocketRegistry(p.webSockets)
+		}
 
 		// call prepare function
 		execController.Prepare()
 
 		// if XSRF is Enable then check cookie where there has any cookie in the  request's cookie _csrf
-		if p.cfg.WebConfig.EnableXSRF {
+		if p.cfg.WebConfig.EnableXSRF && (routerInfo == nil || !routerInfo.xsrfExempt) {
 			execController.XSRFToken()
 			if r.Method == http.MethodPost || r.Method == http.MethodDelete || r.Method == http.MethodPut ||
-				(r.Method == http.MethodPost && (ctx.Input.Query("_method") == http.MethodDelete || ctx.Input.Query("_method") == http.MethodPut)) {
+				r.Method == http.MethodPatch {
 				execController.CheckXSRFCookie()
 			}
 		}
@@ -1267,6 +1430,9 @@ Admin:
 	// admin module record QPS
 
 	statusCode := ctx.ResponseWriter.Status
+	if statusCode == 0 {
+		statusCode = ctx.Output.Status
+	}
 	if statusCode == 0 {
 		statusCode = 200
 	}
@@ -1343,12 +1509,96 @@ func (p *ControllerRegister) FindRouter(context *beecontext.Context) (routerInfo
 	if t, ok := p.routers[httpMethod]; ok {
 		runObject := t.Match(urlPath, context)
 		if r, ok := runObject.(*ControllerInfo); ok {
+			// in strict mode, the trailing slash of URL must be the same as the pattern
+			if p.cfg.TrailingSlash == TrailingSlashStrict && hasTrailingSlash(urlPath) != hasTrailingSlash(r.pattern) {
+				return nil, false
+			}
 			return r, true
 		}
 	}
 	return
 }
 
+// overrideMethod replaces the method of POST request by X-HTTP-Method-Override header or _method form field,
+// only PUT, PATCH and DELETE are allowed
+func overrideMethod(ctx *beecontext.Context, maxMemory int64) {
+	if ctx.Request.Method != http.MethodPost {
+		return
+	}
+	m := ctx.Request.Header.Get("X-HTTP-Method-Override")
+	if m == "" {
+		m = peekFormMethod(ctx.Request, maxMemory)
+	}
+	switch m = strings.ToUpper(m); m {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		ctx.Request.Method = m
+	}
+}
+
+// peekFormMethod reads the _method field of the urlencoded form before the body is parsed,
+// the body is restored so it can be read again. The larger body than limit is ignored
+func peekFormMethod(r *http.Request, limit int64) string {
+	if r.Body == nil || r.ContentLength > limit {
+		return ""
+	}
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil || int64(len(data)) > limit {
+		return ""
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return ""
+	}
+	return values.Get("_method")
+}
+
+// the policies of the request URL ending with "/", see Config.TrailingSlash
+const (
+	TrailingSlashRewrite     = "rewrite"
+	TrailingSlashRedirect    = "redirect"
+	TrailingSlashRedirect308 = "redirect308"
+	TrailingSlashStrict      = "strict"
+)
+
+func hasTrailingSlash(urlPath string) bool {
+	return len(urlPath) > 1 && strings.HasSuffix(urlPath, "/")
+}
+
+// redirectTrailingSlash redirects the request URL ending with "/" to the URL without it
+// if the policy is redirect and the router exists
+func (p *ControllerRegister) redirectTrailingSlash(ctx *beecontext.Context) bool {
+	var status int
+	switch p.cfg.TrailingSlash {
+	case TrailingSlashRedirect:
+		status = http.StatusMovedPermanently
+	case TrailingSlashRedirect308:
+		status = http.StatusPermanentRedirect
+	default:
+		return false
+	}
+	urlPath := ctx.Input.URL()
+	if !hasTrailingSlash(urlPath) {
+		return false
+	}
+	// the pattern ending with "/" matches the URL as it is
+	if r, ok := p.FindRouter(ctx); !ok || hasTrailingSlash(r.pattern) {
+		return false
+	}
+	u := *ctx.Request.URL
+	// Clean also collapses the leading slashes to avoid redirecting to another host
+	u.Path = path.Clean(urlPath)
+	u.RawPath = ""
+	ctx.Redirect(status, u.RequestURI())
+	return true
+}
+
 // GetAllControllerInfo get all ControllerInfo
 func (p *ControllerRegister) GetAllControllerInfo() (routerInfos []*ControllerInfo) {
 	for _, webTree := range p.routers {
@@ -19,11 +19,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/beego/beego/v2/server/web/context"
 )
@@ -134,6 +137,33 @@ func TestUrlFor(t *testing.T) {
 	}
 }
 
+func TestReverseURL(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Add("/api/list", &TestController{}, WithRouterMethods(&TestController{}, "*:List"))
+	handler.Add("/user/:id:int/:name", &TestController{}, WithRouterMethods(&TestController{}, "*:Param"))
+
+	u, err := handler.ReverseURL("TestController.Param", map[string]interface{}{":id": 12, ":name": "John Doe", "q": "a&b"},
+		WithURLScheme("https"), WithURLHost("example.com"), WithURLQuery(url.Values{"page": {"2"}}))
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/user/12/John%20Doe?page=2&q=a%26b", u)
+
+	u, err = handler.ReverseURL("TestController.List", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "/api/list", u)
+
+	// the type of :id is wrong
+	_, err = handler.ReverseURL("TestController.Param", map[string]interface{}{":id": "abc", ":name": "tom"})
+	assert.NotNil(t, err)
+	// :name is missing
+	_, err = handler.ReverseURL("TestController.Param", map[string]interface{}{":id": 1})
+	assert.NotNil(t, err)
+	// :age is unknown
+	_, err = handler.ReverseURL("TestController.List", map[string]interface{}{":age": 1})
+	assert.NotNil(t, err)
+	_, err = handler.ReverseURL("TestController.List", map[string]interface{}{"ids": []int{1}})
+	assert.NotNil(t, err)
+}
+
 func TestUrlFor3(t *testing.T) {
 	handler := NewControllerRegister()
 	handler.AddAuto(&TestController{})
@@ -612,6 +642,33 @@ func TestFilterAfterExec(t *testing.T) {
 	}
 }
 
+func TestFilterAfterExecResponseMetadata(t *testing.T) {
+	url := "/afterExecMetadata"
+	mux := NewControllerRegister()
+	var captured context.Response
+	mux.InsertFilter(url, AfterExec, func(ctx *context.Context) {
+		captured = *ctx.ResponseWriter
+		captured.Elapsed = ctx.ResponseWriter.Duration()
+	}, WithReturnOnOutput(false))
+	mux.Get(url, func(ctx *context.Context) {
+		ctx.Output.Header("X-Stream", "1")
+		ctx.ResponseWriter.WriteHeader(http.StatusAccepted)
+		_, _ = ctx.ResponseWriter.Write([]byte("chunk1"))
+		ctx.ResponseWriter.Flush()
+		_, _ = ctx.ResponseWriter.Write([]byte("chunk2"))
+	})
+
+	rw, r := testRequest("GET", url)
+	mux.ServeHTTP(rw, r)
+
+	assert.Equal(t, http.StatusAccepted, captured.Status)
+	assert.Equal(t, int64(12), captured.Size)
+	assert.Equal(t, 1, captured.Flushes)
+	assert.Equal(t, "1", captured.WrittenHeader.Get("X-Stream"))
+	assert.False(t, captured.HeaderTime.IsZero())
+	assert.True(t, captured.Elapsed > 0)
+}
+
 // Execution point: FinishRouter
 // expectation: only FinishRouter function is executed, match as router handles
 func TestFilterFinishRouter(t *testing.T) {
@@ -783,6 +840,69 @@ func TestRouterEntityTooLargeCopyBody(t *testing.T) {
 	}
 }
 
+func TestRouterBodyLimit(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Add("/api/list", &TestController{}, WithRouterMethods(&TestController{}, "post:List"),
+		WithRouterMaxMemory(20))
+	handler.Add("/upload", &TestController{}, WithRouterMethods(&TestController{}, "post:List"),
+		WithRouterMaxMemory(20), WithRouterMaxUploadSize(1<<20))
+
+	serve := func(url, contentType string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("POST", url, strings.NewReader(strings.Repeat("bar", 10)))
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve("/api/list", "application/json").Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve("/upload", "application/json").Code)
+	assert.NotEqual(t, http.StatusRequestEntityTooLarge, serve("/upload", "multipart/form-data; boundary=beego").Code)
+}
+
+func TestRouterXSRFExempt(t *testing.T) {
+	cfg := *BConfig
+	cfg.WebConfig.EnableXSRF = true
+	handler := NewControllerRegisterWithCfg(&cfg)
+	handler.Add("/api/list", &TestController{}, WithRouterMethods(&TestController{}, "post:List"))
+	handler.Add("/hook", &TestController{}, WithRouterMethods(&TestController{}, "post:List"),
+		WithRouterXSRFExempt())
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("POST", url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, 422, serve("/api/list").Code)
+	assert.Equal(t, http.StatusOK, serve("/hook").Code)
+}
+
+func TestRouterNoCompression(t *testing.T) {
+	cfg := *BConfig
+	cfg.EnableGzip = true
+	context.InitGzip(0, -1, nil)
+	defer context.InitGzip(-1, -1, nil)
+	handler := NewControllerRegisterWithCfg(&cfg)
+	handler.Add("/list", &TestController{}, WithRouterMethods(&TestController{}, "get:List"))
+	handler.Add("/raw", &TestController{}, WithRouterMethods(&TestController{}, "get:List"),
+		WithRouterNoCompression())
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", url, nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, "gzip", serve("/list").Header().Get("Content-Encoding"))
+	w := serve("/raw")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "i am list", w.Body.String())
+}
+
 func TestRouterSessionSet(t *testing.T) {
 	oldGlobalSessionOn := BConfig.WebConfig.Session.SessionOn
 	defer func() {
@@ -1128,3 +1248,91 @@ func TestGetAllControllerInfo(t *testing.T) {
 		t.Errorf("ControllerInfo.GetMethod expected %#v, but %#v got", expectedMethods, actualMethods)
 	}
 }
+
+func TestRouterHost(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Add("/user", &TestController{}, WithRouterMethods(&TestController{}, "get:List"),
+		WithRouterHost("*.example.com"))
+	handler.Add("/user", &TestController{}, WithRouterMethods(&TestController{}, "get:Get"))
+
+	serve := func(url string) string {
+		r, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "i am list", serve("http://api.example.com/user"))
+	assert.Equal(t, "ok", serve("http://example.org/user"))
+}
+
+func TestRouterTrailingSlash(t *testing.T) {
+	serve := func(policy, url string) *httptest.ResponseRecorder {
+		cfg := *BConfig
+		cfg.TrailingSlash = policy
+		handler := NewControllerRegisterWithCfg(&cfg)
+		handler.Add("/user", &TestController{}, WithRouterMethods(&TestController{}, "get:List"))
+		r, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, "i am list", serve(TrailingSlashRewrite, "/user/").Body.String())
+	assert.Equal(t, http.StatusNotFound, serve(TrailingSlashStrict, "/user/").Code)
+	assert.Equal(t, "i am list", serve(TrailingSlashStrict, "/user").Body.String())
+
+	w := serve(TrailingSlashRedirect, "/user/?page=1")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/user?page=1", w.Header().Get("Location"))
+	w = serve(TrailingSlashRedirect308, "/user/")
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "/user", w.Header().Get("Location"))
+	assert.Equal(t, http.StatusNotFound, serve(TrailingSlashRedirect, "/users/").Code)
+}
+
+func TestRouterMethodOverride(t *testing.T) {
+	cfg := *BConfig
+	cfg.WebConfig.EnableMethodOverride = true
+	handler := NewControllerRegisterWithCfg(&cfg)
+	handler.Patch("/user", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("patch"))
+	})
+	handler.Post("/user", func(ctx *context.Context) {
+		ctx.Output.Body([]byte("post"))
+	})
+
+	serve := func(r *http.Request) string {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	r, _ := http.NewRequest("POST", "/user", nil)
+	r.Header.Set("X-HTTP-Method-Override", "patch")
+	assert.Equal(t, "patch", serve(r))
+
+	r, _ = http.NewRequest("POST", "/user", strings.NewReader("_method=PATCH"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, "patch", serve(r))
+
+	// only PUT, PATCH and DELETE are allowed
+	r, _ = http.NewRequest("POST", "/user", nil)
+	r.Header.Set("X-HTTP-Method-Override", "GET")
+	assert.Equal(t, "post", serve(r))
+
+	// the body limit of the overridden route is used
+	handler.Add("/list", &TestController{}, WithRouterMethods(&TestController{}, "put:List"),
+		WithRouterMaxMemory(20))
+	handler.Add("/list", &TestController{}, WithRouterMethods(&TestController{}, "post:List"))
+	for _, override := range []string{"header", "form"} {
+		r, _ = http.NewRequest("POST", "/list", strings.NewReader("_method=PUT&name="+strings.Repeat("bar", 10)))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if override == "header" {
+			r.Header.Set("X-HTTP-Method-Override", "PUT")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, override)
+	}
+}
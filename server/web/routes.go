@@ -0,0 +1,163 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/core/utils"
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+var filterPositionNames = [FinishRouter + 1]string{
+	BeforeStatic: "BeforeStatic",
+	BeforeRouter: "BeforeRouter",
+	BeforeExec:   "BeforeExec",
+	AfterExec:    "AfterExec",
+	FinishRouter: "FinishRouter",
+}
+
+// RouteInfo describes a registered router
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	// Host is the host pattern which the router is constrained by
+	Host string `json:"host,omitempty"`
+	// Handler is controller.Method for the controller routers,
+	// or the name of function or http.Handler type
+	Handler string `json:"handler"`
+	// Filters are the filters and filter chains whose patterns match the router pattern
+	Filters []RouteFilter `json:"filters,omitempty"`
+}
+
+// RouteFilter describes the filter attached to a router
+type RouteFilter struct {
+	// Position is BeforeStatic, BeforeRouter, BeforeExec, AfterExec, FinishRouter or FilterChain
+	Position string `json:"position"`
+	Pattern  string `json:"pattern"`
+	Name     string `json:"name"`
+}
+
+// Routes see ControllerRegister.Routes
+func Routes() []RouteInfo {
+	return BeeApp.Handlers.Routes()
+}
+
+// Routes returns all registered routers sorted by pattern and method.
+// The filters are matched against the router pattern literally,
+// so the filters which only match some of the URLs of a router may be included
+func (p *ControllerRegister) Routes() []RouteInfo {
+	chains := make([]*FilterRouter, 0, len(p.filterChains))
+	for _, fc := range p.filterChains {
+		chains = append(chains, newFilterRouter(fc.pattern, nil, fc.opts...))
+	}
+
+	routes := make([]RouteInfo, 0, 16)
+	for method, t := range p.routers {
+		infos := make([]*ControllerInfo, 0, 8)
+		composeControllerInfos(t, &infos)
+		for _, c := range infos {
+			r := RouteInfo{
+				Method:  method,
+				Pattern: c.pattern,
+				Handler: c.handlerName(method),
+			}
+			if c.host != nil {
+				r.Host = c.host.pattern
+			}
+			for pos, filters := range p.filters {
+				for _, f := range filters {
+					if f.ValidRouter(c.pattern, beecontext.NewContext()) {
+						r.Filters = append(r.Filters, RouteFilter{
+							Position: filterPositionNames[pos],
+							Pattern:  f.pattern,
+							Name:     utils.GetFuncName(f.filterFunc),
+						})
+					}
+				}
+			}
+			for i, f := range chains {
+				if f.ValidRouter(c.pattern, beecontext.NewContext()) {
+					r.Filters = append(r.Filters, RouteFilter{
+						Position: "FilterChain",
+						Pattern:  f.pattern,
+						Name:     utils.GetFuncName(p.filterChains[i].chain),
+					})
+				}
+			}
+			routes = append(routes, r)
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// handlerName returns the name of the function which handles the method
+func (c *ControllerInfo) handlerName(method string) string {
+	switch c.routerType {
+	case routerTypeRESTFul:
+		return utils.GetFuncName(c.runFunction)
+	case routerTypeHandler:
+		return fmt.Sprintf("%T", c.handler)
+	}
+	name, ok := c.methods[method]
+	if !ok {
+		name, ok = c.methods["*"]
+	}
+	if !ok {
+		name = strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+	}
+	return c.controllerType.String() + "." + name
+}
+
+// RoutesTable formats the routers as a table, the filters are separated by comma
+func RoutesTable(routes []RouteInfo) string {
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATTERN\tHOST\tHANDLER\tFILTERS")
+	for _, r := range routes {
+		filters := make([]string, 0, len(r.Filters))
+		for _, f := range r.Filters {
+			filters = append(filters, f.Position+":"+f.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Pattern, r.Host, r.Handler, strings.Join(filters, ","))
+	}
+	_ = w.Flush()
+	return buf.String()
+}
+
+func registerRoutesDebug() error {
+	if !BConfig.WebConfig.EnableRoutesDebug || BConfig.RunMode != DEV {
+		return nil
+	}
+	logs.Info("routes:\n%s", RoutesTable(Routes()))
+	BeeApp.Handlers.Get(BConfig.WebConfig.RoutesDebugPath, func(ctx *beecontext.Context) {
+		_ = ctx.Output.JSON(Routes(), true, false)
+	})
+	return nil
+}
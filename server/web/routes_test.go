@@ -0,0 +1,61 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func routesTestFilter(ctx *context.Context) {}
+
+func TestRoutes(t *testing.T) {
+	handler := NewControllerRegister()
+	handler.Add("/user/:id", &TestController{}, WithRouterMethods(&TestController{}, "get:List"))
+	handler.Add("/admin", &TestController{}, WithRouterMethods(&TestController{}, "post:Post"), WithRouterHost("admin.example.com"))
+	handler.Get("/ping", routesTestFilter)
+	_ = handler.InsertFilter("/user/*", BeforeRouter, routesTestFilter)
+	handler.InsertFilterChain("/*", func(next FilterFunc) FilterFunc {
+		return next
+	})
+
+	routes := handler.Routes()
+	assert.Equal(t, 3, len(routes))
+
+	assert.Equal(t, "POST", routes[0].Method)
+	assert.Equal(t, "/admin", routes[0].Pattern)
+	assert.Equal(t, "admin.example.com", routes[0].Host)
+	assert.Equal(t, "web.TestController.Post", routes[0].Handler)
+	assert.Equal(t, 1, len(routes[0].Filters))
+
+	assert.Equal(t, "GET", routes[1].Method)
+	assert.Equal(t, "/ping", routes[1].Pattern)
+	assert.True(t, strings.HasSuffix(routes[1].Handler, ".routesTestFilter"))
+
+	assert.Equal(t, "/user/:id", routes[2].Pattern)
+	assert.Equal(t, "web.TestController.List", routes[2].Handler)
+	assert.Equal(t, 2, len(routes[2].Filters))
+	assert.Equal(t, "BeforeRouter", routes[2].Filters[0].Position)
+	assert.Equal(t, "/user/*", routes[2].Filters[0].Pattern)
+	assert.Equal(t, "FilterChain", routes[2].Filters[1].Position)
+
+	table := RoutesTable(routes)
+	assert.True(t, strings.HasPrefix(table, "METHOD"))
+	assert.Contains(t, table, "web.TestController.List")
+}
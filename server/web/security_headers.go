@@ -0,0 +1,173 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// CSPNonceKey is the key of CSP nonce in the data of context, the templates use it like
+//
+//	<script nonce="{{.CSPNonce}}">...</script>
+const CSPNonceKey = "CSPNonce"
+
+// the sources of Content-Security-Policy
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+	// CSPNonceSource is replaced by 'nonce-{random value}' for each request
+	CSPNonceSource = "'nonce'"
+)
+
+// CSP builds the Content-Security-Policy header.
+// usage:
+//
+//	web.BConfig.WebConfig.SecurityHeaders.ContentSecurityPolicy = web.NewCSP().
+//		DefaultSrc(web.CSPSelf).
+//		ScriptSrc(web.CSPSelf, web.CSPNonceSource).
+//		ImgSrc(web.CSPSelf, "data:").
+//		String()
+type CSP struct {
+	directives []string
+	sources    map[string][]string
+}
+
+// NewCSP creates an empty CSP
+func NewCSP() *CSP {
+	return &CSP{sources: make(map[string][]string)}
+}
+
+// Add appends the sources to the directive
+func (c *CSP) Add(directive string, sources ...string) *CSP {
+	if _, ok := c.sources[directive]; !ok {
+		c.directives = append(c.directives, directive)
+	}
+	c.sources[directive] = append(c.sources[directive], sources...)
+	return c
+}
+
+// DefaultSrc appends the sources to default-src
+func (c *CSP) DefaultSrc(sources ...string) *CSP {
+	return c.Add("default-src", sources...)
+}
+
+// ScriptSrc appends the sources to script-src
+func (c *CSP) ScriptSrc(sources ...string) *CSP {
+	return c.Add("script-src", sources...)
+}
+
+// StyleSrc appends the sources to style-src
+func (c *CSP) StyleSrc(sources ...string) *CSP {
+	return c.Add("style-src", sources...)
+}
+
+// ImgSrc appends the sources to img-src
+func (c *CSP) ImgSrc(sources ...string) *CSP {
+	return c.Add("img-src", sources...)
+}
+
+// ConnectSrc appends the sources to connect-src
+func (c *CSP) ConnectSrc(sources ...string) *CSP {
+	return c.Add("connect-src", sources...)
+}
+
+// FrameAncestors appends the sources to frame-ancestors
+func (c *CSP) FrameAncestors(sources ...string) *CSP {
+	return c.Add("frame-ancestors", sources...)
+}
+
+// String returns the value of Content-Security-Policy header
+func (c *CSP) String() string {
+	policies := make([]string, 0, len(c.directives))
+	for _, d := range c.directives {
+		if sources := c.sources[d]; len(sources) > 0 {
+			policies = append(policies, d+" "+strings.Join(sources, " "))
+		} else {
+			policies = append(policies, d)
+		}
+	}
+	return strings.Join(policies, "; ")
+}
+
+// SecurityHeaders returns the filter chain which adds the security headers to the responses.
+// It's inserted automatically if WebConfig.EnableSecurityHeaders is true
+func SecurityHeaders(cfg SecurityHeadersConfig) FilterChain {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	useNonce := strings.Contains(cfg.ContentSecurityPolicy, CSPNonceSource)
+	return func(next FilterFunc) FilterFunc {
+		return func(ctx *context.Context) {
+			header := ctx.ResponseWriter.Header()
+			// HSTS is ignored by browsers over HTTP
+			if hsts != "" && ctx.Input.IsSecure() {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+			if cfg.ContentTypeNosniff {
+				header.Set("X-Content-Type-Options", "nosniff")
+			}
+			if cfg.FrameOptions != "" {
+				header.Set("X-Frame-Options", cfg.FrameOptions)
+			}
+			if cfg.ReferrerPolicy != "" {
+				header.Set("Referrer-Policy", cfg.ReferrerPolicy)
+			}
+			if csp := cfg.ContentSecurityPolicy; csp != "" {
+				if useNonce {
+					nonce := newCSPNonce()
+					ctx.Input.SetData(CSPNonceKey, nonce)
+					csp = strings.ReplaceAll(csp, CSPNonceSource, "'nonce-"+nonce+"'")
+				}
+				header.Set("Content-Security-Policy", csp)
+			}
+			next(ctx)
+		}
+	}
+}
+
+// CSPNonce returns the CSP nonce of the request,
+// it's empty if the Content-Security-Policy doesn't contain CSPNonceSource
+func CSPNonce(ctx *context.Context) string {
+	nonce, _ := ctx.Input.GetData(CSPNonceKey).(string)
+	return nonce
+}
+
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func registerSecurityHeaders() error {
+	if BConfig.WebConfig.EnableSecurityHeaders {
+		BeeApp.InsertFilterChain("/*", SecurityHeaders(BConfig.WebConfig.SecurityHeaders))
+	}
+	return nil
+}
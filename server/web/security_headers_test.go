@@ -0,0 +1,66 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func TestCSP(t *testing.T) {
+	csp := NewCSP().DefaultSrc(CSPSelf).
+		ScriptSrc(CSPSelf).
+		ScriptSrc(CSPNonceSource).
+		Add("upgrade-insecure-requests").
+		String()
+	assert.Equal(t, "default-src 'self'; script-src 'self' 'nonce'; upgrade-insecure-requests", csp)
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := newBConfig().WebConfig.SecurityHeaders
+	cfg.HSTSIncludeSubdomains = true
+	cfg.ContentSecurityPolicy = NewCSP().ScriptSrc(CSPSelf, CSPNonceSource).String()
+
+	handler := NewControllerRegister()
+	handler.InsertFilterChain("/*", SecurityHeaders(cfg))
+	handler.Get("/", func(ctx *context.Context) {
+		_ = ctx.Output.Body([]byte(CSPNonce(ctx)))
+	})
+	handler.Init()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	nonce := w.Body.String()
+	assert.NotEmpty(t, nonce)
+	assert.Equal(t, "script-src 'self' 'nonce-"+nonce+"'", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.TLS = &tls.ConnectionState{}
+	handler.ServeHTTP(w, r)
+	assert.NotEqual(t, nonce, w.Body.String())
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -15,8 +15,8 @@
 package web
 
 import (
+	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
@@ -25,6 +25,8 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 
@@ -52,6 +54,21 @@ type HttpServer struct {
 	Server             *http.Server
 	Cfg                *Config
 	LifeCycleCallbacks []LifeCycleCallback
+
+	shutdownHooks []ShutdownHook
+	draining      atomic.Bool
+	shutdownOnce  sync.Once
+	shutdownDone  chan struct{}
+	shutdownErr   error
+
+	// systemdName is the name of listener passed by systemd, it's http by default
+	systemdName string
+
+	clientCertVerifiers []ClientCertVerifier
+
+	autocertCache autocert.Cache
+	autocertOnce  sync.Once
+	autocert      *autocert.Manager
 }
 
 // NewHttpSever returns a new beego application.
@@ -65,9 +82,10 @@ func NewHttpSever() *HttpServer {
 func NewHttpServerWithCfg(cfg *Config) *HttpServer {
 	cr := NewControllerRegisterWithCfg(cfg)
 	app := &HttpServer{
-		Handlers: cr,
-		Server:   &http.Server{},
-		Cfg:      cfg,
+		Handlers:     cr,
+		Server:       &http.Server{},
+		Cfg:          cfg,
+		shutdownDone: make(chan struct{}),
 	}
 
 	return app
@@ -89,6 +107,9 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 
 	// init...
 	app.initAddr(addr)
+	if app.Cfg.Listen.ReadinessPath != "" {
+		app.Handlers.Get(app.Cfg.Listen.ReadinessPath, app.serveReadiness)
+	}
 	app.Handlers.Init()
 
 	addr = app.Cfg.Listen.HTTPAddr
@@ -147,13 +168,39 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 		}
 		app.Server.Handler = mws[i](app.Server.Handler)
 	}
+	if app.Cfg.Listen.AutoTLS {
+		// serves HTTP-01 challenges, TLS-ALPN-01 challenges are served by the TLS config
+		app.Server.Handler = app.autocertManager().HTTPHandler(app.Server.Handler)
+	}
 	app.Server.ReadTimeout = time.Duration(app.Cfg.Listen.ServerTimeOut) * time.Second
 	app.Server.WriteTimeout = time.Duration(app.Cfg.Listen.ServerTimeOut) * time.Second
 	app.Server.ErrorLog = logs.GetLogger("HTTP")
+	// the hijacked WebSocket connections are not tracked by http.Server
+	app.Server.RegisterOnShutdown(app.Handlers.CloseWebSockets)
+	h3 := app.newHTTP3Server(app.Server.Handler)
+	if h3 != nil {
+		app.Server.Handler = altSvcHandler(h3, app.Server.Handler)
+		app.Server.RegisterOnShutdown(func() {
+			_ = h3.Close()
+		})
+	}
 
 	// run graceful mode
 	if app.Cfg.Listen.Graceful {
-		var opts []grace.ServerOption
+		if app.Cfg.Listen.GracefulRestartMode != "" {
+			grace.DefaultRestartMode = grace.RestartMode(app.Cfg.Listen.GracefulRestartMode)
+		}
+		opts := []grace.ServerOption{
+			grace.WithShutdownCallback(func() {
+				app.drain(context.Background())
+			}),
+			grace.WithShutdownCallback(app.Handlers.CloseWebSockets),
+		}
+		if h3 != nil {
+			opts = append(opts, grace.WithShutdownCallback(func() {
+				_ = h3.Close()
+			}))
+		}
 		for _, lifeCycleCallback := range app.LifeCycleCallbacks {
 			lifeCycleCallbackDup := lifeCycleCallback
 			opts = append(opts, grace.WithShutdownCallback(func() {
@@ -181,14 +228,12 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 						logs.Critical("ListenMutualTLS: ", err, fmt.Sprintf("%d", os.Getpid()))
 						return
 					}
+					// the config is shared with the listener, so it takes effect before serving
+					server.TLSConfig.ClientAuth = tls.ClientAuthType(app.Cfg.Listen.ClientAuth)
+					server.TLSConfig.VerifyPeerCertificate = app.verifyPeerCertificate
 				} else {
 					if app.Cfg.Listen.AutoTLS {
-						m := autocert.Manager{
-							Prompt:     autocert.AcceptTOS,
-							HostPolicy: autocert.HostWhitelist(app.Cfg.Listen.Domains...),
-							Cache:      autocert.DirCache(app.Cfg.Listen.TLSCacheDir),
-						}
-						app.Server.TLSConfig = &tls.Config{GetCertificate: m.GetCertificate}
+						server.TLSConfig = app.autocertManager().TLSConfig()
 						app.Cfg.Listen.HTTPSCertFile, app.Cfg.Listen.HTTPSKeyFile = "", ""
 					}
 					if ln, err = server.ListenTLS(app.Cfg.Listen.HTTPSCertFile, app.Cfg.Listen.HTTPSKeyFile); err != nil {
@@ -196,6 +241,9 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 						return
 					}
 				}
+				if h3 != nil {
+					go serveHTTP3(h3, httpsAddr, server.TLSConfig, "", "")
+				}
 				for _, callback := range app.LifeCycleCallbacks {
 					callback.AfterStart(app)
 				}
@@ -214,7 +262,7 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 				if app.Cfg.Listen.ListenTCP4 {
 					server.Network = "tcp4"
 				}
-				ln, err := net.Listen(server.Network, server.Addr)
+				ln, err := server.Listen()
 				logs.Info("graceful http server Running on http://%s", server.Addr)
 				if err != nil {
 					logs.Critical("Listen for HTTP[graceful mode]: ", err)
@@ -232,14 +280,38 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 			}()
 		}
 		<-endRunning
+		ctx, cancel := app.shutdownContext()
+		defer cancel()
+		if err := app.runShutdownHooks(ctx); err != nil {
+			logs.Error("shutdown: %v", err)
+		}
 		return
 	}
 
 	// run normal mode
+	if app.Cfg.Listen.EnableGracefulShutdown {
+		app.handleShutdownSignals()
+	}
+	// the listeners passed by systemd socket activation
+	var httpLn, httpsLn net.Listener
+	if app.Cfg.Listen.EnableSystemdSocket {
+		if httpLn, err = takeSystemdListener(app.systemdListenerName()); err != nil {
+			logs.Critical("Systemd socket activation: ", err)
+			return
+		}
+		if app.Cfg.Listen.EnableHTTPS || app.Cfg.Listen.EnableMutualHTTPS {
+			if httpsLn, err = takeSystemdListener(SystemdListenerHTTPS); err != nil {
+				logs.Critical("Systemd socket activation: ", err)
+				return
+			}
+		}
+	}
 	if app.Cfg.Listen.EnableHTTPS || app.Cfg.Listen.EnableMutualHTTPS {
 		go func() {
 			time.Sleep(1000 * time.Microsecond)
-			if app.Cfg.Listen.HTTPSPort != 0 {
+			if httpsLn != nil {
+				app.Server.Addr = httpsLn.Addr().String()
+			} else if app.Cfg.Listen.HTTPSPort != 0 {
 				app.Server.Addr = fmt.Sprintf("%s:%d", app.Cfg.Listen.HTTPSAddr, app.Cfg.Listen.HTTPSPort)
 			} else if app.Cfg.Listen.EnableHTTP {
 				logs.Info("Start https server error, conflict with http. Please reset https port")
@@ -247,27 +319,31 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 			}
 			logs.Info("https server Running on https://%s", app.Server.Addr)
 			if app.Cfg.Listen.AutoTLS {
-				m := autocert.Manager{
-					Prompt:     autocert.AcceptTOS,
-					HostPolicy: autocert.HostWhitelist(app.Cfg.Listen.Domains...),
-					Cache:      autocert.DirCache(app.Cfg.Listen.TLSCacheDir),
-				}
-				app.Server.TLSConfig = &tls.Config{GetCertificate: m.GetCertificate}
+				app.Server.TLSConfig = app.autocertManager().TLSConfig()
 				app.Cfg.Listen.HTTPSCertFile, app.Cfg.Listen.HTTPSKeyFile = "", ""
 			} else if app.Cfg.Listen.EnableMutualHTTPS {
-				pool := x509.NewCertPool()
-				data, err := os.ReadFile(app.Cfg.Listen.TrustCaFile)
+				tlsConfig, err := app.mutualTLSConfig()
 				if err != nil {
-					logs.Info("MutualHTTPS should provide TrustCaFile")
+					logs.Info(err.Error())
 					return
 				}
-				pool.AppendCertsFromPEM(data)
-				app.Server.TLSConfig = &tls.Config{
-					ClientCAs:  pool,
-					ClientAuth: tls.ClientAuthType(app.Cfg.Listen.ClientAuth),
-				}
+				app.Server.TLSConfig = tlsConfig
 			}
-			if err := app.Server.ListenAndServeTLS(app.Cfg.Listen.HTTPSCertFile, app.Cfg.Listen.HTTPSKeyFile); err != nil {
+			if h3 != nil {
+				go serveHTTP3(h3, app.Server.Addr, app.Server.TLSConfig,
+					app.Cfg.Listen.HTTPSCertFile, app.Cfg.Listen.HTTPSKeyFile)
+			}
+			var err error
+			if httpsLn != nil {
+				err = app.Server.ServeTLS(httpsLn, app.Cfg.Listen.HTTPSCertFile, app.Cfg.Listen.HTTPSKeyFile)
+			} else {
+				err = app.Server.ListenAndServeTLS(app.Cfg.Listen.HTTPSCertFile, app.Cfg.Listen.HTTPSKeyFile)
+			}
+			if err != nil {
+				if app.waitShutdown(err) {
+					endRunning <- true
+					return
+				}
 				logs.Critical("ListenAndServeTLS: ", err)
 				time.Sleep(100 * time.Microsecond)
 				endRunning <- true
@@ -277,16 +353,35 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 	if app.Cfg.Listen.EnableHTTP {
 		go func() {
 			app.Server.Addr = addr
-			logs.Info("http server Running on http://%s", app.Server.Addr)
-			if app.Cfg.Listen.ListenTCP4 {
-				ln, err := net.Listen("tcp4", app.Server.Addr)
+			ln := httpLn
+			if ln != nil {
+				app.Server.Addr = ln.Addr().String()
+			}
+			if ln == nil && app.Cfg.Listen.UnixSocket != "" {
+				logs.Info("http server Running on unix:%s", app.Cfg.Listen.UnixSocket)
+			} else {
+				logs.Info("http server Running on http://%s", app.Server.Addr)
+			}
+			if ln == nil && (app.Cfg.Listen.ListenTCP4 || app.Cfg.Listen.UnixSocket != "") {
+				var err error
+				if app.Cfg.Listen.UnixSocket != "" {
+					ln, err = app.listenUnix()
+				} else {
+					ln, err = net.Listen("tcp4", app.Server.Addr)
+				}
 				if err != nil {
 					logs.Critical("Listen for HTTP[normal mode]: ", err)
 					time.Sleep(100 * time.Microsecond)
 					endRunning <- true
 					return
 				}
-				if err = app.Server.Serve(ln); err != nil {
+			}
+			if ln != nil {
+				if err := app.Server.Serve(ln); err != nil {
+					if app.waitShutdown(err) {
+						endRunning <- true
+						return
+					}
 					logs.Critical("Serve: ", err)
 					time.Sleep(100 * time.Microsecond)
 					endRunning <- true
@@ -294,6 +389,10 @@ func (app *HttpServer) Run(addr string, mws ...MiddleWare) {
 				}
 			} else {
 				if err := app.Server.ListenAndServe(); err != nil {
+					if app.waitShutdown(err) {
+						endRunning <- true
+						return
+					}
 					logs.Critical("ListenAndServe: ", err)
 					time.Sleep(100 * time.Microsecond)
 					endRunning <- true
@@ -818,6 +917,20 @@ func (app *HttpServer) Handler(rootpath string, h http.Handler, options ...inter
 	return app
 }
 
+// Proxy see HttpServer.Proxy
+func Proxy(pattern, target string, opts ...ProxyOption) *HttpServer {
+	return BeeApp.Proxy(pattern, target, opts...)
+}
+
+// Proxy forwards the requests matching the pattern to target
+// usage:
+//
+//	beego.Proxy("/api/*", "http://127.0.0.1:8081", web.WithProxyStripPrefix("/api"), web.WithProxyRetry(2))
+func (app *HttpServer) Proxy(pattern, target string, opts ...ProxyOption) *HttpServer {
+	app.Handlers.Proxy(pattern, target, opts...)
+	return app
+}
+
 // InsertFilter see HttpServer.InsertFilter
 func InsertFilter(pattern string, pos int, filter FilterFunc, opts ...FilterOpt) *HttpServer {
 	return BeeApp.InsertFilter(pattern, pos, filter, opts...)
@@ -887,8 +1000,9 @@ func (app *HttpServer) LogAccess(ctx *beecontext.Context, startTime *time.Time,
 		HTTPReferrer:   r.Header.Get("Referer"),
 		HTTPUserAgent:  r.Header.Get("User-Agent"),
 		RemoteUser:     r.Header.Get("Remote-User"),
-		BodyBytesSent:  r.ContentLength,
+		BodyBytesSent:  ctx.ResponseWriter.Size,
 	}
+	record.RequestID, _ = utils.RequestIDFromContext(r.Context())
 	logs.AccessLog(record, app.Cfg.Log.AccessLogsFormat)
 }
 
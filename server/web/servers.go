@@ -0,0 +1,100 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// RunServers runs the servers in one process, each server has its own routers, filters and Cfg,
+// so they can listen on the different addresses with the different TLS settings.
+// When ctx is done, SIGINT or SIGTERM is received, or any server stops,
+// all servers are shut down gracefully at the same time, and it returns after all servers stop.
+// The servers must not share the same Cfg, and Graceful mode isn't supported.
+// usage:
+//
+//	internalCfg := *web.BConfig
+//	internalCfg.Listen.HTTPPort = 9090
+//	internal := web.NewHttpServerWithCfg(&internalCfg)
+//	internal.Get("/metrics", metrics)
+//	err := web.RunServers(context.Background(), web.BeeApp, internal)
+func RunServers(ctx context.Context, servers ...*HttpServer) error {
+	cfgs := make(map[*Config]struct{}, len(servers))
+	for _, app := range servers {
+		if _, ok := cfgs[app.Cfg]; ok {
+			return errors.New("the servers must not share the same Cfg")
+		}
+		if app.Cfg.Listen.Graceful {
+			return errors.New("the Graceful mode isn't supported when running multiple servers")
+		}
+		cfgs[app.Cfg] = struct{}{}
+	}
+
+	stopped := make(chan struct{}, len(servers))
+	var wg sync.WaitGroup
+	for _, app := range servers {
+		wg.Add(1)
+		go func(app *HttpServer) {
+			defer wg.Done()
+			app.Run("")
+			stopped <- struct{}{}
+		}(app)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	select {
+	case <-ctx.Done():
+	case sig := <-sigChan:
+		logs.Info("received %v, shutting down the servers", sig)
+	case <-stopped:
+		logs.Info("a server stopped, shutting down the servers")
+	}
+
+	errs := make([]error, len(servers))
+	var shutdownWg sync.WaitGroup
+	for i, app := range servers {
+		shutdownWg.Add(1)
+		go func(i int, app *HttpServer) {
+			defer shutdownWg.Done()
+			shutdownCtx, cancel := app.shutdownContext()
+			defer cancel()
+			errs[i] = app.Shutdown(shutdownCtx)
+		}(i, app)
+	}
+	shutdownWg.Wait()
+
+	// Run returns a moment after the server is closed
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		logs.Warn("some servers don't stop after shutting down")
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+func TestRunServers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test listens on unix sockets")
+	}
+	// Run locks the view paths, unlock them for the template tests
+	defer func() { beeViewPathTemplateLocked = false }()
+	dir := t.TempDir()
+	newServer := func(name string) *HttpServer {
+		cfg := *BConfig
+		cfg.Listen.UnixSocket = filepath.Join(dir, name+".sock")
+		app := NewHttpServerWithCfg(&cfg)
+		app.Get("/name", func(ctx *beecontext.Context) {
+			_ = ctx.Output.Body([]byte(name))
+		})
+		return app
+	}
+	public, internal := newServer("public"), newServer("internal")
+	hooks := 0
+	public.OnShutdown(func(ctx context.Context) error {
+		hooks++
+		return nil
+	})
+
+	get := func(name string) string {
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", filepath.Join(dir, name+".sock"))
+			},
+		}}
+		for i := 0; i < 50; i++ {
+			resp, err := client.Get("http://localhost/name")
+			if err != nil {
+				time.Sleep(20 * time.Millisecond)
+				continue
+			}
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return string(body)
+		}
+		return ""
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- RunServers(ctx, public, internal)
+	}()
+	assert.Equal(t, "public", get("public"))
+	assert.Equal(t, "internal", get("internal"))
+
+	cancel()
+	assert.Nil(t, <-done)
+	assert.Equal(t, 1, hooks)
+	assert.False(t, public.Ready())
+	assert.False(t, internal.Ready())
+
+	err := RunServers(context.Background(), public, NewHttpServerWithCfg(public.Cfg))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,360 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bolt for session provider
+//
+// depend on go.etcd.io/bbolt
+//
+// The sessions are stored in a single bbolt file, every write is committed in a transaction,
+// so a crash never leaves a partially written session.
+// The sessions are grouped into the buckets by the day they expire,
+// and GC drops the buckets of the past days as a whole.
+//
+// Usage:
+// import(
+//
+//	_ "github.com/beego/beego/v2/server/web/session/bolt"
+//	"github.com/beego/beego/v2/server/web/session"
+//
+// )
+//
+//	func init() {
+//		globalSessions, _ = session.NewManager("bolt", ``{"cookieName":"gosessionid","gclifetime":3600,"ProviderConfig":"{\"save_path\":\"./tmp/session.db\",\"timeout\":\"1s\"}"}``)
+//		go globalSessions.GC()
+//	}
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/beego/beego/v2/server/web/session"
+)
+
+var boltpder = &Provider{}
+
+// DefaultTimeout is the default time to wait for the file lock of the database
+var DefaultTimeout = time.Second
+
+// indexBucket maps sid to the name of the day bucket which stores it
+var indexBucket = []byte("sid")
+
+// dayLayout is the layout of the day bucket names, they are sorted by time
+const dayLayout = "20060102"
+
+// SessionStore bolt session store
+type SessionStore struct {
+	p           *Provider
+	sid         string
+	lock        sync.RWMutex
+	values      map[interface{}]interface{}
+	maxlifetime int64
+}
+
+// Set value in bolt session
+func (bs *SessionStore) Set(ctx context.Context, key, value interface{}) error {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	bs.values[key] = value
+	return nil
+}
+
+// Get value in bolt session
+func (bs *SessionStore) Get(ctx context.Context, key interface{}) interface{} {
+	bs.lock.RLock()
+	defer bs.lock.RUnlock()
+	if v, ok := bs.values[key]; ok {
+		return v
+	}
+	return nil
+}
+
+// Delete value in bolt session
+func (bs *SessionStore) Delete(ctx context.Context, key interface{}) error {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	delete(bs.values, key)
+	return nil
+}
+
+// Flush clear all values in bolt session
+func (bs *SessionStore) Flush(context.Context) error {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	bs.values = make(map[interface{}]interface{})
+	return nil
+}
+
+// SessionID get bolt session id
+func (bs *SessionStore) SessionID(context.Context) string {
+	return bs.sid
+}
+
+// SessionRelease save session values to bolt
+func (bs *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
+	bs.lock.RLock()
+	values := bs.values
+	bs.lock.RUnlock()
+	b, err := session.EncodeGob(values)
+	if err != nil {
+		return
+	}
+	expire := time.Now().Add(time.Duration(bs.maxlifetime) * time.Second)
+	_ = bs.p.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, bs.sid, expire, b)
+	})
+}
+
+// Provider bolt session provider
+type Provider struct {
+	maxlifetime int64
+	// SavePath is the path of the database file
+	SavePath string `json:"save_path"`
+	// Timeout is the time to wait for the file lock, the file can only be opened by one process
+	Timeout string `json:"timeout"`
+	db      *bolt.DB
+}
+
+// SessionInit init bolt session
+// v1.x cfgStr is the path of the database file, e.g. ./tmp/session.db
+// v2.x you should pass a json string
+// e.g. { "save_path": "./tmp/session.db", "timeout": "1s"}
+func (bp *Provider) SessionInit(ctx context.Context, maxlifetime int64, cfgStr string) error {
+	bp.maxlifetime = maxlifetime
+	cfgStr = strings.TrimSpace(cfgStr)
+	// we think cfgStr is v2.0, using json to init the session
+	if strings.HasPrefix(cfgStr, "{") {
+		if err := json.Unmarshal([]byte(cfgStr), bp); err != nil {
+			return err
+		}
+	} else {
+		bp.SavePath = cfgStr
+	}
+
+	timeout := DefaultTimeout
+	if bp.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(bp.Timeout); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(bp.SavePath), 0o777); err != nil {
+		return err
+	}
+	if bp.db != nil {
+		_ = bp.db.Close()
+	}
+	db, err := bolt.Open(bp.SavePath, 0o600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return err
+	}
+	bp.db = db
+	return db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(indexBucket)
+		return err
+	})
+}
+
+// SessionRead read bolt session by sid
+func (bp *Provider) SessionRead(ctx context.Context, sid string) (session.Store, error) {
+	var kvs []byte
+	err := bp.db.View(func(tx *bolt.Tx) error {
+		if data, ok := get(tx, sid); ok {
+			// the data is only valid in the transaction
+			kvs = append(kvs, data...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var kv map[interface{}]interface{}
+	if len(kvs) == 0 {
+		kv = make(map[interface{}]interface{})
+	} else {
+		if kv, err = session.DecodeGob(kvs); err != nil {
+			return nil, err
+		}
+	}
+	return &SessionStore{p: bp, sid: sid, values: kv, maxlifetime: bp.maxlifetime}, nil
+}
+
+// SessionExist check bolt session exist by sid
+func (bp *Provider) SessionExist(ctx context.Context, sid string) (bool, error) {
+	var ok bool
+	err := bp.db.View(func(tx *bolt.Tx) error {
+		_, ok = get(tx, sid)
+		return nil
+	})
+	return ok, err
+}
+
+// SessionRegenerate generate new sid for bolt session
+func (bp *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (session.Store, error) {
+	err := bp.db.Update(func(tx *bolt.Tx) error {
+		// the new sid is set to empty if oldsid doesn't exist
+		data, _ := get(tx, oldsid)
+		data = append([]byte(nil), data...)
+		if err := del(tx, oldsid); err != nil {
+			return err
+		}
+		return put(tx, sid, time.Now().Add(time.Duration(bp.maxlifetime)*time.Second), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bp.SessionRead(ctx, sid)
+}
+
+// SessionDestroy delete bolt session by id
+func (bp *Provider) SessionDestroy(ctx context.Context, sid string) error {
+	return bp.db.Update(func(tx *bolt.Tx) error {
+		return del(tx, sid)
+	})
+}
+
+// SessionGC drops the day buckets before today, and deletes the expired sessions of today
+func (bp *Provider) SessionGC(context.Context) {
+	now := time.Now()
+	today := []byte(now.UTC().Format(dayLayout))
+	_ = bp.db.Update(func(tx *bolt.Tx) error {
+		var expired [][]byte
+		_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if !bytes.Equal(name, indexBucket) && bytes.Compare(name, today) < 0 {
+				expired = append(expired, append([]byte(nil), name...))
+			}
+			return nil
+		})
+		idx := tx.Bucket(indexBucket)
+		for _, name := range expired {
+			err := tx.Bucket(name).ForEach(func(sid, _ []byte) error {
+				// the session may have been moved to a later day
+				if bytes.Equal(idx.Get(sid), name) {
+					return idx.Delete(sid)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if err = tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+
+		b := tx.Bucket(today)
+		if b == nil {
+			return nil
+		}
+		var sids [][]byte
+		_ = b.ForEach(func(sid, v []byte) error {
+			if !alive(v, now) {
+				sids = append(sids, append([]byte(nil), sid...))
+			}
+			return nil
+		})
+		for _, sid := range sids {
+			if err := del(tx, string(sid)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SessionAll return all active session, including the expired ones which are not collected yet
+func (bp *Provider) SessionAll(context.Context) int {
+	var n int
+	_ = bp.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(indexBucket).Stats().KeyN
+		return nil
+	})
+	return n
+}
+
+// the value is 8 bytes expiration unix time followed by the encoded session
+
+// get returns the encoded session if it exists and doesn't expire
+func get(tx *bolt.Tx, sid string) ([]byte, bool) {
+	day := tx.Bucket(indexBucket).Get([]byte(sid))
+	if day == nil {
+		return nil, false
+	}
+	b := tx.Bucket(day)
+	if b == nil {
+		return nil, false
+	}
+	v := b.Get([]byte(sid))
+	if !alive(v, time.Now()) {
+		return nil, false
+	}
+	return v[8:], true
+}
+
+// put stores the session into the bucket of the day it expires
+func put(tx *bolt.Tx, sid string, expire time.Time, data []byte) error {
+	idx := tx.Bucket(indexBucket)
+	day := []byte(expire.UTC().Format(dayLayout))
+	if old := idx.Get([]byte(sid)); old != nil && !bytes.Equal(old, day) {
+		if b := tx.Bucket(old); b != nil {
+			if err := b.Delete([]byte(sid)); err != nil {
+				return err
+			}
+		}
+	}
+	b, err := tx.CreateBucketIfNotExists(day)
+	if err != nil {
+		return err
+	}
+	v := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(v, uint64(expire.Unix()))
+	copy(v[8:], data)
+	if err = b.Put([]byte(sid), v); err != nil {
+		return err
+	}
+	return idx.Put([]byte(sid), day)
+}
+
+func del(tx *bolt.Tx, sid string) error {
+	idx := tx.Bucket(indexBucket)
+	day := idx.Get([]byte(sid))
+	if day == nil {
+		return nil
+	}
+	if b := tx.Bucket(day); b != nil {
+		if err := b.Delete([]byte(sid)); err != nil {
+			return err
+		}
+	}
+	return idx.Delete([]byte(sid))
+}
+
+func alive(v []byte, now time.Time) bool {
+	return len(v) >= 8 && int64(binary.BigEndian.Uint64(v)) > now.Unix()
+}
+
+func init() {
+	session.Register("bolt", boltpder)
+}
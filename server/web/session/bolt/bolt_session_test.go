@@ -0,0 +1,109 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func newProvider(t *testing.T) *Provider {
+	bp := &Provider{}
+	cfg := `{"save_path": "` + filepath.Join(t.TempDir(), "sess", "session.db") + `", "timeout": "100ms"}`
+	require.Nil(t, bp.SessionInit(context.Background(), 3600, cfg))
+	t.Cleanup(func() { _ = bp.db.Close() })
+	return bp
+}
+
+func TestProvider_SessionInit(t *testing.T) {
+	bp := newProvider(t)
+	assert.Equal(t, "100ms", bp.Timeout)
+	assert.Equal(t, int64(3600), bp.maxlifetime)
+
+	// the file is locked by bp
+	other := &Provider{}
+	assert.NotNil(t, other.SessionInit(context.Background(), 3600, `{"save_path": "`+bp.SavePath+`", "timeout": "10ms"}`))
+}
+
+func TestProvider_Session(t *testing.T) {
+	ctx := context.Background()
+	bp := newProvider(t)
+
+	ok, err := bp.SessionExist(ctx, "sid1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	store, err := bp.SessionRead(ctx, "sid1")
+	require.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "name", "beego"))
+	store.SessionRelease(ctx, httptest.NewRecorder())
+	assert.Equal(t, 1, bp.SessionAll(ctx))
+
+	ok, _ = bp.SessionExist(ctx, "sid1")
+	assert.True(t, ok)
+	store, err = bp.SessionRead(ctx, "sid1")
+	require.Nil(t, err)
+	assert.Equal(t, "beego", store.Get(ctx, "name"))
+
+	store, err = bp.SessionRegenerate(ctx, "sid1", "sid2")
+	require.Nil(t, err)
+	assert.Equal(t, "sid2", store.SessionID(ctx))
+	assert.Equal(t, "beego", store.Get(ctx, "name"))
+	ok, _ = bp.SessionExist(ctx, "sid1")
+	assert.False(t, ok)
+
+	store, err = bp.SessionRegenerate(ctx, "not-exist", "sid3")
+	require.Nil(t, err)
+	assert.Nil(t, store.Get(ctx, "name"))
+	assert.Equal(t, 2, bp.SessionAll(ctx))
+
+	assert.Nil(t, bp.SessionDestroy(ctx, "sid2"))
+	ok, _ = bp.SessionExist(ctx, "sid2")
+	assert.False(t, ok)
+	assert.Equal(t, 1, bp.SessionAll(ctx))
+}
+
+func TestProvider_SessionGC(t *testing.T) {
+	ctx := context.Background()
+	bp := newProvider(t)
+	now := time.Now()
+	err := bp.db.Update(func(tx *bolt.Tx) error {
+		assert.Nil(t, put(tx, "yesterday", now.AddDate(0, 0, -1), []byte("a")))
+		assert.Nil(t, put(tx, "expired", now.Add(-time.Second), []byte("b")))
+		assert.Nil(t, put(tx, "alive", now.Add(time.Hour), []byte("c")))
+		return nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 3, bp.SessionAll(ctx))
+
+	ok, _ := bp.SessionExist(ctx, "expired")
+	assert.False(t, ok)
+
+	bp.SessionGC(ctx)
+	ok, _ = bp.SessionExist(ctx, "alive")
+	assert.True(t, ok)
+	_ = bp.db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket([]byte(now.AddDate(0, 0, -1).UTC().Format(dayLayout))))
+		return nil
+	})
+	assert.Equal(t, 1, bp.SessionAll(ctx))
+}
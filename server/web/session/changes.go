@@ -0,0 +1,66 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+// Changes records the keys changed in the session during a request,
+// so the provider can only write the changed values instead of the whole session.
+// It isn't safe for concurrent use, the store should guard it by its lock
+type Changes struct {
+	// Flushed reports whether the whole session should be written,
+	// such as the session is flushed, new or stored in the old format
+	Flushed bool
+	keys    map[interface{}]struct{}
+}
+
+// Change records key is set or deleted
+func (c *Changes) Change(key interface{}) {
+	if c.Flushed {
+		return
+	}
+	if c.keys == nil {
+		c.keys = make(map[interface{}]struct{})
+	}
+	c.keys[key] = struct{}{}
+}
+
+// Flush records all the values are changed
+func (c *Changes) Flush() {
+	c.Flushed = true
+	c.keys = nil
+}
+
+// Keys returns the changed keys, the key is deleted if it isn't in the values of the session.
+// It's nil if Flushed is true
+func (c *Changes) Keys() []interface{} {
+	if c.Flushed || len(c.keys) == 0 {
+		return nil
+	}
+	keys := make([]interface{}, 0, len(c.keys))
+	for k := range c.keys {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Empty reports whether nothing is changed
+func (c *Changes) Empty() bool {
+	return !c.Flushed && len(c.keys) == 0
+}
+
+// Reset clears the changes after they're written
+func (c *Changes) Reset() {
+	c.Flushed = false
+	c.keys = nil
+}
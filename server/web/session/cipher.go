@@ -0,0 +1,114 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+// the encrypted session starts with 0 and the version,
+// the session encoded by gob, JSON or MessagePack never starts with 0,
+// so the plain sessions written before the encryption is enabled can still be read
+const (
+	encryptedMagic   byte = 0
+	encryptedVersion byte = 1
+)
+
+var errInvalidEncryptedSession = errors.New("session: the encrypted session can't be decrypted")
+
+// Cipher encrypts the encoded session before the provider stores it
+type Cipher interface {
+	Encrypt(plain []byte) ([]byte, error)
+	Decrypt(data []byte) ([]byte, error)
+}
+
+// CipherSetter is implemented by the providers which support the encryption at rest,
+// the manager passes its cipher to the provider after SessionInit if EncryptionKeys is set
+type CipherSetter interface {
+	SetCipher(c Cipher)
+}
+
+// NewAEADCipher returns the Cipher which encrypts the sessions by AES-GCM.
+// The first key encrypts and all the keys decrypt, so a new key is added to the front
+// and the old one is removed after all the sessions encrypted by it expire
+func NewAEADCipher(keys ...string) (Cipher, error) {
+	aeads, err := newAEADs(keys)
+	if err != nil {
+		return nil, err
+	}
+	return &aeadCipher{aeads: aeads}, nil
+}
+
+type aeadCipher struct {
+	aeads []cipher.AEAD
+}
+
+// Encrypt returns 0|version|nonce|cipher text
+func (c *aeadCipher) Encrypt(plain []byte) ([]byte, error) {
+	aead := c.aeads[0]
+	out := make([]byte, 2+aead.NonceSize(), 2+aead.NonceSize()+len(plain)+aead.Overhead())
+	out[0], out[1] = encryptedMagic, encryptedVersion
+	if _, err := rand.Read(out[2:]); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, out[2:], plain, nil), nil
+}
+
+// Decrypt tries all the keys, data is returned as it is if it isn't encrypted
+func (c *aeadCipher) Decrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != encryptedMagic {
+		return data, nil
+	}
+	if len(data) < 2 || data[1] != encryptedVersion {
+		return nil, errInvalidEncryptedSession
+	}
+	for _, aead := range c.aeads {
+		if len(data) < 2+aead.NonceSize() {
+			break
+		}
+		if plain, err := aead.Open(nil, data[2:2+aead.NonceSize()], data[2+aead.NonceSize():], nil); err == nil {
+			return plain, nil
+		}
+	}
+	return nil, errInvalidEncryptedSession
+}
+
+// newAEADs turns the secrets of any length to AES-256 keys
+func newAEADs(keys []string) ([]cipher.AEAD, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("session: the encryption requires at least one key")
+	}
+	aeads := make([]cipher.AEAD, 0, len(keys))
+	for _, k := range keys {
+		if k == "" {
+			return nil, errors.New("session: the encryption key is empty")
+		}
+		key := sha256.Sum256([]byte(k))
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		aeads = append(aeads, aead)
+	}
+	return aeads, nil
+}
@@ -103,6 +103,12 @@ func (cs *SessionStore) SessionID(context.Context) string {
 	return cs.sid
 }
 
+// Close closes the bucket without writing the session, the manager calls it when the session has expired
+func (cs *SessionStore) Close() error {
+	cs.b.Close()
+	return nil
+}
+
 // SessionRelease Write couchbase session with Gob string
 func (cs *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
 	defer cs.b.Close()
@@ -0,0 +1,402 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package etcd for session provider
+//
+// depend on go.etcd.io/etcd/client/v3
+//
+// Every session is attached to an etcd lease whose TTL is the max lifetime,
+// so the expired sessions are deleted by etcd and SessionGC does nothing.
+// If local_cache is enabled, the sessions are cached in memory and the cache is
+// invalidated by watching the prefix, so the changes made by other nodes are seen at once.
+//
+// Usage:
+// import(
+//
+//	_ "github.com/beego/beego/v2/server/web/session/etcd"
+//	"github.com/beego/beego/v2/server/web/session"
+//
+// )
+//
+//	func init() {
+//		globalSessions, _ = session.NewManager("etcd", ``{"cookieName":"gosessionid","gclifetime":3600,"ProviderConfig":"{\"endpoints\":[\"127.0.0.1:2379\"],\"prefix\":\"beego/session/\",\"local_cache\":true}"}``)
+//		go globalSessions.GC()
+//	}
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web/session"
+)
+
+var etcdpder = &Provider{}
+
+// DefaultPrefix is the default prefix of the session keys
+var DefaultPrefix = "beego/session/"
+
+// DefaultDialTimeout is the default timeout of connecting to etcd
+var DefaultDialTimeout = 5 * time.Second
+
+// SessionStore etcd session store
+type SessionStore struct {
+	p           *Provider
+	sid         string
+	lease       clientv3.LeaseID
+	lock        sync.RWMutex
+	values      map[interface{}]interface{}
+	maxlifetime int64
+}
+
+// Set value in etcd session
+func (es *SessionStore) Set(ctx context.Context, key, value interface{}) error {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+	es.values[key] = value
+	return nil
+}
+
+// Get value in etcd session
+func (es *SessionStore) Get(ctx context.Context, key interface{}) interface{} {
+	es.lock.RLock()
+	defer es.lock.RUnlock()
+	if v, ok := es.values[key]; ok {
+		return v
+	}
+	return nil
+}
+
+// Delete value in etcd session
+func (es *SessionStore) Delete(ctx context.Context, key interface{}) error {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+	delete(es.values, key)
+	return nil
+}
+
+// Flush clear all values in etcd session
+func (es *SessionStore) Flush(context.Context) error {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+	es.values = make(map[interface{}]interface{})
+	return nil
+}
+
+// SessionID get etcd session id
+func (es *SessionStore) SessionID(context.Context) string {
+	return es.sid
+}
+
+// SessionRelease save session values to etcd, and renews the lease
+func (es *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
+	es.lock.RLock()
+	values := es.values
+	es.lock.RUnlock()
+	b, err := session.EncodeGob(values)
+	if err != nil {
+		return
+	}
+	if err = es.p.put(ctx, es.sid, es.lease, b); err != nil {
+		logs.Error("save session %s to etcd failed: %v", es.sid, err)
+	}
+}
+
+// Provider etcd session provider
+type Provider struct {
+	maxlifetime int64
+	Endpoints   []string `json:"endpoints"`
+	Username    string   `json:"username"`
+	Password    string   `json:"password"`
+	// Prefix is prepended to sid as the key, it's DefaultPrefix if it's empty
+	Prefix string `json:"prefix"`
+	// DialTimeout is DefaultDialTimeout if it's empty
+	DialTimeout string `json:"dial_timeout"`
+	// LocalCache caches the sessions in memory, the cache is invalidated by watch
+	LocalCache bool `json:"local_cache"`
+
+	client *clientv3.Client
+	cache  *sessionCache
+	cancel context.CancelFunc
+}
+
+// SessionInit init etcd session
+// v1.x cfgStr is the endpoints, e.g. 127.0.0.1:2379;127.0.0.2:2379
+// v2.x you should pass a json string
+// e.g. { "endpoints": ["127.0.0.1:2379"], "prefix": "beego/session/", "dial_timeout": "3s", "local_cache": true}
+func (ep *Provider) SessionInit(ctx context.Context, maxlifetime int64, cfgStr string) error {
+	ep.maxlifetime = maxlifetime
+	cfgStr = strings.TrimSpace(cfgStr)
+	// we think cfgStr is v2.0, using json to init the session
+	if strings.HasPrefix(cfgStr, "{") {
+		if err := json.Unmarshal([]byte(cfgStr), ep); err != nil {
+			return err
+		}
+	} else {
+		ep.Endpoints = strings.Split(cfgStr, ";")
+	}
+	if ep.Prefix == "" {
+		ep.Prefix = DefaultPrefix
+	}
+	dialTimeout := DefaultDialTimeout
+	if ep.DialTimeout != "" {
+		var err error
+		if dialTimeout, err = time.ParseDuration(ep.DialTimeout); err != nil {
+			return err
+		}
+	}
+
+	if ep.cancel != nil {
+		ep.cancel()
+		_ = ep.client.Close()
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   ep.Endpoints,
+		Username:    ep.Username,
+		Password:    ep.Password,
+		DialTimeout: dialTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	ep.client = client
+
+	tctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	resp, err := client.Get(tctx, ep.Prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return err
+	}
+
+	var wctx context.Context
+	wctx, ep.cancel = context.WithCancel(context.Background())
+	ep.cache = nil
+	if ep.LocalCache {
+		ep.cache = newSessionCache()
+		go ep.watch(wctx, resp.Header.Revision+1)
+	}
+	return nil
+}
+
+// SessionRead read etcd session by sid
+func (ep *Provider) SessionRead(ctx context.Context, sid string) (session.Store, error) {
+	e, err := ep.get(ctx, sid)
+	if err != nil {
+		return nil, err
+	}
+
+	var kv map[interface{}]interface{}
+	if len(e.data) == 0 {
+		kv = make(map[interface{}]interface{})
+	} else {
+		if kv, err = session.DecodeGob(e.data); err != nil {
+			return nil, err
+		}
+	}
+	return &SessionStore{p: ep, sid: sid, lease: e.lease, values: kv, maxlifetime: ep.maxlifetime}, nil
+}
+
+// SessionExist check etcd session exist by sid
+func (ep *Provider) SessionExist(ctx context.Context, sid string) (bool, error) {
+	e, err := ep.get(ctx, sid)
+	return e.rev != 0, err
+}
+
+// SessionRegenerate generate new sid for etcd session
+func (ep *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (session.Store, error) {
+	e, err := ep.get(ctx, oldsid)
+	if err != nil {
+		return nil, err
+	}
+	// the new sid is set to empty if oldsid doesn't exist
+	lease, err := ep.client.Grant(ctx, ep.maxlifetime)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ep.client.Txn(ctx).Then(
+		clientv3.OpPut(ep.Prefix+sid, string(e.data), clientv3.WithLease(lease.ID)),
+		clientv3.OpDelete(ep.Prefix+oldsid),
+	).Commit()
+	if err != nil {
+		return nil, err
+	}
+	if ep.cache != nil {
+		ep.cache.delete(oldsid, resp.Header.Revision)
+		ep.cache.set(sid, cacheEntry{data: e.data, rev: resp.Header.Revision, lease: lease.ID}, resp.Header.Revision)
+	}
+	return ep.SessionRead(ctx, sid)
+}
+
+// SessionDestroy delete etcd session by id, the lease is left to expire
+func (ep *Provider) SessionDestroy(ctx context.Context, sid string) error {
+	resp, err := ep.client.Delete(ctx, ep.Prefix+sid)
+	if err != nil {
+		return err
+	}
+	if ep.cache != nil {
+		ep.cache.delete(sid, resp.Header.Revision)
+	}
+	return nil
+}
+
+// SessionGC Impelment method, no used.
+// The sessions are deleted by etcd when the leases expire
+func (ep *Provider) SessionGC(context.Context) {
+}
+
+// SessionAll return all active session
+func (ep *Provider) SessionAll(ctx context.Context) int {
+	resp, err := ep.client.Get(ctx, ep.Prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return 0
+	}
+	return int(resp.Count)
+}
+
+// get reads the session from the cache, or from etcd if it's not cached.
+// The rev of the entry is 0 if the session doesn't exist
+func (ep *Provider) get(ctx context.Context, sid string) (cacheEntry, error) {
+	if ep.cache != nil {
+		if e, ok := ep.cache.get(sid); ok {
+			return e, nil
+		}
+	}
+	resp, err := ep.client.Get(ctx, ep.Prefix+sid)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	if len(resp.Kvs) == 0 {
+		return cacheEntry{}, nil
+	}
+	kv := resp.Kvs[0]
+	e := cacheEntry{data: kv.Value, rev: kv.ModRevision, lease: clientv3.LeaseID(kv.Lease)}
+	if ep.cache != nil {
+		ep.cache.set(sid, e, resp.Header.Revision)
+	}
+	return e, nil
+}
+
+// put saves the session with the lease, the lease is renewed if it's alive, otherwise a new one is granted
+func (ep *Provider) put(ctx context.Context, sid string, lease clientv3.LeaseID, data []byte) error {
+	if lease != 0 {
+		if _, err := ep.client.KeepAliveOnce(ctx, lease); err != nil {
+			lease = 0
+		}
+	}
+	if lease == 0 {
+		resp, err := ep.client.Grant(ctx, ep.maxlifetime)
+		if err != nil {
+			return err
+		}
+		lease = resp.ID
+	}
+	resp, err := ep.client.Put(ctx, ep.Prefix+sid, string(data), clientv3.WithLease(lease))
+	if err != nil {
+		return err
+	}
+	if ep.cache != nil {
+		ep.cache.set(sid, cacheEntry{data: data, rev: resp.Header.Revision, lease: lease}, resp.Header.Revision)
+	}
+	return nil
+}
+
+// watch invalidates the cache when the sessions are changed by others, deleted or expired.
+// The cache is cleared if the watch fails because the events may be lost
+func (ep *Provider) watch(ctx context.Context, rev int64) {
+	for ctx.Err() == nil {
+		wch := ep.client.Watch(clientv3.WithRequireLeader(ctx), ep.Prefix, clientv3.WithPrefix(), clientv3.WithRev(rev))
+		for resp := range wch {
+			if err := resp.Err(); err != nil {
+				logs.Error("watch etcd sessions failed: %v", err)
+				break
+			}
+			for _, ev := range resp.Events {
+				ep.cache.delete(strings.TrimPrefix(string(ev.Kv.Key), ep.Prefix), ev.Kv.ModRevision)
+			}
+			rev = resp.Header.Revision + 1
+		}
+		ep.cache.clear()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+type cacheEntry struct {
+	data  []byte
+	rev   int64
+	lease clientv3.LeaseID
+}
+
+type sessionCache struct {
+	lock    sync.RWMutex
+	entries map[string]cacheEntry
+	// rev is the latest revision of the invalidations
+	rev int64
+}
+
+func newSessionCache() *sessionCache {
+	return &sessionCache{entries: make(map[string]cacheEntry)}
+}
+
+func (c *sessionCache) get(sid string) (cacheEntry, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	e, ok := c.entries[sid]
+	return e, ok
+}
+
+// set caches the entry which is read or written at rev.
+// It's ignored if there is any invalidation after rev, because the entry may be deleted or changed
+func (c *sessionCache) set(sid string, e cacheEntry, rev int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if rev < c.rev {
+		return
+	}
+	if old, ok := c.entries[sid]; ok && old.rev > e.rev {
+		return
+	}
+	c.entries[sid] = e
+}
+
+// delete removes the entry if it's older than rev, so the entry written by the event itself is kept
+func (c *sessionCache) delete(sid string, rev int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if rev > c.rev {
+		c.rev = rev
+	}
+	if e, ok := c.entries[sid]; ok && e.rev < rev {
+		delete(c.entries, sid)
+	}
+}
+
+func (c *sessionCache) clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+func init() {
+	session.Register("etcd", etcdpder)
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_SessionInit(t *testing.T) {
+	defer func(d time.Duration) { DefaultDialTimeout = d }(DefaultDialTimeout)
+	DefaultDialTimeout = 100 * time.Millisecond
+
+	// using old style
+	ep := &Provider{}
+	err := ep.SessionInit(context.Background(), 12, "127.0.0.1:1;127.0.0.1:2")
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"127.0.0.1:1", "127.0.0.1:2"}, ep.Endpoints)
+	assert.Equal(t, DefaultPrefix, ep.Prefix)
+	assert.Equal(t, int64(12), ep.maxlifetime)
+
+	ep = &Provider{}
+	err = ep.SessionInit(context.Background(), 12,
+		`{"endpoints": ["127.0.0.1:1"], "prefix": "sess/", "dial_timeout": "100ms", "local_cache": true}`)
+	assert.NotNil(t, err)
+	assert.Equal(t, "sess/", ep.Prefix)
+	assert.True(t, ep.LocalCache)
+
+	ep = &Provider{}
+	err = ep.SessionInit(context.Background(), 12, `{"dial_timeout": "abc"}`)
+	assert.NotNil(t, err)
+}
+
+func TestSessionCache(t *testing.T) {
+	c := newSessionCache()
+	c.set("a", cacheEntry{data: []byte("1"), rev: 10}, 10)
+	e, ok := c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), e.data)
+
+	// the event of the write itself doesn't invalidate the entry
+	c.delete("a", 10)
+	_, ok = c.get("a")
+	assert.True(t, ok)
+
+	// the older entry doesn't overwrite the newer one
+	c.set("a", cacheEntry{data: []byte("0"), rev: 9}, 10)
+	e, _ = c.get("a")
+	assert.Equal(t, []byte("1"), e.data)
+
+	c.delete("a", 11)
+	_, ok = c.get("a")
+	assert.False(t, ok)
+
+	// the entry read before the invalidation isn't cached
+	c.set("b", cacheEntry{data: []byte("2"), rev: 5}, 10)
+	_, ok = c.get("b")
+	assert.False(t, ok)
+
+	c.set("c", cacheEntry{data: []byte("3"), rev: 12}, 12)
+	c.clear()
+	_, ok = c.get("c")
+	assert.False(t, ok)
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// EventType is the type of the session lifecycle event
+type EventType string
+
+const (
+	// EventCreated is emitted when a new session is started
+	EventCreated EventType = "created"
+	// EventRegenerated is emitted when the id of the session is regenerated
+	EventRegenerated EventType = "regenerated"
+	// EventDestroyed is emitted when the session is destroyed by the manager
+	EventDestroyed EventType = "destroyed"
+	// EventExpired is emitted when the session expires by the timeouts or the GC
+	EventExpired EventType = "expired"
+	// EventInvalidated is emitted when another instance invalidates the session through the InvalidationBus
+	EventInvalidated EventType = "invalidated"
+)
+
+// the reasons of EventDestroyed and EventExpired
+const (
+	ReasonLogout           = "logout"
+	ReasonRevoked          = "revoked"
+	ReasonEvicted          = "evicted"
+	ReasonConcurrencyLimit = "concurrency_limit"
+	ReasonIdleTimeout      = "idle_timeout"
+	ReasonAbsoluteTimeout  = "absolute_timeout"
+	ReasonGC               = "gc"
+)
+
+// Event is the session lifecycle event
+type Event struct {
+	Type      EventType
+	SessionID string
+	// OldSessionID is the id before the regeneration, it's only set for EventRegenerated
+	OldSessionID string
+	// Reason is why the session is destroyed or expired, see the Reason constants
+	Reason string
+	Time   time.Time
+	// Request is the request which triggers the event, it's nil if there is no request such as the GC
+	Request *http.Request
+}
+
+// EventHook is called synchronously when the event happens, so it should be fast
+type EventHook func(ctx context.Context, e Event)
+
+// GCReporter is implemented by the providers which can report the sessions removed by the GC,
+// the manager calls SessionGCExpired instead of SessionGC and emits EventExpired for them
+type GCReporter interface {
+	SessionGCExpired(ctx context.Context) []string
+}
+
+// AddEventHook registers hook for the session lifecycle events,
+// it should be called before the manager is used
+func (manager *Manager) AddEventHook(hook EventHook) {
+	manager.hooks = append(manager.hooks, hook)
+}
+
+func (manager *Manager) emit(ctx context.Context, e Event) {
+	manager.publish(ctx, e)
+	if len(manager.hooks) == 0 {
+		return
+	}
+	e.Time = time.Now()
+	for _, hook := range manager.hooks {
+		hook(ctx, e)
+	}
+}
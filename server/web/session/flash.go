@@ -0,0 +1,80 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import "context"
+
+// flashKey is the key of the flash messages stored in the session,
+// the messages are map[string]interface{} whose values are []interface{},
+// so they can be encoded by gob and JSON
+const flashKey = "__beego_session_flashes"
+
+// Flash adds value to the flash messages of key in store.
+// The messages are kept in the session until they're read by GetFlashes, so they survive the redirects.
+// value must be encodable by the provider, the custom types should be registered by gob.Register
+func Flash(ctx context.Context, store Store, key string, value interface{}) error {
+	old := flashes(ctx, store)
+	// the map is copied because the provider may keep the values by reference
+	messages := make(map[string]interface{}, len(old)+1)
+	for k, v := range old {
+		messages[k] = v
+	}
+	list, _ := messages[key].([]interface{})
+	messages[key] = append(list[:len(list):len(list)], value)
+	return store.Set(ctx, flashKey, messages)
+}
+
+// GetFlashes returns the flash messages of key in the order they're added, and removes them from store.
+// It returns nil if there is no message
+func GetFlashes(ctx context.Context, store Store, key string) []interface{} {
+	old := flashes(ctx, store)
+	list, ok := old[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	if len(old) == 1 {
+		_ = store.Delete(ctx, flashKey)
+		return list
+	}
+	messages := make(map[string]interface{}, len(old)-1)
+	for k, v := range old {
+		if k != key {
+			messages[k] = v
+		}
+	}
+	_ = store.Set(ctx, flashKey, messages)
+	return list
+}
+
+// GetAllFlashes returns all the flash messages by their keys, and removes them from store
+func GetAllFlashes(ctx context.Context, store Store) map[string][]interface{} {
+	old := flashes(ctx, store)
+	if len(old) == 0 {
+		return map[string][]interface{}{}
+	}
+	res := make(map[string][]interface{}, len(old))
+	for k, v := range old {
+		if list, ok := v.([]interface{}); ok {
+			res[k] = list
+		}
+	}
+	_ = store.Delete(ctx, flashKey)
+	return res
+}
+
+func flashes(ctx context.Context, store Store) map[string]interface{} {
+	messages, _ := store.Get(ctx, flashKey).(map[string]interface{})
+	return messages
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// BatchGCReporter is implemented by the providers which can remove the expired sessions in batches,
+// so a sweep can be split by ManagerConfig.GCBatchSize and stopped after ManagerConfig.GCMaxDuration
+type BatchGCReporter interface {
+	// SessionGCBatch removes at most limit expired sessions and returns their ids,
+	// more is true if there may be more expired sessions. It should stop early when ctx is done
+	SessionGCBatch(ctx context.Context, limit int) (expired []string, more bool)
+}
+
+// GCStats is the statistics of the session GC
+type GCStats struct {
+	// Runs is the number of sweeps
+	Runs int64 `json:"runs"`
+	// Expired is the number of sessions removed by all the sweeps,
+	// the sweeps of the providers which don't implement GCReporter aren't counted
+	Expired int64 `json:"expired"`
+	// LastRun is when the last sweep started
+	LastRun time.Time `json:"lastRun"`
+	// LastDuration is how long the last sweep took
+	LastDuration time.Duration `json:"lastDuration"`
+	// LastExpired is the number of sessions removed by the last sweep,
+	// -1 if the provider doesn't implement GCReporter
+	LastExpired int `json:"lastExpired"`
+	// Interrupted is true if the last sweep was stopped by GCMaxDuration before it's finished
+	Interrupted bool `json:"interrupted"`
+	// Active is the number of active sessions after the last sweep
+	Active int `json:"active"`
+}
+
+// GC starts the sweeps of the expired sessions every Gclifetime seconds, it should be called only once
+func (manager *Manager) GC() {
+	manager.gc(context.Background())
+	time.AfterFunc(time.Duration(manager.config.Gclifetime)*time.Second, func() { manager.GC() })
+}
+
+// RunGC sweeps the expired sessions right now and returns the statistics after the sweep.
+// It waits if another sweep is running
+func (manager *Manager) RunGC(ctx context.Context) GCStats {
+	return manager.gc(ctx)
+}
+
+// GCStats returns the statistics of the sweeps so far
+func (manager *Manager) GCStats() GCStats {
+	manager.gcLock.Lock()
+	defer manager.gcLock.Unlock()
+	return manager.gcStats
+}
+
+func (manager *Manager) gc(ctx context.Context) GCStats {
+	stats, expired := manager.sweep(ctx)
+	// the hooks are called out of the lock, so they're able to read the statistics
+	for _, sid := range expired {
+		manager.emit(ctx, Event{Type: EventExpired, SessionID: sid, Reason: ReasonGC})
+	}
+	return stats
+}
+
+func (manager *Manager) sweep(ctx context.Context) (GCStats, []string) {
+	manager.gcLock.Lock()
+	defer manager.gcLock.Unlock()
+
+	start := time.Now()
+	sweepCtx := ctx
+	if manager.config.GCMaxDuration > 0 {
+		var cancel context.CancelFunc
+		sweepCtx, cancel = context.WithTimeout(ctx, time.Duration(manager.config.GCMaxDuration)*time.Millisecond)
+		defer cancel()
+	}
+
+	var (
+		expired     []string
+		reported    = true
+		interrupted bool
+	)
+	switch p := manager.provider.(type) {
+	case BatchGCReporter:
+		for {
+			ids, more := p.SessionGCBatch(sweepCtx, manager.config.GCBatchSize)
+			expired = append(expired, ids...)
+			if !more {
+				break
+			}
+			if sweepCtx.Err() != nil {
+				interrupted = true
+				break
+			}
+		}
+	case GCReporter:
+		expired = p.SessionGCExpired(sweepCtx)
+	default:
+		manager.provider.SessionGC(sweepCtx)
+		reported = false
+	}
+
+	s := &manager.gcStats
+	s.Runs++
+	s.LastRun = start
+	s.LastDuration = time.Since(start)
+	s.Interrupted = interrupted
+	s.LastExpired = -1
+	if reported {
+		s.LastExpired = len(expired)
+		s.Expired += int64(len(expired))
+	}
+	s.Active = manager.provider.SessionAll(ctx)
+	return *s, expired
+}
+
+// canceled reports whether ctx is done, the legacy callers of SessionGC may pass nil
+func canceled(ctx context.Context) bool {
+	return ctx != nil && ctx.Err() != nil
+}
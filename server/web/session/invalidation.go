@@ -0,0 +1,117 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+
+	"github.com/beego/beego/v2/core/pubsub"
+)
+
+// Invalidation is the message broadcast when a session is destroyed, expired or regenerated
+type Invalidation struct {
+	SessionID string `json:"sid"`
+	// Reason is the reason of the original event, it's empty for the regeneration
+	Reason string `json:"reason"`
+	// Origin is the id of the manager which publishes the invalidation, the manager ignores its own invalidations
+	Origin string `json:"origin"`
+}
+
+// InvalidationBus broadcasts the invalidations between the instances,
+// so the other instances purge the copies of the session they cache in memory
+type InvalidationBus = pubsub.Bus[Invalidation]
+
+// Invalidator is implemented by the providers which cache the sessions in memory,
+// SessionInvalidate purges the cached session when another instance invalidates it
+type Invalidator interface {
+	SessionInvalidate(ctx context.Context, sid string)
+}
+
+// invalidationQueueSize bounds the invalidations waiting to be published,
+// the new ones are dropped if the bus is too slow
+const invalidationQueueSize = 1024
+
+// SetInvalidationBus publishes the invalidations of the sessions to bus and subscribes the ones of the other instances.
+// The invalidations are published in the background, so the slow bus doesn't block the requests.
+// The invalidations received are passed to the provider if it implements Invalidator
+// and emitted as EventInvalidated, so the application can purge its own caches in the hooks.
+// It should be called before the manager is used, the subscription and the publishing stop when ctx is done
+func (manager *Manager) SetInvalidationBus(ctx context.Context, bus InvalidationBus) error {
+	if manager.instanceID == "" {
+		id, err := manager.sessionID()
+		if err != nil {
+			return err
+		}
+		manager.instanceID = id
+	}
+	err := bus.Subscribe(ctx, func(ctx context.Context, inv Invalidation) {
+		if inv.Origin == manager.instanceID || inv.SessionID == "" {
+			return
+		}
+		if p, ok := manager.provider.(Invalidator); ok {
+			p.SessionInvalidate(ctx, inv.SessionID)
+		}
+		manager.emit(ctx, Event{Type: EventInvalidated, SessionID: inv.SessionID, Reason: inv.Reason})
+	})
+	if err != nil {
+		return err
+	}
+	queue := make(chan Invalidation, invalidationQueueSize)
+	go publishInvalidations(ctx, bus, queue)
+	manager.invalidations = queue
+	return nil
+}
+
+func publishInvalidations(ctx context.Context, bus InvalidationBus, queue <-chan Invalidation) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case inv := <-queue:
+			if err := bus.Publish(ctx, inv); err != nil {
+				SLogger.Printf("publish the invalidation of session %s failed: %v", inv.SessionID, err)
+			}
+		}
+	}
+}
+
+// publish queues the invalidation for the events which make the session unusable
+func (manager *Manager) publish(ctx context.Context, e Event) {
+	if manager.invalidations == nil {
+		return
+	}
+	inv := Invalidation{SessionID: e.SessionID, Reason: e.Reason, Origin: manager.instanceID}
+	switch e.Type {
+	case EventDestroyed, EventExpired:
+	case EventRegenerated:
+		if e.OldSessionID == "" {
+			return
+		}
+		inv.SessionID = e.OldSessionID
+	default:
+		return
+	}
+	select {
+	case manager.invalidations <- inv:
+	default:
+		SLogger.Printf("the invalidation queue is full, the invalidation of session %s is dropped", inv.SessionID)
+	}
+}
+
+// NewMemoryInvalidationBus returns the InvalidationBus in memory,
+// it only works for the managers in the same process
+func NewMemoryInvalidationBus() InvalidationBus {
+	return pubsub.NewMemoryBus[Invalidation]()
+}
@@ -0,0 +1,356 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwt for session provider
+//
+// depend on github.com/golang-jwt/jwt/v5
+//
+// The session is stored in a JWT signed by HS256, the session id is the jti claim
+// and the values are in the sess claim, so there is no server side storage except the revocation list.
+// The token is read from the cookie or the header by the manager, and it's refreshed by SessionRelease.
+// The values are encoded as JSON, so the keys must be strings and the numbers are read as float64.
+//
+// Usage:
+// import(
+//
+//	_ "github.com/beego/beego/v2/server/web/session/jwt"
+//	"github.com/beego/beego/v2/server/web/session"
+//
+// )
+//
+//	func init() {
+//		globalSessions, _ = session.NewManager("jwt", ``{"cookieName":"gosessionid","enableSetCookie":false,"gclifetime":3600,
+//			"enableSidInHTTPHeader":true,"sessionNameInHTTPHeader":"X-Session-Token",
+//			"ProviderConfig":"{\"keys\":[\"secret\"],\"header\":\"X-Session-Token\"}"}``)
+//		go globalSessions.GC()
+//	}
+//
+// The logout revokes the token by its jti until it expires, the revocation list is in memory by default,
+// use the shared cache for the multiple instances:
+//
+//	jwt.SetRevocationList(jwt.NewCacheRevocationList(redisCache))
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwtgo "github.com/golang-jwt/jwt/v5"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/server/web/session"
+)
+
+var jwtpder = &Provider{}
+
+// sessClaim is the claim which stores the session values
+const sessClaim = "sess"
+
+// RevocationList records the revoked session ids
+type RevocationList interface {
+	// Revoke revokes id until ttl passes, the tokens of id are expired after ttl
+	Revoke(ctx context.Context, id string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+// NewCacheRevocationList returns RevocationList backed by c,
+// it should be the shared cache such as redis if there are multiple instances
+func NewCacheRevocationList(c cache.Cache) RevocationList {
+	return &cacheRevocationList{c: c}
+}
+
+type cacheRevocationList struct {
+	c cache.Cache
+}
+
+func (r *cacheRevocationList) Revoke(ctx context.Context, id string, ttl time.Duration) error {
+	return r.c.Put(ctx, r.key(id), true, ttl)
+}
+
+func (r *cacheRevocationList) IsRevoked(ctx context.Context, id string) (bool, error) {
+	return r.c.IsExist(ctx, r.key(id))
+}
+
+func (r *cacheRevocationList) key(id string) string {
+	return "jwt_session_revoked_" + id
+}
+
+// SetRevocationList sets the revocation list of jwt provider, it's in memory by default
+func SetRevocationList(rl RevocationList) {
+	jwtpder.revocation = rl
+}
+
+// SessionStore jwt session store
+type SessionStore struct {
+	p      *Provider
+	sid    string
+	lock   sync.RWMutex
+	values map[interface{}]interface{}
+}
+
+// Set value in jwt session
+func (st *SessionStore) Set(ctx context.Context, key, value interface{}) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.values[key] = value
+	return nil
+}
+
+// Get value in jwt session
+func (st *SessionStore) Get(ctx context.Context, key interface{}) interface{} {
+	st.lock.RLock()
+	defer st.lock.RUnlock()
+	if v, ok := st.values[key]; ok {
+		return v
+	}
+	return nil
+}
+
+// Delete value in jwt session
+func (st *SessionStore) Delete(ctx context.Context, key interface{}) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	delete(st.values, key)
+	return nil
+}
+
+// Flush clear all values in jwt session
+func (st *SessionStore) Flush(context.Context) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.values = make(map[interface{}]interface{})
+	return nil
+}
+
+// SessionID returns the jti of the token
+func (st *SessionStore) SessionID(context.Context) string {
+	return st.sid
+}
+
+// RegenerateID revokes the old id and changes the id in place, the values are kept in the token
+func (st *SessionStore) RegenerateID(ctx context.Context, sid string) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	if err := st.p.revoke(ctx, st.sid); err != nil {
+		return err
+	}
+	st.sid = sid
+	return nil
+}
+
+// SessionRelease signs a new token and writes it to the cookie and the header,
+// the expiration of the token is extended by maxlifetime
+func (st *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
+	st.lock.RLock()
+	token, err := st.p.sign(st.sid, st.values)
+	st.lock.RUnlock()
+	if err != nil {
+		session.SLogger.Println(err)
+		return
+	}
+	cfg := st.p.config
+	if cfg.CookieName != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     cfg.CookieName,
+			Value:    token,
+			Path:     "/",
+			Domain:   cfg.Domain,
+			HttpOnly: true,
+			Secure:   cfg.Secure,
+			MaxAge:   cfg.Maxage,
+			SameSite: cfg.SameSite,
+		})
+	}
+	if cfg.Header != "" {
+		w.Header().Set(cfg.Header, token)
+	}
+}
+
+type config struct {
+	// Keys are the HMAC secrets, the first one signs the tokens and all of them verify
+	Keys   []string `json:"keys"`
+	Issuer string   `json:"issuer"`
+	// Header is the response header which the token is written to
+	Header string `json:"header"`
+	// CookieName is the cookie which the token is written to
+	CookieName string        `json:"cookieName"`
+	Domain     string        `json:"domain"`
+	Secure     bool          `json:"secure"`
+	Maxage     int           `json:"maxage"`
+	SameSite   http.SameSite `json:"sameSite"`
+}
+
+// Provider jwt session provider
+type Provider struct {
+	maxlifetime int64
+	config      *config
+	parser      *jwtgo.Parser
+	revocation  RevocationList
+}
+
+// SessionInit init jwt session
+// json config:
+//
+//	keys - the HMAC secrets, the first one signs the tokens and all of them verify
+//	issuer - the iss claim, it's verified if it's not empty
+//	header - the response header which the token is written to
+//	cookieName - the cookie which the token is written to, it should be the same as the cookie name of the manager
+//	domain, secure, maxage, sameSite - the cookie attributes
+func (jp *Provider) SessionInit(ctx context.Context, maxlifetime int64, cfgStr string) error {
+	cfg := &config{}
+	if err := json.Unmarshal([]byte(cfgStr), cfg); err != nil {
+		return err
+	}
+	if len(cfg.Keys) == 0 {
+		return errors.New("session: jwt requires at least one key")
+	}
+	opts := []jwtgo.ParserOption{
+		jwtgo.WithValidMethods([]string{jwtgo.SigningMethodHS256.Alg()}),
+		jwtgo.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwtgo.WithIssuer(cfg.Issuer))
+	}
+	jp.config = cfg
+	jp.parser = jwtgo.NewParser(opts...)
+	jp.maxlifetime = maxlifetime
+	if jp.revocation == nil {
+		mc := cache.NewMemoryCache()
+		if err := mc.StartAndGC(`{"interval":60}`); err != nil {
+			return err
+		}
+		jp.revocation = NewCacheRevocationList(mc)
+	}
+	return nil
+}
+
+// SessionRead read jwt session from the token.
+// It returns an empty session whose id is sid if sid isn't a valid token, such as the new sid from the manager
+func (jp *Provider) SessionRead(ctx context.Context, sid string) (session.Store, error) {
+	id, values, err := jp.parse(ctx, sid)
+	if err != nil {
+		id, values = sid, make(map[interface{}]interface{})
+	}
+	return &SessionStore{p: jp, sid: id, values: values}, nil
+}
+
+// SessionExist reports whether sid is a valid token which isn't revoked
+func (jp *Provider) SessionExist(ctx context.Context, sid string) (bool, error) {
+	_, _, err := jp.parse(ctx, sid)
+	return err == nil, nil
+}
+
+// SessionRegenerate revokes the old token and keeps the values with the new id
+func (jp *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (session.Store, error) {
+	id, values, err := jp.parse(ctx, oldsid)
+	if err != nil {
+		values = make(map[interface{}]interface{})
+	} else if err = jp.revoke(ctx, id); err != nil {
+		return nil, err
+	}
+	return &SessionStore{p: jp, sid: sid, values: values}, nil
+}
+
+// SessionDestroy revokes the token
+func (jp *Provider) SessionDestroy(ctx context.Context, sid string) error {
+	id, _, err := jp.parse(ctx, sid)
+	if err != nil {
+		// the invalid token can't be used anyway
+		return nil
+	}
+	return jp.revoke(ctx, id)
+}
+
+// SessionGC Implement method, no used.
+func (jp *Provider) SessionGC(context.Context) {
+}
+
+// SessionAll Implement method, return 0.
+func (jp *Provider) SessionAll(context.Context) int {
+	return 0
+}
+
+func (jp *Provider) revoke(ctx context.Context, id string) error {
+	return jp.revocation.Revoke(ctx, id, time.Duration(jp.maxlifetime)*time.Second)
+}
+
+func (jp *Provider) sign(id string, values map[interface{}]interface{}) (string, error) {
+	sess := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		key, ok := k.(string)
+		if !ok {
+			return "", fmt.Errorf("session: the key of jwt session must be string, but got %T", k)
+		}
+		sess[key] = v
+	}
+	now := time.Now()
+	claims := jwtgo.MapClaims{
+		"jti":     id,
+		"iat":     now.Unix(),
+		"exp":     now.Add(time.Duration(jp.maxlifetime) * time.Second).Unix(),
+		sessClaim: sess,
+	}
+	if jp.config.Issuer != "" {
+		claims["iss"] = jp.config.Issuer
+	}
+	return jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claims).SignedString([]byte(jp.config.Keys[0]))
+}
+
+// parse verifies the token by all the keys, and returns the jti and the values
+func (jp *Provider) parse(ctx context.Context, token string) (string, map[interface{}]interface{}, error) {
+	token = strings.TrimPrefix(token, "Bearer ")
+	var (
+		claims jwtgo.MapClaims
+		err    error
+	)
+	for _, key := range jp.config.Keys {
+		claims = jwtgo.MapClaims{}
+		if _, err = jp.parser.ParseWithClaims(token, claims, func(*jwtgo.Token) (interface{}, error) {
+			return []byte(key), nil
+		}); err == nil || !errors.Is(err, jwtgo.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	id, _ := claims["jti"].(string)
+	if id == "" {
+		return "", nil, errors.New("session: the jwt doesn't have jti")
+	}
+	revoked, err := jp.revocation.IsRevoked(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	if revoked {
+		return "", nil, errors.New("session: the jwt is revoked")
+	}
+	sess, _ := claims[sessClaim].(map[string]interface{})
+	values := make(map[interface{}]interface{}, len(sess))
+	for k, v := range sess {
+		values[k] = v
+	}
+	return id, values, nil
+}
+
+func init() {
+	session.Register("jwt", jwtpder)
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/beego/beego/v2/client/cache"
+	"github.com/beego/beego/v2/server/web/session"
+)
+
+func newProvider(t *testing.T, cfg string) *Provider {
+	jp := &Provider{}
+	require.Nil(t, jp.SessionInit(context.Background(), 3600, cfg))
+	return jp
+}
+
+func TestProvider_Session(t *testing.T) {
+	ctx := context.Background()
+	jp := newProvider(t, `{"keys":["secret"],"issuer":"beego","header":"X-Session-Token","cookieName":"sid"}`)
+
+	store, err := jp.SessionRead(ctx, "new-sid")
+	require.Nil(t, err)
+	assert.Nil(t, store.Set(ctx, "uid", "123"))
+	w := httptest.NewRecorder()
+	store.SessionRelease(ctx, w)
+
+	token := w.Header().Get("X-Session-Token")
+	assert.NotEmpty(t, token)
+	assert.Equal(t, token, w.Result().Cookies()[0].Value)
+
+	ok, err := jp.SessionExist(ctx, token)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, _ = jp.SessionExist(ctx, "Bearer "+token)
+	assert.True(t, ok)
+
+	store, err = jp.SessionRead(ctx, token)
+	require.Nil(t, err)
+	assert.Equal(t, "new-sid", store.SessionID(ctx))
+	assert.Equal(t, "123", store.Get(ctx, "uid"))
+
+	// the other issuer or key is rejected
+	ok, _ = newProvider(t, `{"keys":["secret"],"issuer":"other"}`).SessionExist(ctx, token)
+	assert.False(t, ok)
+	ok, _ = newProvider(t, `{"keys":["other"]}`).SessionExist(ctx, token)
+	assert.False(t, ok)
+
+	// the key is rotated
+	ok, _ = newProvider(t, `{"keys":["new-secret","secret"],"issuer":"beego"}`).SessionExist(ctx, token)
+	assert.True(t, ok)
+
+	// the key of value must be string
+	assert.Nil(t, store.Set(ctx, 1, "one"))
+	w = httptest.NewRecorder()
+	store.SessionRelease(ctx, w)
+	assert.Empty(t, w.Header().Get("X-Session-Token"))
+}
+
+func TestProvider_Revocation(t *testing.T) {
+	ctx := context.Background()
+	jp := newProvider(t, `{"keys":["secret"],"header":"X-Session-Token"}`)
+	jp.revocation = NewCacheRevocationList(cache.NewMemoryCache())
+
+	store, _ := jp.SessionRead(ctx, "sid1")
+	_ = store.Set(ctx, "uid", "123")
+	w := httptest.NewRecorder()
+	store.SessionRelease(ctx, w)
+	token := w.Header().Get("X-Session-Token")
+
+	store, err := jp.SessionRegenerate(ctx, token, "sid2")
+	require.Nil(t, err)
+	assert.Equal(t, "sid2", store.SessionID(ctx))
+	assert.Equal(t, "123", store.Get(ctx, "uid"))
+	ok, _ := jp.SessionExist(ctx, token)
+	assert.False(t, ok)
+
+	w = httptest.NewRecorder()
+	store.SessionRelease(ctx, w)
+	token = w.Header().Get("X-Session-Token")
+	ok, _ = jp.SessionExist(ctx, token)
+	assert.True(t, ok)
+	assert.Nil(t, jp.SessionDestroy(ctx, token))
+	ok, _ = jp.SessionExist(ctx, token)
+	assert.False(t, ok)
+}
+
+func TestProvider_Manager(t *testing.T) {
+	globalSessions, err := session.NewManager("jwt", session.NewManagerConfig(
+		session.CfgCookieName("gosessionid"),
+		session.CfgGcLifeTime(3600),
+		session.CfgMaxLifeTime(3600),
+		session.CfgSessionIdInHTTPHeader(true),
+		session.CfgSetSessionNameInHTTPHeader("X-Session-Token"),
+		session.CfgProviderConfig(`{"keys":["secret"],"header":"X-Session-Token"}`),
+	))
+	require.Nil(t, err)
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	sess, err := globalSessions.SessionStart(w, r)
+	require.Nil(t, err)
+	sid := sess.SessionID(nil)
+	_ = sess.Set(nil, "uid", "123")
+	sess.SessionRelease(nil, w)
+
+	r, _ = http.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Session-Token", w.Header().Get("X-Session-Token"))
+	sess, err = globalSessions.SessionStart(httptest.NewRecorder(), r)
+	require.Nil(t, err)
+	assert.Equal(t, sid, sess.SessionID(nil))
+	assert.Equal(t, "123", sess.Get(nil, "uid"))
+}
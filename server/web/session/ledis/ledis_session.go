@@ -160,7 +160,7 @@ func (lp *Provider) SessionExist(ctx context.Context, sid string) (bool, error)
 
 // SessionRegenerate generate new sid for ledis session
 func (lp *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (session.Store, error) {
-	count, _ := c.Exists([]byte(sid))
+	count, _ := c.Exists([]byte(oldsid))
 	if count == 0 {
 		// oldsid doesn't exists, set the new sid directly
 		// ignore error here, since if it return error
@@ -171,6 +171,7 @@ func (lp *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (
 		data, _ := c.Get([]byte(oldsid))
 		c.Set([]byte(sid), data)
 		c.Expire([]byte(sid), lp.maxlifetime)
+		c.Del([]byte(oldsid))
 	}
 	return lp.SessionRead(context.Background(), sid)
 }
@@ -16,6 +16,7 @@ package ledis
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,19 +24,21 @@ import (
 
 func TestProvider_SessionInit(t *testing.T) {
 	// using old style
-	savePath := `http://host:port/,100`
+	dir := t.TempDir()
+	savePath := dir + ",100"
 	cp := &Provider{}
 	cp.SessionInit(context.Background(), 12, savePath)
-	assert.Equal(t, "http://host:port/", cp.SavePath)
+	assert.Equal(t, dir, cp.SavePath)
 	assert.Equal(t, 100, cp.Db)
 	assert.Equal(t, int64(12), cp.maxlifetime)
 
-	savePath = `
-{ "save_path": "my save path", "db": 100}
-`
+	dir = t.TempDir()
+	savePath = fmt.Sprintf(`
+{ "save_path": %q, "db": 100}
+`, dir)
 	cp = &Provider{}
 	cp.SessionInit(context.Background(), 12, savePath)
-	assert.Equal(t, "my save path", cp.SavePath)
+	assert.Equal(t, dir, cp.SavePath)
 	assert.Equal(t, 100, cp.Db)
 	assert.Equal(t, int64(12), cp.maxlifetime)
 }
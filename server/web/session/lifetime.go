@@ -0,0 +1,117 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// the keys of the timestamps stored in the session when IdleTimeout or AbsoluteTimeout is enabled,
+// they're stored with the values so every provider enforces the timeouts in the same way
+const (
+	createdAtKey  = "__beego_session_created_at"
+	accessedAtKey = "__beego_session_accessed_at"
+)
+
+// Lifetime is the expiration of a session, the zero time means the timeout is disabled
+type Lifetime struct {
+	// IdleExpiresAt is when the session expires if it isn't accessed again
+	IdleExpiresAt time.Time
+	// AbsoluteExpiresAt is when the session expires anyway
+	AbsoluteExpiresAt time.Time
+}
+
+// IdleRemaining returns the duration before the idle timeout, 0 if the idle timeout is disabled
+func (l Lifetime) IdleRemaining() time.Duration {
+	return remaining(l.IdleExpiresAt)
+}
+
+// AbsoluteRemaining returns the duration before the absolute timeout, 0 if the absolute timeout is disabled
+func (l Lifetime) AbsoluteRemaining() time.Duration {
+	return remaining(l.AbsoluteExpiresAt)
+}
+
+// ExpiresAt returns the earlier one of IdleExpiresAt and AbsoluteExpiresAt,
+// the zero time if both timeouts are disabled
+func (l Lifetime) ExpiresAt() time.Time {
+	if l.IdleExpiresAt.IsZero() ||
+		(!l.AbsoluteExpiresAt.IsZero() && l.AbsoluteExpiresAt.Before(l.IdleExpiresAt)) {
+		return l.AbsoluteExpiresAt
+	}
+	return l.IdleExpiresAt
+}
+
+func remaining(t time.Time) time.Duration {
+	if t.IsZero() {
+		return 0
+	}
+	if d := time.Until(t); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// SessionLifetime returns the expiration of store, so the UI can warn the user before the session expires
+func (manager *Manager) SessionLifetime(ctx context.Context, store Store) Lifetime {
+	var l Lifetime
+	if manager.config.IdleTimeout > 0 {
+		if accessed, ok := unixTime(store.Get(ctx, accessedAtKey)); ok {
+			l.IdleExpiresAt = accessed.Add(time.Duration(manager.config.IdleTimeout) * time.Second)
+		}
+	}
+	if manager.config.AbsoluteTimeout > 0 {
+		if created, ok := unixTime(store.Get(ctx, createdAtKey)); ok {
+			l.AbsoluteExpiresAt = created.Add(time.Duration(manager.config.AbsoluteTimeout) * time.Second)
+		}
+	}
+	return l
+}
+
+// touch checks the timeouts of store and records the access at now.
+// It returns the reason if store has expired, the new session gets the timestamps at now
+func (manager *Manager) touch(ctx context.Context, store Store, now time.Time) string {
+	if manager.config.IdleTimeout <= 0 && manager.config.AbsoluteTimeout <= 0 {
+		return ""
+	}
+	l := manager.SessionLifetime(ctx, store)
+	if !l.AbsoluteExpiresAt.IsZero() && !now.Before(l.AbsoluteExpiresAt) {
+		return ReasonAbsoluteTimeout
+	}
+	if !l.IdleExpiresAt.IsZero() && !now.Before(l.IdleExpiresAt) {
+		return ReasonIdleTimeout
+	}
+	if _, ok := unixTime(store.Get(ctx, createdAtKey)); !ok {
+		_ = store.Set(ctx, createdAtKey, now.Unix())
+	}
+	// the access is recorded as often as the TTL is refreshed, so the session isn't modified in every request
+	if accessed, ok := unixTime(store.Get(ctx, accessedAtKey)); !ok || manager.refresh.due(accessed, now) {
+		_ = store.Set(ctx, accessedAtKey, now.Unix())
+	}
+	return ""
+}
+
+// unixTime reads the timestamp, it's float64 if the provider encodes the values as JSON
+func unixTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case int64:
+		return time.Unix(t, 0), true
+	case int:
+		return time.Unix(int64(t), 0), true
+	case float64:
+		return time.Unix(int64(t), 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,256 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the keys of the principal and the session id registered in SessionRegistry,
+// the session is registered again if the principal or the session id changes
+const (
+	principalKey    = "__beego_session_principal"
+	registeredIDKey = "__beego_session_registered_id"
+)
+
+// ErrSessionLimitExceeded is returned when the principal has too many sessions and the eviction policy is EvictDeny
+var ErrSessionLimitExceeded = errors.New("session: the concurrent sessions of the user exceed the limit")
+
+// EvictionPolicy decides which session is evicted when the principal has too many sessions
+type EvictionPolicy string
+
+const (
+	// EvictOldest destroys the oldest sessions of the principal
+	EvictOldest EvictionPolicy = "oldest"
+	// EvictNewest destroys the newest sessions of the principal except the current one
+	EvictNewest EvictionPolicy = "newest"
+	// EvictDeny rejects the current session
+	EvictDeny EvictionPolicy = "deny"
+)
+
+// PrincipalExtractor returns the principal such as the user id of the session,
+// it returns "" if the session isn't authenticated
+type PrincipalExtractor func(ctx context.Context, store Store) string
+
+// SessionInfo is a session of the principal
+type SessionInfo struct {
+	ID        string
+	CreatedAt time.Time
+}
+
+// SessionRegistry records the sessions of the principals.
+// The sessions which expire are removed by the manager when the sessions of the principal are listed.
+// It should be shared by the instances, the default one is in memory
+type SessionRegistry interface {
+	Add(ctx context.Context, principal string, info SessionInfo) error
+	Remove(ctx context.Context, principal string, sid string) error
+	List(ctx context.Context, principal string) ([]SessionInfo, error)
+}
+
+// ConcurrencyLimit limits the concurrent sessions of a principal
+type ConcurrencyLimit struct {
+	Principal PrincipalExtractor
+	// Max is the max number of the sessions of a principal, 0 means no limit
+	Max      int
+	Eviction EvictionPolicy
+	// Registry is in memory if it's nil
+	Registry SessionRegistry
+}
+
+// NewMemorySessionRegistry returns the SessionRegistry in memory, it only works for a single instance
+func NewMemorySessionRegistry() SessionRegistry {
+	return &memorySessionRegistry{sessions: make(map[string][]SessionInfo)}
+}
+
+type memorySessionRegistry struct {
+	lock     sync.RWMutex
+	sessions map[string][]SessionInfo
+}
+
+func (m *memorySessionRegistry) Add(ctx context.Context, principal string, info SessionInfo) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, s := range m.sessions[principal] {
+		if s.ID == info.ID {
+			return nil
+		}
+	}
+	m.sessions[principal] = append(m.sessions[principal], info)
+	return nil
+}
+
+func (m *memorySessionRegistry) Remove(ctx context.Context, principal string, sid string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	list := m.sessions[principal]
+	for i, s := range list {
+		if s.ID == sid {
+			list = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(m.sessions, principal)
+	} else {
+		m.sessions[principal] = list
+	}
+	return nil
+}
+
+func (m *memorySessionRegistry) List(ctx context.Context, principal string) ([]SessionInfo, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return append([]SessionInfo(nil), m.sessions[principal]...), nil
+}
+
+// SetConcurrencyLimit limits the concurrent sessions of a principal.
+// The session is registered when it's started with a principal, or by RegisterSession after login.
+// It should be called before the manager is used
+func (manager *Manager) SetConcurrencyLimit(limit ConcurrencyLimit) error {
+	if limit.Principal == nil {
+		return errors.New("session: the principal extractor of the concurrency limit is nil")
+	}
+	switch limit.Eviction {
+	case "":
+		limit.Eviction = EvictOldest
+	case EvictOldest, EvictNewest, EvictDeny:
+	default:
+		return errors.New("session: unknown eviction policy " + string(limit.Eviction))
+	}
+	if limit.Registry == nil {
+		limit.Registry = NewMemorySessionRegistry()
+	}
+	manager.limit = &limit
+	return nil
+}
+
+// RegisterSession registers store with its principal and evicts the sessions exceeding the limit.
+// It should be called after login, so ErrSessionLimitExceeded can be reported to the user if the policy is EvictDeny.
+// It's no-op if the concurrency limit isn't set or store isn't authenticated
+func (manager *Manager) RegisterSession(ctx context.Context, store Store) error {
+	if manager.limit == nil {
+		return nil
+	}
+	principal := manager.limit.Principal(ctx, store)
+	if principal == "" {
+		return nil
+	}
+	sid := store.SessionID(ctx)
+	if store.Get(ctx, principalKey) == principal && store.Get(ctx, registeredIDKey) == sid {
+		return nil
+	}
+
+	sessions, err := manager.UserSessions(ctx, principal)
+	if err != nil {
+		return err
+	}
+	others := sessions[:0]
+	for _, s := range sessions {
+		if s.ID != sid {
+			others = append(others, s)
+		}
+	}
+	if exceeded := len(others) + 1 - manager.limit.Max; manager.limit.Max > 0 && exceeded > 0 {
+		if manager.limit.Eviction == EvictDeny {
+			return ErrSessionLimitExceeded
+		}
+		evicted := others[:exceeded]
+		if manager.limit.Eviction == EvictNewest {
+			evicted = others[len(others)-exceeded:]
+		}
+		for _, s := range evicted {
+			if err = manager.revoke(ctx, principal, s.ID, ReasonEvicted); err != nil {
+				return err
+			}
+		}
+	}
+
+	createdAt, ok := unixTime(store.Get(ctx, createdAtKey))
+	if !ok {
+		createdAt = time.Now()
+	}
+	if err = manager.limit.Registry.Add(ctx, principal, SessionInfo{ID: sid, CreatedAt: createdAt}); err != nil {
+		return err
+	}
+	_ = store.Set(ctx, principalKey, principal)
+	return store.Set(ctx, registeredIDKey, sid)
+}
+
+// UserSessions returns the sessions of principal from the oldest to the newest,
+// the sessions which don't exist any more are removed from the registry
+func (manager *Manager) UserSessions(ctx context.Context, principal string) ([]SessionInfo, error) {
+	if manager.limit == nil {
+		return nil, nil
+	}
+	sessions, err := manager.limit.Registry.List(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+	alive := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		exist, err := manager.provider.SessionExist(ctx, s.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			if err = manager.limit.Registry.Remove(ctx, principal, s.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		alive = append(alive, s)
+	}
+	sort.SliceStable(alive, func(i, j int) bool {
+		return alive[i].CreatedAt.Before(alive[j].CreatedAt)
+	})
+	return alive, nil
+}
+
+// RevokeUserSession destroys the session sid of principal
+func (manager *Manager) RevokeUserSession(ctx context.Context, principal string, sid string) error {
+	return manager.revoke(ctx, principal, sid, ReasonRevoked)
+}
+
+// RevokeUserSessions destroys all the sessions of principal, such as after the password is changed
+func (manager *Manager) RevokeUserSessions(ctx context.Context, principal string) error {
+	if manager.limit == nil {
+		return nil
+	}
+	sessions, err := manager.limit.Registry.List(ctx, principal)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		if err = manager.RevokeUserSession(ctx, principal, s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (manager *Manager) revoke(ctx context.Context, principal string, sid string, reason string) error {
+	if err := manager.provider.SessionDestroy(ctx, sid); err != nil {
+		return err
+	}
+	manager.emit(ctx, Event{Type: EventDestroyed, SessionID: sid, Reason: reason})
+	if manager.limit == nil {
+		return nil
+	}
+	return manager.limit.Registry.Remove(ctx, principal, sid)
+}
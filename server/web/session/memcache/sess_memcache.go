@@ -175,14 +175,11 @@ func (rp *MemProvider) SessionRegenerate(ctx context.Context, oldsid, sid string
 		}
 	}
 	var contain []byte
-	if item, err := client.Get(sid); err != nil || len(item.Value) == 0 {
+	if item, err := client.Get(oldsid); err != nil || len(item.Value) == 0 {
 		// oldsid doesn't exists, set the new sid directly
 		// ignore error here, since if it return error
 		// the existed value will be 0
-		item.Key = sid
-		item.Value = []byte("")
-		item.Expiration = int32(rp.maxlifetime)
-		client.Set(item)
+		client.Set(&memcache.Item{Key: sid, Value: []byte(""), Expiration: int32(rp.maxlifetime)})
 	} else {
 		client.Delete(oldsid)
 		item.Key = sid
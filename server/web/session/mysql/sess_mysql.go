@@ -65,6 +65,9 @@ type SessionStore struct {
 	sid    string
 	lock   sync.RWMutex
 	values map[interface{}]interface{}
+	// the row is inserted by SessionRead, so modified only reports whether the values are changed
+	refresh  session.RefreshPolicy
+	modified bool
 }
 
 // Set value in mysql session.
@@ -73,6 +76,7 @@ func (st *SessionStore) Set(ctx context.Context, key, value interface{}) error {
 	st.lock.Lock()
 	defer st.lock.Unlock()
 	st.values[key] = value
+	st.modified = true
 	return nil
 }
 
@@ -91,6 +95,7 @@ func (st *SessionStore) Delete(ctx context.Context, key interface{}) error {
 	st.lock.Lock()
 	defer st.lock.Unlock()
 	delete(st.values, key)
+	st.modified = true
 	return nil
 }
 
@@ -99,6 +104,7 @@ func (st *SessionStore) Flush(context.Context) error {
 	st.lock.Lock()
 	defer st.lock.Unlock()
 	st.values = make(map[interface{}]interface{})
+	st.modified = true
 	return nil
 }
 
@@ -107,13 +113,23 @@ func (st *SessionStore) SessionID(context.Context) string {
 	return st.sid
 }
 
+// Close closes the mysql connection without saving the session, see session.Closer
+func (st *SessionStore) Close() error {
+	return st.c.Close()
+}
+
 // SessionRelease save mysql session values to database.
 // must call this method to save values to database.
+// The session which isn't modified is saved according to the refresh policy
 func (st *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
 	defer st.c.Close()
-	st.lock.RLock()
+	st.lock.Lock()
+	if !st.refresh.NeedRefresh(st.values, st.modified, time.Now()) {
+		st.lock.Unlock()
+		return
+	}
 	values := st.values
-	st.lock.RUnlock()
+	st.lock.Unlock()
 	b, err := session.EncodeGob(values)
 	if err != nil {
 		return
@@ -126,6 +142,12 @@ func (st *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWrite
 type Provider struct {
 	maxlifetime int64
 	savePath    string
+	refresh     session.RefreshPolicy
+}
+
+// SetRefreshPolicy set how often the expiry of the session is refreshed, it's called by the manager
+func (mp *Provider) SetRefreshPolicy(policy session.RefreshPolicy) {
+	mp.refresh = policy
 }
 
 // connect to mysql
@@ -166,7 +188,7 @@ func (mp *Provider) SessionRead(ctx context.Context, sid string) (session.Store,
 			return nil, err
 		}
 	}
-	rs := &SessionStore{c: c, sid: sid, values: kv}
+	rs := &SessionStore{c: c, sid: sid, values: kv, refresh: mp.refresh}
 	return rs, nil
 }
 
@@ -208,7 +230,7 @@ func (mp *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (
 			return nil, err
 		}
 	}
-	rs := &SessionStore{c: c, sid: sid, values: kv}
+	rs := &SessionStore{c: c, sid: sid, values: kv, refresh: mp.refresh}
 	return rs, nil
 }
 
@@ -35,6 +35,7 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -58,6 +59,13 @@ type SessionStore struct {
 	lock        sync.RWMutex
 	values      map[interface{}]interface{}
 	maxlifetime int64
+	refresh     session.RefreshPolicy
+	codec       session.Codec
+	// modified reports whether the values are changed or the session is new
+	modified bool
+	// partial is true if the session is a hash and only the changed values are written
+	partial bool
+	changes session.Changes
 }
 
 // Set value in redis session
@@ -65,6 +73,8 @@ func (rs *SessionStore) Set(ctx context.Context, key, value interface{}) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	rs.values[key] = value
+	rs.modified = true
+	rs.changes.Change(key)
 	return nil
 }
 
@@ -83,6 +93,8 @@ func (rs *SessionStore) Delete(ctx context.Context, key interface{}) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	delete(rs.values, key)
+	rs.modified = true
+	rs.changes.Change(key)
 	return nil
 }
 
@@ -91,6 +103,8 @@ func (rs *SessionStore) Flush(context.Context) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	rs.values = make(map[interface{}]interface{})
+	rs.modified = true
+	rs.changes.Flush()
 	return nil
 }
 
@@ -99,12 +113,24 @@ func (rs *SessionStore) SessionID(context.Context) string {
 	return rs.sid
 }
 
-// SessionRelease save session values to redis
+// SessionRelease save session values to redis,
+// the session which isn't modified is saved according to the refresh policy
 func (rs *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
-	rs.lock.RLock()
+	rs.lock.Lock()
+	if !rs.refresh.NeedRefresh(rs.values, rs.modified, time.Now()) {
+		rs.lock.Unlock()
+		return
+	}
+	if rs.partial {
+		defer rs.lock.Unlock()
+		if err := rs.releasePartial(ctx); err != nil {
+			session.SLogger.Println(err)
+		}
+		return
+	}
 	values := rs.values
-	rs.lock.RUnlock()
-	b, err := session.EncodeGob(values)
+	rs.lock.Unlock()
+	b, err := rs.codec.Encode(values)
 	if err != nil {
 		return
 	}
@@ -112,6 +138,76 @@ func (rs *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWrite
 	c.Set(ctx, rs.sid, string(b), time.Duration(rs.maxlifetime)*time.Second)
 }
 
+// releasePartial writes the changed values to the fields of the hash and refreshes the TTL in a transaction,
+// the whole session is written if it's flushed or new
+func (rs *SessionStore) releasePartial(ctx context.Context) error {
+	pipe := rs.p.TxPipeline()
+	keys := rs.changes.Keys()
+	if rs.changes.Flushed {
+		pipe.Del(ctx, rs.sid)
+		keys = make([]interface{}, 0, len(rs.values))
+		for k := range rs.values {
+			keys = append(keys, k)
+		}
+	}
+	// the refresh time is updated by NeedRefresh
+	if _, ok := rs.values[session.RefreshedAtKey]; ok {
+		keys = append(keys, session.RefreshedAtKey)
+	}
+	fields := make([]interface{}, 0, 2*len(keys))
+	for _, k := range keys {
+		v, ok := rs.values[k]
+		if !ok {
+			pipe.HDel(ctx, rs.sid, field(k))
+			continue
+		}
+		b, err := rs.codec.Encode(map[interface{}]interface{}{k: v})
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field(k), b)
+	}
+	if len(fields) == 0 && rs.changes.Flushed {
+		// the empty session is kept by the placeholder
+		fields = append(fields, placeholderField, "")
+	}
+	if len(fields) > 0 {
+		pipe.HSet(ctx, rs.sid, fields...)
+	}
+	pipe.Expire(ctx, rs.sid, time.Duration(rs.maxlifetime)*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	rs.changes.Reset()
+	return nil
+}
+
+// placeholderField keeps the empty session in partial mode, the fields of the values are never empty
+const placeholderField = ""
+
+// field is the field of key in the hash
+func field(key interface{}) string {
+	return fmt.Sprintf("%T:%v", key, key)
+}
+
+// decodeFields merges the values encoded in the fields of the hash
+func decodeFields(c session.Codec, fields map[string]string) (map[interface{}]interface{}, error) {
+	kv := make(map[interface{}]interface{}, len(fields))
+	for f, v := range fields {
+		if f == placeholderField {
+			continue
+		}
+		m, err := c.Decode([]byte(v))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range m {
+			kv[k] = v
+		}
+	}
+	return kv, nil
+}
+
 // Provider redis session provider
 type Provider struct {
 	maxlifetime int64
@@ -126,7 +222,28 @@ type Provider struct {
 	idleCheckFrequency    time.Duration
 	IdleCheckFrequencyStr string `json:"idle_check_frequency"`
 	MaxRetries            int    `json:"max_retries"`
-	poollist              *redis.Client
+	// PartialWrite stores the session as a hash whose fields are the values,
+	// so only the changed values are written instead of the whole session.
+	// The sessions stored as a string are converted when they're written
+	PartialWrite bool `json:"partial_write"`
+	poollist     *redis.Client
+	refresh      session.RefreshPolicy
+	codec        session.Codec
+}
+
+// SetRefreshPolicy set how often the TTL of the session is refreshed, it's called by the manager
+func (rp *Provider) SetRefreshPolicy(policy session.RefreshPolicy) {
+	rp.refresh = policy
+}
+
+// SetCipher set the cipher which encrypts the sessions, it's called by the manager
+func (rp *Provider) SetCipher(c session.Cipher) {
+	rp.codec.Cipher = c
+}
+
+// SetSerializer set the serializer of the sessions, it's called by the manager
+func (rp *Provider) SetSerializer(s session.Serializer) {
+	rp.codec.Serializer = s
 }
 
 // SessionInit init redis session
@@ -219,6 +336,9 @@ func (rp *Provider) initOldStyle(savePath string) {
 
 // SessionRead read redis session by sid
 func (rp *Provider) SessionRead(ctx context.Context, sid string) (session.Store, error) {
+	if rp.PartialWrite {
+		return rp.readPartial(ctx, sid)
+	}
 	var kv map[interface{}]interface{}
 
 	kvs, err := rp.poollist.Get(ctx, sid).Result()
@@ -228,12 +348,46 @@ func (rp *Provider) SessionRead(ctx context.Context, sid string) (session.Store,
 	if len(kvs) == 0 {
 		kv = make(map[interface{}]interface{})
 	} else {
-		if kv, err = session.DecodeGob([]byte(kvs)); err != nil {
+		if kv, err = rp.codec.Decode([]byte(kvs)); err != nil {
 			return nil, err
 		}
 	}
 
-	rs := &SessionStore{p: rp.poollist, sid: sid, values: kv, maxlifetime: rp.maxlifetime}
+	rs := &SessionStore{p: rp.poollist, sid: sid, values: kv, maxlifetime: rp.maxlifetime,
+		refresh: rp.refresh, codec: rp.codec, modified: len(kvs) == 0}
+	return rs, nil
+}
+
+func (rp *Provider) readPartial(ctx context.Context, sid string) (session.Store, error) {
+	rs := &SessionStore{p: rp.poollist, sid: sid, maxlifetime: rp.maxlifetime,
+		refresh: rp.refresh, codec: rp.codec, partial: true}
+	fields, err := rp.poollist.HGetAll(ctx, sid).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE") {
+		// the session is stored as a string before PartialWrite is enabled
+		kvs, err := rp.poollist.Get(ctx, sid).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		rs.values = make(map[interface{}]interface{})
+		if len(kvs) > 0 {
+			if rs.values, err = rp.codec.Decode([]byte(kvs)); err != nil {
+				return nil, err
+			}
+		}
+		rs.modified = true
+		rs.changes.Flush()
+		return rs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rs.values, err = decodeFields(rp.codec, fields); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		rs.modified = true
+		rs.changes.Flush()
+	}
 	return rs, nil
 }
 
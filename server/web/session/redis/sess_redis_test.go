@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/beego/beego/v2/server/web/session"
@@ -112,3 +113,79 @@ func TestProvider_SessionInit(t *testing.T) {
 	assert.Equal(t, 3*time.Second, cp.idleTimeout)
 	assert.Equal(t, int64(12), cp.maxlifetime)
 }
+
+func TestRedisPartialWrite(t *testing.T) {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "127.0.0.1:6379"
+	}
+	rp := &Provider{}
+	err := rp.SessionInit(context.Background(), 3600, fmt.Sprintf(
+		`{"save_path":%q,"idle_timeout":"30s","idle_check_frequency":"30s","partial_write":true}`, redisAddr))
+	if err != nil {
+		t.Fatal("could not init provider:", err)
+	}
+	ctx := context.Background()
+	sid := "partial_write_sid"
+	defer rp.SessionDestroy(ctx, sid)
+
+	// the session stored as a string is converted to a hash
+	b, _ := session.EncodeGob(map[interface{}]interface{}{"username": "astaxie", "age": 18})
+	rp.poollist.Set(ctx, sid, string(b), time.Minute)
+	sess, err := rp.SessionRead(ctx, sid)
+	assert.Nil(t, err)
+	assert.Equal(t, "astaxie", sess.Get(ctx, "username"))
+	sess.SessionRelease(ctx, nil)
+	assert.Equal(t, "hash", rp.poollist.Type(ctx, sid).Val())
+
+	sess, _ = rp.SessionRead(ctx, sid)
+	_ = sess.Set(ctx, "username", "beego")
+	_ = sess.Delete(ctx, "age")
+	sess.SessionRelease(ctx, nil)
+	fields := rp.poollist.HKeys(ctx, sid).Val()
+	assert.ElementsMatch(t, []string{field("username")}, fields)
+
+	sess, _ = rp.SessionRead(ctx, sid)
+	assert.Equal(t, "beego", sess.Get(ctx, "username"))
+	assert.Nil(t, sess.Get(ctx, "age"))
+}
+
+func TestDecodeFields(t *testing.T) {
+	name, _ := session.EncodeGob(map[interface{}]interface{}{"username": "astaxie"})
+	id, _ := session.EncodeGob(map[interface{}]interface{}{1: int64(2)})
+	kv, err := decodeFields(session.Codec{}, map[string]string{
+		field("username"): string(name),
+		field(1):          string(id),
+		placeholderField:  "",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[interface{}]interface{}{"username": "astaxie", 1: int64(2)}, kv)
+	assert.Equal(t, "string:username", field("username"))
+}
+
+func TestInvalidationBus(t *testing.T) {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "127.0.0.1:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := NewInvalidationBus(client, "")
+	received := make(chan session.Invalidation, 1)
+	err := bus.Subscribe(ctx, func(ctx context.Context, inv session.Invalidation) {
+		received <- inv
+	})
+	assert.Nil(t, err)
+
+	inv := session.Invalidation{SessionID: "sid", Reason: session.ReasonLogout, Origin: "instance"}
+	assert.Nil(t, bus.Publish(ctx, inv))
+	select {
+	case got := <-received:
+		assert.Equal(t, inv, got)
+	case <-time.After(time.Second):
+		t.Fatal("the invalidation isn't received")
+	}
+}
@@ -30,12 +30,26 @@
 //		globalSessions, _ = session.NewManager("redis_cluster", ``{"cookieName":"gosessionid","gclifetime":3600,"ProviderConfig":"127.0.0.1:7070;127.0.0.1:7071"}``)
 //		go globalSessions.GC()
 //	}
+//
+// The json config supports the cluster options, for example:
+//
+//	{"save_path":"127.0.0.1:7070;127.0.0.1:7071","username":"app","password":"pwd",
+//	 "key_prefix":"sess:","hash_tag":true,"read_preference":"replica","tls":true,"tls_ca_file":"/etc/redis/ca.pem"}
+//
+// The session keys are key_prefix+sid, so the keys are the raw sids by default as before.
+// If hash_tag is true, they're hash tagged as key_prefix{sid}, so all the keys of one session are in the same slot.
+// MOVED and ASK redirections are followed by the cluster client, up to max_redirects times.
 package redis_cluster
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -55,9 +69,14 @@ var MaxPoolSize = 1000
 type SessionStore struct {
 	p           *rediss.ClusterClient
 	sid         string
+	key         string
 	lock        sync.RWMutex
 	values      map[interface{}]interface{}
 	maxlifetime int64
+	refresh     session.RefreshPolicy
+	codec       session.Codec
+	// modified reports whether the values are changed or the session is new
+	modified bool
 }
 
 // Set value in redis_cluster session
@@ -65,6 +84,7 @@ func (rs *SessionStore) Set(ctx context.Context, key, value interface{}) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	rs.values[key] = value
+	rs.modified = true
 	return nil
 }
 
@@ -83,6 +103,7 @@ func (rs *SessionStore) Delete(ctx context.Context, key interface{}) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	delete(rs.values, key)
+	rs.modified = true
 	return nil
 }
 
@@ -91,6 +112,7 @@ func (rs *SessionStore) Flush(context.Context) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	rs.values = make(map[interface{}]interface{})
+	rs.modified = true
 	return nil
 }
 
@@ -99,17 +121,22 @@ func (rs *SessionStore) SessionID(context.Context) string {
 	return rs.sid
 }
 
-// SessionRelease save session values to redis_cluster
+// SessionRelease save session values to redis_cluster,
+// the session which isn't modified is saved according to the refresh policy
 func (rs *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
-	rs.lock.RLock()
+	rs.lock.Lock()
+	if !rs.refresh.NeedRefresh(rs.values, rs.modified, time.Now()) {
+		rs.lock.Unlock()
+		return
+	}
 	values := rs.values
-	rs.lock.RUnlock()
-	b, err := session.EncodeGob(values)
+	rs.lock.Unlock()
+	b, err := rs.codec.Encode(values)
 	if err != nil {
 		return
 	}
 	c := rs.p
-	c.Set(ctx, rs.sid, string(b), time.Duration(rs.maxlifetime)*time.Second)
+	c.Set(ctx, rs.key, string(b), time.Duration(rs.maxlifetime)*time.Second)
 }
 
 // Provider redis_cluster session provider
@@ -126,7 +153,43 @@ type Provider struct {
 	idleCheckFrequency    time.Duration
 	IdleCheckFrequencyStr string `json:"idle_check_frequency"`
 	MaxRetries            int    `json:"max_retries"`
-	poollist              *rediss.ClusterClient
+	// MaxRedirects is the max number of MOVED and ASK redirections, 0 means 3 and -1 means no redirection
+	MaxRedirects int    `json:"max_redirects"`
+	Username     string `json:"username"`
+	// KeyPrefix is prepended to the sid
+	KeyPrefix string `json:"key_prefix"`
+	// HashTag wraps the sid in the hash tag, like prefix{sid}
+	HashTag bool `json:"hash_tag"`
+	// ReadPreference is one of master(default), replica, latency and random.
+	// The commands which only read are sent to the replicas unless it's master,
+	// so the session written just now may not be read from the replica
+	ReadPreference string `json:"read_preference"`
+
+	TLS           bool   `json:"tls"`
+	TLSServerName string `json:"tls_server_name"`
+	TLSSkipVerify bool   `json:"tls_skip_verify"`
+	TLSCAFile     string `json:"tls_ca_file"`
+	TLSCertFile   string `json:"tls_cert_file"`
+	TLSKeyFile    string `json:"tls_key_file"`
+
+	poollist *rediss.ClusterClient
+	refresh  session.RefreshPolicy
+	codec    session.Codec
+}
+
+// SetRefreshPolicy set how often the TTL of the session is refreshed, it's called by the manager
+func (rp *Provider) SetRefreshPolicy(policy session.RefreshPolicy) {
+	rp.refresh = policy
+}
+
+// SetCipher set the cipher which encrypts the sessions, it's called by the manager
+func (rp *Provider) SetCipher(c session.Cipher) {
+	rp.codec.Cipher = c
+}
+
+// SetSerializer set the serializer of the sessions, it's called by the manager
+func (rp *Provider) SetSerializer(s session.Serializer) {
+	rp.codec.Serializer = s
 }
 
 // SessionInit init redis_cluster session
@@ -146,25 +209,89 @@ func (rp *Provider) SessionInit(ctx context.Context, maxlifetime int64, cfgStr s
 			return err
 		}
 
-		rp.idleCheckFrequency, err = time.ParseDuration(rp.IdleCheckFrequencyStr)
-		if err != nil {
-			return err
+		if rp.IdleCheckFrequencyStr != "" {
+			rp.idleCheckFrequency, err = time.ParseDuration(rp.IdleCheckFrequencyStr)
+			if err != nil {
+				return err
+			}
 		}
 
 	} else {
 		rp.initOldStyle(cfgStr)
 	}
 
-	rp.poollist = rediss.NewClusterClient(&rediss.ClusterOptions{
-		Addrs:              strings.Split(rp.SavePath, ";"),
-		Password:           rp.Password,
-		PoolSize:           rp.Poolsize,
-		ConnMaxIdleTime:    rp.idleTimeout,
-		MaxRetries:         rp.MaxRetries,
-	})
+	opts := &rediss.ClusterOptions{
+		Addrs:           strings.Split(rp.SavePath, ";"),
+		Username:        rp.Username,
+		Password:        rp.Password,
+		PoolSize:        rp.Poolsize,
+		ConnMaxIdleTime: rp.idleTimeout,
+		MaxRetries:      rp.MaxRetries,
+		MaxRedirects:    rp.MaxRedirects,
+	}
+	if err := rp.setReadPreference(opts); err != nil {
+		return err
+	}
+	if rp.TLS {
+		tlsCfg, err := rp.tlsConfig()
+		if err != nil {
+			return err
+		}
+		opts.TLSConfig = tlsCfg
+	}
+	rp.poollist = rediss.NewClusterClient(opts)
 	return rp.poollist.Ping(ctx).Err()
 }
 
+func (rp *Provider) setReadPreference(opts *rediss.ClusterOptions) error {
+	switch rp.ReadPreference {
+	case "", "master":
+	case "replica":
+		opts.ReadOnly = true
+	case "latency":
+		opts.RouteByLatency = true
+	case "random":
+		opts.RouteRandomly = true
+	default:
+		return fmt.Errorf("redis_cluster: unknown read_preference %q", rp.ReadPreference)
+	}
+	return nil
+}
+
+func (rp *Provider) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         rp.TLSServerName,
+		InsecureSkipVerify: rp.TLSSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if rp.TLSCAFile != "" {
+		pem, err := os.ReadFile(rp.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("redis_cluster: no certificate found in " + rp.TLSCAFile)
+		}
+	}
+	if rp.TLSCertFile != "" || rp.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(rp.TLSCertFile, rp.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// key returns the key of sid, it's sid itself if neither KeyPrefix nor HashTag is set
+func (rp *Provider) key(sid string) string {
+	if rp.HashTag {
+		return rp.KeyPrefix + "{" + sid + "}"
+	}
+	return rp.KeyPrefix + sid
+}
+
 // for v1.x
 func (rp *Provider) initOldStyle(savePath string) {
 	configs := strings.Split(savePath, ",")
@@ -217,26 +344,27 @@ func (rp *Provider) initOldStyle(savePath string) {
 // SessionRead read redis_cluster session by sid
 func (rp *Provider) SessionRead(ctx context.Context, sid string) (session.Store, error) {
 	var kv map[interface{}]interface{}
-	kvs, err := rp.poollist.Get(ctx, sid).Result()
+	kvs, err := rp.poollist.Get(ctx, rp.key(sid)).Result()
 	if err != nil && err != rediss.Nil {
 		return nil, err
 	}
 	if len(kvs) == 0 {
 		kv = make(map[interface{}]interface{})
 	} else {
-		if kv, err = session.DecodeGob([]byte(kvs)); err != nil {
+		if kv, err = rp.codec.Decode([]byte(kvs)); err != nil {
 			return nil, err
 		}
 	}
 
-	rs := &SessionStore{p: rp.poollist, sid: sid, values: kv, maxlifetime: rp.maxlifetime}
+	rs := &SessionStore{p: rp.poollist, sid: sid, key: rp.key(sid), values: kv, maxlifetime: rp.maxlifetime,
+		refresh: rp.refresh, codec: rp.codec, modified: len(kvs) == 0}
 	return rs, nil
 }
 
 // SessionExist check redis_cluster session exist by sid
 func (rp *Provider) SessionExist(ctx context.Context, sid string) (bool, error) {
 	c := rp.poollist
-	if existed, err := c.Exists(ctx, sid).Result(); err != nil || existed == 0 {
+	if existed, err := c.Exists(ctx, rp.key(sid)).Result(); err != nil || existed == 0 {
 		return false, err
 	}
 	return true, nil
@@ -246,14 +374,18 @@ func (rp *Provider) SessionExist(ctx context.Context, sid string) (bool, error)
 func (rp *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (session.Store, error) {
 	c := rp.poollist
 
-	if existed, err := c.Exists(ctx, oldsid).Result(); err != nil || existed == 0 {
-		// oldsid doesn't exists, set the new sid directly
-		// ignore error here, since if it return error
-		// the existed value will be 0
-		c.Set(ctx, sid, "", time.Duration(rp.maxlifetime)*time.Second)
-	} else {
-		c.Rename(ctx, oldsid, sid)
-		c.Expire(ctx, sid, time.Duration(rp.maxlifetime)*time.Second)
+	// RENAME fails with CROSSSLOT because the sids are hashed to different slots,
+	// so copy the value to the new key and delete the old one
+	val, err := c.Get(ctx, rp.key(oldsid)).Result()
+	if err != nil && err != rediss.Nil {
+		return nil, err
+	}
+	// the new sid is set to empty if oldsid doesn't exist
+	if err = c.Set(ctx, rp.key(sid), val, time.Duration(rp.maxlifetime)*time.Second).Err(); err != nil {
+		return nil, err
+	}
+	if val != "" {
+		c.Del(ctx, rp.key(oldsid))
 	}
 	return rp.SessionRead(ctx, sid)
 }
@@ -261,7 +393,7 @@ func (rp *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (
 // SessionDestroy delete redis session by id
 func (rp *Provider) SessionDestroy(ctx context.Context, sid string) error {
 	c := rp.poollist
-	c.Del(ctx, sid)
+	c.Del(ctx, rp.key(sid))
 	return nil
 }
 
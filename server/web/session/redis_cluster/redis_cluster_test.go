@@ -16,10 +16,13 @@ package redis_cluster
 
 import (
 	"context"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/session"
 )
 
 func TestProvider_SessionInit(t *testing.T) {
@@ -31,4 +34,55 @@ func TestProvider_SessionInit(t *testing.T) {
 	assert.Equal(t, "my save path", cp.SavePath)
 	assert.Equal(t, 3*time.Second, cp.idleTimeout)
 	assert.Equal(t, int64(12), cp.maxlifetime)
+	// the raw sid is kept as before
+	assert.Equal(t, "abc", cp.key("abc"))
+}
+
+func TestProvider_SessionInitClusterOptions(t *testing.T) {
+	savePath := `
+{ "save_path": "my save path", "idle_timeout": "3s", "username": "app", "password": "pwd",
+  "key_prefix": "sess:", "read_preference": "latency", "max_redirects": 5 }
+`
+	cp := &Provider{}
+	cp.SessionInit(context.Background(), 12, savePath)
+	assert.Equal(t, "app", cp.Username)
+	assert.Equal(t, 5, cp.MaxRedirects)
+	assert.Equal(t, "sess:abc", cp.key("abc"))
+	cp.HashTag = true
+	assert.Equal(t, "sess:{abc}", cp.key("abc"))
+
+	opts := cp.poollist.Options()
+	assert.Equal(t, "app", opts.Username)
+	assert.True(t, opts.RouteByLatency)
+	assert.True(t, opts.ReadOnly)
+	assert.Nil(t, opts.TLSConfig)
+}
+
+func TestProvider_SessionInitInvalidReadPreference(t *testing.T) {
+	cp := &Provider{}
+	err := cp.SessionInit(context.Background(), 12, `{"save_path": "my save path", "idle_timeout": "3s", "read_preference": "slave"}`)
+	assert.EqualError(t, err, `redis_cluster: unknown read_preference "slave"`)
+}
+
+func TestProvider_TLSConfig(t *testing.T) {
+	cp := &Provider{TLS: true, TLSServerName: "redis.local", TLSSkipVerify: true}
+	cfg, err := cp.tlsConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, "redis.local", cfg.ServerName)
+	assert.True(t, cfg.InsecureSkipVerify)
+
+	cp.TLSCAFile = "not-exist-ca.pem"
+	_, err = cp.tlsConfig()
+	assert.NotNil(t, err)
+}
+
+func TestSessionStore_SessionReleaseRefreshNever(t *testing.T) {
+	// the store without the client panics if it's written
+	rs := &SessionStore{values: map[interface{}]interface{}{"k": "v"},
+		refresh: session.RefreshPolicy{Mode: session.RefreshNever}}
+	assert.NotPanics(t, func() {
+		rs.SessionRelease(context.Background(), httptest.NewRecorder())
+	})
+	assert.NoError(t, rs.Set(context.Background(), "k", "v2"))
+	assert.True(t, rs.modified)
 }
@@ -31,12 +31,18 @@
 //		go globalSessions.GC()
 //	}
 //
+// The master is discovered from the sentinels, and the client reconnects to the new master after failover.
+// The reads can be sent to the replicas by read_preference, for example:
+//
+//	{"save_path":"127.0.0.1:26379;127.0.0.2:26379","master_name":"mymaster","read_preference":"replica"}
+//
 // more detail about params: please check the notes on the function SessionInit in this package
 package redis_sentinel
 
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -55,11 +61,15 @@ var DefaultPoolSize = 100
 
 // SessionStore redis_sentinel session store
 type SessionStore struct {
-	p           *redis.Client
+	p           redis.UniversalClient
 	sid         string
 	lock        sync.RWMutex
 	values      map[interface{}]interface{}
 	maxlifetime int64
+	refresh     session.RefreshPolicy
+	codec       session.Codec
+	// modified reports whether the values are changed or the session is new
+	modified bool
 }
 
 // Set value in redis_sentinel session
@@ -67,6 +77,7 @@ func (rs *SessionStore) Set(ctx context.Context, key, value interface{}) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	rs.values[key] = value
+	rs.modified = true
 	return nil
 }
 
@@ -85,6 +96,7 @@ func (rs *SessionStore) Delete(ctx context.Context, key interface{}) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	delete(rs.values, key)
+	rs.modified = true
 	return nil
 }
 
@@ -93,6 +105,7 @@ func (rs *SessionStore) Flush(context.Context) error {
 	rs.lock.Lock()
 	defer rs.lock.Unlock()
 	rs.values = make(map[interface{}]interface{})
+	rs.modified = true
 	return nil
 }
 
@@ -101,12 +114,17 @@ func (rs *SessionStore) SessionID(context.Context) string {
 	return rs.sid
 }
 
-// SessionRelease save session values to redis_sentinel
+// SessionRelease save session values to redis_sentinel,
+// the session which isn't modified is saved according to the refresh policy
 func (rs *SessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
-	rs.lock.RLock()
+	rs.lock.Lock()
+	if !rs.refresh.NeedRefresh(rs.values, rs.modified, time.Now()) {
+		rs.lock.Unlock()
+		return
+	}
 	values := rs.values
-	rs.lock.RUnlock()
-	b, err := session.EncodeGob(values)
+	rs.lock.Unlock()
+	b, err := rs.codec.Encode(values)
 	if err != nil {
 		return
 	}
@@ -128,8 +146,36 @@ type Provider struct {
 	idleCheckFrequency    time.Duration
 	IdleCheckFrequencyStr string `json:"idle_check_frequency"`
 	MaxRetries            int    `json:"max_retries"`
-	poollist              *redis.Client
+	poollist              redis.UniversalClient
 	MasterName            string `json:"master_name"`
+
+	Username         string `json:"username"`
+	SentinelUsername string `json:"sentinel_username"`
+	SentinelPassword string `json:"sentinel_password"`
+	// ReadPreference is one of master(default), replica, latency and random.
+	// replica reads the sessions from a random replica, latency and random read them from
+	// the master or replicas, the writes are always sent to the master.
+	// The session written just now may not be read from the replica because of replication lag
+	ReadPreference string `json:"read_preference"`
+	// reader is used to read the sessions, it's poollist if ReadPreference is master
+	reader  redis.UniversalClient
+	refresh session.RefreshPolicy
+	codec   session.Codec
+}
+
+// SetRefreshPolicy set how often the TTL of the session is refreshed, it's called by the manager
+func (rp *Provider) SetRefreshPolicy(policy session.RefreshPolicy) {
+	rp.refresh = policy
+}
+
+// SetCipher set the cipher which encrypts the sessions, it's called by the manager
+func (rp *Provider) SetCipher(c session.Cipher) {
+	rp.codec.Cipher = c
+}
+
+// SetSerializer set the serializer of the sessions, it's called by the manager
+func (rp *Provider) SetSerializer(s session.Serializer) {
+	rp.codec.Serializer = s
 }
 
 // SessionInit init redis_sentinel session
@@ -149,24 +195,48 @@ func (rp *Provider) SessionInit(ctx context.Context, maxlifetime int64, cfgStr s
 			return err
 		}
 
-		rp.idleCheckFrequency, err = time.ParseDuration(rp.IdleCheckFrequencyStr)
-		if err != nil {
-			return err
+		if rp.IdleCheckFrequencyStr != "" {
+			rp.idleCheckFrequency, err = time.ParseDuration(rp.IdleCheckFrequencyStr)
+			if err != nil {
+				return err
+			}
 		}
 
 	} else {
 		rp.initOldStyle(cfgStr)
 	}
 
-	rp.poollist = redis.NewFailoverClient(&redis.FailoverOptions{
-		SentinelAddrs:      strings.Split(rp.SavePath, ";"),
-		Password:           rp.Password,
-		PoolSize:           rp.Poolsize,
-		DB:                 rp.DbNum,
-		MasterName:         rp.MasterName,
-		ConnMaxIdleTime:    rp.idleTimeout,
-		MaxRetries:         rp.MaxRetries,
-	})
+	opts := &redis.FailoverOptions{
+		SentinelAddrs:    strings.Split(rp.SavePath, ";"),
+		SentinelUsername: rp.SentinelUsername,
+		SentinelPassword: rp.SentinelPassword,
+		Username:         rp.Username,
+		Password:         rp.Password,
+		PoolSize:         rp.Poolsize,
+		DB:               rp.DbNum,
+		MasterName:       rp.MasterName,
+		ConnMaxIdleTime:  rp.idleTimeout,
+		MaxRetries:       rp.MaxRetries,
+	}
+	switch rp.ReadPreference {
+	case "", "master":
+		rp.poollist = redis.NewFailoverClient(opts)
+		rp.reader = rp.poollist
+	case "replica":
+		rp.poollist = redis.NewFailoverClient(opts)
+		replicaOpts := *opts
+		replicaOpts.ReplicaOnly = true
+		rp.reader = redis.NewFailoverClient(&replicaOpts)
+	case "latency", "random":
+		// the read-only commands are routed to master or replicas by the cluster client,
+		// and the others are sent to the master
+		opts.RouteByLatency = rp.ReadPreference == "latency"
+		opts.RouteRandomly = rp.ReadPreference == "random"
+		rp.poollist = redis.NewFailoverClusterClient(opts)
+		rp.reader = rp.poollist
+	default:
+		return fmt.Errorf("redis_sentinel: unknown read_preference %q", rp.ReadPreference)
+	}
 
 	return rp.poollist.Ping(ctx).Err()
 }
@@ -210,19 +280,19 @@ func (rp *Provider) initOldStyle(savePath string) {
 		rp.MasterName = "mymaster"
 	}
 	if len(configs) > 5 {
-		timeout, err := strconv.Atoi(configs[4])
+		timeout, err := strconv.Atoi(configs[5])
 		if err == nil && timeout > 0 {
 			rp.idleTimeout = time.Duration(timeout) * time.Second
 		}
 	}
 	if len(configs) > 6 {
-		checkFrequency, err := strconv.Atoi(configs[5])
+		checkFrequency, err := strconv.Atoi(configs[6])
 		if err == nil && checkFrequency > 0 {
 			rp.idleCheckFrequency = time.Duration(checkFrequency) * time.Second
 		}
 	}
 	if len(configs) > 7 {
-		retries, err := strconv.Atoi(configs[6])
+		retries, err := strconv.Atoi(configs[7])
 		if err == nil && retries > 0 {
 			rp.MaxRetries = retries
 		}
@@ -231,26 +301,31 @@ func (rp *Provider) initOldStyle(savePath string) {
 
 // SessionRead read redis_sentinel session by sid
 func (rp *Provider) SessionRead(ctx context.Context, sid string) (session.Store, error) {
+	return rp.read(ctx, rp.reader, sid)
+}
+
+func (rp *Provider) read(ctx context.Context, c redis.UniversalClient, sid string) (session.Store, error) {
 	var kv map[interface{}]interface{}
-	kvs, err := rp.poollist.Get(ctx, sid).Result()
+	kvs, err := c.Get(ctx, sid).Result()
 	if err != nil && err != redis.Nil {
 		return nil, err
 	}
 	if len(kvs) == 0 {
 		kv = make(map[interface{}]interface{})
 	} else {
-		if kv, err = session.DecodeGob([]byte(kvs)); err != nil {
+		if kv, err = rp.codec.Decode([]byte(kvs)); err != nil {
 			return nil, err
 		}
 	}
 
-	rs := &SessionStore{p: rp.poollist, sid: sid, values: kv, maxlifetime: rp.maxlifetime}
+	rs := &SessionStore{p: rp.poollist, sid: sid, values: kv, maxlifetime: rp.maxlifetime,
+		refresh: rp.refresh, codec: rp.codec, modified: len(kvs) == 0}
 	return rs, nil
 }
 
 // SessionExist check redis_sentinel session exist by sid
 func (rp *Provider) SessionExist(ctx context.Context, sid string) (bool, error) {
-	c := rp.poollist
+	c := rp.reader
 	if existed, err := c.Exists(ctx, sid).Result(); err != nil || existed == 0 {
 		return false, err
 	}
@@ -270,7 +345,8 @@ func (rp *Provider) SessionRegenerate(ctx context.Context, oldsid, sid string) (
 		c.Rename(ctx, oldsid, sid)
 		c.Expire(ctx, sid, time.Duration(rp.maxlifetime)*time.Second)
 	}
-	return rp.SessionRead(ctx, sid)
+	// the replicas may not have the new sid yet
+	return rp.read(ctx, c, sid)
 }
 
 // SessionDestroy delete redis session by id
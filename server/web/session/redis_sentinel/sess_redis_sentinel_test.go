@@ -2,11 +2,13 @@ package redis_sentinel
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/beego/beego/v2/server/web/session"
@@ -104,3 +106,34 @@ func TestProvider_SessionInit(t *testing.T) {
 	assert.Equal(t, 3*time.Second, cp.idleTimeout)
 	assert.Equal(t, int64(12), cp.maxlifetime)
 }
+
+func TestProvider_SessionInitReadPreference(t *testing.T) {
+	cfg := `{"save_path": "127.0.0.1:26379", "idle_timeout": "3s", "master_name": "mymaster", "read_preference": "%s"}`
+
+	cp := &Provider{}
+	cp.SessionInit(context.Background(), 12, fmt.Sprintf(cfg, ""))
+	assert.Same(t, cp.poollist, cp.reader)
+
+	cp = &Provider{}
+	cp.SessionInit(context.Background(), 12, fmt.Sprintf(cfg, "replica"))
+	assert.NotSame(t, cp.poollist, cp.reader)
+	assert.IsType(t, &redis.Client{}, cp.reader)
+
+	cp = &Provider{}
+	cp.SessionInit(context.Background(), 12, fmt.Sprintf(cfg, "latency"))
+	assert.IsType(t, &redis.ClusterClient{}, cp.poollist)
+	assert.True(t, cp.poollist.(*redis.ClusterClient).Options().RouteByLatency)
+
+	cp = &Provider{}
+	err := cp.SessionInit(context.Background(), 12, fmt.Sprintf(cfg, "slave"))
+	assert.EqualError(t, err, `redis_sentinel: unknown read_preference "slave"`)
+}
+
+func TestProvider_InitOldStyle(t *testing.T) {
+	cp := &Provider{}
+	cp.initOldStyle("127.0.0.1:26379,10,pwd,1,mymaster,3,4,5")
+	assert.Equal(t, "mymaster", cp.MasterName)
+	assert.Equal(t, 3*time.Second, cp.idleTimeout)
+	assert.Equal(t, 4*time.Second, cp.idleCheckFrequency)
+	assert.Equal(t, 5, cp.MaxRetries)
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"fmt"
+	"time"
+)
+
+// RefreshMode is how often the TTL of the session is refreshed when it's accessed but not modified
+type RefreshMode string
+
+const (
+	// RefreshAlways refreshes the TTL in every request, it's the default mode
+	RefreshAlways RefreshMode = "always"
+	// RefreshInterval refreshes the TTL at most once in every interval
+	RefreshInterval RefreshMode = "interval"
+	// RefreshNever only writes the session when it's modified
+	RefreshNever RefreshMode = "never"
+)
+
+// RefreshedAtKey is the key of the last refresh time stored in the session in RefreshInterval mode,
+// the providers which only write the changed values should write it if NeedRefresh returns true
+const RefreshedAtKey = "__beego_session_refreshed_at"
+
+// RefreshPolicy controls whether the session store writes the session back in SessionRelease.
+// The modified sessions are always written, the policy only applies to the sessions which are only read
+type RefreshPolicy struct {
+	Mode RefreshMode
+	// Interval is the min interval between two refreshes in RefreshInterval mode
+	Interval time.Duration
+}
+
+// RefreshPolicySetter is implemented by the providers which support RefreshPolicy,
+// the manager passes its policy to the provider after SessionInit
+type RefreshPolicySetter interface {
+	SetRefreshPolicy(policy RefreshPolicy)
+}
+
+// NeedRefresh reports whether the session whose values are values should be written back.
+// modified reports whether the values are changed in this request or the session is new.
+// In RefreshInterval mode, the refresh time is recorded in values if it returns true,
+// so it should be called with the lock of the store held.
+func (p RefreshPolicy) NeedRefresh(values map[interface{}]interface{}, modified bool, now time.Time) bool {
+	switch p.Mode {
+	case RefreshNever:
+		return modified
+	case RefreshInterval:
+		if last, ok := unixTime(values[RefreshedAtKey]); ok && !modified && now.Sub(last) < p.Interval {
+			return false
+		}
+		values[RefreshedAtKey] = now.Unix()
+		return true
+	default:
+		return true
+	}
+}
+
+// due reports whether the access at last should be refreshed at now
+func (p RefreshPolicy) due(last, now time.Time) bool {
+	switch p.Mode {
+	case RefreshNever:
+		return false
+	case RefreshInterval:
+		return now.Sub(last) >= p.Interval
+	default:
+		return true
+	}
+}
+
+func newRefreshPolicy(cf *ManagerConfig) (RefreshPolicy, error) {
+	p := RefreshPolicy{Mode: cf.RefreshMode, Interval: time.Duration(cf.RefreshInterval) * time.Second}
+	switch p.Mode {
+	case "", RefreshAlways:
+		p.Mode = RefreshAlways
+	case RefreshInterval:
+		if cf.RefreshInterval <= 0 || cf.RefreshInterval >= cf.Maxlifetime {
+			return p, fmt.Errorf("session: refresh interval %d should be in (0, %d)", cf.RefreshInterval, cf.Maxlifetime)
+		}
+	case RefreshNever:
+		if cf.IdleTimeout > 0 {
+			return p, fmt.Errorf("session: idle timeout requires refreshing the session, but the refresh mode is %q", p.Mode)
+		}
+	default:
+		return p, fmt.Errorf("session: unknown refresh mode %q", p.Mode)
+	}
+	return p, nil
+}
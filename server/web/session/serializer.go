@@ -0,0 +1,162 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// the names of the builtin serializers
+const (
+	SerializerGob     = "gob"
+	SerializerJSON    = "json"
+	SerializerMsgPack = "msgpack"
+)
+
+// Serializer encodes the values of the session before the provider stores them
+type Serializer interface {
+	Marshal(values map[interface{}]interface{}) ([]byte, error)
+	Unmarshal(data []byte) (map[interface{}]interface{}, error)
+}
+
+// SerializerSetter is implemented by the providers which support the pluggable serializers,
+// the manager passes its serializer to the provider after SessionInit
+type SerializerSetter interface {
+	SetSerializer(s Serializer)
+}
+
+var (
+	serializerLock sync.RWMutex
+	serializers    = map[string]Serializer{
+		SerializerGob:     gobSerializer{},
+		SerializerJSON:    jsonSerializer{},
+		SerializerMsgPack: msgpackSerializer{},
+	}
+)
+
+// RegisterSerializer registers the serializer by name, the registered one is replaced
+func RegisterSerializer(name string, s Serializer) {
+	serializerLock.Lock()
+	defer serializerLock.Unlock()
+	serializers[name] = s
+}
+
+// GetSerializer returns the serializer registered by name
+func GetSerializer(name string) (Serializer, error) {
+	serializerLock.RLock()
+	defer serializerLock.RUnlock()
+	s, ok := serializers[name]
+	if !ok {
+		return nil, fmt.Errorf("session: unknown serializer %q", name)
+	}
+	return s, nil
+}
+
+// gobSerializer is the default serializer, the custom types must be registered by gob.Register
+type gobSerializer struct{}
+
+func (gobSerializer) Marshal(values map[interface{}]interface{}) ([]byte, error) {
+	return EncodeGob(values)
+}
+
+func (gobSerializer) Unmarshal(data []byte) (map[interface{}]interface{}, error) {
+	return DecodeGob(data)
+}
+
+// jsonSerializer can be read by the other languages,
+// the keys must be strings and the numbers are decoded as float64
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(values map[interface{}]interface{}) ([]byte, error) {
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("session: the key of json serializer must be string, but got %T", k)
+		}
+		m[key] = v
+	}
+	return json.Marshal(m)
+}
+
+func (jsonSerializer) Unmarshal(data []byte) (map[interface{}]interface{}, error) {
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	values := make(map[interface{}]interface{}, len(m))
+	for k, v := range m {
+		values[k] = v
+	}
+	return values, nil
+}
+
+// msgpackSerializer is more compact than JSON and keeps the keys which aren't strings,
+// the integers are decoded as int64 or uint64 and the nested maps are map[string]interface{}
+type msgpackSerializer struct{}
+
+func (msgpackSerializer) Marshal(values map[interface{}]interface{}) ([]byte, error) {
+	return msgpack.Marshal(values)
+}
+
+func (msgpackSerializer) Unmarshal(data []byte) (map[interface{}]interface{}, error) {
+	values := make(map[interface{}]interface{})
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.UseLooseInterfaceDecoding(true)
+	if err := dec.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Codec serializes the values of the session by Serializer and encrypts them by Cipher.
+// Gob is used if Serializer is nil, and the values aren't encrypted if Cipher is nil
+type Codec struct {
+	Serializer Serializer
+	Cipher     Cipher
+}
+
+// Encode serializes and encrypts values
+func (c Codec) Encode(values map[interface{}]interface{}) ([]byte, error) {
+	s := c.Serializer
+	if s == nil {
+		s = gobSerializer{}
+	}
+	b, err := s.Marshal(values)
+	if err != nil || c.Cipher == nil {
+		return b, err
+	}
+	return c.Cipher.Encrypt(b)
+}
+
+// Decode decrypts and deserializes data
+func (c Codec) Decode(data []byte) (map[interface{}]interface{}, error) {
+	if c.Cipher != nil {
+		var err error
+		if data, err = c.Cipher.Decrypt(data); err != nil {
+			return nil, err
+		}
+	}
+	s := c.Serializer
+	if s == nil {
+		s = gobSerializer{}
+	}
+	return s.Unmarshal(data)
+}
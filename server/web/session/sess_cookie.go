@@ -73,6 +73,17 @@ func (st *CookieSessionStore) SessionID(context.Context) string {
 	return st.sid
 }
 
+// RegenerateID changes the id in place, the values are kept in the cookie.
+// The old cookie is NOT invalidated, it's still accepted until it expires by maxage,
+// because the cookie provider keeps no state on the server. Don't rely on RegenerateID
+// to prevent session fixation with the cookie provider, use a server side provider instead.
+func (st *CookieSessionStore) RegenerateID(ctx context.Context, sid string) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.sid = sid
+	return nil
+}
+
 // SessionRelease Write cookie session to http response cookie
 func (st *CookieSessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
 	st.lock.RLock()
@@ -157,9 +168,12 @@ func (pder *CookieProvider) SessionExist(ctx context.Context, sid string) (bool,
 	return true, nil
 }
 
-// SessionRegenerate Implement method, no used.
+// SessionRegenerate keeps the values of oldsid with the new sid,
+// the old cookie is still valid, see CookieSessionStore.RegenerateID
 func (pder *CookieProvider) SessionRegenerate(ctx context.Context, oldsid, sid string) (Store, error) {
-	return nil, nil
+	store, _ := pder.SessionRead(ctx, oldsid)
+	store.(*CookieSessionStore).sid = sid
+	return store, nil
 }
 
 // SessionDestroy Implement method, no used.
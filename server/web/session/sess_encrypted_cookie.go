@@ -0,0 +1,285 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultEncryptedCookieMaxSize is the default max size of the encrypted cookie,
+// browsers only accept the cookies up to 4096 bytes including the name and the attributes
+const DefaultEncryptedCookieMaxSize = 4000
+
+// encryptedCookieVersion is the first byte of the encrypted cookie
+const encryptedCookieVersion byte = 1
+
+var (
+	// ErrCookieTooLarge is returned when the encrypted session exceeds the max size
+	ErrCookieTooLarge = errors.New("session: the encrypted cookie is too large")
+	errInvalidCookie  = errors.New("session: the encrypted cookie is invalid")
+)
+
+var encryptedCookiePder = &EncryptedCookieProvider{}
+
+// EncryptedCookieSessionStore is the session store of EncryptedCookieProvider
+type EncryptedCookieSessionStore struct {
+	p      *EncryptedCookieProvider
+	sid    string
+	values map[interface{}]interface{}
+	lock   sync.RWMutex
+}
+
+// Set value to encrypted cookie session
+func (st *EncryptedCookieSessionStore) Set(ctx context.Context, key, value interface{}) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.values[key] = value
+	return nil
+}
+
+// Get value from encrypted cookie session
+func (st *EncryptedCookieSessionStore) Get(ctx context.Context, key interface{}) interface{} {
+	st.lock.RLock()
+	defer st.lock.RUnlock()
+	if v, ok := st.values[key]; ok {
+		return v
+	}
+	return nil
+}
+
+// Delete value in encrypted cookie session
+func (st *EncryptedCookieSessionStore) Delete(ctx context.Context, key interface{}) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	delete(st.values, key)
+	return nil
+}
+
+// Flush Clean all values in encrypted cookie session
+func (st *EncryptedCookieSessionStore) Flush(context.Context) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.values = make(map[interface{}]interface{})
+	return nil
+}
+
+// SessionID returns the id of this session, it's kept in the encrypted cookie
+// so it doesn't change until the session is regenerated
+func (st *EncryptedCookieSessionStore) SessionID(context.Context) string {
+	return st.sid
+}
+
+// RegenerateID changes the id in place, the values are kept in the cookie
+func (st *EncryptedCookieSessionStore) RegenerateID(ctx context.Context, sid string) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.sid = sid
+	return nil
+}
+
+// SessionRelease encrypts the session and writes it to the response cookie.
+// The cookie isn't written if it's larger than maxSize, the error is logged by SLogger
+func (st *EncryptedCookieSessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
+	st.lock.RLock()
+	values := st.values
+	st.lock.RUnlock()
+	value, err := st.p.encode(st.sid, values)
+	if err != nil {
+		SLogger.Println(err)
+		return
+	}
+	cfg := st.p.config
+	http.SetCookie(w, &http.Cookie{
+		Name:     cfg.CookieName,
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.Domain,
+		HttpOnly: true,
+		Secure:   cfg.Secure,
+		MaxAge:   cfg.Maxage,
+		SameSite: cfg.SameSite,
+	})
+}
+
+type encryptedCookieConfig struct {
+	// Keys are the secrets, the first one encrypts the cookies and all of them decrypt,
+	// so a new key is added to the front and the old one is removed after maxlifetime
+	Keys       []string      `json:"keys"`
+	CookieName string        `json:"cookieName"`
+	Domain     string        `json:"domain"`
+	Secure     bool          `json:"secure"`
+	Maxage     int           `json:"maxage"`
+	SameSite   http.SameSite `json:"sameSite"`
+	MaxSize    int           `json:"maxSize"`
+}
+
+// EncryptedCookieProvider stores the whole session in the cookie which is encrypted
+// and authenticated by AES-GCM, so there is no server side storage.
+// The session should be small because the cookie is sent in every request.
+// It's better to disable EnableSetCookie of the manager, because the cookie is written by SessionRelease
+type EncryptedCookieProvider struct {
+	maxlifetime int64
+	config      *encryptedCookieConfig
+	aeads       []cipher.AEAD
+}
+
+// SessionInit Init encrypted cookie session provider with max lifetime and config json.
+// json config:
+//
+//	keys - the secrets, the first one is used to encrypt, all of them are used to decrypt
+//	cookieName - cookie name, it should be the same as the cookie name of the manager
+//	domain - cookie domain
+//	secure - cookie secure
+//	maxage - cookie max life time
+//	sameSite - cookie SameSite, see http.SameSite
+//	maxSize - max size of the cookie value, DefaultEncryptedCookieMaxSize if it's 0
+func (pder *EncryptedCookieProvider) SessionInit(ctx context.Context, maxlifetime int64, config string) error {
+	cfg := &encryptedCookieConfig{}
+	if err := json.Unmarshal([]byte(config), cfg); err != nil {
+		return err
+	}
+	if len(cfg.Keys) == 0 {
+		return errors.New("session: encrypted cookie requires at least one key")
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultEncryptedCookieMaxSize
+	}
+	aeads, err := newAEADs(cfg.Keys)
+	if err != nil {
+		return err
+	}
+	pder.config = cfg
+	pder.aeads = aeads
+	pder.maxlifetime = maxlifetime
+	return nil
+}
+
+// SessionRead decrypts the session from sid which is the cookie value.
+// It returns an empty session with sid if sid can't be decrypted, such as the new sid from the manager
+func (pder *EncryptedCookieProvider) SessionRead(ctx context.Context, sid string) (Store, error) {
+	id, values, err := pder.decode(sid)
+	if err != nil {
+		id, values = sid, make(map[interface{}]interface{})
+	}
+	return &EncryptedCookieSessionStore{p: pder, sid: id, values: values}, nil
+}
+
+// SessionExist reports whether sid is a valid encrypted cookie which doesn't expire
+func (pder *EncryptedCookieProvider) SessionExist(ctx context.Context, sid string) (bool, error) {
+	_, _, err := pder.decode(sid)
+	return err == nil, nil
+}
+
+// SessionRegenerate keeps the values with the new sid
+func (pder *EncryptedCookieProvider) SessionRegenerate(ctx context.Context, oldsid, sid string) (Store, error) {
+	_, values, err := pder.decode(oldsid)
+	if err != nil {
+		values = make(map[interface{}]interface{})
+	}
+	return &EncryptedCookieSessionStore{p: pder, sid: sid, values: values}, nil
+}
+
+// SessionDestroy Implement method, no used.
+// The cookie can't be revoked, it's deleted by the manager
+func (pder *EncryptedCookieProvider) SessionDestroy(ctx context.Context, sid string) error {
+	return nil
+}
+
+// SessionGC Implement method, no used.
+func (pder *EncryptedCookieProvider) SessionGC(context.Context) {
+}
+
+// SessionAll Implement method, return 0.
+func (pder *EncryptedCookieProvider) SessionAll(context.Context) int {
+	return 0
+}
+
+// the plain text is 8 bytes expiration unix time, 2 bytes length of sid, sid and the gob encoded values.
+// The cookie is version|nonce|cipher text in base64, the cookie name is the additional data
+func (pder *EncryptedCookieProvider) encode(sid string, values map[interface{}]interface{}) (string, error) {
+	b, err := EncodeGob(values)
+	if err != nil {
+		return "", err
+	}
+	if len(sid) > 0xffff {
+		return "", errors.New("session: the session id is too long")
+	}
+	plain := make([]byte, 10, 10+len(sid)+len(b))
+	binary.BigEndian.PutUint64(plain, uint64(time.Now().Unix()+pder.maxlifetime))
+	binary.BigEndian.PutUint16(plain[8:], uint16(len(sid)))
+	plain = append(append(plain, sid...), b...)
+
+	aead := pder.aeads[0]
+	out := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(plain)+aead.Overhead())
+	out[0] = encryptedCookieVersion
+	if _, err = rand.Read(out[1:]); err != nil {
+		return "", err
+	}
+	out = aead.Seal(out, out[1:], plain, []byte(pder.config.CookieName))
+	value := base64.RawURLEncoding.EncodeToString(out)
+	if len(value) > pder.config.MaxSize {
+		return "", fmt.Errorf("%w: %d bytes exceeds %d bytes", ErrCookieTooLarge, len(value), pder.config.MaxSize)
+	}
+	return value, nil
+}
+
+func (pder *EncryptedCookieProvider) decode(value string) (string, map[interface{}]interface{}, error) {
+	if len(value) > pder.config.MaxSize {
+		return "", nil, errInvalidCookie
+	}
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 || b[0] != encryptedCookieVersion {
+		return "", nil, errInvalidCookie
+	}
+	var plain []byte
+	for _, aead := range pder.aeads {
+		if len(b) < 1+aead.NonceSize() {
+			break
+		}
+		nonce := b[1 : 1+aead.NonceSize()]
+		if plain, err = aead.Open(nil, nonce, b[1+aead.NonceSize():], []byte(pder.config.CookieName)); err == nil {
+			break
+		}
+	}
+	if plain == nil || len(plain) < 10 {
+		return "", nil, errInvalidCookie
+	}
+	if int64(binary.BigEndian.Uint64(plain)) <= time.Now().Unix() {
+		return "", nil, errInvalidCookie
+	}
+	n := int(binary.BigEndian.Uint16(plain[8:]))
+	if len(plain) < 10+n {
+		return "", nil, errInvalidCookie
+	}
+	values, err := DecodeGob(plain[10+n:])
+	if err != nil {
+		return "", nil, err
+	}
+	return string(plain[10 : 10+n]), values, nil
+}
+
+func init() {
+	Register("encrypted_cookie", encryptedCookiePder)
+}
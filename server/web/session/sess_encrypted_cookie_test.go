@@ -0,0 +1,158 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newEncryptedCookieProvider(t *testing.T, maxlifetime int64, config string) *EncryptedCookieProvider {
+	pder := &EncryptedCookieProvider{}
+	if err := pder.SessionInit(context.Background(), maxlifetime, config); err != nil {
+		t.Fatal("init encrypted cookie provider err", err)
+	}
+	return pder
+}
+
+func TestEncryptedCookie(t *testing.T) {
+	config := `{"cookieName":"gosessionid","gclifetime":3600,"ProviderConfig":"{\"cookieName\":\"gosessionid\",\"keys\":[\"beegocookiekey\"]}"}`
+	conf := new(ManagerConfig)
+	if err := json.Unmarshal([]byte(config), conf); err != nil {
+		t.Fatal("json decode error", err)
+	}
+	globalSessions, err := NewManager("encrypted_cookie", conf)
+	if err != nil {
+		t.Fatal("init encrypted cookie session err", err)
+	}
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	sess, err := globalSessions.SessionStart(w, r)
+	if err != nil {
+		t.Fatal("session start err", err)
+	}
+	sid := sess.SessionID(nil)
+	_ = sess.Set(nil, "username", "astaxie")
+	sess.SessionRelease(nil, w)
+
+	cookie := w.Result().Cookies()[0]
+	if cookie.Name != "gosessionid" || !cookie.HttpOnly {
+		t.Fatal("set cookie error", cookie)
+	}
+	if strings.Contains(cookie.Value, "astaxie") {
+		t.Fatal("the cookie isn't encrypted")
+	}
+
+	r, _ = http.NewRequest("GET", "/", nil)
+	r.AddCookie(cookie)
+	sess, err = globalSessions.SessionStart(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatal("session start err", err)
+	}
+	if sess.SessionID(nil) != sid {
+		t.Fatal("the session id is changed")
+	}
+	if username := sess.Get(nil, "username"); username != "astaxie" {
+		t.Fatal("get username error", username)
+	}
+}
+
+func TestEncryptedCookieKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	old := newEncryptedCookieProvider(t, 3600, `{"cookieName":"sid","keys":["old-key"]}`)
+	value, err := old.encode("id1", map[interface{}]interface{}{"k": "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newEncryptedCookieProvider(t, 3600, `{"cookieName":"sid","keys":["new-key","old-key"]}`)
+	if ok, _ := rotated.SessionExist(ctx, value); !ok {
+		t.Fatal("the cookie encrypted by the old key should be accepted")
+	}
+	sess, _ := rotated.SessionRead(ctx, value)
+	if sess.SessionID(ctx) != "id1" || sess.Get(ctx, "k") != "v" {
+		t.Fatal("decrypt by the old key error")
+	}
+	// it's encrypted by the new key
+	value, _ = rotated.encode("id1", map[interface{}]interface{}{"k": "v"})
+	if ok, _ := old.SessionExist(ctx, value); ok {
+		t.Fatal("the cookie encrypted by the new key shouldn't be accepted by the old key")
+	}
+
+	removed := newEncryptedCookieProvider(t, 3600, `{"cookieName":"sid","keys":["new-key"]}`)
+	if ok, _ := removed.SessionExist(ctx, value); !ok {
+		t.Fatal("the cookie encrypted by the new key should be accepted")
+	}
+}
+
+func TestEncryptedCookieInvalid(t *testing.T) {
+	ctx := context.Background()
+	pder := newEncryptedCookieProvider(t, 3600, `{"cookieName":"sid","keys":["key"]}`)
+	value, _ := pder.encode("id1", map[interface{}]interface{}{"k": "v"})
+
+	// tampered
+	b := []byte(value)
+	if b[len(b)/2] == 'A' {
+		b[len(b)/2] = 'B'
+	} else {
+		b[len(b)/2] = 'A'
+	}
+	if ok, _ := pder.SessionExist(ctx, string(b)); ok {
+		t.Fatal("the tampered cookie should be rejected")
+	}
+
+	// the cookie name is authenticated
+	other := newEncryptedCookieProvider(t, 3600, `{"cookieName":"other","keys":["key"]}`)
+	if ok, _ := other.SessionExist(ctx, value); ok {
+		t.Fatal("the cookie of another name should be rejected")
+	}
+
+	// expired
+	expired := newEncryptedCookieProvider(t, -1, `{"cookieName":"sid","keys":["key"]}`)
+	value, _ = expired.encode("id1", map[interface{}]interface{}{"k": "v"})
+	if ok, _ := pder.SessionExist(ctx, value); ok {
+		t.Fatal("the expired cookie should be rejected")
+	}
+
+	sess, _ := pder.SessionRead(ctx, "new-sid")
+	if sess.SessionID(ctx) != "new-sid" || sess.Get(ctx, "k") != nil {
+		t.Fatal("the invalid cookie should be read as an empty session")
+	}
+}
+
+func TestEncryptedCookieMaxSize(t *testing.T) {
+	pder := newEncryptedCookieProvider(t, 3600, `{"cookieName":"sid","keys":["key"],"maxSize":128}`)
+	_, err := pder.encode("id1", map[interface{}]interface{}{"k": strings.Repeat("v", 128)})
+	if !errors.Is(err, ErrCookieTooLarge) {
+		t.Fatal("the large cookie should be rejected", err)
+	}
+
+	sess, _ := pder.SessionRead(context.Background(), "id1")
+	_ = sess.Set(nil, "k", strings.Repeat("v", 128))
+	w := httptest.NewRecorder()
+	sess.SessionRelease(nil, w)
+	if w.Header().Get("Set-Cookie") != "" {
+		t.Fatal("the large cookie shouldn't be written")
+	}
+
+	if err = (&EncryptedCookieProvider{}).SessionInit(context.Background(), 3600, `{"cookieName":"sid"}`); err == nil {
+		t.Fatal("the key is required")
+	}
+}
@@ -28,8 +28,7 @@ import (
 )
 
 var (
-	filepder      = &FileProvider{}
-	gcmaxlifetime int64
+	filepder = &FileProvider{}
 )
 
 // FileSessionStore File session store
@@ -82,7 +81,7 @@ func (fs *FileSessionStore) SessionID(context.Context) string {
 func (fs *FileSessionStore) SessionRelease(ctx context.Context, w http.ResponseWriter) {
 	filepder.lock.Lock()
 	defer filepder.lock.Unlock()
-	b, err := EncodeGob(fs.values)
+	b, err := filepder.codec.Encode(fs.values)
 	if err != nil {
 		SLogger.Println(err)
 		return
@@ -115,6 +114,7 @@ type FileProvider struct {
 	lock        sync.RWMutex
 	maxlifetime int64
 	savePath    string
+	codec       Codec
 }
 
 // SessionInit Init file session provider.
@@ -125,6 +125,16 @@ func (fp *FileProvider) SessionInit(ctx context.Context, maxlifetime int64, save
 	return nil
 }
 
+// SetCipher set the cipher which encrypts the session files, it's called by the manager
+func (fp *FileProvider) SetCipher(c Cipher) {
+	fp.codec.Cipher = c
+}
+
+// SetSerializer set the serializer of the session files, it's called by the manager
+func (fp *FileProvider) SetSerializer(s Serializer) {
+	fp.codec.Serializer = s
+}
+
 // SessionRead Read file session by sid.
 // if file is not exist, create it.
 // the file path is generated from sid string.
@@ -173,7 +183,7 @@ func (fp *FileProvider) SessionRead(ctx context.Context, sid string) (Store, err
 	if len(b) == 0 {
 		kv = make(map[interface{}]interface{})
 	} else {
-		kv, err = DecodeGob(b)
+		kv, err = fp.codec.Decode(b)
 		if err != nil {
 			return nil, err
 		}
@@ -207,12 +217,45 @@ func (fp *FileProvider) SessionDestroy(ctx context.Context, sid string) error {
 }
 
 // SessionGC Recycle files in save path
-func (fp *FileProvider) SessionGC(context.Context) {
+func (fp *FileProvider) SessionGC(ctx context.Context) {
+	fp.SessionGCExpired(ctx)
+}
+
+// SessionGCExpired recycles files in save path and returns the ids of the expired sessions
+func (fp *FileProvider) SessionGCExpired(ctx context.Context) []string {
+	expired, _ := fp.SessionGCBatch(ctx, 0)
+	return expired
+}
+
+// SessionGCBatch recycles at most limit files in save path, 0 means no limit
+func (fp *FileProvider) SessionGCBatch(ctx context.Context, limit int) ([]string, bool) {
 	filepder.lock.Lock()
 	defer filepder.lock.Unlock()
 
-	gcmaxlifetime = fp.maxlifetime
-	filepath.Walk(fp.savePath, gcpath)
+	var (
+		expired []string
+		more    bool
+	)
+	filepath.Walk(fp.savePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if limit > 0 && len(expired) >= limit || canceled(ctx) {
+			more = true
+			return filepath.SkipAll
+		}
+		if info.IsDir() {
+			return nil
+		}
+		// remove file in save path if expired
+		if (info.ModTime().Unix() + fp.maxlifetime) < time.Now().Unix() {
+			if os.Remove(path) == nil {
+				expired = append(expired, info.Name())
+			}
+		}
+		return nil
+	})
+	return expired, more
 }
 
 // SessionAll Get active file session number.
@@ -265,14 +308,16 @@ func (fp *FileProvider) SessionRegenerate(ctx context.Context, oldsid, sid strin
 		if len(b) == 0 {
 			kv = make(map[interface{}]interface{})
 		} else {
-			kv, err = DecodeGob(b)
+			kv, err = fp.codec.Decode(b)
 			if err != nil {
 				return nil, err
 			}
 		}
 
-		os.WriteFile(newSidFile, b, 0o777)
-		os.Remove(oldSidFile)
+		// the old sid is invalidated at the same time
+		if err = os.Rename(oldSidFile, newSidFile); err != nil {
+			return nil, err
+		}
 		os.Chtimes(newSidFile, time.Now(), time.Now())
 		ss := &FileSessionStore{sid: sid, values: kv}
 		return ss, nil
@@ -288,20 +333,6 @@ func (fp *FileProvider) SessionRegenerate(ctx context.Context, oldsid, sid strin
 	return ss, nil
 }
 
-// remove file in save path if expired
-func gcpath(path string, info os.FileInfo, err error) error {
-	if err != nil {
-		return err
-	}
-	if info.IsDir() {
-		return nil
-	}
-	if (info.ModTime().Unix() + gcmaxlifetime) < time.Now().Unix() {
-		os.Remove(path)
-	}
-	return nil
-}
-
 type activeSession struct {
 	total int
 }
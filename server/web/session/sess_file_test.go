@@ -15,9 +15,11 @@
 package session
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -425,3 +427,55 @@ func TestFileSessionStoreSessionRelease(t *testing.T) {
 		}
 	}
 }
+
+func TestFileProviderEncryption(t *testing.T) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	os.RemoveAll(sessionPath)
+	defer os.RemoveAll(sessionPath)
+	defer filepder.SetCipher(nil)
+
+	// the plain session is written before the encryption is enabled
+	plain, _ := NewManager("file", NewManagerConfig(CfgGcLifeTime(180), CfgProviderConfig(sessionPath)))
+	s, _ := plain.GetSessionStore(sid)
+	s.Set(nil, "username", "plain")
+	s.SessionRelease(nil, nil)
+
+	manager, err := NewManager("file", NewManagerConfig(CfgGcLifeTime(180), CfgProviderConfig(sessionPath),
+		CfgEncryptionKeys("old-secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = manager.GetSessionStore(sid)
+	if err != nil || s.Get(nil, "username") != "plain" {
+		t.Fatal("the plain session can't be read after the encryption is enabled", err)
+	}
+	s.Set(nil, "username", "secret-name")
+	s.SessionRelease(nil, nil)
+
+	b, _ := os.ReadFile(filepath.Join(sessionPath, string(sid[0]), string(sid[1]), sid))
+	if bytes.Contains(b, []byte("secret-name")) {
+		t.Fatal("the session file isn't encrypted")
+	}
+
+	// rotate the key
+	manager, _ = NewManager("file", NewManagerConfig(CfgGcLifeTime(180), CfgProviderConfig(sessionPath),
+		CfgEncryptionKeys("new-secret", "old-secret")))
+	s, err = manager.GetSessionStore(sid)
+	if err != nil || s.Get(nil, "username") != "secret-name" {
+		t.Fatal("the session encrypted by the old key can't be read", err)
+	}
+
+	manager, _ = NewManager("file", NewManagerConfig(CfgGcLifeTime(180), CfgProviderConfig(sessionPath),
+		CfgEncryptionKeys("other-secret")))
+	if _, err = manager.GetSessionStore(sid); err == nil {
+		t.Fatal("the session is decrypted by the wrong key")
+	}
+}
+
+func TestNewManagerEncryptionUnsupported(t *testing.T) {
+	_, err := NewManager("memory", NewManagerConfig(CfgGcLifeTime(180), CfgEncryptionKeys("secret")))
+	if err == nil {
+		t.Fatal("the encryption is enabled for the provider which doesn't support it")
+	}
+}
@@ -153,10 +153,31 @@ func (pder *MemProvider) SessionDestroy(ctx context.Context, sid string) error {
 	return nil
 }
 
+// SessionInvalidate removes the session store which is invalidated by another instance
+func (pder *MemProvider) SessionInvalidate(ctx context.Context, sid string) {
+	pder.SessionDestroy(ctx, sid)
+}
+
 // SessionGC clean expired session stores in memory session
-func (pder *MemProvider) SessionGC(context.Context) {
+func (pder *MemProvider) SessionGC(ctx context.Context) {
+	pder.SessionGCExpired(ctx)
+}
+
+// SessionGCExpired cleans expired session stores and returns their ids
+func (pder *MemProvider) SessionGCExpired(ctx context.Context) []string {
+	expired, _ := pder.SessionGCBatch(ctx, 0)
+	return expired
+}
+
+// SessionGCBatch cleans at most limit expired session stores, 0 means no limit
+func (pder *MemProvider) SessionGCBatch(ctx context.Context, limit int) ([]string, bool) {
+	var expired []string
 	pder.lock.RLock()
 	for {
+		if limit > 0 && len(expired) >= limit || canceled(ctx) {
+			pder.lock.RUnlock()
+			return expired, true
+		}
 		element := pder.list.Back()
 		if element == nil {
 			break
@@ -166,6 +187,7 @@ func (pder *MemProvider) SessionGC(context.Context) {
 			pder.lock.Lock()
 			pder.list.Remove(element)
 			delete(pder.sessions, element.Value.(*MemSessionStore).sid)
+			expired = append(expired, element.Value.(*MemSessionStore).sid)
 			pder.lock.Unlock()
 			pder.lock.RLock()
 		} else {
@@ -173,6 +195,7 @@ func (pder *MemProvider) SessionGC(context.Context) {
 		}
 	}
 	pder.lock.RUnlock()
+	return expired, false
 }
 
 // SessionAll get count number of memory session
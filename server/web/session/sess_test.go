@@ -17,6 +17,7 @@ package session
 import (
 	"crypto/aes"
 	"encoding/json"
+	"fmt"
 	"testing"
 )
 
@@ -129,3 +130,57 @@ func TestParseConfig(t *testing.T) {
 		t.Fatal("ProviderConfig get securityKey error")
 	}
 }
+
+func TestSerializers(t *testing.T) {
+	values := map[interface{}]interface{}{"username": "astaxie", "age": 18}
+	for _, name := range []string{SerializerGob, SerializerJSON, SerializerMsgPack} {
+		s, err := GetSerializer(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, _ := NewAEADCipher("secret")
+		for _, codec := range []Codec{{Serializer: s}, {Serializer: s, Cipher: c}} {
+			b, err := codec.Encode(values)
+			if err != nil {
+				t.Fatal(name, "encode err", err)
+			}
+			decoded, err := codec.Decode(b)
+			if err != nil {
+				t.Fatal(name, "decode err", err)
+			}
+			if decoded["username"] != "astaxie" {
+				t.Fatal(name, "unexpected username", decoded["username"])
+			}
+			if age := fmt.Sprint(decoded["age"]); age != "18" {
+				t.Fatal(name, "unexpected age", age)
+			}
+		}
+	}
+
+	if _, err := (jsonSerializer{}).Marshal(map[interface{}]interface{}{1: 2}); err == nil {
+		t.Fatal("the key which isn't string is encoded by json")
+	}
+	if _, err := GetSerializer("xml"); err == nil {
+		t.Fatal("the unknown serializer is returned")
+	}
+	b, _ := (jsonSerializer{}).Marshal(values)
+	if string(b) != `{"age":18,"username":"astaxie"}` {
+		t.Fatal("the json can't be read by the other languages", string(b))
+	}
+}
+
+func TestFileProviderSerializer(t *testing.T) {
+	defer filepder.SetSerializer(nil)
+	if _, err := NewManager("memory", NewManagerConfig(CfgGcLifeTime(180), CfgSerializer(SerializerJSON))); err == nil {
+		t.Fatal("the serializer is set for the provider which doesn't support it")
+	}
+	if _, err := NewManager("file", NewManagerConfig(CfgGcLifeTime(180), CfgSerializer("xml"))); err == nil {
+		t.Fatal("the unknown serializer is set")
+	}
+	if _, err := NewManager("file", NewManagerConfig(CfgGcLifeTime(180), CfgSerializer(SerializerJSON))); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := filepder.codec.Serializer.(jsonSerializer); !ok {
+		t.Fatal("the serializer isn't set")
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"net/textproto"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -52,6 +53,19 @@ type Store interface {
 	Flush(ctx context.Context) error                           // delete all data
 }
 
+// StatelessStore is the Store which keeps the values in the client, such as the cookie.
+// The provider has nothing to move when the id is regenerated, so the id is changed in place
+type StatelessStore interface {
+	Store
+	RegenerateID(ctx context.Context, sid string) error
+}
+
+// Closer is implemented by the stores which hold resources until SessionRelease, such as the database connection.
+// The manager closes the expired store by it instead of SessionRelease, so the data isn't written back
+type Closer interface {
+	Close() error
+}
+
 // Provider contains global session methods and saved SessionStores.
 // it can operate a SessionStore by its id.
 type Provider interface {
@@ -91,6 +105,16 @@ func GetProvider(name string) (Provider, error) {
 type Manager struct {
 	provider Provider
 	config   *ManagerConfig
+	refresh  RefreshPolicy
+	limit    *ConcurrencyLimit
+	hooks    []EventHook
+
+	// invalidations is the queue of the invalidations published to the bus
+	invalidations chan Invalidation
+	instanceID    string
+
+	gcLock  sync.Mutex
+	gcStats GCStats
 }
 
 // NewManager Create new Manager with provider name and json config string.
@@ -115,6 +139,11 @@ func NewManager(provideName string, cf *ManagerConfig) (*Manager, error) {
 		cf.Maxlifetime = cf.Gclifetime
 	}
 
+	// the providers must keep the session at least IdleTimeout, otherwise it expires before the idle timeout
+	if cf.IdleTimeout > cf.Maxlifetime {
+		cf.Maxlifetime = cf.IdleTimeout
+	}
+
 	if cf.EnableSidInHTTPHeader {
 		if cf.SessionNameInHTTPHeader == "" {
 			panic(errors.New("SessionNameInHTTPHeader is empty"))
@@ -127,18 +156,50 @@ func NewManager(provideName string, cf *ManagerConfig) (*Manager, error) {
 		}
 	}
 
-	err := provider.SessionInit(context.Background(), cf.Maxlifetime, cf.ProviderConfig)
+	refresh, err := newRefreshPolicy(cf)
 	if err != nil {
 		return nil, err
 	}
 
+	err = provider.SessionInit(context.Background(), cf.Maxlifetime, cf.ProviderConfig)
+	if err != nil {
+		return nil, err
+	}
+	if setter, ok := provider.(RefreshPolicySetter); ok {
+		setter.SetRefreshPolicy(refresh)
+	}
+	var c Cipher
+	if len(cf.EncryptionKeys) > 0 {
+		if c, err = NewAEADCipher(cf.EncryptionKeys...); err != nil {
+			return nil, err
+		}
+	}
+	// the cipher is reset if the encryption is disabled, because the providers are shared by the managers
+	if setter, ok := provider.(CipherSetter); ok {
+		setter.SetCipher(c)
+	} else if c != nil {
+		return nil, fmt.Errorf("session: provider %q doesn't support the encryption", provideName)
+	}
+	var serializer Serializer
+	if cf.Serializer != "" {
+		if serializer, err = GetSerializer(cf.Serializer); err != nil {
+			return nil, err
+		}
+	}
+	if setter, ok := provider.(SerializerSetter); ok {
+		setter.SetSerializer(serializer)
+	} else if serializer != nil && cf.Serializer != SerializerGob {
+		return nil, fmt.Errorf("session: provider %q doesn't support the serializer %q", provideName, cf.Serializer)
+	}
+
 	if cf.SessionIDLength == 0 {
 		cf.SessionIDLength = 16
 	}
 
 	return &Manager{
-		provider,
-		cf,
+		provider: provider,
+		config:   cf,
+		refresh:  refresh,
 	}, nil
 }
 
@@ -196,7 +257,33 @@ func (manager *Manager) SessionStart(w http.ResponseWriter, r *http.Request) (se
 			return nil, err
 		}
 		if exists {
-			return manager.provider.SessionRead(context.Background(), sid)
+			session, err = manager.provider.SessionRead(context.Background(), sid)
+			if err != nil {
+				return nil, err
+			}
+			reason := manager.touch(context.Background(), session, time.Now())
+			if reason == "" {
+				err = manager.RegisterSession(context.Background(), session)
+				if err == nil {
+					return session, nil
+				}
+				if !errors.Is(err, ErrSessionLimitExceeded) {
+					return nil, err
+				}
+				reason = ReasonConcurrencyLimit
+			}
+			// the session exceeds the idle timeout, the absolute timeout or the concurrency limit, start a new one
+			if c, ok := session.(Closer); ok {
+				_ = c.Close()
+			}
+			if err = manager.provider.SessionDestroy(context.Background(), sid); err != nil {
+				return nil, err
+			}
+			event := Event{Type: EventExpired, SessionID: sid, Reason: reason, Request: r}
+			if reason == ReasonConcurrencyLimit {
+				event.Type = EventDestroyed
+			}
+			manager.emit(context.Background(), event)
 		}
 	}
 
@@ -210,6 +297,7 @@ func (manager *Manager) SessionStart(w http.ResponseWriter, r *http.Request) (se
 	if err != nil {
 		return nil, err
 	}
+	manager.touch(context.Background(), session, time.Now())
 	cookie := &http.Cookie{
 		Name:     manager.config.CookieName,
 		Value:    url.QueryEscape(sid),
@@ -232,6 +320,7 @@ func (manager *Manager) SessionStart(w http.ResponseWriter, r *http.Request) (se
 		r.Header.Set(manager.config.SessionNameInHTTPHeader, sid)
 		w.Header().Set(manager.config.SessionNameInHTTPHeader, sid)
 	}
+	manager.emit(context.Background(), Event{Type: EventCreated, SessionID: sid, Request: r})
 
 	return
 }
@@ -250,6 +339,7 @@ func (manager *Manager) SessionDestroy(w http.ResponseWriter, r *http.Request) {
 
 	sid, _ := url.QueryUnescape(cookie.Value)
 	manager.provider.SessionDestroy(context.Background(), sid)
+	manager.emit(context.Background(), Event{Type: EventDestroyed, SessionID: sid, Reason: ReasonLogout, Request: r})
 	if manager.config.EnableSetCookie {
 		expiration := time.Now()
 		cookie = &http.Cookie{
@@ -272,42 +362,65 @@ func (manager *Manager) GetSessionStore(sid string) (sessions Store, err error)
 	return
 }
 
-// GC Start session gc process.
-// it can do gc in times after gc lifetime.
-func (manager *Manager) GC() {
-	manager.provider.SessionGC(nil)
-	time.AfterFunc(time.Duration(manager.config.Gclifetime)*time.Second, func() { manager.GC() })
-}
-
 // SessionRegenerateID Regenerate a session id for this SessionStore who's id is saving in http request.
+// The values which are not saved yet are lost, use RegenerateID to keep them
 func (manager *Manager) SessionRegenerateID(w http.ResponseWriter, r *http.Request) (Store, error) {
-	sid, err := manager.sessionID()
-	if err != nil {
-		return nil, err
-	}
-
-	var session Store
+	var oldsid string
 	cookie, err := r.Cookie(manager.config.CookieName)
-	if err != nil || cookie.Value == "" {
-		// delete old cookie
-		session, err = manager.provider.SessionRead(context.Background(), sid)
-		if err != nil {
+	if err == nil && cookie.Value != "" {
+		if oldsid, err = url.QueryUnescape(cookie.Value); err != nil {
 			return nil, err
 		}
-		cookie = &http.Cookie{
-			Name:  manager.config.CookieName,
-			Value: url.QueryEscape(sid),
-		}
-	} else {
-		oldsid, err := url.QueryUnescape(cookie.Value)
+	}
+	return manager.regenerateID(context.Background(), w, r, oldsid)
+}
+
+// RegenerateID issues a new session id for store and invalidates the old one,
+// the values of store are kept, including the ones which are not saved yet.
+// It should be called after login or any privilege change to prevent session fixation.
+// The old id is moved to the new one by the provider, so it can't be used any more.
+// If store is StatelessStore, its id is changed in place and the new id is sent by SessionRelease,
+// but the old id can't be invalidated because there is no state on the server,
+// e.g. the old cookie of the cookie provider is still accepted until it expires.
+func (manager *Manager) RegenerateID(ctx context.Context, w http.ResponseWriter, r *http.Request, store Store) (Store, error) {
+	if store == nil {
+		return manager.SessionRegenerateID(w, r)
+	}
+	if ss, ok := store.(StatelessStore); ok {
+		sid, err := manager.sessionID()
 		if err != nil {
 			return nil, err
 		}
-		session, err = manager.provider.SessionRegenerate(context.Background(), oldsid, sid)
-		if err != nil {
+		oldsid := store.SessionID(ctx)
+		if err = ss.RegenerateID(ctx, sid); err != nil {
 			return nil, err
 		}
-		cookie.Value = url.QueryEscape(sid)
+		manager.emit(ctx, Event{Type: EventRegenerated, SessionID: sid, OldSessionID: oldsid, Request: r})
+		return store, nil
+	}
+	// save the values, so the provider moves them to the new id
+	store.SessionRelease(ctx, w)
+	return manager.regenerateID(ctx, w, r, store.SessionID(ctx))
+}
+
+func (manager *Manager) regenerateID(ctx context.Context, w http.ResponseWriter, r *http.Request, oldsid string) (Store, error) {
+	sid, err := manager.sessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	var session Store
+	if oldsid == "" {
+		session, err = manager.provider.SessionRead(ctx, sid)
+	} else {
+		session, err = manager.provider.SessionRegenerate(ctx, oldsid, sid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cookie := &http.Cookie{
+		Name:  manager.config.CookieName,
+		Value: url.QueryEscape(sid),
 	}
 	if manager.config.CookieLifeTime > 0 {
 		cookie.MaxAge = manager.config.CookieLifeTime
@@ -328,6 +441,11 @@ func (manager *Manager) SessionRegenerateID(w http.ResponseWriter, r *http.Reque
 		r.Header.Set(manager.config.SessionNameInHTTPHeader, sid)
 		w.Header().Set(manager.config.SessionNameInHTTPHeader, sid)
 	}
+	if oldsid == "" {
+		manager.emit(ctx, Event{Type: EventCreated, SessionID: sid, Request: r})
+	} else {
+		manager.emit(ctx, Event{Type: EventRegenerated, SessionID: sid, OldSessionID: oldsid, Request: r})
+	}
 	return session, nil
 }
 
@@ -19,6 +19,28 @@ type ManagerConfig struct {
 	SessionNameInHTTPHeader string        `json:"SessionNameInHTTPHeader"`
 	SessionIDPrefix         string        `json:"sessionIDPrefix"`
 	CookieSameSite          http.SameSite `json:"cookieSameSite"`
+	// IdleTimeout expires the session if it isn't accessed in IdleTimeout seconds, 0 means no idle timeout
+	IdleTimeout int64 `json:"idleTimeout"`
+	// AbsoluteTimeout expires the session AbsoluteTimeout seconds after it's created even if it's active,
+	// 0 means no absolute timeout
+	AbsoluteTimeout int64 `json:"absoluteTimeout"`
+	// RefreshMode is how often the TTL of the session is refreshed when it's only read, RefreshAlways by default.
+	// It's supported by the providers which implement RefreshPolicySetter
+	RefreshMode RefreshMode `json:"refreshMode"`
+	// RefreshInterval is the min interval between two refreshes in RefreshInterval mode, unit: second
+	RefreshInterval int64 `json:"refreshInterval"`
+	// EncryptionKeys enables the encryption at rest of the sessions by AES-GCM,
+	// the first key encrypts and all the keys decrypt. The provider must implement CipherSetter
+	EncryptionKeys []string `json:"encryptionKeys"`
+	// Serializer is the name of the serializer of the sessions, such as gob(default), json and msgpack.
+	// The provider must implement SerializerSetter if it isn't gob
+	Serializer string `json:"serializer"`
+	// GCBatchSize is the max number of sessions removed by one batch of the GC, 0 means no limit.
+	// It's supported by the providers which implement BatchGCReporter
+	GCBatchSize int `json:"gcBatchSize"`
+	// GCMaxDuration stops the sweep of the GC after GCMaxDuration milliseconds, 0 means no limit.
+	// The rest expired sessions are removed by the next sweep
+	GCMaxDuration int64 `json:"gcMaxDuration"`
 }
 
 func (c *ManagerConfig) Opts(opts ...ManagerConfigOpt) {
@@ -141,3 +163,50 @@ func CfgSameSite(sameSite http.SameSite) ManagerConfigOpt {
 		config.CookieSameSite = sameSite
 	}
 }
+
+// CfgIdleTimeout set the idle timeout of session, unit: second
+func CfgIdleTimeout(timeout int64) ManagerConfigOpt {
+	return func(config *ManagerConfig) {
+		config.IdleTimeout = timeout
+	}
+}
+
+// CfgAbsoluteTimeout set the absolute timeout of session, unit: second
+func CfgAbsoluteTimeout(timeout int64) ManagerConfigOpt {
+	return func(config *ManagerConfig) {
+		config.AbsoluteTimeout = timeout
+	}
+}
+
+// CfgRefreshPolicy set how often the TTL of the session is refreshed,
+// interval is only used by RefreshInterval mode, unit: second
+func CfgRefreshPolicy(mode RefreshMode, interval int64) ManagerConfigOpt {
+	return func(config *ManagerConfig) {
+		config.RefreshMode = mode
+		config.RefreshInterval = interval
+	}
+}
+
+// CfgEncryptionKeys set the keys which encrypt the sessions stored by the provider,
+// the first key encrypts and all the keys decrypt
+func CfgEncryptionKeys(keys ...string) ManagerConfigOpt {
+	return func(config *ManagerConfig) {
+		config.EncryptionKeys = keys
+	}
+}
+
+// CfgSerializer set the serializer of the sessions by its name, such as json and msgpack
+func CfgSerializer(name string) ManagerConfigOpt {
+	return func(config *ManagerConfig) {
+		config.Serializer = name
+	}
+}
+
+// CfgGCBatch set the max number of sessions removed by one batch of the GC
+// and the max duration of one sweep, unit: millisecond
+func CfgGCBatch(batchSize int, maxDuration int64) ManagerConfigOpt {
+	return func(config *ManagerConfig) {
+		config.GCBatchSize = batchSize
+		config.GCMaxDuration = maxDuration
+	}
+}
@@ -3,16 +3,21 @@ package session
 type ProviderType string
 
 const (
-	ProviderCookie        ProviderType = `cookie`
-	ProviderFile          ProviderType = `file`
-	ProviderMemory        ProviderType = `memory`
-	ProviderCouchbase     ProviderType = `couchbase`
-	ProviderLedis         ProviderType = `ledis`
-	ProviderMemcache      ProviderType = `memcache`
-	ProviderMysql         ProviderType = `mysql`
-	ProviderPostgresql    ProviderType = `postgresql`
-	ProviderRedis         ProviderType = `redis`
-	ProviderRedisCluster  ProviderType = `redis_cluster`
-	ProviderRedisSentinel ProviderType = `redis_sentinel`
-	ProviderSsdb          ProviderType = `ssdb`
+	ProviderBolt            ProviderType = `bolt`
+	ProviderCookie          ProviderType = `cookie`
+	ProviderFile            ProviderType = `file`
+	ProviderMemory          ProviderType = `memory`
+	ProviderCouchbase       ProviderType = `couchbase`
+	ProviderEncryptedCookie ProviderType = `encrypted_cookie`
+	ProviderEtcd            ProviderType = `etcd`
+	ProviderJWT             ProviderType = `jwt`
+	ProviderLedis           ProviderType = `ledis`
+	ProviderMemcache        ProviderType = `memcache`
+	ProviderMongo           ProviderType = `mongo`
+	ProviderMysql           ProviderType = `mysql`
+	ProviderPostgresql      ProviderType = `postgresql`
+	ProviderRedis           ProviderType = `redis`
+	ProviderRedisCluster    ProviderType = `redis_cluster`
+	ProviderRedisSentinel   ProviderType = `redis_sentinel`
+	ProviderSsdb            ProviderType = `ssdb`
 )
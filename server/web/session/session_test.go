@@ -0,0 +1,727 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestManagerRegenerateID(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(
+		CfgCookieName("gosessionid"),
+		CfgSetCookie(true),
+		CfgGcLifeTime(3600),
+		CfgMaxLifeTime(3600),
+	))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	sess, err := manager.SessionStart(w, r)
+	if err != nil {
+		t.Fatal("session start err", err)
+	}
+	oldsid := sess.SessionID(ctx)
+	_ = sess.Set(ctx, "username", "astaxie")
+
+	w = httptest.NewRecorder()
+	sess, err = manager.RegenerateID(ctx, w, r, sess)
+	if err != nil {
+		t.Fatal("regenerate id err", err)
+	}
+	sid := sess.SessionID(ctx)
+	if sid == oldsid {
+		t.Fatal("the session id isn't regenerated")
+	}
+	if username := sess.Get(ctx, "username"); username != "astaxie" {
+		t.Fatal("the values aren't kept", username)
+	}
+	if cookie := w.Result().Cookies(); len(cookie) != 1 || cookie[0].Value != sid {
+		t.Fatal("the new session id isn't set to the cookie", cookie)
+	}
+	if exist, _ := manager.GetProvider().SessionExist(ctx, oldsid); exist {
+		t.Fatal("the old session id isn't invalidated")
+	}
+}
+
+func TestManagerRegenerateIDStateless(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("encrypted_cookie", NewManagerConfig(
+		CfgCookieName("gosessionid"),
+		CfgGcLifeTime(3600),
+		CfgMaxLifeTime(3600),
+		CfgProviderConfig(`{"cookieName":"gosessionid","keys":["key"]}`),
+	))
+	if err != nil {
+		t.Fatal("init encrypted cookie session err", err)
+	}
+	r, _ := http.NewRequest("GET", "/", nil)
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	oldsid := sess.SessionID(ctx)
+	_ = sess.Set(ctx, "username", "astaxie")
+
+	w := httptest.NewRecorder()
+	sess, err = manager.RegenerateID(ctx, w, r, sess)
+	if err != nil {
+		t.Fatal("regenerate id err", err)
+	}
+	if sess.SessionID(ctx) == oldsid || sess.Get(ctx, "username") != "astaxie" {
+		t.Fatal("regenerate stateless session error")
+	}
+	sess.SessionRelease(ctx, w)
+
+	r, _ = http.NewRequest("GET", "/", nil)
+	r.AddCookie(w.Result().Cookies()[0])
+	newSess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	if newSess.SessionID(ctx) != sess.SessionID(ctx) || newSess.Get(ctx, "username") != "astaxie" {
+		t.Fatal("the regenerated session isn't saved in the cookie")
+	}
+}
+
+func TestCookieProviderSessionRegenerate(t *testing.T) {
+	pder := &CookieProvider{}
+	if err := pder.SessionInit(context.Background(), 3600, `{"cookieName":"gosessionid","securityKey":"beegocookiehashkey"}`); err != nil {
+		t.Fatal(err)
+	}
+	sess, err := pder.SessionRegenerate(context.Background(), "invalid", "new")
+	if err != nil || sess == nil || sess.SessionID(nil) != "new" {
+		t.Fatal("regenerate cookie session error", err)
+	}
+}
+
+func TestManagerIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(
+		CfgCookieName("gosessionid"),
+		CfgGcLifeTime(60),
+		CfgIdleTimeout(3600),
+	))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	if manager.config.Maxlifetime != 3600 {
+		t.Fatal("the max lifetime is less than the idle timeout", manager.config.Maxlifetime)
+	}
+	r, _ := http.NewRequest("GET", "/", nil)
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	sid := sess.SessionID(ctx)
+	l := manager.SessionLifetime(ctx, sess)
+	if l.IdleRemaining() <= 0 || !l.AbsoluteExpiresAt.IsZero() || l.AbsoluteRemaining() != 0 {
+		t.Fatal("unexpected lifetime", l)
+	}
+
+	sess, _ = manager.SessionStart(httptest.NewRecorder(), r)
+	if sess.SessionID(ctx) != sid {
+		t.Fatal("the active session expires")
+	}
+
+	_ = sess.Set(ctx, accessedAtKey, time.Now().Add(-2*time.Hour).Unix())
+	sess, _ = manager.SessionStart(httptest.NewRecorder(), r)
+	if sess.SessionID(ctx) == sid {
+		t.Fatal("the idle session doesn't expire")
+	}
+	if exist, _ := manager.GetProvider().SessionExist(ctx, sid); exist {
+		t.Fatal("the idle session isn't destroyed")
+	}
+}
+
+func TestManagerAbsoluteTimeout(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(
+		CfgCookieName("gosessionid"),
+		CfgGcLifeTime(3600),
+		CfgIdleTimeout(600),
+		CfgAbsoluteTimeout(7200),
+	))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	r, _ := http.NewRequest("GET", "/", nil)
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	sid := sess.SessionID(ctx)
+	l := manager.SessionLifetime(ctx, sess)
+	if l.AbsoluteRemaining() <= l.IdleRemaining() || !l.ExpiresAt().Equal(l.IdleExpiresAt) {
+		t.Fatal("unexpected lifetime", l)
+	}
+
+	// the session is active but it's created too long ago
+	_ = sess.Set(ctx, createdAtKey, time.Now().Add(-3*time.Hour).Unix())
+	sess, _ = manager.SessionStart(httptest.NewRecorder(), r)
+	if sess.SessionID(ctx) == sid {
+		t.Fatal("the session doesn't expire after the absolute timeout")
+	}
+}
+
+func TestLifetimeFloatTimestamp(t *testing.T) {
+	ts, ok := unixTime(float64(1700000000))
+	if !ok || ts.Unix() != 1700000000 {
+		t.Fatal("the timestamp decoded from JSON isn't supported")
+	}
+	if _, ok = unixTime("1700000000"); ok {
+		t.Fatal("the string timestamp is accepted")
+	}
+}
+
+func TestRefreshPolicyNeedRefresh(t *testing.T) {
+	now := time.Now()
+	values := make(map[interface{}]interface{})
+	if !(RefreshPolicy{Mode: RefreshAlways}).NeedRefresh(values, false, now) {
+		t.Fatal("always mode doesn't refresh")
+	}
+	never := RefreshPolicy{Mode: RefreshNever}
+	if never.NeedRefresh(values, false, now) || !never.NeedRefresh(values, true, now) {
+		t.Fatal("never mode should only write the modified session")
+	}
+	if len(values) != 0 {
+		t.Fatal("the refresh time is recorded in the values", values)
+	}
+
+	interval := RefreshPolicy{Mode: RefreshInterval, Interval: time.Minute}
+	if !interval.NeedRefresh(values, false, now) {
+		t.Fatal("the session which isn't refreshed should be refreshed")
+	}
+	if interval.NeedRefresh(values, false, now.Add(30*time.Second)) {
+		t.Fatal("the session is refreshed in the interval")
+	}
+	if !interval.NeedRefresh(values, true, now.Add(30*time.Second)) {
+		t.Fatal("the modified session isn't written")
+	}
+	if !interval.NeedRefresh(values, false, now.Add(2*time.Minute)) {
+		t.Fatal("the session isn't refreshed after the interval")
+	}
+}
+
+func TestNewManagerRefreshPolicy(t *testing.T) {
+	cases := []struct {
+		opts  []ManagerConfigOpt
+		valid bool
+	}{
+		{[]ManagerConfigOpt{CfgRefreshPolicy("", 0)}, true},
+		{[]ManagerConfigOpt{CfgRefreshPolicy(RefreshInterval, 60)}, true},
+		{[]ManagerConfigOpt{CfgRefreshPolicy(RefreshInterval, 0)}, false},
+		{[]ManagerConfigOpt{CfgRefreshPolicy(RefreshInterval, 3600)}, false},
+		{[]ManagerConfigOpt{CfgRefreshPolicy(RefreshNever, 0)}, true},
+		{[]ManagerConfigOpt{CfgRefreshPolicy(RefreshNever, 0), CfgIdleTimeout(600)}, false},
+		{[]ManagerConfigOpt{CfgRefreshPolicy("sometimes", 0)}, false},
+	}
+	for i, c := range cases {
+		cf := NewManagerConfig(append(c.opts, CfgGcLifeTime(3600))...)
+		_, err := NewManager("memory", cf)
+		if (err == nil) != c.valid {
+			t.Fatal("case", i, "unexpected error", err)
+		}
+	}
+}
+
+func TestManagerIdleTimeoutRefreshInterval(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(
+		CfgCookieName("gosessionid"),
+		CfgGcLifeTime(3600),
+		CfgIdleTimeout(3600),
+		CfgRefreshPolicy(RefreshInterval, 60),
+	))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	r, _ := http.NewRequest("GET", "/", nil)
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+
+	accessed := time.Now().Add(-30 * time.Second).Unix()
+	_ = sess.Set(ctx, accessedAtKey, accessed)
+	sess, _ = manager.SessionStart(httptest.NewRecorder(), r)
+	if sess.Get(ctx, accessedAtKey) != accessed {
+		t.Fatal("the access is recorded in the refresh interval")
+	}
+
+	_ = sess.Set(ctx, accessedAtKey, time.Now().Add(-2*time.Minute).Unix())
+	sess, _ = manager.SessionStart(httptest.NewRecorder(), r)
+	if at, _ := unixTime(sess.Get(ctx, accessedAtKey)); time.Since(at) > time.Minute {
+		t.Fatal("the access isn't recorded after the refresh interval")
+	}
+}
+
+func TestFlash(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(CfgCookieName("gosessionid"), CfgGcLifeTime(3600)))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	r, _ := http.NewRequest("POST", "/", nil)
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	_ = Flash(ctx, sess, "notice", "saved")
+	_ = Flash(ctx, sess, "notice", "published")
+	_ = Flash(ctx, sess, "error", 1)
+
+	// the next request after the redirect reads the flashes
+	sess, _ = manager.SessionStart(httptest.NewRecorder(), r)
+	notices := GetFlashes(ctx, sess, "notice")
+	if len(notices) != 2 || notices[0] != "saved" || notices[1] != "published" {
+		t.Fatal("unexpected flashes", notices)
+	}
+	if notices = GetFlashes(ctx, sess, "notice"); notices != nil {
+		t.Fatal("the flashes are read twice", notices)
+	}
+	all := GetAllFlashes(ctx, sess)
+	if len(all) != 1 || len(all["error"]) != 1 || all["error"][0] != 1 {
+		t.Fatal("unexpected flashes", all)
+	}
+	if sess.Get(ctx, flashKey) != nil {
+		t.Fatal("the flashes aren't removed from the session")
+	}
+}
+
+func TestFlashGob(t *testing.T) {
+	ctx := context.Background()
+	sess := &MemSessionStore{value: make(map[interface{}]interface{})}
+	_ = Flash(ctx, sess, "notice", "saved")
+	b, err := EncodeGob(sess.value)
+	if err != nil {
+		t.Fatal("encode flashes err", err)
+	}
+	values, err := DecodeGob(b)
+	if err != nil {
+		t.Fatal("decode flashes err", err)
+	}
+	sess = &MemSessionStore{value: values}
+	if notices := GetFlashes(ctx, sess, "notice"); len(notices) != 1 || notices[0] != "saved" {
+		t.Fatal("unexpected flashes", notices)
+	}
+}
+
+// closingProvider counts the stores closed by the manager
+type closingProvider struct {
+	Provider
+	closed int
+}
+
+func (p *closingProvider) SessionRead(ctx context.Context, sid string) (Store, error) {
+	st, err := p.Provider.SessionRead(ctx, sid)
+	return &closingStore{Store: st, provider: p}, err
+}
+
+type closingStore struct {
+	Store
+	provider *closingProvider
+}
+
+func (s *closingStore) Close() error {
+	s.provider.closed++
+	return nil
+}
+
+func TestManagerClosesExpiredStore(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(CfgCookieName("gosessionid"), CfgIdleTimeout(3600)))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	provider := &closingProvider{Provider: manager.provider}
+	manager.provider = provider
+	r, _ := http.NewRequest("GET", "/", nil)
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	_ = sess.Set(ctx, accessedAtKey, time.Now().Add(-2*time.Hour).Unix())
+	r.AddCookie(&http.Cookie{Name: "gosessionid", Value: sess.SessionID(ctx)})
+	if _, err = manager.SessionStart(httptest.NewRecorder(), r); err != nil {
+		t.Fatal("session start err", err)
+	}
+	if provider.closed != 1 {
+		t.Fatal("the idle store isn't closed", provider.closed)
+	}
+
+	manager = newLimitedManager(t, 1, EvictDeny)
+	provider = &closingProvider{Provider: manager.provider}
+	manager.provider = provider
+	_, _ = login(t, manager, "astaxie", time.Now())
+	second, _ := login(t, manager, "astaxie", time.Now())
+	r, _ = http.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "gosessionid", Value: second.SessionID(ctx)})
+	if _, err = manager.SessionStart(httptest.NewRecorder(), r); err != nil {
+		t.Fatal("session start err", err)
+	}
+	if provider.closed != 1 {
+		t.Fatal("the denied store isn't closed", provider.closed)
+	}
+}
+
+func newLimitedManager(t *testing.T, max int, eviction EvictionPolicy) *Manager {
+	manager, err := NewManager("memory", NewManagerConfig(CfgCookieName("gosessionid"), CfgGcLifeTime(3600)))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	err = manager.SetConcurrencyLimit(ConcurrencyLimit{
+		Principal: func(ctx context.Context, store Store) string {
+			uid, _ := store.Get(ctx, "uid").(string)
+			return uid
+		},
+		Max:      max,
+		Eviction: eviction,
+	})
+	if err != nil {
+		t.Fatal("set concurrency limit err", err)
+	}
+	return manager
+}
+
+func login(t *testing.T, manager *Manager, uid string, createdAt time.Time) (Store, error) {
+	ctx := context.Background()
+	r, _ := http.NewRequest("POST", "/login", nil)
+	sess, err := manager.SessionStart(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatal("session start err", err)
+	}
+	_ = sess.Set(ctx, createdAtKey, createdAt.Unix())
+	_ = sess.Set(ctx, "uid", uid)
+	return sess, manager.RegisterSession(ctx, sess)
+}
+
+func TestConcurrencyLimitEvictOldest(t *testing.T) {
+	ctx := context.Background()
+	manager := newLimitedManager(t, 2, EvictOldest)
+	now := time.Now()
+	first, _ := login(t, manager, "astaxie", now.Add(-time.Hour))
+	second, _ := login(t, manager, "astaxie", now.Add(-time.Minute))
+	_, _ = login(t, manager, "other", now)
+	third, err := login(t, manager, "astaxie", now)
+	if err != nil {
+		t.Fatal("register session err", err)
+	}
+
+	sessions, _ := manager.UserSessions(ctx, "astaxie")
+	if len(sessions) != 2 || sessions[0].ID != second.SessionID(ctx) || sessions[1].ID != third.SessionID(ctx) {
+		t.Fatal("the oldest session isn't evicted", sessions)
+	}
+	if exist, _ := manager.GetProvider().SessionExist(ctx, first.SessionID(ctx)); exist {
+		t.Fatal("the evicted session isn't destroyed")
+	}
+	if sessions, _ = manager.UserSessions(ctx, "other"); len(sessions) != 1 {
+		t.Fatal("the sessions of the other user are evicted", sessions)
+	}
+
+	if err = manager.RevokeUserSessions(ctx, "astaxie"); err != nil {
+		t.Fatal("revoke sessions err", err)
+	}
+	if sessions, _ = manager.UserSessions(ctx, "astaxie"); len(sessions) != 0 {
+		t.Fatal("the sessions aren't revoked", sessions)
+	}
+}
+
+func TestConcurrencyLimitEvictNewest(t *testing.T) {
+	ctx := context.Background()
+	manager := newLimitedManager(t, 2, EvictNewest)
+	now := time.Now()
+	first, _ := login(t, manager, "astaxie", now.Add(-time.Hour))
+	_, _ = login(t, manager, "astaxie", now.Add(-time.Minute))
+	third, _ := login(t, manager, "astaxie", now)
+
+	sessions, _ := manager.UserSessions(ctx, "astaxie")
+	if len(sessions) != 2 || sessions[0].ID != first.SessionID(ctx) || sessions[1].ID != third.SessionID(ctx) {
+		t.Fatal("the newest session isn't evicted", sessions)
+	}
+}
+
+func TestConcurrencyLimitDeny(t *testing.T) {
+	ctx := context.Background()
+	manager := newLimitedManager(t, 1, EvictDeny)
+	first, _ := login(t, manager, "astaxie", time.Now())
+	second, err := login(t, manager, "astaxie", time.Now())
+	if err != ErrSessionLimitExceeded {
+		t.Fatal("the session exceeding the limit isn't denied", err)
+	}
+	// the denied session isn't started by the next request
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "gosessionid", Value: second.SessionID(ctx)})
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	if sess.SessionID(ctx) == second.SessionID(ctx) || sess.Get(ctx, "uid") != nil {
+		t.Fatal("the denied session is started")
+	}
+	if sessions, _ := manager.UserSessions(ctx, "astaxie"); len(sessions) != 1 || sessions[0].ID != first.SessionID(ctx) {
+		t.Fatal("unexpected sessions", sessions)
+	}
+}
+
+func TestManagerEventHooks(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(
+		CfgCookieName("gosessionid"),
+		CfgGcLifeTime(3600),
+		CfgIdleTimeout(3600),
+	))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	var events []Event
+	manager.AddEventHook(func(ctx context.Context, e Event) {
+		events = append(events, e)
+	})
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	sess, _ := manager.SessionStart(httptest.NewRecorder(), r)
+	sid := sess.SessionID(ctx)
+	sess, _ = manager.RegenerateID(ctx, httptest.NewRecorder(), r, sess)
+	newsid := sess.SessionID(ctx)
+	_ = sess.Set(ctx, accessedAtKey, time.Now().Add(-2*time.Hour).Unix())
+	r, _ = http.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "gosessionid", Value: newsid})
+	sess, _ = manager.SessionStart(httptest.NewRecorder(), r)
+	r, _ = http.NewRequest("GET", "/logout", nil)
+	r.AddCookie(&http.Cookie{Name: "gosessionid", Value: sess.SessionID(ctx)})
+	manager.SessionDestroy(httptest.NewRecorder(), r)
+
+	expected := []Event{
+		{Type: EventCreated, SessionID: sid},
+		{Type: EventRegenerated, SessionID: newsid, OldSessionID: sid},
+		{Type: EventExpired, SessionID: newsid, Reason: ReasonIdleTimeout},
+		{Type: EventCreated, SessionID: sess.SessionID(ctx)},
+		{Type: EventDestroyed, SessionID: sess.SessionID(ctx), Reason: ReasonLogout},
+	}
+	if len(events) != len(expected) {
+		t.Fatal("unexpected events", events)
+	}
+	for i, e := range expected {
+		got := events[i]
+		if got.Type != e.Type || got.SessionID != e.SessionID || got.OldSessionID != e.OldSessionID ||
+			got.Reason != e.Reason || got.Request == nil || got.Time.IsZero() {
+			t.Fatal("unexpected event", i, got)
+		}
+	}
+}
+
+func TestManagerGCEvent(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(CfgCookieName("gosessionid"), CfgGcLifeTime(3600)))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	var expired []string
+	manager.AddEventHook(func(ctx context.Context, e Event) {
+		if e.Type == EventExpired && e.Reason == ReasonGC {
+			expired = append(expired, e.SessionID)
+		}
+	})
+	sess, _ := manager.GetSessionStore("gc_event_sid")
+	sess.(*MemSessionStore).timeAccessed = time.Now().Add(-2 * time.Hour)
+	mempder.list.MoveToBack(mempder.sessions["gc_event_sid"])
+	manager.gc(ctx)
+	if len(expired) != 1 || expired[0] != "gc_event_sid" {
+		t.Fatal("the expired session isn't reported", expired)
+	}
+}
+
+func TestManagerRunGCBatch(t *testing.T) {
+	ctx := context.Background()
+	manager, err := NewManager("memory", NewManagerConfig(CfgCookieName("gosessionid"), CfgGcLifeTime(3600), CfgGCBatch(2, 0)))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	manager.RunGC(ctx)
+	for _, sid := range []string{"gc_batch_sid1", "gc_batch_sid2", "gc_batch_sid3"} {
+		sess, _ := manager.GetSessionStore(sid)
+		sess.(*MemSessionStore).timeAccessed = time.Now().Add(-2 * time.Hour)
+		mempder.list.MoveToBack(mempder.sessions[sid])
+	}
+	manager.GetSessionStore("gc_batch_active")
+
+	stats := manager.RunGC(ctx)
+	if stats.LastExpired != 3 || stats.Interrupted || stats.LastRun.IsZero() {
+		t.Fatal("unexpected gc stats", stats)
+	}
+	if stats.Active != mempder.SessionAll(ctx) || stats.Runs < 2 || stats.Expired < 3 {
+		t.Fatal("unexpected gc stats", stats)
+	}
+	if manager.GCStats() != stats {
+		t.Fatal("the stats of the last sweep aren't kept")
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	expired, more := mempder.SessionGCBatch(cctx, 0)
+	if len(expired) != 0 || !more {
+		t.Fatal("the batch should stop when the context is done", expired, more)
+	}
+}
+
+func TestInvalidationBus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := NewMemoryInvalidationBus()
+	managers := make([]*Manager, 2)
+	invalidated := make([]chan Event, 2)
+	for i := range managers {
+		manager, err := NewManager("memory", NewManagerConfig(CfgCookieName("gosessionid"), CfgGcLifeTime(3600)))
+		if err != nil {
+			t.Fatal("init memory session err", err)
+		}
+		invalidated[i] = make(chan Event, 1)
+		ch := invalidated[i]
+		manager.AddEventHook(func(ctx context.Context, e Event) {
+			if e.Type == EventInvalidated {
+				ch <- e
+			}
+		})
+		if err = manager.SetInvalidationBus(ctx, bus); err != nil {
+			t.Fatal("set invalidation bus err", err)
+		}
+		managers[i] = manager
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "gosessionid", Value: "invalidation_sid"})
+	managers[0].GetSessionStore("invalidation_sid")
+	managers[0].SessionDestroy(w, r)
+
+	select {
+	case e := <-invalidated[1]:
+		if e.SessionID != "invalidation_sid" || e.Reason != ReasonLogout {
+			t.Fatal("unexpected invalidation", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the invalidation isn't broadcast")
+	}
+	select {
+	case e := <-invalidated[0]:
+		t.Fatal("the manager shouldn't receive its own invalidation", e)
+	default:
+	}
+}
+
+// blockingBus blocks Publish until release is closed
+type blockingBus struct {
+	release   chan struct{}
+	published chan Invalidation
+}
+
+func (b *blockingBus) Publish(ctx context.Context, inv Invalidation) error {
+	<-b.release
+	b.published <- inv
+	return nil
+}
+
+func (b *blockingBus) Subscribe(ctx context.Context, handler func(ctx context.Context, inv Invalidation)) error {
+	return nil
+}
+
+func TestInvalidationBusAsync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager, err := NewManager("memory", NewManagerConfig(CfgCookieName("gosessionid"), CfgGcLifeTime(3600)))
+	if err != nil {
+		t.Fatal("init memory session err", err)
+	}
+	bus := &blockingBus{release: make(chan struct{}), published: make(chan Invalidation, invalidationQueueSize+1)}
+	if err = manager.SetInvalidationBus(ctx, bus); err != nil {
+		t.Fatal("set invalidation bus err", err)
+	}
+
+	// the slow bus doesn't block the requests, and the overflowed invalidations are dropped
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < invalidationQueueSize+10; i++ {
+			manager.emit(ctx, Event{Type: EventDestroyed, SessionID: fmt.Sprintf("sid_%d", i)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishing the invalidations blocks")
+	}
+	close(bus.release)
+	if inv := <-bus.published; inv.SessionID != "sid_0" {
+		t.Fatal("unexpected invalidation", inv)
+	}
+}
+
+func TestTypedAccessors(t *testing.T) {
+	ctx := context.Background()
+	store := &MemSessionStore{value: make(map[interface{}]interface{})}
+	if err := Set(ctx, store, "uid", int64(42)); err != nil {
+		t.Fatal(err)
+	}
+	if uid, ok := Get[int64](ctx, store, "uid"); !ok || uid != 42 {
+		t.Fatal("unexpected uid", uid, ok)
+	}
+	if uid, ok := Get[int](ctx, store, "uid"); !ok || uid != 42 {
+		t.Fatal("the number should be converted", uid, ok)
+	}
+	if _, ok := Get[string](ctx, store, "uid"); ok {
+		t.Fatal("the number shouldn't be read as string")
+	}
+	store.Set(ctx, "ratio", 1.5)
+	if _, ok := Get[int](ctx, store, "ratio"); ok {
+		t.Fatal("the fraction shouldn't be dropped")
+	}
+	if name := GetOr(ctx, store, "name", "guest"); name != "guest" {
+		t.Fatal("the default value should be returned", name)
+	}
+
+	type Prefs struct {
+		Lang    string `session:"lang"`
+		Size    int    `session:"size"`
+		Tags    []string
+		Ignored string `session:"-"`
+		Missing string `session:"missing"`
+	}
+	// the values decoded by the json serializer
+	store.Set(ctx, "lang", "en")
+	store.Set(ctx, "size", float64(12))
+	store.Set(ctx, "Tags", []interface{}{"a", "b"})
+	store.Set(ctx, "Ignored", "x")
+	prefs := Prefs{Missing: "kept"}
+	if err := BindSession(ctx, store, &prefs); err != nil {
+		t.Fatal(err)
+	}
+	if prefs.Lang != "en" || prefs.Size != 12 || len(prefs.Tags) != 2 || prefs.Tags[1] != "b" ||
+		prefs.Ignored != "" || prefs.Missing != "kept" {
+		t.Fatal("unexpected prefs", prefs)
+	}
+	store.Set(ctx, "size", "large")
+	if err := BindSession(ctx, store, &prefs); err == nil {
+		t.Fatal("the string shouldn't be bound to int")
+	}
+	if err := BindSession(ctx, store, prefs); err == nil {
+		t.Fatal("BindSession requires a pointer")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	var c Changes
+	if !c.Empty() {
+		t.Fatal("the changes aren't empty")
+	}
+	c.Change("a")
+	c.Change("b")
+	c.Change("a")
+	if keys := c.Keys(); len(keys) != 2 || c.Empty() {
+		t.Fatal("unexpected changed keys", keys)
+	}
+	c.Flush()
+	c.Change("c")
+	if !c.Flushed || c.Keys() != nil {
+		t.Fatal("the flushed changes should write the whole session")
+	}
+	c.Reset()
+	if !c.Empty() {
+		t.Fatal("the changes aren't reset")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Get returns the value of key in store as T, ok is false if the value doesn't exist or can't be converted to T.
+// Besides the values of T, the numbers are converted if they're in the range of T,
+// and the maps and slices decoded by the json and msgpack serializers are converted to T by JSON.
+// usage:
+//
+//	uid, ok := session.Get[int64](ctx, store, "uid")
+func Get[T any](ctx context.Context, store Store, key interface{}) (value T, ok bool) {
+	v, ok := convert(store.Get(ctx, key), reflect.TypeOf(&value).Elem())
+	if !ok {
+		return value, false
+	}
+	return v.Interface().(T), true
+}
+
+// GetOr returns the value of key in store as T, or def if the value doesn't exist or can't be converted to T
+func GetOr[T any](ctx context.Context, store Store, key interface{}, def T) T {
+	if v, ok := Get[T](ctx, store, key); ok {
+		return v
+	}
+	return def
+}
+
+// Set stores value with key in store, T makes sure the value read by Get[T] is the same type
+func Set[T any](ctx context.Context, store Store, key interface{}, value T) error {
+	return store.Set(ctx, key, value)
+}
+
+// BindSession fills the fields of the struct obj points to by the values in store.
+// The key of the field is its `session` tag, or its name if there is no tag, "-" skips the field.
+// The fields whose values don't exist are kept, and the values are converted in the same way as Get.
+// usage:
+//
+//	type Prefs struct {
+//		Lang  string `session:"lang"`
+//		Theme string `session:"theme"`
+//	}
+//	var prefs Prefs
+//	err := session.BindSession(ctx, store, &prefs)
+func BindSession(ctx context.Context, store Store, obj interface{}) error {
+	objV := reflect.ValueOf(obj)
+	if objV.Kind() != reflect.Ptr || objV.IsNil() || objV.Elem().Kind() != reflect.Struct {
+		return errors.New("session: BindSession requires a non-nil pointer to struct")
+	}
+	objV = objV.Elem()
+	objT := objV.Type()
+	for i := 0; i < objT.NumField(); i++ {
+		field := objT.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := field.Name
+		if tag, ok := field.Tag.Lookup("session"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				key = tag
+			}
+		}
+		raw := store.Get(ctx, key)
+		if raw == nil {
+			continue
+		}
+		v, ok := convert(raw, field.Type)
+		if !ok {
+			return fmt.Errorf("session: can't bind %T of key %q to field %s %s", raw, key, field.Name, field.Type)
+		}
+		objV.Field(i).Set(v)
+	}
+	return nil
+}
+
+// convert converts v to t, see Get
+func convert(v interface{}, t reflect.Type) (reflect.Value, bool) {
+	if v == nil {
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(t) {
+		out := reflect.New(t).Elem()
+		out.Set(rv)
+		return out, true
+	}
+	if isNumber(rv.Kind()) && isNumber(t.Kind()) {
+		out := rv.Convert(t)
+		// the number is out of the range of t or has the fraction
+		if !out.Convert(rv.Type()).Equal(rv) {
+			return reflect.Value{}, false
+		}
+		return out, true
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return reflect.Value{}, false
+		}
+		out := reflect.New(t)
+		if err = json.Unmarshal(b, out.Interface()); err != nil {
+			return reflect.Value{}, false
+		}
+		return out.Elem(), true
+	}
+	return reflect.Value{}, false
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
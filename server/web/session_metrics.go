@@ -0,0 +1,75 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sessionCollector exports the statistics of the GC of GlobalSessions, nothing is exported if the session is disabled
+type sessionCollector struct {
+	active       *prometheus.Desc
+	runs         *prometheus.Desc
+	expired      *prometheus.Desc
+	lastExpired  *prometheus.Desc
+	lastDuration *prometheus.Desc
+	lastRun      *prometheus.Desc
+}
+
+func newSessionCollector() *sessionCollector {
+	return &sessionCollector{
+		active: prometheus.NewDesc("beego_session_active",
+			"The number of active sessions after the last GC sweep", nil, nil),
+		runs: prometheus.NewDesc("beego_session_gc_runs_total",
+			"The number of session GC sweeps", nil, nil),
+		expired: prometheus.NewDesc("beego_session_gc_expired_total",
+			"The number of sessions removed by the GC", nil, nil),
+		lastExpired: prometheus.NewDesc("beego_session_gc_last_expired",
+			"The number of sessions removed by the last GC sweep, -1 if the provider doesn't report it", nil, nil),
+		lastDuration: prometheus.NewDesc("beego_session_gc_last_duration_seconds",
+			"The duration of the last GC sweep", nil, nil),
+		lastRun: prometheus.NewDesc("beego_session_gc_last_run_timestamp_seconds",
+			"When the last GC sweep started", nil, nil),
+	}
+}
+
+func (c *sessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+	ch <- c.runs
+	ch <- c.expired
+	ch <- c.lastExpired
+	ch <- c.lastDuration
+	ch <- c.lastRun
+}
+
+func (c *sessionCollector) Collect(ch chan<- prometheus.Metric) {
+	if GlobalSessions == nil {
+		return
+	}
+	s := GlobalSessions.GCStats()
+	if s.Runs == 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(s.Active))
+	ch <- prometheus.MustNewConstMetric(c.runs, prometheus.CounterValue, float64(s.Runs))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(s.Expired))
+	ch <- prometheus.MustNewConstMetric(c.lastExpired, prometheus.GaugeValue, float64(s.LastExpired))
+	ch <- prometheus.MustNewConstMetric(c.lastDuration, prometheus.GaugeValue, s.LastDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.lastRun, prometheus.GaugeValue, float64(s.LastRun.UnixNano())/1e9)
+}
+
+func init() {
+	MetricsRegistry.MustRegister(newSessionCollector())
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+// ShutdownHook is executed after the server stops serving,
+// for example, closing the database pools, flushing the logs and stopping the tasks
+type ShutdownHook func(ctx context.Context) error
+
+// OnShutdown see HttpServer.OnShutdown
+func OnShutdown(hooks ...ShutdownHook) *HttpServer {
+	return BeeApp.OnShutdown(hooks...)
+}
+
+// OnShutdown registers the hooks which are executed in order when the server is shut down.
+// The ctx of hooks is the ctx passed to Shutdown, so the hooks should respect its deadline
+// usage:
+//
+//	web.OnShutdown(func(ctx context.Context) error {
+//		logs.GetBeeLogger().Flush()
+//		return nil
+//	})
+func (app *HttpServer) OnShutdown(hooks ...ShutdownHook) *HttpServer {
+	app.shutdownHooks = append(app.shutdownHooks, hooks...)
+	return app
+}
+
+// Ready reports whether the server is ready to accept the requests,
+// it becomes false once the server starts shutting down
+func (app *HttpServer) Ready() bool {
+	return !app.draining.Load()
+}
+
+// Shutdown shuts down the server gracefully by phases:
+// flipping the readiness, waiting Listen.ShutdownDelay, stopping accepting
+// and waiting for the in-flight requests until ctx is done, and running the OnShutdown hooks.
+// The connections are closed if ctx is done before the requests finish.
+// Calling it more than once waits for the first call and returns its result.
+// In Graceful mode, the server is shut down by the signals instead
+func (app *HttpServer) Shutdown(ctx context.Context) error {
+	app.shutdownOnce.Do(func() {
+		app.shutdownErr = app.shutdown(ctx)
+		close(app.shutdownDone)
+	})
+	<-app.shutdownDone
+	return app.shutdownErr
+}
+
+func (app *HttpServer) shutdown(ctx context.Context) error {
+	app.drain(ctx)
+	for _, callback := range app.LifeCycleCallbacks {
+		callback.BeforeShutdown(app)
+	}
+	var errs []error
+	if err := app.Server.Shutdown(ctx); err != nil {
+		logs.Error("failed to wait for the in-flight requests: %v", err)
+		errs = append(errs, err, app.Server.Close())
+	}
+	errs = append(errs, app.runShutdownHooks(ctx))
+	return errors.Join(errs...)
+}
+
+// drain flips the readiness and waits for the load balancer to remove the server
+func (app *HttpServer) drain(ctx context.Context) {
+	app.draining.Store(true)
+	delay := time.Duration(app.Cfg.Listen.ShutdownDelay) * time.Second
+	if delay <= 0 {
+		return
+	}
+	logs.Info("server is not ready, waiting %s before draining", delay)
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+func (app *HttpServer) runShutdownHooks(ctx context.Context) error {
+	var errs []error
+	for _, hook := range app.shutdownHooks {
+		if err := hook(ctx); err != nil {
+			logs.Error("shutdown hook failed: %v", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// shutdownContext returns the context whose deadline is Listen.ShutdownTimeout
+func (app *HttpServer) shutdownContext() (context.Context, context.CancelFunc) {
+	if app.Cfg.Listen.ShutdownTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(app.Cfg.Listen.ShutdownTimeout)*time.Second)
+}
+
+// handleShutdownSignals shuts down the server when receiving SIGINT or SIGTERM
+func (app *HttpServer) handleShutdownSignals() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		signal.Stop(sigChan)
+		logs.Info("received %v, shutting down the server", sig)
+		ctx, cancel := app.shutdownContext()
+		defer cancel()
+		if err := app.Shutdown(ctx); err != nil {
+			logs.Error("shutdown: %v", err)
+		}
+	}()
+}
+
+// waitShutdown waits for Shutdown to finish if the server is closed by it
+func (app *HttpServer) waitShutdown(err error) bool {
+	if !errors.Is(err, http.ErrServerClosed) || !app.draining.Load() {
+		return false
+	}
+	<-app.shutdownDone
+	return true
+}
+
+// serveReadiness responds 200 if the server is ready, otherwise 503
+func (app *HttpServer) serveReadiness(ctx *beecontext.Context) {
+	if !app.Ready() {
+		ctx.Output.SetStatus(http.StatusServiceUnavailable)
+		_ = ctx.Output.Body([]byte("shutting down"))
+		return
+	}
+	_ = ctx.Output.Body([]byte("ok"))
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+func TestHttpServerShutdown(t *testing.T) {
+	cfg := *BConfig
+	app := NewHttpServerWithCfg(&cfg)
+	started := make(chan struct{})
+	app.Handlers.Get("/slow", func(ctx *beecontext.Context) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_ = ctx.Output.Body([]byte("done"))
+	})
+	app.Handlers.Get("/ready", app.serveReadiness)
+
+	var order []string
+	errHook := errors.New("hook failed")
+	app.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "orm")
+		return nil
+	}, func(ctx context.Context) error {
+		order = append(order, "logs")
+		return errHook
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	app.Server.Handler = app.Handlers
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- app.Server.Serve(ln)
+	}()
+
+	w := httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+
+	err = app.Shutdown(context.Background())
+	assert.True(t, errors.Is(err, errHook))
+	assert.Equal(t, "done", <-body)
+	assert.Equal(t, []string{"orm", "logs"}, order)
+	assert.False(t, app.Ready())
+	assert.True(t, app.waitShutdown(<-serveErr))
+
+	w = httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// the second call returns the result of first call
+	assert.True(t, errors.Is(app.Shutdown(context.Background()), errHook))
+}
+
+func TestHttpServerShutdownBeforeRun(t *testing.T) {
+	cfg := *BConfig
+	app := NewHttpServerWithCfg(&cfg)
+	hooked := false
+	app.OnShutdown(func(ctx context.Context) error {
+		hooked = true
+		return nil
+	})
+
+	assert.NotPanics(t, func() {
+		assert.Nil(t, app.Shutdown(context.Background()))
+	})
+	assert.True(t, hooked)
+	assert.False(t, app.Ready())
+	// Run started after Shutdown returns at once
+	assert.True(t, app.waitShutdown(http.ErrServerClosed))
+	assert.Nil(t, app.Shutdown(context.Background()))
+}
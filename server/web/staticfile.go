@@ -34,12 +34,15 @@ import (
 
 var errNotStaticRequest = errors.New("request not a static file request")
 
+// the url prefix => file system, see SetStaticFS
+var staticFS = map[string]http.FileSystem{}
+
 func serverStaticRouter(ctx *context.Context) {
 	if ctx.Input.Method() != "GET" && ctx.Input.Method() != "HEAD" {
 		return
 	}
 
-	fbd, filePath, fileInfo, err := lookupFile(ctx)
+	fbd, fsys, filePath, fileInfo, err := lookupFile(ctx)
 	if err == errNotStaticRequest {
 		return
 	}
@@ -66,12 +69,12 @@ func serverStaticRouter(ctx *context.Context) {
 			ctx.Redirect(302, redirectURL)
 		} else {
 			// serveFile will list dir
-			http.ServeFile(ctx.ResponseWriter, ctx.Request, filePath)
+			serveFile(ctx, fsys, filePath, fileInfo)
 		}
 		return
 	} else if fileInfo.Size() > int64(BConfig.WebConfig.StaticCacheFileSize) {
 		// over size file serve with http module
-		http.ServeFile(ctx.ResponseWriter, ctx.Request, filePath)
+		serveFile(ctx, fsys, filePath, fileInfo)
 		return
 	}
 
@@ -80,7 +83,7 @@ func serverStaticRouter(ctx *context.Context) {
 	if enableCompress {
 		acceptEncoding = context.ParseEncoding(ctx.Request)
 	}
-	b, n, sch, reader, err := openFile(filePath, fileInfo, acceptEncoding)
+	b, n, sch, reader, err := openFile(fsys, filePath, fileInfo, acceptEncoding)
 	if err != nil {
 		if BConfig.RunMode == DEV {
 			logs.Warn("Can't compress the file:", filePath, err)
@@ -115,7 +118,28 @@ var (
 	lruLock            sync.RWMutex
 )
 
-func openFile(filePath string, fi os.FileInfo, acceptEncoding string) (bool, string, *serveContentHolder, *serveContentReader, error) {
+// serveFile serves the file or lists the directory by net/http
+func serveFile(ctx *context.Context, fsys http.FileSystem, filePath string, fi os.FileInfo) {
+	if _, ok := fsys.(FileSystem); ok {
+		http.ServeFile(ctx.ResponseWriter, ctx.Request, filePath)
+		return
+	}
+	if fi.IsDir() {
+		r := ctx.Request.Clone(ctx.Request.Context())
+		r.URL.Path = strings.TrimSuffix(filePath, "/") + "/"
+		http.FileServer(fsys).ServeHTTP(ctx.ResponseWriter, r)
+		return
+	}
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		http.NotFound(ctx.ResponseWriter, ctx.Request)
+		return
+	}
+	defer file.Close()
+	http.ServeContent(ctx.ResponseWriter, ctx.Request, filePath, fi.ModTime(), file)
+}
+
+func openFile(fsys http.FileSystem, filePath string, fi os.FileInfo, acceptEncoding string) (bool, string, *serveContentHolder, *serveContentReader, error) {
 	if staticFileLruCache == nil {
 		// avoid lru cache error
 		if BConfig.WebConfig.StaticCacheFileNum >= 1 {
@@ -141,13 +165,13 @@ func openFile(filePath string, fi os.FileInfo, acceptEncoding string) (bool, str
 		mapFile = cacheItem.(*serveContentHolder)
 	}
 	if !isOk(mapFile, fi) {
-		file, err := os.Open(filePath)
+		file, err := fsys.Open(filePath)
 		if err != nil {
 			return false, "", nil, nil, err
 		}
 		defer file.Close()
 		var bufferWriter bytes.Buffer
-		_, n, err := context.WriteFile(acceptEncoding, &bufferWriter, file)
+		_, n, err := context.WriteReader(acceptEncoding, &bufferWriter, file)
 		if err != nil {
 			return false, "", nil, nil, err
 		}
@@ -180,23 +204,42 @@ func isStaticCompress(filePath string) bool {
 	return false
 }
 
+// statFile returns the FileInfo of the file in fsys
+func statFile(fsys http.FileSystem, name string) (os.FileInfo, error) {
+	if _, ok := fsys.(FileSystem); ok {
+		return os.Stat(name)
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Stat()
+}
+
 // searchFile search the file by url path
 // if none the static file prefix matches ,return notStaticRequestErr
-func searchFile(ctx *context.Context) (string, os.FileInfo, error) {
+func searchFile(ctx *context.Context) (http.FileSystem, string, os.FileInfo, error) {
 	requestPath := filepath.ToSlash(filepath.Clean(ctx.Request.URL.Path))
 	// special processing : favicon.ico/robots.txt  can be in any static dir
 	if requestPath == "/favicon.ico" || requestPath == "/robots.txt" {
 		file := path.Join(".", requestPath)
 		if fi, _ := os.Stat(file); fi != nil {
-			return file, fi, nil
+			return FileSystem{}, file, fi, nil
 		}
 		for _, staticDir := range BConfig.WebConfig.StaticDir {
 			filePath := path.Join(staticDir, requestPath)
 			if fi, _ := os.Stat(filePath); fi != nil {
-				return filePath, fi, nil
+				return FileSystem{}, filePath, fi, nil
 			}
 		}
-		return "", nil, errNotStaticRequest
+		for prefix, fsys := range staticFS {
+			filePath := path.Join(prefix, requestPath)
+			if fi, _ := statFile(fsys, filePath); fi != nil {
+				return fsys, filePath, fi, nil
+			}
+		}
+		return nil, "", nil, errNotStaticRequest
 	}
 
 	for prefix, staticDir := range BConfig.WebConfig.StaticDir {
@@ -208,28 +251,42 @@ func searchFile(ctx *context.Context) (string, os.FileInfo, error) {
 		}
 		filePath := path.Join(staticDir, requestPath[len(prefix):])
 		if fi, err := os.Stat(filePath); fi != nil {
-			return filePath, fi, err
+			return FileSystem{}, filePath, fi, err
+		}
+	}
+	for prefix, fsys := range staticFS {
+		if !strings.HasPrefix(requestPath, prefix) {
+			continue
+		}
+		if prefix != "/" && len(requestPath) > len(prefix) && requestPath[len(prefix)] != '/' {
+			continue
+		}
+		if fi, err := statFile(fsys, requestPath); fi != nil {
+			return fsys, requestPath, fi, err
 		}
 	}
-	return "", nil, errNotStaticRequest
+	return nil, "", nil, errNotStaticRequest
 }
 
 // lookupFile find the file to serve
 // if the file is dir ,search the index.html as default file( MUST NOT A DIR also)
 // if the index.html not exist or is a dir, give a forbidden response depending on  DirectoryIndex
-func lookupFile(ctx *context.Context) (bool, string, os.FileInfo, error) {
-	fp, fi, err := searchFile(ctx)
+func lookupFile(ctx *context.Context) (bool, http.FileSystem, string, os.FileInfo, error) {
+	fsys, fp, fi, err := searchFile(ctx)
 	if fp == "" || fi == nil {
-		return false, "", nil, err
+		return false, nil, "", nil, err
 	}
 	if !fi.IsDir() {
-		return false, fp, fi, err
+		return false, fsys, fp, fi, err
 	}
 	if requestURL := ctx.Input.URL(); requestURL[len(requestURL)-1] == '/' {
 		ifp := filepath.Join(fp, "index.html")
-		if ifi, _ := os.Stat(ifp); ifi != nil && ifi.Mode().IsRegular() {
-			return false, ifp, ifi, err
+		if _, ok := fsys.(FileSystem); !ok {
+			ifp = path.Join(fp, "index.html")
+		}
+		if ifi, _ := statFile(fsys, ifp); ifi != nil && ifi.Mode().IsRegular() {
+			return false, fsys, ifp, ifi, err
 		}
 	}
-	return !BConfig.WebConfig.DirectoryIndex, fp, fi, err
+	return !BConfig.WebConfig.DirectoryIndex, fsys, fp, fi, err
 }
@@ -6,9 +6,15 @@ import (
 	"compress/zlib"
 	"fmt"
 	"io"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web/context"
 )
 
 var (
@@ -18,7 +24,7 @@ var (
 
 func testOpenFile(encoding string, content []byte, t *testing.T) {
 	fi, _ := os.Stat(licenseFile)
-	b, n, sch, reader, err := openFile(licenseFile, fi, encoding)
+	b, n, sch, reader, err := openFile(FileSystem{}, licenseFile, fi, encoding)
 	if err != nil {
 		t.Log(err)
 		t.Fail()
@@ -62,13 +68,13 @@ func TestStaticCacheWork(t *testing.T) {
 
 	fi, _ := os.Stat(licenseFile)
 	for _, encoding := range encodings {
-		_, _, first, _, err := openFile(licenseFile, fi, encoding)
+		_, _, first, _, err := openFile(FileSystem{}, licenseFile, fi, encoding)
 		if err != nil {
 			t.Error(err)
 			continue
 		}
 
-		_, _, second, _, err := openFile(licenseFile, fi, encoding)
+		_, _, second, _, err := openFile(FileSystem{}, licenseFile, fi, encoding)
 		if err != nil {
 			t.Error(err)
 			continue
@@ -82,6 +88,53 @@ func TestStaticCacheWork(t *testing.T) {
 	}
 }
 
+func TestSetStaticFS(t *testing.T) {
+	SetStaticFS("/assets/", fstest.MapFS{
+		"app.js":          {Data: bytes.Repeat([]byte("var beego = 1;\n"), 10)},
+		"dir/index.html":  {Data: []byte("<html></html>")},
+		"empty/README.md": {Data: []byte("readme")},
+	})
+	defer DelStaticPath("/assets")
+	enableGzip := BConfig.EnableGzip
+	BConfig.EnableGzip = true
+	context.InitGzip(-1, -1, nil)
+	defer func() {
+		BConfig.EnableGzip = enableGzip
+	}()
+
+	serve := func(url string, encoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", url, nil)
+		r.Header.Set("Accept-Encoding", encoding)
+		ctx := context.NewContext()
+		ctx.Reset(w, r)
+		serverStaticRouter(ctx)
+		return w
+	}
+
+	w := serve("/assets/app.js", "")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, bytes.Repeat([]byte("var beego = 1;\n"), 10), w.Body.Bytes())
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+
+	w = serve("/assets/app.js", "gzip")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	data, _ := io.ReadAll(zr)
+	assert.Equal(t, bytes.Repeat([]byte("var beego = 1;\n"), 10), data)
+
+	w = serve("/assets/dir/", "")
+	assert.Equal(t, "<html></html>", w.Body.String())
+
+	w = serve("/assets/empty/", "")
+	assert.Equal(t, 403, w.Code)
+
+	// it's not a static file request, so it will be handled by routers
+	w = serve("/assets/missing.js", "")
+	assert.Empty(t, w.Body.String())
+}
+
 func assetOpenFileAndContent(sch *serveContentHolder, reader *serveContentReader, content []byte, t *testing.T) {
 	t.Log(sch.size, len(content))
 	if sch.size != int64(len(content)) {
@@ -0,0 +1,118 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+// TemplateErrorTrailer is the trailer carrying the template error which occurs
+// after the streamed response has been started
+const TemplateErrorTrailer = "X-Template-Error"
+
+// RenderStream executes the template against the response writer and flushes the output
+// every WebConfig.StreamRenderFlushSize bytes or WebConfig.StreamRenderFlushInterval milliseconds,
+// so the client receives the beginning of large pages before the whole page is rendered.
+// The content and sections of layout are still buffered, only the outermost template is streamed.
+// If the template fails before anything is flushed, the error is returned and nothing is sent.
+// Otherwise the status and part of the page have been sent, the error is returned
+// and reported to the client by the X-Template-Error trailer.
+// The output is written to the response writer directly instead of Output.Body,
+// so it's neither compressed by EnableGzip nor tagged by EnableETag.
+// The automatic rendering is disabled after it's called.
+func (c *Controller) RenderStream() error {
+	c.EnableRender = false
+	var name string
+	if c.Layout != "" {
+		buf, err := c.renderTemplate()
+		if err != nil {
+			return err
+		}
+		if err = c.renderLayoutData(&buf); err != nil {
+			return err
+		}
+		name = c.Layout
+	} else {
+		c.prepareTemplate()
+		name = c.TplName
+	}
+
+	if c.Ctx.ResponseWriter.Header().Get("Content-Type") == "" {
+		c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	}
+	sw := newStreamWriter(c.Ctx, BConfig.WebConfig.StreamRenderFlushSize,
+		time.Duration(BConfig.WebConfig.StreamRenderFlushInterval)*time.Millisecond)
+	err := ExecuteViewPathTemplate(sw, name, c.viewPath(), c.Data)
+	if err != nil && !sw.started {
+		return err
+	}
+	if flushErr := sw.flush(); err == nil {
+		err = flushErr
+	} else {
+		msg := strings.NewReplacer("\r", " ", "\n", " ").Replace(err.Error())
+		c.Ctx.ResponseWriter.Header().Set(http.TrailerPrefix+TemplateErrorTrailer, msg)
+	}
+	return err
+}
+
+// streamWriter buffers the output and flushes it to the response by size and interval
+type streamWriter struct {
+	ctx       *beecontext.Context
+	buf       bytes.Buffer
+	size      int
+	interval  time.Duration
+	lastFlush time.Time
+	started   bool
+}
+
+func newStreamWriter(ctx *beecontext.Context, size int, interval time.Duration) *streamWriter {
+	return &streamWriter{
+		ctx:       ctx,
+		size:      size,
+		interval:  interval,
+		lastFlush: time.Now(),
+	}
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	n, _ := w.buf.Write(p)
+	if w.buf.Len() >= w.size || (w.interval > 0 && time.Since(w.lastFlush) >= w.interval) {
+		return n, w.flush()
+	}
+	return n, nil
+}
+
+// flush writes the buffered output to the response and flushes it
+func (w *streamWriter) flush() error {
+	w.lastFlush = time.Now()
+	if !w.started {
+		w.started = true
+		if w.ctx.Output.Status != 0 {
+			w.ctx.ResponseWriter.WriteHeader(w.ctx.Output.Status)
+		}
+	}
+	if w.buf.Len() > 0 {
+		if _, err := w.buf.WriteTo(w.ctx.ResponseWriter); err != nil {
+			return err
+		}
+	}
+	w.ctx.ResponseWriter.Flush()
+	return nil
+}
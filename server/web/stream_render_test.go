@@ -0,0 +1,89 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var streamViewPath string
+
+type streamController struct {
+	Controller
+}
+
+func (c *streamController) Get() {
+	c.ViewPath = streamViewPath
+	c.TplName = c.GetString("tpl")
+	c.Data["Rows"] = strings.Repeat("x", 100)
+	c.Data["Fail"] = func() (string, error) {
+		return "", errors.New("broken\nrow")
+	}
+}
+
+func TestControllerRenderStream(t *testing.T) {
+	streamViewPath = filepath.Join(t.TempDir(), "TestControllerRenderStream")
+	writeTemplateFile(t, streamViewPath, "ok.tpl", `<p>{{.Rows}}</p>`, time.Now())
+	writeTemplateFile(t, streamViewPath, "late.tpl", `<p>{{.Rows}}</p>{{call .Fail}}`, time.Now())
+	writeTemplateFile(t, streamViewPath, "early.tpl", `{{call .Fail}}<p>{{.Rows}}</p>`, time.Now())
+	require.Nil(t, AddViewPath(streamViewPath))
+
+	webCfg := BConfig.WebConfig
+	defer func() { BConfig.WebConfig = webCfg }()
+	BConfig.WebConfig.EnableStreamRender = true
+	BConfig.WebConfig.StreamRenderFlushSize = 10
+
+	cfg := *BConfig
+	app := NewHttpServerWithCfg(&cfg)
+	app.Router("/stream", &streamController{})
+	app.Handlers.Init()
+	server := httptest.NewServer(app.Handlers)
+	defer server.Close()
+
+	get := func(tpl string) (*http.Response, string) {
+		resp, err := http.Get(server.URL + "/stream?tpl=" + tpl)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp, string(body)
+	}
+
+	resp, body := get("ok.tpl")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "<p>"+strings.Repeat("x", 100)+"</p>", body)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, int64(-1), resp.ContentLength)
+	assert.Empty(t, resp.Trailer.Get(TemplateErrorTrailer))
+
+	resp, body = get("late.tpl")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "<p>"+strings.Repeat("x", 100)+"</p>", body)
+	assert.Contains(t, resp.Trailer.Get(TemplateErrorTrailer), "broken row")
+
+	resp, body = get("early.tpl")
+	assert.Empty(t, body)
+	assert.Empty(t, resp.Trailer.Get(TemplateErrorTrailer))
+}
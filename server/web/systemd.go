@@ -0,0 +1,136 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// the names of listeners, which are set by FileDescriptorName of systemd socket unit
+const (
+	SystemdListenerHTTP  = "http"
+	SystemdListenerHTTPS = "https"
+	SystemdListenerAdmin = "admin"
+)
+
+// the first file descriptor passed by systemd
+const systemdListenFdsStart = 3
+
+// SystemdListener is the listener passed by systemd socket activation
+type SystemdListener struct {
+	net.Listener
+	// Name is the FileDescriptorName of socket unit
+	Name string
+}
+
+var systemdSockets struct {
+	once      sync.Once
+	mutex     sync.Mutex
+	listeners []SystemdListener
+	taken     []bool
+	err       error
+}
+
+// SystemdListeners returns the listeners passed by systemd socket activation,
+// or nil if the process isn't activated by socket.
+// The environment variables are unset so that the child processes don't inherit them
+func SystemdListeners() ([]SystemdListener, error) {
+	systemdSockets.once.Do(func() {
+		systemdSockets.listeners, systemdSockets.err = systemdListeners()
+		systemdSockets.taken = make([]bool, len(systemdSockets.listeners))
+	})
+	return systemdSockets.listeners, systemdSockets.err
+}
+
+func systemdListeners() ([]SystemdListener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	n, names, err := parseSystemdEnv(os.Getpid(), os.Getenv)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	listeners := make([]SystemdListener, 0, n)
+	for i := 0; i < n; i++ {
+		f := os.NewFile(uintptr(systemdListenFdsStart+i), names[i])
+		l, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("the file descriptor %d passed by systemd isn't a listener: %w", systemdListenFdsStart+i, err)
+		}
+		listeners = append(listeners, SystemdListener{Listener: l, Name: names[i]})
+	}
+	return listeners, nil
+}
+
+// parseSystemdEnv returns the number of file descriptors and their names
+func parseSystemdEnv(pid int, getenv func(string) string) (int, []string, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return 0, nil, nil
+	}
+	n, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || n < 0 {
+		return 0, nil, fmt.Errorf("invalid LISTEN_FDS %q", getenv("LISTEN_FDS"))
+	}
+	names := make([]string, n)
+	if fdNames := getenv("LISTEN_FDNAMES"); fdNames != "" {
+		copy(names, strings.Split(fdNames, ":"))
+	}
+	return n, names, nil
+}
+
+// takeSystemdListener returns the listener with the name, each listener is returned only once.
+// The first unnamed listener or the one with unknown name is used as http listener
+func takeSystemdListener(name string) (net.Listener, error) {
+	listeners, err := SystemdListeners()
+	if err != nil {
+		return nil, err
+	}
+	systemdSockets.mutex.Lock()
+	defer systemdSockets.mutex.Unlock()
+	fallback := -1
+	for i, l := range listeners {
+		if systemdSockets.taken[i] {
+			continue
+		}
+		if l.Name == name {
+			systemdSockets.taken[i] = true
+			return l, nil
+		}
+		if fallback < 0 && name == SystemdListenerHTTP &&
+			l.Name != SystemdListenerHTTPS && l.Name != SystemdListenerAdmin {
+			fallback = i
+		}
+	}
+	if fallback >= 0 {
+		systemdSockets.taken[fallback] = true
+		return listeners[fallback], nil
+	}
+	return nil, nil
+}
+
+func (app *HttpServer) systemdListenerName() string {
+	if app.systemdName != "" {
+		return app.systemdName
+	}
+	return SystemdListenerHTTP
+}
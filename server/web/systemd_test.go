@@ -0,0 +1,77 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSystemdEnv(t *testing.T) {
+	env := map[string]string{
+		"LISTEN_PID":     "100",
+		"LISTEN_FDS":     "3",
+		"LISTEN_FDNAMES": "admin:http",
+	}
+	n, names, err := parseSystemdEnv(100, func(k string) string { return env[k] })
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []string{"admin", "http", ""}, names)
+
+	// passed to another process
+	n, _, err = parseSystemdEnv(101, func(k string) string { return env[k] })
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	env["LISTEN_FDS"] = "x"
+	_, _, err = parseSystemdEnv(100, func(k string) string { return env[k] })
+	assert.NotNil(t, err)
+}
+
+func TestTakeSystemdListener(t *testing.T) {
+	newListener := func(name string) SystemdListener {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		return SystemdListener{Listener: l, Name: name}
+	}
+	_, _ = SystemdListeners()
+	systemdSockets.listeners = []SystemdListener{newListener("admin"), newListener("")}
+	systemdSockets.taken = make([]bool, 2)
+	defer func() {
+		for _, l := range systemdSockets.listeners {
+			_ = l.Close()
+		}
+		systemdSockets.listeners, systemdSockets.taken = nil, nil
+	}()
+
+	l, err := takeSystemdListener(SystemdListenerHTTPS)
+	assert.Nil(t, err)
+	assert.Nil(t, l)
+
+	l, err = takeSystemdListener(SystemdListenerHTTP)
+	assert.Nil(t, err)
+	assert.Equal(t, systemdSockets.listeners[1].Addr(), l.Addr())
+
+	l, err = takeSystemdListener(SystemdListenerAdmin)
+	assert.Nil(t, err)
+	assert.Equal(t, systemdSockets.listeners[0].Addr(), l.Addr())
+
+	// each listener is taken only once
+	l, err = takeSystemdListener(SystemdListenerHTTP)
+	assert.Nil(t, err)
+	assert.Nil(t, l)
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -58,6 +59,13 @@ func ExecuteViewPathTemplate(wr io.Writer, name string, viewPath string, data in
 		templatesLock.RLock()
 		defer templatesLock.RUnlock()
 	}
+	if engine, ok := beeViewPathEngines[viewPath][name]; ok {
+		err := engine.ExecuteTemplate(wr, viewPath, name, data)
+		if err != nil {
+			logs.Trace("template Execute err:", err)
+		}
+		return err
+	}
 	if beeTemplates, ok := beeViewPathTemplates[viewPath]; ok {
 		if t, ok := beeTemplates[name]; ok {
 			var err error
@@ -102,7 +110,8 @@ func init() {
 	beegoTplFuncMap["lt"] = lt // <
 	beegoTplFuncMap["ne"] = ne // !=
 
-	beegoTplFuncMap["urlfor"] = URLFor // build an URL to match a Controller and it's method
+	beegoTplFuncMap["urlfor"] = URLFor         // build an URL to match a Controller and it's method
+	beegoTplFuncMap["reverseurl"] = reverseURL // like urlfor, but it fails if the params don't match the router
 }
 
 // AddFuncMap let user to register a func in the template.
@@ -212,6 +221,17 @@ func BuildTemplate(dir string, files ...string) error {
 			if buildAllFiles || utils.InSlice(file, files) {
 				templatesLock.Lock()
 				ext := filepath.Ext(file)
+				if len(ext) > 0 {
+					if ok, err := compileByEngine(fs, self.root, file, ext[1:]); ok {
+						templatesLock.Unlock()
+						if err != nil {
+							logs.Error("parse template err:", file, err)
+							return err
+						}
+						recordTemplateModTimes(fs, self.root, file, nil)
+						continue
+					}
+				}
 				var t *template.Template
 				if len(ext) == 0 {
 					t, err = getTemplate(self.root, fs, file, v...)
@@ -226,6 +246,7 @@ func BuildTemplate(dir string, files ...string) error {
 					return err
 				}
 				beeTemplates[file] = t
+				recordTemplateModTimes(fs, self.root, file, t)
 				templatesLock.Unlock()
 			}
 		}
@@ -391,6 +412,27 @@ func DelStaticPath(url string) *HttpServer {
 		url = strings.TrimRight(url, "/")
 	}
 	delete(BConfig.WebConfig.StaticDir, url)
+	delete(staticFS, url)
+	return BeeApp
+}
+
+// SetStaticFS serves the files in fsys with url pattern in beego application,
+// it works like SetStaticPath, and it's useful to serve the files embedded into binary.
+// usage:
+//
+//	//go:embed static
+//	var staticFiles embed.FS
+//
+//	sub, _ := fs.Sub(staticFiles, "static")
+//	web.SetStaticFS("/static", sub)
+func SetStaticFS(url string, fsys fs.FS) *HttpServer {
+	if !strings.HasPrefix(url, "/") {
+		url = "/" + url
+	}
+	if url != "/" {
+		url = strings.TrimRight(url, "/")
+	}
+	staticFS[url] = newStaticFileSystem(url, fsys)
 	return BeeApp
 }
 
@@ -0,0 +1,129 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jet renders the templates by Jet, https://github.com/CloudyKit/jet
+//
+// Usage:
+//
+//	import "github.com/beego/beego/v2/server/web/template/jet"
+//
+//	web.RegisterTemplateEngine("jet", jet.NewEngine())
+//
+// The Data of controller are the variables of template, {{ Title }} for example,
+// and the template paths are relative to the view path, like {{ extends "/layout.jet" }}
+package jet
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+
+	jetlib "github.com/CloudyKit/jet/v6"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+var _ web.TemplateEngine = (*Engine)(nil)
+
+// Engine is the TemplateEngine of Jet, a Jet set is created for each view path
+type Engine struct {
+	opts  []jetlib.Option
+	mutex sync.RWMutex
+	sets  map[string]*jetlib.Set
+	dev   bool
+}
+
+// NewEngine creates the engine, the options are passed to the Jet sets
+func NewEngine(opts ...jetlib.Option) *Engine {
+	return &Engine{
+		opts: opts,
+		sets: make(map[string]*jetlib.Set),
+	}
+}
+
+// Compile implements web.TemplateEngine
+func (e *Engine) Compile(fs http.FileSystem, root, name string, funcs template.FuncMap) error {
+	e.mutex.Lock()
+	set, ok := e.sets[root]
+	if !ok {
+		opts := e.opts
+		if e.dev {
+			opts = append(opts[:len(opts):len(opts)], jetlib.InDevelopmentMode())
+		}
+		set = jetlib.NewSet(&loader{fs: fs, root: root}, opts...)
+		for k, fn := range funcs {
+			set.AddGlobal(k, fn)
+		}
+		e.sets[root] = set
+	}
+	e.mutex.Unlock()
+	_, err := set.GetTemplate(name)
+	return err
+}
+
+// ExecuteTemplate implements web.TemplateEngine
+func (e *Engine) ExecuteTemplate(wr io.Writer, root, name string, data interface{}) error {
+	e.mutex.RLock()
+	set, ok := e.sets[root]
+	e.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("jet: unknown view path %s", root)
+	}
+	t, err := set.GetTemplate(name)
+	if err != nil {
+		return err
+	}
+	vars := make(jetlib.VarMap)
+	if m, ok := data.(map[interface{}]interface{}); ok {
+		for k, v := range m {
+			if key, ok := k.(string); ok {
+				vars.Set(key, v)
+			}
+		}
+	}
+	return t.Execute(wr, vars, data)
+}
+
+// Watch implements web.TemplateEngine, the sets are recreated in development mode
+// so the templates are reloaded when they change
+func (e *Engine) Watch(enable bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.dev != enable {
+		e.dev = enable
+		e.sets = make(map[string]*jetlib.Set)
+	}
+}
+
+// loader reads the templates in the view path from the file system of beego
+type loader struct {
+	fs   http.FileSystem
+	root string
+}
+
+func (l *loader) Exists(templatePath string) bool {
+	f, err := l.fs.Open(path.Join(l.root, templatePath))
+	if err != nil {
+		return false
+	}
+	_ = f.Close()
+	return true
+}
+
+func (l *loader) Open(templatePath string) (io.ReadCloser, error) {
+	return l.fs.Open(path.Join(l.root, templatePath))
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pongo2 renders the Django-syntax templates by Pongo2, https://github.com/flosch/pongo2
+//
+// Usage:
+//
+//	import "github.com/beego/beego/v2/server/web/template/pongo2"
+//
+//	web.RegisterTemplateEngine("pongo2", pongo2.NewEngine())
+//
+// The Data of controller are the context of template, {{ Title }} for example,
+// and the template paths are relative to the including template, like {% extends "layout.pongo2" %}
+package pongo2
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+
+	pongo2lib "github.com/flosch/pongo2/v6"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+var _ web.TemplateEngine = (*Engine)(nil)
+
+// Engine is the TemplateEngine of Pongo2, a template set is created for each view path
+type Engine struct {
+	mutex sync.RWMutex
+	sets  map[string]*pongo2lib.TemplateSet
+	dev   bool
+}
+
+// NewEngine creates the engine
+func NewEngine() *Engine {
+	return &Engine{
+		sets: make(map[string]*pongo2lib.TemplateSet),
+	}
+}
+
+// Compile implements web.TemplateEngine
+func (e *Engine) Compile(fs http.FileSystem, root, name string, funcs template.FuncMap) error {
+	e.mutex.Lock()
+	set, ok := e.sets[root]
+	if !ok {
+		set = pongo2lib.NewSet(root, &loader{fs: fs, root: root})
+		for k, fn := range funcs {
+			set.Globals[k] = fn
+		}
+		e.sets[root] = set
+	}
+	// the templates aren't cached in debug mode
+	set.Debug = e.dev
+	e.mutex.Unlock()
+	_, err := set.FromCache(name)
+	return err
+}
+
+// ExecuteTemplate implements web.TemplateEngine
+func (e *Engine) ExecuteTemplate(wr io.Writer, root, name string, data interface{}) error {
+	e.mutex.RLock()
+	set, ok := e.sets[root]
+	e.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("pongo2: unknown view path %s", root)
+	}
+	t, err := set.FromCache(name)
+	if err != nil {
+		return err
+	}
+	ctx := make(pongo2lib.Context)
+	if m, ok := data.(map[interface{}]interface{}); ok {
+		for k, v := range m {
+			if key, ok := k.(string); ok {
+				ctx[key] = v
+			}
+		}
+	}
+	return t.ExecuteWriter(ctx, wr)
+}
+
+// Watch implements web.TemplateEngine, the templates are recompiled when rendering in debug mode
+func (e *Engine) Watch(enable bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.dev = enable
+	for _, set := range e.sets {
+		set.Debug = enable
+	}
+}
+
+// loader reads the templates in the view path from the file system of beego
+type loader struct {
+	fs   http.FileSystem
+	root string
+}
+
+func (l *loader) Abs(base, name string) string {
+	if path.IsAbs(name) || base == "" {
+		return path.Clean("/" + name)
+	}
+	return path.Join(path.Dir(base), name)
+}
+
+func (l *loader) Get(name string) (io.Reader, error) {
+	f, err := l.fs.Open(path.Join(l.root, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pongo2
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+func TestEngine(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "user"), 0o700))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "layout.pongo2"),
+		[]byte(`<title>{% block title %}{% endblock %}</title>{% block body %}{% endblock %}`), 0o600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "user", "index.pongo2"),
+		[]byte(`{% extends "../layout.pongo2" %}{% block title %}{{ Title }}{% endblock %}{% block body %}{{ substr(Body, 0, 5) }}{% endblock %}`), 0o600))
+
+	web.RegisterTemplateEngine("pongo2", NewEngine())
+	assert.Nil(t, web.AddViewPath(dir))
+
+	buf := &bytes.Buffer{}
+	err := web.ExecuteViewPathTemplate(buf, "user/index.pongo2", dir, map[interface{}]interface{}{
+		"Title": "beego",
+		"Body":  "hello world",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "<title>beego</title>hello", buf.String())
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// TemplateEngine renders the templates of the extensions instead of html/template,
+// the adapters of Jet and Pongo2 are in web/template/jet and web/template/pongo2
+type TemplateEngine interface {
+	// Compile parses the template file name in the view path root, so the errors are reported when building.
+	// The files are read from fs, and funcs are the functions registered by AddFuncMap
+	Compile(fs http.FileSystem, root, name string, funcs template.FuncMap) error
+	// ExecuteTemplate renders the template name in the view path root,
+	// data is the Data of controller for the templates rendered by controller
+	ExecuteTemplate(wr io.Writer, root, name string, data interface{}) error
+	// Watch is called with true in dev mode, the engine should reload the templates when they change
+	Watch(enable bool)
+}
+
+var (
+	// beeTemplateEngineAdapters stores associations of extension -> TemplateEngine
+	beeTemplateEngineAdapters = map[string]TemplateEngine{}
+	// beeViewPathEngines stores the templates rendered by TemplateEngine per view
+	beeViewPathEngines = make(map[string]map[string]TemplateEngine)
+)
+
+// RegisterTemplateEngine renders the templates with the extension by the engine,
+// the extension is added to the template extensions
+// usage:
+//
+//	web.RegisterTemplateEngine("jet", jet.NewEngine())
+func RegisterTemplateEngine(extension string, engine TemplateEngine) *HttpServer {
+	AddTemplateExt(extension)
+	beeTemplateEngineAdapters[extension] = engine
+	return BeeApp
+}
+
+// compileByEngine compiles the file if its extension is registered by RegisterTemplateEngine
+func compileByEngine(fs http.FileSystem, root, file, ext string) (bool, error) {
+	engine, ok := beeTemplateEngineAdapters[ext]
+	if !ok {
+		return false, nil
+	}
+	engine.Watch(BConfig.RunMode == DEV)
+	if err := engine.Compile(fs, root, file, beegoTplFuncMap); err != nil {
+		return true, err
+	}
+	engines, ok := beeViewPathEngines[root]
+	if !ok {
+		engines = make(map[string]TemplateEngine)
+		beeViewPathEngines[root] = engines
+	}
+	engines[file] = engine
+	return true, nil
+}
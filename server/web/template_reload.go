@@ -0,0 +1,133 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+var (
+	// templateModTimes stores the modification time of the files which each template depends on,
+	// viewPath -> template file -> dependent file -> modification time
+	templateModTimes     = make(map[string]map[string]map[string]time.Time)
+	templateModTimesLock sync.Mutex
+)
+
+// recordTemplateModTimes records the modification time of the template file and the files it includes
+func recordTemplateModTimes(fs http.FileSystem, root, file string, t *template.Template) {
+	deps := map[string]time.Time{file: templateModTime(fs, root, file)}
+	if t != nil {
+		for _, sub := range t.Templates() {
+			name := sub.Name()
+			if name == file || !HasTemplateExt(name) {
+				continue
+			}
+			if strings.HasPrefix(name, "../") {
+				name = path.Join(path.Dir(file), name)
+			}
+			deps[name] = templateModTime(fs, root, name)
+		}
+	}
+	templateModTimesLock.Lock()
+	defer templateModTimesLock.Unlock()
+	if templateModTimes[root] == nil {
+		templateModTimes[root] = make(map[string]map[string]time.Time)
+	}
+	templateModTimes[root][file] = deps
+}
+
+// changedTemplates returns the files which are modified or never compiled,
+// so that dev mode recompiles them only instead of the whole view path
+func changedTemplates(viewPath string, files ...string) []string {
+	fs := beeTemplateFS()
+	templateModTimesLock.Lock()
+	defer templateModTimesLock.Unlock()
+	changed := make([]string, 0, len(files))
+	for _, file := range files {
+		deps, ok := templateModTimes[viewPath][file]
+		if !ok {
+			changed = append(changed, file)
+			continue
+		}
+		for dep, modTime := range deps {
+			if !templateModTime(fs, viewPath, dep).Equal(modTime) {
+				changed = append(changed, file)
+				break
+			}
+		}
+	}
+	return changed
+}
+
+// templateModTime returns zero time if the file can't be found
+func templateModTime(fs http.FileSystem, root, file string) time.Time {
+	f, err := fs.Open(filepath.Join(root, file))
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reloadTemplates recompiles the modified templates in dev mode
+func reloadTemplates(viewPath string, files ...string) {
+	if BConfig.RunMode != DEV {
+		return
+	}
+	if changed := changedTemplates(viewPath, files...); len(changed) > 0 {
+		if err := BuildTemplate(viewPath, changed...); err != nil {
+			logs.Error("reload template err:", changed, err)
+		}
+	}
+}
+
+// ExecuteViewPathTemplateBlock applies the template defined by {{define "block"}} in the template file name,
+// it's useful for rendering the fragments of page.
+// The templates compiled by TemplateEngine don't support it
+func ExecuteViewPathTemplateBlock(wr io.Writer, name string, block string, viewPath string, data interface{}) error {
+	if BConfig.RunMode == DEV {
+		templatesLock.RLock()
+		defer templatesLock.RUnlock()
+	}
+	if _, ok := beeViewPathEngines[viewPath][name]; ok {
+		return fmt.Errorf("the template %s is compiled by template engine, which doesn't support rendering block", name)
+	}
+	t, ok := beeViewPathTemplates[viewPath][name]
+	if !ok {
+		return fmt.Errorf("can't find templatefile in the path: %s/%s", viewPath, name)
+	}
+	if t.Lookup(block) == nil {
+		return fmt.Errorf("can't find block %s in template %s", block, name)
+	}
+	err := t.ExecuteTemplate(wr, block, data)
+	if err != nil {
+		logs.Trace("template Execute err:", err)
+	}
+	return err
+}
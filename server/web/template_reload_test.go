@@ -0,0 +1,94 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+func writeTemplateFile(t *testing.T, dir, name, content string, modTime time.Time) {
+	file := filepath.Join(dir, name)
+	require.Nil(t, os.MkdirAll(filepath.Dir(file), 0o777))
+	require.Nil(t, os.WriteFile(file, []byte(content), 0o666))
+	require.Nil(t, os.Chtimes(file, modTime, modTime))
+}
+
+func TestReloadTemplates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "TestReloadTemplates")
+	modTime := time.Now().Add(-time.Hour)
+	writeTemplateFile(t, dir, "index.tpl", `<p>{{template "part.tpl" .}}</p>`, modTime)
+	writeTemplateFile(t, dir, "part.tpl", `{{.}}`, modTime)
+	writeTemplateFile(t, dir, "other.tpl", `other`, modTime)
+	require.Nil(t, AddViewPath(dir))
+
+	assert.Empty(t, changedTemplates(dir, "index.tpl", "part.tpl", "other.tpl"))
+	assert.Equal(t, []string{"unknown.tpl"}, changedTemplates(dir, "unknown.tpl"))
+
+	writeTemplateFile(t, dir, "part.tpl", `[{{.}}]`, time.Now())
+	assert.Equal(t, []string{"index.tpl", "part.tpl"}, changedTemplates(dir, "index.tpl", "part.tpl", "other.tpl"))
+
+	runMode := BConfig.RunMode
+	BConfig.RunMode = DEV
+	defer func() { BConfig.RunMode = runMode }()
+	reloadTemplates(dir, "index.tpl")
+	assert.Empty(t, changedTemplates(dir, "index.tpl"))
+	assert.Equal(t, []string{"part.tpl"}, changedTemplates(dir, "part.tpl"))
+
+	ctrl := Controller{TplName: "index.tpl", ViewPath: dir}
+	ctrl.Data = map[interface{}]interface{}{}
+	result, err := ctrl.RenderString()
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>[map[]]</p>", result)
+}
+
+func TestControllerRenderPartial(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "TestControllerRenderPartial")
+	writeTemplateFile(t, dir, "list.tpl",
+		`{{define "rows"}}{{range .}}<li>{{.}}</li>{{end}}{{end}}<ul>{{template "rows" .}}</ul>`, time.Now())
+	require.Nil(t, AddViewPath(dir))
+
+	newController := func() (*Controller, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		ctx := context.NewContext()
+		ctx.Reset(w, httptest.NewRequest("GET", "/", nil))
+		return &Controller{Ctx: ctx, TplName: "list.tpl", ViewPath: dir, EnableRender: true}, w
+	}
+
+	ctrl, w := newController()
+	assert.Nil(t, ctrl.RenderPartial("list.tpl#rows", []string{"a", "b"}))
+	assert.False(t, ctrl.EnableRender)
+	assert.Equal(t, "<li>a</li><li>b</li>", w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+	ctrl, w = newController()
+	assert.Nil(t, ctrl.RenderPartial("#rows", []string{"c"}))
+	assert.Equal(t, "<li>c</li>", w.Body.String())
+
+	ctrl, w = newController()
+	assert.Nil(t, ctrl.RenderPartial("list.tpl", []string{"d"}))
+	assert.Equal(t, "<ul><li>d</li></ul>", w.Body.String())
+
+	ctrl, _ = newController()
+	assert.NotNil(t, ctrl.RenderPartial("list.tpl#missing", nil))
+}
@@ -111,7 +111,7 @@ func (t *Tree) addtree(segments []string, tree *Tree, wildcards []string, reg st
 			reg = strings.Trim(reg+"/"+regexpStr, "/")
 			filterTreeWithPrefix(tree, append(wildcards, params...), reg)
 			tree.prefix = seg
-			t.fixrouters = append(t.fixrouters, tree)
+			t.addFixRouter(tree)
 		}
 		return
 	}
@@ -153,8 +153,8 @@ func (t *Tree) addtree(segments []string, tree *Tree, wildcards []string, reg st
 	} else {
 		subTree := NewTree()
 		subTree.prefix = seg
-		t.fixrouters = append(t.fixrouters, subTree)
 		subTree.addtree(segments[1:], tree, append(wildcards, params...), reg)
+		t.addFixRouter(subTree)
 	}
 }
 
@@ -197,6 +197,32 @@ func filterTreeWithPrefix(t *Tree, wildcards []string, reg string) {
 	}
 }
 
+// addFixRouter appends the sub tree,
+// but the sub tree constrained by host is matched before the others, see hostRank
+func (t *Tree) addFixRouter(tree *Tree) {
+	rank := tree.hostRank()
+	if rank == 0 {
+		t.fixrouters = append(t.fixrouters, tree)
+		return
+	}
+	i := 0
+	for i < len(t.fixrouters) && t.fixrouters[i].hostRank() > rank {
+		i++
+	}
+	t.fixrouters = append(t.fixrouters[:i], append([]*Tree{tree}, t.fixrouters[i:]...)...)
+}
+
+// addLeaf prepends the leaf so the later one has higher priority,
+// but the leaves constrained by host are matched before the others, see hostRank
+func (t *Tree) addLeaf(leaf *leafInfo) {
+	rank := leaf.hostRank()
+	i := 0
+	for i < len(t.leaves) && t.leaves[i].hostRank() > rank {
+		i++
+	}
+	t.leaves = append(t.leaves[:i], append([]*leafInfo{leaf}, t.leaves[i:]...)...)
+}
+
 // AddRouter call addseg function
 func (t *Tree) AddRouter(pattern string, runObject interface{}) {
 	t.addseg(splitPath(pattern), runObject, nil, "")
@@ -207,9 +233,9 @@ func (t *Tree) AddRouter(pattern string, runObject interface{}) {
 func (t *Tree) addseg(segments []string, route interface{}, wildcards []string, reg string) {
 	if len(segments) == 0 {
 		if reg != "" {
-			t.leaves = append([]*leafInfo{{runObject: route, wildcards: wildcards, regexps: regexp.MustCompile("^" + reg + "$")}}, t.leaves...)
+			t.addLeaf(&leafInfo{runObject: route, wildcards: wildcards, regexps: regexp.MustCompile("^" + reg + "$")})
 		} else {
-			t.leaves = append([]*leafInfo{{runObject: route, wildcards: wildcards}}, t.leaves...)
+			t.addLeaf(&leafInfo{runObject: route, wildcards: wildcards})
 		}
 	} else {
 		seg := segments[0]
@@ -395,6 +421,22 @@ type leafInfo struct {
 }
 
 func (leaf *leafInfo) match(treePattern string, wildcardValues []string, ctx *context.Context) (ok bool) {
+	var hostParams map[string]string
+	if c, ok := leaf.runObject.(*ControllerInfo); ok && c.host != nil {
+		if hostParams, ok = c.host.match(ctx); !ok {
+			return false
+		}
+	}
+	if !leaf.matchPath(treePattern, wildcardValues, ctx) {
+		return false
+	}
+	for k, v := range hostParams {
+		ctx.Input.SetParam(k, v)
+	}
+	return true
+}
+
+func (leaf *leafInfo) matchPath(treePattern string, wildcardValues []string, ctx *context.Context) (ok bool) {
 	// fmt.Println("Leaf:", wildcardValues, leaf.wildcards, leaf.regexps)
 	if leaf.regexps == nil {
 		if len(wildcardValues) == 0 && len(leaf.wildcards) == 0 { // static path
@@ -0,0 +1,82 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// ListenUnix listens on the unix socket path with the permission, 0660 for example.
+// The stale socket file left by the crashed process is removed,
+// but it returns error if another process is listening on the path or the file isn't a socket.
+// The socket file is removed when the listener is closed.
+// The permission is set by chmod after binding, the umask of process isn't changed
+func ListenUnix(path string, perm os.FileMode) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(path, perm); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("the file %s exists and it isn't a unix socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("another process is listening on the unix socket %s", path)
+	}
+	logs.Info("remove the stale unix socket %s", path)
+	return os.Remove(path)
+}
+
+// parseSocketPerm parses the octal permission like 0660
+func parseSocketPerm(perm string) (os.FileMode, error) {
+	p, err := strconv.ParseUint(perm, 8, 32)
+	if err != nil || p > 0o777 {
+		return 0, fmt.Errorf("invalid unix socket permission %q", perm)
+	}
+	return os.FileMode(p), nil
+}
+
+func (app *HttpServer) listenUnix() (net.Listener, error) {
+	perm, err := parseSocketPerm(app.Cfg.Listen.UnixSocketPerm)
+	if err != nil {
+		return nil, err
+	}
+	return ListenUnix(app.Cfg.Listen.UnixSocket, perm)
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenUnix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the permission of unix socket isn't supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "app.sock")
+	perm, err := parseSocketPerm("0660")
+	assert.Nil(t, err)
+
+	l, err := ListenUnix(path, perm)
+	assert.Nil(t, err)
+	fi, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o660), fi.Mode().Perm())
+
+	// the socket is in use
+	_, err = ListenUnix(path, perm)
+	assert.NotNil(t, err)
+
+	// leave the stale socket file like a crashed process
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	assert.Nil(t, l.Close())
+	l, err = ListenUnix(path, perm)
+	assert.Nil(t, err)
+	assert.Nil(t, l.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// not a socket
+	assert.Nil(t, os.WriteFile(path, []byte("data"), 0o600))
+	_, err = ListenUnix(path, perm)
+	assert.NotNil(t, err)
+
+	_, err = parseSocketPerm("999")
+	assert.NotNil(t, err)
+}
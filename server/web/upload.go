@@ -0,0 +1,279 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	beecontext "github.com/beego/beego/v2/server/web/context"
+)
+
+// the default max size of the form values in streamed upload
+const defaultUploadMaxValueSize = 1 << 20
+
+// ErrUploadPartTooLarge is returned when a part of streamed upload exceeds the limit
+var ErrUploadPartTooLarge = errors.New("upload part too large")
+
+// UploadFile is the file part streamed to UploadStorage
+type UploadFile struct {
+	// FieldName is the form field name of the part
+	FieldName string
+	// FileName is the base name of the file sent by client
+	FileName string
+	// ContentType is sniffed from the first 512 bytes of the file by http.DetectContentType
+	ContentType string
+	// DeclaredContentType is the Content-Type of the part sent by client, it can't be trusted
+	DeclaredContentType string
+	// Size is the bytes stored
+	Size int64
+	// Location is returned by UploadStorage, the file path or the object key for example
+	Location string
+}
+
+// UploadStorage stores the streamed files, the implementation should read r until EOF
+// and must not keep r after returning. If r returns an error, the partial file should be removed
+type UploadStorage interface {
+	Save(ctx context.Context, file *UploadFile, r io.Reader) (location string, err error)
+}
+
+// ObjectPutter is implemented by the clients of object storage, S3 and GCS for example.
+// The implementation usually wraps the upload manager of S3 or the object writer of GCS
+type ObjectPutter interface {
+	PutObject(ctx context.Context, key string, r io.Reader, contentType string) error
+}
+
+var (
+	uploadStorages     = make(map[string]UploadStorage)
+	uploadStoragesLock sync.RWMutex
+)
+
+// RegisterUploadStorage registers the storage by name, which can be used by Controller.StreamUpload
+func RegisterUploadStorage(name string, storage UploadStorage) {
+	uploadStoragesLock.Lock()
+	defer uploadStoragesLock.Unlock()
+	uploadStorages[name] = storage
+}
+
+// GetUploadStorage returns the registered storage
+func GetUploadStorage(name string) (UploadStorage, bool) {
+	uploadStoragesLock.RLock()
+	defer uploadStoragesLock.RUnlock()
+	s, ok := uploadStorages[name]
+	return s, ok
+}
+
+type localUploadStorage struct {
+	dir string
+}
+
+// NewLocalUploadStorage stores the files in dir, each file is named by a random prefix and its base name
+func NewLocalUploadStorage(dir string) UploadStorage {
+	return &localUploadStorage{dir: dir}
+}
+
+func (s *localUploadStorage) Save(_ context.Context, file *UploadFile, r io.Reader) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(s.dir, "*-"+file.FileName)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+type objectUploadStorage struct {
+	putter  ObjectPutter
+	keyFunc func(file *UploadFile) string
+}
+
+// NewObjectUploadStorage stores the files by the object storage client, keyFunc returns the object key of file
+func NewObjectUploadStorage(putter ObjectPutter, keyFunc func(file *UploadFile) string) UploadStorage {
+	return &objectUploadStorage{putter: putter, keyFunc: keyFunc}
+}
+
+func (s *objectUploadStorage) Save(ctx context.Context, file *UploadFile, r io.Reader) (string, error) {
+	key := s.keyFunc(file)
+	if err := s.putter.PutObject(ctx, key, r, file.ContentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// UploadOptions controls the streamed upload
+type UploadOptions struct {
+	// MaxPartSize is the max bytes of each file, 0 means no limit except MaxUploadSize
+	MaxPartSize int64
+	// MaxValueSize is the max bytes of each form value, default 1MB
+	MaxValueSize int64
+	// Progress is called after each read of file with the bytes read so far
+	Progress func(file *UploadFile, written int64)
+}
+
+// UploadResult is the files and form values of streamed upload
+type UploadResult struct {
+	Files  []*UploadFile
+	Values url.Values
+}
+
+// StreamUpload reads the multipart body part by part and streams the files to storage
+// without buffering the whole files in memory or temp files.
+// The router must be registered with WithRouterStreamUpload, otherwise the body has been parsed.
+// The form values are also added to the request form, so they can be got by GetString
+func StreamUpload(ctx *beecontext.Context, storage UploadStorage, opts UploadOptions) (*UploadResult, error) {
+	r := ctx.Request
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxValueSize <= 0 {
+		opts.MaxValueSize = defaultUploadMaxValueSize
+	}
+	res := &UploadResult{Values: make(url.Values)}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, err
+		}
+		name := part.FormName()
+		if name == "" {
+			_ = part.Close()
+			continue
+		}
+		if part.FileName() == "" {
+			value, err := readUploadValue(part, opts.MaxValueSize)
+			_ = part.Close()
+			if err != nil {
+				return res, fmt.Errorf("form value %s: %w", name, err)
+			}
+			res.Values.Add(name, value)
+			r.Form.Add(name, value)
+			continue
+		}
+		file, err := saveUploadPart(r.Context(), part, storage, opts)
+		_ = part.Close()
+		if err != nil {
+			return res, err
+		}
+		res.Files = append(res.Files, file)
+	}
+	return res, nil
+}
+
+func readUploadValue(r io.Reader, limit int64) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > limit {
+		return "", ErrUploadPartTooLarge
+	}
+	return string(data), nil
+}
+
+func saveUploadPart(ctx context.Context, part *multipart.Part, storage UploadStorage, opts UploadOptions) (*UploadFile, error) {
+	file := &UploadFile{
+		FieldName:           part.FormName(),
+		FileName:            filepath.Base(filepath.Clean("/" + part.FileName())),
+		DeclaredContentType: part.Header.Get("Content-Type"),
+	}
+	br := bufio.NewReaderSize(part, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	file.ContentType = http.DetectContentType(head)
+
+	ur := &uploadReader{r: br, file: file, limit: opts.MaxPartSize, progress: opts.Progress}
+	location, err := storage.Save(ctx, file, ur)
+	if ur.err != nil {
+		err = ur.err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("upload file %s: %w", file.FileName, err)
+	}
+	file.Location = location
+	return file, nil
+}
+
+// uploadReader counts the bytes, reports the progress and enforces the limit of part
+type uploadReader struct {
+	r        io.Reader
+	file     *UploadFile
+	limit    int64
+	progress func(file *UploadFile, written int64)
+	err      error
+}
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	n, err := u.r.Read(p)
+	u.file.Size += int64(n)
+	if u.limit > 0 && u.file.Size > u.limit {
+		u.err = ErrUploadPartTooLarge
+		return 0, u.err
+	}
+	if n > 0 && u.progress != nil {
+		u.progress(u.file, u.file.Size)
+	}
+	if err != nil && err != io.EOF {
+		u.err = err
+	}
+	return n, err
+}
+
+// StreamUpload streams the files of request to the storage registered by RegisterUploadStorage
+// usage:
+//
+//	web.RegisterUploadStorage("local", web.NewLocalUploadStorage("uploads"))
+//	web.RouterWithOpts("/upload", &UploadController{}, web.WithRouterStreamUpload())
+//
+//	func (c *UploadController) Post() {
+//		res, err := c.StreamUpload("local", web.UploadOptions{MaxPartSize: 10 << 20})
+//		...
+//	}
+func (c *Controller) StreamUpload(storageName string, opts UploadOptions) (*UploadResult, error) {
+	storage, ok := GetUploadStorage(storageName)
+	if !ok {
+		return nil, fmt.Errorf("unknown upload storage %q", storageName)
+	}
+	return StreamUpload(c.Ctx, storage, opts)
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryObjectPutter struct {
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func (m *memoryObjectPutter) PutObject(_ context.Context, key string, r io.Reader, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.objects[key] = data
+	m.contentTypes[key] = contentType
+	return nil
+}
+
+type uploadController struct {
+	Controller
+}
+
+func (c *uploadController) Post() {
+	var progress []int64
+	res, err := c.StreamUpload(c.GetString("storage"), UploadOptions{
+		MaxPartSize: 1024,
+		Progress: func(file *UploadFile, written int64) {
+			progress = append(progress, written)
+		},
+	})
+	if errors.Is(err, ErrUploadPartTooLarge) {
+		c.Ctx.Output.SetStatus(http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		c.Ctx.Output.SetStatus(http.StatusBadRequest)
+		return
+	}
+	c.Data["json"] = map[string]interface{}{
+		"files":    res.Files,
+		"title":    c.GetString("title"),
+		"progress": len(progress) > 0,
+	}
+	_ = c.ServeJSON()
+}
+
+func newUploadRequest(t *testing.T, url string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	require.Nil(t, w.WriteField("title", "avatar"))
+	fw, err := w.CreateFormFile("file", "../../a.png")
+	require.Nil(t, err)
+	_, err = fw.Write(content)
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+	r := httptest.NewRequest(http.MethodPost, url, body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+func TestStreamUpload(t *testing.T) {
+	dir := t.TempDir()
+	putter := &memoryObjectPutter{objects: map[string][]byte{}, contentTypes: map[string]string{}}
+	RegisterUploadStorage("test-local", NewLocalUploadStorage(dir))
+	RegisterUploadStorage("test-object", NewObjectUploadStorage(putter, func(file *UploadFile) string {
+		return "avatars/" + file.FileName
+	}))
+
+	cfg := *BConfig
+	app := NewHttpServerWithCfg(&cfg)
+	app.RouterWithOpts("/upload", &uploadController{}, WithRouterStreamUpload())
+	png := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 100)...)
+
+	w := httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, newUploadRequest(t, "/upload?storage=test-local", png))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res struct {
+		Files    []*UploadFile
+		Title    string
+		Progress bool
+	}
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "avatar", res.Title)
+	assert.True(t, res.Progress)
+	require.Len(t, res.Files, 1)
+	assert.Equal(t, "a.png", res.Files[0].FileName)
+	assert.Equal(t, "image/png", res.Files[0].ContentType)
+	assert.Equal(t, "application/octet-stream", res.Files[0].DeclaredContentType)
+	assert.Equal(t, int64(len(png)), res.Files[0].Size)
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, strings.HasSuffix(entries[0].Name(), "-a.png"))
+
+	w = httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, newUploadRequest(t, "/upload?storage=test-object", png))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, png, putter.objects["avatars/a.png"])
+	assert.Equal(t, "image/png", putter.contentTypes["avatars/a.png"])
+
+	w = httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, newUploadRequest(t, "/upload?storage=test-local", bytes.Repeat([]byte("a"), 2048)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	entries, err = os.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Len(t, entries, 1)
+
+	w = httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, newUploadRequest(t, "/upload?storage=unknown", png))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
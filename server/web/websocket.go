@@ -0,0 +1,277 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// DefaultWebSocketPingInterval is used when WebSocketController.PingInterval is 0
+var DefaultWebSocketPingInterval = 30 * time.Second
+
+// the closing handshake should be done in this duration
+const webSocketCloseTimeout = time.Second
+
+// webSocketRegistry keeps the alive connections served by a ControllerRegister,
+// they will be closed when its server is shutting down
+type webSocketRegistry struct {
+	conns sync.Map
+}
+
+// closeAll sends close message to all the connections and then closes them
+func (r *webSocketRegistry) closeAll() {
+	r.conns.Range(func(key, value interface{}) bool {
+		_ = key.(*WebSocketConn).Close(websocket.CloseGoingAway, "server shutting down")
+		return true
+	})
+}
+
+// webSocketTracker is implemented by WebSocketController,
+// the router passes its registry to the controller before executing it
+type webSocketTracker interface {
+	setWebSocketRegistry(registry *webSocketRegistry)
+}
+
+// WebSocketHandler handles the events of WebSocket connection.
+// The controller which embeds WebSocketController could override those methods.
+type WebSocketHandler interface {
+	// OnOpen is invoked after the connection was upgraded
+	OnOpen(conn *WebSocketConn)
+	// OnMessage is invoked when receiving a text or binary message.
+	// The messages from one connection are handled one by one
+	OnMessage(conn *WebSocketConn, messageType int, data []byte)
+	// OnClose is invoked after the connection was closed.
+	// err is nil if the connection was closed normally
+	OnClose(conn *WebSocketConn, err error)
+}
+
+// WebSocketController upgrades GET requests to WebSocket connections,
+// and dispatches the events to the methods of WebSocketHandler.
+// usage:
+//
+//	type ChatController struct {
+//		web.WebSocketController
+//	}
+//
+//	func (c *ChatController) OnMessage(conn *web.WebSocketConn, messageType int, data []byte) {
+//		_ = conn.WriteMessage(messageType, data)
+//	}
+//
+//	web.Router("/chat", &ChatController{})
+type WebSocketController struct {
+	Controller
+	// Upgrader is used to upgrade the connection, context.DefaultWebSocketUpgrader will be used if it's nil
+	Upgrader *websocket.Upgrader
+	// PingInterval is the interval of sending ping message.
+	// If client doesn't reply pong message in 2 * PingInterval, the connection will be closed.
+	// DefaultWebSocketPingInterval will be used if it's 0, and negative value disables it
+	PingInterval time.Duration
+	// MaxMessageSize is the max size of message read from client, 0 means no limit
+	MaxMessageSize int64
+	// the registry of the router which executes this controller
+	webSockets *webSocketRegistry
+}
+
+// Get upgrades the connection and serves it until it was closed
+func (c *WebSocketController) Get() {
+	ws, err := c.Ctx.UpgradeWebSocket(c.Upgrader, nil)
+	if err != nil {
+		logs.Error("upgrade websocket failed: %v", err)
+		return
+	}
+	handler, ok := c.AppController.(WebSocketHandler)
+	if !ok {
+		handler = c
+	}
+	if c.MaxMessageSize > 0 {
+		ws.SetReadLimit(c.MaxMessageSize)
+	}
+	pingInterval := c.PingInterval
+	if pingInterval == 0 {
+		pingInterval = DefaultWebSocketPingInterval
+	}
+	newWebSocketConn(ws, c.Ctx).serve(handler, pingInterval, c.webSockets)
+}
+
+func (c *WebSocketController) setWebSocketRegistry(registry *webSocketRegistry) {
+	c.webSockets = registry
+}
+
+// OnOpen does nothing by default
+func (c *WebSocketController) OnOpen(conn *WebSocketConn) {}
+
+// OnMessage does nothing by default
+func (c *WebSocketController) OnMessage(conn *WebSocketConn, messageType int, data []byte) {}
+
+// OnClose does nothing by default
+func (c *WebSocketController) OnClose(conn *WebSocketConn, err error) {}
+
+// WebSocketConn is the WebSocket connection used by WebSocketController.
+// It's safe to write messages in different goroutines.
+type WebSocketConn struct {
+	conn      *websocket.Conn
+	ctx       *context.Context
+	writeLock sync.Mutex
+	closing   int32
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newWebSocketConn(conn *websocket.Conn, ctx *context.Context) *WebSocketConn {
+	return &WebSocketConn{
+		conn: conn,
+		ctx:  ctx,
+		done: make(chan struct{}),
+	}
+}
+
+// Conn returns the underlying connection.
+// Be careful that you should not read from it, and you should not write to it concurrently.
+func (c *WebSocketConn) Conn() *websocket.Conn {
+	return c.conn
+}
+
+// Context returns the context of the upgrading request.
+// It's only valid before OnClose returns
+func (c *WebSocketConn) Context() *context.Context {
+	return c.ctx
+}
+
+// Done returns a channel which is closed when the connection is closed
+func (c *WebSocketConn) Done() <-chan struct{} {
+	return c.done
+}
+
+// WriteMessage writes a message with the given message type and data
+func (c *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// WriteText writes a text message
+func (c *WebSocketConn) WriteText(text string) error {
+	return c.WriteMessage(websocket.TextMessage, []byte(text))
+}
+
+// WriteJSON writes the JSON encoding of v as a text message
+func (c *WebSocketConn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(websocket.TextMessage, data)
+}
+
+// Close sends a close message with code and text, the connection will be closed
+// after client replying the close message or in one second.
+// The code is defined by websocket package, for example, websocket.CloseNormalClosure
+func (c *WebSocketConn) Close(code int, text string) error {
+	if !atomic.CompareAndSwapInt32(&c.closing, 0, 1) {
+		return nil
+	}
+	deadline := time.Now().Add(webSocketCloseTimeout)
+	// WriteControl can be invoked concurrently with other write methods
+	err := c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+	if err != nil {
+		c.close()
+		return err
+	}
+	return c.conn.SetReadDeadline(deadline)
+}
+
+func (c *WebSocketConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+func (c *WebSocketConn) serve(handler WebSocketHandler, pingInterval time.Duration, registry *webSocketRegistry) {
+	if registry != nil {
+		registry.conns.Store(c, struct{}{})
+		defer registry.conns.Delete(c)
+	}
+
+	if pingInterval > 0 {
+		pongWait := 2 * pingInterval
+		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.conn.SetPongHandler(func(string) error {
+			if atomic.LoadInt32(&c.closing) == 1 {
+				return nil
+			}
+			return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		go c.keepalive(pingInterval)
+	}
+
+	handler.OnOpen(c)
+	var err error
+	for {
+		var (
+			messageType int
+			data        []byte
+		)
+		messageType, data, err = c.conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		handler.OnMessage(c, messageType, data)
+	}
+	c.close()
+	if atomic.LoadInt32(&c.closing) == 1 {
+		// closed by server, the error is caused by closing
+		err = nil
+	}
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		err = nil
+	}
+	handler.OnClose(c, err)
+}
+
+func (c *WebSocketConn) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// CloseWebSockets sends close message to all WebSocket connections served by WebSocketController of BeeApp
+// and then closes them. It will be invoked when BeeApp is shutting down.
+func CloseWebSockets() {
+	BeeApp.Handlers.CloseWebSockets()
+}
+
+// CloseWebSockets sends close message to all WebSocket connections served by WebSocketController of this router
+// and then closes them. It will be invoked when the server of this router is shutting down.
+func (p *ControllerRegister) CloseWebSockets() {
+	p.webSockets.closeAll()
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoWebSocketController struct {
+	WebSocketController
+	Closed chan error
+}
+
+func (c *echoWebSocketController) OnOpen(conn *WebSocketConn) {
+	_ = conn.WriteText("hello " + conn.Context().Input.Query("name"))
+}
+
+func (c *echoWebSocketController) OnMessage(conn *WebSocketConn, messageType int, data []byte) {
+	_ = conn.WriteMessage(messageType, data)
+}
+
+func (c *echoWebSocketController) OnClose(conn *WebSocketConn, err error) {
+	c.Closed <- err
+}
+
+func TestWebSocketController(t *testing.T) {
+	closed := make(chan error, 1)
+	app := NewHttpServerWithCfg(newBConfig())
+	app.Handlers.Add("/ws", &echoWebSocketController{
+		WebSocketController: WebSocketController{PingInterval: 100 * time.Millisecond},
+		Closed:              closed,
+	})
+	app.Handlers.Init()
+	srv := httptest.NewServer(app.Handlers)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?name=beego"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Nil(t, err)
+
+	_, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "hello beego", string(data))
+
+	// the default ping handler of client replies pong when reading,
+	// so the connection is kept alive longer than 2 * PingInterval
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+		_, data, err = conn.ReadMessage()
+		assert.Nil(t, err)
+		assert.Equal(t, "ping", string(data))
+		time.Sleep(60 * time.Millisecond)
+	}
+
+	app.Handlers.CloseWebSockets()
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "%v", err)
+	select {
+	case err = <-closed:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("OnClose is not invoked")
+	}
+}
+
+func TestWebSocketControllerCloseByServer(t *testing.T) {
+	dial := func() (*HttpServer, *websocket.Conn, func()) {
+		app := NewHttpServerWithCfg(newBConfig())
+		app.Handlers.Add("/ws", &echoWebSocketController{Closed: make(chan error, 1)})
+		app.Handlers.Init()
+		srv := httptest.NewServer(app.Handlers)
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+		assert.Nil(t, err)
+		_, _, err = conn.ReadMessage()
+		assert.Nil(t, err)
+		return app, conn, srv.Close
+	}
+	app1, conn1, close1 := dial()
+	defer close1()
+	_, conn2, close2 := dial()
+	defer close2()
+
+	// only the connections of app1 are closed
+	app1.Handlers.CloseWebSockets()
+	_, _, err := conn1.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "%v", err)
+	assert.Nil(t, conn2.WriteMessage(websocket.TextMessage, []byte("ping")))
+	_, data, err := conn2.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "ping", string(data))
+}
+
+func TestWebSocketControllerNotUpgrade(t *testing.T) {
+	app := NewHttpServerWithCfg(newBConfig())
+	app.Handlers.Add("/ws", &echoWebSocketController{})
+	app.Handlers.Init()
+	w := httptest.NewRecorder()
+	app.Handlers.ServeHTTP(w, httptest.NewRequest("GET", "/ws", nil))
+	assert.Equal(t, 400, w.Code)
+}